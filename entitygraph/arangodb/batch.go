@@ -0,0 +1,37 @@
+// batch.go contains Batch for the Backend. Every operation runs inside one
+// stream transaction with write access to all entity collections, the edge
+// collection, the history collection and the outbox; the single-entity
+// methods join it rather than begin their own (see withTransaction).
+package arangodb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+)
+
+// Batch applies ops for agencyID in one stream transaction (see
+// [entitygraph.ApplyBatch]), committed only when every operation succeeds.
+func (b *Backend) Batch(ctx context.Context, agencyID string, ops []entitygraph.BatchOp) (entitygraph.BatchResult, error) {
+	if err := entitygraph.ValidateBatch(ops); err != nil {
+		return entitygraph.BatchResult{}, fmt.Errorf("Batch: %w", err)
+	}
+	write := []string{b.relCollectionName, b.historyName}
+	if b.outbox != nil {
+		write = append(write, b.outboxName)
+	}
+	for _, col := range b.allEntityCollections() {
+		write = append(write, col.Name())
+	}
+	var result entitygraph.BatchResult
+	err := b.withTransaction(ctx, write, func(ctx context.Context) error {
+		var err error
+		result, err = entitygraph.ApplyBatch(ctx, b, agencyID, ops)
+		return err
+	})
+	if err != nil {
+		return entitygraph.BatchResult{}, fmt.Errorf("Batch: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,197 @@
+package arangodb_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	driver "github.com/arangodb/go-driver"
+	"github.com/google/uuid"
+
+	"github.com/aosanya/CodeValdSharedLib/arangoutil"
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+	"github.com/aosanya/CodeValdSharedLib/entitygraph/arangodb"
+	"github.com/aosanya/CodeValdSharedLib/entitygraph/conformance"
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+// The ArangoDB conformance run needs a live server and is skipped unless
+// ARANGO_TEST_ENDPOINT is set, e.g.
+//
+//	ARANGO_TEST_ENDPOINT=http://localhost:8529 ARANGO_TEST_PASSWORD=… go test ./entitygraph/arangodb/
+//
+// Each sub-test gets its own uniquely named collections and graph, which are
+// removed on cleanup.
+
+func TestConformance_DataManager(t *testing.T) {
+	db := testDB(t)
+	conformance.RunDataManagerSuite(t, func(t *testing.T, s types.Schema) entitygraph.DataManager {
+		return newTestBackend(t, db, s)
+	})
+}
+
+func TestConformance_SchemaManager(t *testing.T) {
+	db := testDB(t)
+	conformance.RunSchemaManagerSuite(t, func(t *testing.T) entitygraph.SchemaManager {
+		return newTestBackend(t, db, types.Schema{})
+	})
+}
+
+func testDB(t *testing.T) driver.Database {
+	t.Helper()
+	endpoint := os.Getenv("ARANGO_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("ARANGO_TEST_ENDPOINT not set; skipping ArangoDB conformance")
+	}
+	database := os.Getenv("ARANGO_TEST_DATABASE")
+	if database == "" {
+		database = "codevald_conformance"
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	db, err := arangoutil.Connect(ctx, arangoutil.Config{
+		Endpoint: endpoint,
+		Username: os.Getenv("ARANGO_TEST_USERNAME"),
+		Password: os.Getenv("ARANGO_TEST_PASSWORD"),
+		Database: database,
+	})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	return db
+}
+
+// newTestBackend builds a Backend over freshly named collections and
+// registers their removal with t.Cleanup. opts adjust the Config first.
+func newTestBackend(t *testing.T, db driver.Database, s types.Schema, opts ...func(prefix string, cfg *arangodb.Config)) *arangodb.Backend {
+	t.Helper()
+	prefix := "c" + strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+	cfg := arangodb.Config{
+		Schema:              s,
+		EntityCollection:    prefix + "_entities",
+		RelCollection:       prefix + "_relationships",
+		SchemasDraftCol:     prefix + "_schemas_draft",
+		SchemasPublishedCol: prefix + "_schemas_published",
+		GraphName:           prefix + "_graph",
+		SearchWaitForSync:   true,
+	}
+	for _, opt := range opts {
+		opt(prefix, &cfg)
+	}
+	b, err := arangodb.NewBackendFromDB(db, cfg)
+	if err != nil {
+		t.Fatalf("NewBackendFromDB: %v", err)
+	}
+	t.Cleanup(func() { dropPrefixed(t, db, prefix) })
+	return b
+}
+
+// dropPrefixed removes the graph and every view and collection whose name
+// starts with prefix.
+func dropPrefixed(t *testing.T, db driver.Database, prefix string) {
+	ctx := context.Background()
+	if g, err := db.Graph(ctx, prefix+"_graph"); err == nil {
+		_ = g.Remove(ctx)
+	}
+	if views, err := db.Views(ctx); err == nil {
+		for _, v := range views {
+			if strings.HasPrefix(v.Name(), prefix) {
+				if err := v.Remove(ctx); err != nil {
+					t.Logf("cleanup %s: remove view: %v", v.Name(), err)
+				}
+			}
+		}
+	}
+	cols, err := db.Collections(ctx)
+	if err != nil {
+		t.Logf("cleanup %s: list collections: %v", prefix, err)
+		return
+	}
+	for _, c := range cols {
+		if strings.HasPrefix(c.Name(), prefix) {
+			if err := c.Remove(ctx); err != nil {
+				t.Logf("cleanup %s: remove: %v", c.Name(), err)
+			}
+		}
+	}
+}
+
+// TestActivate_ReconcilesStorage activates a version that adds a
+// StorageCollection, a UniqueKey and an Indexed property, and checks that the
+// collection and its indexes exist once Activate returns, and that the
+// running backend writes the new type to that collection, lists it from
+// there, and enforces its UniqueKey. Like the conformance run it needs
+// ARANGO_TEST_ENDPOINT.
+func TestActivate_ReconcilesStorage(t *testing.T) {
+	db := testDB(t)
+	var colName string
+	b := newTestBackend(t, db, types.Schema{}, func(prefix string, _ *arangodb.Config) {
+		colName = prefix + "_goals"
+	})
+	ctx := context.Background()
+	s := types.Schema{
+		AgencyID: "agency-a",
+		Tag:      "v1",
+		Types: []types.TypeDefinition{{
+			Name:              "Goal",
+			StorageCollection: colName,
+			UniqueKey:         []string{"code"},
+			Properties: []types.PropertyDefinition{
+				{Name: "code", Type: types.PropertyTypeString},
+				{Name: "rank", Type: types.PropertyTypeInteger, Indexed: true},
+			},
+		}},
+	}
+	if err := b.SetSchema(ctx, s); err != nil {
+		t.Fatalf("SetSchema: %v", err)
+	}
+	if err := b.Publish(ctx, s.AgencyID); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if err := b.Activate(ctx, s.AgencyID, 1); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+
+	col, err := db.Collection(ctx, colName)
+	if err != nil {
+		t.Fatalf("Collection(%s): %v", colName, err)
+	}
+	idxs, err := col.Indexes(ctx)
+	if err != nil {
+		t.Fatalf("Indexes: %v", err)
+	}
+	have := make(map[string]bool, len(idxs))
+	for _, idx := range idxs {
+		have[idx.UserName()] = true
+	}
+	for _, want := range []string{"entitygraph_agency_type", "entitygraph_unique_key", "entitygraph_prop_rank"} {
+		if !have[want] {
+			t.Errorf("index %s missing on %s", want, colName)
+		}
+	}
+
+	goal, err := b.CreateEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: s.AgencyID, TypeID: "Goal", Properties: map[string]any{"code": "G1", "rank": 1},
+	})
+	if err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+	if ok, err := col.DocumentExists(ctx, goal.ID); err != nil || !ok {
+		t.Errorf("Goal %s in %s: exists=%v, err=%v; want it stored there", goal.ID, colName, ok, err)
+	}
+	got, err := b.ListEntities(ctx, entitygraph.EntityFilter{AgencyID: s.AgencyID, TypeID: "Goal"})
+	if err != nil {
+		t.Fatalf("ListEntities: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != goal.ID {
+		t.Errorf("ListEntities(Goal) = %+v, want [%s]", got, goal.ID)
+	}
+	if _, err := b.CreateEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: s.AgencyID, TypeID: "Goal", Properties: map[string]any{"code": "G1"},
+	}); !errors.Is(err, entitygraph.ErrEntityAlreadyExists) {
+		t.Errorf("CreateEntity(duplicate code): got %v, want ErrEntityAlreadyExists", err)
+	}
+}
@@ -1,10 +1,14 @@
 // entities.go contains CreateEntity, GetEntity, UpdateEntity, DeleteEntity,
-// ListEntities, and UpsertEntity for the Backend.
+// ListEntities, ListEntitiesPage, UpsertEntity, RestoreEntity, PurgeEntity,
+// and PurgeDeletedBefore for the Backend.
 package arangodb
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 	"time"
 
@@ -12,11 +16,13 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+	"github.com/aosanya/CodeValdSharedLib/types"
 )
 
 // entityDoc is the ArangoDB document representation of an [entitygraph.Entity].
 type entityDoc struct {
 	Key        string         `json:"_key,omitempty"`
+	Rev        string         `json:"_rev,omitempty"`
 	TypeID     string         `json:"type_id"`
 	AgencyID   string         `json:"agency_id"`
 	Properties map[string]any `json:"properties"`
@@ -24,11 +30,42 @@ type entityDoc struct {
 	UpdatedAt  time.Time      `json:"updated_at"`
 	Deleted    bool           `json:"deleted"`
 	DeletedAt  *time.Time     `json:"deleted_at,omitempty"`
+	// UniqueKey is the canonical UniqueKey of a live entity, backing the
+	// unique index (see indexes.go). Empty — and absent from the document —
+	// for deleted entities and types without a key.
+	UniqueKey string `json:"unique_key,omitempty"`
 }
 
+// maxWriteAttempts bounds how often UpdateEntity and UpsertEntity re-read and
+// retry a write that lost a race with a concurrent writer.
+const maxWriteAttempts = 10
+
 // CreateEntity creates a new entity document in the appropriate collection.
-// Returns entitygraph.ErrEntityAlreadyExists if a document with the same key already exists.
+//
+// When req.Relationships is non-empty, the entity document and every inline
+// edge are written in a single stream transaction: all targets are resolved
+// and validated first, and if any edge write fails the entity is rolled back.
+//
+// Returns entitygraph.ErrEntityAlreadyExists if a document with the same key
+// already exists or the unique index finds a live entity of the type holding
+// the same UniqueKey values.
+// Returns a *entitygraph.ValidationError if the properties do not conform to
+// the type's PropertyDefinitions.
+// Returns entitygraph.ErrInvalidRelationship or
+// entitygraph.ErrRelationshipCardinalityViolation if an inline edge is not
+// permitted, and entitygraph.ErrRequiredRelationshipViolation if a Required
+// relationship is not supplied.
 func (b *Backend) CreateEntity(ctx context.Context, req entitygraph.CreateEntityRequest) (entitygraph.Entity, error) {
+	if err := b.validateProperties(req.TypeID, req.Properties, entitygraph.ValidateCreate); err != nil {
+		return entitygraph.Entity{}, fmt.Errorf("CreateEntity: %w", err)
+	}
+	if err := b.validateRequiredRelationships(req); err != nil {
+		return entitygraph.Entity{}, fmt.Errorf("CreateEntity: %w", err)
+	}
+	edges, err := b.inlineEdges(ctx, req)
+	if err != nil {
+		return entitygraph.Entity{}, fmt.Errorf("CreateEntity: %w", err)
+	}
 	now := time.Now().UTC()
 	id := uuid.NewString()
 	doc := entityDoc{
@@ -42,16 +79,57 @@ func (b *Backend) CreateEntity(ctx context.Context, req entitygraph.CreateEntity
 	if doc.Properties == nil {
 		doc.Properties = make(map[string]any)
 	}
+	doc.UniqueKey = b.uniqueKey(req.TypeID, doc.Properties)
 	col := b.collectionFor(req.TypeID)
-	if _, err := col.CreateDocument(ctx, doc); err != nil {
-		if driver.IsConflict(err) {
-			return entitygraph.Entity{}, fmt.Errorf("CreateEntity: %w", entitygraph.ErrEntityAlreadyExists)
-		}
+	rev, err := b.insertEntity(ctx, col, doc, edges)
+	if err != nil {
 		return entitygraph.Entity{}, fmt.Errorf("CreateEntity: %w", err)
 	}
+	doc.Rev = rev
 	return toEntity(doc, id), nil
 }
 
+// insertEntity writes doc to col together with its inline edges and its
+// first history revision, and returns the new document revision. Without
+// edges or history this is a single document insert; otherwise everything
+// happens in one stream transaction so a failed edge leaves no entity behind.
+func (b *Backend) insertEntity(ctx context.Context, col driver.Collection, doc entityDoc, edges []inlineEdge) (string, error) {
+	create := func(ctx context.Context) error {
+		meta, err := col.CreateDocument(ctx, doc)
+		if err != nil {
+			if driver.IsConflict(err) {
+				return entitygraph.ErrEntityAlreadyExists
+			}
+			return err
+		}
+		doc.Rev = meta.Rev
+		if err := b.recordChange(ctx, doc, entitygraph.ChangeCreated, nil); err != nil {
+			return err
+		}
+		return b.writeInlineEdges(ctx, col.Name()+"/"+doc.Key, edges, false)
+	}
+	write := []string{col.Name()}
+	if len(edges) > 0 {
+		write = append(write, b.relCollectionName)
+	}
+	write = append(write, b.changeWrite(doc.TypeID)...)
+	if err := b.withWrites(ctx, write, create); err != nil {
+		return "", err
+	}
+	return doc.Rev, nil
+}
+
+// validateRequiredRelationships runs
+// [entitygraph.ValidateRequiredRelationships] for req.TypeID. Types not
+// declared in the schema have no required relationships.
+func (b *Backend) validateRequiredRelationships(req entitygraph.CreateEntityRequest) error {
+	td, ok := b.typeDefs()[req.TypeID]
+	if !ok {
+		return nil
+	}
+	return entitygraph.ValidateRequiredRelationships(td, req.Relationships)
+}
+
 // GetEntity returns the entity identified by agencyID and entityID.
 // Searches every entity collection derived from the schema. Returns
 // [entitygraph.ErrEntityNotFound] if the entity is absent from all
@@ -74,12 +152,37 @@ func (b *Backend) GetEntity(ctx context.Context, agencyID, entityID string) (ent
 }
 
 // UpdateEntity patches the mutable properties of an entity.
+//
+// The merged document is written with the revision it was read at as an
+// If-Match precondition, so a concurrent write is never overwritten. When
+// req.IfMatch is set a lost race is reported as entitygraph.ErrConflict;
+// otherwise the update is re-read and retried, up to maxWriteAttempts times.
 // Returns entitygraph.ErrImmutableType if the entity's TypeID has Immutable set.
 // Returns entitygraph.ErrEntityNotFound if the entity does not exist.
+// Returns entitygraph.ErrConflict if req.IfMatch is not the current revision.
+// Returns entitygraph.ErrEntityAlreadyExists if the patch would give the
+// entity the UniqueKey values of another live entity.
+// Returns a *entitygraph.ValidationError if the patch does not conform to the
+// type's PropertyDefinitions.
 func (b *Backend) UpdateEntity(
 	ctx context.Context,
 	agencyID, entityID string,
 	req entitygraph.UpdateEntityRequest,
+) (entitygraph.Entity, error) {
+	for attempt := 1; ; attempt++ {
+		e, err := b.updateEntity(ctx, agencyID, entityID, req)
+		if req.IfMatch == "" && errors.Is(err, entitygraph.ErrConflict) && attempt < maxWriteAttempts {
+			continue
+		}
+		return e, err
+	}
+}
+
+// updateEntity makes one read-merge-write attempt of UpdateEntity.
+func (b *Backend) updateEntity(
+	ctx context.Context,
+	agencyID, entityID string,
+	req entitygraph.UpdateEntityRequest,
 ) (entitygraph.Entity, error) {
 	existing, err := b.GetEntity(ctx, agencyID, entityID)
 	if err != nil {
@@ -88,6 +191,14 @@ func (b *Backend) UpdateEntity(
 	if b.isImmutable(existing.TypeID) {
 		return entitygraph.Entity{}, fmt.Errorf("UpdateEntity %s: %w", entityID, entitygraph.ErrImmutableType)
 	}
+	if req.IfMatch != "" && req.IfMatch != existing.Revision {
+		return entitygraph.Entity{}, fmt.Errorf("UpdateEntity %s: revision %s: %w", entityID, req.IfMatch, entitygraph.ErrConflict)
+	}
+	if err := b.validateProperties(existing.TypeID, req.Properties, entitygraph.ValidatePatch); err != nil {
+		return entitygraph.Entity{}, fmt.Errorf("UpdateEntity %s: %w", entityID, err)
+	}
+	before := existing
+	before.Properties = maps.Clone(existing.Properties)
 	if existing.Properties == nil {
 		existing.Properties = make(map[string]any)
 	}
@@ -104,54 +215,309 @@ func (b *Backend) UpdateEntity(
 		UpdatedAt:  existing.UpdatedAt,
 		Deleted:    existing.Deleted,
 		DeletedAt:  existing.DeletedAt,
+		UniqueKey:  b.uniqueKey(existing.TypeID, existing.Properties),
 	}
 	col := b.collectionFor(existing.TypeID)
-	if _, err := col.ReplaceDocument(ctx, entityID, updated); err != nil {
-		if driver.IsNotFound(err) {
-			return entitygraph.Entity{}, fmt.Errorf("UpdateEntity %s: %w", entityID, entitygraph.ErrEntityNotFound)
+	write := append([]string{col.Name()}, b.changeWrite(existing.TypeID)...)
+	err = b.withWrites(ctx, write, func(ctx context.Context) error {
+		meta, err := col.ReplaceDocument(driver.WithRevision(ctx, existing.Revision), entityID, updated)
+		if err != nil {
+			if driver.IsNotFound(err) {
+				return entitygraph.ErrEntityNotFound
+			}
+			if driver.IsPreconditionFailed(err) {
+				return entitygraph.ErrConflict
+			}
+			if driver.IsConflict(err) {
+				return entitygraph.ErrEntityAlreadyExists
+			}
+			return err
 		}
+		updated.Rev = meta.Rev
+		return b.recordChange(ctx, updated, entitygraph.ChangeUpdated, &before)
+	})
+	if err != nil {
 		return entitygraph.Entity{}, fmt.Errorf("UpdateEntity %s: %w", entityID, err)
 	}
 	return toEntity(updated, entityID), nil
 }
 
-// DeleteEntity soft-deletes the entity by setting Deleted=true and recording
-// DeletedAt. The document is never hard-deleted.
+// DeleteEntity soft-deletes the entity by setting Deleted=true, recording
+// DeletedAt and releasing its unique_key, together with every entity its OnDelete cascade policies reach
+// (see deletionSet). Every edge from or to any of them is removed. The
+// documents are never hard-deleted, and all writes happen in one stream
+// transaction; nothing is written when a restrict policy blocks the delete,
+// or when a surviving entity would lose a Required relationship (see
+// checkRequiredInbound). Both checks run inside the transaction, before any
+// write, so an edge created after deletionSet ran still blocks the delete.
 func (b *Backend) DeleteEntity(ctx context.Context, agencyID, entityID string) error {
+	return b.DeleteEntityIfMatch(ctx, agencyID, entityID, "")
+}
+
+// DeleteEntityIfMatch deletes the entity as DeleteEntity does. A non-empty
+// ifMatch is checked against the entity's revision and sent as an If-Match
+// precondition on its write; a mismatch returns entitygraph.ErrConflict.
+func (b *Backend) DeleteEntityIfMatch(ctx context.Context, agencyID, entityID, ifMatch string) error {
 	existing, err := b.GetEntity(ctx, agencyID, entityID)
 	if err != nil {
 		return fmt.Errorf("DeleteEntity %s: %w", entityID, err)
 	}
-	now := time.Now().UTC()
-	updated := entityDoc{
-		Key:        entityID,
-		TypeID:     existing.TypeID,
-		AgencyID:   existing.AgencyID,
-		Properties: existing.Properties,
-		CreatedAt:  existing.CreatedAt,
-		UpdatedAt:  now,
-		Deleted:    true,
-		DeletedAt:  &now,
+	if ifMatch != "" && ifMatch != existing.Revision {
+		return fmt.Errorf("DeleteEntity %s: revision %s: %w", entityID, ifMatch, entitygraph.ErrConflict)
 	}
-	col := b.collectionFor(existing.TypeID)
-	if _, err := col.ReplaceDocument(ctx, entityID, updated); err != nil {
-		if driver.IsNotFound(err) {
-			return fmt.Errorf("DeleteEntity %s: %w", entityID, entitygraph.ErrEntityNotFound)
+	set, err := b.deletionSet(ctx, agencyID, entityID)
+	if err != nil {
+		return fmt.Errorf("DeleteEntity %s: %w", entityID, err)
+	}
+	now := time.Now().UTC()
+	write := []string{b.relCollectionName}
+	handles := make([]string, len(set))
+	typeIDs := make([]string, len(set))
+	for i, d := range set {
+		handles[i] = d.handle
+		typeIDs[i] = d.typeID
+		write = append(write, d.col.Name())
+	}
+	write = append(write, b.changeWrite(typeIDs...)...)
+	err = b.withTransaction(ctx, write, func(ctx context.Context) error {
+		if err := b.checkRestricted(ctx, agencyID, set); err != nil {
+			return err
 		}
+		if err := b.checkRequiredInbound(ctx, agencyID, handles); err != nil {
+			return err
+		}
+		patch := map[string]any{"deleted": true, "deleted_at": now, "updated_at": now, "unique_key": nil}
+		for i, d := range set {
+			wctx := ctx
+			if i == 0 && ifMatch != "" {
+				wctx = driver.WithRevision(ctx, ifMatch)
+			}
+			var deleted entityDoc
+			if b.recordsChange(d.typeID) {
+				wctx = driver.WithReturnNew(wctx, &deleted)
+			}
+			if _, err := d.col.UpdateDocument(wctx, d.key, patch); err != nil {
+				if driver.IsNotFound(err) {
+					return entitygraph.ErrEntityNotFound
+				}
+				if driver.IsPreconditionFailed(err) {
+					return entitygraph.ErrConflict
+				}
+				return err
+			}
+			if err := b.recordChange(ctx, deleted, entitygraph.ChangeDeleted, nil); err != nil {
+				return err
+			}
+		}
+		return b.removeEdgesTouching(ctx, agencyID, handles)
+	})
+	if err != nil {
 		return fmt.Errorf("DeleteEntity %s: %w", entityID, err)
 	}
 	return nil
 }
 
-// ListEntities returns non-deleted entities matching the filter.
+// deletionTarget is an entity DeleteEntity will soft-delete.
+type deletionTarget struct {
+	col      driver.Collection
+	key      string
+	handle   string
+	typeID   string
+	restrict []string // labels of its outbound relationships with a restrict policy
+}
+
+// checkRestricted returns an error wrapping entitygraph.ErrDeleteRestricted
+// when any entity of set has an outbound edge whose OnDelete policy is
+// restrict. DeleteEntity calls it inside its transaction, before writing,
+// so the check sees edges created since deletionSet ran.
+func (b *Backend) checkRestricted(ctx context.Context, agencyID string, set []deletionTarget) error {
+	var targets []map[string]any
+	for _, d := range set {
+		if len(d.restrict) > 0 {
+			targets = append(targets, map[string]any{"handle": d.handle, "names": d.restrict})
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+	q := fmt.Sprintf(
+		`FOR t IN @targets
+		 FOR e IN %s
+		   FILTER e._from == t.handle AND e.agency_id == @agencyID AND e.name IN t.names
+		   LIMIT 1
+		   RETURN e`,
+		b.relCollectionName,
+	)
+	cursor, err := b.db.Query(ctx, q, map[string]interface{}{"targets": targets, "agencyID": agencyID})
+	if err != nil {
+		return fmt.Errorf("check restrict edges: %w", err)
+	}
+	defer cursor.Close()
+	if !cursor.HasMore() {
+		return nil
+	}
+	var e relationshipDoc
+	if _, err := cursor.ReadDocument(ctx, &e); err != nil {
+		return fmt.Errorf("check restrict edges: read: %w", err)
+	}
+	return fmt.Errorf("entity %s has %q edge to %s: %w",
+		stripCollectionPrefix(e.From), e.Name, stripCollectionPrefix(e.To), entitygraph.ErrDeleteRestricted)
+}
+
+// checkRequiredInbound returns an error wrapping
+// entitygraph.ErrRequiredRelationshipViolation when an entity outside handles
+// has an edge into handles whose label its type declares Required, and no
+// other edge of that label to an entity outside handles. DeleteEntity calls it
+// inside its transaction, before removing any edge, so only inbound edges it
+// allows are detached.
+func (b *Backend) checkRequiredInbound(ctx context.Context, agencyID string, handles []string) error {
+	var required []string
+	for typeID, td := range b.typeDefs() {
+		for _, rd := range td.Relationships {
+			if rd.Required {
+				required = append(required, typeID+"/"+rd.Name)
+			}
+		}
+	}
+	if len(required) == 0 {
+		return nil
+	}
+	q := fmt.Sprintf(
+		`FOR e IN %s
+		   FILTER e.agency_id == @agencyID AND e._to IN @handles AND e._from NOT IN @handles
+		   LET src = DOCUMENT(e._from)
+		   FILTER src != null AND src.deleted != true AND CONCAT(src.type_id, "/", e.name) IN @required
+		   FILTER LENGTH(
+		     FOR o IN %s
+		       FILTER o._from == e._from AND o.name == e.name AND o.agency_id == @agencyID AND o._to NOT IN @handles
+		       LIMIT 1
+		       RETURN 1
+		   ) == 0
+		   LIMIT 1
+		   RETURN e`,
+		b.relCollectionName, b.relCollectionName,
+	)
+	cursor, err := b.db.Query(ctx, q, map[string]interface{}{"agencyID": agencyID, "handles": handles, "required": required})
+	if err != nil {
+		return fmt.Errorf("check required edges: %w", err)
+	}
+	defer cursor.Close()
+	if !cursor.HasMore() {
+		return nil
+	}
+	var e relationshipDoc
+	if _, err := cursor.ReadDocument(ctx, &e); err != nil {
+		return fmt.Errorf("check required edges: read: %w", err)
+	}
+	return fmt.Errorf("entity %s requires its %q edge to %s: %w",
+		stripCollectionPrefix(e.From), e.Name, stripCollectionPrefix(e.To), entitygraph.ErrRequiredRelationshipViolation)
+}
+
+// deletionSet returns entityID followed by every live entity that deleting it
+// cascades to, applying [entitygraph.DeletePolicyFor] to the outbound edges of
+// each entity in turn. Returns [entitygraph.ErrDeleteRestricted] if any entity
+// in the set has an edge whose policy is restrict. It performs no writes.
+func (b *Backend) deletionSet(ctx context.Context, agencyID, entityID string) ([]deletionTarget, error) {
+	handle, doc, err := b.resolveEntity(ctx, agencyID, entityID)
+	if err != nil {
+		return nil, err
+	}
+	set := []deletionTarget{b.deletionTarget(handle, doc)}
+	seen := map[string]struct{}{entityID: {}}
+	for i := 0; i < len(set); i++ {
+		edges, err := b.outboundEdges(ctx, agencyID, set[i].handle)
+		if err != nil {
+			return nil, err
+		}
+		td := b.typeDefs()[set[i].typeID]
+		for _, e := range edges {
+			toID := stripCollectionPrefix(e.To)
+			switch entitygraph.DeletePolicyFor(td, e.Name) {
+			case types.DeletePolicyRestrict:
+				return nil, fmt.Errorf("entity %s has %q edge to %s: %w", set[i].key, e.Name, toID, entitygraph.ErrDeleteRestricted)
+			case types.DeletePolicyCascade:
+				if _, ok := seen[toID]; ok {
+					continue
+				}
+				seen[toID] = struct{}{}
+				h, d, err := b.resolveEntity(ctx, agencyID, toID)
+				if errors.Is(err, entitygraph.ErrEntityNotFound) || (err == nil && d.Deleted) {
+					continue
+				}
+				if err != nil {
+					return nil, err
+				}
+				set = append(set, b.deletionTarget(h, d))
+			}
+		}
+	}
+	return set, nil
+}
+
+// deletionTarget builds the deletionTarget for the entity doc stored under
+// handle.
+func (b *Backend) deletionTarget(handle string, doc entityDoc) deletionTarget {
+	t := deletionTarget{col: b.collectionOf(handle, doc), key: doc.Key, handle: handle, typeID: doc.TypeID}
+	for _, rd := range b.typeDefs()[doc.TypeID].Relationships {
+		if rd.OnDelete == types.DeletePolicyRestrict {
+			t.restrict = append(t.restrict, rd.Name)
+		}
+	}
+	return t
+}
+
+// ListEntities returns entities matching the filter, sorted and paged when
+// filter.OrderBy, Limit, or PageToken is set. Soft-deleted entities are
+// skipped unless filter.IncludeDeleted is set.
 // Zero-value filter fields are treated as "no restriction".
 func (b *Backend) ListEntities(
 	ctx context.Context,
 	filter entitygraph.EntityFilter,
 ) ([]entitygraph.Entity, error) {
+	page, err := b.listEntities(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("ListEntities: %w", err)
+	}
+	return page.Entities, nil
+}
+
+// ListEntitiesPage returns one page of the entities matching the filter
+// together with the next page token and, when requested, the total count.
+// Listings without a TypeID sort across every entity collection at once.
+func (b *Backend) ListEntitiesPage(
+	ctx context.Context,
+	filter entitygraph.EntityFilter,
+) (entitygraph.EntityPage, error) {
+	page, err := b.listEntities(ctx, filter)
+	if err != nil {
+		return entitygraph.EntityPage{}, fmt.Errorf("ListEntitiesPage: %w", err)
+	}
+	return page, nil
+}
+
+// listEntities implements ListEntities and ListEntitiesPage.
+func (b *Backend) listEntities(ctx context.Context, filter entitygraph.EntityFilter) (entitygraph.EntityPage, error) {
+	var td *types.TypeDefinition
+	if d, ok := b.typeDefs()[filter.TypeID]; ok {
+		td = &d
+	}
+	if err := entitygraph.ValidatePageRequest(filter.Limit, filter.OrderBy, td, false); err != nil {
+		return entitygraph.EntityPage{}, err
+	}
+	if filter.Where != nil {
+		if err := entitygraph.ValidateFilter(*filter.Where, td); err != nil {
+			return entitygraph.EntityPage{}, err
+		}
+	}
+
 	bindVars := map[string]interface{}{}
 	var conditions []string
-	conditions = append(conditions, "doc.deleted != true")
+	switch {
+	case filter.OnlyDeleted:
+		conditions = append(conditions, "doc.deleted == true")
+	case !filter.IncludeDeleted:
+		conditions = append(conditions, "doc.deleted != true")
+	}
 	if filter.AgencyID != "" {
 		conditions = append(conditions, "doc.agency_id == @agencyID")
 		bindVars["agencyID"] = filter.AgencyID
@@ -161,13 +527,23 @@ func (b *Backend) ListEntities(
 		bindVars["typeID"] = filter.TypeID
 	}
 	// Property filters: each key-value pair in filter.Properties must match
-	// the corresponding value in the stored document's properties map.
-	for k, v := range filter.Properties {
-		paramName := "prop_" + k
-		conditions = append(conditions, fmt.Sprintf("doc.properties.`%s` == @%s", k, paramName))
-		bindVars[paramName] = v
+	// the corresponding value in the stored document's properties map. They
+	// compile as FilterEq nodes alongside Where, so their keys are bound like
+	// any other property name rather than spliced into the query.
+	var nodes []entitygraph.FilterExpr
+	for _, k := range slices.Sorted(maps.Keys(filter.Properties)) {
+		nodes = append(nodes, entitygraph.Eq(k, filter.Properties[k]))
+	}
+	if filter.Where != nil {
+		nodes = append(nodes, *filter.Where)
+	}
+	if len(nodes) > 0 {
+		conditions = append(conditions, compileFilter(entitygraph.And(nodes...), bindVars))
+	}
+	where := "true"
+	if len(conditions) > 0 {
+		where = strings.Join(conditions, " AND ")
 	}
-	where := strings.Join(conditions, " AND ")
 
 	// Determine which collection(s) to query based on the TypeID filter.
 	// When TypeID is set we go directly to that type's collection.
@@ -178,40 +554,96 @@ func (b *Backend) ListEntities(
 	} else {
 		cols = b.allEntityCollections()
 	}
+	var page entitygraph.EntityPage
+	if len(cols) == 0 {
+		return page, nil
+	}
+	loop := entityLoop(cols, where)
+
+	if filter.IncludeTotal {
+		n, err := countMatches(ctx, b.db, loop, bindVars)
+		if err != nil {
+			return entitygraph.EntityPage{}, err
+		}
+		page.Total = n
+	}
+
+	if entitygraph.Paginated(filter.OrderBy, filter.Limit, filter.PageToken) {
+		docs, next, err := queryPage(ctx, b.db, loop, bindVars, filter.OrderBy, filter.Limit, filter.PageToken,
+			func(d entityDoc) string { return d.Key })
+		if err != nil {
+			return entitygraph.EntityPage{}, err
+		}
+		for _, doc := range docs {
+			page.Entities = append(page.Entities, toEntity(doc, doc.Key))
+		}
+		page.NextPageToken = next
+		return page, nil
+	}
 
-	var results []entitygraph.Entity
 	for _, col := range cols {
 		q := fmt.Sprintf("FOR doc IN %s FILTER %s RETURN doc", col.Name(), where)
 		cursor, qErr := b.db.Query(ctx, q, bindVars)
 		if qErr != nil {
-			return nil, fmt.Errorf("ListEntities: query %s: %w", col.Name(), qErr)
+			return entitygraph.EntityPage{}, fmt.Errorf("query %s: %w", col.Name(), qErr)
 		}
 		var readErr error
 		for cursor.HasMore() {
 			var doc entityDoc
 			meta, rErr := cursor.ReadDocument(ctx, &doc)
 			if rErr != nil {
-				readErr = fmt.Errorf("ListEntities: read: %w", rErr)
+				readErr = fmt.Errorf("read: %w", rErr)
 				break
 			}
-			results = append(results, toEntity(doc, meta.Key))
+			page.Entities = append(page.Entities, toEntity(doc, meta.Key))
 		}
 		cursor.Close()
 		if readErr != nil {
-			return nil, readErr
+			return entitygraph.EntityPage{}, readErr
 		}
 	}
-	return results, nil
+	return page, nil
 }
 
 // UpsertEntity finds a non-deleted entity whose UniqueKey property values
 // match the request and merges the supplied properties onto it, or inserts a
-// new entity if no match is found.
+// new entity if no match is found. Properties are validated as a full set on
+// insert and as a patch on merge.
+//
+// Inline req.Relationships are written in the same stream transaction as the
+// entity. On insert they must satisfy every Required relationship; on merge
+// they are applied idempotently — ToMany=false edges are replaced and ToMany
+// edges that already exist are not duplicated.
+// The lookup and the write are separate requests. When a concurrent writer
+// inserts the same key in between, the unique index rejects the insert; when
+// one changes the matched entity, its revision precondition fails. Either way
+// the upsert is retried, up to maxWriteAttempts times, and merges onto the
+// entity that won.
 // Returns [entitygraph.ErrUniqueKeyNotDefined] if the type has no UniqueKey.
 func (b *Backend) UpsertEntity(ctx context.Context, req entitygraph.CreateEntityRequest) (entitygraph.Entity, error) {
-	td, ok := b.typeDefs[req.TypeID]
+	res, err := b.UpsertEntityResult(ctx, req)
+	return res.Entity, err
+}
+
+// UpsertEntityResult implements [entitygraph.EntityUpserter]: it upserts as
+// UpsertEntity and reports the entity merged onto, if any. Previous is the
+// document the successful attempt's revision precondition held against.
+func (b *Backend) UpsertEntityResult(ctx context.Context, req entitygraph.CreateEntityRequest) (entitygraph.UpsertResult, error) {
+	for attempt := 1; ; attempt++ {
+		res, err := b.upsertEntity(ctx, req)
+		lostRace := errors.Is(err, entitygraph.ErrEntityAlreadyExists) || errors.Is(err, entitygraph.ErrConflict)
+		if lostRace && attempt < maxWriteAttempts {
+			continue
+		}
+		return res, err
+	}
+}
+
+// upsertEntity makes one lookup-then-write attempt of UpsertEntity.
+func (b *Backend) upsertEntity(ctx context.Context, req entitygraph.CreateEntityRequest) (entitygraph.UpsertResult, error) {
+	td, ok := b.typeDefs()[req.TypeID]
 	if !ok || len(td.UniqueKey) == 0 {
-		return entitygraph.Entity{}, fmt.Errorf("UpsertEntity %s: %w", req.TypeID, entitygraph.ErrUniqueKeyNotDefined)
+		return entitygraph.UpsertResult{}, fmt.Errorf("UpsertEntity %s: %w", req.TypeID, entitygraph.ErrUniqueKeyNotDefined)
 	}
 
 	props := req.Properties
@@ -219,48 +651,26 @@ func (b *Backend) UpsertEntity(ctx context.Context, req entitygraph.CreateEntity
 		props = make(map[string]any)
 	}
 
-	// Build an AQL query that locates an existing entity by the UniqueKey fields.
-	bindVars := map[string]interface{}{
-		"agencyID": req.AgencyID,
-		"typeID":   req.TypeID,
-	}
-	conditions := []string{
-		"doc.agency_id == @agencyID",
-		"doc.type_id == @typeID",
-		"doc.deleted != true",
-	}
-	for i, field := range td.UniqueKey {
-		valParam := fmt.Sprintf("ukval%d", i)
-		conditions = append(conditions, fmt.Sprintf("doc.properties.`%s` == @%s", field, valParam))
-		bindVars[valParam] = props[field]
-	}
 	col := b.collectionFor(req.TypeID)
-	q := fmt.Sprintf(
-		"FOR doc IN %s FILTER %s LIMIT 1 RETURN doc",
-		col.Name(), strings.Join(conditions, " AND "),
-	)
-	cursor, err := b.db.Query(ctx, q, bindVars)
+	existingDoc, err := b.findByUniqueKey(ctx, td, req.AgencyID, props)
 	if err != nil {
-		return entitygraph.Entity{}, fmt.Errorf("UpsertEntity %s: query: %w", req.TypeID, err)
+		return entitygraph.UpsertResult{}, fmt.Errorf("UpsertEntity %s: %w", req.TypeID, err)
 	}
 
-	var existingDoc *entityDoc
-	if cursor.HasMore() {
-		var doc entityDoc
-		meta, rErr := cursor.ReadDocument(ctx, &doc)
-		if rErr != nil {
-			cursor.Close()
-			return entitygraph.Entity{}, fmt.Errorf("UpsertEntity %s: read: %w", req.TypeID, rErr)
-		}
-		doc.Key = meta.Key
-		existingDoc = &doc
+	edges, err := b.inlineEdges(ctx, req)
+	if err != nil {
+		return entitygraph.UpsertResult{}, fmt.Errorf("UpsertEntity: %w", err)
 	}
-	cursor.Close()
-
 	now := time.Now().UTC()
 
 	if existingDoc == nil {
 		// No match — insert a new entity.
+		if err := b.validateProperties(req.TypeID, props, entitygraph.ValidateCreate); err != nil {
+			return entitygraph.UpsertResult{}, fmt.Errorf("UpsertEntity: %w", err)
+		}
+		if err := entitygraph.ValidateRequiredRelationships(td, req.Relationships); err != nil {
+			return entitygraph.UpsertResult{}, fmt.Errorf("UpsertEntity: %w", err)
+		}
 		id := uuid.NewString()
 		doc := entityDoc{
 			Key:        id,
@@ -269,17 +679,22 @@ func (b *Backend) UpsertEntity(ctx context.Context, req entitygraph.CreateEntity
 			Properties: props,
 			CreatedAt:  now,
 			UpdatedAt:  now,
+			UniqueKey:  b.uniqueKey(req.TypeID, props),
 		}
-		if _, err := col.CreateDocument(ctx, doc); err != nil {
-			if driver.IsConflict(err) {
-				return entitygraph.Entity{}, fmt.Errorf("UpsertEntity: %w", entitygraph.ErrEntityAlreadyExists)
-			}
-			return entitygraph.Entity{}, fmt.Errorf("UpsertEntity: %w", err)
+		rev, err := b.insertEntity(ctx, col, doc, edges)
+		if err != nil {
+			return entitygraph.UpsertResult{}, fmt.Errorf("UpsertEntity: %w", err)
 		}
-		return toEntity(doc, id), nil
+		doc.Rev = rev
+		return entitygraph.UpsertResult{Entity: toEntity(doc, id)}, nil
 	}
 
 	// Match found — merge supplied properties onto the existing entity.
+	if err := b.validateProperties(req.TypeID, props, entitygraph.ValidatePatch); err != nil {
+		return entitygraph.UpsertResult{}, fmt.Errorf("UpsertEntity: %w", err)
+	}
+	before := toEntity(*existingDoc, existingDoc.Key)
+	before.Properties = maps.Clone(existingDoc.Properties)
 	if existingDoc.Properties == nil {
 		existingDoc.Properties = make(map[string]any)
 	}
@@ -296,14 +711,224 @@ func (b *Backend) UpsertEntity(ctx context.Context, req entitygraph.CreateEntity
 		UpdatedAt:  existingDoc.UpdatedAt,
 		Deleted:    existingDoc.Deleted,
 		DeletedAt:  existingDoc.DeletedAt,
+		UniqueKey:  b.uniqueKey(existingDoc.TypeID, existingDoc.Properties),
 	}
-	if _, err := col.ReplaceDocument(ctx, existingDoc.Key, updated); err != nil {
-		if driver.IsNotFound(err) {
-			return entitygraph.Entity{}, fmt.Errorf("UpsertEntity: %w", entitygraph.ErrEntityNotFound)
+	replace := func(ctx context.Context) error {
+		meta, err := col.ReplaceDocument(driver.WithRevision(ctx, existingDoc.Rev), existingDoc.Key, updated)
+		if err != nil {
+			if driver.IsNotFound(err) {
+				return entitygraph.ErrEntityNotFound
+			}
+			if driver.IsPreconditionFailed(err) {
+				return entitygraph.ErrConflict
+			}
+			if driver.IsConflict(err) {
+				return entitygraph.ErrEntityAlreadyExists
+			}
+			return err
+		}
+		updated.Rev = meta.Rev
+		if err := b.recordChange(ctx, updated, entitygraph.ChangeUpdated, &before); err != nil {
+			return err
 		}
-		return entitygraph.Entity{}, fmt.Errorf("UpsertEntity: %w", err)
+		return b.writeInlineEdges(ctx, col.Name()+"/"+existingDoc.Key, edges, true)
+	}
+	write := []string{col.Name()}
+	if len(edges) > 0 {
+		write = append(write, b.relCollectionName)
+	}
+	write = append(write, b.changeWrite(req.TypeID)...)
+	if err := b.withWrites(ctx, write, replace); err != nil {
+		return entitygraph.UpsertResult{}, fmt.Errorf("UpsertEntity: %w", err)
+	}
+	return entitygraph.UpsertResult{Entity: toEntity(updated, existingDoc.Key), Previous: &before}, nil
+}
+
+// findByUniqueKey returns the live entity of type td in agencyID whose
+// UniqueKey property values equal those in props, or nil when there is none.
+func (b *Backend) findByUniqueKey(ctx context.Context, td types.TypeDefinition, agencyID string, props map[string]any) (*entityDoc, error) {
+	bindVars := map[string]interface{}{
+		"agencyID": agencyID,
+		"typeID":   td.Name,
+	}
+	conditions := []string{
+		"doc.agency_id == @agencyID",
+		"doc.type_id == @typeID",
+		"doc.deleted != true",
+	}
+	for i, field := range td.UniqueKey {
+		valParam := fmt.Sprintf("ukval%d", i)
+		conditions = append(conditions, fmt.Sprintf("doc.properties.`%s` == @%s", field, valParam))
+		bindVars[valParam] = props[field]
+	}
+	col := b.collectionFor(td.Name)
+	q := fmt.Sprintf(
+		"FOR doc IN %s FILTER %s LIMIT 1 RETURN doc",
+		col.Name(), strings.Join(conditions, " AND "),
+	)
+	cursor, err := b.db.Query(ctx, q, bindVars)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	defer cursor.Close()
+	if !cursor.HasMore() {
+		return nil, nil
+	}
+	var doc entityDoc
+	meta, err := cursor.ReadDocument(ctx, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	doc.Key = meta.Key
+	return &doc, nil
+}
+
+// RestoreEntity clears deleted and deleted_at on a soft-deleted entity
+// document, reinstates its unique_key, and bumps updated_at. Returns
+// entitygraph.ErrEntityNotDeleted for a live entity and
+// entitygraph.ErrEntityAlreadyExists when the unique index finds a live
+// entity of the same type now holding its UniqueKey values.
+func (b *Backend) RestoreEntity(ctx context.Context, agencyID, entityID string) (entitygraph.Entity, error) {
+	handle, doc, err := b.resolveEntity(ctx, agencyID, entityID)
+	if err != nil {
+		return entitygraph.Entity{}, fmt.Errorf("RestoreEntity: %w", err)
+	}
+	if !doc.Deleted {
+		return entitygraph.Entity{}, fmt.Errorf("RestoreEntity %s: %w", entityID, entitygraph.ErrEntityNotDeleted)
+	}
+	now := time.Now().UTC()
+	uniqueKey := b.uniqueKey(doc.TypeID, doc.Properties)
+	patch := map[string]any{"deleted": false, "deleted_at": nil, "updated_at": now, "unique_key": uniqueKeyPatch(uniqueKey)}
+	col := b.collectionOf(handle, doc)
+	write := append([]string{col.Name()}, b.changeWrite(doc.TypeID)...)
+	err = b.withWrites(ctx, write, func(ctx context.Context) error {
+		meta, err := col.UpdateDocument(ctx, entityID, patch)
+		if err != nil {
+			if driver.IsNotFound(err) {
+				return entitygraph.ErrEntityNotFound
+			}
+			if driver.IsConflict(err) {
+				return fmt.Errorf("unique key held by another entity: %w", entitygraph.ErrEntityAlreadyExists)
+			}
+			return err
+		}
+		doc.Deleted, doc.DeletedAt, doc.UpdatedAt, doc.UniqueKey = false, nil, now, uniqueKey
+		doc.Rev = meta.Rev
+		return b.recordChange(ctx, doc, entitygraph.ChangeRestored, nil)
+	})
+	if err != nil {
+		return entitygraph.Entity{}, fmt.Errorf("RestoreEntity %s: %w", entityID, err)
+	}
+	return toEntity(doc, entityID), nil
+}
+
+// PurgeEntity permanently removes a soft-deleted entity document, its
+// history, and any edge still referencing it, in one stream transaction.
+// Returns
+// entitygraph.ErrEntityNotDeleted for a live entity.
+func (b *Backend) PurgeEntity(ctx context.Context, agencyID, entityID string) error {
+	handle, doc, err := b.resolveEntity(ctx, agencyID, entityID)
+	if err != nil {
+		return fmt.Errorf("PurgeEntity: %w", err)
+	}
+	if !doc.Deleted {
+		return fmt.Errorf("PurgeEntity %s: %w", entityID, entitygraph.ErrEntityNotDeleted)
+	}
+	col := b.collectionOf(handle, doc)
+	err = b.withTransaction(ctx, []string{col.Name(), b.relCollectionName, b.historyName}, func(ctx context.Context) error {
+		if _, err := col.RemoveDocument(ctx, entityID); err != nil {
+			if driver.IsNotFound(err) {
+				return entitygraph.ErrEntityNotFound
+			}
+			return err
+		}
+		if err := b.purgeHistory(ctx, agencyID, []string{entityID}); err != nil {
+			return err
+		}
+		return b.removeEdgesTouching(ctx, agencyID, []string{handle})
+	})
+	if err != nil {
+		return fmt.Errorf("PurgeEntity %s: %w", entityID, err)
+	}
+	return nil
+}
+
+// PurgeDeletedBefore permanently removes every entity document of agencyID
+// whose deleted_at is before cutoff, their history, and any edge still
+// referencing them. Each entity collection is purged in its own stream
+// transaction together with the matching edges and revisions.
+func (b *Backend) PurgeDeletedBefore(ctx context.Context, agencyID string, cutoff time.Time) (int, error) {
+	purged := 0
+	for _, col := range b.allEntityCollections() {
+		col := col
+		var handles []string
+		err := b.withTransaction(ctx, []string{col.Name(), b.relCollectionName, b.historyName}, func(ctx context.Context) error {
+			q := fmt.Sprintf(
+				`FOR doc IN %s
+				 FILTER doc.agency_id == @agencyID AND doc.deleted == true AND DATE_TIMESTAMP(doc.deleted_at) < DATE_TIMESTAMP(@cutoff)
+				 REMOVE doc IN %s
+				 RETURN CONCAT(@col, "/", OLD._key)`,
+				col.Name(), col.Name(),
+			)
+			cursor, err := b.db.Query(ctx, q, map[string]interface{}{
+				"agencyID": agencyID,
+				"cutoff":   cutoff.UTC(),
+				"col":      col.Name(),
+			})
+			if err != nil {
+				return fmt.Errorf("query %s: %w", col.Name(), err)
+			}
+			handles = handles[:0]
+			var ids []string
+			for cursor.HasMore() {
+				var h string
+				if _, err := cursor.ReadDocument(ctx, &h); err != nil {
+					cursor.Close()
+					return fmt.Errorf("read: %w", err)
+				}
+				handles = append(handles, h)
+				ids = append(ids, stripCollectionPrefix(h))
+			}
+			cursor.Close()
+			if err := b.purgeHistory(ctx, agencyID, ids); err != nil {
+				return err
+			}
+			return b.removeEdgesTouching(ctx, agencyID, handles)
+		})
+		if err != nil {
+			return purged, fmt.Errorf("PurgeDeletedBefore: %w", err)
+		}
+		purged += len(handles)
+	}
+	return purged, nil
+}
+
+// removeEdgesTouching removes every edge of agencyID whose _from or _to is one
+// of handles.
+func (b *Backend) removeEdgesTouching(ctx context.Context, agencyID string, handles []string) error {
+	if len(handles) == 0 {
+		return nil
+	}
+	q := fmt.Sprintf(
+		`FOR e IN %s
+		 FILTER e.agency_id == @agencyID AND (e._from IN @handles OR e._to IN @handles)
+		 REMOVE e IN %s`,
+		b.relCollectionName, b.relCollectionName,
+	)
+	cursor, err := b.db.Query(ctx, q, map[string]interface{}{"agencyID": agencyID, "handles": handles})
+	if err != nil {
+		return fmt.Errorf("remove edges: %w", err)
+	}
+	return cursor.Close()
+}
+
+// collectionOf returns the entity collection holding the document stored
+// under handle, falling back to the type's configured collection.
+func (b *Backend) collectionOf(handle string, doc entityDoc) driver.Collection {
+	if col, ok := b.entityCollection(strings.TrimSuffix(handle, "/"+doc.Key)); ok {
+		return col
 	}
-	return toEntity(updated, existingDoc.Key), nil
+	return b.collectionFor(doc.TypeID)
 }
 
 // toEntity converts an entityDoc and its ArangoDB _key to an
@@ -318,6 +943,7 @@ func toEntity(doc entityDoc, key string) entitygraph.Entity {
 		UpdatedAt:  doc.UpdatedAt,
 		Deleted:    doc.Deleted,
 		DeletedAt:  doc.DeletedAt,
+		Revision:   doc.Rev,
 	}
 	if e.Properties == nil {
 		e.Properties = make(map[string]any)
@@ -0,0 +1,78 @@
+// filter.go compiles an [entitygraph.FilterExpr] tree to an AQL condition.
+// Property names and operands are always passed as bind variables, so no
+// part of the tree is spliced into the query text.
+package arangodb
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+)
+
+// filterCompiler numbers the bind variables of one compiled filter tree.
+type filterCompiler struct {
+	bindVars map[string]any
+	n        int
+}
+
+// compileFilter returns the AQL condition for f over the document bound to
+// doc, adding its bind variables to bindVars. f must have passed
+// [entitygraph.ValidateFilter].
+func compileFilter(f entitygraph.FilterExpr, bindVars map[string]any) string {
+	c := &filterCompiler{bindVars: bindVars}
+	return c.compile(f)
+}
+
+// bind stores v under a fresh bind variable name with prefix and returns the
+// "@name" reference.
+func (c *filterCompiler) bind(prefix string, v any) string {
+	name := fmt.Sprintf("%s%d", prefix, c.n)
+	c.n++
+	c.bindVars[name] = v
+	return "@" + name
+}
+
+// compile returns the AQL condition for the node f.
+func (c *filterCompiler) compile(f entitygraph.FilterExpr) string {
+	switch f.Op {
+	case entitygraph.FilterAnd, entitygraph.FilterOr:
+		parts := make([]string, len(f.Operands))
+		for i, op := range f.Operands {
+			parts[i] = c.compile(op)
+		}
+		return "(" + strings.Join(parts, " "+strings.ToUpper(string(f.Op))+" ") + ")"
+	case entitygraph.FilterNot:
+		return "NOT (" + c.compile(f.Operands[0]) + ")"
+	}
+
+	// A missing attribute reads as null, matching the in-memory semantics.
+	acc := "doc.properties[" + c.bind("fp", f.Property) + "]"
+	if f.Op == entitygraph.FilterExists {
+		return acc + " != null"
+	}
+	v := c.bind("fv", f.Value)
+	switch f.Op {
+	case entitygraph.FilterEq:
+		return fmt.Sprintf("%s == %s", acc, v)
+	case entitygraph.FilterNe:
+		return fmt.Sprintf("%s != %s", acc, v)
+	case entitygraph.FilterLt, entitygraph.FilterLte, entitygraph.FilterGt, entitygraph.FilterGte:
+		// AQL orders values of different types; the filter only compares
+		// like with like.
+		op := map[entitygraph.FilterOp]string{
+			entitygraph.FilterLt:  "<",
+			entitygraph.FilterLte: "<=",
+			entitygraph.FilterGt:  ">",
+			entitygraph.FilterGte: ">=",
+		}[f.Op]
+		return fmt.Sprintf("(TYPENAME(%s) == TYPENAME(%s) AND %s %s %s)", acc, v, acc, op, v)
+	case entitygraph.FilterIn:
+		return fmt.Sprintf("%s IN %s", acc, v)
+	case entitygraph.FilterContains:
+		return fmt.Sprintf("(IS_ARRAY(%[1]s) ? %[2]s IN %[1]s : (IS_STRING(%[1]s) AND IS_STRING(%[2]s) AND CONTAINS(%[1]s, %[2]s)))", acc, v)
+	case entitygraph.FilterPrefix:
+		return fmt.Sprintf("(IS_STRING(%[1]s) AND LEFT(%[1]s, LENGTH(%[2]s)) == %[2]s)", acc, v)
+	}
+	return "false"
+}
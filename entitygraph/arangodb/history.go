@@ -0,0 +1,168 @@
+// history.go contains GetEntityHistory and GetEntityAt for the Backend, and
+// the recording of revisions for History-enabled types.
+//
+// Each revision is one document in the HistoryCol collection holding a full
+// copy of the entity document as the write left it. Every write to an entity
+// of a History-enabled type runs in a stream transaction that also declares
+// HistoryCol and inserts the revision, so a revision exists exactly when its
+// write committed. Purges remove an entity's revisions in the same
+// transaction as the entity.
+package arangodb
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	driver "github.com/arangodb/go-driver"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+)
+
+// historyIndexName is the persistent index serving the per-entity history
+// lookups.
+const historyIndexName = "entitygraph_history_entity"
+
+// historyDoc is the ArangoDB document representation of an
+// [entitygraph.EntityRevision].
+type historyDoc struct {
+	EntityID   string    `json:"entity_id"`
+	AgencyID   string    `json:"agency_id"`
+	Change     string    `json:"change"`
+	Actor      string    `json:"actor,omitempty"`
+	RecordedAt time.Time `json:"recorded_at"`
+	Entity     entityDoc `json:"entity"`
+}
+
+// ensureHistoryIndex creates the [agency_id, entity_id] index on the history
+// collection if it does not exist yet.
+func ensureHistoryIndex(ctx context.Context, b *Backend) error {
+	_, _, err := b.history.EnsurePersistentIndex(ctx, []string{"agency_id", "entity_id"}, &driver.EnsurePersistentIndexOptions{
+		Name: historyIndexName,
+	})
+	if err != nil {
+		return fmt.Errorf("ensureHistoryIndex: %w", err)
+	}
+	return nil
+}
+
+// GetEntityHistory returns the recorded revisions of the entity, oldest
+// first. Returns entitygraph.ErrEntityNotFound if the entity does not exist
+// and entitygraph.ErrHistoryNotEnabled if its type does not set History.
+func (b *Backend) GetEntityHistory(ctx context.Context, agencyID, entityID string) ([]entitygraph.EntityRevision, error) {
+	history, err := b.entityHistory(ctx, agencyID, entityID)
+	if err != nil {
+		return nil, fmt.Errorf("GetEntityHistory %s: %w", entityID, err)
+	}
+	return history, nil
+}
+
+// GetEntityAt returns the entity as it was at the instant at. Returns
+// entitygraph.ErrEntityNotFound if it did not exist, or was deleted, at that
+// instant and entitygraph.ErrHistoryNotEnabled if its type does not set
+// History.
+func (b *Backend) GetEntityAt(ctx context.Context, agencyID, entityID string, at time.Time) (entitygraph.Entity, error) {
+	history, err := b.entityHistory(ctx, agencyID, entityID)
+	if err != nil {
+		return entitygraph.Entity{}, fmt.Errorf("GetEntityAt %s: %w", entityID, err)
+	}
+	e, ok := entitygraph.EntityAt(history, at)
+	if !ok {
+		return entitygraph.Entity{}, fmt.Errorf("GetEntityAt %s at %s: %w", entityID, at.Format(time.RFC3339Nano), entitygraph.ErrEntityNotFound)
+	}
+	return e, nil
+}
+
+// entityHistory reads the revisions of the entity, deleted or not, oldest
+// first. Revisions are ordered in Go: recorded_at is stored as RFC 3339 text
+// whose fractional seconds vary in length, so it does not sort as a string.
+func (b *Backend) entityHistory(ctx context.Context, agencyID, entityID string) ([]entitygraph.EntityRevision, error) {
+	_, doc, err := b.resolveEntity(ctx, agencyID, entityID)
+	if err != nil {
+		return nil, err
+	}
+	if !b.hasHistory(doc.TypeID) {
+		return nil, fmt.Errorf("type %s: %w", doc.TypeID, entitygraph.ErrHistoryNotEnabled)
+	}
+	q := fmt.Sprintf("FOR h IN %s FILTER h.agency_id == @agencyID AND h.entity_id == @entityID RETURN h", b.historyName)
+	cursor, err := b.db.Query(ctx, q, map[string]interface{}{"agencyID": agencyID, "entityID": entityID})
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	defer cursor.Close()
+	var history []entitygraph.EntityRevision
+	for cursor.HasMore() {
+		var h historyDoc
+		if _, err := cursor.ReadDocument(ctx, &h); err != nil {
+			return nil, fmt.Errorf("read: %w", err)
+		}
+		history = append(history, entitygraph.EntityRevision{
+			Entity:     toEntity(h.Entity, h.EntityID),
+			Change:     entitygraph.ChangeKind(h.Change),
+			Actor:      h.Actor,
+			RecordedAt: h.RecordedAt,
+		})
+	}
+	slices.SortStableFunc(history, func(a, b entitygraph.EntityRevision) int {
+		return a.RecordedAt.Compare(b.RecordedAt)
+	})
+	return history, nil
+}
+
+// hasHistory reports whether the TypeDefinition for typeID has History set.
+func (b *Backend) hasHistory(typeID string) bool {
+	td, ok := b.typeDefs()[typeID]
+	return ok && td.History
+}
+
+// historyWrite returns the collections a write to entities of typeIDs must
+// declare for their revisions: the history collection when any of the types
+// has History set, nothing otherwise.
+func (b *Backend) historyWrite(typeIDs ...string) []string {
+	for _, typeID := range typeIDs {
+		if b.hasHistory(typeID) {
+			return []string{b.historyName}
+		}
+	}
+	return nil
+}
+
+// recordHistory inserts the revision left by a change to doc, the entity
+// document as written including its new _rev, when its type has History set.
+// ctx must carry the transaction of the write.
+func (b *Backend) recordHistory(ctx context.Context, doc entityDoc, change entitygraph.ChangeKind) error {
+	if !b.hasHistory(doc.TypeID) {
+		return nil
+	}
+	doc.UniqueKey = ""
+	h := historyDoc{
+		EntityID:   doc.Key,
+		AgencyID:   doc.AgencyID,
+		Change:     string(change),
+		Actor:      entitygraph.ActorFromContext(ctx),
+		RecordedAt: doc.UpdatedAt,
+		Entity:     doc,
+	}
+	if _, err := b.history.CreateDocument(ctx, h); err != nil {
+		return fmt.Errorf("record history: %w", err)
+	}
+	return nil
+}
+
+// purgeHistory removes every revision of the entities of agencyID with the
+// given IDs. ctx must carry the transaction of the purge.
+func (b *Backend) purgeHistory(ctx context.Context, agencyID string, entityIDs []string) error {
+	if len(entityIDs) == 0 {
+		return nil
+	}
+	q := fmt.Sprintf(
+		"FOR h IN %s FILTER h.agency_id == @agencyID AND h.entity_id IN @ids REMOVE h IN %s",
+		b.historyName, b.historyName,
+	)
+	cursor, err := b.db.Query(ctx, q, map[string]interface{}{"agencyID": agencyID, "ids": entityIDs})
+	if err != nil {
+		return fmt.Errorf("purge history: %w", err)
+	}
+	return cursor.Close()
+}
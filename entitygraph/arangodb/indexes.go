@@ -0,0 +1,302 @@
+// indexes.go contains the persistent indexes the Backend keeps on its entity
+// collections, all derived from the schema TypeDefinitions:
+//   - [agency_id, type_id] on every entity collection, serving the scoping
+//     filter of every listing;
+//   - a sparse unique index on [agency_id, unique_key] on every collection
+//     holding a type with a UniqueKey;
+//   - [agency_id, type_id, properties.<name>] for every Indexed property.
+//
+// The relationships collection carries a sparse unique index on
+// [agency_id, _from, functional_name]. functional_name is set only on edges
+// of ToMany=false relationships (see upsertEdge), so the index allows one such
+// edge per source and label and leaves ToMany edges unconstrained.
+//
+// unique_key is a derived document attribute holding
+// [entitygraph.UniqueKeyOf] of the entity's properties. It is written only on
+// live documents and cleared on soft delete, so the index constrains exactly
+// the entities the UniqueKey contract covers: soft-deleted entities and
+// entities missing a key value never collide.
+//
+// unique_key is backfilled on documents written before it existed by
+// MigrateUniqueKeys, run once per set of UniqueKeys at construction.
+//
+// Indexes are ensured at construction and again before Activate makes a
+// schema version live, after creating any StorageCollection the version adds
+// (see ensureEntityCollections). A UniqueKey the version adds gets its unique
+// index then; the unique_key values themselves follow the Backend's
+// configured types, and are backfilled when the Backend is next constructed
+// with the new schema. Indexes are never dropped automatically: entity
+// collections are shared by every agency, and another agency's active schema
+// may still need an index this one no longer declares.
+package arangodb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	driver "github.com/arangodb/go-driver"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+// Names of the indexes managed by the Backend.
+const (
+	typeIndexName       = "entitygraph_agency_type"
+	uniqueKeyIndexName  = "entitygraph_unique_key"
+	functionalIndexName = "entitygraph_functional_edge"
+	propertyIndexPrefix = "entitygraph_prop_"
+)
+
+// indexSpec describes one persistent index.
+type indexSpec struct {
+	name   string
+	fields []string
+	unique bool
+	sparse bool
+}
+
+// indexPlan returns the indexes tds require, keyed by entity collection name.
+// The fallback collection always receives the [agency_id, type_id] index.
+func (b *Backend) indexPlan(tds []types.TypeDefinition) map[string][]indexSpec {
+	plan := make(map[string][]indexSpec)
+	add := func(col string, spec indexSpec) {
+		for _, s := range plan[col] {
+			if s.name == spec.name {
+				return
+			}
+		}
+		plan[col] = append(plan[col], spec)
+	}
+	typeIndex := indexSpec{name: typeIndexName, fields: []string{"agency_id", "type_id"}}
+	add(b.fallback.Name(), typeIndex)
+	for _, td := range tds {
+		col := b.collectionForDef(td).Name()
+		add(col, typeIndex)
+		if len(td.UniqueKey) > 0 {
+			add(col, indexSpec{name: uniqueKeyIndexName, fields: []string{"agency_id", "unique_key"}, unique: true, sparse: true})
+		}
+		for _, pd := range td.Properties {
+			if pd.Indexed {
+				add(col, indexSpec{
+					name:   propertyIndexPrefix + pd.Name,
+					fields: []string{"agency_id", "type_id", "properties." + pd.Name},
+				})
+			}
+		}
+	}
+	return plan
+}
+
+// ensureIndexes creates every index tds require that does not exist yet.
+func ensureIndexes(ctx context.Context, b *Backend, tds []types.TypeDefinition) error {
+	for name, specs := range b.indexPlan(tds) {
+		col, ok := b.entityCollection(name)
+		if !ok {
+			return fmt.Errorf("ensureIndexes: collection %q is not configured on the backend", name)
+		}
+		for _, spec := range specs {
+			_, _, err := col.EnsurePersistentIndex(ctx, spec.fields, &driver.EnsurePersistentIndexOptions{
+				Name:   spec.name,
+				Unique: spec.unique,
+				Sparse: spec.sparse,
+			})
+			if err != nil {
+				return fmt.Errorf("ensureIndexes: %s.%s: %w", col.Name(), spec.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ensureFunctionalEdgeIndex creates the sparse unique index on the
+// relationships collection that backs ToMany=false upserts. Edges written
+// before functional_name existed lack the attribute and are not covered until
+// their next upsert sets it.
+func ensureFunctionalEdgeIndex(ctx context.Context, b *Backend) error {
+	_, _, err := b.relationships.EnsurePersistentIndex(ctx, []string{"agency_id", "_from", "functional_name"}, &driver.EnsurePersistentIndexOptions{
+		Name:   functionalIndexName,
+		Unique: true,
+		Sparse: true,
+	})
+	if err != nil {
+		return fmt.Errorf("ensureFunctionalEdgeIndex: %w", err)
+	}
+	return nil
+}
+
+// UniqueKeyDuplicate is a live entity whose UniqueKey values collide with
+// those of another entity of its agency, found by MigrateUniqueKeys. Its
+// unique_key attribute is left unset, so the unique index does not cover it
+// until the duplicate is resolved and the migration run again.
+type UniqueKeyDuplicate struct {
+	TypeID    string `json:"type_id"`
+	EntityID  string `json:"entity_id"`
+	UniqueKey string `json:"unique_key"`
+}
+
+// UniqueKeyMigration reports what MigrateUniqueKeys changed.
+type UniqueKeyMigration struct {
+	// Updated is the number of documents whose unique_key was rewritten.
+	Updated int `json:"updated"`
+
+	// Duplicates lists the entities that could not take their unique_key
+	// because another entity already holds it.
+	Duplicates []UniqueKeyDuplicate `json:"duplicates,omitempty"`
+}
+
+// MigrateUniqueKeys brings the unique_key attribute of every live document of
+// the configured types in line with their current UniqueKey, filling it in on
+// documents written before the attribute existed and clearing it for types
+// that no longer declare a key. Documents whose attribute is already correct
+// are skipped by the query itself.
+//
+// Duplicates — entities written by the check-then-insert race the unique
+// index now prevents — do not stop the migration: each is reported in the
+// result and left without a unique_key for an operator to resolve.
+//
+// The Backend runs the migration once per set of UniqueKeys at construction
+// (see migrateUniqueKeysOnce); call it directly to re-run it after resolving
+// duplicates.
+func (b *Backend) MigrateUniqueKeys(ctx context.Context) (UniqueKeyMigration, error) {
+	var out UniqueKeyMigration
+	for _, td := range b.typeDefs() {
+		if err := b.migrateUniqueKeys(ctx, td, &out); err != nil {
+			return out, fmt.Errorf("MigrateUniqueKeys %s: %w", td.Name, err)
+		}
+	}
+	return out, nil
+}
+
+// migrateUniqueKeys rewrites the stale unique_key attributes of td's live
+// documents, recording the results in out.
+func (b *Backend) migrateUniqueKeys(ctx context.Context, td types.TypeDefinition, out *UniqueKeyMigration) error {
+	col := b.collectionForDef(td)
+	// want mirrors entitygraph.UniqueKeyOf so that up-to-date documents are
+	// filtered out in AQL; the rare value it renders differently from
+	// encoding/json is rechecked below and left unchanged.
+	q := fmt.Sprintf(
+		`FOR doc IN %s
+		 FILTER doc.type_id == @typeID AND doc.deleted != true
+		 LET vals = (FOR f IN @fields RETURN doc.properties[f])
+		 LET want = LENGTH(@fields) == 0 OR POSITION(vals, null) ? null : CONCAT(@typeID, ":", JSON_STRINGIFY(vals))
+		 FILTER doc.unique_key != want
+		 RETURN doc`,
+		col.Name(),
+	)
+	fields := td.UniqueKey
+	if fields == nil {
+		fields = []string{}
+	}
+	cursor, err := b.db.Query(ctx, q, map[string]interface{}{"typeID": td.Name, "fields": fields})
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+	defer cursor.Close()
+	for cursor.HasMore() {
+		var doc entityDoc
+		meta, err := cursor.ReadDocument(ctx, &doc)
+		if err != nil {
+			return fmt.Errorf("read: %w", err)
+		}
+		want, _ := entitygraph.UniqueKeyOf(td, doc.Properties)
+		if want == doc.UniqueKey {
+			continue
+		}
+		_, err = col.UpdateDocument(ctx, meta.Key, map[string]any{"unique_key": uniqueKeyPatch(want)})
+		switch {
+		case err == nil:
+			out.Updated++
+		case driver.IsConflict(err):
+			out.Duplicates = append(out.Duplicates, UniqueKeyDuplicate{TypeID: td.Name, EntityID: meta.Key, UniqueKey: want})
+			if doc.UniqueKey != "" {
+				if _, err := col.UpdateDocument(ctx, meta.Key, map[string]any{"unique_key": nil}); err != nil {
+					return fmt.Errorf("entity %s: clear unique key: %w", meta.Key, err)
+				}
+			}
+		default:
+			return fmt.Errorf("entity %s: %w", meta.Key, err)
+		}
+	}
+	return nil
+}
+
+// uniqueKeysMarker is the migrations document recording that
+// MigrateUniqueKeys ran for one set of UniqueKeys.
+type uniqueKeysMarker struct {
+	Key   string    `json:"_key"`
+	RanAt time.Time `json:"ran_at"`
+	UniqueKeyMigration
+}
+
+// migrateUniqueKeysOnce runs MigrateUniqueKeys unless the migrations
+// collection holds the marker of the configured types' UniqueKeys, then
+// writes the marker. A schema that adds, changes, or drops a UniqueKey has a
+// new marker, so its documents are migrated on the next start. Duplicates
+// are logged and recorded in the marker; they never fail construction.
+func migrateUniqueKeysOnce(ctx context.Context, b *Backend) error {
+	key := b.uniqueKeysMarkerKey()
+	exists, err := b.migrations.DocumentExists(ctx, key)
+	if err != nil {
+		return fmt.Errorf("migrate unique keys: check marker: %w", err)
+	}
+	if exists {
+		return nil
+	}
+	res, err := b.MigrateUniqueKeys(ctx)
+	if err != nil {
+		return err
+	}
+	for _, d := range res.Duplicates {
+		log.Printf("arangodb: %s entity %s duplicates unique key %s; resolve it and run MigrateUniqueKeys", d.TypeID, d.EntityID, d.UniqueKey)
+	}
+	marker := uniqueKeysMarker{Key: key, RanAt: time.Now().UTC(), UniqueKeyMigration: res}
+	if _, err := b.migrations.CreateDocument(ctx, marker); err != nil && !driver.IsConflict(err) {
+		return fmt.Errorf("migrate unique keys: write marker: %w", err)
+	}
+	return nil
+}
+
+// uniqueKeysMarkerKey returns the marker key of the configured types'
+// collections and UniqueKeys.
+func (b *Backend) uniqueKeysMarkerKey() string {
+	defs := b.typeDefs()
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, name := range names {
+		td := defs[name]
+		fmt.Fprintf(h, "%s\x00%s\x00%s\n", name, b.collectionForDef(td).Name(), strings.Join(td.UniqueKey, "\x00"))
+	}
+	return "unique_keys-" + hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// uniqueKey returns the unique_key attribute for a live document of typeID
+// with props, or "" when the document is not subject to a UniqueKey. Types
+// not declared in the schema have no unique key.
+func (b *Backend) uniqueKey(typeID string, props map[string]any) string {
+	td, ok := b.typeDefs()[typeID]
+	if !ok {
+		return ""
+	}
+	key, _ := entitygraph.UniqueKeyOf(td, props)
+	return key
+}
+
+// uniqueKeyPatch returns the unique_key value to store in a partial update:
+// the key itself, or nil to clear the attribute.
+func uniqueKeyPatch(key string) any {
+	if key == "" {
+		return nil
+	}
+	return key
+}
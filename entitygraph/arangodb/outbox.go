@@ -0,0 +1,561 @@
+// outbox.go contains the transactional outbox of the Backend and the
+// OutboxRelay that delivers it.
+//
+// When Config.OutboxCol is set, every write to an entity of a PublishEvents
+// type also inserts its lifecycle events (see entitygraph.LifecycleEvents) into
+// the outbox collection, in the same stream transaction as the write: an
+// event exists exactly when its change committed, and is not lost when the
+// event bus is unreachable. An [OutboxRelay] drains the collection to an
+// eventbus.Publisher or, as CloudEvents envelopes, to
+// registrar.Registrar.Publish, removing each event only once it has been
+// delivered. Delivery is therefore at least once —
+// consumers drop duplicates by entitygraph.EntityEvent.ID, which is also the
+// outbox document key.
+//
+// A relay claims due events by leasing them (pushing due_ns past the lease),
+// so several relays over one collection do not deliver the same event at the
+// same time. Events of one agency are delivered in the order they were
+// written: while an agency has an event leased or waiting for a retry, none
+// of its later events are claimed. A failed delivery is retried with
+// exponential backoff, up to MaxAttempts times. An event that exhausts them
+// is parked: it stays in the outbox with its last error but is no longer
+// claimed and no longer holds back its agency's later events, so one event
+// no consumer accepts cannot stall the agency. Stats counts parked events,
+// and RequeueParked makes them due again.
+//
+// The relay's decisions — what becomes of each claimed event once its
+// delivery was tried — are made by runOutboxBatch and planOutboxStep, which
+// do not touch the database; the relay applies the steps they return.
+package arangodb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	driver "github.com/arangodb/go-driver"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+	"github.com/aosanya/CodeValdSharedLib/eventbus"
+	"github.com/aosanya/CodeValdSharedLib/registrar"
+)
+
+// outboxIndexName is the persistent index serving the relay's due-event
+// lookups.
+const outboxIndexName = "entitygraph_outbox_due"
+
+// Defaults for the zero-valued fields of OutboxRelayConfig.
+const (
+	defaultOutboxBatchSize    = 100
+	defaultOutboxPollInterval = time.Second
+	defaultOutboxMinBackoff   = time.Second
+	defaultOutboxMaxBackoff   = 5 * time.Minute
+	defaultOutboxLease        = 30 * time.Second
+	defaultOutboxMaxAttempts  = 20
+)
+
+// outboxDoc is one pending event in the outbox collection. Times used by the
+// relay's queries are stored as Unix nanoseconds so that they compare and
+// sort as numbers.
+type outboxDoc struct {
+	Key       string    `json:"_key"`
+	AgencyID  string    `json:"agency_id"`
+	Topic     string    `json:"topic"`
+	Payload   string    `json:"payload"` // JSON of the entitygraph.EntityEvent
+	Timestamp time.Time `json:"timestamp"`
+	CreatedNS int64     `json:"created_ns"`
+	DueNS     int64     `json:"due_ns"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error,omitempty"`
+	Parked    bool      `json:"parked,omitempty"`
+}
+
+// ensureOutboxIndex creates the due_ns index on the outbox collection if it
+// does not exist yet.
+func ensureOutboxIndex(ctx context.Context, b *Backend) error {
+	_, _, err := b.outbox.EnsurePersistentIndex(ctx, []string{"due_ns"}, &driver.EnsurePersistentIndexOptions{
+		Name: outboxIndexName,
+	})
+	if err != nil {
+		return fmt.Errorf("ensureOutboxIndex: %w", err)
+	}
+	return nil
+}
+
+// publishes reports whether writes to entities of typeID raise outbox events:
+// the outbox is enabled and the type sets PublishEvents.
+func (b *Backend) publishes(typeID string) bool {
+	td, ok := b.typeDefs()[typeID]
+	return b.outbox != nil && ok && td.PublishEvents
+}
+
+// recordsChange reports whether a write to an entity of typeID records
+// anything besides the entity itself: a history revision or outbox events.
+func (b *Backend) recordsChange(typeID string) bool {
+	return b.hasHistory(typeID) || b.publishes(typeID)
+}
+
+// changeWrite returns the collections a write to entities of typeIDs must
+// declare to record the change: the history collection when any of the types
+// has History set, and the outbox when any of them publishes events.
+func (b *Backend) changeWrite(typeIDs ...string) []string {
+	var write []string
+	if b.historyWrite(typeIDs...) != nil {
+		write = append(write, b.historyName)
+	}
+	for _, typeID := range typeIDs {
+		if b.publishes(typeID) {
+			write = append(write, b.outboxName)
+			break
+		}
+	}
+	return write
+}
+
+// recordChange records the change left on doc, the entity document as written
+// including its new _rev, in its history and in the outbox. before is the
+// entity as it was before an update, nil for other changes. ctx must carry
+// the transaction of the write.
+func (b *Backend) recordChange(ctx context.Context, doc entityDoc, change entitygraph.ChangeKind, before *entitygraph.Entity) error {
+	if err := b.recordHistory(ctx, doc, change); err != nil {
+		return err
+	}
+	return b.recordEvents(ctx, doc, change, before)
+}
+
+// recordEvents inserts the lifecycle events of the change into the outbox
+// when the type of doc publishes events. ctx must carry the transaction of
+// the write.
+func (b *Backend) recordEvents(ctx context.Context, doc entityDoc, change entitygraph.ChangeKind, before *entitygraph.Entity) error {
+	if !b.publishes(doc.TypeID) {
+		return nil
+	}
+	events := entitygraph.LifecycleEvents(b.eventPrefix, b.typeDefs()[doc.TypeID], change, before, toEntity(doc, doc.Key), entitygraph.ActorFromContext(ctx))
+	for _, ev := range events {
+		payload := ev.Payload.(entitygraph.EntityEvent)
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("record event %s: %w", ev.Topic, err)
+		}
+		seq := b.nextOutboxSeq()
+		o := outboxDoc{
+			Key:       payload.ID,
+			AgencyID:  ev.AgencyID,
+			Topic:     ev.Topic,
+			Payload:   string(raw),
+			Timestamp: ev.Timestamp,
+			CreatedNS: seq,
+			DueNS:     seq,
+		}
+		if _, err := b.outbox.CreateDocument(ctx, o); err != nil {
+			return fmt.Errorf("record event %s: %w", ev.Topic, err)
+		}
+	}
+	return nil
+}
+
+// nextOutboxSeq returns the current time in Unix nanoseconds, bumped past the
+// previous value it returned so that the events of one write, and of
+// successive writes by this Backend, keep their order.
+func (b *Backend) nextOutboxSeq() int64 {
+	for {
+		last := b.outboxSeq.Load()
+		next := max(time.Now().UnixNano(), last+1)
+		if b.outboxSeq.CompareAndSwap(last, next) {
+			return next
+		}
+	}
+}
+
+// OutboxRelayConfig configures an [OutboxRelay]. Exactly one of Publisher and
+// Registrar must be set.
+type OutboxRelayConfig struct {
+	// Publisher receives each event with its entitygraph.EntityEvent payload.
+	// A non-nil error is a failed delivery and the event is retried, so the
+	// Publisher must report failures rather than log and swallow them.
+	Publisher eventbus.Publisher
+
+	// Registrar, used instead of Publisher, receives each event through
+	// registrar.PublishEnvelope: a structured-mode CloudEvents envelope whose
+	// ID is the EntityEvent ID and whose data is the JSON-encoded EntityEvent.
+	Registrar registrar.Registrar
+
+	// LegacyPayload makes Registrar deliveries send the bare JSON-encoded
+	// EntityEvent as payload instead of an envelope, for consumers that
+	// cannot yet read envelopes.
+	LegacyPayload bool
+
+	// Source names the producing service: the envelope source and the
+	// source passed to Registrar.Publish, and Event.Source on Publisher
+	// deliveries. Required with Registrar unless LegacyPayload is set.
+	Source string
+
+	// BatchSize is how many events one claim leases. Defaults to 100.
+	BatchSize int
+
+	// PollInterval is how long Run waits after draining the outbox before it
+	// looks for new events. Defaults to 1s.
+	PollInterval time.Duration
+
+	// MinBackoff and MaxBackoff bound the delay before a failed event is
+	// retried; it doubles with every failed attempt. Default to 1s and 5m.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// Lease is how long a claimed event is hidden from other relays while
+	// this one delivers it; a relay that dies mid-delivery releases its
+	// events when the lease expires. Defaults to 30s.
+	Lease time.Duration
+
+	// MaxAttempts is how many failed deliveries an event gets before it is
+	// parked. Defaults to 20.
+	MaxAttempts int
+}
+
+// OutboxStats reports the state of an outbox and of the relay draining it.
+type OutboxStats struct {
+	// Pending is the number of undelivered events, including those waiting
+	// for a retry and excluding parked ones.
+	Pending int
+
+	// Retrying is the number of pending events whose delivery has failed at
+	// least once.
+	Retrying int
+
+	// Parked is the number of events that used up MaxAttempts and are no
+	// longer delivered until RequeueParked.
+	Parked int
+
+	// Lag is the age of the oldest pending event; zero when none is
+	// pending.
+	Lag time.Duration
+
+	// Delivered is the number of events this relay has delivered.
+	Delivered uint64
+
+	// Failures is the number of failed delivery attempts by this relay.
+	Failures uint64
+
+	// LastDelivery is when this relay last delivered an event; zero if it
+	// has not yet.
+	LastDelivery time.Time
+}
+
+// OutboxRelay delivers the events of a Backend's outbox. Create it with
+// [Backend.NewOutboxRelay] and start Run in a goroutine; several relays, in
+// one process or many, may drain the same outbox.
+type OutboxRelay struct {
+	b            *Backend
+	cfg          OutboxRelayConfig
+	delivered    atomic.Uint64
+	failures     atomic.Uint64
+	lastDelivery atomic.Int64 // Unix nanoseconds
+}
+
+// NewOutboxRelay returns a relay for the outbox of b, filling in the defaults
+// of cfg. Returns an error if the outbox is not enabled (Config.OutboxCol) or
+// cfg does not set exactly one of Publisher and Registrar, or sets Registrar
+// without the Source its envelopes require.
+func (b *Backend) NewOutboxRelay(cfg OutboxRelayConfig) (*OutboxRelay, error) {
+	if b.outbox == nil {
+		return nil, errors.New("arangodb: NewOutboxRelay: outbox not enabled (Config.OutboxCol)")
+	}
+	if (cfg.Publisher == nil) == (cfg.Registrar == nil) {
+		return nil, errors.New("arangodb: NewOutboxRelay: exactly one of Publisher and Registrar must be set")
+	}
+	if cfg.Registrar != nil && !cfg.LegacyPayload && cfg.Source == "" {
+		return nil, errors.New("arangodb: NewOutboxRelay: Source must be set with Registrar")
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultOutboxBatchSize
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultOutboxPollInterval
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = defaultOutboxMinBackoff
+	}
+	if cfg.MaxBackoff < cfg.MinBackoff {
+		cfg.MaxBackoff = max(defaultOutboxMaxBackoff, cfg.MinBackoff)
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = defaultOutboxLease
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultOutboxMaxAttempts
+	}
+	return &OutboxRelay{b: b, cfg: cfg}, nil
+}
+
+// Run drains the outbox, then repeats every PollInterval until ctx is
+// cancelled. Must be called inside a goroutine. Errors are logged and do not
+// stop the loop.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.Drain(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("arangodb: outbox relay %s: %v", r.b.outboxName, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Drain delivers due events until none is left that this relay may claim,
+// and returns how many it delivered. Failed deliveries are scheduled for a
+// retry and are not errors; the error reports a failure to read or update
+// the outbox itself.
+func (r *OutboxRelay) Drain(ctx context.Context) (int, error) {
+	delivered := 0
+	for {
+		batch, err := r.claim(ctx)
+		if err != nil {
+			return delivered, fmt.Errorf("Drain: %w", err)
+		}
+		if len(batch) == 0 {
+			return delivered, nil
+		}
+		n, err := r.deliverBatch(ctx, batch)
+		delivered += n
+		if err != nil {
+			return delivered, fmt.Errorf("Drain: %w", err)
+		}
+	}
+}
+
+// claim leases up to BatchSize due events, oldest first, skipping parked
+// events and agencies that have an event leased or waiting for a retry.
+func (r *OutboxRelay) claim(ctx context.Context) ([]outboxDoc, error) {
+	now := time.Now().UnixNano()
+	q := fmt.Sprintf(`
+LET blocked = (FOR o IN %[1]s FILTER o.due_ns > @now AND o.parked != true RETURN DISTINCT o.agency_id)
+FOR o IN %[1]s
+  FILTER o.due_ns <= @now AND o.parked != true AND o.agency_id NOT IN blocked
+  SORT o.created_ns, o._key
+  LIMIT @limit
+  UPDATE o WITH { due_ns: @leaseUntil } IN %[1]s
+  RETURN NEW`, r.b.outboxName)
+	cursor, err := r.b.db.Query(ctx, q, map[string]interface{}{
+		"now":        now,
+		"limit":      r.cfg.BatchSize,
+		"leaseUntil": now + r.cfg.Lease.Nanoseconds(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("claim: %w", err)
+	}
+	defer cursor.Close()
+	var batch []outboxDoc
+	for cursor.HasMore() {
+		var o outboxDoc
+		if _, err := cursor.ReadDocument(ctx, &o); err != nil {
+			return nil, fmt.Errorf("claim: read: %w", err)
+		}
+		batch = append(batch, o)
+	}
+	return batch, nil
+}
+
+// deliverBatch delivers the claimed events in order, applying the step
+// runOutboxBatch plans for each as it goes, and returns how many were
+// delivered.
+func (r *OutboxRelay) deliverBatch(ctx context.Context, batch []outboxDoc) (int, error) {
+	delivered := 0
+	deliver := func(o outboxDoc) error { return r.deliver(ctx, o) }
+	err := runOutboxBatch(r.cfg, batch, time.Now, deliver, func(o outboxDoc, step outboxStep) error {
+		if step.failed {
+			r.failures.Add(1)
+		}
+		if !step.remove {
+			if _, err := r.b.outbox.UpdateDocument(ctx, o.Key, step.patch); err != nil && !driver.IsNotFound(err) {
+				return fmt.Errorf("reschedule %s: %w", o.Key, err)
+			}
+			return nil
+		}
+		if _, err := r.b.outbox.RemoveDocument(ctx, o.Key); err != nil && !driver.IsNotFound(err) {
+			return fmt.Errorf("remove %s: %w", o.Key, err)
+		}
+		delivered++
+		r.delivered.Add(1)
+		r.lastDelivery.Store(time.Now().UnixNano())
+		return nil
+	})
+	return delivered, err
+}
+
+// outboxStep is what becomes of one claimed event once its delivery was
+// tried or held back.
+type outboxStep struct {
+	// remove is set when the event was delivered and leaves the outbox.
+	remove bool
+
+	// patch updates an event that stays: due_ns releases or reschedules
+	// it, attempts and last_error record a failure, and parked parks it.
+	patch map[string]any
+
+	// failed is set when the delivery was tried and failed.
+	failed bool
+
+	// holds is set when the agency's later events in the batch must wait
+	// for this one.
+	holds bool
+}
+
+// runOutboxBatch tries the claimed batch in order through deliver and hands
+// the step planOutboxStep plans for each event to apply, stopping at the
+// first error apply returns. Once an event holds its agency, the agency's
+// remaining events in the batch are released undelivered, to be claimed
+// once the held one has gone through or been parked.
+func runOutboxBatch(cfg OutboxRelayConfig, batch []outboxDoc, now func() time.Time, deliver func(outboxDoc) error, apply func(outboxDoc, outboxStep) error) error {
+	held := make(map[string]bool)
+	for _, o := range batch {
+		waiting := held[o.AgencyID]
+		var err error
+		if !waiting {
+			err = deliver(o)
+		}
+		step := planOutboxStep(cfg, o, waiting, err, now())
+		if step.holds {
+			held[o.AgencyID] = true
+		}
+		if err := apply(o, step); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// planOutboxStep plans the step for claimed event o at now. waiting reports
+// that an earlier event of its agency holds it back, in which case o was not
+// tried and is released as due now; otherwise deliverErr is the result of
+// delivering it. A failed event is rescheduled after the backoff of its
+// attempts, holding its agency, or parked without holding it once it has
+// failed cfg.MaxAttempts times.
+func planOutboxStep(cfg OutboxRelayConfig, o outboxDoc, waiting bool, deliverErr error, now time.Time) outboxStep {
+	switch {
+	case waiting:
+		return outboxStep{patch: map[string]any{"due_ns": now.UnixNano()}, holds: true}
+	case deliverErr == nil:
+		return outboxStep{remove: true}
+	}
+	attempts := o.Attempts + 1
+	patch := map[string]any{"attempts": attempts, "last_error": deliverErr.Error()}
+	if attempts >= cfg.MaxAttempts {
+		patch["parked"] = true
+		return outboxStep{patch: patch, failed: true}
+	}
+	patch["due_ns"] = now.Add(outboxBackoff(cfg, attempts)).UnixNano()
+	return outboxStep{patch: patch, failed: true, holds: true}
+}
+
+// deliver hands one event to the configured Publisher, or to the Registrar
+// as an envelope unless LegacyPayload is set.
+func (r *OutboxRelay) deliver(ctx context.Context, o outboxDoc) error {
+	if r.cfg.Registrar != nil {
+		if r.cfg.LegacyPayload {
+			return r.cfg.Registrar.Publish(ctx, o.AgencyID, o.Topic, r.cfg.Source, o.Payload)
+		}
+		env, err := eventbus.EnvelopeFromEvent(eventbus.Event{
+			ID:        o.Key,
+			Topic:     o.Topic,
+			AgencyID:  o.AgencyID,
+			Timestamp: o.Timestamp,
+			Payload:   json.RawMessage(o.Payload),
+		}, r.cfg.Source, nil)
+		if err != nil {
+			return err
+		}
+		return registrar.PublishEnvelope(ctx, r.cfg.Registrar, env)
+	}
+	var payload entitygraph.EntityEvent
+	if err := json.Unmarshal([]byte(o.Payload), &payload); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+	return r.cfg.Publisher.Publish(ctx, eventbus.Event{
+		ID:        payload.ID,
+		Topic:     o.Topic,
+		AgencyID:  o.AgencyID,
+		Timestamp: o.Timestamp,
+		Payload:   payload,
+		Source:    r.cfg.Source,
+	})
+}
+
+// outboxBackoff returns the delay before the retry following the given
+// number of failed attempts: cfg.MinBackoff doubled per earlier failure,
+// capped at cfg.MaxBackoff.
+func outboxBackoff(cfg OutboxRelayConfig, attempts int) time.Duration {
+	d := cfg.MinBackoff
+	for i := 1; i < attempts && d < cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, cfg.MaxBackoff)
+}
+
+// RequeueParked makes every parked event due again with its failed attempts
+// reset, and returns how many it requeued. Each goes back to its place in
+// its agency's order, ahead of the events written after it.
+func (r *OutboxRelay) RequeueParked(ctx context.Context) (int, error) {
+	q := fmt.Sprintf(`RETURN LENGTH(
+  FOR o IN %[1]s
+    FILTER o.parked == true
+    UPDATE o WITH { parked: false, attempts: 0, due_ns: @now } IN %[1]s
+    RETURN 1
+)`, r.b.outboxName)
+	cursor, err := r.b.db.Query(ctx, q, map[string]interface{}{"now": time.Now().UnixNano()})
+	if err != nil {
+		return 0, fmt.Errorf("RequeueParked: %w", err)
+	}
+	defer cursor.Close()
+	var n int
+	if _, err := cursor.ReadDocument(ctx, &n); err != nil {
+		return 0, fmt.Errorf("RequeueParked: read: %w", err)
+	}
+	return n, nil
+}
+
+// Stats returns the outbox backlog together with this relay's delivery
+// counters.
+func (r *OutboxRelay) Stats(ctx context.Context) (OutboxStats, error) {
+	q := fmt.Sprintf(`RETURN {
+  pending: LENGTH(FOR o IN %[1]s FILTER o.parked != true RETURN 1),
+  retrying: LENGTH(FOR o IN %[1]s FILTER o.parked != true AND o.attempts > 0 RETURN 1),
+  parked: LENGTH(FOR o IN %[1]s FILTER o.parked == true RETURN 1),
+  oldest: FIRST(FOR o IN %[1]s FILTER o.parked != true SORT o.created_ns LIMIT 1 RETURN o.created_ns)
+}`, r.b.outboxName)
+	cursor, err := r.b.db.Query(ctx, q, nil)
+	if err != nil {
+		return OutboxStats{}, fmt.Errorf("Stats: %w", err)
+	}
+	defer cursor.Close()
+	var row struct {
+		Pending  int    `json:"pending"`
+		Retrying int    `json:"retrying"`
+		Parked   int    `json:"parked"`
+		Oldest   *int64 `json:"oldest"`
+	}
+	if _, err := cursor.ReadDocument(ctx, &row); err != nil {
+		return OutboxStats{}, fmt.Errorf("Stats: read: %w", err)
+	}
+	stats := OutboxStats{
+		Pending:   row.Pending,
+		Retrying:  row.Retrying,
+		Parked:    row.Parked,
+		Delivered: r.delivered.Load(),
+		Failures:  r.failures.Load(),
+	}
+	if row.Oldest != nil {
+		stats.Lag = time.Since(time.Unix(0, *row.Oldest))
+	}
+	if ns := r.lastDelivery.Load(); ns != 0 {
+		stats.LastDelivery = time.Unix(0, ns)
+	}
+	return stats, nil
+}
@@ -0,0 +1,160 @@
+package arangodb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aosanya/CodeValdSharedLib/envelope"
+	"github.com/aosanya/CodeValdSharedLib/registrar"
+)
+
+// The relay's decisions and its encoding of deliveries are tested here,
+// inside the package, because they run without a database; outbox_test.go
+// covers the relay against ArangoDB.
+
+var planNow = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func planConfig() OutboxRelayConfig {
+	return OutboxRelayConfig{MinBackoff: time.Second, MaxBackoff: 4 * time.Second, MaxAttempts: 3}
+}
+
+// planRun runs batch through runOutboxBatch with deliveries of the keys in
+// failing failing, and returns the keys tried and the step of each event.
+func planRun(t *testing.T, cfg OutboxRelayConfig, batch []outboxDoc, failing ...string) ([]string, map[string]outboxStep) {
+	t.Helper()
+	var tried []string
+	steps := make(map[string]outboxStep)
+	deliver := func(o outboxDoc) error {
+		tried = append(tried, o.Key)
+		if slices.Contains(failing, o.Key) {
+			return errors.New("bus down")
+		}
+		return nil
+	}
+	apply := func(o outboxDoc, step outboxStep) error {
+		steps[o.Key] = step
+		return nil
+	}
+	if err := runOutboxBatch(cfg, batch, func() time.Time { return planNow }, deliver, apply); err != nil {
+		t.Fatalf("runOutboxBatch: %v", err)
+	}
+	return tried, steps
+}
+
+func TestRunOutboxBatch_FailureHoldsOnlyItsAgency(t *testing.T) {
+	batch := []outboxDoc{
+		{Key: "a1", AgencyID: "a"},
+		{Key: "b1", AgencyID: "b"},
+		{Key: "a2", AgencyID: "a"},
+		{Key: "b2", AgencyID: "b"},
+	}
+	tried, steps := planRun(t, planConfig(), batch, "a1")
+	if want := []string{"a1", "b1", "b2"}; !slices.Equal(tried, want) {
+		t.Errorf("tried %v, want %v", tried, want)
+	}
+	if s := steps["a1"]; !s.failed || s.remove || s.patch["attempts"] != 1 || s.patch["last_error"] != "bus down" {
+		t.Errorf("a1 step = %+v, want a recorded failure", s)
+	}
+	if due := steps["a1"].patch["due_ns"]; due != planNow.Add(time.Second).UnixNano() {
+		t.Errorf("a1 due_ns = %v, want now + MinBackoff", due)
+	}
+	if s := steps["a2"]; s.failed || s.remove || s.patch["due_ns"] != planNow.UnixNano() || len(s.patch) != 1 {
+		t.Errorf("a2 step = %+v, want released as due now", s)
+	}
+	for _, key := range []string{"b1", "b2"} {
+		if !steps[key].remove {
+			t.Errorf("%s step = %+v, want removed", key, steps[key])
+		}
+	}
+}
+
+func TestRunOutboxBatch_ParkedEventReleasesItsAgency(t *testing.T) {
+	batch := []outboxDoc{
+		{Key: "a1", AgencyID: "a", Attempts: 2},
+		{Key: "a2", AgencyID: "a"},
+	}
+	tried, steps := planRun(t, planConfig(), batch, "a1")
+	if want := []string{"a1", "a2"}; !slices.Equal(tried, want) {
+		t.Errorf("tried %v, want %v", tried, want)
+	}
+	s := steps["a1"]
+	if !s.failed || s.holds || s.patch["parked"] != true || s.patch["attempts"] != 3 {
+		t.Errorf("a1 step = %+v, want parked after its third failure", s)
+	}
+	if _, ok := s.patch["due_ns"]; ok {
+		t.Errorf("a1 step = %+v, want a parked event left unscheduled", s)
+	}
+	if !steps["a2"].remove {
+		t.Errorf("a2 step = %+v, want delivered past the parked event", steps["a2"])
+	}
+}
+
+func TestRunOutboxBatch_StopsAtApplyError(t *testing.T) {
+	batch := []outboxDoc{{Key: "a1", AgencyID: "a"}, {Key: "a2", AgencyID: "a"}}
+	var tried []string
+	deliver := func(o outboxDoc) error { tried = append(tried, o.Key); return nil }
+	apply := func(outboxDoc, outboxStep) error { return errors.New("write failed") }
+	if err := runOutboxBatch(planConfig(), batch, time.Now, deliver, apply); err == nil {
+		t.Fatal("runOutboxBatch succeeded, want the apply error")
+	}
+	if want := []string{"a1"}; !slices.Equal(tried, want) {
+		t.Errorf("tried %v, want %v", tried, want)
+	}
+}
+
+func TestOutboxBackoff_DoublesUpToMax(t *testing.T) {
+	cfg := planConfig()
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 10: 4 * time.Second} {
+		if got := outboxBackoff(cfg, attempts); got != want {
+			t.Errorf("outboxBackoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+// recordingRegistrar records Publish calls; every other Registrar method
+// panics.
+type recordingRegistrar struct {
+	registrar.Registrar
+	payloads []string
+}
+
+func (r *recordingRegistrar) Publish(_ context.Context, _, _, _, payload string) error {
+	r.payloads = append(r.payloads, payload)
+	return nil
+}
+
+func TestOutboxRelayDeliver_RegistrarSendsEnvelope(t *testing.T) {
+	o := outboxDoc{
+		Key: "ev-1", AgencyID: "a", Topic: "work.task.created",
+		Payload: `{"id":"ev-1"}`, Timestamp: planNow,
+	}
+	reg := &recordingRegistrar{}
+	relay := &OutboxRelay{cfg: OutboxRelayConfig{Registrar: reg, Source: "codevaldwork"}}
+	if err := relay.deliver(context.Background(), o); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if len(reg.payloads) != 1 {
+		t.Fatalf("got %d publishes, want 1", len(reg.payloads))
+	}
+	var env envelope.Envelope
+	if err := json.Unmarshal([]byte(reg.payloads[0]), &env); err != nil {
+		t.Fatalf("payload is not an envelope: %v", err)
+	}
+	if env.ID != "ev-1" || env.Source != "codevaldwork" || env.Type != o.Topic || env.AgencyID != "a" ||
+		!env.Time.Equal(planNow) || string(env.Data) != o.Payload {
+		t.Errorf("envelope = %+v", env)
+	}
+
+	legacy := &recordingRegistrar{}
+	relay = &OutboxRelay{cfg: OutboxRelayConfig{Registrar: legacy, LegacyPayload: true}}
+	if err := relay.deliver(context.Background(), o); err != nil {
+		t.Fatalf("deliver (legacy): %v", err)
+	}
+	if len(legacy.payloads) != 1 || legacy.payloads[0] != o.Payload {
+		t.Errorf("legacy payloads = %q, want the bare event", legacy.payloads)
+	}
+}
@@ -0,0 +1,220 @@
+package arangodb_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+	"github.com/aosanya/CodeValdSharedLib/entitygraph/arangodb"
+	"github.com/aosanya/CodeValdSharedLib/entitygraph/conformance"
+	"github.com/aosanya/CodeValdSharedLib/eventbus"
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+// withOutbox enables the outbox under the "test" event prefix.
+func withOutbox(prefix string, cfg *arangodb.Config) {
+	cfg.OutboxCol = prefix + "_outbox"
+	cfg.EventPrefix = "test"
+}
+
+// publishingSchema returns s with PublishEvents set on every type.
+func publishingSchema(s types.Schema) types.Schema {
+	out := s
+	out.Types = make([]types.TypeDefinition, len(s.Types))
+	for i, td := range s.Types {
+		td.PublishEvents = true
+		out.Types[i] = td
+	}
+	return out
+}
+
+// TestConformance_DataManager_Outbox runs the suite with every write also
+// recording outbox events.
+func TestConformance_DataManager_Outbox(t *testing.T) {
+	db := testDB(t)
+	conformance.RunDataManagerSuite(t, func(t *testing.T, s types.Schema) entitygraph.DataManager {
+		return newTestBackend(t, db, publishingSchema(s), withOutbox)
+	})
+}
+
+// flakyPublisher records events and fails while failing is set.
+type flakyPublisher struct {
+	mu      sync.Mutex
+	failing bool
+	topics  []string
+}
+
+func (p *flakyPublisher) Publish(_ context.Context, e eventbus.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failing {
+		return errors.New("bus down")
+	}
+	if _, ok := e.Payload.(entitygraph.EntityEvent); !ok {
+		return errors.New("unexpected payload type")
+	}
+	p.topics = append(p.topics, e.Topic)
+	return nil
+}
+
+func (p *flakyPublisher) setFailing(failing bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failing = failing
+}
+
+func TestOutbox_RelayDeliversInOrderWithRetry(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	schema := types.Schema{Types: []types.TypeDefinition{{
+		Name:          "Task",
+		PublishEvents: true,
+		Properties:    []types.PropertyDefinition{{Name: "status", Type: types.PropertyTypeString}},
+	}}}
+	b := newTestBackend(t, db, schema, withOutbox)
+	pub := &flakyPublisher{failing: true}
+	relay, err := b.NewOutboxRelay(arangodb.OutboxRelayConfig{Publisher: pub, MinBackoff: 50 * time.Millisecond, MaxBackoff: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewOutboxRelay: %v", err)
+	}
+
+	task, err := b.CreateEntity(ctx, entitygraph.CreateEntityRequest{AgencyID: "ag", TypeID: "Task"})
+	if err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+	if _, err := b.UpdateEntity(ctx, "ag", task.ID, entitygraph.UpdateEntityRequest{Properties: map[string]any{"status": "done"}}); err != nil {
+		t.Fatalf("UpdateEntity: %v", err)
+	}
+	if err := b.DeleteEntity(ctx, "ag", task.ID); err != nil {
+		t.Fatalf("DeleteEntity: %v", err)
+	}
+
+	if n, err := relay.Drain(ctx); err != nil || n != 0 {
+		t.Fatalf("Drain while failing = %d, %v; want 0, nil", n, err)
+	}
+	stats, err := relay.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.Pending != 4 || stats.Retrying != 1 || stats.Failures != 1 || stats.Lag <= 0 {
+		t.Errorf("stats while failing = %+v, want 4 pending, 1 retrying, 1 failure", stats)
+	}
+
+	pub.setFailing(false)
+	time.Sleep(60 * time.Millisecond)
+	if n, err := relay.Drain(ctx); err != nil || n != 4 {
+		t.Fatalf("Drain = %d, %v; want 4, nil", n, err)
+	}
+	want := []string{"test.task.created", "test.task.updated", "test.task.update.status", "test.task.deleted"}
+	if !slices.Equal(pub.topics, want) {
+		t.Errorf("delivered %v, want %v", pub.topics, want)
+	}
+	if stats, _ := relay.Stats(ctx); stats.Pending != 0 || stats.Delivered != 4 || stats.Lag != 0 {
+		t.Errorf("stats after drain = %+v, want empty with 4 delivered", stats)
+	}
+}
+
+// poisonPublisher records events and rejects those of topic while poisoned.
+type poisonPublisher struct {
+	flakyPublisher
+	topic    string
+	poisoned bool
+}
+
+func (p *poisonPublisher) Publish(ctx context.Context, e eventbus.Event) error {
+	p.mu.Lock()
+	poisoned := p.poisoned && e.Topic == p.topic
+	p.mu.Unlock()
+	if poisoned {
+		return errors.New("rejected")
+	}
+	return p.flakyPublisher.Publish(ctx, e)
+}
+
+func TestOutbox_ParkedEventStopsBlockingItsAgency(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	schema := types.Schema{Types: []types.TypeDefinition{{
+		Name:          "Task",
+		PublishEvents: true,
+		Properties:    []types.PropertyDefinition{{Name: "status", Type: types.PropertyTypeString}},
+	}}}
+	b := newTestBackend(t, db, schema, withOutbox)
+	pub := &poisonPublisher{topic: "test.task.created", poisoned: true}
+	relay, err := b.NewOutboxRelay(arangodb.OutboxRelayConfig{Publisher: pub, MaxAttempts: 1})
+	if err != nil {
+		t.Fatalf("NewOutboxRelay: %v", err)
+	}
+	task, err := b.CreateEntity(ctx, entitygraph.CreateEntityRequest{AgencyID: "ag", TypeID: "Task"})
+	if err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+	if err := b.DeleteEntity(ctx, "ag", task.ID); err != nil {
+		t.Fatalf("DeleteEntity: %v", err)
+	}
+
+	if n, err := relay.Drain(ctx); err != nil || n != 1 {
+		t.Fatalf("Drain with a poison event = %d, %v; want 1, nil", n, err)
+	}
+	if want := []string{"test.task.deleted"}; !slices.Equal(pub.topics, want) {
+		t.Errorf("delivered %v, want %v past the parked event", pub.topics, want)
+	}
+	if stats, err := relay.Stats(ctx); err != nil || stats.Parked != 1 || stats.Pending != 0 || stats.Failures != 1 {
+		t.Errorf("Stats = %+v, %v; want 1 parked, none pending, 1 failure", stats, err)
+	}
+
+	pub.mu.Lock()
+	pub.poisoned = false
+	pub.mu.Unlock()
+	if n, err := relay.RequeueParked(ctx); err != nil || n != 1 {
+		t.Fatalf("RequeueParked = %d, %v; want 1, nil", n, err)
+	}
+	if n, err := relay.Drain(ctx); err != nil || n != 1 {
+		t.Fatalf("Drain after requeue = %d, %v; want 1, nil", n, err)
+	}
+	if stats, err := relay.Stats(ctx); err != nil || stats.Parked != 0 || stats.Pending != 0 {
+		t.Errorf("Stats after requeue = %+v, %v; want an empty outbox", stats, err)
+	}
+}
+
+func TestOutbox_RolledBackWriteLeavesNoEvent(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	schema := types.Schema{Types: []types.TypeDefinition{{
+		Name:          "Task",
+		PublishEvents: true,
+		UniqueKey:     []string{"code"},
+		Properties:    []types.PropertyDefinition{{Name: "code", Type: types.PropertyTypeString}},
+	}}}
+	b := newTestBackend(t, db, schema, withOutbox)
+	relay, err := b.NewOutboxRelay(arangodb.OutboxRelayConfig{Publisher: &flakyPublisher{}})
+	if err != nil {
+		t.Fatalf("NewOutboxRelay: %v", err)
+	}
+	_, err = b.Batch(ctx, "ag", []entitygraph.BatchOp{
+		{Kind: entitygraph.BatchCreateEntity, Entity: entitygraph.CreateEntityRequest{TypeID: "Task", Properties: map[string]any{"code": "A"}}},
+		{Kind: entitygraph.BatchCreateEntity, Entity: entitygraph.CreateEntityRequest{TypeID: "Task", Properties: map[string]any{"code": "A"}}},
+	})
+	if !errors.Is(err, entitygraph.ErrEntityAlreadyExists) {
+		t.Fatalf("Batch: got %v, want ErrEntityAlreadyExists", err)
+	}
+	if stats, err := relay.Stats(ctx); err != nil || stats.Pending != 0 {
+		t.Errorf("Stats = %+v, %v; want no pending events", stats, err)
+	}
+}
+
+func TestOutbox_NewOutboxRelay_RequiresOutboxAndOneSink(t *testing.T) {
+	db := testDB(t)
+	plain := newTestBackend(t, db, types.Schema{})
+	if _, err := plain.NewOutboxRelay(arangodb.OutboxRelayConfig{Publisher: &flakyPublisher{}}); err == nil {
+		t.Error("NewOutboxRelay without an outbox succeeded")
+	}
+	b := newTestBackend(t, db, types.Schema{}, withOutbox)
+	if _, err := b.NewOutboxRelay(arangodb.OutboxRelayConfig{}); err == nil {
+		t.Error("NewOutboxRelay without a sink succeeded")
+	}
+}
@@ -0,0 +1,156 @@
+// paging.go contains the AQL building blocks shared by ListEntitiesPage and
+// ListRelationshipsPage: sort expressions, the keyset filter that resumes
+// after a page token, and match counting.
+package arangodb
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+
+	driver "github.com/arangodb/go-driver"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+)
+
+// pageRow is one row of a paged query: the document and its sort key values.
+type pageRow[T any] struct {
+	Doc  T     `json:"doc"`
+	Keys []any `json:"keys"`
+}
+
+// sortExpr returns the AQL expression for sort key o of the document bound
+// to doc. Timestamps are compared as Unix milliseconds, matching
+// [entitygraph.EntitySortKeys].
+func sortExpr(o entitygraph.OrderBy) string {
+	switch o.Field {
+	case entitygraph.OrderByCreatedAt, entitygraph.OrderByUpdatedAt:
+		return fmt.Sprintf("DATE_TIMESTAMP(doc.%s)", o.Field)
+	}
+	name, _ := entitygraph.PropertyOrderField(o.Field)
+	return fmt.Sprintf("doc.properties.`%s`", name)
+}
+
+// keysetFilter returns an AQL condition selecting rows strictly after cursor
+// in order, binding the cursor values into bindVars.
+func keysetFilter(order []entitygraph.OrderBy, cursor *entitygraph.PageCursor, bindVars map[string]any) string {
+	var alts []string
+	var equal []string
+	for i, o := range order {
+		param := fmt.Sprintf("ck%d", i)
+		bindVars[param] = cursor.Keys[i]
+		op := ">"
+		if o.Desc {
+			op = "<"
+		}
+		alts = append(alts, "("+strings.Join(append(equal, fmt.Sprintf("keys[%d] %s @%s", i, op, param)), " AND ")+")")
+		equal = append(equal, fmt.Sprintf("keys[%d] == @%s", i, param))
+	}
+	bindVars["cid"] = cursor.ID
+	alts = append(alts, "("+strings.Join(append(equal, "doc._key > @cid"), " AND ")+")")
+	return strings.Join(alts, " OR ")
+}
+
+// queryPage runs loop — a "FOR doc IN … FILTER …" prefix using bindVars —
+// sorted by order with _key as tie-breaker, resuming after token and cut to
+// limit rows. It returns the documents and the token for the next page.
+func queryPage[T any](
+	ctx context.Context,
+	db driver.Database,
+	loop string,
+	bindVars map[string]any,
+	order []entitygraph.OrderBy,
+	limit int,
+	token string,
+	keyOf func(T) string,
+) ([]T, string, error) {
+	cursor, err := entitygraph.DecodePageToken(token, order)
+	if err != nil {
+		return nil, "", err
+	}
+	vars := maps.Clone(bindVars)
+	if vars == nil {
+		vars = map[string]any{}
+	}
+	exprs := make([]string, len(order))
+	sorts := make([]string, 0, len(order)+1)
+	for i, o := range order {
+		exprs[i] = sortExpr(o)
+		dir := "ASC"
+		if o.Desc {
+			dir = "DESC"
+		}
+		sorts = append(sorts, fmt.Sprintf("keys[%d] %s", i, dir))
+	}
+	sorts = append(sorts, "doc._key ASC")
+
+	var q strings.Builder
+	q.WriteString(loop)
+	fmt.Fprintf(&q, " LET keys = [%s]", strings.Join(exprs, ", "))
+	if cursor != nil {
+		q.WriteString(" FILTER " + keysetFilter(order, cursor, vars))
+	}
+	q.WriteString(" SORT " + strings.Join(sorts, ", "))
+	if limit > 0 {
+		// One extra row tells us whether another page follows.
+		q.WriteString(" LIMIT @pageLimit")
+		vars["pageLimit"] = limit + 1
+	}
+	q.WriteString(" RETURN { doc: doc, keys: keys }")
+
+	cur, err := db.Query(ctx, q.String(), vars)
+	if err != nil {
+		return nil, "", fmt.Errorf("query: %w", err)
+	}
+	defer cur.Close()
+	var rows []pageRow[T]
+	for cur.HasMore() {
+		var row pageRow[T]
+		if _, err := cur.ReadDocument(ctx, &row); err != nil {
+			return nil, "", fmt.Errorf("read: %w", err)
+		}
+		rows = append(rows, row)
+	}
+	next := ""
+	if limit > 0 && len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[limit-1]
+		next = entitygraph.EncodePageToken(order, last.Keys, keyOf(last.Doc))
+	}
+	docs := make([]T, len(rows))
+	for i, row := range rows {
+		docs[i] = row.Doc
+	}
+	return docs, next, nil
+}
+
+// countMatches returns the number of documents produced by loop.
+func countMatches(ctx context.Context, db driver.Database, loop string, bindVars map[string]any) (int, error) {
+	cur, err := db.Query(ctx, loop+" COLLECT WITH COUNT INTO n RETURN n", bindVars)
+	if err != nil {
+		return 0, fmt.Errorf("count: %w", err)
+	}
+	defer cur.Close()
+	var n int
+	if cur.HasMore() {
+		if _, err := cur.ReadDocument(ctx, &n); err != nil {
+			return 0, fmt.Errorf("count: read: %w", err)
+		}
+	}
+	return n, nil
+}
+
+// entityLoop returns the "FOR doc IN … FILTER where" prefix over cols. Several
+// collections are combined with UNION, each filtered on its own so indexes
+// still apply.
+func entityLoop(cols []driver.Collection, where string) string {
+	if len(cols) == 1 {
+		return fmt.Sprintf("FOR doc IN %s FILTER %s", cols[0].Name(), where)
+	}
+	subs := make([]string, len(cols))
+	for i, col := range cols {
+		subs[i] = fmt.Sprintf("(FOR doc IN %s FILTER %s RETURN doc)", col.Name(), where)
+	}
+	return fmt.Sprintf("FOR doc IN UNION(%s)", strings.Join(subs, ", "))
+}
@@ -1,9 +1,11 @@
 // relationships.go contains CreateRelationship, GetRelationship,
-// DeleteRelationship, ListRelationships, and TraverseGraph for the Backend.
+// DeleteRelationship, ListRelationships, ListRelationshipsPage, and
+// TraverseGraph for the Backend.
 package arangodb
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -12,6 +14,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+	"github.com/aosanya/CodeValdSharedLib/types"
 )
 
 // relationshipDoc is the ArangoDB edge-document representation of an
@@ -24,61 +27,360 @@ type relationshipDoc struct {
 	Name       string         `json:"name"`
 	AgencyID   string         `json:"agency_id"`
 	Properties map[string]any `json:"properties"`
-	CreatedAt  time.Time      `json:"created_at"`
+	Derived    bool           `json:"derived,omitempty"`
+	PairID     string         `json:"pair_id,omitempty"`
+	// FunctionalName repeats Name on edges of a ToMany=false relationship and
+	// is empty otherwise. It backs the sparse unique index that keeps one such
+	// edge per source and label (see ensureFunctionalEdgeIndex).
+	FunctionalName string    `json:"functional_name,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
 }
 
 // entityHandle returns the ArangoDB document handle for an entity ID, e.g.
 // "ai_entities/<id>". It searches every entity collection derived from the
 // schema so that the correct collection prefix is used in edge documents.
 func (b *Backend) entityHandle(ctx context.Context, agencyID, entityID string) (string, error) {
+	handle, _, err := b.resolveEntity(ctx, agencyID, entityID)
+	return handle, err
+}
+
+// resolveEntity is like entityHandle but also returns the stored document, so
+// callers can inspect the entity's TypeID.
+func (b *Backend) resolveEntity(ctx context.Context, agencyID, entityID string) (string, entityDoc, error) {
 	for _, col := range b.allEntityCollections() {
 		var doc entityDoc
 		if _, err := col.ReadDocument(ctx, entityID, &doc); err == nil {
 			if doc.AgencyID == agencyID {
-				return col.Name() + "/" + entityID, nil
+				return col.Name() + "/" + entityID, doc, nil
 			}
 		} else if !driver.IsNotFound(err) {
-			return "", err
+			return "", entityDoc{}, err
 		}
 	}
-	return "", fmt.Errorf("entityHandle %s: %w", entityID, entitygraph.ErrEntityNotFound)
+	return "", entityDoc{}, fmt.Errorf("entityHandle %s: %w", entityID, entitygraph.ErrEntityNotFound)
+}
+
+// resolveLiveEntity is like resolveEntity but treats a soft-deleted entity as
+// missing, so that no edge is written to or from a tombstone.
+func (b *Backend) resolveLiveEntity(ctx context.Context, agencyID, entityID string) (string, entityDoc, error) {
+	handle, doc, err := b.resolveEntity(ctx, agencyID, entityID)
+	if err == nil && doc.Deleted {
+		return "", entityDoc{}, fmt.Errorf("entityHandle %s: deleted: %w", entityID, entitygraph.ErrEntityNotFound)
+	}
+	return handle, doc, err
+}
+
+// relationshipDef resolves the RelationshipDefinition for an edge labelled
+// name from an entity of fromTypeID to one of toTypeID. Returns
+// [entitygraph.ErrInvalidRelationship] if the source type is not in the
+// schema, does not declare name, or declares it with a different ToType.
+func (b *Backend) relationshipDef(fromTypeID, name, toTypeID string) (types.RelationshipDefinition, error) {
+	td, ok := b.typeDefs()[fromTypeID]
+	if !ok {
+		return types.RelationshipDefinition{}, fmt.Errorf("type %q not in schema: %w", fromTypeID, entitygraph.ErrInvalidRelationship)
+	}
+	if err := entitygraph.ValidateCreateRelationship(td, name, toTypeID); err != nil {
+		return types.RelationshipDefinition{}, fmt.Errorf("%q from %q to %q: %w", name, fromTypeID, toTypeID, err)
+	}
+	return entitygraph.FindRelationshipDef(td, name)
 }
 
 // CreateRelationship creates a directed edge in the relationships collection.
+//
+// The edge is validated against the source entity's TypeDefinition via
+// [entitygraph.ValidateCreateRelationship]. For ToMany=true relationships a
+// new edge is always inserted. For ToMany=false relationships the write is an
+// AQL UPSERT keyed on (_from, name): an existing edge is re-pointed at the new
+// target and its properties replaced, keeping its ID. When the definition
+// declares an Inverse, or a replacement may orphan an existing pair, the write
+// runs in a stream transaction (see writeLinkedEdge). Two concurrent writes of
+// the same functional edge may both miss the UPSERT lookup; the unique index on
+// functional edges rejects the second insert, and the write is retried, up to
+// maxWriteAttempts times, re-pointing the edge that won.
+//
 // Returns entitygraph.ErrEntityNotFound if the FromID or ToID entity does not exist.
+// Returns entitygraph.ErrInvalidRelationship if the edge is not declared by the schema.
 func (b *Backend) CreateRelationship(
 	ctx context.Context,
 	req entitygraph.CreateRelationshipRequest,
 ) (entitygraph.Relationship, error) {
-	fromHandle, err := b.entityHandle(ctx, req.AgencyID, req.FromID)
+	fromHandle, fromDoc, err := b.resolveLiveEntity(ctx, req.AgencyID, req.FromID)
 	if err != nil {
 		return entitygraph.Relationship{}, fmt.Errorf("CreateRelationship from: %w", err)
 	}
-	toHandle, err := b.entityHandle(ctx, req.AgencyID, req.ToID)
+	toHandle, toDoc, err := b.resolveLiveEntity(ctx, req.AgencyID, req.ToID)
 	if err != nil {
 		return entitygraph.Relationship{}, fmt.Errorf("CreateRelationship to: %w", err)
 	}
-	now := time.Now().UTC()
-	id := uuid.NewString()
+	rd, err := b.relationshipDef(fromDoc.TypeID, req.Name, toDoc.TypeID)
+	if err != nil {
+		return entitygraph.Relationship{}, fmt.Errorf("CreateRelationship: %w", err)
+	}
 	doc := relationshipDoc{
-		Key:        id,
+		Key:        uuid.NewString(),
 		From:       fromHandle,
 		To:         toHandle,
 		Name:       req.Name,
 		AgencyID:   req.AgencyID,
 		Properties: req.Properties,
-		CreatedAt:  now,
+		CreatedAt:  time.Now().UTC(),
 	}
 	if doc.Properties == nil {
 		doc.Properties = make(map[string]any)
 	}
+	var stored relationshipDoc
+	write := func(ctx context.Context) error {
+		var err error
+		stored, err = b.writeLinkedEdge(ctx, doc, rd, false)
+		return err
+	}
+	for attempt := 1; ; attempt++ {
+		if rd.ToMany && rd.Inverse == "" {
+			err = write(ctx)
+		} else {
+			err = b.withTransaction(ctx, []string{b.relCollectionName}, write)
+		}
+		if !errors.Is(err, entitygraph.ErrConflict) || attempt >= maxWriteAttempts {
+			break
+		}
+	}
+	if err != nil {
+		return entitygraph.Relationship{}, fmt.Errorf("CreateRelationship: %w", err)
+	}
+	return toRelationship(stored, stored.Key), nil
+}
+
+// writeLinkedEdge stores the primary edge doc of relationship rd via writeEdge
+// and keeps its inverse pair consistent:
+//
+//   - Re-asserting an edge that already exists with the same target leaves
+//     its pairing untouched and copies the new properties onto its pair.
+//   - Re-pointing an edge (ToMany=false replacement) removes its stale pair.
+//   - When rd declares an Inverse, a derived edge _to → Inverse → _from is
+//     written with the inverse definition's cardinality. If that write itself
+//     re-points a functional edge on the target, the edge it was paired with
+//     is removed too. If the reciprocal edge already exists, doc stays
+//     unpaired.
+//
+// It issues several writes, so callers run it inside withTransaction whenever
+// rd.Inverse is set or rd.ToMany is false.
+func (b *Backend) writeLinkedEdge(ctx context.Context, doc relationshipDoc, rd types.RelationshipDefinition, idempotent bool) (relationshipDoc, error) {
+	stored, old, err := b.writeEdge(ctx, doc, rd.ToMany, idempotent)
+	if err != nil {
+		return relationshipDoc{}, err
+	}
+	if old != nil && old.To == stored.To {
+		if stored.PairID != "" {
+			patch := map[string]any{"properties": stored.Properties}
+			_, err := b.relationships.UpdateDocument(driver.WithMergeObjects(ctx, false), stored.PairID, patch)
+			if err == nil {
+				return stored, nil
+			}
+			if !driver.IsNotFound(err) {
+				return relationshipDoc{}, fmt.Errorf("update pair %s: %w", stored.PairID, err)
+			}
+		}
+	} else if old != nil {
+		if err := b.removeEdge(ctx, old.PairID); err != nil {
+			return relationshipDoc{}, err
+		}
+	}
+	if rd.Inverse == "" {
+		return stored, nil
+	}
+	inverse := relationshipDoc{
+		Key:        uuid.NewString(),
+		From:       stored.To,
+		To:         stored.From,
+		Name:       rd.Inverse,
+		AgencyID:   stored.AgencyID,
+		Properties: stored.Properties,
+		Derived:    true,
+		PairID:     stored.Key,
+		CreatedAt:  time.Now().UTC(),
+	}
+	pair, pairOld, err := b.writeEdge(ctx, inverse, b.inverseToMany(rd), false)
+	if err != nil {
+		return relationshipDoc{}, fmt.Errorf("inverse %s: %w", rd.Inverse, err)
+	}
+	if pairOld != nil {
+		if pairOld.To == pair.To {
+			return stored, nil
+		}
+		if pairOld.PairID != stored.Key {
+			if err := b.removeEdge(ctx, pairOld.PairID); err != nil {
+				return relationshipDoc{}, err
+			}
+		}
+	}
+	if _, err := b.relationships.UpdateDocument(ctx, stored.Key, map[string]any{"pair_id": pair.Key}); err != nil {
+		return relationshipDoc{}, fmt.Errorf("link pair %s: %w", pair.Key, err)
+	}
+	stored.PairID = pair.Key
+	return stored, nil
+}
+
+// inverseToMany reports the cardinality of rd's inverse definition on
+// rd.ToType. An inverse missing from the schema is treated as ToMany so that
+// it never replaces an unrelated edge.
+func (b *Backend) inverseToMany(rd types.RelationshipDefinition) bool {
+	inv, err := entitygraph.FindRelationshipDef(b.typeDefs()[rd.ToType], rd.Inverse)
+	return err != nil || inv.ToMany
+}
+
+// removeEdge deletes the edge document with key. An empty key or an edge that
+// is already gone is not an error.
+func (b *Backend) removeEdge(ctx context.Context, key string) error {
+	if key == "" {
+		return nil
+	}
+	if _, err := b.relationships.RemoveDocument(ctx, key); err != nil && !driver.IsNotFound(err) {
+		return fmt.Errorf("remove edge %s: %w", key, err)
+	}
+	return nil
+}
+
+// writeEdge stores doc according to the relationship's cardinality. ToMany
+// edges are inserted; ToMany=false edges are upserted on (_from, name) so an
+// existing edge is re-pointed at doc._to. When idempotent is set, ToMany edges
+// are upserted on (_from, name, _to) instead, so repeating the write does not
+// create a duplicate. When an existing edge is updated, its state before the
+// write is returned as well.
+func (b *Backend) writeEdge(ctx context.Context, doc relationshipDoc, toMany, idempotent bool) (relationshipDoc, *relationshipDoc, error) {
+	if !toMany || idempotent {
+		return b.upsertEdge(ctx, doc, toMany)
+	}
 	if _, err := b.relationships.CreateDocument(ctx, doc); err != nil {
 		if driver.IsConflict(err) {
-			return entitygraph.Relationship{}, fmt.Errorf("CreateRelationship: relationship already exists")
+			return relationshipDoc{}, nil, fmt.Errorf("relationship already exists")
 		}
-		return entitygraph.Relationship{}, fmt.Errorf("CreateRelationship: %w", err)
+		return relationshipDoc{}, nil, err
 	}
-	return toRelationship(doc, id), nil
+	return doc, nil, nil
+}
+
+// upsertEdge writes doc with an AQL UPSERT keyed on (_from, name), plus _to
+// when matchTo is set. A matching edge keeps its ID and has its properties
+// replaced; when its _to changes it also takes doc's derived and pair_id, since
+// its previous pairing no longer applies. Otherwise doc is inserted.
+//
+// The UPSERT's lookup and write are not atomic. Without matchTo the edge is
+// functional, so its functional_name is set and the unique index on
+// [agency_id, _from, functional_name] rejects a concurrent duplicate insert;
+// that is returned as an error wrapping entitygraph.ErrConflict, for the
+// caller to retry. Returns the stored edge and, for an update, the edge as it
+// was before.
+func (b *Backend) upsertEdge(ctx context.Context, doc relationshipDoc, matchTo bool) (relationshipDoc, *relationshipDoc, error) {
+	match := "_from: @from, name: @name, agency_id: @agencyID"
+	var functional any
+	if matchTo {
+		match += ", _to: @to"
+		doc.FunctionalName = ""
+	} else {
+		doc.FunctionalName = doc.Name
+		functional = doc.Name
+	}
+	q := fmt.Sprintf(
+		`UPSERT { %s }
+		 INSERT @doc
+		 UPDATE OLD._to == @to
+		   ? { properties: @properties, functional_name: @functional }
+		   : { _to: @to, properties: @properties, derived: @derived, pair_id: @pairID, functional_name: @functional }
+		 IN %s OPTIONS { mergeObjects: false }
+		 RETURN { doc: NEW, old: OLD }`,
+		match, b.relCollectionName,
+	)
+	bindVars := map[string]interface{}{
+		"from":       doc.From,
+		"to":         doc.To,
+		"name":       doc.Name,
+		"agencyID":   doc.AgencyID,
+		"properties": doc.Properties,
+		"derived":    doc.Derived,
+		"pairID":     doc.PairID,
+		"functional": functional,
+		"doc":        doc,
+	}
+	cursor, err := b.db.Query(ctx, q, bindVars)
+	if err != nil {
+		if driver.IsConflict(err) {
+			return relationshipDoc{}, nil, fmt.Errorf("upsert %s: %v: %w", doc.Name, err, entitygraph.ErrConflict)
+		}
+		return relationshipDoc{}, nil, fmt.Errorf("upsert %s: %w", doc.Name, err)
+	}
+	defer cursor.Close()
+	var row struct {
+		Doc relationshipDoc  `json:"doc"`
+		Old *relationshipDoc `json:"old"`
+	}
+	if _, err := cursor.ReadDocument(ctx, &row); err != nil {
+		return relationshipDoc{}, nil, fmt.Errorf("upsert %s: read: %w", doc.Name, err)
+	}
+	return row.Doc, row.Old, nil
+}
+
+// inlineEdge is a validated entry of CreateEntityRequest.Relationships ready
+// to be written once the source entity's handle is known.
+type inlineEdge struct {
+	doc relationshipDoc // From is filled in by the caller
+	rd  types.RelationshipDefinition
+}
+
+// inlineEdges resolves every target in req.Relationships and validates the
+// set with [entitygraph.ValidateEntityRelationships]. It performs no writes.
+// Required relationships are checked by the caller, since they only apply
+// when a new entity is inserted.
+func (b *Backend) inlineEdges(ctx context.Context, req entitygraph.CreateEntityRequest) ([]inlineEdge, error) {
+	if len(req.Relationships) == 0 {
+		return nil, nil
+	}
+	td, ok := b.typeDefs()[req.TypeID]
+	if !ok {
+		return nil, fmt.Errorf("type %q not in schema: %w", req.TypeID, entitygraph.ErrInvalidRelationship)
+	}
+	handles := make([]string, len(req.Relationships))
+	toTypeIDs := make([]string, len(req.Relationships))
+	for i, rel := range req.Relationships {
+		handle, doc, err := b.resolveLiveEntity(ctx, req.AgencyID, rel.ToID)
+		if err != nil {
+			return nil, fmt.Errorf("relationship %q: %w", rel.Name, err)
+		}
+		handles[i], toTypeIDs[i] = handle, doc.TypeID
+	}
+	if err := entitygraph.ValidateEntityRelationships(td, req.Relationships, toTypeIDs); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	edges := make([]inlineEdge, len(req.Relationships))
+	for i, rel := range req.Relationships {
+		rd, _ := entitygraph.FindRelationshipDef(td, rel.Name)
+		edges[i] = inlineEdge{
+			doc: relationshipDoc{
+				Key:        uuid.NewString(),
+				To:         handles[i],
+				Name:       rel.Name,
+				AgencyID:   req.AgencyID,
+				Properties: make(map[string]any),
+				CreatedAt:  now,
+			},
+			rd: rd,
+		}
+	}
+	return edges, nil
+}
+
+// writeInlineEdges writes edges from fromHandle, together with their
+// inverses. When idempotent is set (UpsertEntity merging onto an existing
+// entity), ToMany edges that already exist are not duplicated. Callers run it
+// inside withTransaction.
+func (b *Backend) writeInlineEdges(ctx context.Context, fromHandle string, edges []inlineEdge, idempotent bool) error {
+	for _, e := range edges {
+		e.doc.From = fromHandle
+		if _, err := b.writeLinkedEdge(ctx, e.doc, e.rd, idempotent); err != nil {
+			return fmt.Errorf("relationship %q: %w", e.doc.Name, err)
+		}
+	}
+	return nil
 }
 
 // GetRelationship returns the relationship identified by agencyID and
@@ -100,30 +402,88 @@ func (b *Backend) GetRelationship(
 	return toRelationship(doc, relationshipID), nil
 }
 
-// DeleteRelationship removes an edge document permanently.
+// DeleteRelationship removes an edge document permanently. When the edge has
+// a pair (PairID), both documents are removed in one stream transaction.
 // Returns entitygraph.ErrRelationshipNotFound if the relationship does not exist.
 func (b *Backend) DeleteRelationship(
 	ctx context.Context,
 	agencyID, relationshipID string,
 ) error {
-	if _, err := b.GetRelationship(ctx, agencyID, relationshipID); err != nil {
+	r, err := b.GetRelationship(ctx, agencyID, relationshipID)
+	if err != nil {
 		return fmt.Errorf("DeleteRelationship %s: %w", relationshipID, err)
 	}
-	if _, err := b.relationships.RemoveDocument(ctx, relationshipID); err != nil {
-		if driver.IsNotFound(err) {
-			return fmt.Errorf("DeleteRelationship %s: %w", relationshipID, entitygraph.ErrRelationshipNotFound)
+	remove := func(ctx context.Context) error {
+		if _, err := b.relationships.RemoveDocument(ctx, relationshipID); err != nil {
+			if driver.IsNotFound(err) {
+				return entitygraph.ErrRelationshipNotFound
+			}
+			return err
 		}
+		return b.removeEdge(ctx, r.PairID)
+	}
+	if r.PairID == "" {
+		err = remove(ctx)
+	} else {
+		err = b.withTransaction(ctx, []string{b.relCollectionName}, remove)
+	}
+	if err != nil {
 		return fmt.Errorf("DeleteRelationship %s: %w", relationshipID, err)
 	}
 	return nil
 }
 
-// ListRelationships returns all edges matching the filter.
+// outboundEdges returns every edge of agencyID whose _from is handle.
+func (b *Backend) outboundEdges(ctx context.Context, agencyID, handle string) ([]relationshipDoc, error) {
+	q := fmt.Sprintf("FOR e IN %s FILTER e._from == @from AND e.agency_id == @agencyID RETURN e", b.relCollectionName)
+	cursor, err := b.db.Query(ctx, q, map[string]interface{}{"from": handle, "agencyID": agencyID})
+	if err != nil {
+		return nil, fmt.Errorf("outbound edges %s: %w", handle, err)
+	}
+	defer cursor.Close()
+	var edges []relationshipDoc
+	for cursor.HasMore() {
+		var doc relationshipDoc
+		if _, err := cursor.ReadDocument(ctx, &doc); err != nil {
+			return nil, fmt.Errorf("outbound edges %s: read: %w", handle, err)
+		}
+		edges = append(edges, doc)
+	}
+	return edges, nil
+}
+
+// ListRelationships returns all edges matching the filter, sorted and paged
+// when filter.OrderBy, Limit, or PageToken is set.
 // Zero-value filter fields are treated as "no restriction".
 func (b *Backend) ListRelationships(
 	ctx context.Context,
 	filter entitygraph.RelationshipFilter,
 ) ([]entitygraph.Relationship, error) {
+	page, err := b.listRelationships(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("ListRelationships: %w", err)
+	}
+	return page.Relationships, nil
+}
+
+// ListRelationshipsPage returns one page of the edges matching the filter
+// together with the next page token and, when requested, the total count.
+func (b *Backend) ListRelationshipsPage(
+	ctx context.Context,
+	filter entitygraph.RelationshipFilter,
+) (entitygraph.RelationshipPage, error) {
+	page, err := b.listRelationships(ctx, filter)
+	if err != nil {
+		return entitygraph.RelationshipPage{}, fmt.Errorf("ListRelationshipsPage: %w", err)
+	}
+	return page, nil
+}
+
+// listRelationships implements ListRelationships and ListRelationshipsPage.
+func (b *Backend) listRelationships(ctx context.Context, filter entitygraph.RelationshipFilter) (entitygraph.RelationshipPage, error) {
+	if err := entitygraph.ValidatePageRequest(filter.Limit, filter.OrderBy, nil, true); err != nil {
+		return entitygraph.RelationshipPage{}, err
+	}
 	bindVars := map[string]interface{}{}
 	conditions := []string{"1==1"}
 	if filter.AgencyID != "" {
@@ -142,35 +502,44 @@ func (b *Backend) ListRelationships(
 		conditions = append(conditions, "doc._to LIKE CONCAT('%/', @toID)")
 		bindVars["toID"] = filter.ToID
 	}
-	where := ""
-	for i, c := range conditions {
-		if i == 0 {
-			where = c
-		} else {
-			where += " AND " + c
+	loop := fmt.Sprintf("FOR doc IN %s FILTER %s", b.relCollectionName, strings.Join(conditions, " AND "))
+
+	var page entitygraph.RelationshipPage
+	if filter.IncludeTotal {
+		n, err := countMatches(ctx, b.db, loop, bindVars)
+		if err != nil {
+			return entitygraph.RelationshipPage{}, err
 		}
+		page.Total = n
 	}
-	q := fmt.Sprintf("FOR doc IN %s FILTER %s RETURN doc", b.relCollectionName, where)
-	cursor, err := b.db.Query(ctx, q, bindVars)
+
+	if entitygraph.Paginated(filter.OrderBy, filter.Limit, filter.PageToken) {
+		docs, next, err := queryPage(ctx, b.db, loop, bindVars, filter.OrderBy, filter.Limit, filter.PageToken,
+			func(d relationshipDoc) string { return d.Key })
+		if err != nil {
+			return entitygraph.RelationshipPage{}, err
+		}
+		for _, doc := range docs {
+			page.Relationships = append(page.Relationships, toRelationship(doc, doc.Key))
+		}
+		page.NextPageToken = next
+		return page, nil
+	}
+
+	cursor, err := b.db.Query(ctx, loop+" RETURN doc", bindVars)
 	if err != nil {
-		return nil, fmt.Errorf("ListRelationships: query: %w", err)
+		return entitygraph.RelationshipPage{}, fmt.Errorf("query: %w", err)
 	}
-	var results []entitygraph.Relationship
-	var readErr error
+	defer cursor.Close()
 	for cursor.HasMore() {
 		var doc relationshipDoc
 		meta, rErr := cursor.ReadDocument(ctx, &doc)
 		if rErr != nil {
-			readErr = fmt.Errorf("ListRelationships: read: %w", rErr)
-			break
+			return entitygraph.RelationshipPage{}, fmt.Errorf("read: %w", rErr)
 		}
-		results = append(results, toRelationship(doc, meta.Key))
-	}
-	cursor.Close()
-	if readErr != nil {
-		return nil, readErr
+		page.Relationships = append(page.Relationships, toRelationship(doc, meta.Key))
 	}
-	return results, nil
+	return page, nil
 }
 
 // TraverseGraph walks the named graph from the start entity up to the
@@ -278,6 +647,8 @@ func toRelationship(doc relationshipDoc, key string) entitygraph.Relationship {
 		AgencyID:   doc.AgencyID,
 		Name:       doc.Name,
 		Properties: doc.Properties,
+		Derived:    doc.Derived,
+		PairID:     doc.PairID,
 		CreatedAt:  doc.CreatedAt,
 	}
 	// Strip collection prefix from _from / _to to get plain entity IDs.
@@ -119,13 +119,20 @@ func (b *Backend) Publish(ctx context.Context, agencyID string) error {
 	return nil
 }
 
-// Activate sets Active=true on the specified published version and Active=false
-// on all others for the agency. Returns [entitygraph.ErrSchemaNotFound] if the
-// version does not exist.
+// Activate reconciles the entity storage with the specified published
+// version — creating the StorageCollections it adds, the indexes its types
+// require (see indexes.go), and their search views — and makes the backend
+// serve its TypeDefinitions, so that writes of its types go to their
+// StorageCollections and carry their unique_key. It then migrates the
+// unique_key of stored documents to the version's UniqueKeys, sets
+// Active=true on it, and Active=false on all others for the agency.
+// Types the version does not declare keep their current definitions.
+// Returns [entitygraph.ErrSchemaNotFound] if the version does not exist;
+// when reconciliation fails the previous version stays active.
 func (b *Backend) Activate(ctx context.Context, agencyID string, version int) error {
-	// Resolve the document key for the target version.
+	// Resolve the target version.
 	keyQ := fmt.Sprintf(
-		"FOR doc IN %s FILTER doc.agency_id == @agencyID AND doc.version == @version LIMIT 1 RETURN doc._key",
+		"FOR doc IN %s FILTER doc.agency_id == @agencyID AND doc.version == @version LIMIT 1 RETURN doc",
 		b.schemasPublishedName,
 	)
 	keyCursor, err := b.db.Query(ctx, keyQ, map[string]interface{}{
@@ -133,20 +140,43 @@ func (b *Backend) Activate(ctx context.Context, agencyID string, version int) er
 		"version":  version,
 	})
 	if err != nil {
-		return fmt.Errorf("Activate %s v%d: query key: %w", agencyID, version, err)
+		return fmt.Errorf("Activate %s v%d: query: %w", agencyID, version, err)
 	}
-	var targetKey string
+	var (
+		targetKey string
+		target    publishedDoc
+	)
 	if keyCursor.HasMore() {
-		if _, err := keyCursor.ReadDocument(ctx, &targetKey); err != nil {
+		meta, err := keyCursor.ReadDocument(ctx, &target)
+		if err != nil {
 			keyCursor.Close()
-			return fmt.Errorf("Activate %s v%d: read key: %w", agencyID, version, err)
+			return fmt.Errorf("Activate %s v%d: read: %w", agencyID, version, err)
 		}
+		targetKey = meta.Key
 	}
 	keyCursor.Close()
 	if targetKey == "" {
 		return fmt.Errorf("Activate %s v%d: %w", agencyID, version, entitygraph.ErrSchemaNotFound)
 	}
 
+	// Reconcile storage before switching versions, so that a failure leaves
+	// the previous version active.
+	if err := ensureEntityCollections(ctx, b, target.Types); err != nil {
+		return fmt.Errorf("Activate %s v%d: %w", agencyID, version, err)
+	}
+	if err := ensureIndexes(ctx, b, target.Types); err != nil {
+		return fmt.Errorf("Activate %s v%d: %w", agencyID, version, err)
+	}
+	if err := ensureSearchViews(ctx, b, target.Types); err != nil {
+		return fmt.Errorf("Activate %s v%d: %w", agencyID, version, err)
+	}
+	// Serve the version's types before migrating, so that documents written
+	// from now on carry their unique_key and the migration covers the rest.
+	b.addTypeDefs(target.Types)
+	if err := migrateUniqueKeysOnce(ctx, b); err != nil {
+		return fmt.Errorf("Activate %s v%d: %w", agencyID, version, err)
+	}
+
 	// Deactivate all versions for this agency.
 	deactivateQ := fmt.Sprintf(
 		"FOR doc IN %s FILTER doc.agency_id == @agencyID UPDATE doc WITH { active: false } IN %s",
@@ -0,0 +1,183 @@
+// search.go contains SearchEntities and the ArangoSearch views behind it.
+// Each TypeDefinition with Searchable properties gets one view over its
+// storage collection, indexing exactly those properties with a shared text
+// analyzer (lower-cased words, no stemming) so that matches agree with
+// [entitygraph.SearchTerms].
+package arangodb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	driver "github.com/arangodb/go-driver"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+// searchAnalyzer is the database-scoped text analyzer used by every search
+// view.
+const searchAnalyzer = "entitygraph_text"
+
+// searchViewName returns the name of the ArangoSearch view for td stored in
+// the collection named colName.
+func searchViewName(colName string, td types.TypeDefinition) string {
+	return colName + "_" + td.Name + "_search"
+}
+
+// ensureSearchViews creates the search analyzer and one view per type of tds
+// with Searchable properties, and updates the links of views that already
+// exist so that they index the current set of searchable properties.
+func ensureSearchViews(ctx context.Context, b *Backend, tds []types.TypeDefinition) error {
+	var searchable []types.TypeDefinition
+	for _, td := range tds {
+		if len(entitygraph.SearchableProperties(td)) > 0 {
+			searchable = append(searchable, td)
+		}
+	}
+	if len(searchable) == 0 {
+		return nil
+	}
+
+	keepAccents := true
+	noStemming := false
+	_, _, err := b.db.EnsureAnalyzer(ctx, driver.ArangoSearchAnalyzerDefinition{
+		Name: searchAnalyzer,
+		Type: driver.ArangoSearchAnalyzerTypeText,
+		Properties: driver.ArangoSearchAnalyzerProperties{
+			Locale:    "en",
+			Case:      driver.ArangoSearchCaseLower,
+			Accent:    &keepAccents,
+			Stemming:  &noStemming,
+			Stopwords: []string{},
+		},
+		Features: []driver.ArangoSearchAnalyzerFeature{
+			driver.ArangoSearchAnalyzerFeatureFrequency,
+			driver.ArangoSearchAnalyzerFeatureNorm,
+			driver.ArangoSearchAnalyzerFeaturePosition,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("ensureSearchViews: analyzer: %w", err)
+	}
+
+	for _, td := range searchable {
+		col := b.collectionForDef(td)
+		fields := driver.ArangoSearchFields{}
+		for _, name := range entitygraph.SearchableProperties(td) {
+			fields[name] = driver.ArangoSearchElementProperties{Analyzers: []string{searchAnalyzer}}
+		}
+		props := driver.ArangoSearchViewProperties{
+			Links: driver.ArangoSearchLinks{
+				col.Name(): {Fields: driver.ArangoSearchFields{"properties": {Fields: fields}}},
+			},
+		}
+		name := searchViewName(col.Name(), td)
+		if err := ensureSearchView(ctx, b.db, name, props); err != nil {
+			return fmt.Errorf("ensureSearchViews: %s: %w", name, err)
+		}
+		b.mu.Lock()
+		b.searchViews[td.Name] = name
+		b.mu.Unlock()
+	}
+	return nil
+}
+
+// ensureSearchView creates the ArangoSearch view name with props, or replaces
+// the properties of the existing view.
+func ensureSearchView(ctx context.Context, db driver.Database, name string, props driver.ArangoSearchViewProperties) error {
+	exists, err := db.ViewExists(ctx, name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		_, err := db.CreateArangoSearchView(ctx, name, &props)
+		if err == nil || !driver.IsConflict(err) {
+			return err
+		}
+	}
+	v, err := db.View(ctx, name)
+	if err != nil {
+		return err
+	}
+	sv, err := v.ArangoSearchView()
+	if err != nil {
+		return err
+	}
+	return sv.SetProperties(ctx, props)
+}
+
+// searchRow is one row of the search query.
+type searchRow struct {
+	Doc   entityDoc `json:"doc"`
+	Score float64   `json:"score"`
+}
+
+// SearchEntities searches the ArangoSearch view of req.TypeID and returns the
+// live entities matching any word of req.Query, ranked by BM25.
+func (b *Backend) SearchEntities(ctx context.Context, req entitygraph.SearchRequest) ([]entitygraph.SearchHit, error) {
+	var td *types.TypeDefinition
+	if d, ok := b.typeDefs()[req.TypeID]; ok {
+		td = &d
+	}
+	props, terms, err := entitygraph.ValidateSearchRequest(req, td)
+	if err != nil {
+		return nil, fmt.Errorf("SearchEntities %s: %w", req.TypeID, err)
+	}
+	b.mu.RLock()
+	view, ok := b.searchViews[req.TypeID]
+	b.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("SearchEntities %s: no search view", req.TypeID)
+	}
+
+	bindVars := map[string]any{
+		"query":    req.Query,
+		"analyzer": searchAnalyzer,
+		"typeID":   req.TypeID,
+		"limit":    entitygraph.SearchLimit(req),
+	}
+	// Property names are bound rather than spliced into the query text.
+	matches := make([]string, len(props))
+	for i, p := range props {
+		param := fmt.Sprintf("sp%d", i)
+		bindVars[param] = p
+		matches[i] = fmt.Sprintf("doc.properties[@%s] IN TOKENS(@query, @analyzer)", param)
+	}
+	filter := "doc.type_id == @typeID AND doc.deleted != true"
+	if req.AgencyID != "" {
+		filter += " AND doc.agency_id == @agencyID"
+		bindVars["agencyID"] = req.AgencyID
+	}
+	options := ""
+	if b.searchWaitForSync {
+		options = " OPTIONS { waitForSync: true }"
+	}
+	query := fmt.Sprintf(
+		"FOR doc IN %s SEARCH ANALYZER(%s, @analyzer)%s FILTER %s"+
+			" LET score = BM25(doc) SORT score DESC, doc._key ASC LIMIT @limit"+
+			" RETURN { doc: doc, score: score }",
+		view, strings.Join(matches, " OR "), options, filter,
+	)
+
+	cur, err := b.db.Query(ctx, query, bindVars)
+	if err != nil {
+		return nil, fmt.Errorf("SearchEntities %s: query: %w", req.TypeID, err)
+	}
+	defer cur.Close()
+	var hits []entitygraph.SearchHit
+	for cur.HasMore() {
+		var row searchRow
+		if _, err := cur.ReadDocument(ctx, &row); err != nil {
+			return nil, fmt.Errorf("SearchEntities %s: read: %w", req.TypeID, err)
+		}
+		e := toEntity(row.Doc, row.Doc.Key)
+		hits = append(hits, entitygraph.SearchHit{
+			Entity:     e,
+			Score:      row.Score,
+			Highlights: entitygraph.HighlightEntity(e, props, terms),
+		})
+	}
+	return hits, nil
+}
@@ -12,12 +12,23 @@
 //   - RelCollection       — ArangoDB edge collection for directed graph edges
 //   - SchemasDraftCol     — one mutable document per agency (draft schema)
 //   - SchemasPublishedCol — immutable append-only published schema snapshots
+//   - HistoryCol          — immutable entity revisions of History-enabled types
+//   - MigrationsCol       — markers of the one-shot data migrations already run
+//   - OutboxCol           — optional; lifecycle events awaiting delivery
 //
 // File layout:
 //   - storage.go       — Config, Backend struct, constructors, collection setup
-//   - entities.go      — CreateEntity, GetEntity, UpdateEntity, DeleteEntity, ListEntities, UpsertEntity
+//   - entities.go      — CreateEntity, GetEntity, UpdateEntity, DeleteEntity, ListEntities,
+//     ListEntitiesPage, UpsertEntity, RestoreEntity, PurgeEntity, PurgeDeletedBefore
 //   - relationships.go — CreateRelationship, GetRelationship, DeleteRelationship,
-//     ListRelationships, TraverseGraph
+//     ListRelationships, ListRelationshipsPage, TraverseGraph
+//   - paging.go        — sort, keyset and count AQL shared by the paged listings
+//   - filter.go        — compiles EntityFilter.Where to bind-variable AQL
+//   - search.go        — SearchEntities and the per-type ArangoSearch views
+//   - indexes.go       — persistent and UniqueKey indexes derived from the schema
+//   - batch.go         — Batch
+//   - history.go       — GetEntityHistory, GetEntityAt and revision recording
+//   - outbox.go        — transactional event outbox and OutboxRelay
 //   - schemaops.go     — SetSchema, GetSchema, Publish, Activate, GetActive,
 //     GetVersion, ListVersions
 //
@@ -29,6 +40,10 @@ package arangodb
 import (
 	"context"
 	"fmt"
+	"maps"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	driver "github.com/arangodb/go-driver"
@@ -108,6 +123,33 @@ type Config struct {
 	// GraphName is the ArangoDB named graph
 	// (e.g. "agency_graph", "ai_graph").
 	GraphName string
+
+	// HistoryCol is the collection for the entity revisions of types with
+	// TypeDefinition.History set (e.g. "agency_entity_history"). Optional:
+	// defaults to EntityCollection + "_history".
+	HistoryCol string
+
+	// MigrationsCol is the collection recording the one-shot data
+	// migrations already run, such as the unique_key backfill (see
+	// MigrateUniqueKeys). Optional: defaults to EntityCollection +
+	// "_migrations".
+	MigrationsCol string
+
+	// OutboxCol enables the transactional outbox: the collection the
+	// lifecycle events of PublishEvents types are written to, in the same
+	// transaction as the change that raises them, for an OutboxRelay to
+	// deliver (e.g. "agency_outbox"). Optional: empty disables the outbox.
+	OutboxCol string
+
+	// EventPrefix is the topic prefix of the outbox events — the service
+	// prefix passed to types.TopicsFromSchema (e.g. "work"). Required when
+	// OutboxCol is set.
+	EventPrefix string
+
+	// SearchWaitForSync makes SearchEntities wait until the ArangoSearch
+	// views have indexed every committed write. Views otherwise trail writes
+	// by up to a second. Intended for tests.
+	SearchWaitForSync bool
 }
 
 // Backend is the ArangoDB implementation of both [entitygraph.DataManager] and
@@ -115,51 +157,115 @@ type Config struct {
 // [NewBackendFromDB].
 //
 // entityColMap maps collection name → driver.Collection for every collection
-// referenced by the schema TypeDefinitions plus the fallback EntityCollection.
-// typeDefs maps TypeID → TypeDefinition for O(1) immutability and
-// StorageCollection lookups.
+// referenced by the schema TypeDefinitions plus the fallback EntityCollection,
+// and every StorageCollection a later Activate created (see
+// ensureEntityCollections).
+// defs maps TypeID → TypeDefinition for O(1) immutability and
+// StorageCollection lookups. Activate replaces it with a copy that adds the
+// activated version's types; it is never changed in place, so a map read
+// through typeDefs stays valid after a swap.
+// entityColMap, defs, and searchViews are guarded by mu.
 //
 // The name fields (relCollectionName, graphName, schemasDraftName,
 // schemasPublishedName) are stored for use in AQL query strings.
 type Backend struct {
 	db                   driver.Database
+	mu                   sync.RWMutex
 	entityColMap         map[string]driver.Collection    // collection name → driver.Collection
-	typeDefs             map[string]types.TypeDefinition // TypeID → TypeDefinition
+	defs                 map[string]types.TypeDefinition // TypeID → TypeDefinition
 	fallback             driver.Collection               // EntityCollection
 	relationships        driver.Collection
 	schemasDraft         driver.Collection
 	schemasPublished     driver.Collection
-	relCollectionName    string // used in ListRelationships AQL
-	graphName            string // used in TraverseGraph AQL
-	schemasDraftName     string // used in schemaops AQL
-	schemasPublishedName string // used in schemaops AQL
+	history              driver.Collection
+	migrations           driver.Collection
+	outbox               driver.Collection // nil when the outbox is disabled
+	relCollectionName    string            // used in ListRelationships AQL
+	graphName            string            // used in TraverseGraph AQL
+	schemasDraftName     string            // used in schemaops AQL
+	schemasPublishedName string            // used in schemaops AQL
+	historyName          string            // used in history AQL
+	outboxName           string            // used in outbox AQL
+	eventPrefix          string            // topic prefix of outbox events
+	outboxSeq            atomic.Int64      // last outbox created_ns (see nextOutboxSeq)
+	searchViews          map[string]string // TypeID → ArangoSearch view name
+	searchWaitForSync    bool
 }
 
 // collectionFor returns the driver.Collection for the given TypeID,
 // falling back to the EntityCollection when StorageCollection is empty.
 func (b *Backend) collectionFor(typeID string) driver.Collection {
-	if td, ok := b.typeDefs[typeID]; ok && td.StorageCollection != "" {
-		if col, ok := b.entityColMap[td.StorageCollection]; ok {
+	if td, ok := b.typeDefs()[typeID]; ok {
+		return b.collectionForDef(td)
+	}
+	return b.fallback
+}
+
+// collectionForDef returns the driver.Collection holding entities of td: its
+// StorageCollection when the backend manages it, the EntityCollection
+// otherwise.
+func (b *Backend) collectionForDef(td types.TypeDefinition) driver.Collection {
+	if td.StorageCollection != "" {
+		if col, ok := b.entityCollection(td.StorageCollection); ok {
 			return col
 		}
 	}
 	return b.fallback
 }
 
+// entityCollection returns the managed entity collection called name.
+func (b *Backend) entityCollection(name string) (driver.Collection, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	col, ok := b.entityColMap[name]
+	return col, ok
+}
+
+// typeDefs returns the TypeDefinitions the backend serves, keyed by TypeID.
+// The map must not be modified.
+func (b *Backend) typeDefs() map[string]types.TypeDefinition {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.defs
+}
+
+// addTypeDefs makes the backend serve tds, replacing the definitions of the
+// same TypeIDs and keeping the others.
+func (b *Backend) addTypeDefs(tds []types.TypeDefinition) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	defs := maps.Clone(b.defs)
+	for _, td := range tds {
+		defs[td.Name] = td
+	}
+	b.defs = defs
+}
+
 // isImmutable returns true when the TypeDefinition for typeID has Immutable set.
 func (b *Backend) isImmutable(typeID string) bool {
-	if td, ok := b.typeDefs[typeID]; ok {
+	if td, ok := b.typeDefs()[typeID]; ok {
 		return td.Immutable
 	}
 	return false
 }
 
+// validateProperties runs [entitygraph.ValidateProperties] against the
+// TypeDefinition for typeID. Types not declared in the schema are stored
+// without validation.
+func (b *Backend) validateProperties(typeID string, props map[string]any, mode entitygraph.ValidationMode) error {
+	td, ok := b.typeDefs()[typeID]
+	if !ok {
+		return nil
+	}
+	return entitygraph.ValidateProperties(td, props, mode)
+}
+
 // allEntityCollections returns every distinct entity collection derived from
 // the schema — used for graph vertex lists and cross-collection searches.
 func (b *Backend) allEntityCollections() []driver.Collection {
 	seen := make(map[string]struct{})
 	var cols []driver.Collection
-	for _, td := range b.typeDefs {
+	for _, td := range b.typeDefs() {
 		name := td.StorageCollection
 		if name == "" {
 			name = b.fallback.Name()
@@ -168,7 +274,7 @@ func (b *Backend) allEntityCollections() []driver.Collection {
 			continue
 		}
 		seen[name] = struct{}{}
-		if col, ok := b.entityColMap[name]; ok {
+		if col, ok := b.entityCollection(name); ok {
 			cols = append(cols, col)
 		}
 	}
@@ -179,6 +285,51 @@ func (b *Backend) allEntityCollections() []driver.Collection {
 	return cols
 }
 
+// txKey is the context key under which withTransaction records the
+// transaction it began.
+type txKey struct{}
+
+// withTransaction runs fn inside an ArangoDB stream transaction with write
+// access to the named collections. The context passed to fn carries the
+// transaction ID, so every document and query call made with it participates
+// in the transaction; reads of undeclared collections are allowed. The
+// transaction is committed when fn returns nil and aborted otherwise.
+//
+// When ctx already carries a transaction begun by withTransaction — a write
+// made by Batch — fn joins it instead, so the enclosing transaction commits
+// or aborts everything. The enclosing transaction must declare write access
+// to the collections named in write.
+func (b *Backend) withTransaction(ctx context.Context, write []string, fn func(ctx context.Context) error) error {
+	if _, nested := ctx.Value(txKey{}).(driver.TransactionID); nested {
+		return fn(ctx)
+	}
+	tid, err := b.db.BeginTransaction(ctx, driver.TransactionCollections{Write: write},
+		&driver.BeginTransactionOptions{AllowImplicit: true})
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	if err := fn(context.WithValue(driver.WithTransactionID(ctx, tid), txKey{}, tid)); err != nil {
+		if abortErr := b.db.AbortTransaction(ctx, tid, nil); abortErr != nil {
+			return fmt.Errorf("%w (abort transaction: %v)", err, abortErr)
+		}
+		return err
+	}
+	if err := b.db.CommitTransaction(ctx, tid, nil); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// withWrites runs fn inside a stream transaction (see withTransaction) when
+// write names more than one collection, and directly otherwise: a write to a
+// single document is atomic on its own.
+func (b *Backend) withWrites(ctx context.Context, write []string, fn func(ctx context.Context) error) error {
+	if len(write) == 1 {
+		return fn(ctx)
+	}
+	return b.withTransaction(ctx, write, fn)
+}
+
 // New constructs a Backend from an already-open driver.Database using the
 // provided Config, ensures all collections and the named graph exist, and
 // returns the Backend as both a DataManager and a SchemaManager.
@@ -271,11 +422,25 @@ func newBackendFromDB(ctx context.Context, db driver.Database, cfg Config) (*Bac
 	if err != nil {
 		return nil, fmt.Errorf("ensure %q: %w", cfg.SchemasPublishedCol, err)
 	}
+	if cfg.HistoryCol == "" {
+		cfg.HistoryCol = cfg.EntityCollection + "_history"
+	}
+	history, err := ensureDocumentCollection(ctx, db, cfg.HistoryCol)
+	if err != nil {
+		return nil, fmt.Errorf("ensure %q: %w", cfg.HistoryCol, err)
+	}
+	if cfg.MigrationsCol == "" {
+		cfg.MigrationsCol = cfg.EntityCollection + "_migrations"
+	}
+	migrations, err := ensureDocumentCollection(ctx, db, cfg.MigrationsCol)
+	if err != nil {
+		return nil, fmt.Errorf("ensure %q: %w", cfg.MigrationsCol, err)
+	}
 
 	b := &Backend{
 		db:                   db,
 		entityColMap:         entityColMap,
-		typeDefs:             typeDefs,
+		defs:                 typeDefs,
 		fallback:             entityColMap[cfg.EntityCollection],
 		relationships:        relationships,
 		schemasDraft:         schemasDraft,
@@ -284,11 +449,44 @@ func newBackendFromDB(ctx context.Context, db driver.Database, cfg Config) (*Bac
 		graphName:            cfg.GraphName,
 		schemasDraftName:     cfg.SchemasDraftCol,
 		schemasPublishedName: cfg.SchemasPublishedCol,
+		history:              history,
+		historyName:          cfg.HistoryCol,
+		migrations:           migrations,
+		outboxName:           cfg.OutboxCol,
+		eventPrefix:          cfg.EventPrefix,
+		searchViews:          make(map[string]string),
+		searchWaitForSync:    cfg.SearchWaitForSync,
 	}
 
 	if err := ensureGraph(ctx, b); err != nil {
 		return nil, err
 	}
+	if err := ensureIndexes(ctx, b, cfg.Schema.Types); err != nil {
+		return nil, err
+	}
+	if err := ensureFunctionalEdgeIndex(ctx, b); err != nil {
+		return nil, err
+	}
+	if err := ensureHistoryIndex(ctx, b); err != nil {
+		return nil, err
+	}
+	if cfg.OutboxCol != "" {
+		if cfg.EventPrefix == "" {
+			return nil, fmt.Errorf("arangodb: EventPrefix must be set with OutboxCol")
+		}
+		if b.outbox, err = ensureDocumentCollection(ctx, db, cfg.OutboxCol); err != nil {
+			return nil, fmt.Errorf("ensure %q: %w", cfg.OutboxCol, err)
+		}
+		if err := ensureOutboxIndex(ctx, b); err != nil {
+			return nil, err
+		}
+	}
+	if err := migrateUniqueKeysOnce(ctx, b); err != nil {
+		return nil, err
+	}
+	if err := ensureSearchViews(ctx, b, cfg.Schema.Types); err != nil {
+		return nil, err
+	}
 	return b, nil
 }
 
@@ -322,6 +520,48 @@ func ensureGraph(ctx context.Context, b *Backend) error {
 	return nil
 }
 
+// ensureEntityCollections creates the StorageCollections named by tds that
+// the Backend does not manage yet, registers them in entityColMap, and adds
+// them to the graph's vertex collections so that edges may reach them.
+// Activate calls it before switching versions.
+func ensureEntityCollections(ctx context.Context, b *Backend, tds []types.TypeDefinition) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	added := false
+	for _, td := range tds {
+		name := td.StorageCollection
+		if name == "" {
+			continue
+		}
+		if _, ok := b.entityColMap[name]; ok {
+			continue
+		}
+		col, err := ensureDocumentCollection(ctx, b.db, name)
+		if err != nil {
+			return fmt.Errorf("ensure entity collection %q: %w", name, err)
+		}
+		b.entityColMap[name] = col
+		added = true
+	}
+	if !added {
+		return nil
+	}
+	names := make([]string, 0, len(b.entityColMap))
+	for name := range b.entityColMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	g, err := b.db.Graph(ctx, b.graphName)
+	if err != nil {
+		return fmt.Errorf("ensure entity collections: graph: %w", err)
+	}
+	constraints := driver.VertexConstraints{From: names, To: names}
+	if err := g.SetVertexConstraints(ctx, b.relCollectionName, constraints); err != nil {
+		return fmt.Errorf("ensure entity collections: vertex constraints: %w", err)
+	}
+	return nil
+}
+
 func ensureDocumentCollection(ctx context.Context, db driver.Database, name string) (driver.Collection, error) {
 	exists, err := db.CollectionExists(ctx, name)
 	if err != nil {
@@ -0,0 +1,274 @@
+// batch.go — transactional batch writes.
+//
+// DataManager.Batch applies an ordered list of entity and relationship writes
+// for one agency as a single unit: either every operation takes effect or
+// none does. Later operations may refer to an entity created or upserted
+// earlier in the same batch by a temporary ID — [BatchRef] of the name given
+// in that operation's Ref — before its real ID is known, so that an importer
+// can create a parent and link its children to it in one call.
+//
+// [ValidateBatch] checks the shape of a batch before anything is written, and
+// [ApplyBatch] runs the operations in order against a DataManager; backends
+// call it inside their own transaction so that batch semantics, reference
+// resolution and error reporting are identical everywhere.
+package entitygraph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidBatch is returned by Batch when the batch is malformed: too many
+// or no operations, an unknown Kind, a missing required field, a duplicate
+// Ref, or a temporary ID that no earlier operation declares.
+var ErrInvalidBatch = errors.New("invalid batch")
+
+// MaxBatchOps caps the number of operations in one Batch call.
+const MaxBatchOps = 1000
+
+// BatchRefPrefix marks a temporary ID. BatchRef("parent") is "$parent".
+const BatchRefPrefix = "$"
+
+// BatchRef returns the temporary ID by which later operations of a batch
+// refer to the entity of the operation whose Ref is name.
+func BatchRef(name string) string {
+	return BatchRefPrefix + name
+}
+
+// BatchOpKind names the write a [BatchOp] performs.
+type BatchOpKind string
+
+const (
+	// BatchCreateEntity creates BatchOp.Entity, as CreateEntity.
+	BatchCreateEntity BatchOpKind = "create_entity"
+
+	// BatchUpsertEntity upserts BatchOp.Entity, as UpsertEntity.
+	BatchUpsertEntity BatchOpKind = "upsert_entity"
+
+	// BatchUpdateEntity patches BatchOp.EntityID with BatchOp.Update, as
+	// UpdateEntity.
+	BatchUpdateEntity BatchOpKind = "update_entity"
+
+	// BatchDeleteEntity soft-deletes BatchOp.EntityID, as DeleteEntity with
+	// BatchOp.IfMatch.
+	BatchDeleteEntity BatchOpKind = "delete_entity"
+
+	// BatchCreateRelationship creates BatchOp.Relationship, as
+	// CreateRelationship.
+	BatchCreateRelationship BatchOpKind = "create_relationship"
+
+	// BatchDeleteRelationship removes BatchOp.RelationshipID, as
+	// DeleteRelationship.
+	BatchDeleteRelationship BatchOpKind = "delete_relationship"
+)
+
+// BatchOp is one operation of a Batch. Kind selects which of the remaining
+// fields are read; the AgencyID fields of the embedded requests are ignored
+// in favour of the agency passed to Batch.
+type BatchOp struct {
+	// Kind is the write to perform. Required.
+	Kind BatchOpKind
+
+	// Ref names the entity produced by a create or upsert so that later
+	// operations can use BatchRef(Ref) in place of its ID. Optional; unique
+	// within the batch.
+	Ref string
+
+	// Entity is the create or upsert request. Its Relationships' ToIDs may
+	// be temporary IDs.
+	Entity CreateEntityRequest
+
+	// EntityID is the entity to update or delete, or a temporary ID.
+	EntityID string
+
+	// Update is the patch applied by an update, including its IfMatch.
+	Update UpdateEntityRequest
+
+	// IfMatch makes a delete conditional on the entity's Revision.
+	IfMatch string
+
+	// Relationship is the edge to create. FromID and ToID may be temporary
+	// IDs.
+	Relationship CreateRelationshipRequest
+
+	// RelationshipID is the edge to delete.
+	RelationshipID string
+}
+
+// BatchOpResult is the outcome of one operation of a successful Batch.
+type BatchOpResult struct {
+	// Entity is the entity as written by a create, upsert or update; nil for
+	// other kinds.
+	Entity *Entity
+
+	// Relationship is the edge written by a relationship create; nil for
+	// other kinds.
+	Relationship *Relationship
+}
+
+// BatchResult is the outcome of a successful Batch.
+type BatchResult struct {
+	// Results holds one entry per operation, in order.
+	Results []BatchOpResult
+
+	// Refs maps every Ref declared in the batch to the real ID of its entity.
+	Refs map[string]string
+}
+
+// BatchOpError reports the operation that made a Batch fail. It wraps the
+// operation's error, so errors.Is matches the usual sentinels
+// (ErrEntityNotFound, ErrConflict, …).
+type BatchOpError struct {
+	// Index is the position of the failing operation in the batch.
+	Index int
+
+	// Kind is the failing operation's Kind.
+	Kind BatchOpKind
+
+	// Err is the error the operation returned.
+	Err error
+}
+
+func (e *BatchOpError) Error() string {
+	return fmt.Sprintf("batch op %d (%s): %v", e.Index, e.Kind, e.Err)
+}
+
+func (e *BatchOpError) Unwrap() error { return e.Err }
+
+// ValidateBatch checks the shape of ops without touching storage: the number
+// of operations, each Kind and its required fields, Ref uniqueness, and that
+// every temporary ID refers to a Ref declared by an earlier operation.
+// Errors wrap [ErrInvalidBatch] in a [BatchOpError] where an operation is at
+// fault.
+func ValidateBatch(ops []BatchOp) error {
+	if len(ops) == 0 {
+		return fmt.Errorf("%w: no operations", ErrInvalidBatch)
+	}
+	if len(ops) > MaxBatchOps {
+		return fmt.Errorf("%w: %d operations exceed the limit of %d", ErrInvalidBatch, len(ops), MaxBatchOps)
+	}
+	declared := make(map[string]struct{})
+	for i, op := range ops {
+		fail := func(format string, args ...any) error {
+			return &BatchOpError{Index: i, Kind: op.Kind, Err: fmt.Errorf("%w: "+format, append([]any{ErrInvalidBatch}, args...)...)}
+		}
+		var ids []string
+		switch op.Kind {
+		case BatchCreateEntity, BatchUpsertEntity:
+			if op.Entity.TypeID == "" {
+				return fail("type is required")
+			}
+			for _, r := range op.Entity.Relationships {
+				ids = append(ids, r.ToID)
+			}
+		case BatchUpdateEntity, BatchDeleteEntity:
+			if op.EntityID == "" {
+				return fail("entity ID is required")
+			}
+			ids = append(ids, op.EntityID)
+		case BatchCreateRelationship:
+			if op.Relationship.Name == "" || op.Relationship.FromID == "" || op.Relationship.ToID == "" {
+				return fail("name, from and to are required")
+			}
+			ids = append(ids, op.Relationship.FromID, op.Relationship.ToID)
+		case BatchDeleteRelationship:
+			if op.RelationshipID == "" {
+				return fail("relationship ID is required")
+			}
+		default:
+			return fail("unknown kind %q", op.Kind)
+		}
+		for _, id := range ids {
+			name, isRef := strings.CutPrefix(id, BatchRefPrefix)
+			if !isRef {
+				continue
+			}
+			if _, ok := declared[name]; !ok {
+				return fail("%s does not name an earlier operation's Ref", id)
+			}
+		}
+		if op.Ref == "" {
+			continue
+		}
+		if op.Kind != BatchCreateEntity && op.Kind != BatchUpsertEntity {
+			return fail("Ref is only allowed on entity creates and upserts")
+		}
+		if _, dup := declared[op.Ref]; dup {
+			return fail("duplicate Ref %q", op.Ref)
+		}
+		declared[op.Ref] = struct{}{}
+	}
+	return nil
+}
+
+// ApplyBatch validates ops and applies them in order to dm for agencyID,
+// replacing temporary IDs with the IDs of the entities they name. It stops at
+// the first failing operation and returns its error as a [BatchOpError];
+// writes already made are not undone, so backends call ApplyBatch inside a
+// transaction they roll back on error.
+func ApplyBatch(ctx context.Context, dm DataManager, agencyID string, ops []BatchOp) (BatchResult, error) {
+	if err := ValidateBatch(ops); err != nil {
+		return BatchResult{}, err
+	}
+	result := BatchResult{
+		Results: make([]BatchOpResult, len(ops)),
+		Refs:    make(map[string]string),
+	}
+	resolve := func(id string) string {
+		if name, ok := strings.CutPrefix(id, BatchRefPrefix); ok {
+			return result.Refs[name]
+		}
+		return id
+	}
+	for i, op := range ops {
+		var err error
+		res := &result.Results[i]
+		switch op.Kind {
+		case BatchCreateEntity, BatchUpsertEntity:
+			req := op.Entity
+			req.AgencyID = agencyID
+			req.Relationships = make([]EntityRelationshipRequest, len(op.Entity.Relationships))
+			for j, r := range op.Entity.Relationships {
+				req.Relationships[j] = EntityRelationshipRequest{Name: r.Name, ToID: resolve(r.ToID)}
+			}
+			var e Entity
+			if op.Kind == BatchCreateEntity {
+				e, err = dm.CreateEntity(ctx, req)
+			} else {
+				e, err = dm.UpsertEntity(ctx, req)
+			}
+			if err == nil {
+				res.Entity = &e
+				if op.Ref != "" {
+					result.Refs[op.Ref] = e.ID
+				}
+			}
+		case BatchUpdateEntity:
+			var e Entity
+			e, err = dm.UpdateEntity(ctx, agencyID, resolve(op.EntityID), op.Update)
+			if err == nil {
+				res.Entity = &e
+			}
+		case BatchDeleteEntity:
+			err = dm.DeleteEntityIfMatch(ctx, agencyID, resolve(op.EntityID), op.IfMatch)
+		case BatchCreateRelationship:
+			req := op.Relationship
+			req.AgencyID = agencyID
+			req.FromID = resolve(req.FromID)
+			req.ToID = resolve(req.ToID)
+			var r Relationship
+			r, err = dm.CreateRelationship(ctx, req)
+			if err == nil {
+				res.Relationship = &r
+			}
+		case BatchDeleteRelationship:
+			err = dm.DeleteRelationship(ctx, agencyID, op.RelationshipID)
+		}
+		if err != nil {
+			return BatchResult{}, &BatchOpError{Index: i, Kind: op.Kind, Err: err}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,179 @@
+// batch.go contains the conformance cases for DataManager.Batch: temporary ID
+// references between operations, all-or-nothing application, and rejection of
+// malformed batches before anything is written.
+package conformance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+)
+
+func batchCases() []dmCase {
+	return []dmCase{
+		{"Batch_Refs_LinkChildrenToNewParent", testBatchRefs},
+		{"Batch_FailingOp_RollsBackEverything", testBatchRollback},
+		{"Batch_UnknownRef_ErrInvalidBatch", testBatchUnknownRef},
+		{"Batch_MixedWrites", testBatchMixed},
+	}
+}
+
+func testBatchRefs(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	result, err := dm.Batch(ctx, agencyA, []entitygraph.BatchOp{
+		{Kind: entitygraph.BatchCreateEntity, Ref: "goal", Entity: entitygraph.CreateEntityRequest{
+			TypeID: "Goal", Properties: map[string]any{"code": "G1"},
+		}},
+		{Kind: entitygraph.BatchCreateEntity, Ref: "task", Entity: entitygraph.CreateEntityRequest{
+			TypeID: "Task", Properties: map[string]any{"title": "T1"},
+			Relationships: []entitygraph.EntityRelationshipRequest{{Name: "part_of", ToID: entitygraph.BatchRef("goal")}},
+		}},
+		{Kind: entitygraph.BatchCreateEntity, Ref: "agency", Entity: entitygraph.CreateEntityRequest{TypeID: "Agency"}},
+		{Kind: entitygraph.BatchCreateRelationship, Relationship: entitygraph.CreateRelationshipRequest{
+			Name: "has_goal", FromID: entitygraph.BatchRef("agency"), ToID: entitygraph.BatchRef("goal"),
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Batch: %v", err)
+	}
+	if len(result.Results) != 4 {
+		t.Fatalf("got %d results, want 4", len(result.Results))
+	}
+	goal, task := result.Results[0].Entity, result.Results[1].Entity
+	if goal == nil || task == nil || result.Results[3].Relationship == nil {
+		t.Fatalf("missing results: %+v", result.Results)
+	}
+	if result.Refs["goal"] != goal.ID || result.Refs["task"] != task.ID || result.Refs["agency"] == "" {
+		t.Errorf("Refs = %v, want goal=%s task=%s and agency", result.Refs, goal.ID, task.ID)
+	}
+	if rel := result.Results[3].Relationship; rel.FromID != result.Refs["agency"] || rel.ToID != goal.ID {
+		t.Errorf("has_goal = %s → %s, want %s → %s", rel.FromID, rel.ToID, result.Refs["agency"], goal.ID)
+	}
+
+	rels, err := dm.ListRelationships(ctx, entitygraph.RelationshipFilter{AgencyID: agencyA, FromID: task.ID, Name: "part_of"})
+	if err != nil {
+		t.Fatalf("ListRelationships: %v", err)
+	}
+	if len(rels) != 1 || rels[0].ToID != goal.ID {
+		t.Errorf("task part_of edges = %+v, want one to %s", rels, goal.ID)
+	}
+}
+
+func testBatchRollback(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	note := mustCreate(t, dm, agencyA, "Note", map[string]any{"text": "before"})
+
+	_, err := dm.Batch(ctx, agencyA, []entitygraph.BatchOp{
+		{Kind: entitygraph.BatchCreateEntity, Ref: "goal", Entity: entitygraph.CreateEntityRequest{
+			TypeID: "Goal", Properties: map[string]any{"code": "G1"},
+		}},
+		{Kind: entitygraph.BatchUpdateEntity, EntityID: note.ID, Update: entitygraph.UpdateEntityRequest{
+			Properties: map[string]any{"text": "after"},
+		}},
+		{Kind: entitygraph.BatchCreateEntity, Entity: entitygraph.CreateEntityRequest{
+			TypeID: "Goal", Properties: map[string]any{"code": "G1"},
+		}},
+	})
+	var opErr *entitygraph.BatchOpError
+	if !errors.As(err, &opErr) || opErr.Index != 2 {
+		t.Fatalf("Batch: got %v, want a BatchOpError for op 2", err)
+	}
+	if !errors.Is(err, entitygraph.ErrEntityAlreadyExists) {
+		t.Errorf("Batch: got %v, want it to wrap ErrEntityAlreadyExists", err)
+	}
+
+	goals, err := dm.ListEntities(ctx, entitygraph.EntityFilter{AgencyID: agencyA, TypeID: "Goal"})
+	if err != nil {
+		t.Fatalf("ListEntities: %v", err)
+	}
+	if len(goals) != 0 {
+		t.Errorf("failed batch left %d goals, want 0", len(goals))
+	}
+	got, err := dm.GetEntity(ctx, agencyA, note.ID)
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	if got.Properties["text"] != "before" || got.Revision != note.Revision {
+		t.Errorf("failed batch changed the note: %+v", got)
+	}
+}
+
+func testBatchUnknownRef(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	for name, ops := range map[string][]entitygraph.BatchOp{
+		"empty": nil,
+		"unknown ref": {
+			{Kind: entitygraph.BatchCreateEntity, Entity: entitygraph.CreateEntityRequest{TypeID: "Note"}},
+			{Kind: entitygraph.BatchUpdateEntity, EntityID: entitygraph.BatchRef("missing")},
+		},
+		"forward ref": {
+			{Kind: entitygraph.BatchCreateRelationship, Relationship: entitygraph.CreateRelationshipRequest{
+				Name: "has_goal", FromID: entitygraph.BatchRef("agency"), ToID: entitygraph.BatchRef("goal"),
+			}},
+			{Kind: entitygraph.BatchCreateEntity, Ref: "agency", Entity: entitygraph.CreateEntityRequest{TypeID: "Agency"}},
+			{Kind: entitygraph.BatchCreateEntity, Ref: "goal", Entity: entitygraph.CreateEntityRequest{TypeID: "Goal"}},
+		},
+		"duplicate ref": {
+			{Kind: entitygraph.BatchCreateEntity, Ref: "n", Entity: entitygraph.CreateEntityRequest{TypeID: "Note"}},
+			{Kind: entitygraph.BatchCreateEntity, Ref: "n", Entity: entitygraph.CreateEntityRequest{TypeID: "Note"}},
+		},
+		"unknown kind": {{Kind: "rename_entity", EntityID: "x"}},
+	} {
+		if _, err := dm.Batch(ctx, agencyA, ops); !errors.Is(err, entitygraph.ErrInvalidBatch) {
+			t.Errorf("%s: got %v, want ErrInvalidBatch", name, err)
+		}
+	}
+	all, err := dm.ListEntities(ctx, entitygraph.EntityFilter{AgencyID: agencyA})
+	if err != nil {
+		t.Fatalf("ListEntities: %v", err)
+	}
+	if len(all) != 0 {
+		t.Errorf("invalid batches wrote %d entities, want 0", len(all))
+	}
+}
+
+func testBatchMixed(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	goal := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1", "title": "old"})
+	doomed := mustCreate(t, dm, agencyA, "Note", nil)
+	dep := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G2"})
+	edge := mustRelate(t, dm, agencyA, "depends_on", goal.ID, dep.ID)
+
+	result, err := dm.Batch(ctx, agencyA, []entitygraph.BatchOp{
+		{Kind: entitygraph.BatchUpdateEntity, EntityID: goal.ID, Update: entitygraph.UpdateEntityRequest{
+			Properties: map[string]any{"title": "new"}, IfMatch: goal.Revision,
+		}},
+		{Kind: entitygraph.BatchUpsertEntity, Entity: entitygraph.CreateEntityRequest{
+			TypeID: "Goal", Properties: map[string]any{"code": "G2", "rank": 3},
+		}},
+		{Kind: entitygraph.BatchDeleteEntity, EntityID: doomed.ID, IfMatch: doomed.Revision},
+		{Kind: entitygraph.BatchDeleteRelationship, RelationshipID: edge.ID},
+		{Kind: entitygraph.BatchCreateRelationship, Relationship: entitygraph.CreateRelationshipRequest{
+			Name: "depends_on", FromID: dep.ID, ToID: goal.ID,
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Batch: %v", err)
+	}
+	if e := result.Results[0].Entity; e == nil || e.Properties["title"] != "new" {
+		t.Errorf("update result = %+v, want title new", e)
+	}
+	if e := result.Results[1].Entity; e == nil || e.ID != dep.ID {
+		t.Errorf("upsert result = %+v, want merged onto %s", e, dep.ID)
+	}
+	if _, err := dm.GetEntity(ctx, agencyA, doomed.ID); !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("GetEntity(deleted): got %v, want ErrEntityNotFound", err)
+	}
+	if _, err := dm.GetRelationship(ctx, agencyA, edge.ID); !errors.Is(err, entitygraph.ErrRelationshipNotFound) {
+		t.Errorf("GetRelationship(deleted): got %v, want ErrRelationshipNotFound", err)
+	}
+	rels, err := dm.ListRelationships(ctx, entitygraph.RelationshipFilter{AgencyID: agencyA, FromID: dep.ID, Name: "depends_on"})
+	if err != nil {
+		t.Fatalf("ListRelationships: %v", err)
+	}
+	if len(rels) != 1 || rels[0].ToID != goal.ID {
+		t.Errorf("dep depends_on edges = %+v, want one to %s", rels, goal.ID)
+	}
+}
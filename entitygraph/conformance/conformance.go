@@ -0,0 +1,295 @@
+// Package conformance is the executable behavioural contract for
+// [entitygraph.DataManager] and [entitygraph.SchemaManager] implementations.
+//
+// Every backend (ArangoDB, in-memory, …) runs the same suites from its own
+// _test.go file, so behaviour such as "GetEntity hides soft-deleted entities"
+// or "Activate deactivates all other versions" is pinned down once rather
+// than living only in doc comments:
+//
+//	func TestConformance(t *testing.T) {
+//	    conformance.RunDataManagerSuite(t, func(t *testing.T, s types.Schema) entitygraph.DataManager {
+//	        return memory.NewBackend(memory.Config{Schema: s})
+//	    })
+//	    conformance.RunSchemaManagerSuite(t, func(t *testing.T) entitygraph.SchemaManager {
+//	        return memory.NewBackend(memory.Config{})
+//	    })
+//	}
+//
+// Factories are called once per sub-test and must return an empty store; any
+// cleanup should be registered with t.Cleanup.
+//
+// File layout:
+//   - conformance.go   — factories, fixture schema, suite entry points, helpers
+//   - entities.go      — entity lifecycle cases (create, get, update, delete, list, upsert)
+//   - relationships.go — relationship and TraverseGraph cases
+//   - validation.go    — PropertyDefinition validation cases
+//   - inline.go        — CreateEntity/UpsertEntity inline Relationships cases
+//   - inverse.go       — automatic inverse edge cases
+//   - deletion.go      — DeleteEntity edge removal and OnDelete policy cases
+//   - restore.go       — IncludeDeleted, RestoreEntity and purge cases
+//   - paging.go        — ListEntitiesPage / ListRelationshipsPage cases
+//   - filter.go        — EntityFilter.Where expression cases
+//   - search.go        — SearchEntities cases
+//   - uniquekey.go     — UniqueKey enforcement cases
+//   - revision.go      — Revision / IfMatch optimistic concurrency cases
+//   - batch.go         — Batch cases
+//   - history.go       — GetEntityHistory / GetEntityAt cases
+//   - schemaops.go     — SchemaManager cases (draft/publish/activate lifecycle)
+package conformance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+// Agency IDs used by every case. Two agencies are needed to verify isolation.
+const (
+	agencyA = "conformance-agency-a"
+	agencyB = "conformance-agency-b"
+)
+
+// DataManagerFactory returns a fresh, empty DataManager whose type-level
+// behaviour (immutability, UniqueKey, …) is driven by schema.
+type DataManagerFactory func(t *testing.T, schema types.Schema) entitygraph.DataManager
+
+// SchemaManagerFactory returns a fresh SchemaManager with no drafts or
+// published versions.
+type SchemaManagerFactory func(t *testing.T) entitygraph.SchemaManager
+
+// Schema returns the fixture schema passed to every [DataManagerFactory]
+// call. It declares:
+//
+//   - Agency   — mutable, has_goal → Goal (ToMany)
+//   - Goal     — mutable, UniqueKey ["code"], "rank" Indexed,
+//     belongs_to_agency → Agency
+//   - Snapshot — Immutable
+//   - Reading  — mutable, one property of each validated PropertyType;
+//     "serial" is Required
+//   - Task     — mutable, UniqueKey ["title"], part_of → Goal (Required),
+//     tagged → Agency (ToMany)
+//   - Workflow — mutable, has_item → WorkItem (ToMany, Inverse "belongs_to",
+//     OnDelete cascade)
+//   - WorkItem — mutable, History, "title", "description" and "labels"
+//     Searchable, belongs_to → Workflow (Inverse "has_item"),
+//     has_note → Note (ToMany, OnDelete cascade),
+//     locked_by → Snapshot (OnDelete restrict)
+//   - Note     — mutable, no relationships
+func Schema() types.Schema {
+	return types.Schema{
+		ID:       "conformance",
+		AgencyID: agencyA,
+		Tag:      "v1",
+		Types: []types.TypeDefinition{
+			{
+				Name: "Agency",
+				Properties: []types.PropertyDefinition{
+					{Name: "name", Type: types.PropertyTypeString},
+					{Name: "rank", Type: types.PropertyTypeInteger},
+				},
+				Relationships: []types.RelationshipDefinition{
+					{Name: "has_goal", ToType: "Goal", ToMany: true},
+					{Name: "has_snapshot", ToType: "Snapshot", ToMany: true},
+				},
+			},
+			{
+				Name: "Goal",
+				Properties: []types.PropertyDefinition{
+					{Name: "code", Type: types.PropertyTypeString},
+					{Name: "title", Type: types.PropertyTypeString},
+					{Name: "rank", Type: types.PropertyTypeInteger, Indexed: true},
+				},
+				Relationships: []types.RelationshipDefinition{
+					{Name: "belongs_to_agency", ToType: "Agency"},
+					{Name: "depends_on", ToType: "Goal", ToMany: true},
+				},
+				UniqueKey: []string{"code"},
+			},
+			{
+				Name:      "Snapshot",
+				Immutable: true,
+				Properties: []types.PropertyDefinition{
+					{Name: "label", Type: types.PropertyTypeString},
+				},
+			},
+			{
+				Name: "Reading",
+				Properties: []types.PropertyDefinition{
+					{Name: "serial", Type: types.PropertyTypeString, Required: true},
+					{Name: "count", Type: types.PropertyTypeInteger},
+					{Name: "level", Type: types.PropertyTypeRating, RatingConfig: &types.RatingConfig{Min: 1, Max: 5}},
+					{Name: "status", Type: types.PropertyTypeOption, Options: []string{"open", "closed"}},
+					{Name: "taken_on", Type: types.PropertyTypeDate},
+					{Name: "device", Type: types.PropertyTypeUUID},
+					{Name: "tags", Type: types.PropertyTypeArray, ElementType: types.PropertyTypeString},
+				},
+			},
+			{
+				Name: "Task",
+				Properties: []types.PropertyDefinition{
+					{Name: "title", Type: types.PropertyTypeString},
+				},
+				Relationships: []types.RelationshipDefinition{
+					{Name: "part_of", ToType: "Goal", Required: true},
+					{Name: "tagged", ToType: "Agency", ToMany: true},
+				},
+				UniqueKey: []string{"title"},
+			},
+			{
+				Name: "Workflow",
+				Properties: []types.PropertyDefinition{
+					{Name: "name", Type: types.PropertyTypeString},
+				},
+				Relationships: []types.RelationshipDefinition{
+					{Name: "has_item", ToType: "WorkItem", ToMany: true, Inverse: "belongs_to", OnDelete: types.DeletePolicyCascade},
+				},
+			},
+			{
+				Name:    "WorkItem",
+				History: true,
+				Properties: []types.PropertyDefinition{
+					{Name: "title", Type: types.PropertyTypeString, Searchable: true},
+					{Name: "description", Type: types.PropertyTypeString, Searchable: true},
+					{Name: "labels", Type: types.PropertyTypeArray, ElementType: types.PropertyTypeString, Searchable: true},
+					{Name: "code", Type: types.PropertyTypeString},
+				},
+				Relationships: []types.RelationshipDefinition{
+					{Name: "belongs_to", ToType: "Workflow", Inverse: "has_item"},
+					{Name: "has_note", ToType: "Note", ToMany: true, OnDelete: types.DeletePolicyCascade},
+					{Name: "locked_by", ToType: "Snapshot", OnDelete: types.DeletePolicyRestrict},
+				},
+			},
+			{Name: "Note"},
+		},
+	}
+}
+
+// RunDataManagerSuite runs every DataManager conformance case as a sub-test
+// of t, calling factory once per case.
+func RunDataManagerSuite(t *testing.T, factory DataManagerFactory) {
+	t.Helper()
+	cases := append(entityCases(), relationshipCases()...)
+	cases = append(cases, validationCases()...)
+	cases = append(cases, inlineCases()...)
+	cases = append(cases, inverseCases()...)
+	cases = append(cases, deletionCases()...)
+	cases = append(cases, restoreCases()...)
+	cases = append(cases, pagingCases()...)
+	cases = append(cases, filterCases()...)
+	cases = append(cases, searchCases()...)
+	cases = append(cases, uniqueKeyCases()...)
+	cases = append(cases, revisionCases()...)
+	cases = append(cases, batchCases()...)
+	cases = append(cases, historyCases()...)
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			c.run(t, factory(t, Schema()))
+		})
+	}
+}
+
+// RunSchemaManagerSuite runs every SchemaManager conformance case as a
+// sub-test of t, calling factory once per case.
+func RunSchemaManagerSuite(t *testing.T, factory SchemaManagerFactory) {
+	t.Helper()
+	for _, c := range schemaManagerCases() {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			c.run(t, factory(t))
+		})
+	}
+}
+
+// dmCase is a single named DataManager conformance case.
+type dmCase struct {
+	name string
+	run  func(t *testing.T, dm entitygraph.DataManager)
+}
+
+// smCase is a single named SchemaManager conformance case.
+type smCase struct {
+	name string
+	run  func(t *testing.T, sm entitygraph.SchemaManager)
+}
+
+// ── helpers ──────────────────────────────────────────────────────────────────
+
+func mustCreate(t *testing.T, dm entitygraph.DataManager, agencyID, typeID string, props map[string]any) entitygraph.Entity {
+	t.Helper()
+	e, err := dm.CreateEntity(context.Background(), entitygraph.CreateEntityRequest{
+		AgencyID: agencyID, TypeID: typeID, Properties: props,
+	})
+	if err != nil {
+		t.Fatalf("CreateEntity(%s): %v", typeID, err)
+	}
+	return e
+}
+
+func mustRelate(t *testing.T, dm entitygraph.DataManager, agencyID, name, fromID, toID string) entitygraph.Relationship {
+	t.Helper()
+	r, err := dm.CreateRelationship(context.Background(), entitygraph.CreateRelationshipRequest{
+		AgencyID: agencyID, Name: name, FromID: fromID, ToID: toID,
+	})
+	if err != nil {
+		t.Fatalf("CreateRelationship(%s): %v", name, err)
+	}
+	return r
+}
+
+// entityIDs returns the IDs of es in order.
+func entityIDs(es []entitygraph.Entity) []string {
+	ids := make([]string, len(es))
+	for i, e := range es {
+		ids[i] = e.ID
+	}
+	return ids
+}
+
+// relationshipIDs returns the IDs of rs in order.
+func relationshipIDs(rs []entitygraph.Relationship) []string {
+	ids := make([]string, len(rs))
+	for i, r := range rs {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+// sameSet reports whether got and want contain the same IDs, ignoring order
+// and duplicates. Backends are not required to return list results in any
+// particular order.
+func sameSet(got, want []string) bool {
+	g := make(map[string]struct{}, len(got))
+	for _, id := range got {
+		g[id] = struct{}{}
+	}
+	w := make(map[string]struct{}, len(want))
+	for _, id := range want {
+		w[id] = struct{}{}
+	}
+	if len(g) != len(w) {
+		return false
+	}
+	for id := range w {
+		if _, ok := g[id]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// numberEquals reports whether v is a numeric value equal to want. Backends
+// may return numbers as int, int64, or float64 depending on their encoding.
+func numberEquals(v any, want float64) bool {
+	switch n := v.(type) {
+	case int:
+		return float64(n) == want
+	case int64:
+		return float64(n) == want
+	case float64:
+		return n == want
+	}
+	return false
+}
@@ -0,0 +1,249 @@
+// deletion.go contains the conformance cases for DeleteEntity's handling of
+// edges: every edge touching a deleted entity is removed, no new edge may
+// touch it, the RelationshipDefinition.OnDelete policies detach, cascade, and
+// restrict are applied recursively and atomically, and no surviving entity is
+// left without a Required relationship.
+package conformance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+)
+
+func deletionCases() []dmCase {
+	return []dmCase{
+		{"DeleteEntity_RemovesEdges", testDeleteEntityRemovesEdges},
+		{"DeleteEntity_Detach_KeepsTargets", testDeleteEntityDetachKeepsTargets},
+		{"DeleteEntity_Cascade_DeletesTargetsRecursively", testDeleteEntityCascadeRecursive},
+		{"DeleteEntity_Cascade_LeavesUnrelatedEntities", testDeleteEntityCascadeLeavesUnrelated},
+		{"DeleteEntity_InboundCascadeEdge_Detached", testDeleteEntityInboundCascadeDetached},
+		{"DeleteEntity_Restrict_ErrDeleteRestricted", testDeleteEntityRestrict},
+		{"DeleteEntity_RestrictInCascade_DeletesNothing", testDeleteEntityRestrictInCascade},
+		{"DeleteEntity_RequiredInbound_ErrRequiredRelationshipViolation", testDeleteEntityRequiredInbound},
+		{"CreateRelationship_DeletedEndpoint_ErrEntityNotFound", testCreateRelationshipDeletedEndpoint},
+	}
+}
+
+// wantGone fails t unless every entity in ids is hidden from GetEntity.
+func wantGone(t *testing.T, dm entitygraph.DataManager, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		if _, err := dm.GetEntity(context.Background(), agencyA, id); !errors.Is(err, entitygraph.ErrEntityNotFound) {
+			t.Errorf("GetEntity(%s): got %v, want ErrEntityNotFound", id, err)
+		}
+	}
+}
+
+// wantLive fails t unless every entity in ids is still readable.
+func wantLive(t *testing.T, dm entitygraph.DataManager, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		if _, err := dm.GetEntity(context.Background(), agencyA, id); err != nil {
+			t.Errorf("GetEntity(%s): %v, want live entity", id, err)
+		}
+	}
+}
+
+// edgesTouching returns every agencyA edge from or to entityID.
+func edgesTouching(t *testing.T, dm entitygraph.DataManager, entityID string) []entitygraph.Relationship {
+	t.Helper()
+	ctx := context.Background()
+	from, err := dm.ListRelationships(ctx, entitygraph.RelationshipFilter{AgencyID: agencyA, FromID: entityID})
+	if err != nil {
+		t.Fatalf("ListRelationships(from): %v", err)
+	}
+	to, err := dm.ListRelationships(ctx, entitygraph.RelationshipFilter{AgencyID: agencyA, ToID: entityID})
+	if err != nil {
+		t.Fatalf("ListRelationships(to): %v", err)
+	}
+	return append(from, to...)
+}
+
+func testDeleteEntityRemovesEdges(t *testing.T, dm entitygraph.DataManager) {
+	a := mustCreate(t, dm, agencyA, "Agency", nil)
+	g := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	mustRelate(t, dm, agencyA, "has_goal", a.ID, g.ID)
+	mustRelate(t, dm, agencyA, "belongs_to_agency", g.ID, a.ID)
+
+	if err := dm.DeleteEntity(context.Background(), agencyA, g.ID); err != nil {
+		t.Fatalf("DeleteEntity: %v", err)
+	}
+	if edges := edgesTouching(t, dm, g.ID); len(edges) != 0 {
+		t.Errorf("got %d edges touching deleted entity, want 0: %v", len(edges), relationshipIDs(edges))
+	}
+}
+
+func testDeleteEntityDetachKeepsTargets(t *testing.T, dm entitygraph.DataManager) {
+	w := mustCreate(t, dm, agencyA, "Workflow", nil)
+	item := mustCreate(t, dm, agencyA, "WorkItem", nil)
+	mustRelate(t, dm, agencyA, "belongs_to", item.ID, w.ID)
+
+	if err := dm.DeleteEntity(context.Background(), agencyA, item.ID); err != nil {
+		t.Fatalf("DeleteEntity: %v", err)
+	}
+	wantLive(t, dm, w.ID)
+	if edges := edgesTouching(t, dm, w.ID); len(edges) != 0 {
+		t.Errorf("got %d edges on surviving workflow, want 0 (inverse removed too)", len(edges))
+	}
+}
+
+func testDeleteEntityCascadeRecursive(t *testing.T, dm entitygraph.DataManager) {
+	w := mustCreate(t, dm, agencyA, "Workflow", nil)
+	i1 := mustCreate(t, dm, agencyA, "WorkItem", nil)
+	i2 := mustCreate(t, dm, agencyA, "WorkItem", nil)
+	n := mustCreate(t, dm, agencyA, "Note", nil)
+	mustRelate(t, dm, agencyA, "has_item", w.ID, i1.ID)
+	mustRelate(t, dm, agencyA, "has_item", w.ID, i2.ID)
+	mustRelate(t, dm, agencyA, "has_note", i1.ID, n.ID)
+
+	if err := dm.DeleteEntity(context.Background(), agencyA, w.ID); err != nil {
+		t.Fatalf("DeleteEntity: %v", err)
+	}
+	wantGone(t, dm, w.ID, i1.ID, i2.ID, n.ID)
+	edges, err := dm.ListRelationships(context.Background(), entitygraph.RelationshipFilter{AgencyID: agencyA})
+	if err != nil {
+		t.Fatalf("ListRelationships: %v", err)
+	}
+	if len(edges) != 0 {
+		t.Errorf("got %d edges after cascade, want 0: %v", len(edges), relationshipIDs(edges))
+	}
+}
+
+func testDeleteEntityCascadeLeavesUnrelated(t *testing.T, dm entitygraph.DataManager) {
+	w1 := mustCreate(t, dm, agencyA, "Workflow", nil)
+	w2 := mustCreate(t, dm, agencyA, "Workflow", nil)
+	i1 := mustCreate(t, dm, agencyA, "WorkItem", nil)
+	i2 := mustCreate(t, dm, agencyA, "WorkItem", nil)
+	mustRelate(t, dm, agencyA, "has_item", w1.ID, i1.ID)
+	kept := mustRelate(t, dm, agencyA, "has_item", w2.ID, i2.ID)
+
+	if err := dm.DeleteEntity(context.Background(), agencyA, w1.ID); err != nil {
+		t.Fatalf("DeleteEntity: %v", err)
+	}
+	wantGone(t, dm, w1.ID, i1.ID)
+	wantLive(t, dm, w2.ID, i2.ID)
+	if _, err := dm.GetRelationship(context.Background(), agencyA, kept.ID); err != nil {
+		t.Errorf("GetRelationship(unrelated edge): %v", err)
+	}
+}
+
+func testDeleteEntityInboundCascadeDetached(t *testing.T, dm entitygraph.DataManager) {
+	item := mustCreate(t, dm, agencyA, "WorkItem", nil)
+	n := mustCreate(t, dm, agencyA, "Note", nil)
+	mustRelate(t, dm, agencyA, "has_note", item.ID, n.ID)
+
+	// The cascade policy belongs to the source side; deleting the target
+	// only detaches the edge.
+	if err := dm.DeleteEntity(context.Background(), agencyA, n.ID); err != nil {
+		t.Fatalf("DeleteEntity: %v", err)
+	}
+	wantLive(t, dm, item.ID)
+	if edges := edgesTouching(t, dm, item.ID); len(edges) != 0 {
+		t.Errorf("got %d edges on source after deleting target, want 0", len(edges))
+	}
+}
+
+func testDeleteEntityRestrict(t *testing.T, dm entitygraph.DataManager) {
+	item := mustCreate(t, dm, agencyA, "WorkItem", nil)
+	s := mustCreate(t, dm, agencyA, "Snapshot", nil)
+	lock := mustRelate(t, dm, agencyA, "locked_by", item.ID, s.ID)
+
+	if err := dm.DeleteEntity(context.Background(), agencyA, item.ID); !errors.Is(err, entitygraph.ErrDeleteRestricted) {
+		t.Fatalf("DeleteEntity: got %v, want ErrDeleteRestricted", err)
+	}
+	wantLive(t, dm, item.ID)
+	if _, err := dm.GetRelationship(context.Background(), agencyA, lock.ID); err != nil {
+		t.Errorf("GetRelationship(restricting edge): %v", err)
+	}
+
+	if err := dm.DeleteRelationship(context.Background(), agencyA, lock.ID); err != nil {
+		t.Fatalf("DeleteRelationship: %v", err)
+	}
+	if err := dm.DeleteEntity(context.Background(), agencyA, item.ID); err != nil {
+		t.Errorf("DeleteEntity after removing restricting edge: %v", err)
+	}
+}
+
+func testDeleteEntityRestrictInCascade(t *testing.T, dm entitygraph.DataManager) {
+	w := mustCreate(t, dm, agencyA, "Workflow", nil)
+	free := mustCreate(t, dm, agencyA, "WorkItem", nil)
+	locked := mustCreate(t, dm, agencyA, "WorkItem", nil)
+	s := mustCreate(t, dm, agencyA, "Snapshot", nil)
+	mustRelate(t, dm, agencyA, "has_item", w.ID, free.ID)
+	mustRelate(t, dm, agencyA, "has_item", w.ID, locked.ID)
+	mustRelate(t, dm, agencyA, "locked_by", locked.ID, s.ID)
+
+	if err := dm.DeleteEntity(context.Background(), agencyA, w.ID); !errors.Is(err, entitygraph.ErrDeleteRestricted) {
+		t.Fatalf("DeleteEntity: got %v, want ErrDeleteRestricted", err)
+	}
+	wantLive(t, dm, w.ID, free.ID, locked.ID, s.ID)
+	if n := len(edgesNamed(t, dm, "has_item")); n != 2 {
+		t.Errorf("got %d has_item edges after blocked delete, want 2", n)
+	}
+}
+
+func testDeleteEntityRequiredInbound(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	g := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	task, err := dm.CreateEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: agencyA, TypeID: "Task", Properties: map[string]any{"title": "T1"},
+		Relationships: []entitygraph.EntityRelationshipRequest{{Name: "part_of", ToID: g.ID}},
+	})
+	if err != nil {
+		t.Fatalf("CreateEntity(Task): %v", err)
+	}
+
+	// Deleting the parent would orphan the task's Required part_of edge.
+	if err := dm.DeleteEntity(ctx, agencyA, g.ID); !errors.Is(err, entitygraph.ErrRequiredRelationshipViolation) {
+		t.Fatalf("DeleteEntity(parent): got %v, want ErrRequiredRelationshipViolation", err)
+	}
+	wantLive(t, dm, g.ID, task.ID)
+	if n := len(edgesNamed(t, dm, "part_of")); n != 1 {
+		t.Errorf("got %d part_of edges after blocked delete, want 1", n)
+	}
+
+	if err := dm.DeleteEntity(ctx, agencyA, task.ID); err != nil {
+		t.Fatalf("DeleteEntity(child): %v", err)
+	}
+	if err := dm.DeleteEntity(ctx, agencyA, g.ID); err != nil {
+		t.Errorf("DeleteEntity(parent) after deleting the child: %v", err)
+	}
+}
+
+func testCreateRelationshipDeletedEndpoint(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	a := mustCreate(t, dm, agencyA, "Agency", nil)
+	g := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	w := mustCreate(t, dm, agencyA, "Workflow", nil)
+	item := mustCreate(t, dm, agencyA, "WorkItem", nil)
+	for _, id := range []string{g.ID, item.ID} {
+		if err := dm.DeleteEntity(ctx, agencyA, id); err != nil {
+			t.Fatalf("DeleteEntity(%s): %v", id, err)
+		}
+	}
+
+	for _, req := range []entitygraph.CreateRelationshipRequest{
+		{AgencyID: agencyA, Name: "has_goal", FromID: a.ID, ToID: g.ID},
+		{AgencyID: agencyA, Name: "belongs_to_agency", FromID: g.ID, ToID: a.ID},
+		{AgencyID: agencyA, Name: "has_item", FromID: w.ID, ToID: item.ID},
+	} {
+		if _, err := dm.CreateRelationship(ctx, req); !errors.Is(err, entitygraph.ErrEntityNotFound) {
+			t.Errorf("CreateRelationship %s %s→%s: got %v, want ErrEntityNotFound", req.Name, req.FromID, req.ToID, err)
+		}
+	}
+	_, err := dm.CreateEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: agencyA, TypeID: "Task", Properties: map[string]any{"title": "T1"},
+		Relationships: []entitygraph.EntityRelationshipRequest{{Name: "part_of", ToID: g.ID}},
+	})
+	if !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("CreateEntity with an edge to a deleted entity: got %v, want ErrEntityNotFound", err)
+	}
+	for _, id := range []string{g.ID, item.ID} {
+		if edges := edgesTouching(t, dm, id); len(edges) != 0 {
+			t.Errorf("got %d edges touching deleted entity %s, want 0", len(edges), id)
+		}
+	}
+}
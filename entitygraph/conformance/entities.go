@@ -0,0 +1,342 @@
+// entities.go contains the entity lifecycle conformance cases: CreateEntity,
+// GetEntity, UpdateEntity, DeleteEntity, ListEntities, and UpsertEntity.
+package conformance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+)
+
+func entityCases() []dmCase {
+	return []dmCase{
+		{"CreateEntity_AssignsIDAndTimestamps", testCreateEntityAssignsIDAndTimestamps},
+		{"CreateEntity_NilProperties_ReturnsEmptyMap", testCreateEntityNilProperties},
+		{"GetEntity_RoundTripsProperties", testGetEntityRoundTrip},
+		{"GetEntity_UnknownID_ErrEntityNotFound", testGetEntityUnknownID},
+		{"GetEntity_OtherAgency_ErrEntityNotFound", testGetEntityOtherAgency},
+		{"UpdateEntity_MergesProperties", testUpdateEntityMerges},
+		{"UpdateEntity_UnknownID_ErrEntityNotFound", testUpdateEntityUnknownID},
+		{"UpdateEntity_ImmutableType_ErrImmutableType", testUpdateEntityImmutable},
+		{"DeleteEntity_HidesFromGetAndList", testDeleteEntityHides},
+		{"DeleteEntity_Twice_ErrEntityNotFound", testDeleteEntityTwice},
+		{"DeleteEntity_OtherAgency_ErrEntityNotFound", testDeleteEntityOtherAgency},
+		{"DeleteEntity_ImmutableType_Allowed", testDeleteEntityImmutable},
+		{"ListEntities_FiltersByAgencyTypeAndProperties", testListEntitiesFilters},
+		{"ListEntities_EmptyFilter_ReturnsAllLive", testListEntitiesEmptyFilter},
+		{"UpsertEntity_NoMatch_Inserts", testUpsertEntityInserts},
+		{"UpsertEntity_Match_MergesOntoExisting", testUpsertEntityMerges},
+		{"UpsertEntity_DeletedMatch_InsertsNew", testUpsertEntityIgnoresDeleted},
+		{"UpsertEntity_ScopedByAgency", testUpsertEntityScopedByAgency},
+		{"UpsertEntity_NoUniqueKey_ErrUniqueKeyNotDefined", testUpsertEntityNoUniqueKey},
+		{"UpsertEntityResult_ReportsInsertAndMerge", testUpsertEntityResult},
+	}
+}
+
+func testCreateEntityAssignsIDAndTimestamps(t *testing.T, dm entitygraph.DataManager) {
+	e := mustCreate(t, dm, agencyA, "Agency", map[string]any{"name": "Acme"})
+	if e.ID == "" {
+		t.Error("ID is empty")
+	}
+	if e.AgencyID != agencyA || e.TypeID != "Agency" {
+		t.Errorf("AgencyID/TypeID = %q/%q, want %q/%q", e.AgencyID, e.TypeID, agencyA, "Agency")
+	}
+	if e.CreatedAt.IsZero() || e.UpdatedAt.IsZero() {
+		t.Error("CreatedAt and UpdatedAt must be set")
+	}
+	if e.Deleted || e.DeletedAt != nil {
+		t.Error("new entity must not be marked deleted")
+	}
+	other := mustCreate(t, dm, agencyA, "Agency", nil)
+	if other.ID == e.ID {
+		t.Error("two CreateEntity calls returned the same ID")
+	}
+}
+
+func testCreateEntityNilProperties(t *testing.T, dm entitygraph.DataManager) {
+	e := mustCreate(t, dm, agencyA, "Agency", nil)
+	got, err := dm.GetEntity(context.Background(), agencyA, e.ID)
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	if got.Properties == nil {
+		t.Error("Properties is nil, want empty map")
+	}
+}
+
+func testGetEntityRoundTrip(t *testing.T, dm entitygraph.DataManager) {
+	e := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1", "title": "Grow", "rank": 3})
+	got, err := dm.GetEntity(context.Background(), agencyA, e.ID)
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	if got.ID != e.ID || got.TypeID != "Goal" || got.AgencyID != agencyA {
+		t.Errorf("got %+v, want ID=%s TypeID=Goal AgencyID=%s", got, e.ID, agencyA)
+	}
+	if got.Properties["code"] != "G1" || got.Properties["title"] != "Grow" {
+		t.Errorf("string properties = %v", got.Properties)
+	}
+	if !numberEquals(got.Properties["rank"], 3) {
+		t.Errorf("rank = %v (%T), want 3", got.Properties["rank"], got.Properties["rank"])
+	}
+}
+
+func testGetEntityUnknownID(t *testing.T, dm entitygraph.DataManager) {
+	_, err := dm.GetEntity(context.Background(), agencyA, "does-not-exist")
+	if !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("got %v, want ErrEntityNotFound", err)
+	}
+}
+
+func testGetEntityOtherAgency(t *testing.T, dm entitygraph.DataManager) {
+	e := mustCreate(t, dm, agencyA, "Agency", nil)
+	_, err := dm.GetEntity(context.Background(), agencyB, e.ID)
+	if !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("got %v, want ErrEntityNotFound", err)
+	}
+}
+
+func testUpdateEntityMerges(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	e := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1", "title": "old"})
+	updated, err := dm.UpdateEntity(ctx, agencyA, e.ID, entitygraph.UpdateEntityRequest{
+		Properties: map[string]any{"title": "new"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateEntity: %v", err)
+	}
+	if updated.Properties["code"] != "G1" || updated.Properties["title"] != "new" {
+		t.Errorf("returned properties = %v, want code=G1 title=new", updated.Properties)
+	}
+	if updated.UpdatedAt.Before(e.UpdatedAt) {
+		t.Error("UpdatedAt moved backwards")
+	}
+	if !updated.CreatedAt.Equal(e.CreatedAt) {
+		t.Errorf("CreatedAt changed: %v → %v", e.CreatedAt, updated.CreatedAt)
+	}
+	got, err := dm.GetEntity(ctx, agencyA, e.ID)
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	if got.Properties["title"] != "new" {
+		t.Errorf("stored title = %v, want %q", got.Properties["title"], "new")
+	}
+}
+
+func testUpdateEntityUnknownID(t *testing.T, dm entitygraph.DataManager) {
+	_, err := dm.UpdateEntity(context.Background(), agencyA, "does-not-exist", entitygraph.UpdateEntityRequest{})
+	if !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("got %v, want ErrEntityNotFound", err)
+	}
+}
+
+func testUpdateEntityImmutable(t *testing.T, dm entitygraph.DataManager) {
+	e := mustCreate(t, dm, agencyA, "Snapshot", map[string]any{"label": "s1"})
+	_, err := dm.UpdateEntity(context.Background(), agencyA, e.ID, entitygraph.UpdateEntityRequest{
+		Properties: map[string]any{"label": "s2"},
+	})
+	if !errors.Is(err, entitygraph.ErrImmutableType) {
+		t.Errorf("got %v, want ErrImmutableType", err)
+	}
+}
+
+func testDeleteEntityHides(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	keep := mustCreate(t, dm, agencyA, "Agency", nil)
+	gone := mustCreate(t, dm, agencyA, "Agency", nil)
+	if err := dm.DeleteEntity(ctx, agencyA, gone.ID); err != nil {
+		t.Fatalf("DeleteEntity: %v", err)
+	}
+	if _, err := dm.GetEntity(ctx, agencyA, gone.ID); !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("GetEntity after delete: got %v, want ErrEntityNotFound", err)
+	}
+	if _, err := dm.UpdateEntity(ctx, agencyA, gone.ID, entitygraph.UpdateEntityRequest{}); !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("UpdateEntity after delete: got %v, want ErrEntityNotFound", err)
+	}
+	list, err := dm.ListEntities(ctx, entitygraph.EntityFilter{AgencyID: agencyA})
+	if err != nil {
+		t.Fatalf("ListEntities: %v", err)
+	}
+	if !sameSet(entityIDs(list), []string{keep.ID}) {
+		t.Errorf("ListEntities = %v, want [%s]", entityIDs(list), keep.ID)
+	}
+}
+
+func testDeleteEntityTwice(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	e := mustCreate(t, dm, agencyA, "Agency", nil)
+	if err := dm.DeleteEntity(ctx, agencyA, e.ID); err != nil {
+		t.Fatalf("DeleteEntity: %v", err)
+	}
+	if err := dm.DeleteEntity(ctx, agencyA, e.ID); !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("second DeleteEntity: got %v, want ErrEntityNotFound", err)
+	}
+}
+
+func testDeleteEntityOtherAgency(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	e := mustCreate(t, dm, agencyA, "Agency", nil)
+	if err := dm.DeleteEntity(ctx, agencyB, e.ID); !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("got %v, want ErrEntityNotFound", err)
+	}
+	if _, err := dm.GetEntity(ctx, agencyA, e.ID); err != nil {
+		t.Errorf("entity must survive a cross-agency delete: %v", err)
+	}
+}
+
+func testDeleteEntityImmutable(t *testing.T, dm entitygraph.DataManager) {
+	e := mustCreate(t, dm, agencyA, "Snapshot", nil)
+	if err := dm.DeleteEntity(context.Background(), agencyA, e.ID); err != nil {
+		t.Errorf("DeleteEntity on immutable type: %v", err)
+	}
+}
+
+func testListEntitiesFilters(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	g1 := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1", "rank": 1})
+	g2 := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G2", "rank": 2})
+	a1 := mustCreate(t, dm, agencyA, "Agency", map[string]any{"rank": 1})
+	mustCreate(t, dm, agencyB, "Goal", map[string]any{"code": "G1", "rank": 1})
+
+	cases := []struct {
+		name   string
+		filter entitygraph.EntityFilter
+		want   []string
+	}{
+		{"agency only", entitygraph.EntityFilter{AgencyID: agencyA}, []string{g1.ID, g2.ID, a1.ID}},
+		{"agency and type", entitygraph.EntityFilter{AgencyID: agencyA, TypeID: "Goal"}, []string{g1.ID, g2.ID}},
+		{"string property", entitygraph.EntityFilter{AgencyID: agencyA, TypeID: "Goal", Properties: map[string]any{"code": "G2"}}, []string{g2.ID}},
+		{"numeric property across types", entitygraph.EntityFilter{AgencyID: agencyA, Properties: map[string]any{"rank": 1}}, []string{g1.ID, a1.ID}},
+		{"no match", entitygraph.EntityFilter{AgencyID: agencyA, Properties: map[string]any{"code": "nope"}}, nil},
+		{"property name with AQL syntax", entitygraph.EntityFilter{AgencyID: agencyA, Properties: map[string]any{"code` != null OR doc.properties.`code": "G1"}}, nil},
+		{"property with Where", entitygraph.EntityFilter{AgencyID: agencyA, Properties: map[string]any{"rank": 1}, Where: &entitygraph.FilterExpr{Op: entitygraph.FilterEq, Property: "code", Value: "G1"}}, []string{g1.ID}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := dm.ListEntities(ctx, c.filter)
+			if err != nil {
+				t.Fatalf("ListEntities: %v", err)
+			}
+			if !sameSet(entityIDs(got), c.want) {
+				t.Errorf("got %v, want %v", entityIDs(got), c.want)
+			}
+		})
+	}
+}
+
+func testListEntitiesEmptyFilter(t *testing.T, dm entitygraph.DataManager) {
+	a := mustCreate(t, dm, agencyA, "Agency", nil)
+	b := mustCreate(t, dm, agencyB, "Goal", map[string]any{"code": "G1"})
+	got, err := dm.ListEntities(context.Background(), entitygraph.EntityFilter{})
+	if err != nil {
+		t.Fatalf("ListEntities: %v", err)
+	}
+	if !sameSet(entityIDs(got), []string{a.ID, b.ID}) {
+		t.Errorf("got %v, want [%s %s]", entityIDs(got), a.ID, b.ID)
+	}
+}
+
+func testUpsertEntityInserts(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	e, err := dm.UpsertEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: agencyA, TypeID: "Goal", Properties: map[string]any{"code": "G1", "title": "a"},
+	})
+	if err != nil {
+		t.Fatalf("UpsertEntity: %v", err)
+	}
+	if _, err := dm.GetEntity(ctx, agencyA, e.ID); err != nil {
+		t.Errorf("GetEntity on upserted entity: %v", err)
+	}
+}
+
+func testUpsertEntityMerges(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	first := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1", "title": "a", "rank": 1})
+	second, err := dm.UpsertEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: agencyA, TypeID: "Goal", Properties: map[string]any{"code": "G1", "title": "b"},
+	})
+	if err != nil {
+		t.Fatalf("UpsertEntity: %v", err)
+	}
+	if second.ID != first.ID {
+		t.Errorf("UpsertEntity inserted %s, want merge onto %s", second.ID, first.ID)
+	}
+	if second.Properties["title"] != "b" || !numberEquals(second.Properties["rank"], 1) {
+		t.Errorf("merged properties = %v, want title=b rank=1", second.Properties)
+	}
+	list, _ := dm.ListEntities(ctx, entitygraph.EntityFilter{AgencyID: agencyA, TypeID: "Goal"})
+	if len(list) != 1 {
+		t.Errorf("got %d Goals, want 1", len(list))
+	}
+}
+
+func testUpsertEntityIgnoresDeleted(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	old := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	if err := dm.DeleteEntity(ctx, agencyA, old.ID); err != nil {
+		t.Fatalf("DeleteEntity: %v", err)
+	}
+	e, err := dm.UpsertEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: agencyA, TypeID: "Goal", Properties: map[string]any{"code": "G1"},
+	})
+	if err != nil {
+		t.Fatalf("UpsertEntity: %v", err)
+	}
+	if e.ID == old.ID {
+		t.Error("UpsertEntity resurrected a soft-deleted entity")
+	}
+}
+
+func testUpsertEntityScopedByAgency(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	a := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	b, err := dm.UpsertEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: agencyB, TypeID: "Goal", Properties: map[string]any{"code": "G1"},
+	})
+	if err != nil {
+		t.Fatalf("UpsertEntity: %v", err)
+	}
+	if b.ID == a.ID || b.AgencyID != agencyB {
+		t.Errorf("UpsertEntity matched across agencies: got %+v", b)
+	}
+}
+
+func testUpsertEntityResult(t *testing.T, dm entitygraph.DataManager) {
+	u, ok := dm.(entitygraph.EntityUpserter)
+	if !ok {
+		t.Skip("DataManager does not implement EntityUpserter")
+	}
+	ctx := context.Background()
+	inserted, err := u.UpsertEntityResult(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: agencyA, TypeID: "Goal", Properties: map[string]any{"code": "G1", "title": "a"},
+	})
+	if err != nil {
+		t.Fatalf("UpsertEntityResult(insert): %v", err)
+	}
+	if inserted.Previous != nil {
+		t.Errorf("insert Previous = %+v, want nil", inserted.Previous)
+	}
+	merged, err := u.UpsertEntityResult(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: agencyA, TypeID: "Goal", Properties: map[string]any{"code": "G1", "title": "b"},
+	})
+	if err != nil {
+		t.Fatalf("UpsertEntityResult(merge): %v", err)
+	}
+	if merged.Entity.ID != inserted.Entity.ID || merged.Entity.Properties["title"] != "b" {
+		t.Errorf("merge Entity = %+v, want %s with title=b", merged.Entity, inserted.Entity.ID)
+	}
+	if p := merged.Previous; p == nil || p.ID != inserted.Entity.ID || p.Properties["title"] != "a" {
+		t.Errorf("merge Previous = %+v, want %s with title=a", p, inserted.Entity.ID)
+	}
+}
+
+func testUpsertEntityNoUniqueKey(t *testing.T, dm entitygraph.DataManager) {
+	_, err := dm.UpsertEntity(context.Background(), entitygraph.CreateEntityRequest{
+		AgencyID: agencyA, TypeID: "Agency", Properties: map[string]any{"name": "Acme"},
+	})
+	if !errors.Is(err, entitygraph.ErrUniqueKeyNotDefined) {
+		t.Errorf("got %v, want ErrUniqueKeyNotDefined", err)
+	}
+}
@@ -0,0 +1,195 @@
+// filter.go contains the conformance cases for EntityFilter.Where: every
+// comparison operator, logical combinations, the treatment of missing
+// properties, and rejection of malformed filter trees.
+package conformance
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+)
+
+func filterCases() []dmCase {
+	return []dmCase{
+		{"ListEntities_Where_ComparisonOperators", testWhereComparisons},
+		{"ListEntities_Where_LogicalOperators", testWhereLogical},
+		{"ListEntities_Where_MissingPropertyIsNull", testWhereMissingProperty},
+		{"ListEntities_Where_CombinesWithPropertiesAndPaging", testWhereWithPaging},
+		{"ListEntities_Where_PropertyNameIsData", testWherePropertyNameIsData},
+		{"ListEntities_Where_Invalid_ErrInvalidFilter", testWhereInvalid},
+	}
+}
+
+// seedReadings creates four Reading entities in agencyA and one in agencyB.
+// Cases identify them by serial.
+func seedReadings(t *testing.T, dm entitygraph.DataManager) {
+	t.Helper()
+	mustCreate(t, dm, agencyA, "Reading", map[string]any{"serial": "r-001", "count": 1, "status": "open", "tags": []any{"hot", "wet"}})
+	mustCreate(t, dm, agencyA, "Reading", map[string]any{"serial": "r-002", "count": 5, "status": "closed", "tags": []any{"cold"}})
+	mustCreate(t, dm, agencyA, "Reading", map[string]any{"serial": "r-010", "count": 10, "status": "open"})
+	mustCreate(t, dm, agencyA, "Reading", map[string]any{"serial": "x-100"})
+	mustCreate(t, dm, agencyB, "Reading", map[string]any{"serial": "r-001", "count": 1, "status": "open"})
+}
+
+// whereSerials lists agencyA's Readings matching where and returns their
+// sorted serials.
+func whereSerials(t *testing.T, dm entitygraph.DataManager, where entitygraph.FilterExpr) []string {
+	t.Helper()
+	got, err := dm.ListEntities(context.Background(), entitygraph.EntityFilter{
+		AgencyID: agencyA, TypeID: "Reading", Where: &where,
+	})
+	if err != nil {
+		t.Fatalf("ListEntities(%+v): %v", where, err)
+	}
+	serials := make([]string, len(got))
+	for i, e := range got {
+		serials[i], _ = e.Properties["serial"].(string)
+	}
+	slices.Sort(serials)
+	return serials
+}
+
+func testWhereComparisons(t *testing.T, dm entitygraph.DataManager) {
+	seedReadings(t, dm)
+	cases := []struct {
+		name  string
+		where entitygraph.FilterExpr
+		want  []string
+	}{
+		{"eq string", entitygraph.Eq("status", "open"), []string{"r-001", "r-010"}},
+		{"eq number", entitygraph.Eq("count", 5), []string{"r-002"}},
+		{"ne", entitygraph.Ne("status", "open"), []string{"r-002", "x-100"}},
+		{"lt", entitygraph.Lt("count", 5), []string{"r-001"}},
+		{"lte", entitygraph.Lte("count", 5), []string{"r-001", "r-002"}},
+		{"gt", entitygraph.Gt("count", 1), []string{"r-002", "r-010"}},
+		{"gte", entitygraph.Gte("count", 5), []string{"r-002", "r-010"}},
+		{"gt string", entitygraph.Gt("serial", "r-002"), []string{"r-010", "x-100"}},
+		{"in", entitygraph.In("count", 1, 10), []string{"r-001", "r-010"}},
+		{"contains element", entitygraph.Contains("tags", "wet"), []string{"r-001"}},
+		{"contains substring", entitygraph.Contains("serial", "-0"), []string{"r-001", "r-002", "r-010"}},
+		{"prefix", entitygraph.Prefix("serial", "r-0"), []string{"r-001", "r-002", "r-010"}},
+		{"exists", entitygraph.Exists("tags"), []string{"r-001", "r-002"}},
+	}
+	for _, tc := range cases {
+		if got := whereSerials(t, dm, tc.where); !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func testWhereLogical(t *testing.T, dm entitygraph.DataManager) {
+	seedReadings(t, dm)
+	cases := []struct {
+		name  string
+		where entitygraph.FilterExpr
+		want  []string
+	}{
+		{"and", entitygraph.And(entitygraph.Eq("status", "open"), entitygraph.Gt("count", 1)), []string{"r-010"}},
+		{"or", entitygraph.Or(entitygraph.Eq("count", 5), entitygraph.Prefix("serial", "x-")), []string{"r-002", "x-100"}},
+		{"not", entitygraph.Not(entitygraph.Exists("status")), []string{"x-100"}},
+		{"nested", entitygraph.And(
+			entitygraph.Prefix("serial", "r-"),
+			entitygraph.Not(entitygraph.Or(entitygraph.Eq("count", 1), entitygraph.Contains("tags", "cold"))),
+		), []string{"r-010"}},
+	}
+	for _, tc := range cases {
+		if got := whereSerials(t, dm, tc.where); !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func testWhereMissingProperty(t *testing.T, dm entitygraph.DataManager) {
+	seedReadings(t, dm)
+	if got := whereSerials(t, dm, entitygraph.Eq("count", nil)); !slices.Equal(got, []string{"x-100"}) {
+		t.Errorf("eq null: got %v, want [x-100]", got)
+	}
+	// A missing property is null, which is never ordered against a number.
+	if got := whereSerials(t, dm, entitygraph.Lt("count", 100)); !slices.Equal(got, []string{"r-001", "r-002", "r-010"}) {
+		t.Errorf("lt: got %v, want the three Readings with a count", got)
+	}
+	if got := whereSerials(t, dm, entitygraph.Contains("tags", "hot")); !slices.Equal(got, []string{"r-001"}) {
+		t.Errorf("contains: got %v, want [r-001]", got)
+	}
+}
+
+func testWhereWithPaging(t *testing.T, dm entitygraph.DataManager) {
+	seedReadings(t, dm)
+	where := entitygraph.Prefix("serial", "r-")
+	got, _ := collectPages(t, dm, entitygraph.EntityFilter{
+		AgencyID:   agencyA,
+		TypeID:     "Reading",
+		Properties: map[string]any{"status": "open"},
+		Where:      &where,
+		OrderBy:    []entitygraph.OrderBy{{Field: "properties.count", Desc: true}},
+		Limit:      1,
+	})
+	var serials []string
+	for _, e := range got {
+		serials = append(serials, e.Properties["serial"].(string))
+	}
+	if !slices.Equal(serials, []string{"r-010", "r-001"}) {
+		t.Errorf("paged serials = %v, want [r-010 r-001]", serials)
+	}
+
+	page, err := dm.ListEntitiesPage(context.Background(), entitygraph.EntityFilter{
+		AgencyID: agencyA, TypeID: "Reading", Where: &where, Limit: 1, IncludeTotal: true,
+	})
+	if err != nil {
+		t.Fatalf("ListEntitiesPage: %v", err)
+	}
+	if page.Total != 3 {
+		t.Errorf("Total = %d, want 3 (only entities matching Where)", page.Total)
+	}
+}
+
+func testWherePropertyNameIsData(t *testing.T, dm entitygraph.DataManager) {
+	mustCreate(t, dm, agencyA, "Note", nil)
+	// Without a TypeID the property is not checked against a schema; the
+	// name must still be treated as data rather than query text.
+	where := entitygraph.Eq("x` == null || true || `y", nil)
+	got, err := dm.ListEntities(context.Background(), entitygraph.EntityFilter{AgencyID: agencyA, Where: &where})
+	if err != nil {
+		t.Fatalf("ListEntities: %v", err)
+	}
+	if len(got) != 1 {
+		t.Errorf("got %d entities, want 1 (the Note, whose odd property is missing and so null)", len(got))
+	}
+	where = entitygraph.Exists("x` == null || true || `y")
+	got, err = dm.ListEntities(context.Background(), entitygraph.EntityFilter{AgencyID: agencyA, Where: &where})
+	if err != nil {
+		t.Fatalf("ListEntities: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("got %d entities, want 0", len(got))
+	}
+}
+
+func testWhereInvalid(t *testing.T, dm entitygraph.DataManager) {
+	cases := []struct {
+		name  string
+		where entitygraph.FilterExpr
+	}{
+		{"undeclared property", entitygraph.Eq("colour", "red")},
+		{"wrong operand type", entitygraph.Eq("count", "five")},
+		{"ordered bool", entitygraph.Gt("serial", true)},
+		{"prefix on number", entitygraph.Prefix("count", "1")},
+		{"empty in", entitygraph.In("count")},
+		{"empty and", entitygraph.And()},
+		{"unknown operator", entitygraph.FilterExpr{Op: "like", Property: "serial", Value: "r%"}},
+		{"missing property", entitygraph.FilterExpr{Op: entitygraph.FilterEq, Value: 1}},
+		{"not with two operands", entitygraph.FilterExpr{Op: entitygraph.FilterNot, Operands: []entitygraph.FilterExpr{entitygraph.Exists("count"), entitygraph.Exists("tags")}}},
+	}
+	for _, tc := range cases {
+		where := tc.where
+		_, err := dm.ListEntities(context.Background(), entitygraph.EntityFilter{
+			AgencyID: agencyA, TypeID: "Reading", Where: &where,
+		})
+		if !errors.Is(err, entitygraph.ErrInvalidFilter) {
+			t.Errorf("%s: got %v, want ErrInvalidFilter", tc.name, err)
+		}
+	}
+}
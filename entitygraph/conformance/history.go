@@ -0,0 +1,160 @@
+// history.go contains the conformance cases for entity change history:
+// GetEntityHistory, GetEntityAt and the actor recorded by WithActor. The
+// fixture's WorkItem type sets History; Note does not.
+package conformance
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+)
+
+func historyCases() []dmCase {
+	return []dmCase{
+		{"GetEntityHistory_RecordsEveryChange", testHistoryRecordsChanges},
+		{"GetEntityAt_ReplaysPastStates", testEntityAt},
+		{"GetEntityHistory_TypeWithoutHistory_ErrHistoryNotEnabled", testHistoryNotEnabled},
+		{"GetEntityHistory_CascadeDeleteAndPurge", testHistoryCascadeAndPurge},
+	}
+}
+
+func testHistoryRecordsChanges(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	created, err := dm.CreateEntity(entitygraph.WithActor(ctx, "alice"), entitygraph.CreateEntityRequest{
+		AgencyID: agencyA, TypeID: "WorkItem", Properties: map[string]any{"title": "v1"},
+	})
+	if err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+	updated, err := dm.UpdateEntity(entitygraph.WithActor(ctx, "bob"), agencyA, created.ID, entitygraph.UpdateEntityRequest{
+		Properties: map[string]any{"title": "v2"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateEntity: %v", err)
+	}
+	if err := dm.DeleteEntity(ctx, agencyA, created.ID); err != nil {
+		t.Fatalf("DeleteEntity: %v", err)
+	}
+	restored, err := dm.RestoreEntity(entitygraph.WithActor(ctx, "carol"), agencyA, created.ID)
+	if err != nil {
+		t.Fatalf("RestoreEntity: %v", err)
+	}
+
+	history, err := dm.GetEntityHistory(ctx, agencyA, created.ID)
+	if err != nil {
+		t.Fatalf("GetEntityHistory: %v", err)
+	}
+	var changes, actors, titles []string
+	for _, rev := range history {
+		changes = append(changes, string(rev.Change))
+		actors = append(actors, rev.Actor)
+		title, _ := rev.Entity.Properties["title"].(string)
+		titles = append(titles, title)
+		if rev.Entity.ID != created.ID || !rev.RecordedAt.Equal(rev.Entity.UpdatedAt) {
+			t.Errorf("revision %s: entity %s, RecordedAt %v, UpdatedAt %v", rev.Change, rev.Entity.ID, rev.RecordedAt, rev.Entity.UpdatedAt)
+		}
+	}
+	if want := []string{"created", "updated", "deleted", "restored"}; !slices.Equal(changes, want) {
+		t.Fatalf("changes = %v, want %v", changes, want)
+	}
+	if want := []string{"alice", "bob", "", "carol"}; !slices.Equal(actors, want) {
+		t.Errorf("actors = %v, want %v", actors, want)
+	}
+	if want := []string{"v1", "v2", "v2", "v2"}; !slices.Equal(titles, want) {
+		t.Errorf("titles = %v, want %v", titles, want)
+	}
+	if !history[2].Entity.Deleted || history[3].Entity.Deleted {
+		t.Errorf("Deleted flags = %v, %v; want true, false", history[2].Entity.Deleted, history[3].Entity.Deleted)
+	}
+	for i, want := range map[int]string{0: created.Revision, 1: updated.Revision, 3: restored.Revision} {
+		if history[i].Entity.Revision != want {
+			t.Errorf("revision %d Revision = %q, want %q", i, history[i].Entity.Revision, want)
+		}
+	}
+}
+
+func testEntityAt(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	v1 := mustCreate(t, dm, agencyA, "WorkItem", map[string]any{"title": "v1"})
+	v2, err := dm.UpdateEntity(ctx, agencyA, v1.ID, entitygraph.UpdateEntityRequest{
+		Properties: map[string]any{"title": "v2"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateEntity: %v", err)
+	}
+	mustDelete(t, dm, agencyA, v1.ID)
+	history, err := dm.GetEntityHistory(ctx, agencyA, v1.ID)
+	if err != nil {
+		t.Fatalf("GetEntityHistory: %v", err)
+	}
+	deletedAt := history[len(history)-1].RecordedAt
+
+	for _, tc := range []struct {
+		name  string
+		at    time.Time
+		title string // "" expects ErrEntityNotFound
+	}{
+		{"before create", v1.UpdatedAt.Add(-time.Millisecond), ""},
+		{"at create", v1.UpdatedAt, "v1"},
+		{"at update", v2.UpdatedAt, "v2"},
+		{"after delete", deletedAt.Add(time.Millisecond), ""},
+	} {
+		e, err := dm.GetEntityAt(ctx, agencyA, v1.ID, tc.at)
+		if tc.title == "" {
+			if !errors.Is(err, entitygraph.ErrEntityNotFound) {
+				t.Errorf("%s: got %v, want ErrEntityNotFound", tc.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: GetEntityAt: %v", tc.name, err)
+			continue
+		}
+		if e.Properties["title"] != tc.title {
+			t.Errorf("%s: title = %v, want %s", tc.name, e.Properties["title"], tc.title)
+		}
+	}
+}
+
+func testHistoryNotEnabled(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	note := mustCreate(t, dm, agencyA, "Note", nil)
+	if _, err := dm.GetEntityHistory(ctx, agencyA, note.ID); !errors.Is(err, entitygraph.ErrHistoryNotEnabled) {
+		t.Errorf("GetEntityHistory(Note): got %v, want ErrHistoryNotEnabled", err)
+	}
+	if _, err := dm.GetEntityAt(ctx, agencyA, note.ID, time.Now()); !errors.Is(err, entitygraph.ErrHistoryNotEnabled) {
+		t.Errorf("GetEntityAt(Note): got %v, want ErrHistoryNotEnabled", err)
+	}
+
+	item := mustCreate(t, dm, agencyA, "WorkItem", nil)
+	if _, err := dm.GetEntityHistory(ctx, agencyB, item.ID); !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("GetEntityHistory from another agency: got %v, want ErrEntityNotFound", err)
+	}
+}
+
+func testHistoryCascadeAndPurge(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	wf := mustCreate(t, dm, agencyA, "Workflow", nil)
+	item := mustCreate(t, dm, agencyA, "WorkItem", map[string]any{"title": "child"})
+	mustRelate(t, dm, agencyA, "has_item", wf.ID, item.ID)
+	mustDelete(t, dm, agencyA, wf.ID)
+
+	history, err := dm.GetEntityHistory(ctx, agencyA, item.ID)
+	if err != nil {
+		t.Fatalf("GetEntityHistory: %v", err)
+	}
+	if len(history) != 2 || history[1].Change != entitygraph.ChangeDeleted {
+		t.Fatalf("history = %+v, want created then deleted by the cascade", history)
+	}
+
+	if err := dm.PurgeEntity(ctx, agencyA, item.ID); err != nil {
+		t.Fatalf("PurgeEntity: %v", err)
+	}
+	if _, err := dm.GetEntityHistory(ctx, agencyA, item.ID); !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("GetEntityHistory after purge: got %v, want ErrEntityNotFound", err)
+	}
+}
@@ -0,0 +1,183 @@
+// inline.go contains the conformance cases for CreateEntityRequest.Relationships:
+// inline edges are written atomically with the entity, Required relationships
+// are enforced, and a rejected request persists neither entity nor edges.
+package conformance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+)
+
+func inlineCases() []dmCase {
+	return []dmCase{
+		{"CreateEntity_InlineRelationships_CreatesEdges", testCreateEntityInlineCreatesEdges},
+		{"CreateEntity_MissingRequired_ErrRequiredRelationshipViolation", testCreateEntityMissingRequiredRelationship},
+		{"CreateEntity_InlineUndeclaredName_ErrInvalidRelationship", testCreateEntityInlineUndeclared},
+		{"CreateEntity_InlineWrongTargetType_ErrInvalidRelationship", testCreateEntityInlineWrongTarget},
+		{"CreateEntity_InlineMissingTarget_ErrEntityNotFound", testCreateEntityInlineMissingTarget},
+		{"CreateEntity_InlineDuplicateToOne_ErrRelationshipCardinalityViolation", testCreateEntityInlineDuplicateToOne},
+		{"UpsertEntity_Insert_RequiresRequiredRelationships", testUpsertEntityInsertRequiresRelationships},
+		{"UpsertEntity_Merge_AppliesEdgesIdempotently", testUpsertEntityMergeEdgesIdempotent},
+	}
+}
+
+func createTask(dm entitygraph.DataManager, title string, rels ...entitygraph.EntityRelationshipRequest) (entitygraph.Entity, error) {
+	return dm.CreateEntity(context.Background(), entitygraph.CreateEntityRequest{
+		AgencyID: agencyA, TypeID: "Task", Properties: map[string]any{"title": title}, Relationships: rels,
+	})
+}
+
+// wantNoTasks fails t if any Task entity or any edge from a Task was persisted.
+func wantNoTasks(t *testing.T, dm entitygraph.DataManager) {
+	t.Helper()
+	ctx := context.Background()
+	tasks, err := dm.ListEntities(ctx, entitygraph.EntityFilter{AgencyID: agencyA, TypeID: "Task"})
+	if err != nil {
+		t.Fatalf("ListEntities: %v", err)
+	}
+	if len(tasks) != 0 {
+		t.Errorf("got %d Task entities after rejected request, want 0", len(tasks))
+	}
+	for _, name := range []string{"part_of", "tagged"} {
+		edges, err := dm.ListRelationships(ctx, entitygraph.RelationshipFilter{AgencyID: agencyA, Name: name})
+		if err != nil {
+			t.Fatalf("ListRelationships: %v", err)
+		}
+		if len(edges) != 0 {
+			t.Errorf("got %d %s edges after rejected request, want 0", len(edges), name)
+		}
+	}
+}
+
+func testCreateEntityInlineCreatesEdges(t *testing.T, dm entitygraph.DataManager) {
+	g := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	a1 := mustCreate(t, dm, agencyA, "Agency", nil)
+	a2 := mustCreate(t, dm, agencyA, "Agency", nil)
+	task, err := createTask(dm, "T1",
+		entitygraph.EntityRelationshipRequest{Name: "part_of", ToID: g.ID},
+		entitygraph.EntityRelationshipRequest{Name: "tagged", ToID: a1.ID},
+		entitygraph.EntityRelationshipRequest{Name: "tagged", ToID: a2.ID},
+	)
+	if err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+	edges, err := dm.ListRelationships(context.Background(), entitygraph.RelationshipFilter{AgencyID: agencyA, FromID: task.ID})
+	if err != nil {
+		t.Fatalf("ListRelationships: %v", err)
+	}
+	got := map[string][]string{}
+	for _, e := range edges {
+		got[e.Name] = append(got[e.Name], e.ToID)
+	}
+	if !sameSet(got["part_of"], []string{g.ID}) || len(got["part_of"]) != 1 {
+		t.Errorf("part_of targets = %v, want [%s]", got["part_of"], g.ID)
+	}
+	if !sameSet(got["tagged"], []string{a1.ID, a2.ID}) || len(got["tagged"]) != 2 {
+		t.Errorf("tagged targets = %v, want [%s %s]", got["tagged"], a1.ID, a2.ID)
+	}
+}
+
+func testCreateEntityMissingRequiredRelationship(t *testing.T, dm entitygraph.DataManager) {
+	a := mustCreate(t, dm, agencyA, "Agency", nil)
+	_, err := createTask(dm, "T1", entitygraph.EntityRelationshipRequest{Name: "tagged", ToID: a.ID})
+	if !errors.Is(err, entitygraph.ErrRequiredRelationshipViolation) {
+		t.Errorf("got %v, want ErrRequiredRelationshipViolation", err)
+	}
+	wantNoTasks(t, dm)
+}
+
+func testCreateEntityInlineUndeclared(t *testing.T, dm entitygraph.DataManager) {
+	g := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	_, err := createTask(dm, "T1",
+		entitygraph.EntityRelationshipRequest{Name: "part_of", ToID: g.ID},
+		entitygraph.EntityRelationshipRequest{Name: "blocks", ToID: g.ID},
+	)
+	if !errors.Is(err, entitygraph.ErrInvalidRelationship) {
+		t.Errorf("got %v, want ErrInvalidRelationship", err)
+	}
+	wantNoTasks(t, dm)
+}
+
+func testCreateEntityInlineWrongTarget(t *testing.T, dm entitygraph.DataManager) {
+	g := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	_, err := createTask(dm, "T1",
+		entitygraph.EntityRelationshipRequest{Name: "part_of", ToID: g.ID},
+		entitygraph.EntityRelationshipRequest{Name: "tagged", ToID: g.ID},
+	)
+	if !errors.Is(err, entitygraph.ErrInvalidRelationship) {
+		t.Errorf("got %v, want ErrInvalidRelationship", err)
+	}
+	wantNoTasks(t, dm)
+}
+
+func testCreateEntityInlineMissingTarget(t *testing.T, dm entitygraph.DataManager) {
+	_, err := createTask(dm, "T1", entitygraph.EntityRelationshipRequest{Name: "part_of", ToID: "does-not-exist"})
+	if !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("got %v, want ErrEntityNotFound", err)
+	}
+	wantNoTasks(t, dm)
+}
+
+func testCreateEntityInlineDuplicateToOne(t *testing.T, dm entitygraph.DataManager) {
+	g1 := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	g2 := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G2"})
+	_, err := createTask(dm, "T1",
+		entitygraph.EntityRelationshipRequest{Name: "part_of", ToID: g1.ID},
+		entitygraph.EntityRelationshipRequest{Name: "part_of", ToID: g2.ID},
+	)
+	if !errors.Is(err, entitygraph.ErrRelationshipCardinalityViolation) {
+		t.Errorf("got %v, want ErrRelationshipCardinalityViolation", err)
+	}
+	wantNoTasks(t, dm)
+}
+
+func testUpsertEntityInsertRequiresRelationships(t *testing.T, dm entitygraph.DataManager) {
+	_, err := dm.UpsertEntity(context.Background(), entitygraph.CreateEntityRequest{
+		AgencyID: agencyA, TypeID: "Task", Properties: map[string]any{"title": "T1"},
+	})
+	if !errors.Is(err, entitygraph.ErrRequiredRelationshipViolation) {
+		t.Errorf("got %v, want ErrRequiredRelationshipViolation", err)
+	}
+	wantNoTasks(t, dm)
+}
+
+func testUpsertEntityMergeEdgesIdempotent(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	g1 := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	g2 := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G2"})
+	a := mustCreate(t, dm, agencyA, "Agency", nil)
+	upsert := func(goalID string) entitygraph.Entity {
+		t.Helper()
+		e, err := dm.UpsertEntity(ctx, entitygraph.CreateEntityRequest{
+			AgencyID: agencyA, TypeID: "Task", Properties: map[string]any{"title": "T1"},
+			Relationships: []entitygraph.EntityRelationshipRequest{
+				{Name: "part_of", ToID: goalID},
+				{Name: "tagged", ToID: a.ID},
+			},
+		})
+		if err != nil {
+			t.Fatalf("UpsertEntity: %v", err)
+		}
+		return e
+	}
+	first := upsert(g1.ID)
+	second := upsert(g2.ID)
+	if first.ID != second.ID {
+		t.Fatalf("second upsert created a new entity %s, want merge onto %s", second.ID, first.ID)
+	}
+	edges, err := dm.ListRelationships(ctx, entitygraph.RelationshipFilter{AgencyID: agencyA, FromID: first.ID})
+	if err != nil {
+		t.Fatalf("ListRelationships: %v", err)
+	}
+	if len(edges) != 2 {
+		t.Fatalf("got %d edges after repeated upsert, want 2: %+v", len(edges), edges)
+	}
+	for _, e := range edges {
+		if e.Name == "part_of" && e.ToID != g2.ID {
+			t.Errorf("part_of → %s, want replaced with %s", e.ToID, g2.ID)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+// inverse.go contains the conformance cases for automatic inverse edges:
+// a relationship that declares an Inverse writes a Derived reciprocal edge,
+// the two edges reference each other via PairID, and deleting or re-pointing
+// either side never leaves half a pair behind.
+package conformance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+)
+
+func inverseCases() []dmCase {
+	return []dmCase{
+		{"CreateRelationship_Inverse_CreatesDerivedPair", testInverseCreatesDerivedPair},
+		{"CreateRelationship_Inverse_CopiesProperties", testInverseCopiesProperties},
+		{"CreateRelationship_NoInverse_Unpaired", testNoInverseUnpaired},
+		{"DeleteRelationship_Primary_RemovesInverse", testDeletePrimaryRemovesInverse},
+		{"DeleteRelationship_Derived_RemovesPrimary", testDeleteDerivedRemovesPrimary},
+		{"CreateRelationship_ToOneRepoint_ReplacesInverse", testToOneRepointReplacesInverse},
+		{"CreateRelationship_InverseRepoint_RemovesStalePrimary", testInverseRepointRemovesStalePrimary},
+		{"CreateRelationship_Reassert_KeepsSinglePair", testReassertKeepsSinglePair},
+		{"CreateEntity_InlineRelationships_CreateInverse", testInlineCreatesInverse},
+	}
+}
+
+// edgesNamed returns every agencyA edge labelled name.
+func edgesNamed(t *testing.T, dm entitygraph.DataManager, name string) []entitygraph.Relationship {
+	t.Helper()
+	edges, err := dm.ListRelationships(context.Background(), entitygraph.RelationshipFilter{AgencyID: agencyA, Name: name})
+	if err != nil {
+		t.Fatalf("ListRelationships(%s): %v", name, err)
+	}
+	return edges
+}
+
+// wantPair fails t unless primary and derived are the two halves of one
+// inverse pair.
+func wantPair(t *testing.T, primary, derived entitygraph.Relationship) {
+	t.Helper()
+	if primary.Derived || !derived.Derived {
+		t.Errorf("Derived = (%v, %v), want (false, true)", primary.Derived, derived.Derived)
+	}
+	if primary.PairID != derived.ID || derived.PairID != primary.ID {
+		t.Errorf("PairID = (%q, %q), want (%q, %q)", primary.PairID, derived.PairID, derived.ID, primary.ID)
+	}
+	if derived.FromID != primary.ToID || derived.ToID != primary.FromID {
+		t.Errorf("derived edge %s→%s does not reverse primary %s→%s",
+			derived.FromID, derived.ToID, primary.FromID, primary.ToID)
+	}
+}
+
+func testInverseCreatesDerivedPair(t *testing.T, dm entitygraph.DataManager) {
+	w := mustCreate(t, dm, agencyA, "Workflow", nil)
+	item := mustCreate(t, dm, agencyA, "WorkItem", nil)
+	primary := mustRelate(t, dm, agencyA, "has_item", w.ID, item.ID)
+
+	inverse := edgesNamed(t, dm, "belongs_to")
+	if len(inverse) != 1 {
+		t.Fatalf("got %d belongs_to edges, want 1", len(inverse))
+	}
+	wantPair(t, primary, inverse[0])
+
+	stored, err := dm.GetRelationship(context.Background(), agencyA, primary.ID)
+	if err != nil {
+		t.Fatalf("GetRelationship: %v", err)
+	}
+	if stored.Derived || stored.PairID != inverse[0].ID {
+		t.Errorf("stored primary Derived=%v PairID=%q, want false %q", stored.Derived, stored.PairID, inverse[0].ID)
+	}
+}
+
+func testInverseCopiesProperties(t *testing.T, dm entitygraph.DataManager) {
+	w := mustCreate(t, dm, agencyA, "Workflow", nil)
+	item := mustCreate(t, dm, agencyA, "WorkItem", nil)
+	if _, err := dm.CreateRelationship(context.Background(), entitygraph.CreateRelationshipRequest{
+		AgencyID: agencyA, Name: "has_item", FromID: w.ID, ToID: item.ID,
+		Properties: map[string]any{"position": 2},
+	}); err != nil {
+		t.Fatalf("CreateRelationship: %v", err)
+	}
+	inverse := edgesNamed(t, dm, "belongs_to")
+	if len(inverse) != 1 {
+		t.Fatalf("got %d belongs_to edges, want 1", len(inverse))
+	}
+	if !numberEquals(inverse[0].Properties["position"], 2) {
+		t.Errorf("inverse properties = %v, want position 2", inverse[0].Properties)
+	}
+}
+
+func testNoInverseUnpaired(t *testing.T, dm entitygraph.DataManager) {
+	a := mustCreate(t, dm, agencyA, "Agency", nil)
+	g := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	r := mustRelate(t, dm, agencyA, "has_goal", a.ID, g.ID)
+	if r.Derived || r.PairID != "" {
+		t.Errorf("Derived=%v PairID=%q, want false and empty", r.Derived, r.PairID)
+	}
+	if n := len(edgesNamed(t, dm, "belongs_to_agency")); n != 0 {
+		t.Errorf("got %d belongs_to_agency edges, want 0", n)
+	}
+}
+
+func testDeletePrimaryRemovesInverse(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	w := mustCreate(t, dm, agencyA, "Workflow", nil)
+	item := mustCreate(t, dm, agencyA, "WorkItem", nil)
+	primary := mustRelate(t, dm, agencyA, "has_item", w.ID, item.ID)
+
+	if err := dm.DeleteRelationship(ctx, agencyA, primary.ID); err != nil {
+		t.Fatalf("DeleteRelationship: %v", err)
+	}
+	if n := len(edgesNamed(t, dm, "belongs_to")); n != 0 {
+		t.Errorf("got %d belongs_to edges after deleting primary, want 0", n)
+	}
+	if _, err := dm.GetRelationship(ctx, agencyA, primary.PairID); !errors.Is(err, entitygraph.ErrRelationshipNotFound) {
+		t.Errorf("GetRelationship(inverse): got %v, want ErrRelationshipNotFound", err)
+	}
+}
+
+func testDeleteDerivedRemovesPrimary(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	w := mustCreate(t, dm, agencyA, "Workflow", nil)
+	item := mustCreate(t, dm, agencyA, "WorkItem", nil)
+	primary := mustRelate(t, dm, agencyA, "has_item", w.ID, item.ID)
+
+	if err := dm.DeleteRelationship(ctx, agencyA, primary.PairID); err != nil {
+		t.Fatalf("DeleteRelationship: %v", err)
+	}
+	if _, err := dm.GetRelationship(ctx, agencyA, primary.ID); !errors.Is(err, entitygraph.ErrRelationshipNotFound) {
+		t.Errorf("GetRelationship(primary): got %v, want ErrRelationshipNotFound", err)
+	}
+}
+
+func testToOneRepointReplacesInverse(t *testing.T, dm entitygraph.DataManager) {
+	w1 := mustCreate(t, dm, agencyA, "Workflow", nil)
+	w2 := mustCreate(t, dm, agencyA, "Workflow", nil)
+	item := mustCreate(t, dm, agencyA, "WorkItem", nil)
+	mustRelate(t, dm, agencyA, "belongs_to", item.ID, w1.ID)
+	moved := mustRelate(t, dm, agencyA, "belongs_to", item.ID, w2.ID)
+
+	inverse := edgesNamed(t, dm, "has_item")
+	if len(inverse) != 1 {
+		t.Fatalf("got %d has_item edges, want 1", len(inverse))
+	}
+	if inverse[0].FromID != w2.ID {
+		t.Errorf("has_item from %s, want %s (the new target)", inverse[0].FromID, w2.ID)
+	}
+	wantPair(t, moved, inverse[0])
+}
+
+func testInverseRepointRemovesStalePrimary(t *testing.T, dm entitygraph.DataManager) {
+	w1 := mustCreate(t, dm, agencyA, "Workflow", nil)
+	w2 := mustCreate(t, dm, agencyA, "Workflow", nil)
+	item := mustCreate(t, dm, agencyA, "WorkItem", nil)
+	mustRelate(t, dm, agencyA, "has_item", w1.ID, item.ID)
+	// belongs_to is to-one, so the inverse of w2→item re-points item's
+	// belongs_to edge and w1→item would be left without a pair.
+	primary := mustRelate(t, dm, agencyA, "has_item", w2.ID, item.ID)
+
+	hasItem := edgesNamed(t, dm, "has_item")
+	if len(hasItem) != 1 || hasItem[0].ID != primary.ID {
+		t.Errorf("has_item edges = %v, want only %s", relationshipIDs(hasItem), primary.ID)
+	}
+	belongsTo := edgesNamed(t, dm, "belongs_to")
+	if len(belongsTo) != 1 {
+		t.Fatalf("got %d belongs_to edges, want 1", len(belongsTo))
+	}
+	wantPair(t, primary, belongsTo[0])
+}
+
+func testReassertKeepsSinglePair(t *testing.T, dm entitygraph.DataManager) {
+	w := mustCreate(t, dm, agencyA, "Workflow", nil)
+	item := mustCreate(t, dm, agencyA, "WorkItem", nil)
+	first := mustRelate(t, dm, agencyA, "belongs_to", item.ID, w.ID)
+	again := mustRelate(t, dm, agencyA, "belongs_to", item.ID, w.ID)
+
+	if again.ID != first.ID || again.PairID != first.PairID {
+		t.Errorf("re-asserted edge = (%s, pair %s), want (%s, pair %s)", again.ID, again.PairID, first.ID, first.PairID)
+	}
+	if n := len(edgesNamed(t, dm, "has_item")); n != 1 {
+		t.Errorf("got %d has_item edges, want 1", n)
+	}
+}
+
+func testInlineCreatesInverse(t *testing.T, dm entitygraph.DataManager) {
+	w := mustCreate(t, dm, agencyA, "Workflow", nil)
+	item, err := dm.CreateEntity(context.Background(), entitygraph.CreateEntityRequest{
+		AgencyID: agencyA, TypeID: "WorkItem",
+		Relationships: []entitygraph.EntityRelationshipRequest{{Name: "belongs_to", ToID: w.ID}},
+	})
+	if err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+	primary := edgesNamed(t, dm, "belongs_to")
+	inverse := edgesNamed(t, dm, "has_item")
+	if len(primary) != 1 || len(inverse) != 1 {
+		t.Fatalf("got %d belongs_to and %d has_item edges, want 1 each", len(primary), len(inverse))
+	}
+	if primary[0].FromID != item.ID {
+		t.Errorf("belongs_to from %s, want %s", primary[0].FromID, item.ID)
+	}
+	wantPair(t, primary[0], inverse[0])
+}
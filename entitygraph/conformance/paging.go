@@ -0,0 +1,216 @@
+// paging.go contains the conformance cases for ListEntitiesPage and
+// ListRelationshipsPage: keyset page tokens, OrderBy on properties and
+// timestamps, Limit, IncludeTotal, and rejection of malformed requests.
+package conformance
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+)
+
+func pagingCases() []dmCase {
+	return []dmCase{
+		{"ListEntitiesPage_WalksEveryEntityOnce", testPageWalksEveryEntity},
+		{"ListEntitiesPage_OrderByProperty", testPageOrderByProperty},
+		{"ListEntitiesPage_OrderByCreatedAtDesc", testPageOrderByCreatedAtDesc},
+		{"ListEntitiesPage_IncludeTotal", testPageIncludeTotal},
+		{"ListEntitiesPage_OnlyDeleted", testPageOnlyDeleted},
+		{"ListEntities_Limit_ReturnsFirstPage", testListEntitiesLimit},
+		{"ListEntitiesPage_InvalidRequest_ErrInvalidPageRequest", testPageInvalidRequest},
+		{"ListRelationshipsPage_WalksEveryEdgeOnce", testRelationshipPageWalk},
+	}
+}
+
+// collectPages lists every page of filter with the given limit and returns
+// the entities in page order together with the number of pages read.
+func collectPages(t *testing.T, dm entitygraph.DataManager, filter entitygraph.EntityFilter) ([]entitygraph.Entity, int) {
+	t.Helper()
+	var all []entitygraph.Entity
+	pages := 0
+	for {
+		page, err := dm.ListEntitiesPage(context.Background(), filter)
+		if err != nil {
+			t.Fatalf("ListEntitiesPage: %v", err)
+		}
+		pages++
+		if filter.Limit > 0 && len(page.Entities) > filter.Limit {
+			t.Fatalf("page %d has %d entities, want at most %d", pages, len(page.Entities), filter.Limit)
+		}
+		all = append(all, page.Entities...)
+		if page.NextPageToken == "" {
+			return all, pages
+		}
+		if pages > 100 {
+			t.Fatal("paging did not terminate")
+		}
+		filter.PageToken = page.NextPageToken
+	}
+}
+
+func testPageWalksEveryEntity(t *testing.T, dm entitygraph.DataManager) {
+	var want []string
+	for range 7 {
+		want = append(want, mustCreate(t, dm, agencyA, "Note", nil).ID)
+	}
+	mustCreate(t, dm, agencyB, "Note", nil)
+
+	got, pages := collectPages(t, dm, entitygraph.EntityFilter{AgencyID: agencyA, TypeID: "Note", Limit: 3})
+	if pages != 3 {
+		t.Errorf("read %d pages, want 3", pages)
+	}
+	slices.Sort(want)
+	if ids := entityIDs(got); !slices.Equal(ids, want) {
+		t.Errorf("paged IDs = %v, want %v (ID order, each once)", ids, want)
+	}
+}
+
+func testPageOrderByProperty(t *testing.T, dm entitygraph.DataManager) {
+	for _, rank := range []int{3, 1, 2, 1, 5} {
+		mustCreate(t, dm, agencyA, "Agency", map[string]any{"rank": rank})
+	}
+	order := []entitygraph.OrderBy{{Field: "properties.rank", Desc: true}}
+	got, _ := collectPages(t, dm, entitygraph.EntityFilter{AgencyID: agencyA, TypeID: "Agency", OrderBy: order, Limit: 2})
+	var ranks []float64
+	for _, e := range got {
+		n, _ := e.Properties["rank"].(float64) // JSON numbers decode as float64
+		ranks = append(ranks, n)
+	}
+	if want := []float64{5, 3, 2, 1, 1}; !slices.Equal(ranks, want) {
+		t.Errorf("ranks = %v, want %v", ranks, want)
+	}
+	if got[3].ID > got[4].ID {
+		t.Errorf("tied ranks not ordered by ID: %s before %s", got[3].ID, got[4].ID)
+	}
+}
+
+func testPageOrderByCreatedAtDesc(t *testing.T, dm entitygraph.DataManager) {
+	var created []string
+	for range 3 {
+		created = append(created, mustCreate(t, dm, agencyA, "Note", nil).ID)
+		time.Sleep(5 * time.Millisecond)
+	}
+	order := []entitygraph.OrderBy{{Field: entitygraph.OrderByCreatedAt, Desc: true}}
+	got, _ := collectPages(t, dm, entitygraph.EntityFilter{AgencyID: agencyA, TypeID: "Note", OrderBy: order, Limit: 1})
+	slices.Reverse(created)
+	if ids := entityIDs(got); !slices.Equal(ids, created) {
+		t.Errorf("IDs newest first = %v, want %v", ids, created)
+	}
+}
+
+func testPageIncludeTotal(t *testing.T, dm entitygraph.DataManager) {
+	for range 4 {
+		mustCreate(t, dm, agencyA, "Note", nil)
+	}
+	page, err := dm.ListEntitiesPage(context.Background(), entitygraph.EntityFilter{
+		AgencyID: agencyA, TypeID: "Note", Limit: 1, IncludeTotal: true,
+	})
+	if err != nil {
+		t.Fatalf("ListEntitiesPage: %v", err)
+	}
+	if page.Total != 4 || len(page.Entities) != 1 {
+		t.Errorf("Total=%d len=%d, want 4 and 1", page.Total, len(page.Entities))
+	}
+
+	page, err = dm.ListEntitiesPage(context.Background(), entitygraph.EntityFilter{AgencyID: agencyA, TypeID: "Note", Limit: 1})
+	if err != nil {
+		t.Fatalf("ListEntitiesPage: %v", err)
+	}
+	if page.Total != 0 {
+		t.Errorf("Total without IncludeTotal = %d, want 0", page.Total)
+	}
+}
+
+func testPageOnlyDeleted(t *testing.T, dm entitygraph.DataManager) {
+	mustCreate(t, dm, agencyA, "Note", nil)
+	gone := mustCreate(t, dm, agencyA, "Note", nil)
+	mustDelete(t, dm, agencyA, gone.ID)
+
+	page, err := dm.ListEntitiesPage(context.Background(), entitygraph.EntityFilter{
+		AgencyID: agencyA, TypeID: "Note", OnlyDeleted: true, IncludeTotal: true,
+	})
+	if err != nil {
+		t.Fatalf("ListEntitiesPage: %v", err)
+	}
+	if page.Total != 1 || len(page.Entities) != 1 || page.Entities[0].ID != gone.ID {
+		t.Errorf("OnlyDeleted = %v (total %d), want only %s", entityIDs(page.Entities), page.Total, gone.ID)
+	}
+}
+
+func testListEntitiesLimit(t *testing.T, dm entitygraph.DataManager) {
+	for range 3 {
+		mustCreate(t, dm, agencyA, "Note", nil)
+	}
+	got, err := dm.ListEntities(context.Background(), entitygraph.EntityFilter{AgencyID: agencyA, TypeID: "Note", Limit: 2})
+	if err != nil {
+		t.Fatalf("ListEntities: %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("got %d entities with Limit 2, want 2", len(got))
+	}
+}
+
+func testPageInvalidRequest(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	for range 2 {
+		mustCreate(t, dm, agencyA, "Note", nil)
+	}
+	first, err := dm.ListEntitiesPage(ctx, entitygraph.EntityFilter{AgencyID: agencyA, TypeID: "Note", Limit: 1})
+	if err != nil {
+		t.Fatalf("ListEntitiesPage: %v", err)
+	}
+	cases := map[string]entitygraph.EntityFilter{
+		"negative limit":   {AgencyID: agencyA, Limit: -1},
+		"garbage token":    {AgencyID: agencyA, PageToken: "not-a-token"},
+		"unknown field":    {AgencyID: agencyA, OrderBy: []entitygraph.OrderBy{{Field: "colour"}}},
+		"undeclared prop":  {AgencyID: agencyA, TypeID: "Note", OrderBy: []entitygraph.OrderBy{{Field: "properties.nope"}}},
+		"token, new order": {AgencyID: agencyA, TypeID: "Note", PageToken: first.NextPageToken, OrderBy: []entitygraph.OrderBy{{Field: entitygraph.OrderByCreatedAt}}},
+	}
+	for name, filter := range cases {
+		if _, err := dm.ListEntitiesPage(ctx, filter); !errors.Is(err, entitygraph.ErrInvalidPageRequest) {
+			t.Errorf("%s: got %v, want ErrInvalidPageRequest", name, err)
+		}
+	}
+	if _, err := dm.ListRelationshipsPage(ctx, entitygraph.RelationshipFilter{
+		AgencyID: agencyA, OrderBy: []entitygraph.OrderBy{{Field: entitygraph.OrderByUpdatedAt}},
+	}); !errors.Is(err, entitygraph.ErrInvalidPageRequest) {
+		t.Errorf("relationships by updated_at: got %v, want ErrInvalidPageRequest", err)
+	}
+}
+
+func testRelationshipPageWalk(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	a := mustCreate(t, dm, agencyA, "Agency", nil)
+	var want []string
+	for _, code := range []string{"G1", "G2", "G3", "G4", "G5"} {
+		g := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": code})
+		want = append(want, mustRelate(t, dm, agencyA, "has_goal", a.ID, g.ID).ID)
+	}
+	filter := entitygraph.RelationshipFilter{AgencyID: agencyA, FromID: a.ID, Limit: 2, IncludeTotal: true}
+	var got []string
+	for pages := 1; ; pages++ {
+		page, err := dm.ListRelationshipsPage(ctx, filter)
+		if err != nil {
+			t.Fatalf("ListRelationshipsPage: %v", err)
+		}
+		if page.Total != len(want) {
+			t.Errorf("Total = %d, want %d", page.Total, len(want))
+		}
+		got = append(got, relationshipIDs(page.Relationships)...)
+		if page.NextPageToken == "" {
+			if pages != 3 {
+				t.Errorf("read %d pages, want 3", pages)
+			}
+			break
+		}
+		filter.PageToken = page.NextPageToken
+	}
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("paged edge IDs = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,384 @@
+// relationships.go contains the relationship and graph conformance cases:
+// CreateRelationship, GetRelationship, DeleteRelationship, ListRelationships,
+// and TraverseGraph.
+package conformance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+)
+
+func relationshipCases() []dmCase {
+	return []dmCase{
+		{"CreateRelationship_ReturnsEdge", testCreateRelationshipReturnsEdge},
+		{"CreateRelationship_MissingFrom_ErrEntityNotFound", testCreateRelationshipMissingFrom},
+		{"CreateRelationship_MissingTo_ErrEntityNotFound", testCreateRelationshipMissingTo},
+		{"CreateRelationship_CrossAgency_ErrEntityNotFound", testCreateRelationshipCrossAgency},
+		{"CreateRelationship_UndeclaredName_ErrInvalidRelationship", testCreateRelationshipUndeclaredName},
+		{"CreateRelationship_WrongTargetType_ErrInvalidRelationship", testCreateRelationshipWrongTarget},
+		{"CreateRelationship_ToOne_ReplacesExistingEdge", testCreateRelationshipToOneReplaces},
+		{"CreateRelationship_ToMany_InsertsEachEdge", testCreateRelationshipToManyInserts},
+		{"GetRelationship_RoundTrip", testGetRelationshipRoundTrip},
+		{"GetRelationship_OtherAgency_ErrRelationshipNotFound", testGetRelationshipOtherAgency},
+		{"DeleteRelationship_Removes", testDeleteRelationshipRemoves},
+		{"DeleteRelationship_Unknown_ErrRelationshipNotFound", testDeleteRelationshipUnknown},
+		{"ListRelationships_Filters", testListRelationshipsFilters},
+		{"TraverseGraph_DepthLimitsReach", testTraverseGraphDepth},
+		{"TraverseGraph_DirectionInboundAndAny", testTraverseGraphDirection},
+		{"TraverseGraph_NamesFilter", testTraverseGraphNames},
+		{"TraverseGraph_ExcludesDeletedVertices", testTraverseGraphExcludesDeleted},
+		{"TraverseGraph_DeduplicatesVertices", testTraverseGraphDedup},
+		{"TraverseGraph_UnknownStart_ErrEntityNotFound", testTraverseGraphUnknownStart},
+		{"TraverseGraph_InvalidDirection_Error", testTraverseGraphInvalidDirection},
+	}
+}
+
+func testCreateRelationshipReturnsEdge(t *testing.T, dm entitygraph.DataManager) {
+	a := mustCreate(t, dm, agencyA, "Agency", nil)
+	g := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	r, err := dm.CreateRelationship(context.Background(), entitygraph.CreateRelationshipRequest{
+		AgencyID: agencyA, Name: "has_goal", FromID: a.ID, ToID: g.ID,
+		Properties: map[string]any{"weight": "high"},
+	})
+	if err != nil {
+		t.Fatalf("CreateRelationship: %v", err)
+	}
+	if r.ID == "" || r.AgencyID != agencyA || r.Name != "has_goal" {
+		t.Errorf("got %+v", r)
+	}
+	if r.FromID != a.ID || r.ToID != g.ID {
+		t.Errorf("FromID/ToID = %s/%s, want %s/%s", r.FromID, r.ToID, a.ID, g.ID)
+	}
+	if r.Properties["weight"] != "high" {
+		t.Errorf("Properties = %v, want weight=high", r.Properties)
+	}
+	if r.CreatedAt.IsZero() {
+		t.Error("CreatedAt is zero")
+	}
+}
+
+func testCreateRelationshipMissingFrom(t *testing.T, dm entitygraph.DataManager) {
+	g := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	_, err := dm.CreateRelationship(context.Background(), entitygraph.CreateRelationshipRequest{
+		AgencyID: agencyA, Name: "has_goal", FromID: "does-not-exist", ToID: g.ID,
+	})
+	if !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("got %v, want ErrEntityNotFound", err)
+	}
+}
+
+func testCreateRelationshipMissingTo(t *testing.T, dm entitygraph.DataManager) {
+	a := mustCreate(t, dm, agencyA, "Agency", nil)
+	_, err := dm.CreateRelationship(context.Background(), entitygraph.CreateRelationshipRequest{
+		AgencyID: agencyA, Name: "has_goal", FromID: a.ID, ToID: "does-not-exist",
+	})
+	if !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("got %v, want ErrEntityNotFound", err)
+	}
+}
+
+func testCreateRelationshipCrossAgency(t *testing.T, dm entitygraph.DataManager) {
+	a := mustCreate(t, dm, agencyA, "Agency", nil)
+	g := mustCreate(t, dm, agencyB, "Goal", map[string]any{"code": "G1"})
+	_, err := dm.CreateRelationship(context.Background(), entitygraph.CreateRelationshipRequest{
+		AgencyID: agencyA, Name: "has_goal", FromID: a.ID, ToID: g.ID,
+	})
+	if !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("got %v, want ErrEntityNotFound", err)
+	}
+}
+
+func testCreateRelationshipUndeclaredName(t *testing.T, dm entitygraph.DataManager) {
+	a := mustCreate(t, dm, agencyA, "Agency", nil)
+	g := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	_, err := dm.CreateRelationship(context.Background(), entitygraph.CreateRelationshipRequest{
+		AgencyID: agencyA, Name: "sponsors", FromID: a.ID, ToID: g.ID,
+	})
+	if !errors.Is(err, entitygraph.ErrInvalidRelationship) {
+		t.Errorf("got %v, want ErrInvalidRelationship", err)
+	}
+}
+
+func testCreateRelationshipWrongTarget(t *testing.T, dm entitygraph.DataManager) {
+	a1 := mustCreate(t, dm, agencyA, "Agency", nil)
+	a2 := mustCreate(t, dm, agencyA, "Agency", nil)
+	_, err := dm.CreateRelationship(context.Background(), entitygraph.CreateRelationshipRequest{
+		AgencyID: agencyA, Name: "has_goal", FromID: a1.ID, ToID: a2.ID,
+	})
+	if !errors.Is(err, entitygraph.ErrInvalidRelationship) {
+		t.Errorf("got %v, want ErrInvalidRelationship", err)
+	}
+	list, err := dm.ListRelationships(context.Background(), entitygraph.RelationshipFilter{AgencyID: agencyA})
+	if err != nil {
+		t.Fatalf("ListRelationships: %v", err)
+	}
+	if len(list) != 0 {
+		t.Errorf("got %d edges after rejected create, want 0", len(list))
+	}
+}
+
+func testCreateRelationshipToOneReplaces(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	g := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	a1 := mustCreate(t, dm, agencyA, "Agency", nil)
+	a2 := mustCreate(t, dm, agencyA, "Agency", nil)
+	first := mustRelate(t, dm, agencyA, "belongs_to_agency", g.ID, a1.ID)
+	second, err := dm.CreateRelationship(ctx, entitygraph.CreateRelationshipRequest{
+		AgencyID: agencyA, Name: "belongs_to_agency", FromID: g.ID, ToID: a2.ID,
+		Properties: map[string]any{"note": "moved"},
+	})
+	if err != nil {
+		t.Fatalf("CreateRelationship (replace): %v", err)
+	}
+	if second.ID != first.ID {
+		t.Errorf("replacement ID = %s, want existing edge ID %s", second.ID, first.ID)
+	}
+	if second.ToID != a2.ID || second.Properties["note"] != "moved" {
+		t.Errorf("replacement = %+v, want ToID %s and note=moved", second, a2.ID)
+	}
+	list, err := dm.ListRelationships(ctx, entitygraph.RelationshipFilter{AgencyID: agencyA, FromID: g.ID, Name: "belongs_to_agency"})
+	if err != nil {
+		t.Fatalf("ListRelationships: %v", err)
+	}
+	if len(list) != 1 || list[0].ToID != a2.ID {
+		t.Errorf("edges = %+v, want exactly one pointing at %s", list, a2.ID)
+	}
+}
+
+func testCreateRelationshipToManyInserts(t *testing.T, dm entitygraph.DataManager) {
+	a := mustCreate(t, dm, agencyA, "Agency", nil)
+	g1 := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	g2 := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G2"})
+	r1 := mustRelate(t, dm, agencyA, "has_goal", a.ID, g1.ID)
+	r2 := mustRelate(t, dm, agencyA, "has_goal", a.ID, g2.ID)
+	if r1.ID == r2.ID {
+		t.Fatal("ToMany edges share an ID")
+	}
+	list, err := dm.ListRelationships(context.Background(), entitygraph.RelationshipFilter{AgencyID: agencyA, FromID: a.ID})
+	if err != nil {
+		t.Fatalf("ListRelationships: %v", err)
+	}
+	if !sameSet(relationshipIDs(list), []string{r1.ID, r2.ID}) {
+		t.Errorf("edges = %v, want [%s %s]", relationshipIDs(list), r1.ID, r2.ID)
+	}
+}
+
+func testGetRelationshipRoundTrip(t *testing.T, dm entitygraph.DataManager) {
+	a := mustCreate(t, dm, agencyA, "Agency", nil)
+	g := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	r := mustRelate(t, dm, agencyA, "has_goal", a.ID, g.ID)
+	got, err := dm.GetRelationship(context.Background(), agencyA, r.ID)
+	if err != nil {
+		t.Fatalf("GetRelationship: %v", err)
+	}
+	if got.ID != r.ID || got.Name != "has_goal" || got.FromID != a.ID || got.ToID != g.ID {
+		t.Errorf("got %+v, want %+v", got, r)
+	}
+}
+
+func testGetRelationshipOtherAgency(t *testing.T, dm entitygraph.DataManager) {
+	a := mustCreate(t, dm, agencyA, "Agency", nil)
+	g := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	r := mustRelate(t, dm, agencyA, "has_goal", a.ID, g.ID)
+	_, err := dm.GetRelationship(context.Background(), agencyB, r.ID)
+	if !errors.Is(err, entitygraph.ErrRelationshipNotFound) {
+		t.Errorf("got %v, want ErrRelationshipNotFound", err)
+	}
+}
+
+func testDeleteRelationshipRemoves(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	a := mustCreate(t, dm, agencyA, "Agency", nil)
+	g := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	r := mustRelate(t, dm, agencyA, "has_goal", a.ID, g.ID)
+	if err := dm.DeleteRelationship(ctx, agencyB, r.ID); !errors.Is(err, entitygraph.ErrRelationshipNotFound) {
+		t.Errorf("cross-agency DeleteRelationship: got %v, want ErrRelationshipNotFound", err)
+	}
+	if err := dm.DeleteRelationship(ctx, agencyA, r.ID); err != nil {
+		t.Fatalf("DeleteRelationship: %v", err)
+	}
+	if _, err := dm.GetRelationship(ctx, agencyA, r.ID); !errors.Is(err, entitygraph.ErrRelationshipNotFound) {
+		t.Errorf("GetRelationship after delete: got %v, want ErrRelationshipNotFound", err)
+	}
+	list, err := dm.ListRelationships(ctx, entitygraph.RelationshipFilter{AgencyID: agencyA})
+	if err != nil {
+		t.Fatalf("ListRelationships: %v", err)
+	}
+	if len(list) != 0 {
+		t.Errorf("ListRelationships after delete = %v, want empty", relationshipIDs(list))
+	}
+}
+
+func testDeleteRelationshipUnknown(t *testing.T, dm entitygraph.DataManager) {
+	err := dm.DeleteRelationship(context.Background(), agencyA, "does-not-exist")
+	if !errors.Is(err, entitygraph.ErrRelationshipNotFound) {
+		t.Errorf("got %v, want ErrRelationshipNotFound", err)
+	}
+}
+
+func testListRelationshipsFilters(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	a := mustCreate(t, dm, agencyA, "Agency", nil)
+	g1 := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	g2 := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G2"})
+	r1 := mustRelate(t, dm, agencyA, "has_goal", a.ID, g1.ID)
+	r2 := mustRelate(t, dm, agencyA, "has_goal", a.ID, g2.ID)
+	r3 := mustRelate(t, dm, agencyA, "belongs_to_agency", g1.ID, a.ID)
+	bA := mustCreate(t, dm, agencyB, "Agency", nil)
+	bG := mustCreate(t, dm, agencyB, "Goal", map[string]any{"code": "G1"})
+	mustRelate(t, dm, agencyB, "has_goal", bA.ID, bG.ID)
+
+	cases := []struct {
+		name   string
+		filter entitygraph.RelationshipFilter
+		want   []string
+	}{
+		{"agency", entitygraph.RelationshipFilter{AgencyID: agencyA}, []string{r1.ID, r2.ID, r3.ID}},
+		{"from", entitygraph.RelationshipFilter{AgencyID: agencyA, FromID: a.ID}, []string{r1.ID, r2.ID}},
+		{"to", entitygraph.RelationshipFilter{AgencyID: agencyA, ToID: g2.ID}, []string{r2.ID}},
+		{"name", entitygraph.RelationshipFilter{AgencyID: agencyA, Name: "belongs_to_agency"}, []string{r3.ID}},
+		{"from and name", entitygraph.RelationshipFilter{AgencyID: agencyA, FromID: g1.ID, Name: "has_goal"}, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := dm.ListRelationships(ctx, c.filter)
+			if err != nil {
+				t.Fatalf("ListRelationships: %v", err)
+			}
+			if !sameSet(relationshipIDs(got), c.want) {
+				t.Errorf("got %v, want %v", relationshipIDs(got), c.want)
+			}
+		})
+	}
+}
+
+// chain builds Agency -has_goal-> g1 -depends_on-> g2 -depends_on-> g3 in
+// agencyA and returns the four entities in order.
+func chain(t *testing.T, dm entitygraph.DataManager) (a, g1, g2, g3 entitygraph.Entity) {
+	t.Helper()
+	a = mustCreate(t, dm, agencyA, "Agency", nil)
+	g1 = mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	g2 = mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G2"})
+	g3 = mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G3"})
+	mustRelate(t, dm, agencyA, "has_goal", a.ID, g1.ID)
+	mustRelate(t, dm, agencyA, "depends_on", g1.ID, g2.ID)
+	mustRelate(t, dm, agencyA, "depends_on", g2.ID, g3.ID)
+	return a, g1, g2, g3
+}
+
+func traverse(t *testing.T, dm entitygraph.DataManager, req entitygraph.TraverseGraphRequest) entitygraph.TraverseGraphResult {
+	t.Helper()
+	req.AgencyID = agencyA
+	res, err := dm.TraverseGraph(context.Background(), req)
+	if err != nil {
+		t.Fatalf("TraverseGraph: %v", err)
+	}
+	return res
+}
+
+func testTraverseGraphDepth(t *testing.T, dm entitygraph.DataManager) {
+	a, g1, g2, g3 := chain(t, dm)
+	cases := []struct {
+		depth int
+		want  []string
+	}{
+		{0, []string{g1.ID}},
+		{1, []string{g1.ID}},
+		{2, []string{g1.ID, g2.ID}},
+		{5, []string{g1.ID, g2.ID, g3.ID}},
+	}
+	for _, c := range cases {
+		res := traverse(t, dm, entitygraph.TraverseGraphRequest{StartID: a.ID, Depth: c.depth})
+		if !sameSet(entityIDs(res.Vertices), c.want) {
+			t.Errorf("depth %d: vertices = %v, want %v", c.depth, entityIDs(res.Vertices), c.want)
+		}
+		if len(res.Edges) != len(c.want) {
+			t.Errorf("depth %d: got %d edges, want %d", c.depth, len(res.Edges), len(c.want))
+		}
+	}
+}
+
+func testTraverseGraphDirection(t *testing.T, dm entitygraph.DataManager) {
+	a, g1, g2, g3 := chain(t, dm)
+	in := traverse(t, dm, entitygraph.TraverseGraphRequest{StartID: g3.ID, Direction: "inbound", Depth: 5})
+	if !sameSet(entityIDs(in.Vertices), []string{g2.ID, g1.ID, a.ID}) {
+		t.Errorf("inbound vertices = %v, want [g2 g1 a]", entityIDs(in.Vertices))
+	}
+	for _, e := range in.Edges {
+		if e.FromID == g3.ID {
+			t.Errorf("inbound edge %s reported with FromID == start; edges must keep storage direction", e.ID)
+		}
+	}
+	anyDir := traverse(t, dm, entitygraph.TraverseGraphRequest{StartID: g2.ID, Direction: "ANY"})
+	if !sameSet(entityIDs(anyDir.Vertices), []string{g1.ID, g3.ID}) {
+		t.Errorf("any vertices = %v, want [g1 g3]", entityIDs(anyDir.Vertices))
+	}
+	out := traverse(t, dm, entitygraph.TraverseGraphRequest{StartID: g3.ID, Depth: 5})
+	if len(out.Vertices) != 0 || len(out.Edges) != 0 {
+		t.Errorf("outbound from leaf = %v, want empty", entityIDs(out.Vertices))
+	}
+}
+
+func testTraverseGraphNames(t *testing.T, dm entitygraph.DataManager) {
+	a, g1, _, _ := chain(t, dm)
+	res := traverse(t, dm, entitygraph.TraverseGraphRequest{StartID: a.ID, Depth: 5, Names: []string{"has_goal"}})
+	if !sameSet(entityIDs(res.Vertices), []string{g1.ID}) {
+		t.Errorf("vertices = %v, want [g1]", entityIDs(res.Vertices))
+	}
+	for _, e := range res.Edges {
+		if e.Name != "has_goal" {
+			t.Errorf("edge %s has name %q, want only has_goal", e.ID, e.Name)
+		}
+	}
+}
+
+func testTraverseGraphExcludesDeleted(t *testing.T, dm entitygraph.DataManager) {
+	a := mustCreate(t, dm, agencyA, "Agency", nil)
+	g1 := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	g2 := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G2"})
+	mustRelate(t, dm, agencyA, "has_goal", a.ID, g1.ID)
+	mustRelate(t, dm, agencyA, "has_goal", a.ID, g2.ID)
+	if err := dm.DeleteEntity(context.Background(), agencyA, g2.ID); err != nil {
+		t.Fatalf("DeleteEntity: %v", err)
+	}
+	res := traverse(t, dm, entitygraph.TraverseGraphRequest{StartID: a.ID})
+	if !sameSet(entityIDs(res.Vertices), []string{g1.ID}) {
+		t.Errorf("vertices = %v, want [g1]", entityIDs(res.Vertices))
+	}
+}
+
+func testTraverseGraphDedup(t *testing.T, dm entitygraph.DataManager) {
+	a := mustCreate(t, dm, agencyA, "Agency", nil)
+	g := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	mustRelate(t, dm, agencyA, "has_goal", a.ID, g.ID)
+	mustRelate(t, dm, agencyA, "has_goal", a.ID, g.ID)
+	res := traverse(t, dm, entitygraph.TraverseGraphRequest{StartID: a.ID})
+	if len(res.Vertices) != 1 {
+		t.Errorf("got %d vertices, want 1 (deduplicated)", len(res.Vertices))
+	}
+	if len(res.Edges) != 2 {
+		t.Errorf("got %d edges, want 2", len(res.Edges))
+	}
+}
+
+func testTraverseGraphUnknownStart(t *testing.T, dm entitygraph.DataManager) {
+	_, err := dm.TraverseGraph(context.Background(), entitygraph.TraverseGraphRequest{
+		AgencyID: agencyA, StartID: "does-not-exist",
+	})
+	if !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("got %v, want ErrEntityNotFound", err)
+	}
+}
+
+func testTraverseGraphInvalidDirection(t *testing.T, dm entitygraph.DataManager) {
+	a := mustCreate(t, dm, agencyA, "Agency", nil)
+	_, err := dm.TraverseGraph(context.Background(), entitygraph.TraverseGraphRequest{
+		AgencyID: agencyA, StartID: a.ID, Direction: "sideways",
+	})
+	if err == nil {
+		t.Error("expected error for invalid direction, got nil")
+	}
+}
@@ -0,0 +1,183 @@
+// restore.go contains the conformance cases for the soft-delete lifecycle
+// after DeleteEntity: listing tombstones via EntityFilter.IncludeDeleted,
+// RestoreEntity, PurgeEntity, and PurgeDeletedBefore.
+package conformance
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+)
+
+func restoreCases() []dmCase {
+	return []dmCase{
+		{"ListEntities_IncludeDeleted_ReturnsTombstones", testListIncludeDeleted},
+		{"RestoreEntity_ClearsDeletedFlags", testRestoreClearsFlags},
+		{"RestoreEntity_Live_ErrEntityNotDeleted", testRestoreLive},
+		{"RestoreEntity_Unknown_ErrEntityNotFound", testRestoreUnknown},
+		{"RestoreEntity_UniqueKeyTaken_ErrEntityAlreadyExists", testRestoreUniqueKeyTaken},
+		{"PurgeEntity_RemovesPermanently", testPurgeRemovesPermanently},
+		{"PurgeEntity_Live_ErrEntityNotDeleted", testPurgeLive},
+		{"PurgeDeletedBefore_RespectsCutoffAndAgency", testPurgeDeletedBefore},
+	}
+}
+
+// mustDelete soft-deletes entityID in agencyID or fails t.
+func mustDelete(t *testing.T, dm entitygraph.DataManager, agencyID, entityID string) {
+	t.Helper()
+	if err := dm.DeleteEntity(context.Background(), agencyID, entityID); err != nil {
+		t.Fatalf("DeleteEntity(%s): %v", entityID, err)
+	}
+}
+
+func testListIncludeDeleted(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	live := mustCreate(t, dm, agencyA, "Note", nil)
+	gone := mustCreate(t, dm, agencyA, "Note", nil)
+	mustDelete(t, dm, agencyA, gone.ID)
+
+	got, err := dm.ListEntities(ctx, entitygraph.EntityFilter{AgencyID: agencyA, TypeID: "Note", IncludeDeleted: true})
+	if err != nil {
+		t.Fatalf("ListEntities: %v", err)
+	}
+	byID := make(map[string]entitygraph.Entity, len(got))
+	for _, e := range got {
+		byID[e.ID] = e
+	}
+	if len(byID) != 2 {
+		t.Fatalf("got %d entities with IncludeDeleted, want 2", len(byID))
+	}
+	if byID[live.ID].Deleted {
+		t.Errorf("live entity reported Deleted")
+	}
+	if tomb := byID[gone.ID]; !tomb.Deleted || tomb.DeletedAt == nil {
+		t.Errorf("tombstone Deleted=%v DeletedAt=%v, want true and set", tomb.Deleted, tomb.DeletedAt)
+	}
+
+	got, err = dm.ListEntities(ctx, entitygraph.EntityFilter{AgencyID: agencyA, TypeID: "Note"})
+	if err != nil {
+		t.Fatalf("ListEntities: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != live.ID {
+		t.Errorf("ListEntities without IncludeDeleted = %v, want only %s", entityIDs(got), live.ID)
+	}
+}
+
+func testRestoreClearsFlags(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	e := mustCreate(t, dm, agencyA, "Agency", map[string]any{"name": "Acme"})
+	mustDelete(t, dm, agencyA, e.ID)
+
+	restored, err := dm.RestoreEntity(ctx, agencyA, e.ID)
+	if err != nil {
+		t.Fatalf("RestoreEntity: %v", err)
+	}
+	if restored.Deleted || restored.DeletedAt != nil {
+		t.Errorf("restored Deleted=%v DeletedAt=%v, want false and nil", restored.Deleted, restored.DeletedAt)
+	}
+	if restored.Properties["name"] != "Acme" {
+		t.Errorf("restored properties = %v, want name Acme", restored.Properties)
+	}
+	got, err := dm.GetEntity(ctx, agencyA, e.ID)
+	if err != nil {
+		t.Fatalf("GetEntity after restore: %v", err)
+	}
+	if got.Deleted {
+		t.Errorf("GetEntity after restore: Deleted = true")
+	}
+}
+
+func testRestoreLive(t *testing.T, dm entitygraph.DataManager) {
+	e := mustCreate(t, dm, agencyA, "Note", nil)
+	if _, err := dm.RestoreEntity(context.Background(), agencyA, e.ID); !errors.Is(err, entitygraph.ErrEntityNotDeleted) {
+		t.Errorf("RestoreEntity(live): got %v, want ErrEntityNotDeleted", err)
+	}
+}
+
+func testRestoreUnknown(t *testing.T, dm entitygraph.DataManager) {
+	if _, err := dm.RestoreEntity(context.Background(), agencyA, "does-not-exist"); !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("RestoreEntity(unknown): got %v, want ErrEntityNotFound", err)
+	}
+}
+
+func testRestoreUniqueKeyTaken(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	old := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	mustDelete(t, dm, agencyA, old.ID)
+	mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+
+	if _, err := dm.RestoreEntity(ctx, agencyA, old.ID); !errors.Is(err, entitygraph.ErrEntityAlreadyExists) {
+		t.Fatalf("RestoreEntity: got %v, want ErrEntityAlreadyExists", err)
+	}
+	wantGone(t, dm, old.ID)
+}
+
+func testPurgeRemovesPermanently(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	e := mustCreate(t, dm, agencyA, "Note", nil)
+	mustDelete(t, dm, agencyA, e.ID)
+
+	if err := dm.PurgeEntity(ctx, agencyA, e.ID); err != nil {
+		t.Fatalf("PurgeEntity: %v", err)
+	}
+	if _, err := dm.RestoreEntity(ctx, agencyA, e.ID); !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("RestoreEntity after purge: got %v, want ErrEntityNotFound", err)
+	}
+	all, err := dm.ListEntities(ctx, entitygraph.EntityFilter{AgencyID: agencyA, IncludeDeleted: true})
+	if err != nil {
+		t.Fatalf("ListEntities: %v", err)
+	}
+	for _, got := range all {
+		if got.ID == e.ID {
+			t.Errorf("purged entity %s still listed with IncludeDeleted", e.ID)
+		}
+	}
+	if err := dm.PurgeEntity(ctx, agencyA, e.ID); !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("PurgeEntity twice: got %v, want ErrEntityNotFound", err)
+	}
+}
+
+func testPurgeLive(t *testing.T, dm entitygraph.DataManager) {
+	e := mustCreate(t, dm, agencyA, "Note", nil)
+	if err := dm.PurgeEntity(context.Background(), agencyA, e.ID); !errors.Is(err, entitygraph.ErrEntityNotDeleted) {
+		t.Fatalf("PurgeEntity(live): got %v, want ErrEntityNotDeleted", err)
+	}
+	wantLive(t, dm, e.ID)
+}
+
+func testPurgeDeletedBefore(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	old := mustCreate(t, dm, agencyA, "Note", nil)
+	other := mustCreate(t, dm, agencyB, "Note", nil)
+	live := mustCreate(t, dm, agencyA, "Note", nil)
+	mustDelete(t, dm, agencyA, old.ID)
+	mustDelete(t, dm, agencyB, other.ID)
+
+	time.Sleep(10 * time.Millisecond)
+	cutoff := time.Now().UTC()
+	time.Sleep(10 * time.Millisecond)
+
+	recent := mustCreate(t, dm, agencyA, "Note", nil)
+	mustDelete(t, dm, agencyA, recent.ID)
+
+	n, err := dm.PurgeDeletedBefore(ctx, agencyA, cutoff)
+	if err != nil {
+		t.Fatalf("PurgeDeletedBefore: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("PurgeDeletedBefore purged %d, want 1", n)
+	}
+	if _, err := dm.RestoreEntity(ctx, agencyA, old.ID); !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("RestoreEntity(purged): got %v, want ErrEntityNotFound", err)
+	}
+	if _, err := dm.RestoreEntity(ctx, agencyA, recent.ID); err != nil {
+		t.Errorf("RestoreEntity(deleted after cutoff): %v", err)
+	}
+	if _, err := dm.RestoreEntity(ctx, agencyB, other.ID); err != nil {
+		t.Errorf("RestoreEntity(other agency): %v", err)
+	}
+	wantLive(t, dm, live.ID)
+}
@@ -0,0 +1,149 @@
+// revision.go contains the conformance cases for optimistic concurrency:
+// Entity.Revision, UpdateEntityRequest.IfMatch and DeleteEntityIfMatch.
+package conformance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+)
+
+func revisionCases() []dmCase {
+	return []dmCase{
+		{"Revision_ChangesOnEveryWrite", testRevisionChanges},
+		{"UpdateEntity_StaleIfMatch_ErrConflict", testUpdateStaleIfMatch},
+		{"DeleteEntity_StaleIfMatch_ErrConflict", testDeleteStaleIfMatch},
+		{"UpdateEntity_Concurrent_NoLostUpdates", testUpdateConcurrent},
+	}
+}
+
+func testRevisionChanges(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	created := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	if created.Revision == "" {
+		t.Fatal("CreateEntity returned an empty Revision")
+	}
+	got, err := dm.GetEntity(ctx, agencyA, created.ID)
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	if got.Revision != created.Revision {
+		t.Errorf("GetEntity Revision = %q, want %q", got.Revision, created.Revision)
+	}
+
+	updated, err := dm.UpdateEntity(ctx, agencyA, created.ID, entitygraph.UpdateEntityRequest{
+		Properties: map[string]any{"title": "Grow"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateEntity: %v", err)
+	}
+	upserted, err := dm.UpsertEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: agencyA, TypeID: "Goal", Properties: map[string]any{"code": "G1", "rank": 2},
+	})
+	if err != nil {
+		t.Fatalf("UpsertEntity: %v", err)
+	}
+	mustDelete(t, dm, agencyA, created.ID)
+	restored, err := dm.RestoreEntity(ctx, agencyA, created.ID)
+	if err != nil {
+		t.Fatalf("RestoreEntity: %v", err)
+	}
+
+	seen := map[string]string{}
+	for _, step := range []struct{ name, rev string }{
+		{"create", created.Revision},
+		{"update", updated.Revision},
+		{"upsert", upserted.Revision},
+		{"restore", restored.Revision},
+	} {
+		if prev, dup := seen[step.rev]; dup {
+			t.Errorf("%s reused the revision of %s: %q", step.name, prev, step.rev)
+		}
+		seen[step.rev] = step.name
+	}
+}
+
+func testUpdateStaleIfMatch(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	e := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1", "title": "v1"})
+
+	v2, err := dm.UpdateEntity(ctx, agencyA, e.ID, entitygraph.UpdateEntityRequest{
+		Properties: map[string]any{"title": "v2"}, IfMatch: e.Revision,
+	})
+	if err != nil {
+		t.Fatalf("UpdateEntity with current IfMatch: %v", err)
+	}
+	_, err = dm.UpdateEntity(ctx, agencyA, e.ID, entitygraph.UpdateEntityRequest{
+		Properties: map[string]any{"title": "lost"}, IfMatch: e.Revision,
+	})
+	if !errors.Is(err, entitygraph.ErrConflict) {
+		t.Fatalf("UpdateEntity with stale IfMatch: got %v, want ErrConflict", err)
+	}
+	got, err := dm.GetEntity(ctx, agencyA, e.ID)
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	if got.Properties["title"] != "v2" || got.Revision != v2.Revision {
+		t.Errorf("entity changed by a rejected update: %+v", got)
+	}
+}
+
+func testDeleteStaleIfMatch(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	e := mustCreate(t, dm, agencyA, "Note", nil)
+	updated, err := dm.UpdateEntity(ctx, agencyA, e.ID, entitygraph.UpdateEntityRequest{
+		Properties: map[string]any{"text": "edited"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateEntity: %v", err)
+	}
+
+	if err := dm.DeleteEntityIfMatch(ctx, agencyA, e.ID, e.Revision); !errors.Is(err, entitygraph.ErrConflict) {
+		t.Fatalf("DeleteEntityIfMatch with stale ifMatch: got %v, want ErrConflict", err)
+	}
+	if _, err := dm.GetEntity(ctx, agencyA, e.ID); err != nil {
+		t.Fatalf("entity gone after a rejected delete: %v", err)
+	}
+	if err := dm.DeleteEntityIfMatch(ctx, agencyA, e.ID, updated.Revision); err != nil {
+		t.Fatalf("DeleteEntityIfMatch with current ifMatch: %v", err)
+	}
+	if _, err := dm.GetEntity(ctx, agencyA, e.ID); !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("GetEntity after delete: got %v, want ErrEntityNotFound", err)
+	}
+}
+
+func testUpdateConcurrent(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	e := mustCreate(t, dm, agencyA, "Note", nil)
+	const writers = 8
+	var wg sync.WaitGroup
+	errs := make([]error, writers)
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = dm.UpdateEntity(ctx, agencyA, e.ID, entitygraph.UpdateEntityRequest{
+				Properties: map[string]any{fmt.Sprintf("w%d", i): true},
+			})
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("UpdateEntity #%d: %v", i, err)
+		}
+	}
+	got, err := dm.GetEntity(ctx, agencyA, e.ID)
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	for i := range writers {
+		if got.Properties[fmt.Sprintf("w%d", i)] != true {
+			t.Errorf("update from writer %d was lost: %v", i, got.Properties)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+// schemaops.go contains the SchemaManager conformance cases covering the
+// SetSchema → Publish → Activate lifecycle.
+package conformance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+func schemaManagerCases() []smCase {
+	return []smCase{
+		{"GetSchema_NoDraft_ErrSchemaNotFound", testGetSchemaNoDraft},
+		{"SetSchema_OverwritesDraft", testSetSchemaOverwrites},
+		{"Publish_NoDraft_Error", testPublishNoDraft},
+		{"Publish_AssignsIncreasingInactiveVersions", testPublishVersions},
+		{"Publish_InvalidDraft_NoVersionCreated", testPublishInvalidDraft},
+		{"Activate_DeactivatesOthers", testActivateDeactivatesOthers},
+		{"Activate_UnknownVersion_ErrSchemaNotFound", testActivateUnknownVersion},
+		{"Activate_NewUniqueKeyAndIndex_Activates", testActivateNewUniqueKeyAndIndex},
+		{"GetActive_NoneActive_ErrSchemaNotFound", testGetActiveNone},
+		{"GetVersion_Unknown_ErrSchemaNotFound", testGetVersionUnknown},
+		{"ListVersions_Ascending", testListVersionsAscending},
+		{"Agencies_AreIsolated", testSchemaAgenciesIsolated},
+	}
+}
+
+// draft returns a minimal valid schema for agencyID tagged tag.
+func draft(agencyID, tag string) types.Schema {
+	return types.Schema{
+		AgencyID: agencyID,
+		Tag:      tag,
+		Types: []types.TypeDefinition{
+			{Name: "Agency", Properties: []types.PropertyDefinition{{Name: "name", Type: types.PropertyTypeString}}},
+		},
+	}
+}
+
+func mustSetAndPublish(t *testing.T, sm entitygraph.SchemaManager, agencyID, tag string) {
+	t.Helper()
+	ctx := context.Background()
+	if err := sm.SetSchema(ctx, draft(agencyID, tag)); err != nil {
+		t.Fatalf("SetSchema(%s): %v", tag, err)
+	}
+	if err := sm.Publish(ctx, agencyID); err != nil {
+		t.Fatalf("Publish(%s): %v", tag, err)
+	}
+}
+
+func testGetSchemaNoDraft(t *testing.T, sm entitygraph.SchemaManager) {
+	_, err := sm.GetSchema(context.Background(), agencyA)
+	if !errors.Is(err, entitygraph.ErrSchemaNotFound) {
+		t.Errorf("got %v, want ErrSchemaNotFound", err)
+	}
+}
+
+func testSetSchemaOverwrites(t *testing.T, sm entitygraph.SchemaManager) {
+	ctx := context.Background()
+	if err := sm.SetSchema(ctx, draft(agencyA, "v1")); err != nil {
+		t.Fatalf("SetSchema: %v", err)
+	}
+	if err := sm.SetSchema(ctx, draft(agencyA, "v2")); err != nil {
+		t.Fatalf("SetSchema: %v", err)
+	}
+	got, err := sm.GetSchema(ctx, agencyA)
+	if err != nil {
+		t.Fatalf("GetSchema: %v", err)
+	}
+	if got.AgencyID != agencyA || got.Tag != "v2" {
+		t.Errorf("got AgencyID=%q Tag=%q, want %q/v2", got.AgencyID, got.Tag, agencyA)
+	}
+	if len(got.Types) != 1 || got.Types[0].Name != "Agency" {
+		t.Errorf("Types = %+v, want [Agency]", got.Types)
+	}
+}
+
+func testPublishNoDraft(t *testing.T, sm entitygraph.SchemaManager) {
+	if err := sm.Publish(context.Background(), agencyA); err == nil {
+		t.Error("expected error publishing without a draft, got nil")
+	}
+}
+
+func testPublishVersions(t *testing.T, sm entitygraph.SchemaManager) {
+	ctx := context.Background()
+	mustSetAndPublish(t, sm, agencyA, "v1")
+	mustSetAndPublish(t, sm, agencyA, "v2")
+	for i, tag := range []string{"v1", "v2"} {
+		s, err := sm.GetVersion(ctx, agencyA, i+1)
+		if err != nil {
+			t.Fatalf("GetVersion(%d): %v", i+1, err)
+		}
+		if s.Version != i+1 || s.Tag != tag {
+			t.Errorf("version %d: got Version=%d Tag=%q, want %d/%s", i+1, s.Version, s.Tag, i+1, tag)
+		}
+		if s.Active {
+			t.Errorf("version %d: Active = true, want false until Activate", i+1)
+		}
+		if s.CreatedAt.IsZero() {
+			t.Errorf("version %d: CreatedAt is zero", i+1)
+		}
+	}
+}
+
+func testPublishInvalidDraft(t *testing.T, sm entitygraph.SchemaManager) {
+	ctx := context.Background()
+	bad := types.Schema{
+		AgencyID: agencyA,
+		Types: []types.TypeDefinition{
+			{Name: "Agency"},
+			{Name: "Agency"},
+		},
+	}
+	if err := sm.SetSchema(ctx, bad); err != nil {
+		t.Fatalf("SetSchema: %v", err)
+	}
+	if err := sm.Publish(ctx, agencyA); err == nil {
+		t.Fatal("expected Publish to reject an invalid draft, got nil")
+	}
+	versions, err := sm.ListVersions(ctx, agencyA)
+	if err != nil {
+		t.Fatalf("ListVersions: %v", err)
+	}
+	if len(versions) != 0 {
+		t.Errorf("got %d versions after rejected Publish, want 0", len(versions))
+	}
+}
+
+func testActivateDeactivatesOthers(t *testing.T, sm entitygraph.SchemaManager) {
+	ctx := context.Background()
+	mustSetAndPublish(t, sm, agencyA, "v1")
+	mustSetAndPublish(t, sm, agencyA, "v2")
+	if err := sm.Activate(ctx, agencyA, 1); err != nil {
+		t.Fatalf("Activate(1): %v", err)
+	}
+	if err := sm.Activate(ctx, agencyA, 2); err != nil {
+		t.Fatalf("Activate(2): %v", err)
+	}
+	active, err := sm.GetActive(ctx, agencyA)
+	if err != nil {
+		t.Fatalf("GetActive: %v", err)
+	}
+	if active.Version != 2 {
+		t.Errorf("active version = %d, want 2", active.Version)
+	}
+	v1, err := sm.GetVersion(ctx, agencyA, 1)
+	if err != nil {
+		t.Fatalf("GetVersion(1): %v", err)
+	}
+	if v1.Active {
+		t.Error("version 1 still active after activating version 2")
+	}
+}
+
+func testActivateUnknownVersion(t *testing.T, sm entitygraph.SchemaManager) {
+	mustSetAndPublish(t, sm, agencyA, "v1")
+	err := sm.Activate(context.Background(), agencyA, 99)
+	if !errors.Is(err, entitygraph.ErrSchemaNotFound) {
+		t.Errorf("got %v, want ErrSchemaNotFound", err)
+	}
+}
+
+// testActivateNewUniqueKeyAndIndex publishes a version that adds a UniqueKey
+// and an Indexed property to the configured — empty — schema, and checks that
+// the backend reconciles its storage and activates it.
+func testActivateNewUniqueKeyAndIndex(t *testing.T, sm entitygraph.SchemaManager) {
+	ctx := context.Background()
+	mustSetAndPublish(t, sm, agencyA, "v1")
+	if err := sm.Activate(ctx, agencyA, 1); err != nil {
+		t.Fatalf("Activate(1): %v", err)
+	}
+	keyed := draft(agencyA, "keyed")
+	keyed.Types[0].UniqueKey = []string{"name"}
+	keyed.Types[0].Properties = append(keyed.Types[0].Properties,
+		types.PropertyDefinition{Name: "rank", Type: types.PropertyTypeInteger, Indexed: true})
+	if err := sm.SetSchema(ctx, keyed); err != nil {
+		t.Fatalf("SetSchema: %v", err)
+	}
+	if err := sm.Publish(ctx, agencyA); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if err := sm.Activate(ctx, agencyA, 2); err != nil {
+		t.Fatalf("Activate(2): %v", err)
+	}
+	active, err := sm.GetActive(ctx, agencyA)
+	if err != nil {
+		t.Fatalf("GetActive: %v", err)
+	}
+	if active.Version != 2 {
+		t.Errorf("active version = %d, want 2", active.Version)
+	}
+}
+
+func testGetActiveNone(t *testing.T, sm entitygraph.SchemaManager) {
+	mustSetAndPublish(t, sm, agencyA, "v1")
+	_, err := sm.GetActive(context.Background(), agencyA)
+	if !errors.Is(err, entitygraph.ErrSchemaNotFound) {
+		t.Errorf("got %v, want ErrSchemaNotFound", err)
+	}
+}
+
+func testGetVersionUnknown(t *testing.T, sm entitygraph.SchemaManager) {
+	_, err := sm.GetVersion(context.Background(), agencyA, 1)
+	if !errors.Is(err, entitygraph.ErrSchemaNotFound) {
+		t.Errorf("got %v, want ErrSchemaNotFound", err)
+	}
+}
+
+func testListVersionsAscending(t *testing.T, sm entitygraph.SchemaManager) {
+	ctx := context.Background()
+	empty, err := sm.ListVersions(ctx, agencyA)
+	if err != nil {
+		t.Fatalf("ListVersions (empty): %v", err)
+	}
+	if len(empty) != 0 {
+		t.Errorf("got %d versions before Publish, want 0", len(empty))
+	}
+	for _, tag := range []string{"v1", "v2", "v3"} {
+		mustSetAndPublish(t, sm, agencyA, tag)
+	}
+	versions, err := sm.ListVersions(ctx, agencyA)
+	if err != nil {
+		t.Fatalf("ListVersions: %v", err)
+	}
+	if len(versions) != 3 {
+		t.Fatalf("got %d versions, want 3", len(versions))
+	}
+	for i, s := range versions {
+		if s.Version != i+1 {
+			t.Errorf("versions[%d].Version = %d, want %d", i, s.Version, i+1)
+		}
+	}
+}
+
+func testSchemaAgenciesIsolated(t *testing.T, sm entitygraph.SchemaManager) {
+	ctx := context.Background()
+	mustSetAndPublish(t, sm, agencyA, "a1")
+	if err := sm.Activate(ctx, agencyA, 1); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	if _, err := sm.GetSchema(ctx, agencyB); !errors.Is(err, entitygraph.ErrSchemaNotFound) {
+		t.Errorf("GetSchema(agencyB): got %v, want ErrSchemaNotFound", err)
+	}
+	if _, err := sm.GetActive(ctx, agencyB); !errors.Is(err, entitygraph.ErrSchemaNotFound) {
+		t.Errorf("GetActive(agencyB): got %v, want ErrSchemaNotFound", err)
+	}
+	mustSetAndPublish(t, sm, agencyB, "b1")
+	vb, err := sm.GetVersion(ctx, agencyB, 1)
+	if err != nil {
+		t.Fatalf("GetVersion(agencyB, 1): %v", err)
+	}
+	if vb.Tag != "b1" || vb.Active {
+		t.Errorf("agencyB v1 = Tag %q Active %v, want b1/false", vb.Tag, vb.Active)
+	}
+	va, err := sm.GetActive(ctx, agencyA)
+	if err != nil {
+		t.Fatalf("GetActive(agencyA): %v", err)
+	}
+	if va.Tag != "a1" {
+		t.Errorf("agencyA active Tag = %q, want a1", va.Tag)
+	}
+}
@@ -0,0 +1,142 @@
+// search.go contains the conformance cases for SearchEntities: matching and
+// ranking over Searchable properties, highlights, scoping, and rejection of
+// malformed requests.
+package conformance
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+)
+
+func searchCases() []dmCase {
+	return []dmCase{
+		{"SearchEntities_RanksBestMatchFirst", testSearchRanksBestMatch},
+		{"SearchEntities_Highlights", testSearchHighlights},
+		{"SearchEntities_IgnoresUnsearchableDeletedAndOtherAgencies", testSearchScoping},
+		{"SearchEntities_PropertiesAndLimit", testSearchPropertiesAndLimit},
+		{"SearchEntities_Invalid_ErrInvalidSearch", testSearchInvalid},
+	}
+}
+
+// searchIDs runs req and returns the hit entity IDs in rank order.
+func searchIDs(t *testing.T, dm entitygraph.DataManager, req entitygraph.SearchRequest) []string {
+	t.Helper()
+	hits, err := dm.SearchEntities(context.Background(), req)
+	if err != nil {
+		t.Fatalf("SearchEntities(%q): %v", req.Query, err)
+	}
+	return hitIDs(hits)
+}
+
+func testSearchRanksBestMatch(t *testing.T, dm entitygraph.DataManager) {
+	best := mustCreate(t, dm, agencyA, "WorkItem", map[string]any{
+		"title":       "Login crash",
+		"description": "The login page crashes after a failed login",
+	})
+	weak := mustCreate(t, dm, agencyA, "WorkItem", map[string]any{
+		"title":       "Update the onboarding guide",
+		"description": "Mention single sign-on next to the login form",
+	})
+	mustCreate(t, dm, agencyA, "WorkItem", map[string]any{"title": "Unrelated chore"})
+
+	hits, err := dm.SearchEntities(context.Background(), entitygraph.SearchRequest{
+		AgencyID: agencyA, TypeID: "WorkItem", Query: "LOGIN crash",
+	})
+	if err != nil {
+		t.Fatalf("SearchEntities: %v", err)
+	}
+	if got := hitIDs(hits); !slices.Equal(got, []string{best.ID, weak.ID}) {
+		t.Fatalf("hits = %v, want [%s %s]", got, best.ID, weak.ID)
+	}
+	if hits[0].Score <= hits[1].Score {
+		t.Errorf("scores = %v, %v; want the first hit strictly higher", hits[0].Score, hits[1].Score)
+	}
+}
+
+// hitIDs returns the entity IDs of hits in order.
+func hitIDs(hits []entitygraph.SearchHit) []string {
+	ids := make([]string, len(hits))
+	for i, h := range hits {
+		ids[i] = h.Entity.ID
+	}
+	return ids
+}
+
+func testSearchHighlights(t *testing.T, dm entitygraph.DataManager) {
+	mustCreate(t, dm, agencyA, "WorkItem", map[string]any{
+		"title":       "Deploy: deploy the API",
+		"description": "no match here",
+		"labels":      []any{"ops", "Deploy-blocker"},
+		"code":        "deploy",
+	})
+	hits, err := dm.SearchEntities(context.Background(), entitygraph.SearchRequest{
+		AgencyID: agencyA, TypeID: "WorkItem", Query: "deploy",
+	})
+	if err != nil {
+		t.Fatalf("SearchEntities: %v", err)
+	}
+	if len(hits) != 1 {
+		t.Fatalf("got %d hits, want 1", len(hits))
+	}
+	want := []entitygraph.Highlight{
+		{Property: "title", Text: "Deploy: deploy the API", Spans: []entitygraph.TextSpan{{Start: 0, End: 6}, {Start: 8, End: 14}}},
+		{Property: "labels", Text: "Deploy-blocker", Spans: []entitygraph.TextSpan{{Start: 0, End: 6}}},
+	}
+	got := hits[0].Highlights
+	if !slices.EqualFunc(got, want, func(a, b entitygraph.Highlight) bool {
+		return a.Property == b.Property && a.Text == b.Text && slices.Equal(a.Spans, b.Spans)
+	}) {
+		t.Errorf("highlights = %+v, want %+v", got, want)
+	}
+}
+
+func testSearchScoping(t *testing.T, dm entitygraph.DataManager) {
+	live := mustCreate(t, dm, agencyA, "WorkItem", map[string]any{"title": "Rotate keys"})
+	deleted := mustCreate(t, dm, agencyA, "WorkItem", map[string]any{"title": "Rotate certificates"})
+	mustDelete(t, dm, agencyA, deleted.ID)
+	mustCreate(t, dm, agencyB, "WorkItem", map[string]any{"title": "Rotate tokens"})
+	mustCreate(t, dm, agencyA, "WorkItem", map[string]any{"title": "Audit", "code": "rotate"})
+	mustCreate(t, dm, agencyA, "Workflow", map[string]any{"name": "Rotate"})
+
+	got := searchIDs(t, dm, entitygraph.SearchRequest{AgencyID: agencyA, TypeID: "WorkItem", Query: "rotate"})
+	if !slices.Equal(got, []string{live.ID}) {
+		t.Errorf("hits = %v, want only the live agency A WorkItem %s", got, live.ID)
+	}
+}
+
+func testSearchPropertiesAndLimit(t *testing.T, dm entitygraph.DataManager) {
+	inTitle := mustCreate(t, dm, agencyA, "WorkItem", map[string]any{"title": "Billing export"})
+	mustCreate(t, dm, agencyA, "WorkItem", map[string]any{"title": "Export", "description": "billing follow-up"})
+
+	got := searchIDs(t, dm, entitygraph.SearchRequest{
+		AgencyID: agencyA, TypeID: "WorkItem", Query: "billing", Properties: []string{"title"},
+	})
+	if !slices.Equal(got, []string{inTitle.ID}) {
+		t.Errorf("title-only hits = %v, want [%s]", got, inTitle.ID)
+	}
+	if got := searchIDs(t, dm, entitygraph.SearchRequest{AgencyID: agencyA, TypeID: "WorkItem", Query: "export", Limit: 1}); len(got) != 1 {
+		t.Errorf("got %d hits with Limit 1, want 1", len(got))
+	}
+}
+
+func testSearchInvalid(t *testing.T, dm entitygraph.DataManager) {
+	cases := []struct {
+		name string
+		req  entitygraph.SearchRequest
+	}{
+		{"no type", entitygraph.SearchRequest{AgencyID: agencyA, Query: "x"}},
+		{"no words", entitygraph.SearchRequest{AgencyID: agencyA, TypeID: "WorkItem", Query: " -- "}},
+		{"negative limit", entitygraph.SearchRequest{AgencyID: agencyA, TypeID: "WorkItem", Query: "x", Limit: -1}},
+		{"type without searchable properties", entitygraph.SearchRequest{AgencyID: agencyA, TypeID: "Note", Query: "x"}},
+		{"unsearchable property", entitygraph.SearchRequest{AgencyID: agencyA, TypeID: "WorkItem", Query: "x", Properties: []string{"code"}}},
+	}
+	for _, tc := range cases {
+		if _, err := dm.SearchEntities(context.Background(), tc.req); !errors.Is(err, entitygraph.ErrInvalidSearch) {
+			t.Errorf("%s: got %v, want ErrInvalidSearch", tc.name, err)
+		}
+	}
+}
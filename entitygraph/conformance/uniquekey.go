@@ -0,0 +1,117 @@
+// uniquekey.go contains the conformance cases for UniqueKey enforcement:
+// duplicate live keys are rejected on create, update and restore, scoped by
+// agency, and concurrent upserts of one key converge on a single entity.
+package conformance
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+)
+
+func uniqueKeyCases() []dmCase {
+	return []dmCase{
+		{"CreateEntity_DuplicateUniqueKey_ErrEntityAlreadyExists", testCreateDuplicateUniqueKey},
+		{"CreateEntity_UniqueKey_IgnoresDeletedAndIncompleteKeys", testUniqueKeyExemptions},
+		{"UpdateEntity_DuplicateUniqueKey_ErrEntityAlreadyExists", testUpdateDuplicateUniqueKey},
+		{"UpsertEntity_Concurrent_SingleEntity", testUpsertConcurrent},
+	}
+}
+
+func testCreateDuplicateUniqueKey(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1", "title": "first"})
+	_, err := dm.CreateEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: agencyA, TypeID: "Goal", Properties: map[string]any{"code": "G1", "title": "second"},
+	})
+	if !errors.Is(err, entitygraph.ErrEntityAlreadyExists) {
+		t.Fatalf("duplicate CreateEntity: got %v, want ErrEntityAlreadyExists", err)
+	}
+	// The same key is free in another agency and for another type.
+	mustCreate(t, dm, agencyB, "Goal", map[string]any{"code": "G1"})
+	mustCreate(t, dm, agencyA, "WorkItem", map[string]any{"code": "G1"})
+
+	goals, err := dm.ListEntities(ctx, entitygraph.EntityFilter{AgencyID: agencyA, TypeID: "Goal"})
+	if err != nil {
+		t.Fatalf("ListEntities: %v", err)
+	}
+	if len(goals) != 1 {
+		t.Errorf("got %d agency A goals, want 1", len(goals))
+	}
+}
+
+func testUniqueKeyExemptions(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	old := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	mustDelete(t, dm, agencyA, old.ID)
+	mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+
+	if _, err := dm.RestoreEntity(ctx, agencyA, old.ID); !errors.Is(err, entitygraph.ErrEntityAlreadyExists) {
+		t.Errorf("RestoreEntity onto a held key: got %v, want ErrEntityAlreadyExists", err)
+	}
+
+	// Entities missing a key value are not constrained.
+	mustCreate(t, dm, agencyA, "Goal", map[string]any{"title": "no code"})
+	mustCreate(t, dm, agencyA, "Goal", map[string]any{"title": "no code"})
+}
+
+func testUpdateDuplicateUniqueKey(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1"})
+	g2 := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G2"})
+
+	_, err := dm.UpdateEntity(ctx, agencyA, g2.ID, entitygraph.UpdateEntityRequest{
+		Properties: map[string]any{"code": "G1"},
+	})
+	if !errors.Is(err, entitygraph.ErrEntityAlreadyExists) {
+		t.Fatalf("UpdateEntity onto a held key: got %v, want ErrEntityAlreadyExists", err)
+	}
+	got, err := dm.GetEntity(ctx, agencyA, g2.ID)
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	if got.Properties["code"] != "G2" {
+		t.Errorf("code = %v after rejected update, want G2", got.Properties["code"])
+	}
+
+	// Rewriting an entity's own key, or other properties, is not a conflict.
+	if _, err := dm.UpdateEntity(ctx, agencyA, g2.ID, entitygraph.UpdateEntityRequest{
+		Properties: map[string]any{"code": "G2", "title": "renamed"},
+	}); err != nil {
+		t.Errorf("UpdateEntity keeping its own key: %v", err)
+	}
+}
+
+func testUpsertConcurrent(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	const writers = 8
+	var wg sync.WaitGroup
+	errs := make([]error, writers)
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = dm.UpsertEntity(ctx, entitygraph.CreateEntityRequest{
+				AgencyID: agencyA, TypeID: "Goal", Properties: map[string]any{"code": "G1", "rank": i},
+			})
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("UpsertEntity #%d: %v", i, err)
+		}
+	}
+	goals, err := dm.ListEntities(ctx, entitygraph.EntityFilter{
+		AgencyID: agencyA, TypeID: "Goal", Properties: map[string]any{"code": "G1"},
+	})
+	if err != nil {
+		t.Fatalf("ListEntities: %v", err)
+	}
+	if len(goals) != 1 {
+		t.Errorf("concurrent upserts left %d entities, want 1", len(goals))
+	}
+}
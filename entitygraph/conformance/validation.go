@@ -0,0 +1,151 @@
+// validation.go contains the property validation conformance cases: every
+// backend must reject writes that violate the type's PropertyDefinitions with
+// a *entitygraph.ValidationError and persist nothing.
+package conformance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+)
+
+func validationCases() []dmCase {
+	return []dmCase{
+		{"CreateEntity_ValidProperties_Accepted", testCreateEntityValidProperties},
+		{"CreateEntity_InvalidProperties_ValidationError", testCreateEntityInvalidProperties},
+		{"CreateEntity_MissingRequired_ValidationError", testCreateEntityMissingRequired},
+		{"UpdateEntity_InvalidPatch_NotApplied", testUpdateEntityInvalidPatch},
+		{"UpdateEntity_ClearRequired_ValidationError", testUpdateEntityClearRequired},
+		{"UpsertEntity_Insert_ValidatesRequired", testUpsertEntityInsertValidates},
+		{"UpsertEntity_Merge_ValidatesPatch", testUpsertEntityMergeValidates},
+	}
+}
+
+func validReading() map[string]any {
+	return map[string]any{
+		"serial":   "R-1",
+		"count":    3,
+		"level":    4,
+		"status":   "open",
+		"taken_on": "2026-01-15",
+		"device":   "550e8400-e29b-41d4-a716-446655440000",
+		"tags":     []any{"a", "b"},
+	}
+}
+
+// wantViolation fails t unless err is a *entitygraph.ValidationError that
+// reports property.
+func wantViolation(t *testing.T, err error, property string) {
+	t.Helper()
+	if !errors.Is(err, entitygraph.ErrInvalidProperties) {
+		t.Fatalf("got %v, want ErrInvalidProperties", err)
+	}
+	var ve *entitygraph.ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("got %T, want *entitygraph.ValidationError", err)
+	}
+	for _, v := range ve.Violations {
+		if v.Property == property {
+			return
+		}
+	}
+	t.Errorf("violations %+v do not include %q", ve.Violations, property)
+}
+
+func testCreateEntityValidProperties(t *testing.T, dm entitygraph.DataManager) {
+	e := mustCreate(t, dm, agencyA, "Reading", validReading())
+	if e.Properties["status"] != "open" {
+		t.Errorf("status = %v, want open", e.Properties["status"])
+	}
+}
+
+func testCreateEntityInvalidProperties(t *testing.T, dm entitygraph.DataManager) {
+	cases := []struct {
+		key      string
+		value    any
+		property string // violation expected in the error
+	}{
+		{"count", 1.5, "count"},
+		{"level", 9, "level"},
+		{"status", "pending", "status"},
+		{"taken_on", "15/01/2026", "taken_on"},
+		{"device", "not-a-uuid", "device"},
+		{"tags", []any{"a", 2}, "tags[1]"},
+	}
+	for _, c := range cases {
+		t.Run(c.key, func(t *testing.T) {
+			props := validReading()
+			props[c.key] = c.value
+			_, err := dm.CreateEntity(context.Background(), entitygraph.CreateEntityRequest{
+				AgencyID: agencyA, TypeID: "Reading", Properties: props,
+			})
+			wantViolation(t, err, c.property)
+		})
+	}
+	list, err := dm.ListEntities(context.Background(), entitygraph.EntityFilter{AgencyID: agencyA, TypeID: "Reading"})
+	if err != nil {
+		t.Fatalf("ListEntities: %v", err)
+	}
+	if len(list) != 0 {
+		t.Errorf("got %d persisted Readings after rejected creates, want 0", len(list))
+	}
+}
+
+func testCreateEntityMissingRequired(t *testing.T, dm entitygraph.DataManager) {
+	props := validReading()
+	delete(props, "serial")
+	_, err := dm.CreateEntity(context.Background(), entitygraph.CreateEntityRequest{
+		AgencyID: agencyA, TypeID: "Reading", Properties: props,
+	})
+	wantViolation(t, err, "serial")
+}
+
+func testUpdateEntityInvalidPatch(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	e := mustCreate(t, dm, agencyA, "Reading", validReading())
+	_, err := dm.UpdateEntity(ctx, agencyA, e.ID, entitygraph.UpdateEntityRequest{
+		Properties: map[string]any{"level": 0, "count": 7},
+	})
+	wantViolation(t, err, "level")
+	got, err := dm.GetEntity(ctx, agencyA, e.ID)
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	if !numberEquals(got.Properties["count"], 3) {
+		t.Errorf("count = %v, want 3 (rejected patch must not be applied)", got.Properties["count"])
+	}
+}
+
+func testUpdateEntityClearRequired(t *testing.T, dm entitygraph.DataManager) {
+	e := mustCreate(t, dm, agencyA, "Reading", validReading())
+	_, err := dm.UpdateEntity(context.Background(), agencyA, e.ID, entitygraph.UpdateEntityRequest{
+		Properties: map[string]any{"serial": nil},
+	})
+	wantViolation(t, err, "serial")
+}
+
+func testUpsertEntityInsertValidates(t *testing.T, dm entitygraph.DataManager) {
+	// Goal has no Required properties but rejects a non-string code.
+	_, err := dm.UpsertEntity(context.Background(), entitygraph.CreateEntityRequest{
+		AgencyID: agencyA, TypeID: "Goal", Properties: map[string]any{"code": 42},
+	})
+	wantViolation(t, err, "code")
+}
+
+func testUpsertEntityMergeValidates(t *testing.T, dm entitygraph.DataManager) {
+	ctx := context.Background()
+	g := mustCreate(t, dm, agencyA, "Goal", map[string]any{"code": "G1", "rank": 1})
+	_, err := dm.UpsertEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: agencyA, TypeID: "Goal", Properties: map[string]any{"code": "G1", "rank": "high"},
+	})
+	wantViolation(t, err, "rank")
+	got, err := dm.GetEntity(ctx, agencyA, g.ID)
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	if !numberEquals(got.Properties["rank"], 1) {
+		t.Errorf("rank = %v, want 1 (rejected merge must not be applied)", got.Properties["rank"])
+	}
+}
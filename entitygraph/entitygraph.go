@@ -27,9 +27,11 @@ import (
 // target entity's TypeID does not match RelationshipDefinition.ToType.
 var ErrInvalidRelationship = errors.New("invalid relationship")
 
-// ErrRelationshipCardinalityViolation is returned by CreateRelationship when
-// a second edge with the same label is created from the same source entity and
-// RelationshipDefinition.ToMany is false (functional / at-most-one).
+// ErrRelationshipCardinalityViolation is returned when a single request
+// supplies more than one edge with the same label from the same source entity
+// and RelationshipDefinition.ToMany is false (functional / at-most-one).
+// CreateRelationship itself never returns it: a second ToMany=false edge
+// replaces the first.
 var ErrRelationshipCardinalityViolation = errors.New("relationship cardinality violation")
 
 // ErrRequiredRelationshipViolation is returned when an operation (e.g.
@@ -37,6 +39,15 @@ var ErrRelationshipCardinalityViolation = errors.New("relationship cardinality v
 // relationship declared with Required = true.
 var ErrRequiredRelationshipViolation = errors.New("required relationship violation")
 
+// ErrDeleteRestricted is returned by DeleteEntity when the entity — or an
+// entity the delete would cascade to — still has an edge of a relationship
+// whose OnDelete policy is types.DeletePolicyRestrict. Nothing is deleted.
+var ErrDeleteRestricted = errors.New("delete restricted by relationship")
+
+// ErrEntityNotDeleted is returned by RestoreEntity and PurgeEntity when the
+// entity exists but has not been soft-deleted.
+var ErrEntityNotDeleted = errors.New("entity is not deleted")
+
 // ErrSchemaNotFound is returned by SchemaManager methods when no schema
 // document (draft or published) exists for the given agency or version.
 var ErrSchemaNotFound = errors.New("schema not found")
@@ -46,9 +57,15 @@ var ErrSchemaNotFound = errors.New("schema not found")
 var ErrEntityNotFound = errors.New("entity not found")
 
 // ErrEntityAlreadyExists is returned by CreateEntity when an entity with the
-// same ID already exists for the agency.
+// same ID already exists for the agency, and by any write that would leave two
+// live entities of a type holding the same UniqueKey values (see UniqueKeyOf).
 var ErrEntityAlreadyExists = errors.New("entity already exists")
 
+// ErrConflict is returned by UpdateEntity and DeleteEntity when an IfMatch
+// revision is supplied and no longer equals the entity's current Revision —
+// another writer changed the entity since the caller read it.
+var ErrConflict = errors.New("revision conflict")
+
 // ErrRelationshipNotFound is returned by GetRelationship and DeleteRelationship
 // when no relationship with the given ID exists for the agency.
 var ErrRelationshipNotFound = errors.New("relationship not found")
@@ -76,37 +93,114 @@ var ErrUniqueKeyNotDefined = errors.New("unique key not defined for type")
 type DataManager interface {
 	// CreateEntity creates a new entity of the given type for the agency.
 	// The TypeID must match a TypeDefinition.Name in the agency's current schema.
-	// Returns ErrEntityAlreadyExists if an entity with the same ID already exists.
+	// Returns ErrEntityAlreadyExists if an entity with the same ID, or a live
+	// entity of the type with the same UniqueKey values, already exists.
+	// Returns a *ValidationError (matching ErrInvalidProperties) if Properties
+	// do not conform to the TypeDefinition.
+	// Inline req.Relationships are created atomically with the entity; see
+	// ValidateEntityRelationships and ValidateRequiredRelationships for the
+	// errors returned when they are not permitted or a Required relationship
+	// is missing. On any failure neither the entity nor its edges persist.
 	CreateEntity(ctx context.Context, req CreateEntityRequest) (Entity, error)
 
 	// GetEntity returns the entity identified by agencyID and entityID.
 	// Returns ErrEntityNotFound if no entity matches.
 	GetEntity(ctx context.Context, agencyID, entityID string) (Entity, error)
 
-	// UpdateEntity patches the properties of an existing entity.
+	// UpdateEntity patches the properties of an existing entity. Concurrent
+	// updates never lose each other's changes; set req.IfMatch to also reject
+	// an update when the entity changed since it was read.
 	// Returns ErrEntityNotFound if the entity does not exist.
+	// Returns ErrConflict if req.IfMatch is stale.
 	// Returns ErrImmutableType if the entity's type has Immutable set to true.
+	// Returns ErrEntityAlreadyExists if the patch would give the entity the
+	// UniqueKey values of another live entity.
+	// Returns a *ValidationError (matching ErrInvalidProperties) if the patch
+	// does not conform to the TypeDefinition.
 	UpdateEntity(ctx context.Context, agencyID, entityID string, req UpdateEntityRequest) (Entity, error)
 
 	// DeleteEntity soft-deletes the entity by setting Deleted=true and
-	// recording DeletedAt. The entity is never hard-deleted in v1.
-	// Relationships referencing the entity are retained as orphans.
+	// recording DeletedAt; use RestoreEntity to undo it and PurgeEntity to
+	// remove the entity permanently.
+	// Every edge from or to the entity is removed. The entity's outbound
+	// edges are handled according to their RelationshipDefinition.OnDelete
+	// (see DeletePolicyFor): cascade deletes the targets too, recursively, and
+	// restrict blocks the whole delete. Edges pointing at a deleted entity are
+	// detached, unless their source survives the delete and declares the
+	// relationship Required with no other edge of that label left. All
+	// entities and edges involved change in one transaction.
 	// Returns ErrEntityNotFound if the entity does not exist.
+	// Returns ErrDeleteRestricted if a restrict policy blocks the delete.
+	// Returns ErrRequiredRelationshipViolation if the delete would leave a
+	// surviving entity without a Required relationship.
 	DeleteEntity(ctx context.Context, agencyID, entityID string) error
 
+	// DeleteEntityIfMatch deletes the entity as DeleteEntity does, but only
+	// while its Revision equals ifMatch; an empty ifMatch deletes
+	// unconditionally.
+	// Returns ErrConflict if ifMatch is stale, and otherwise the errors of
+	// DeleteEntity.
+	DeleteEntityIfMatch(ctx context.Context, agencyID, entityID, ifMatch string) error
+
 	// ListEntities returns all entities matching the filter.
-	// Soft-deleted entities are excluded from the results.
+	// Soft-deleted entities are excluded unless filter.IncludeDeleted is set.
+	// When filter.Limit or filter.PageToken is set only that page is
+	// returned; use ListEntitiesPage to obtain the next page token.
+	// Returns ErrInvalidPageRequest for a malformed Limit, OrderBy, or
+	// PageToken, and ErrInvalidFilter for a malformed filter.Where.
 	ListEntities(ctx context.Context, filter EntityFilter) ([]Entity, error)
 
+	// ListEntitiesPage returns one page of the entities matching the filter,
+	// ordered by filter.OrderBy with ID as the final tie-breaker, together with
+	// the token for the next page and, when filter.IncludeTotal is set, the
+	// total number of matches.
+	// Returns ErrInvalidPageRequest for a malformed Limit, OrderBy, or
+	// PageToken.
+	ListEntitiesPage(ctx context.Context, filter EntityFilter) (EntityPage, error)
+
+	// RestoreEntity clears Deleted and DeletedAt on a soft-deleted entity and
+	// returns it. Edges removed by DeleteEntity, and entities removed by its
+	// cascade, are not restored.
+	// Returns ErrEntityNotFound if the entity does not exist or was purged.
+	// Returns ErrEntityNotDeleted if the entity is live.
+	// Returns ErrEntityAlreadyExists if a live entity of the same type now
+	// holds the same UniqueKey values.
+	RestoreEntity(ctx context.Context, agencyID, entityID string) (Entity, error)
+
+	// PurgeEntity permanently removes a soft-deleted entity, together with any
+	// edge still referencing it. Purged entities cannot be restored.
+	// Returns ErrEntityNotFound if the entity does not exist.
+	// Returns ErrEntityNotDeleted if the entity is live — call DeleteEntity
+	// first.
+	PurgeEntity(ctx context.Context, agencyID, entityID string) error
+
+	// PurgeDeletedBefore permanently removes every entity of agencyID that was
+	// soft-deleted before cutoff, as PurgeEntity does, and returns how many
+	// were purged. Intended for retention-window cleanup jobs.
+	PurgeDeletedBefore(ctx context.Context, agencyID string, cutoff time.Time) (int, error)
+
 	// UpsertEntity creates or merges an entity using the type's UniqueKey.
 	// If a non-deleted entity whose UniqueKey property values match the request
 	// already exists, its properties are patched (merged) and the updated entity
 	// is returned. Otherwise a new entity is inserted.
 	// Returns ErrUniqueKeyNotDefined if the TypeDefinition has no UniqueKey declared.
+	// Properties are validated as a full set on insert and as a patch on merge.
 	UpsertEntity(ctx context.Context, req CreateEntityRequest) (Entity, error)
 
 	// CreateRelationship creates a directed edge between two entities.
+	// The edge is validated with ValidateCreateRelationship against the source
+	// entity's TypeDefinition. When the RelationshipDefinition has
+	// ToMany = false, an existing edge with the same Name from the same source
+	// is replaced (re-pointed at ToID, properties overwritten, ID kept) rather
+	// than duplicated.
+	// When the RelationshipDefinition declares an Inverse, the reciprocal edge
+	// (ToID → Inverse → FromID, same Properties) is written in the same
+	// transaction and marked Derived; the two edges reference each other via
+	// PairID. When a ToMany = false replacement re-points an edge that has a
+	// pair (on either side), the now-stale pair is removed, so a half-pair is
+	// never left behind.
 	// Returns ErrEntityNotFound if either the FromID or ToID entity does not exist.
+	// Returns ErrInvalidRelationship if the edge is not declared by the schema.
 	CreateRelationship(ctx context.Context, req CreateRelationshipRequest) (Relationship, error)
 
 	// GetRelationship returns the relationship identified by agencyID and
@@ -114,20 +208,75 @@ type DataManager interface {
 	// Returns ErrRelationshipNotFound if no relationship matches.
 	GetRelationship(ctx context.Context, agencyID, relationshipID string) (Relationship, error)
 
-	// DeleteRelationship removes the edge permanently.
+	// DeleteRelationship removes the edge permanently, together with its
+	// paired edge (PairID) when it has one — deleting either the primary or
+	// the derived inverse removes both in a single transaction.
 	// Returns ErrRelationshipNotFound if no relationship matches.
 	DeleteRelationship(ctx context.Context, agencyID, relationshipID string) error
 
 	// ListRelationships returns all edges matching the filter.
 	// Zero-value filter fields are ignored (no filtering on that field).
+	// Paging fields behave as for ListEntities.
 	ListRelationships(ctx context.Context, filter RelationshipFilter) ([]Relationship, error)
 
+	// ListRelationshipsPage returns one page of the edges matching the filter,
+	// as ListEntitiesPage does for entities.
+	ListRelationshipsPage(ctx context.Context, filter RelationshipFilter) (RelationshipPage, error)
+
+	// SearchEntities runs a full-text search of req.Query over the Searchable
+	// properties of req.TypeID and returns the best-ranked live entities,
+	// highest score first, with highlights of the matched words.
+	// Returns ErrInvalidSearch for a malformed request or a type without
+	// Searchable properties.
+	SearchEntities(ctx context.Context, req SearchRequest) ([]SearchHit, error)
+
+	// GetEntityHistory returns every recorded revision of the entity, oldest
+	// first (see EntityRevision). Soft-deleted entities keep their history.
+	// Returns ErrEntityNotFound if the entity does not exist and
+	// ErrHistoryNotEnabled if its type does not set TypeDefinition.History.
+	GetEntityHistory(ctx context.Context, agencyID, entityID string) ([]EntityRevision, error)
+
+	// GetEntityAt returns the entity as it was at the instant at, replayed
+	// from its history (see EntityAt).
+	// Returns ErrEntityNotFound if the entity does not exist, did not yet
+	// exist at that instant, or was deleted at it, and ErrHistoryNotEnabled
+	// if its type does not set TypeDefinition.History.
+	GetEntityAt(ctx context.Context, agencyID, entityID string, at time.Time) (Entity, error)
+
+	// Batch applies ops — entity creates, upserts, updates and deletes, and
+	// relationship creates and deletes — for agencyID in order, as one
+	// transaction: if any operation fails none of them take effect, and the
+	// error is a *BatchOpError naming the operation. Operations may refer to
+	// entities created earlier in the batch by temporary ID (see BatchRef).
+	// Returns ErrInvalidBatch for a malformed batch.
+	Batch(ctx context.Context, agencyID string, ops []BatchOp) (BatchResult, error)
+
 	// TraverseGraph walks the entity graph from StartID to the given Depth and
 	// returns all reachable vertices and traversed edges.
 	// Soft-deleted entities are excluded from the result vertices.
 	TraverseGraph(ctx context.Context, req TraverseGraphRequest) (TraverseGraphResult, error)
 }
 
+// UpsertResult is the outcome of an upsert reported by [EntityUpserter].
+type UpsertResult struct {
+	// Entity is the entity as the upsert left it.
+	Entity Entity
+
+	// Previous is the entity the upsert merged onto, as it stood just
+	// before the merge; nil when the upsert inserted a new entity.
+	Previous *Entity
+}
+
+// EntityUpserter is implemented by DataManagers that can report what an
+// upsert did. UpsertEntityResult behaves exactly as UpsertEntity, but tells
+// an insert from a merge from within the write itself, which a read before
+// UpsertEntity cannot do once concurrent writers are involved.
+// NewPublishingDataManager relies on it to publish created or updated
+// exactly; both backends of this module implement it.
+type EntityUpserter interface {
+	UpsertEntityResult(ctx context.Context, req CreateEntityRequest) (UpsertResult, error)
+}
+
 // SchemaManager is the schema storage contract injected into a concrete
 // DataManager implementation. It separates the mutable draft schema
 // (one document per agency, overwritten by SetSchema) from the immutable
@@ -157,7 +306,10 @@ type SchemaManager interface {
 
 	// Activate promotes the given published version to active, setting Active = true
 	// on the target and Active = false on any previously active version, in a single
-	// transaction. Returns ErrSchemaNotFound if the version does not exist.
+	// transaction. Before switching, the backend reconciles its storage with the
+	// version's types, creating any collections and indexes they need; if that
+	// fails nothing is activated. Returns ErrSchemaNotFound if the version does
+	// not exist.
 	Activate(ctx context.Context, agencyID string, version int) error
 
 	// GetActive returns the single published version where Active == true.
@@ -175,10 +327,10 @@ type SchemaManager interface {
 
 // Entity is an instance of a typed real-world object managed by a DataManager.
 // TypeID matches TypeDefinition.Name in the agency's current schema.
-// Properties hold the current state values; no schema validation is performed
-// in v1.
+// Properties hold the current state values and are validated against the
+// type's PropertyDefinitions on every write (see [ValidateProperties]).
 // Deleted and DeletedAt are set by DeleteEntity (soft delete) — the entity is
-// never hard-deleted in v1.
+// only hard-deleted by PurgeEntity or PurgeDeletedBefore.
 type Entity struct {
 	// ID is the unique identifier for this entity (UUID).
 	ID string `json:"id"`
@@ -191,7 +343,6 @@ type Entity struct {
 	TypeID string `json:"typeId"`
 
 	// Properties holds the current state values keyed by property name.
-	// No schema validation is applied in v1.
 	Properties map[string]any `json:"properties,omitempty"`
 
 	// CreatedAt is the time this entity was created.
@@ -200,11 +351,19 @@ type Entity struct {
 	// UpdatedAt is the time this entity was last updated.
 	UpdatedAt time.Time `json:"updatedAt"`
 
-	// Deleted is true once DeleteEntity has been called.
+	// Deleted is true once DeleteEntity has been called, until RestoreEntity
+	// clears it.
 	Deleted bool `json:"deleted,omitempty"`
 
-	// DeletedAt is set when DeleteEntity is called; nil until then.
+	// DeletedAt is set when DeleteEntity is called; nil while the entity is
+	// live.
 	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+
+	// Revision is an opaque token that changes on every write to the entity.
+	// Pass it back as UpdateEntityRequest.IfMatch, or as the ifMatch of
+	// DeleteEntityIfMatch, to make the write conditional on nobody having changed the
+	// entity since it was read.
+	Revision string `json:"revision,omitempty"`
 }
 
 // CreateEntityRequest is the input for creating a new entity.
@@ -242,6 +401,11 @@ type EntityRelationshipRequest struct {
 type UpdateEntityRequest struct {
 	// Properties are the property values to patch onto the entity.
 	Properties map[string]any
+
+	// IfMatch, when non-empty, makes the update conditional: it is applied
+	// only while the entity's Revision equals IfMatch, and fails with
+	// ErrConflict otherwise. Empty updates unconditionally.
+	IfMatch string
 }
 
 // EntityFilter scopes a ListEntities query.
@@ -260,6 +424,37 @@ type EntityFilter struct {
 	// Typical use: filter Draft* sub-types by draft_id when the type shares a
 	// collection with multiple drafts.
 	Properties map[string]any
+
+	// Where restricts results to entities whose properties satisfy the
+	// expression tree (see FilterExpr), in addition to Properties. It is
+	// checked with ValidateFilter against the TypeDefinition when TypeID is
+	// set; a malformed tree returns ErrInvalidFilter. Nil applies no filter.
+	Where *FilterExpr
+
+	// IncludeDeleted also returns soft-deleted entities (tombstones), which
+	// are identified by Entity.Deleted. False returns live entities only.
+	IncludeDeleted bool
+
+	// OnlyDeleted returns soft-deleted entities only. It takes precedence
+	// over IncludeDeleted.
+	OnlyDeleted bool
+
+	// OrderBy sorts the results by each key in turn, with ID as the final
+	// tie-breaker. When empty, paged listings are ordered by ID and unpaged
+	// listings keep the backend's natural order.
+	// Property keys must be declared in the TypeDefinition when TypeID is set.
+	OrderBy []OrderBy
+
+	// Limit caps the number of entities returned per page. 0 means no limit.
+	Limit int
+
+	// PageToken resumes a listing after the page that returned it as
+	// EntityPage.NextPageToken. It is only valid with the same OrderBy.
+	PageToken string
+
+	// IncludeTotal asks ListEntitiesPage to count every match across all
+	// pages into EntityPage.Total.
+	IncludeTotal bool
 }
 
 // Relationship is a directed graph edge between two entities.
@@ -285,6 +480,16 @@ type Relationship struct {
 	// Properties are optional metadata carried on the edge.
 	Properties map[string]any `json:"properties,omitempty"`
 
+	// Derived is true when the edge was written automatically as the inverse
+	// of another edge (see types.RelationshipDefinition.Inverse) and false for
+	// a primary edge created directly by the caller.
+	Derived bool `json:"derived,omitempty"`
+
+	// PairID is the ID of the counterpart edge — the derived inverse of a
+	// primary edge, or the primary of a derived one. Empty when the edge has
+	// no inverse.
+	PairID string `json:"pairId,omitempty"`
+
 	// CreatedAt is the time this relationship was created.
 	CreatedAt time.Time `json:"createdAt"`
 }
@@ -322,6 +527,22 @@ type RelationshipFilter struct {
 
 	// Name filters by relationship type label; empty means all labels.
 	Name string
+
+	// OrderBy sorts the results as EntityFilter.OrderBy does. Relationships
+	// accept OrderByCreatedAt and property keys.
+	OrderBy []OrderBy
+
+	// Limit caps the number of relationships returned per page. 0 means no
+	// limit.
+	Limit int
+
+	// PageToken resumes a listing after the page that returned it as
+	// RelationshipPage.NextPageToken.
+	PageToken string
+
+	// IncludeTotal asks ListRelationshipsPage to count every match across all
+	// pages into RelationshipPage.Total.
+	IncludeTotal bool
 }
 
 // TraverseGraphRequest walks the entity graph from a starting entity.
@@ -392,6 +613,26 @@ func FindRelationshipDef(td types.TypeDefinition, label string) (types.Relations
 	return types.RelationshipDefinition{}, fmt.Errorf("relationship %q not declared on type %q", label, td.Name)
 }
 
+// DeletePolicyFor returns the OnDelete policy DeleteEntity applies to an
+// outbound edge labelled name from an entity of type td. Labels not declared
+// on td, and definitions with an empty OnDelete, resolve to
+// [types.DeletePolicyDetach].
+func DeletePolicyFor(td types.TypeDefinition, name string) types.DeletePolicy {
+	rd, err := FindRelationshipDef(td, name)
+	if err != nil || rd.OnDelete == "" {
+		return types.DeletePolicyDetach
+	}
+	return rd.OnDelete
+}
+
+// RelationshipRequired reports whether td declares the relationship name with
+// Required = true. DeleteEntity uses it to refuse a delete that would leave a
+// surviving entity without its required edge.
+func RelationshipRequired(td types.TypeDefinition, name string) bool {
+	rd, err := FindRelationshipDef(td, name)
+	return err == nil && rd.Required
+}
+
 // ValidateCreateRelationship checks that the proposed edge is permitted by the
 // schema. Must be called by every DataManager backend before writing an edge.
 //
@@ -414,6 +655,63 @@ func ValidateCreateRelationship(fromTypeDef types.TypeDefinition, label, toTypeI
 	return nil
 }
 
+// ValidateEntityRelationships checks the inline Relationships of a
+// [CreateEntityRequest] against the new entity's TypeDefinition. Must be
+// called by every DataManager backend before any writes are made.
+// toTypeIDs[i] is the TypeID of the entity referenced by rels[i].ToID.
+//
+// Rules enforced:
+//  1. Every entry passes [ValidateCreateRelationship] — otherwise
+//     [ErrInvalidRelationship].
+//  2. At most one entry per RelationshipDefinition with ToMany = false —
+//     otherwise [ErrRelationshipCardinalityViolation].
+//
+// Required relationships are checked separately by
+// [ValidateRequiredRelationships].
+func ValidateEntityRelationships(td types.TypeDefinition, rels []EntityRelationshipRequest, toTypeIDs []string) error {
+	seen := make(map[string]struct{}, len(rels))
+	for i, rel := range rels {
+		if err := ValidateCreateRelationship(td, rel.Name, toTypeIDs[i]); err != nil {
+			return fmt.Errorf("relationship %q from %q to %q: %w", rel.Name, td.Name, toTypeIDs[i], err)
+		}
+		rd, _ := FindRelationshipDef(td, rel.Name)
+		if rd.ToMany {
+			continue
+		}
+		if _, dup := seen[rel.Name]; dup {
+			return fmt.Errorf("relationship %q on %q: more than one target: %w", rel.Name, td.Name, ErrRelationshipCardinalityViolation)
+		}
+		seen[rel.Name] = struct{}{}
+	}
+	return nil
+}
+
+// ValidateRequiredRelationships checks that rels supplies at least one edge for
+// every RelationshipDefinition on td with Required = true. Called by
+// CreateEntity, and by UpsertEntity when it inserts, so that a new entity is
+// never persisted without its required edges.
+//
+// Returns [ErrRequiredRelationshipViolation] naming the first missing
+// relationship.
+func ValidateRequiredRelationships(td types.TypeDefinition, rels []EntityRelationshipRequest) error {
+	for _, rd := range td.Relationships {
+		if !rd.Required {
+			continue
+		}
+		found := false
+		for _, rel := range rels {
+			if rel.Name == rd.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("type %q: relationship %q is required: %w", td.Name, rd.Name, ErrRequiredRelationshipViolation)
+		}
+	}
+	return nil
+}
+
 // ValidateSchema checks the internal consistency of a [types.Schema] before it
 // is persisted by [SchemaManager.Publish]. Called inside Publish — invalid
 // schemas are rejected and no snapshot is created.
@@ -428,6 +726,9 @@ func ValidateCreateRelationship(fromTypeDef types.TypeDefinition, label, toTypeI
 //     with Name == rd.Inverse.
 //  4. Within each TypeDefinition, all RelationshipDefinition.PathSegment
 //     values are unique (non-empty segments only).
+//  5. RelationshipDefinition.OnDelete is empty or one of detach, cascade,
+//     restrict.
+//  6. Searchable properties are string-valued or arrays of strings.
 //
 // Returns a descriptive error on the first violation found.
 func ValidateSchema(schema types.Schema) error {
@@ -462,6 +763,12 @@ func ValidateSchema(schema types.Schema) error {
 						schema.AgencyID, td.Name, rd.Name, rd.Inverse, rd.ToType)
 				}
 			}
+			switch rd.OnDelete {
+			case "", types.DeletePolicyDetach, types.DeletePolicyCascade, types.DeletePolicyRestrict:
+			default:
+				return fmt.Errorf("ValidateSchema %s: type %q: relationship %q: unknown OnDelete policy %q",
+					schema.AgencyID, td.Name, rd.Name, rd.OnDelete)
+			}
 			if rd.PathSegment != "" {
 				if _, dup := relPathSegs[rd.PathSegment]; dup {
 					return fmt.Errorf("ValidateSchema %s: type %q: duplicate relationship PathSegment %q",
@@ -471,6 +778,13 @@ func ValidateSchema(schema types.Schema) error {
 			}
 		}
 
+		for _, pd := range td.Properties {
+			if pd.Searchable && !validSearchableType(pd) {
+				return fmt.Errorf("ValidateSchema %s: type %q: property %q of type %q cannot be Searchable",
+					schema.AgencyID, td.Name, pd.Name, pd.Type)
+			}
+		}
+
 		// Validate that every UniqueKey field references a declared property.
 		if len(td.UniqueKey) > 0 {
 			propNames := make(map[string]struct{}, len(td.Properties))
@@ -351,3 +351,42 @@ if err := entitygraph.ValidateSchema(s); err != nil {
 t.Errorf("multiple relationships with empty PathSegment should be allowed: %v", err)
 }
 }
+
+func TestValidateSchema_UnknownOnDelete_ReturnsError(t *testing.T) {
+s := types.Schema{
+ID:       "bad-on-delete",
+AgencyID: "agency-1",
+Types: []types.TypeDefinition{
+{
+Name: "Agency",
+Relationships: []types.RelationshipDefinition{
+{Name: "has_goal", ToType: "Goal", ToMany: true, OnDelete: "nullify"},
+},
+},
+{Name: "Goal"},
+},
+}
+if err := entitygraph.ValidateSchema(s); err == nil {
+t.Fatal("expected error for unknown OnDelete policy, got nil")
+}
+}
+
+func TestDeletePolicyFor_DefaultsToDetach(t *testing.T) {
+td := types.TypeDefinition{
+Name: "Agency",
+Relationships: []types.RelationshipDefinition{
+{Name: "has_goal", ToType: "Goal", OnDelete: types.DeletePolicyCascade},
+{Name: "has_workflow", ToType: "Workflow"},
+},
+}
+cases := map[string]types.DeletePolicy{
+"has_goal":     types.DeletePolicyCascade,
+"has_workflow": types.DeletePolicyDetach,
+"undeclared":   types.DeletePolicyDetach,
+}
+for name, want := range cases {
+if got := entitygraph.DeletePolicyFor(td, name); got != want {
+t.Errorf("DeletePolicyFor(%q) = %q, want %q", name, got, want)
+}
+}
+}
@@ -0,0 +1,514 @@
+// events.go — lifecycle events for PublishEvents types.
+//
+// [NewPublishingDataManager] wraps any DataManager and, after every
+// successful write, publishes the topics types.TopicsFromSchema declares for
+// the written entity's type — and only those:
+//
+//   - {prefix}.{type}.created           — CreateEntity, UpsertEntity inserting
+//   - {prefix}.{type}.updated           — UpdateEntity, UpsertEntity merging
+//   - {prefix}.{type}.update.{property} — one per declared property whose
+//     value the update changed, carrying the old and new value
+//   - {prefix}.{type}.deleted           — DeleteEntity, once per entity the
+//     delete removes, including those reached by an OnDelete cascade
+//
+// Topic names come from types.EntityTopic and types.PropertyUpdateTopic, the
+// helpers TopicsFromSchema itself uses, so the declared and the emitted
+// topics cannot drift apart. Immutable types never publish update topics.
+// Batch publishes the same events for each of its operations once the whole
+// batch has committed. RestoreEntity, PurgeEntity and relationship writes
+// have no topics and publish nothing.
+//
+// Events are published through eventbus.SafePublish after the write returns:
+// a write that fails publishes nothing, and a publish failure never fails the
+// write. The previous state an update or delete event carries is read just
+// before the write; an UpdateEntity without IfMatch is made conditional on
+// that read and retried on conflict so that the old values are exact. An
+// UpsertEntity takes insert-or-merge, and the merged-onto entity, from the
+// backend's [EntityUpserter] result instead. Writes
+// that cannot publish — every write when no type of the schema publishes,
+// and updates of types with no update topics — skip that read and pass
+// straight through.
+package entitygraph
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aosanya/CodeValdSharedLib/eventbus"
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+// maxConditionalAttempts is how many times NewPublishingDataManager tries an
+// update made conditional on its own read before it gives up on exact old
+// values and writes unconditionally, as the caller asked.
+const maxConditionalAttempts = 3
+
+// EntityEvent is the [eventbus.Event.Payload] of every lifecycle event (see
+// [LifecycleEvents]).
+type EntityEvent struct {
+	// ID identifies the event. It is unique per event and stays the same
+	// when an outbox redelivers it, so consumers can drop duplicates.
+	ID string `json:"id"`
+
+	// Entity is the entity as the write left it. For a deleted event
+	// published by NewPublishingDataManager it is the entity as read
+	// immediately before the delete.
+	Entity Entity `json:"entity"`
+
+	// Previous is the entity before the write, for updated events; nil for
+	// other events.
+	Previous *Entity `json:"previous,omitempty"`
+
+	// Property names the changed property of an update.{property} event;
+	// empty for other events.
+	Property string `json:"property,omitempty"`
+
+	// OldValue and NewValue are Property's values before and after the
+	// update; nil where the property was absent.
+	OldValue any `json:"oldValue,omitempty"`
+	NewValue any `json:"newValue,omitempty"`
+
+	// Actor identifies who made the write, as set by WithActor on its
+	// context; empty when none was set.
+	Actor string `json:"actor,omitempty"`
+}
+
+// publishingDataManager is the DataManager returned by
+// NewPublishingDataManager. Reads pass straight through to the embedded
+// DataManager.
+type publishingDataManager struct {
+	DataManager
+	pub      eventbus.Publisher
+	prefix   string
+	typeDefs map[string]types.TypeDefinition
+
+	// publishing and updating report whether any type of the schema
+	// publishes events, and update events, respectively.
+	publishing bool
+	updating   bool
+}
+
+// NewPublishingDataManager returns a DataManager that delegates to dm and
+// publishes the lifecycle events of the PublishEvents types of schema to pub
+// under servicePrefix, the same prefix passed to types.TopicsFromSchema. A nil
+// pub drops every event.
+func NewPublishingDataManager(dm DataManager, pub eventbus.Publisher, servicePrefix string, schema types.Schema) DataManager {
+	p := &publishingDataManager{DataManager: dm, pub: pub, prefix: servicePrefix,
+		typeDefs: make(map[string]types.TypeDefinition, len(schema.Types))}
+	for _, td := range schema.Types {
+		p.typeDefs[td.Name] = td
+		p.publishing = p.publishing || td.PublishEvents
+		p.updating = p.updating || publishesUpdates(td)
+	}
+	return p
+}
+
+// CreateEntity creates the entity and publishes created.
+func (p *publishingDataManager) CreateEntity(ctx context.Context, req CreateEntityRequest) (Entity, error) {
+	e, err := p.DataManager.CreateEntity(ctx, req)
+	if err != nil {
+		return e, err
+	}
+	p.publishCreated(ctx, e)
+	return e, nil
+}
+
+// UpsertEntity upserts the entity and publishes created when it was inserted,
+// or updated and update.{property} when it was merged onto an existing one.
+func (p *publishingDataManager) UpsertEntity(ctx context.Context, req CreateEntityRequest) (Entity, error) {
+	res, err := p.UpsertEntityResult(ctx, req)
+	return res.Entity, err
+}
+
+// UpsertEntityResult upserts and publishes as UpsertEntity, and reports the
+// result as [EntityUpserter] does.
+func (p *publishingDataManager) UpsertEntityResult(ctx context.Context, req CreateEntityRequest) (UpsertResult, error) {
+	res, err := p.upsert(ctx, req)
+	if err != nil {
+		return res, err
+	}
+	if res.Previous != nil {
+		p.publishUpdated(ctx, res.Previous, res.Entity)
+	} else {
+		p.publishCreated(ctx, res.Entity)
+	}
+	return res, nil
+}
+
+// upsert upserts through the embedded DataManager. An [EntityUpserter]
+// reports from the write itself whether it inserted or merged. Any other
+// DataManager is asked after a read of the entity the upsert would merge
+// onto, which a concurrent insert or delete of the same key between the read
+// and the write can make stale.
+func (p *publishingDataManager) upsert(ctx context.Context, req CreateEntityRequest) (UpsertResult, error) {
+	if u, ok := p.DataManager.(EntityUpserter); ok {
+		return u.UpsertEntityResult(ctx, req)
+	}
+	existing, found := p.upsertTarget(ctx, req.AgencyID, req)
+	e, err := p.DataManager.UpsertEntity(ctx, req)
+	res := UpsertResult{Entity: e}
+	if err == nil && found && existing.ID == e.ID {
+		res.Previous = &existing
+	}
+	return res, err
+}
+
+// UpdateEntity patches the entity and publishes updated and update.{property}.
+// Without req.IfMatch the update is made conditional on the state just read,
+// and retried on ErrConflict, so that the published old values are exact.
+// When no type of the schema publishes update events the update is passed
+// through without reading the entity first.
+func (p *publishingDataManager) UpdateEntity(ctx context.Context, agencyID, entityID string, req UpdateEntityRequest) (Entity, error) {
+	if !p.updating {
+		return p.DataManager.UpdateEntity(ctx, agencyID, entityID, req)
+	}
+	for attempt := 1; ; attempt++ {
+		before, err := p.DataManager.GetEntity(ctx, agencyID, entityID)
+		if err != nil || !publishesUpdates(p.typeDefs[before.TypeID]) {
+			return p.DataManager.UpdateEntity(ctx, agencyID, entityID, req)
+		}
+		conditional := req
+		if req.IfMatch == "" && attempt < maxConditionalAttempts {
+			conditional.IfMatch = before.Revision
+		}
+		after, err := p.DataManager.UpdateEntity(ctx, agencyID, entityID, conditional)
+		if errors.Is(err, ErrConflict) && conditional.IfMatch != req.IfMatch {
+			continue
+		}
+		if err != nil {
+			return after, err
+		}
+		p.publishUpdated(ctx, &before, after)
+		return after, nil
+	}
+}
+
+// DeleteEntity deletes the entity and publishes deleted for it and for every
+// entity its cascade removed.
+func (p *publishingDataManager) DeleteEntity(ctx context.Context, agencyID, entityID string) error {
+	return p.DeleteEntityIfMatch(ctx, agencyID, entityID, "")
+}
+
+// DeleteEntityIfMatch deletes the entity while its Revision equals ifMatch
+// and publishes as DeleteEntity does.
+func (p *publishingDataManager) DeleteEntityIfMatch(ctx context.Context, agencyID, entityID, ifMatch string) error {
+	if !p.publishing {
+		return p.DataManager.DeleteEntityIfMatch(ctx, agencyID, entityID, ifMatch)
+	}
+	var doomed []Entity
+	if root, err := p.DataManager.GetEntity(ctx, agencyID, entityID); err == nil {
+		doomed = p.deletionSet(ctx, root)
+	}
+	if err := p.DataManager.DeleteEntityIfMatch(ctx, agencyID, entityID, ifMatch); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	for _, e := range doomed {
+		p.publishDeleted(ctx, e, now)
+	}
+	return nil
+}
+
+// Batch applies the batch and, once it has committed, publishes the events of
+// each operation in order. Updates without IfMatch are made conditional as in
+// UpdateEntity. Cascades of deletes are planned against the graph as it stood
+// before the batch.
+func (p *publishingDataManager) Batch(ctx context.Context, agencyID string, ops []BatchOp) (BatchResult, error) {
+	if !p.publishing {
+		return p.DataManager.Batch(ctx, agencyID, ops)
+	}
+	for attempt := 1; ; attempt++ {
+		known, doomed := p.batchTargets(ctx, agencyID, ops)
+		conditional, injected := ops, map[int]bool(nil)
+		if attempt < maxConditionalAttempts {
+			conditional, injected = conditionalBatch(ops, known)
+		}
+		result, err := p.DataManager.Batch(ctx, agencyID, conditional)
+		var opErr *BatchOpError
+		if errors.As(err, &opErr) && injected[opErr.Index] && errors.Is(err, ErrConflict) {
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+		p.publishBatch(ctx, ops, result, known, doomed)
+		return result, nil
+	}
+}
+
+// batchTargets reads, before the batch runs, the entities its upserts would
+// merge onto and its updates and deletes name by real ID, keyed by ID, and
+// the deletion set of each such delete, keyed by operation index.
+func (p *publishingDataManager) batchTargets(ctx context.Context, agencyID string, ops []BatchOp) (map[string]Entity, map[int][]Entity) {
+	known := make(map[string]Entity)
+	doomed := make(map[int][]Entity)
+	for i, op := range ops {
+		switch op.Kind {
+		case BatchUpsertEntity:
+			if e, ok := p.upsertTarget(ctx, agencyID, op.Entity); ok {
+				known[e.ID] = e
+			}
+		case BatchUpdateEntity, BatchDeleteEntity:
+			if strings.HasPrefix(op.EntityID, BatchRefPrefix) {
+				continue
+			}
+			e, ok := known[op.EntityID]
+			if !ok {
+				var err error
+				if e, err = p.DataManager.GetEntity(ctx, agencyID, op.EntityID); err != nil {
+					continue
+				}
+				known[e.ID] = e
+			}
+			if op.Kind == BatchDeleteEntity {
+				doomed[i] = p.deletionSet(ctx, e)
+			}
+		}
+	}
+	return known, doomed
+}
+
+// conditionalBatch returns a copy of ops in which every update without
+// IfMatch of an entity in known, that no other operation of the batch writes,
+// is made conditional on the known Revision, together with the indexes of
+// the operations so changed.
+func conditionalBatch(ops []BatchOp, known map[string]Entity) ([]BatchOp, map[int]bool) {
+	writes := make(map[string]int)
+	for _, op := range ops {
+		switch op.Kind {
+		case BatchUpdateEntity, BatchDeleteEntity:
+			writes[op.EntityID]++
+		case BatchUpsertEntity:
+			for id, e := range known {
+				if e.TypeID == op.Entity.TypeID {
+					writes[id]++
+				}
+			}
+		}
+	}
+	out := make([]BatchOp, len(ops))
+	copy(out, ops)
+	injected := make(map[int]bool)
+	for i, op := range out {
+		e, ok := known[op.EntityID]
+		if op.Kind != BatchUpdateEntity || op.Update.IfMatch != "" || !ok || writes[op.EntityID] > 1 {
+			continue
+		}
+		out[i].Update.IfMatch = e.Revision
+		injected[i] = true
+	}
+	return out, injected
+}
+
+// publishBatch publishes the events of the committed batch ops, replaying
+// its results over the states read before it in known.
+func (p *publishingDataManager) publishBatch(ctx context.Context, ops []BatchOp, result BatchResult, known map[string]Entity, doomed map[int][]Entity) {
+	state := maps.Clone(known)
+	now := time.Now().UTC()
+	for i, op := range ops {
+		res := result.Results[i]
+		switch op.Kind {
+		case BatchCreateEntity:
+			p.publishCreated(ctx, *res.Entity)
+			state[res.Entity.ID] = *res.Entity
+		case BatchUpsertEntity, BatchUpdateEntity:
+			before, ok := state[res.Entity.ID]
+			switch {
+			case ok:
+				p.publishUpdated(ctx, &before, *res.Entity)
+			case op.Kind == BatchUpdateEntity:
+				p.publishUpdated(ctx, nil, *res.Entity)
+			default:
+				p.publishCreated(ctx, *res.Entity)
+			}
+			state[res.Entity.ID] = *res.Entity
+		case BatchDeleteEntity:
+			set, ok := doomed[i]
+			if !ok {
+				id := op.EntityID
+				if name, isRef := strings.CutPrefix(id, BatchRefPrefix); isRef {
+					id = result.Refs[name]
+				}
+				if e, live := state[id]; live {
+					set = []Entity{e}
+				}
+			}
+			for _, e := range set {
+				if current, live := state[e.ID]; live {
+					e = current
+				}
+				p.publishDeleted(ctx, e, now)
+				delete(state, e.ID)
+			}
+		}
+	}
+}
+
+// upsertTarget returns the live entity an upsert of req would merge onto,
+// when its type publishes events.
+func (p *publishingDataManager) upsertTarget(ctx context.Context, agencyID string, req CreateEntityRequest) (Entity, bool) {
+	td, ok := p.typeDefs[req.TypeID]
+	if !ok || !td.PublishEvents {
+		return Entity{}, false
+	}
+	key, ok := UniqueKeyOf(td, req.Properties)
+	if !ok {
+		return Entity{}, false
+	}
+	match := make(map[string]any, len(td.UniqueKey))
+	for _, field := range td.UniqueKey {
+		match[field] = req.Properties[field]
+	}
+	candidates, err := p.DataManager.ListEntities(ctx, EntityFilter{AgencyID: agencyID, TypeID: req.TypeID, Properties: match})
+	if err != nil {
+		return Entity{}, false
+	}
+	for _, e := range candidates {
+		if k, ok := UniqueKeyOf(td, e.Properties); ok && k == key {
+			return e, true
+		}
+	}
+	return Entity{}, false
+}
+
+// deletionSet returns root followed by every live entity that deleting it
+// cascades to, following OnDelete cascade edges as DeleteEntity does. Reads
+// that fail end that branch of the walk.
+func (p *publishingDataManager) deletionSet(ctx context.Context, root Entity) []Entity {
+	set := []Entity{root}
+	seen := map[string]struct{}{root.ID: {}}
+	for i := 0; i < len(set); i++ {
+		e := set[i]
+		td := p.typeDefs[e.TypeID]
+		if !hasCascade(td) {
+			continue
+		}
+		rels, err := p.DataManager.ListRelationships(ctx, RelationshipFilter{AgencyID: e.AgencyID, FromID: e.ID})
+		if err != nil {
+			continue
+		}
+		for _, r := range rels {
+			if _, dup := seen[r.ToID]; dup || DeletePolicyFor(td, r.Name) != types.DeletePolicyCascade {
+				continue
+			}
+			target, err := p.DataManager.GetEntity(ctx, e.AgencyID, r.ToID)
+			if err != nil {
+				continue
+			}
+			seen[target.ID] = struct{}{}
+			set = append(set, target)
+		}
+	}
+	return set
+}
+
+// hasCascade reports whether td declares any relationship with OnDelete
+// cascade.
+func hasCascade(td types.TypeDefinition) bool {
+	for _, rd := range td.Relationships {
+		if rd.OnDelete == types.DeletePolicyCascade {
+			return true
+		}
+	}
+	return false
+}
+
+// publishesUpdates reports whether td has update topics: it publishes events
+// and is not Immutable.
+func publishesUpdates(td types.TypeDefinition) bool {
+	return td.PublishEvents && !td.Immutable
+}
+
+// publishCreated publishes the created event of e.
+func (p *publishingDataManager) publishCreated(ctx context.Context, e Entity) {
+	p.emit(ctx, ChangeCreated, nil, e)
+}
+
+// publishUpdated publishes the updated event of after and, when before is
+// known, the update.{property} events of the properties it changed.
+func (p *publishingDataManager) publishUpdated(ctx context.Context, before *Entity, after Entity) {
+	p.emit(ctx, ChangeUpdated, before, after)
+}
+
+// publishDeleted publishes the deleted event of e, the entity as last read
+// before it was deleted at at. The event carries e as the backend stored it
+// — Deleted, with DeletedAt and UpdatedAt set to at — so it matches the one
+// a backend writes to its outbox from the deleted document.
+func (p *publishingDataManager) publishDeleted(ctx context.Context, e Entity, at time.Time) {
+	e.Deleted = true
+	e.DeletedAt = &at
+	e.UpdatedAt = at
+	p.emit(ctx, ChangeDeleted, nil, e)
+}
+
+// emit publishes the LifecycleEvents of a change to after.
+func (p *publishingDataManager) emit(ctx context.Context, change ChangeKind, before *Entity, after Entity) {
+	td, ok := p.typeDefs[after.TypeID]
+	if !ok {
+		return
+	}
+	for _, ev := range LifecycleEvents(p.prefix, td, change, before, after, ActorFromContext(ctx)) {
+		eventbus.SafePublish(ctx, p.pub, ev)
+	}
+}
+
+// LifecycleEvents returns the events a change to after, an entity of type td,
+// publishes under servicePrefix — none unless td sets PublishEvents:
+//
+//   - ChangeCreated: created
+//   - ChangeUpdated: updated, then update.{property} for each declared
+//     property whose value differs between before and after; only updated
+//     when before is nil, and nothing for an Immutable type
+//   - ChangeDeleted: deleted
+//   - ChangeRestored: nothing, as no topic is declared for it
+//
+// Each event carries an [EntityEvent] with a fresh ID, which is also the
+// event's ID, and is stamped with
+// after.UpdatedAt, or for deleted with after.DeletedAt (zero when unknown).
+// It is shared by NewPublishingDataManager and by backends that write the
+// events to an outbox, so both publish exactly what TopicsFromSchema declares.
+func LifecycleEvents(servicePrefix string, td types.TypeDefinition, change ChangeKind, before *Entity, after Entity, actor string) []eventbus.Event {
+	if !td.PublishEvents {
+		return nil
+	}
+	event := func(topic string, at time.Time, payload EntityEvent) eventbus.Event {
+		payload.ID = uuid.NewString()
+		payload.Entity = after
+		payload.Actor = actor
+		return eventbus.Event{ID: payload.ID, Topic: topic, AgencyID: after.AgencyID, Timestamp: at, Payload: payload}
+	}
+	switch change {
+	case ChangeCreated:
+		return []eventbus.Event{event(types.EntityTopic(servicePrefix, td.Name, types.TopicEventCreated), after.UpdatedAt, EntityEvent{})}
+	case ChangeDeleted:
+		var at time.Time
+		if after.DeletedAt != nil {
+			at = *after.DeletedAt
+		}
+		return []eventbus.Event{event(types.EntityTopic(servicePrefix, td.Name, types.TopicEventDeleted), at, EntityEvent{})}
+	case ChangeUpdated:
+		if td.Immutable {
+			return nil
+		}
+		events := []eventbus.Event{event(types.EntityTopic(servicePrefix, td.Name, types.TopicEventUpdated), after.UpdatedAt, EntityEvent{Previous: before})}
+		if before == nil {
+			return events
+		}
+		for _, pd := range td.Properties {
+			old, cur := before.Properties[pd.Name], after.Properties[pd.Name]
+			if CompareValues(old, cur) == 0 {
+				continue
+			}
+			events = append(events, event(types.PropertyUpdateTopic(servicePrefix, td.Name, pd.Name), after.UpdatedAt, EntityEvent{
+				Property: pd.Name, OldValue: old, NewValue: cur,
+			}))
+		}
+		return events
+	}
+	return nil
+}
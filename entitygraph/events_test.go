@@ -0,0 +1,416 @@
+package entitygraph_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+	"github.com/aosanya/CodeValdSharedLib/entitygraph/memory"
+	"github.com/aosanya/CodeValdSharedLib/eventbus"
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+const eventsAgency = "agency-1"
+
+// eventsSchema publishes Task and Board events; Board cascades to its tasks,
+// Log is Immutable and Scratch publishes nothing.
+func eventsSchema() types.Schema {
+	return types.Schema{Types: []types.TypeDefinition{
+		{
+			Name:          "Task",
+			PublishEvents: true,
+			UniqueKey:     []string{"code"},
+			Properties: []types.PropertyDefinition{
+				{Name: "code", Type: types.PropertyTypeString},
+				{Name: "status", Type: types.PropertyTypeString},
+				{Name: "points", Type: types.PropertyTypeInteger},
+			},
+		},
+		{
+			Name:          "Board",
+			PublishEvents: true,
+			Relationships: []types.RelationshipDefinition{
+				{Name: "holds", ToType: "Task", ToMany: true, OnDelete: types.DeletePolicyCascade},
+			},
+		},
+		{
+			Name:          "Log",
+			PublishEvents: true,
+			Immutable:     true,
+			Properties:    []types.PropertyDefinition{{Name: "line", Type: types.PropertyTypeString}},
+		},
+		{
+			Name:       "Scratch",
+			Properties: []types.PropertyDefinition{{Name: "text", Type: types.PropertyTypeString}},
+		},
+	}}
+}
+
+// eventRecorder collects the events published to it.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []eventbus.Event
+}
+
+func (r *eventRecorder) Publish(_ context.Context, e eventbus.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+	return nil
+}
+
+// take returns the topics recorded since the last call, and the events.
+func (r *eventRecorder) take() ([]string, []eventbus.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := r.events
+	r.events = nil
+	topics := make([]string, len(events))
+	for i, e := range events {
+		topics[i] = e.Topic
+	}
+	return topics, events
+}
+
+func newPublishing(t *testing.T) (entitygraph.DataManager, *eventRecorder) {
+	t.Helper()
+	rec := &eventRecorder{}
+	backend := memory.NewBackend(memory.Config{Schema: eventsSchema()})
+	return entitygraph.NewPublishingDataManager(backend, rec, "work", eventsSchema()), rec
+}
+
+func TestPublishingDataManager_CreateUpdateDelete(t *testing.T) {
+	ctx := entitygraph.WithActor(context.Background(), "alice")
+	dm, rec := newPublishing(t)
+
+	task, err := dm.CreateEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: eventsAgency, TypeID: "Task", Properties: map[string]any{"code": "T1", "status": "open"},
+	})
+	if err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+	topics, events := rec.take()
+	if want := []string{"work.task.created"}; !slices.Equal(topics, want) {
+		t.Fatalf("create topics = %v, want %v", topics, want)
+	}
+	if ev := events[0]; ev.AgencyID != eventsAgency || ev.Payload.(entitygraph.EntityEvent).Actor != "alice" {
+		t.Errorf("created event = %+v", ev)
+	}
+
+	if _, err := dm.UpdateEntity(ctx, eventsAgency, task.ID, entitygraph.UpdateEntityRequest{
+		Properties: map[string]any{"status": "done", "points": 3, "code": "T1"},
+	}); err != nil {
+		t.Fatalf("UpdateEntity: %v", err)
+	}
+	topics, events = rec.take()
+	if want := []string{"work.task.updated", "work.task.update.status", "work.task.update.points"}; !slices.Equal(topics, want) {
+		t.Fatalf("update topics = %v, want %v", topics, want)
+	}
+	updated := events[0].Payload.(entitygraph.EntityEvent)
+	if updated.Previous == nil || updated.Previous.Properties["status"] != "open" || updated.Entity.Properties["status"] != "done" {
+		t.Errorf("updated payload = %+v", updated)
+	}
+	status := events[1].Payload.(entitygraph.EntityEvent)
+	if status.Property != "status" || status.OldValue != "open" || status.NewValue != "done" {
+		t.Errorf("update.status payload = %+v, want open → done", status)
+	}
+	if points := events[2].Payload.(entitygraph.EntityEvent); points.OldValue != nil || points.NewValue == nil {
+		t.Errorf("update.points payload = %+v, want nil → 3", points)
+	}
+
+	if err := dm.DeleteEntity(ctx, eventsAgency, task.ID); err != nil {
+		t.Fatalf("DeleteEntity: %v", err)
+	}
+	topics, events = rec.take()
+	if want := []string{"work.task.deleted"}; !slices.Equal(topics, want) {
+		t.Fatalf("delete topics = %v, want %v", topics, want)
+	}
+	if e := events[0].Payload.(entitygraph.EntityEvent).Entity; e.ID != task.ID || e.Properties["status"] != "done" || !e.Deleted || e.DeletedAt == nil || e.DeletedAt.IsZero() {
+		t.Errorf("deleted payload entity = %+v, want the deleted task", e)
+	}
+	if events[0].Timestamp.IsZero() {
+		t.Error("deleted event Timestamp is zero, want the deletion time")
+	}
+}
+
+func TestPublishingDataManager_FailedAndUnpublishedWritesAreSilent(t *testing.T) {
+	ctx := context.Background()
+	dm, rec := newPublishing(t)
+
+	if _, err := dm.UpdateEntity(ctx, eventsAgency, "missing", entitygraph.UpdateEntityRequest{}); !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Fatalf("UpdateEntity(missing): got %v, want ErrEntityNotFound", err)
+	}
+	task, err := dm.CreateEntity(ctx, entitygraph.CreateEntityRequest{AgencyID: eventsAgency, TypeID: "Task"})
+	if err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+	rec.take()
+	if _, err := dm.UpdateEntity(ctx, eventsAgency, task.ID, entitygraph.UpdateEntityRequest{
+		Properties: map[string]any{"status": "done"}, IfMatch: "stale",
+	}); !errors.Is(err, entitygraph.ErrConflict) {
+		t.Fatalf("UpdateEntity(stale): got %v, want ErrConflict", err)
+	}
+	scratch, err := dm.CreateEntity(ctx, entitygraph.CreateEntityRequest{AgencyID: eventsAgency, TypeID: "Scratch"})
+	if err != nil {
+		t.Fatalf("CreateEntity(Scratch): %v", err)
+	}
+	if err := dm.DeleteEntity(ctx, eventsAgency, scratch.ID); err != nil {
+		t.Fatalf("DeleteEntity(Scratch): %v", err)
+	}
+	if topics, _ := rec.take(); len(topics) != 0 {
+		t.Errorf("published %v, want nothing", topics)
+	}
+}
+
+// readCounter counts the GetEntity calls made through it.
+type readCounter struct {
+	entitygraph.DataManager
+	mu    sync.Mutex
+	reads int
+}
+
+func (c *readCounter) GetEntity(ctx context.Context, agencyID, entityID string) (entitygraph.Entity, error) {
+	c.mu.Lock()
+	c.reads++
+	c.mu.Unlock()
+	return c.DataManager.GetEntity(ctx, agencyID, entityID)
+}
+
+func TestPublishingDataManager_SkipsReadsThatCannotPublish(t *testing.T) {
+	ctx := context.Background()
+	all := eventsSchema()
+	for _, tc := range []struct {
+		name       string
+		types      []string
+		wantDelete int // GetEntity calls made by DeleteEntity
+	}{
+		{name: "nothing publishes", types: []string{"Scratch"}, wantDelete: 0},
+		{name: "only immutable types publish", types: []string{"Log", "Scratch"}, wantDelete: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var schema types.Schema
+			for _, td := range all.Types {
+				if slices.Contains(tc.types, td.Name) {
+					schema.Types = append(schema.Types, td)
+				}
+			}
+			counter := &readCounter{DataManager: memory.NewBackend(memory.Config{Schema: schema})}
+			dm := entitygraph.NewPublishingDataManager(counter, &eventRecorder{}, "work", schema)
+
+			scratch, err := dm.CreateEntity(ctx, entitygraph.CreateEntityRequest{AgencyID: eventsAgency, TypeID: "Scratch"})
+			if err != nil {
+				t.Fatalf("CreateEntity: %v", err)
+			}
+			if _, err := dm.UpdateEntity(ctx, eventsAgency, scratch.ID, entitygraph.UpdateEntityRequest{
+				Properties: map[string]any{"text": "x"},
+			}); err != nil {
+				t.Fatalf("UpdateEntity: %v", err)
+			}
+			if counter.reads != 0 {
+				t.Errorf("UpdateEntity read the entity %d times, want 0", counter.reads)
+			}
+			if err := dm.DeleteEntity(ctx, eventsAgency, scratch.ID); err != nil {
+				t.Fatalf("DeleteEntity: %v", err)
+			}
+			if counter.reads != tc.wantDelete {
+				t.Errorf("DeleteEntity read %d entities, want %d", counter.reads, tc.wantDelete)
+			}
+		})
+	}
+}
+
+func TestPublishingDataManager_UpsertAndCascade(t *testing.T) {
+	ctx := context.Background()
+	dm, rec := newPublishing(t)
+
+	task, err := dm.UpsertEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: eventsAgency, TypeID: "Task", Properties: map[string]any{"code": "T1", "status": "open"},
+	})
+	if err != nil {
+		t.Fatalf("UpsertEntity(insert): %v", err)
+	}
+	if _, err := dm.UpsertEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: eventsAgency, TypeID: "Task", Properties: map[string]any{"code": "T1", "status": "done"},
+	}); err != nil {
+		t.Fatalf("UpsertEntity(merge): %v", err)
+	}
+	board, err := dm.CreateEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: eventsAgency, TypeID: "Board",
+		Relationships: []entitygraph.EntityRelationshipRequest{{Name: "holds", ToID: task.ID}},
+	})
+	if err != nil {
+		t.Fatalf("CreateEntity(Board): %v", err)
+	}
+	if err := dm.DeleteEntity(ctx, eventsAgency, board.ID); err != nil {
+		t.Fatalf("DeleteEntity(Board): %v", err)
+	}
+	topics, _ := rec.take()
+	want := []string{
+		"work.task.created",
+		"work.task.updated", "work.task.update.status",
+		"work.board.created",
+		"work.board.deleted", "work.task.deleted",
+	}
+	if !slices.Equal(topics, want) {
+		t.Errorf("topics = %v, want %v", topics, want)
+	}
+}
+
+// racingUpserter runs race just before each upsert reaches the backend, in
+// the window between any read the caller made and the write.
+type racingUpserter struct {
+	*memory.Backend
+	race func(ctx context.Context)
+}
+
+func (r *racingUpserter) UpsertEntityResult(ctx context.Context, req entitygraph.CreateEntityRequest) (entitygraph.UpsertResult, error) {
+	r.race(ctx)
+	return r.Backend.UpsertEntityResult(ctx, req)
+}
+
+func TestPublishingDataManager_UpsertRacingWriters(t *testing.T) {
+	ctx := context.Background()
+	schema := eventsSchema()
+	open := entitygraph.CreateEntityRequest{
+		AgencyID: eventsAgency, TypeID: "Task", Properties: map[string]any{"code": "T1", "status": "open"},
+	}
+	done := entitygraph.CreateEntityRequest{
+		AgencyID: eventsAgency, TypeID: "Task", Properties: map[string]any{"code": "T1", "status": "done"},
+	}
+
+	t.Run("concurrent insert", func(t *testing.T) {
+		backend := memory.NewBackend(memory.Config{Schema: schema})
+		racer := &racingUpserter{Backend: backend, race: func(ctx context.Context) {
+			if _, err := backend.CreateEntity(ctx, open); err != nil {
+				t.Fatalf("CreateEntity(racer): %v", err)
+			}
+		}}
+		rec := &eventRecorder{}
+		dm := entitygraph.NewPublishingDataManager(racer, rec, "work", schema)
+		if _, err := dm.UpsertEntity(ctx, done); err != nil {
+			t.Fatalf("UpsertEntity: %v", err)
+		}
+		topics, events := rec.take()
+		if want := []string{"work.task.updated", "work.task.update.status"}; !slices.Equal(topics, want) {
+			t.Fatalf("topics = %v, want %v", topics, want)
+		}
+		if prev := events[0].Payload.(entitygraph.EntityEvent).Previous; prev == nil || prev.Properties["status"] != "open" {
+			t.Errorf("updated Previous = %+v, want the racer's entity", prev)
+		}
+	})
+
+	t.Run("concurrent delete", func(t *testing.T) {
+		backend := memory.NewBackend(memory.Config{Schema: schema})
+		existing, err := backend.CreateEntity(ctx, open)
+		if err != nil {
+			t.Fatalf("CreateEntity: %v", err)
+		}
+		racer := &racingUpserter{Backend: backend, race: func(ctx context.Context) {
+			if err := backend.DeleteEntity(ctx, eventsAgency, existing.ID); err != nil {
+				t.Fatalf("DeleteEntity(racer): %v", err)
+			}
+		}}
+		rec := &eventRecorder{}
+		dm := entitygraph.NewPublishingDataManager(racer, rec, "work", schema)
+		if _, err := dm.UpsertEntity(ctx, done); err != nil {
+			t.Fatalf("UpsertEntity: %v", err)
+		}
+		topics, events := rec.take()
+		if want := []string{"work.task.created"}; !slices.Equal(topics, want) {
+			t.Fatalf("topics = %v, want %v", topics, want)
+		}
+		if e := events[0].Payload.(entitygraph.EntityEvent).Entity; e.ID == existing.ID {
+			t.Errorf("created event names the deleted entity %s", e.ID)
+		}
+	})
+}
+
+func TestPublishingDataManager_Batch(t *testing.T) {
+	ctx := context.Background()
+	dm, rec := newPublishing(t)
+	existing, err := dm.CreateEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: eventsAgency, TypeID: "Task", Properties: map[string]any{"code": "T0", "status": "open"},
+	})
+	if err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+	rec.take()
+
+	if _, err := dm.Batch(ctx, eventsAgency, []entitygraph.BatchOp{
+		{Kind: entitygraph.BatchCreateEntity, Ref: "t1", Entity: entitygraph.CreateEntityRequest{
+			TypeID: "Task", Properties: map[string]any{"code": "T1"},
+		}},
+		{Kind: entitygraph.BatchUpdateEntity, EntityID: existing.ID, Update: entitygraph.UpdateEntityRequest{
+			Properties: map[string]any{"status": "done"},
+		}},
+		{Kind: entitygraph.BatchUpdateEntity, EntityID: entitygraph.BatchRef("t1"), Update: entitygraph.UpdateEntityRequest{
+			Properties: map[string]any{"status": "open"},
+		}},
+		{Kind: entitygraph.BatchDeleteEntity, EntityID: entitygraph.BatchRef("t1")},
+	}); err != nil {
+		t.Fatalf("Batch: %v", err)
+	}
+	topics, _ := rec.take()
+	want := []string{
+		"work.task.created",
+		"work.task.updated", "work.task.update.status",
+		"work.task.updated", "work.task.update.status",
+		"work.task.deleted",
+	}
+	if !slices.Equal(topics, want) {
+		t.Errorf("topics = %v, want %v", topics, want)
+	}
+
+	if _, err := dm.Batch(ctx, eventsAgency, []entitygraph.BatchOp{
+		{Kind: entitygraph.BatchCreateEntity, Entity: entitygraph.CreateEntityRequest{TypeID: "Task"}},
+		{Kind: entitygraph.BatchDeleteEntity, EntityID: "missing"},
+	}); err == nil {
+		t.Fatal("Batch with a missing entity succeeded")
+	}
+	if topics, _ := rec.take(); len(topics) != 0 {
+		t.Errorf("failed batch published %v, want nothing", topics)
+	}
+}
+
+// TestPublishingDataManager_TopicsAreDeclared checks every topic published
+// across a full write cycle is one TopicsFromSchema declares.
+func TestPublishingDataManager_TopicsAreDeclared(t *testing.T) {
+	ctx := context.Background()
+	dm, rec := newPublishing(t)
+	log, err := dm.CreateEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: eventsAgency, TypeID: "Log", Properties: map[string]any{"line": "x"},
+	})
+	if err != nil {
+		t.Fatalf("CreateEntity(Log): %v", err)
+	}
+	task, err := dm.CreateEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: eventsAgency, TypeID: "Task", Properties: map[string]any{"code": "T1"},
+	})
+	if err != nil {
+		t.Fatalf("CreateEntity(Task): %v", err)
+	}
+	if _, err := dm.UpdateEntity(ctx, eventsAgency, task.ID, entitygraph.UpdateEntityRequest{
+		Properties: map[string]any{"code": "T2", "status": "open", "points": 1},
+	}); err != nil {
+		t.Fatalf("UpdateEntity: %v", err)
+	}
+	for _, id := range []string{log.ID, task.ID} {
+		if err := dm.DeleteEntity(ctx, eventsAgency, id); err != nil {
+			t.Fatalf("DeleteEntity: %v", err)
+		}
+	}
+
+	declared := types.TopicsFromSchema("work", eventsSchema())
+	topics, _ := rec.take()
+	if len(topics) != 8 {
+		t.Errorf("published %d events %v, want 8", len(topics), topics)
+	}
+	for _, topic := range topics {
+		if !slices.Contains(declared, topic) {
+			t.Errorf("published undeclared topic %q; declared %v", topic, declared)
+		}
+	}
+}
@@ -0,0 +1,387 @@
+// filter.go — the typed filter expression tree accepted by
+// EntityFilter.Where.
+//
+// A [FilterExpr] is either a comparison on one property (eq, ne, lt, lte, gt,
+// gte, in, contains, prefix, exists) or a logical combination of other
+// expressions (and, or, not). Backends call [ValidateFilter] before running a
+// listing and either evaluate the tree in process ([FilterExpr.Match]) or
+// compile it to a query language; the Arango backend passes every property
+// name and value as a bind variable so the tree carries no injection surface.
+//
+// Semantics, shared by every backend:
+//   - A missing property behaves as null.
+//   - eq, ne, and in compare by value; numbers compare numerically.
+//   - lt, lte, gt, and gte only match values of the same JSON type as the
+//     operand (number vs number, string vs string).
+//   - contains matches an array property holding the operand as an element,
+//     or a string property containing the operand as a substring.
+//   - prefix matches a string property starting with the operand.
+//   - exists matches a present, non-null property.
+package entitygraph
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+// ErrInvalidFilter is returned by ListEntities and ListEntitiesPage when
+// EntityFilter.Where is malformed or does not fit the type's
+// PropertyDefinitions.
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// FilterOp is the operator of a [FilterExpr] node.
+type FilterOp string
+
+// Comparison operators apply to FilterExpr.Property; logical operators
+// combine FilterExpr.Operands.
+const (
+	FilterEq       FilterOp = "eq"
+	FilterNe       FilterOp = "ne"
+	FilterLt       FilterOp = "lt"
+	FilterLte      FilterOp = "lte"
+	FilterGt       FilterOp = "gt"
+	FilterGte      FilterOp = "gte"
+	FilterIn       FilterOp = "in"
+	FilterContains FilterOp = "contains"
+	FilterPrefix   FilterOp = "prefix"
+	FilterExists   FilterOp = "exists"
+	FilterAnd      FilterOp = "and"
+	FilterOr       FilterOp = "or"
+	FilterNot      FilterOp = "not"
+)
+
+// maxFilterDepth bounds the nesting of a filter tree.
+const maxFilterDepth = 16
+
+// FilterExpr is one node of a filter expression tree. Build trees with the
+// constructor functions ([Eq], [And], …) rather than by hand.
+type FilterExpr struct {
+	// Op is the node's operator.
+	Op FilterOp
+
+	// Property is the property name compared by a comparison node.
+	Property string
+
+	// Value is the operand of a comparison node: a scalar, or a list of
+	// scalars for FilterIn. Unused by FilterExists.
+	Value any
+
+	// Operands are the children of a logical node: one or more for FilterAnd
+	// and FilterOr, exactly one for FilterNot.
+	Operands []FilterExpr
+}
+
+// Eq matches entities whose property equals value.
+func Eq(property string, value any) FilterExpr {
+	return FilterExpr{Op: FilterEq, Property: property, Value: value}
+}
+
+// Ne matches entities whose property does not equal value.
+func Ne(property string, value any) FilterExpr {
+	return FilterExpr{Op: FilterNe, Property: property, Value: value}
+}
+
+// Lt matches entities whose property is less than value.
+func Lt(property string, value any) FilterExpr {
+	return FilterExpr{Op: FilterLt, Property: property, Value: value}
+}
+
+// Lte matches entities whose property is less than or equal to value.
+func Lte(property string, value any) FilterExpr {
+	return FilterExpr{Op: FilterLte, Property: property, Value: value}
+}
+
+// Gt matches entities whose property is greater than value.
+func Gt(property string, value any) FilterExpr {
+	return FilterExpr{Op: FilterGt, Property: property, Value: value}
+}
+
+// Gte matches entities whose property is greater than or equal to value.
+func Gte(property string, value any) FilterExpr {
+	return FilterExpr{Op: FilterGte, Property: property, Value: value}
+}
+
+// In matches entities whose property equals any of values.
+func In(property string, values ...any) FilterExpr {
+	return FilterExpr{Op: FilterIn, Property: property, Value: values}
+}
+
+// Contains matches entities whose array property holds value, or whose
+// string property contains value as a substring.
+func Contains(property string, value any) FilterExpr {
+	return FilterExpr{Op: FilterContains, Property: property, Value: value}
+}
+
+// Prefix matches entities whose string property starts with prefix.
+func Prefix(property, prefix string) FilterExpr {
+	return FilterExpr{Op: FilterPrefix, Property: property, Value: prefix}
+}
+
+// Exists matches entities on which property is set to a non-null value.
+func Exists(property string) FilterExpr {
+	return FilterExpr{Op: FilterExists, Property: property}
+}
+
+// And matches entities matched by every operand.
+func And(operands ...FilterExpr) FilterExpr {
+	return FilterExpr{Op: FilterAnd, Operands: operands}
+}
+
+// Or matches entities matched by at least one operand.
+func Or(operands ...FilterExpr) FilterExpr {
+	return FilterExpr{Op: FilterOr, Operands: operands}
+}
+
+// Not matches entities not matched by operand.
+func Not(operand FilterExpr) FilterExpr {
+	return FilterExpr{Op: FilterNot, Operands: []FilterExpr{operand}}
+}
+
+// ValidateFilter checks the structure of f and, when td is non-nil, that every
+// compared property is declared on td with an operand of a matching type.
+// Returns an error wrapping [ErrInvalidFilter].
+func ValidateFilter(f FilterExpr, td *types.TypeDefinition) error {
+	if err := validateFilter(f, td, 0); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidFilter, err)
+	}
+	return nil
+}
+
+// validateFilter implements ValidateFilter for the node f at depth.
+func validateFilter(f FilterExpr, td *types.TypeDefinition, depth int) error {
+	if depth > maxFilterDepth {
+		return fmt.Errorf("nested deeper than %d levels", maxFilterDepth)
+	}
+	switch f.Op {
+	case FilterAnd, FilterOr, FilterNot:
+		if f.Property != "" || f.Value != nil {
+			return fmt.Errorf("%s takes operands, not a property or value", f.Op)
+		}
+		if f.Op == FilterNot && len(f.Operands) != 1 {
+			return fmt.Errorf("not needs exactly one operand")
+		}
+		if len(f.Operands) == 0 {
+			return fmt.Errorf("%s needs at least one operand", f.Op)
+		}
+		for _, op := range f.Operands {
+			if err := validateFilter(op, td, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FilterEq, FilterNe, FilterLt, FilterLte, FilterGt, FilterGte,
+		FilterIn, FilterContains, FilterPrefix, FilterExists:
+	default:
+		return fmt.Errorf("unknown operator %q", f.Op)
+	}
+
+	if f.Property == "" {
+		return fmt.Errorf("%s needs a property", f.Op)
+	}
+	if len(f.Operands) > 0 {
+		return fmt.Errorf("%s %s takes no operands", f.Property, f.Op)
+	}
+	var pd *types.PropertyDefinition
+	if td != nil {
+		i := slices.IndexFunc(td.Properties, func(p types.PropertyDefinition) bool { return p.Name == f.Property })
+		if i < 0 {
+			return fmt.Errorf("%q is not a property of %s", f.Property, td.Name)
+		}
+		pd = &td.Properties[i]
+	}
+	return validateOperand(f, pd)
+}
+
+// validateOperand checks f.Value for comparison node f, against pd when it is
+// non-nil.
+func validateOperand(f FilterExpr, pd *types.PropertyDefinition) error {
+	kind := ""
+	if pd != nil {
+		kind = propertyKind(pd.Type)
+	}
+	switch f.Op {
+	case FilterExists:
+		if f.Value != nil {
+			return fmt.Errorf("%s exists takes no value", f.Property)
+		}
+		return nil
+
+	case FilterIn:
+		values, ok := asSlice(f.Value)
+		if !ok || len(values) == 0 {
+			return fmt.Errorf("%s in needs a non-empty list", f.Property)
+		}
+		for _, v := range values {
+			if err := checkOperandKind(f, kind, v); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case FilterLt, FilterLte, FilterGt, FilterGte:
+		if k := valueKind(f.Value); k != "number" && k != "string" {
+			return fmt.Errorf("%s %s needs a number or string", f.Property, f.Op)
+		}
+		if kind == "array" || kind == "bool" {
+			return fmt.Errorf("%s %s: %s properties are not ordered", f.Property, f.Op, pd.Type)
+		}
+		return checkOperandKind(f, kind, f.Value)
+
+	case FilterPrefix:
+		if _, ok := f.Value.(string); !ok {
+			return fmt.Errorf("%s prefix needs a string", f.Property)
+		}
+		if kind != "" && kind != "string" {
+			return fmt.Errorf("%s prefix: %s properties are not strings", f.Property, pd.Type)
+		}
+		return nil
+
+	case FilterContains:
+		if kind == "array" {
+			if elemKind := propertyKind(arrayElementType(*pd)); elemKind != "" && valueKind(f.Value) != elemKind {
+				return fmt.Errorf("%s contains needs a %s element", f.Property, elemKind)
+			}
+			return nil
+		}
+		if kind != "" && kind != "string" {
+			return fmt.Errorf("%s contains: %s properties are neither arrays nor strings", f.Property, pd.Type)
+		}
+		if _, ok := f.Value.(string); kind == "string" && !ok {
+			return fmt.Errorf("%s contains needs a string", f.Property)
+		}
+		if !isScalar(f.Value) {
+			return fmt.Errorf("%s contains needs a scalar value", f.Property)
+		}
+		return nil
+	}
+
+	// eq, ne
+	if f.Value == nil {
+		return nil
+	}
+	return checkOperandKind(f, kind, f.Value)
+}
+
+// checkOperandKind reports an error when v is not a scalar, or when kind is
+// set and v is not of that kind.
+func checkOperandKind(f FilterExpr, kind string, v any) error {
+	if !isScalar(v) {
+		return fmt.Errorf("%s %s needs scalar values", f.Property, f.Op)
+	}
+	if kind != "" && kind != "array" && valueKind(v) != kind {
+		return fmt.Errorf("%s %s needs a %s value", f.Property, f.Op, kind)
+	}
+	return nil
+}
+
+// propertyKind returns the JSON kind ("string", "number", "bool", "array")
+// stored for pt, or "" when pt is unknown.
+func propertyKind(pt types.PropertyType) string {
+	switch pt {
+	case types.PropertyTypeString, types.PropertyTypeSelect, types.PropertyTypeOption,
+		types.PropertyTypeDate, types.PropertyTypeDatetime, types.PropertyTypeUUID:
+		return "string"
+	case types.PropertyTypeInteger, types.PropertyTypeFloat, types.PropertyTypeNumber, types.PropertyTypeRating:
+		return "number"
+	case types.PropertyTypeBoolean:
+		return "bool"
+	case types.PropertyTypeArray, types.PropertyTypeMultiSelect:
+		return "array"
+	}
+	return ""
+}
+
+// arrayElementType returns the element PropertyType of an array or
+// multiselect property.
+func arrayElementType(pd types.PropertyDefinition) types.PropertyType {
+	if pd.Type == types.PropertyTypeMultiSelect {
+		return types.PropertyTypeString
+	}
+	return pd.ElementType
+}
+
+// valueKind returns the JSON kind of v.
+func valueKind(v any) string {
+	switch typeRank(v) {
+	case 1:
+		return "bool"
+	case 2:
+		return "number"
+	case 3:
+		return "string"
+	case 4:
+		return "array"
+	case 5:
+		return "object"
+	}
+	return "null"
+}
+
+// isScalar reports whether v is null, a bool, a number, or a string.
+func isScalar(v any) bool {
+	return typeRank(v) <= 3
+}
+
+// Match reports whether props satisfy f. f must have passed ValidateFilter.
+func (f FilterExpr) Match(props map[string]any) bool {
+	switch f.Op {
+	case FilterAnd:
+		for _, op := range f.Operands {
+			if !op.Match(props) {
+				return false
+			}
+		}
+		return true
+	case FilterOr:
+		for _, op := range f.Operands {
+			if op.Match(props) {
+				return true
+			}
+		}
+		return false
+	case FilterNot:
+		return !f.Operands[0].Match(props)
+	}
+
+	v := props[f.Property]
+	switch f.Op {
+	case FilterEq:
+		return CompareValues(v, f.Value) == 0
+	case FilterNe:
+		return CompareValues(v, f.Value) != 0
+	case FilterLt, FilterLte, FilterGt, FilterGte:
+		if typeRank(v) != typeRank(f.Value) {
+			return false
+		}
+		c := CompareValues(v, f.Value)
+		switch f.Op {
+		case FilterLt:
+			return c < 0
+		case FilterLte:
+			return c <= 0
+		case FilterGt:
+			return c > 0
+		}
+		return c >= 0
+	case FilterIn:
+		values, _ := asSlice(f.Value)
+		return slices.ContainsFunc(values, func(want any) bool { return CompareValues(v, want) == 0 })
+	case FilterContains:
+		if elems, ok := asSlice(v); ok {
+			return slices.ContainsFunc(elems, func(e any) bool { return CompareValues(e, f.Value) == 0 })
+		}
+		s, ok := v.(string)
+		sub, subOK := f.Value.(string)
+		return ok && subOK && strings.Contains(s, sub)
+	case FilterPrefix:
+		s, ok := v.(string)
+		return ok && strings.HasPrefix(s, f.Value.(string))
+	case FilterExists:
+		return v != nil
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+// history.go — per-entity change history and point-in-time reads.
+//
+// Entities of a type with [types.TypeDefinition.History] set leave an
+// immutable [EntityRevision] behind on every create, update (including an
+// upsert merge), delete and restore: a full snapshot of the entity as the
+// write left it, the kind of change, and the actor recorded on the context by
+// [WithActor]. The revision is written in the same transaction as the change
+// itself, so history never misses a committed write nor records one that was
+// rolled back. Relationship changes are not recorded. Purging an entity
+// purges its history with it.
+//
+// DataManager.GetEntityHistory lists the revisions oldest first and
+// DataManager.GetEntityAt replays them to the state at a given instant via
+// [EntityAt], shared by every backend.
+package entitygraph
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrHistoryNotEnabled is returned by GetEntityHistory and GetEntityAt when
+// the entity's type does not set TypeDefinition.History.
+var ErrHistoryNotEnabled = errors.New("history not enabled for type")
+
+// ChangeKind names the write an [EntityRevision] records.
+type ChangeKind string
+
+const (
+	// ChangeCreated records CreateEntity, or UpsertEntity inserting.
+	ChangeCreated ChangeKind = "created"
+
+	// ChangeUpdated records UpdateEntity, or UpsertEntity merging.
+	ChangeUpdated ChangeKind = "updated"
+
+	// ChangeDeleted records DeleteEntity, including entities removed by a
+	// cascade.
+	ChangeDeleted ChangeKind = "deleted"
+
+	// ChangeRestored records RestoreEntity.
+	ChangeRestored ChangeKind = "restored"
+)
+
+// EntityRevision is one immutable entry of an entity's history.
+type EntityRevision struct {
+	// Entity is the full state of the entity immediately after the change,
+	// including its Revision and, for ChangeDeleted, Deleted and DeletedAt.
+	Entity Entity `json:"entity"`
+
+	// Change is the kind of write recorded.
+	Change ChangeKind `json:"change"`
+
+	// Actor identifies who made the change, as set by WithActor on the
+	// write's context; empty when none was set.
+	Actor string `json:"actor,omitempty"`
+
+	// RecordedAt is when the change was made; it equals Entity.UpdatedAt.
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+// actorKey is the context key under which WithActor stores the actor.
+type actorKey struct{}
+
+// WithActor returns a copy of ctx that attributes the writes made with it to
+// actor in the history of History-enabled types.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set by WithActor, or "".
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// EntityAt returns the state recorded by the last revision in history — an
+// entity's revisions, oldest first — made at or before at. It reports false
+// when at precedes the first revision or the entity was deleted at that
+// instant.
+func EntityAt(history []EntityRevision, at time.Time) (Entity, bool) {
+	var found *EntityRevision
+	for i := range history {
+		if history[i].RecordedAt.After(at) {
+			break
+		}
+		found = &history[i]
+	}
+	if found == nil || found.Entity.Deleted {
+		return Entity{}, false
+	}
+	return found.Entity, true
+}
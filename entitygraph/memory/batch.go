@@ -0,0 +1,54 @@
+// batch.go contains Batch for the Backend. The batch runs against a private
+// copy of the entity and relationship state while b.mu is held; the copy
+// replaces the live state only when every operation succeeds.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+)
+
+// Batch applies ops for agencyID atomically (see [entitygraph.ApplyBatch]).
+// No other read or write observes the batch half-applied, and a failing
+// operation leaves the store exactly as it was.
+func (b *Backend) Batch(ctx context.Context, agencyID string, ops []entitygraph.BatchOp) (entitygraph.BatchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return entitygraph.BatchResult{}, fmt.Errorf("Batch: %w", err)
+	}
+	if err := entitygraph.ValidateBatch(ops); err != nil {
+		return entitygraph.BatchResult{}, fmt.Errorf("Batch: %w", err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	tx := b.snapshotLocked()
+	result, err := entitygraph.ApplyBatch(ctx, tx, agencyID, ops)
+	if err != nil {
+		return entitygraph.BatchResult{}, fmt.Errorf("Batch: %w", err)
+	}
+	b.entities, b.entityOrder = tx.entities, tx.entityOrder
+	b.relationships, b.relOrder = tx.relationships, tx.relOrder
+	b.revision, b.history = tx.revision, tx.history
+	return result, nil
+}
+
+// snapshotLocked returns a Backend holding copies of b's entity and
+// relationship state and sharing its schema. Entities and relationships are
+// never mutated in place, and history slices are only ever appended to, so
+// copying the maps and order slices suffices. The caller must hold b.mu.
+func (b *Backend) snapshotLocked() *Backend {
+	return &Backend{
+		typeDefs:      b.typeDefs,
+		entities:      maps.Clone(b.entities),
+		entityOrder:   slices.Clone(b.entityOrder),
+		relationships: maps.Clone(b.relationships),
+		relOrder:      slices.Clone(b.relOrder),
+		drafts:        b.drafts,
+		published:     b.published,
+		revision:      b.revision,
+		history:       maps.Clone(b.history),
+	}
+}
@@ -0,0 +1,37 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+	"github.com/aosanya/CodeValdSharedLib/entitygraph/conformance"
+	"github.com/aosanya/CodeValdSharedLib/entitygraph/memory"
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+func TestConformance_DataManager(t *testing.T) {
+	conformance.RunDataManagerSuite(t, func(t *testing.T, s types.Schema) entitygraph.DataManager {
+		return memory.NewBackend(memory.Config{Schema: s})
+	})
+}
+
+func TestConformance_SchemaManager(t *testing.T) {
+	conformance.RunSchemaManagerSuite(t, func(t *testing.T) entitygraph.SchemaManager {
+		return memory.NewBackend(memory.Config{})
+	})
+}
+
+// TestConformance_PublishingDataManager checks that wrapping a backend with
+// entitygraph.NewPublishingDataManager leaves its behaviour unchanged, with
+// every fixture type publishing events.
+func TestConformance_PublishingDataManager(t *testing.T) {
+	conformance.RunDataManagerSuite(t, func(t *testing.T, s types.Schema) entitygraph.DataManager {
+		published := s
+		published.Types = make([]types.TypeDefinition, len(s.Types))
+		for i, td := range s.Types {
+			td.PublishEvents = true
+			published.Types[i] = td
+		}
+		return entitygraph.NewPublishingDataManager(memory.NewBackend(memory.Config{Schema: s}), nil, "conformance", published)
+	})
+}
@@ -0,0 +1,605 @@
+// entities.go contains CreateEntity, GetEntity, UpdateEntity, DeleteEntity,
+// ListEntities, ListEntitiesPage, UpsertEntity, RestoreEntity, PurgeEntity,
+// and PurgeDeletedBefore for the Backend.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+// CreateEntity stores a new entity under a freshly generated UUID together
+// with its inline req.Relationships. All targets are validated before anything
+// is written, so a rejected request leaves no trace.
+// Returns entitygraph.ErrEntityAlreadyExists if the generated ID collides with
+// an existing entity or a live entity of the type already holds the same
+// UniqueKey values. Returns a *entitygraph.ValidationError if the properties
+// do not conform to the type's PropertyDefinitions.
+// Returns entitygraph.ErrInvalidRelationship or
+// entitygraph.ErrRelationshipCardinalityViolation if an inline edge is not
+// permitted, and entitygraph.ErrRequiredRelationshipViolation if a Required
+// relationship is not supplied.
+func (b *Backend) CreateEntity(ctx context.Context, req entitygraph.CreateEntityRequest) (entitygraph.Entity, error) {
+	if err := ctx.Err(); err != nil {
+		return entitygraph.Entity{}, fmt.Errorf("CreateEntity: %w", err)
+	}
+	if err := b.validateProperties(req.TypeID, req.Properties, entitygraph.ValidateCreate); err != nil {
+		return entitygraph.Entity{}, fmt.Errorf("CreateEntity: %w", err)
+	}
+	if td, ok := b.typeDefs[req.TypeID]; ok {
+		if err := entitygraph.ValidateRequiredRelationships(td, req.Relationships); err != nil {
+			return entitygraph.Entity{}, fmt.Errorf("CreateEntity: %w", err)
+		}
+	}
+	props, err := cloneProps(req.Properties)
+	if err != nil {
+		return entitygraph.Entity{}, fmt.Errorf("CreateEntity: %w", err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	edges, err := b.inlineEdgesLocked(req)
+	if err != nil {
+		return entitygraph.Entity{}, fmt.Errorf("CreateEntity: %w", err)
+	}
+	if holder, ok := b.uniqueKeyHolderLocked(req.AgencyID, req.TypeID, props, ""); ok {
+		return entitygraph.Entity{}, fmt.Errorf("CreateEntity: unique key held by %s: %w", holder, entitygraph.ErrEntityAlreadyExists)
+	}
+	e, err := b.insertEntityLocked(ctx, req.AgencyID, req.TypeID, props)
+	if err != nil {
+		return entitygraph.Entity{}, fmt.Errorf("CreateEntity: %w", err)
+	}
+	b.writeInlineEdgesLocked(e.ID, edges, false)
+	return copyEntity(e), nil
+}
+
+// GetEntity returns the entity identified by agencyID and entityID.
+// Returns [entitygraph.ErrEntityNotFound] if the entity does not exist,
+// belongs to a different agency, or has been soft-deleted.
+func (b *Backend) GetEntity(ctx context.Context, agencyID, entityID string) (entitygraph.Entity, error) {
+	if err := ctx.Err(); err != nil {
+		return entitygraph.Entity{}, fmt.Errorf("GetEntity: %w", err)
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	e, ok := b.liveEntityLocked(agencyID, entityID)
+	if !ok {
+		return entitygraph.Entity{}, fmt.Errorf("GetEntity %s: %w", entityID, entitygraph.ErrEntityNotFound)
+	}
+	return copyEntity(e), nil
+}
+
+// UpdateEntity merges req.Properties onto the stored entity.
+// Returns entitygraph.ErrImmutableType if the entity's TypeID has Immutable set.
+// Returns entitygraph.ErrEntityNotFound if the entity does not exist.
+// Returns entitygraph.ErrConflict if req.IfMatch is not the entity's current
+// Revision.
+// Returns entitygraph.ErrEntityAlreadyExists if the patch would give the
+// entity the UniqueKey values of another live entity.
+// Returns a *entitygraph.ValidationError if the patch does not conform to the
+// type's PropertyDefinitions.
+func (b *Backend) UpdateEntity(
+	ctx context.Context,
+	agencyID, entityID string,
+	req entitygraph.UpdateEntityRequest,
+) (entitygraph.Entity, error) {
+	if err := ctx.Err(); err != nil {
+		return entitygraph.Entity{}, fmt.Errorf("UpdateEntity %s: %w", entityID, err)
+	}
+	patch, err := cloneProps(req.Properties)
+	if err != nil {
+		return entitygraph.Entity{}, fmt.Errorf("UpdateEntity %s: %w", entityID, err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	existing, ok := b.liveEntityLocked(agencyID, entityID)
+	if !ok {
+		return entitygraph.Entity{}, fmt.Errorf("UpdateEntity %s: %w", entityID, entitygraph.ErrEntityNotFound)
+	}
+	if b.isImmutable(existing.TypeID) {
+		return entitygraph.Entity{}, fmt.Errorf("UpdateEntity %s: %w", entityID, entitygraph.ErrImmutableType)
+	}
+	if req.IfMatch != "" && req.IfMatch != existing.Revision {
+		return entitygraph.Entity{}, fmt.Errorf("UpdateEntity %s: revision %s: %w", entityID, req.IfMatch, entitygraph.ErrConflict)
+	}
+	if err := b.validateProperties(existing.TypeID, req.Properties, entitygraph.ValidatePatch); err != nil {
+		return entitygraph.Entity{}, fmt.Errorf("UpdateEntity %s: %w", entityID, err)
+	}
+	if holder, ok := b.uniqueKeyHolderLocked(agencyID, existing.TypeID, mergeProps(existing.Properties, patch), entityID); ok {
+		return entitygraph.Entity{}, fmt.Errorf("UpdateEntity %s: unique key held by %s: %w", entityID, holder, entitygraph.ErrEntityAlreadyExists)
+	}
+	updated := b.mergeLocked(ctx, existing, patch)
+	return copyEntity(updated), nil
+}
+
+// DeleteEntity soft-deletes the entity by setting Deleted=true and recording
+// DeletedAt, together with every entity its OnDelete cascade policies reach
+// (see deletionSetLocked). The entities are retained in memory; every edge
+// from or to any of them is removed. All changes are made under one lock, and
+// nothing changes when a restrict policy blocks the delete or a surviving
+// entity would lose a Required relationship (see checkRequiredInboundLocked).
+func (b *Backend) DeleteEntity(ctx context.Context, agencyID, entityID string) error {
+	return b.DeleteEntityIfMatch(ctx, agencyID, entityID, "")
+}
+
+// DeleteEntityIfMatch deletes the entity as DeleteEntity does, changing
+// nothing when a non-empty ifMatch is not the entity's current Revision.
+func (b *Backend) DeleteEntityIfMatch(ctx context.Context, agencyID, entityID, ifMatch string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("DeleteEntity %s: %w", entityID, err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	existing, ok := b.liveEntityLocked(agencyID, entityID)
+	if !ok {
+		return fmt.Errorf("DeleteEntity %s: %w", entityID, entitygraph.ErrEntityNotFound)
+	}
+	if ifMatch != "" && ifMatch != existing.Revision {
+		return fmt.Errorf("DeleteEntity %s: revision %s: %w", entityID, ifMatch, entitygraph.ErrConflict)
+	}
+	ids, err := b.deletionSetLocked(agencyID, entityID)
+	if err != nil {
+		return fmt.Errorf("DeleteEntity %s: %w", entityID, err)
+	}
+	doomed := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		doomed[id] = struct{}{}
+	}
+	if err := b.checkRequiredInboundLocked(agencyID, doomed); err != nil {
+		return fmt.Errorf("DeleteEntity %s: %w", entityID, err)
+	}
+	now := time.Now().UTC()
+	for _, id := range ids {
+		e := b.entities[id]
+		e.UpdatedAt = now
+		e.Deleted = true
+		e.DeletedAt = &now
+		e.Revision = b.nextRevisionLocked()
+		b.entities[id] = e
+		b.recordLocked(ctx, e, entitygraph.ChangeDeleted)
+	}
+	b.removeEdgesTouchingLocked(agencyID, doomed)
+	return nil
+}
+
+// removeEdgesTouchingLocked removes every edge of agencyID whose FromID or
+// ToID is in ids. The caller must hold b.mu for writing.
+func (b *Backend) removeEdgesTouchingLocked(agencyID string, ids map[string]struct{}) {
+	kept := b.relOrder[:0]
+	for _, rid := range b.relOrder {
+		r := b.relationships[rid]
+		_, from := ids[r.FromID]
+		_, to := ids[r.ToID]
+		if r.AgencyID == agencyID && (from || to) {
+			delete(b.relationships, rid)
+			continue
+		}
+		kept = append(kept, rid)
+	}
+	b.relOrder = kept
+}
+
+// checkRequiredInboundLocked returns an error wrapping
+// [entitygraph.ErrRequiredRelationshipViolation] when a live entity outside
+// ids has an edge into ids whose label its type declares Required, and no
+// other edge of that label to an entity outside ids. DeleteEntity calls it
+// before removing any edge, so only inbound edges it allows are detached. The
+// caller must hold b.mu.
+func (b *Backend) checkRequiredInboundLocked(agencyID string, ids map[string]struct{}) error {
+	kept := make(map[[2]string]bool)
+	var inbound []entitygraph.Relationship
+	for _, rid := range b.relOrder {
+		r := b.relationships[rid]
+		if r.AgencyID != agencyID {
+			continue
+		}
+		if _, doomed := ids[r.FromID]; doomed {
+			continue
+		}
+		if _, doomed := ids[r.ToID]; doomed {
+			inbound = append(inbound, r)
+		} else {
+			kept[[2]string{r.FromID, r.Name}] = true
+		}
+	}
+	for _, r := range inbound {
+		src, live := b.liveEntityLocked(agencyID, r.FromID)
+		if !live || kept[[2]string{r.FromID, r.Name}] {
+			continue
+		}
+		if entitygraph.RelationshipRequired(b.typeDefs[src.TypeID], r.Name) {
+			return fmt.Errorf("entity %s requires its %q edge to %s: %w", r.FromID, r.Name, r.ToID, entitygraph.ErrRequiredRelationshipViolation)
+		}
+	}
+	return nil
+}
+
+// deletionSetLocked returns entityID followed by every live entity that
+// deleting it cascades to, applying [entitygraph.DeletePolicyFor] to the
+// outbound edges of each entity in turn. Returns
+// [entitygraph.ErrDeleteRestricted] if any entity in the set has an edge
+// whose policy is restrict. The caller must hold b.mu.
+func (b *Backend) deletionSetLocked(agencyID, entityID string) ([]string, error) {
+	ids := []string{entityID}
+	seen := map[string]struct{}{entityID: {}}
+	for i := 0; i < len(ids); i++ {
+		id := ids[i]
+		td := b.typeDefs[b.entities[id].TypeID]
+		for _, rid := range b.relOrder {
+			r := b.relationships[rid]
+			if r.FromID != id || r.AgencyID != agencyID {
+				continue
+			}
+			switch entitygraph.DeletePolicyFor(td, r.Name) {
+			case types.DeletePolicyRestrict:
+				return nil, fmt.Errorf("entity %s has %q edge to %s: %w", id, r.Name, r.ToID, entitygraph.ErrDeleteRestricted)
+			case types.DeletePolicyCascade:
+				if _, ok := seen[r.ToID]; ok {
+					continue
+				}
+				if _, live := b.liveEntityLocked(agencyID, r.ToID); !live {
+					continue
+				}
+				seen[r.ToID] = struct{}{}
+				ids = append(ids, r.ToID)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// ListEntities returns entities matching the filter in insertion order, or
+// sorted and paged when filter.OrderBy, Limit, or PageToken is set.
+// Soft-deleted entities are skipped unless filter.IncludeDeleted is set.
+// Zero-value filter fields are treated as "no restriction".
+func (b *Backend) ListEntities(
+	ctx context.Context,
+	filter entitygraph.EntityFilter,
+) ([]entitygraph.Entity, error) {
+	page, err := b.listEntities(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("ListEntities: %w", err)
+	}
+	return page.Entities, nil
+}
+
+// ListEntitiesPage returns one page of the entities matching the filter
+// together with the next page token and, when requested, the total count.
+func (b *Backend) ListEntitiesPage(
+	ctx context.Context,
+	filter entitygraph.EntityFilter,
+) (entitygraph.EntityPage, error) {
+	page, err := b.listEntities(ctx, filter)
+	if err != nil {
+		return entitygraph.EntityPage{}, fmt.Errorf("ListEntitiesPage: %w", err)
+	}
+	return page, nil
+}
+
+// listEntities implements ListEntities and ListEntitiesPage.
+func (b *Backend) listEntities(ctx context.Context, filter entitygraph.EntityFilter) (entitygraph.EntityPage, error) {
+	if err := ctx.Err(); err != nil {
+		return entitygraph.EntityPage{}, err
+	}
+	td := b.typeDefFor(filter.TypeID)
+	if err := entitygraph.ValidatePageRequest(filter.Limit, filter.OrderBy, td, false); err != nil {
+		return entitygraph.EntityPage{}, err
+	}
+	if filter.Where != nil {
+		if err := entitygraph.ValidateFilter(*filter.Where, td); err != nil {
+			return entitygraph.EntityPage{}, err
+		}
+	}
+	want, err := cloneProps(filter.Properties)
+	if err != nil {
+		return entitygraph.EntityPage{}, err
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	var matched []entitygraph.Entity
+	for _, id := range b.entityOrder {
+		e := b.entities[id]
+		if filter.OnlyDeleted && !e.Deleted {
+			continue
+		}
+		if e.Deleted && !filter.IncludeDeleted && !filter.OnlyDeleted {
+			continue
+		}
+		if filter.AgencyID != "" && e.AgencyID != filter.AgencyID {
+			continue
+		}
+		if filter.TypeID != "" && e.TypeID != filter.TypeID {
+			continue
+		}
+		if !propsMatch(e.Properties, want) {
+			continue
+		}
+		if filter.Where != nil && !filter.Where.Match(e.Properties) {
+			continue
+		}
+		matched = append(matched, e)
+	}
+
+	var page entitygraph.EntityPage
+	if filter.IncludeTotal {
+		page.Total = len(matched)
+	}
+	if entitygraph.Paginated(filter.OrderBy, filter.Limit, filter.PageToken) {
+		keys := func(e entitygraph.Entity) ([]any, string) {
+			return entitygraph.EntitySortKeys(e, filter.OrderBy), e.ID
+		}
+		matched, page.NextPageToken, err = pageOf(matched, keys, filter.OrderBy, filter.Limit, filter.PageToken)
+		if err != nil {
+			return entitygraph.EntityPage{}, err
+		}
+	}
+	for _, e := range matched {
+		page.Entities = append(page.Entities, copyEntity(e))
+	}
+	return page, nil
+}
+
+// RestoreEntity clears Deleted and DeletedAt on a soft-deleted entity and
+// bumps UpdatedAt. Returns entitygraph.ErrEntityNotDeleted for a live entity
+// and entitygraph.ErrEntityAlreadyExists when a live entity of the same type
+// now holds its UniqueKey values.
+func (b *Backend) RestoreEntity(ctx context.Context, agencyID, entityID string) (entitygraph.Entity, error) {
+	if err := ctx.Err(); err != nil {
+		return entitygraph.Entity{}, fmt.Errorf("RestoreEntity %s: %w", entityID, err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e, ok := b.entityLocked(agencyID, entityID)
+	if !ok {
+		return entitygraph.Entity{}, fmt.Errorf("RestoreEntity %s: %w", entityID, entitygraph.ErrEntityNotFound)
+	}
+	if !e.Deleted {
+		return entitygraph.Entity{}, fmt.Errorf("RestoreEntity %s: %w", entityID, entitygraph.ErrEntityNotDeleted)
+	}
+	if holder, ok := b.uniqueKeyHolderLocked(agencyID, e.TypeID, e.Properties, entityID); ok {
+		return entitygraph.Entity{}, fmt.Errorf("RestoreEntity %s: unique key held by %s: %w", entityID, holder, entitygraph.ErrEntityAlreadyExists)
+	}
+	e.Deleted = false
+	e.DeletedAt = nil
+	e.UpdatedAt = time.Now().UTC()
+	e.Revision = b.nextRevisionLocked()
+	b.entities[entityID] = e
+	b.recordLocked(ctx, e, entitygraph.ChangeRestored)
+	return copyEntity(e), nil
+}
+
+// PurgeEntity permanently removes a soft-deleted entity and any edge still
+// referencing it. Returns entitygraph.ErrEntityNotDeleted for a live entity.
+func (b *Backend) PurgeEntity(ctx context.Context, agencyID, entityID string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("PurgeEntity %s: %w", entityID, err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e, ok := b.entityLocked(agencyID, entityID)
+	if !ok {
+		return fmt.Errorf("PurgeEntity %s: %w", entityID, entitygraph.ErrEntityNotFound)
+	}
+	if !e.Deleted {
+		return fmt.Errorf("PurgeEntity %s: %w", entityID, entitygraph.ErrEntityNotDeleted)
+	}
+	b.purgeLocked(agencyID, map[string]struct{}{entityID: {}})
+	return nil
+}
+
+// PurgeDeletedBefore permanently removes every entity of agencyID whose
+// DeletedAt is before cutoff, and any edge still referencing them.
+func (b *Backend) PurgeDeletedBefore(ctx context.Context, agencyID string, cutoff time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, fmt.Errorf("PurgeDeletedBefore: %w", err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	doomed := make(map[string]struct{})
+	for _, id := range b.entityOrder {
+		e := b.entities[id]
+		if e.AgencyID == agencyID && e.Deleted && e.DeletedAt != nil && e.DeletedAt.Before(cutoff) {
+			doomed[id] = struct{}{}
+		}
+	}
+	b.purgeLocked(agencyID, doomed)
+	return len(doomed), nil
+}
+
+// purgeLocked removes the entities in ids, their history, and every edge of
+// agencyID from or to any of them, from both the indexes and the
+// insertion-order slices. The caller must hold b.mu for writing.
+func (b *Backend) purgeLocked(agencyID string, ids map[string]struct{}) {
+	if len(ids) == 0 {
+		return
+	}
+	keptEntities := b.entityOrder[:0]
+	for _, id := range b.entityOrder {
+		if _, ok := ids[id]; ok {
+			delete(b.entities, id)
+			delete(b.history, id)
+			continue
+		}
+		keptEntities = append(keptEntities, id)
+	}
+	b.entityOrder = keptEntities
+	b.removeEdgesTouchingLocked(agencyID, ids)
+}
+
+// UpsertEntity finds a non-deleted entity whose UniqueKey property values
+// match the request and merges the supplied properties onto it, or inserts a
+// new entity if no match is found. The lookup and write happen under a single
+// lock, so concurrent upserts with the same key never produce duplicates.
+// Properties are validated as a full set on insert and as a patch on merge.
+// Inline req.Relationships are written with the entity: on insert they must
+// satisfy every Required relationship; on merge they are applied idempotently.
+// Returns [entitygraph.ErrUniqueKeyNotDefined] if the type has no UniqueKey.
+func (b *Backend) UpsertEntity(ctx context.Context, req entitygraph.CreateEntityRequest) (entitygraph.Entity, error) {
+	res, err := b.UpsertEntityResult(ctx, req)
+	return res.Entity, err
+}
+
+// UpsertEntityResult implements [entitygraph.EntityUpserter]: it upserts as
+// UpsertEntity and reports the entity merged onto, if any.
+func (b *Backend) UpsertEntityResult(ctx context.Context, req entitygraph.CreateEntityRequest) (entitygraph.UpsertResult, error) {
+	if err := ctx.Err(); err != nil {
+		return entitygraph.UpsertResult{}, fmt.Errorf("UpsertEntity %s: %w", req.TypeID, err)
+	}
+	td, ok := b.typeDefs[req.TypeID]
+	if !ok || len(td.UniqueKey) == 0 {
+		return entitygraph.UpsertResult{}, fmt.Errorf("UpsertEntity %s: %w", req.TypeID, entitygraph.ErrUniqueKeyNotDefined)
+	}
+	props, err := cloneProps(req.Properties)
+	if err != nil {
+		return entitygraph.UpsertResult{}, fmt.Errorf("UpsertEntity %s: %w", req.TypeID, err)
+	}
+	key := make(map[string]any, len(td.UniqueKey))
+	for _, field := range td.UniqueKey {
+		key[field] = props[field]
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	edges, err := b.inlineEdgesLocked(req)
+	if err != nil {
+		return entitygraph.UpsertResult{}, fmt.Errorf("UpsertEntity: %w", err)
+	}
+	for _, id := range b.entityOrder {
+		e := b.entities[id]
+		if e.Deleted || e.AgencyID != req.AgencyID || e.TypeID != req.TypeID {
+			continue
+		}
+		if !propsMatch(e.Properties, key) {
+			continue
+		}
+		if err := b.validateProperties(req.TypeID, req.Properties, entitygraph.ValidatePatch); err != nil {
+			return entitygraph.UpsertResult{}, fmt.Errorf("UpsertEntity: %w", err)
+		}
+		previous := copyEntity(e)
+		merged := b.mergeLocked(ctx, e, props)
+		b.writeInlineEdgesLocked(merged.ID, edges, true)
+		return entitygraph.UpsertResult{Entity: copyEntity(merged), Previous: &previous}, nil
+	}
+	if err := b.validateProperties(req.TypeID, req.Properties, entitygraph.ValidateCreate); err != nil {
+		return entitygraph.UpsertResult{}, fmt.Errorf("UpsertEntity: %w", err)
+	}
+	if err := entitygraph.ValidateRequiredRelationships(td, req.Relationships); err != nil {
+		return entitygraph.UpsertResult{}, fmt.Errorf("UpsertEntity: %w", err)
+	}
+	e, err := b.insertEntityLocked(ctx, req.AgencyID, req.TypeID, props)
+	if err != nil {
+		return entitygraph.UpsertResult{}, fmt.Errorf("UpsertEntity: %w", err)
+	}
+	b.writeInlineEdgesLocked(e.ID, edges, false)
+	return entitygraph.UpsertResult{Entity: copyEntity(e)}, nil
+}
+
+// insertEntityLocked stores a new entity with a generated ID and records it
+// in the type's history. props must already be cloned. The caller must hold
+// b.mu for writing.
+func (b *Backend) insertEntityLocked(ctx context.Context, agencyID, typeID string, props map[string]any) (entitygraph.Entity, error) {
+	id := uuid.NewString()
+	if _, exists := b.entities[id]; exists {
+		return entitygraph.Entity{}, entitygraph.ErrEntityAlreadyExists
+	}
+	now := time.Now().UTC()
+	e := entitygraph.Entity{
+		ID:         id,
+		AgencyID:   agencyID,
+		TypeID:     typeID,
+		Properties: props,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Revision:   b.nextRevisionLocked(),
+	}
+	b.entities[id] = e
+	b.entityOrder = append(b.entityOrder, id)
+	b.recordLocked(ctx, e, entitygraph.ChangeCreated)
+	return e, nil
+}
+
+// mergeLocked applies patch onto existing, bumps UpdatedAt, stores and
+// records the result, and returns it. patch must already be cloned. The caller
+// must hold b.mu for writing.
+func (b *Backend) mergeLocked(ctx context.Context, existing entitygraph.Entity, patch map[string]any) entitygraph.Entity {
+	existing.Properties = mergeProps(existing.Properties, patch)
+	existing.UpdatedAt = time.Now().UTC()
+	existing.Revision = b.nextRevisionLocked()
+	b.entities[existing.ID] = existing
+	b.recordLocked(ctx, existing, entitygraph.ChangeUpdated)
+	return existing
+}
+
+// nextRevisionLocked returns a fresh Entity.Revision. Revisions are drawn
+// from one counter per Backend, so they never repeat. The caller must hold
+// b.mu for writing.
+func (b *Backend) nextRevisionLocked() string {
+	b.revision++
+	return strconv.FormatUint(b.revision, 10)
+}
+
+// mergeProps returns a new map holding props overlaid with patch.
+func mergeProps(props, patch map[string]any) map[string]any {
+	merged := make(map[string]any, len(props)+len(patch))
+	for k, v := range props {
+		merged[k] = v
+	}
+	for k, v := range patch {
+		merged[k] = v
+	}
+	return merged
+}
+
+// uniqueKeyHolderLocked returns the ID of the live entity of agencyID, other
+// than exceptID, whose UniqueKey values for typeID equal those in props (see
+// [entitygraph.UniqueKeyOf]). The caller must hold b.mu.
+func (b *Backend) uniqueKeyHolderLocked(agencyID, typeID string, props map[string]any, exceptID string) (string, bool) {
+	td, ok := b.typeDefs[typeID]
+	if !ok {
+		return "", false
+	}
+	key, ok := entitygraph.UniqueKeyOf(td, props)
+	if !ok {
+		return "", false
+	}
+	for _, id := range b.entityOrder {
+		e := b.entities[id]
+		if id == exceptID || e.Deleted || e.AgencyID != agencyID || e.TypeID != typeID {
+			continue
+		}
+		if k, ok := entitygraph.UniqueKeyOf(td, e.Properties); ok && k == key {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// liveEntityLocked returns the non-deleted entity with entityID owned by
+// agencyID. The caller must hold b.mu.
+func (b *Backend) liveEntityLocked(agencyID, entityID string) (entitygraph.Entity, bool) {
+	e, ok := b.entities[entityID]
+	if !ok || e.AgencyID != agencyID || e.Deleted {
+		return entitygraph.Entity{}, false
+	}
+	return e, true
+}
+
+// propsMatch reports whether every key in want is present in have with an
+// equal value. A nil value in want matches an absent key, mirroring AQL's
+// treatment of missing attributes as null. Both maps must already be in
+// JSON-decoded form (see cloneProps) so that numeric types compare equal.
+func propsMatch(have, want map[string]any) bool {
+	for k, v := range want {
+		if !reflect.DeepEqual(have[k], v) {
+			return false
+		}
+	}
+	return true
+}
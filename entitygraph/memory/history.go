@@ -0,0 +1,87 @@
+// history.go contains GetEntityHistory and GetEntityAt for the Backend, and
+// the recording of revisions for History-enabled types. Revisions are
+// appended under the same lock as the write they record.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+)
+
+// GetEntityHistory returns the recorded revisions of the entity, oldest
+// first. Returns entitygraph.ErrEntityNotFound if the entity does not exist
+// and entitygraph.ErrHistoryNotEnabled if its type does not set History.
+func (b *Backend) GetEntityHistory(ctx context.Context, agencyID, entityID string) ([]entitygraph.EntityRevision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("GetEntityHistory %s: %w", entityID, err)
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	history, err := b.historyLocked(agencyID, entityID)
+	if err != nil {
+		return nil, fmt.Errorf("GetEntityHistory %s: %w", entityID, err)
+	}
+	out := make([]entitygraph.EntityRevision, len(history))
+	for i, rev := range history {
+		rev.Entity = copyEntity(rev.Entity)
+		out[i] = rev
+	}
+	return out, nil
+}
+
+// GetEntityAt returns the entity as it was at the instant at. Returns
+// entitygraph.ErrEntityNotFound if it did not exist, or was deleted, at that
+// instant and entitygraph.ErrHistoryNotEnabled if its type does not set
+// History.
+func (b *Backend) GetEntityAt(ctx context.Context, agencyID, entityID string, at time.Time) (entitygraph.Entity, error) {
+	if err := ctx.Err(); err != nil {
+		return entitygraph.Entity{}, fmt.Errorf("GetEntityAt %s: %w", entityID, err)
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	history, err := b.historyLocked(agencyID, entityID)
+	if err != nil {
+		return entitygraph.Entity{}, fmt.Errorf("GetEntityAt %s: %w", entityID, err)
+	}
+	e, ok := entitygraph.EntityAt(history, at)
+	if !ok {
+		return entitygraph.Entity{}, fmt.Errorf("GetEntityAt %s at %s: %w", entityID, at.Format(time.RFC3339Nano), entitygraph.ErrEntityNotFound)
+	}
+	return copyEntity(e), nil
+}
+
+// historyLocked returns the stored history of the entity with entityID owned
+// by agencyID, deleted or not. The caller must hold b.mu.
+func (b *Backend) historyLocked(agencyID, entityID string) ([]entitygraph.EntityRevision, error) {
+	e, ok := b.entityLocked(agencyID, entityID)
+	if !ok {
+		return nil, entitygraph.ErrEntityNotFound
+	}
+	if !b.hasHistory(e.TypeID) {
+		return nil, fmt.Errorf("type %s: %w", e.TypeID, entitygraph.ErrHistoryNotEnabled)
+	}
+	return b.history[entityID], nil
+}
+
+// hasHistory reports whether the TypeDefinition for typeID has History set.
+func (b *Backend) hasHistory(typeID string) bool {
+	td, ok := b.typeDefs[typeID]
+	return ok && td.History
+}
+
+// recordLocked appends the state e was just written in to its history when
+// its type has History set. The caller must hold b.mu for writing.
+func (b *Backend) recordLocked(ctx context.Context, e entitygraph.Entity, change entitygraph.ChangeKind) {
+	if !b.hasHistory(e.TypeID) {
+		return
+	}
+	b.history[e.ID] = append(b.history[e.ID], entitygraph.EntityRevision{
+		Entity:     copyEntity(e),
+		Change:     change,
+		Actor:      entitygraph.ActorFromContext(ctx),
+		RecordedAt: e.UpdatedAt,
+	})
+}
@@ -0,0 +1,446 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+	"github.com/aosanya/CodeValdSharedLib/entitygraph/memory"
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+// testSchema builds a small Agency/Goal/Snapshot schema used across tests.
+func testSchema() types.Schema {
+	return types.Schema{
+		ID:       "memory-test",
+		AgencyID: "agency-1",
+		Types: []types.TypeDefinition{
+			{
+				Name: "Agency",
+				Properties: []types.PropertyDefinition{
+					{Name: "name", Type: types.PropertyTypeString},
+				},
+				Relationships: []types.RelationshipDefinition{
+					{Name: "has_goal", ToType: "Goal", ToMany: true, Inverse: "belongs_to_agency"},
+				},
+			},
+			{
+				Name: "Goal",
+				Properties: []types.PropertyDefinition{
+					{Name: "code", Type: types.PropertyTypeString},
+					{Name: "title", Type: types.PropertyTypeString},
+				},
+				Relationships: []types.RelationshipDefinition{
+					{Name: "belongs_to_agency", ToType: "Agency"},
+					{Name: "snapshot_of", ToType: "Snapshot"},
+				},
+				UniqueKey: []string{"code"},
+			},
+			{Name: "Snapshot", Immutable: true},
+		},
+	}
+}
+
+func newBackend(t *testing.T) *memory.Backend {
+	t.Helper()
+	return memory.NewBackend(memory.Config{Schema: testSchema()})
+}
+
+func mustCreate(t *testing.T, b *memory.Backend, agencyID, typeID string, props map[string]any) entitygraph.Entity {
+	t.Helper()
+	e, err := b.CreateEntity(context.Background(), entitygraph.CreateEntityRequest{
+		AgencyID: agencyID, TypeID: typeID, Properties: props,
+	})
+	if err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+	return e
+}
+
+func mustRelate(t *testing.T, b *memory.Backend, agencyID, name, from, to string) entitygraph.Relationship {
+	t.Helper()
+	r, err := b.CreateRelationship(context.Background(), entitygraph.CreateRelationshipRequest{
+		AgencyID: agencyID, Name: name, FromID: from, ToID: to,
+	})
+	if err != nil {
+		t.Fatalf("CreateRelationship: %v", err)
+	}
+	return r
+}
+
+// ── entities ─────────────────────────────────────────────────────────────────
+
+func TestNew_ReturnsSameBackendForBothInterfaces(t *testing.T) {
+	dm, sm := memory.New(memory.Config{Schema: testSchema()})
+	if dm == nil || sm == nil {
+		t.Fatal("New returned nil manager")
+	}
+	if dm.(*memory.Backend) != sm.(*memory.Backend) {
+		t.Error("DataManager and SchemaManager should be the same Backend")
+	}
+}
+
+func TestCreateEntity_PropertiesAreIsolatedFromCaller(t *testing.T) {
+	b := newBackend(t)
+	props := map[string]any{"name": "Acme"}
+	e := mustCreate(t, b, "agency-1", "Agency", props)
+	props["name"] = "mutated"
+
+	got, err := b.GetEntity(context.Background(), "agency-1", e.ID)
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	if got.Properties["name"] != "Acme" {
+		t.Errorf("name = %v, want %q", got.Properties["name"], "Acme")
+	}
+}
+
+func TestCreateEntity_NumbersRoundTripAsFloat64(t *testing.T) {
+	b := newBackend(t)
+	e := mustCreate(t, b, "agency-1", "Agency", map[string]any{"count": 3})
+	if _, ok := e.Properties["count"].(float64); !ok {
+		t.Errorf("count has type %T, want float64", e.Properties["count"])
+	}
+}
+
+func TestGetEntity_OtherAgency_NotFound(t *testing.T) {
+	b := newBackend(t)
+	e := mustCreate(t, b, "agency-1", "Agency", nil)
+	_, err := b.GetEntity(context.Background(), "agency-2", e.ID)
+	if !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("got %v, want ErrEntityNotFound", err)
+	}
+}
+
+func TestDeleteEntity_SoftDeleteHidesEntity(t *testing.T) {
+	ctx := context.Background()
+	b := newBackend(t)
+	e := mustCreate(t, b, "agency-1", "Agency", nil)
+	if err := b.DeleteEntity(ctx, "agency-1", e.ID); err != nil {
+		t.Fatalf("DeleteEntity: %v", err)
+	}
+	if _, err := b.GetEntity(ctx, "agency-1", e.ID); !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("GetEntity after delete: got %v, want ErrEntityNotFound", err)
+	}
+	if err := b.DeleteEntity(ctx, "agency-1", e.ID); !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("second DeleteEntity: got %v, want ErrEntityNotFound", err)
+	}
+	list, _ := b.ListEntities(ctx, entitygraph.EntityFilter{AgencyID: "agency-1"})
+	if len(list) != 0 {
+		t.Errorf("ListEntities after delete returned %d entities, want 0", len(list))
+	}
+}
+
+func TestUpdateEntity_MergesProperties(t *testing.T) {
+	b := newBackend(t)
+	e := mustCreate(t, b, "agency-1", "Goal", map[string]any{"code": "G1", "title": "old"})
+	got, err := b.UpdateEntity(context.Background(), "agency-1", e.ID, entitygraph.UpdateEntityRequest{
+		Properties: map[string]any{"title": "new"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateEntity: %v", err)
+	}
+	if got.Properties["code"] != "G1" || got.Properties["title"] != "new" {
+		t.Errorf("properties = %v, want code=G1 title=new", got.Properties)
+	}
+	if got.UpdatedAt.Before(e.UpdatedAt) {
+		t.Error("UpdatedAt should not move backwards")
+	}
+}
+
+func TestUpdateEntity_ImmutableType_ReturnsErrImmutableType(t *testing.T) {
+	b := newBackend(t)
+	e := mustCreate(t, b, "agency-1", "Snapshot", nil)
+	_, err := b.UpdateEntity(context.Background(), "agency-1", e.ID, entitygraph.UpdateEntityRequest{})
+	if !errors.Is(err, entitygraph.ErrImmutableType) {
+		t.Errorf("got %v, want ErrImmutableType", err)
+	}
+}
+
+func TestListEntities_FiltersByTypeAndProperties(t *testing.T) {
+	b := newBackend(t)
+	mustCreate(t, b, "agency-1", "Goal", map[string]any{"code": "G1", "rank": 1})
+	mustCreate(t, b, "agency-1", "Goal", map[string]any{"code": "G2", "rank": 2})
+	mustCreate(t, b, "agency-1", "Agency", map[string]any{"rank": 1})
+	mustCreate(t, b, "agency-2", "Goal", map[string]any{"code": "G1", "rank": 1})
+
+	got, err := b.ListEntities(context.Background(), entitygraph.EntityFilter{
+		AgencyID:   "agency-1",
+		TypeID:     "Goal",
+		Properties: map[string]any{"rank": 1},
+	})
+	if err != nil {
+		t.Fatalf("ListEntities: %v", err)
+	}
+	if len(got) != 1 || got[0].Properties["code"] != "G1" {
+		t.Errorf("got %v, want the single agency-1 Goal G1", got)
+	}
+}
+
+func TestUpsertEntity_MatchingKey_MergesInsteadOfInserting(t *testing.T) {
+	ctx := context.Background()
+	b := newBackend(t)
+	first, err := b.UpsertEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: "agency-1", TypeID: "Goal", Properties: map[string]any{"code": "G1", "title": "a"},
+	})
+	if err != nil {
+		t.Fatalf("UpsertEntity: %v", err)
+	}
+	second, err := b.UpsertEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: "agency-1", TypeID: "Goal", Properties: map[string]any{"code": "G1", "title": "b"},
+	})
+	if err != nil {
+		t.Fatalf("UpsertEntity: %v", err)
+	}
+	if first.ID != second.ID {
+		t.Errorf("upsert inserted a duplicate: %s vs %s", first.ID, second.ID)
+	}
+	if second.Properties["title"] != "b" {
+		t.Errorf("title = %v, want %q", second.Properties["title"], "b")
+	}
+}
+
+func TestUpsertEntity_ConcurrentSameKey_SingleEntity(t *testing.T) {
+	ctx := context.Background()
+	b := newBackend(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = b.UpsertEntity(ctx, entitygraph.CreateEntityRequest{
+				AgencyID: "agency-1", TypeID: "Goal", Properties: map[string]any{"code": "G1"},
+			})
+		}()
+	}
+	wg.Wait()
+	got, _ := b.ListEntities(ctx, entitygraph.EntityFilter{AgencyID: "agency-1", TypeID: "Goal"})
+	if len(got) != 1 {
+		t.Errorf("got %d Goals, want 1", len(got))
+	}
+}
+
+func TestUpsertEntity_NoUniqueKey_ReturnsErrUniqueKeyNotDefined(t *testing.T) {
+	b := newBackend(t)
+	_, err := b.UpsertEntity(context.Background(), entitygraph.CreateEntityRequest{
+		AgencyID: "agency-1", TypeID: "Agency",
+	})
+	if !errors.Is(err, entitygraph.ErrUniqueKeyNotDefined) {
+		t.Errorf("got %v, want ErrUniqueKeyNotDefined", err)
+	}
+}
+
+// ── relationships ────────────────────────────────────────────────────────────
+
+func TestCreateRelationship_MissingEndpoint_ReturnsErrEntityNotFound(t *testing.T) {
+	b := newBackend(t)
+	a := mustCreate(t, b, "agency-1", "Agency", nil)
+	_, err := b.CreateRelationship(context.Background(), entitygraph.CreateRelationshipRequest{
+		AgencyID: "agency-1", Name: "has_goal", FromID: a.ID, ToID: "missing",
+	})
+	if !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("got %v, want ErrEntityNotFound", err)
+	}
+}
+
+func TestListRelationships_FiltersAndDelete(t *testing.T) {
+	ctx := context.Background()
+	b := newBackend(t)
+	a := mustCreate(t, b, "agency-1", "Agency", nil)
+	g1 := mustCreate(t, b, "agency-1", "Goal", map[string]any{"code": "G1"})
+	g2 := mustCreate(t, b, "agency-1", "Goal", map[string]any{"code": "G2"})
+	r1 := mustRelate(t, b, "agency-1", "has_goal", a.ID, g1.ID)
+	mustRelate(t, b, "agency-1", "has_goal", a.ID, g2.ID)
+
+	got, _ := b.ListRelationships(ctx, entitygraph.RelationshipFilter{AgencyID: "agency-1", ToID: g1.ID})
+	if len(got) != 1 || got[0].ID != r1.ID {
+		t.Fatalf("ListRelationships(ToID) = %v, want [%s]", got, r1.ID)
+	}
+	if err := b.DeleteRelationship(ctx, "agency-1", r1.ID); err != nil {
+		t.Fatalf("DeleteRelationship: %v", err)
+	}
+	if _, err := b.GetRelationship(ctx, "agency-1", r1.ID); !errors.Is(err, entitygraph.ErrRelationshipNotFound) {
+		t.Errorf("GetRelationship after delete: got %v, want ErrRelationshipNotFound", err)
+	}
+	got, _ = b.ListRelationships(ctx, entitygraph.RelationshipFilter{FromID: a.ID})
+	if len(got) != 1 {
+		t.Errorf("got %d relationships after delete, want 1", len(got))
+	}
+}
+
+func TestTraverseGraph_DirectionDepthAndNames(t *testing.T) {
+	ctx := context.Background()
+	b := newBackend(t)
+	a := mustCreate(t, b, "agency-1", "Agency", nil)
+	g := mustCreate(t, b, "agency-1", "Goal", map[string]any{"code": "G1"})
+	s := mustCreate(t, b, "agency-1", "Snapshot", nil)
+	mustRelate(t, b, "agency-1", "has_goal", a.ID, g.ID)
+	mustRelate(t, b, "agency-1", "snapshot_of", g.ID, s.ID)
+
+	// has_goal declares Inverse "belongs_to_agency", so a→g also stores the
+	// derived edge g→a, which outbound and any-direction walks follow.
+	cases := []struct {
+		name      string
+		req       entitygraph.TraverseGraphRequest
+		wantVerts int
+		wantEdges int
+	}{
+		{"outbound depth 1", entitygraph.TraverseGraphRequest{StartID: a.ID}, 1, 1},
+		{"outbound depth 2", entitygraph.TraverseGraphRequest{StartID: a.ID, Depth: 2}, 3, 3},
+		{"names filter", entitygraph.TraverseGraphRequest{StartID: a.ID, Depth: 2, Names: []string{"has_goal"}}, 1, 1},
+		{"inbound from snapshot", entitygraph.TraverseGraphRequest{StartID: s.ID, Direction: "INBOUND", Depth: 2}, 2, 2},
+		{"any from goal", entitygraph.TraverseGraphRequest{StartID: g.ID, Direction: "any"}, 2, 3},
+		{"outbound from leaf", entitygraph.TraverseGraphRequest{StartID: s.ID}, 0, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.req.AgencyID = "agency-1"
+			res, err := b.TraverseGraph(ctx, c.req)
+			if err != nil {
+				t.Fatalf("TraverseGraph: %v", err)
+			}
+			if len(res.Vertices) != c.wantVerts {
+				t.Errorf("got %d vertices, want %d", len(res.Vertices), c.wantVerts)
+			}
+			if len(res.Edges) != c.wantEdges {
+				t.Errorf("got %d edges, want %d", len(res.Edges), c.wantEdges)
+			}
+		})
+	}
+}
+
+func TestTraverseGraph_ExcludesDeletedVerticesAndOtherAgencies(t *testing.T) {
+	ctx := context.Background()
+	b := newBackend(t)
+	a := mustCreate(t, b, "agency-1", "Agency", nil)
+	g1 := mustCreate(t, b, "agency-1", "Goal", map[string]any{"code": "G1"})
+	g2 := mustCreate(t, b, "agency-1", "Goal", map[string]any{"code": "G2"})
+	mustRelate(t, b, "agency-1", "has_goal", a.ID, g1.ID)
+	mustRelate(t, b, "agency-1", "has_goal", a.ID, g2.ID)
+	if err := b.DeleteEntity(ctx, "agency-1", g2.ID); err != nil {
+		t.Fatalf("DeleteEntity: %v", err)
+	}
+
+	res, err := b.TraverseGraph(ctx, entitygraph.TraverseGraphRequest{AgencyID: "agency-1", StartID: a.ID})
+	if err != nil {
+		t.Fatalf("TraverseGraph: %v", err)
+	}
+	if len(res.Vertices) != 1 || res.Vertices[0].ID != g1.ID {
+		t.Errorf("vertices = %v, want only %s", res.Vertices, g1.ID)
+	}
+	if _, err := b.TraverseGraph(ctx, entitygraph.TraverseGraphRequest{AgencyID: "agency-2", StartID: a.ID}); !errors.Is(err, entitygraph.ErrEntityNotFound) {
+		t.Errorf("cross-agency start: got %v, want ErrEntityNotFound", err)
+	}
+}
+
+func TestTraverseGraph_InvalidDirection_ReturnsError(t *testing.T) {
+	b := newBackend(t)
+	a := mustCreate(t, b, "agency-1", "Agency", nil)
+	_, err := b.TraverseGraph(context.Background(), entitygraph.TraverseGraphRequest{
+		AgencyID: "agency-1", StartID: a.ID, Direction: "sideways",
+	})
+	if err == nil {
+		t.Fatal("expected error for invalid direction, got nil")
+	}
+}
+
+// ── schema lifecycle ─────────────────────────────────────────────────────────
+
+func TestSchemaLifecycle_PublishActivate(t *testing.T) {
+	ctx := context.Background()
+	b := newBackend(t)
+
+	if _, err := b.GetSchema(ctx, "agency-1"); !errors.Is(err, entitygraph.ErrSchemaNotFound) {
+		t.Fatalf("GetSchema before SetSchema: got %v, want ErrSchemaNotFound", err)
+	}
+	if err := b.SetSchema(ctx, testSchema()); err != nil {
+		t.Fatalf("SetSchema: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := b.Publish(ctx, "agency-1"); err != nil {
+			t.Fatalf("Publish #%d: %v", i+1, err)
+		}
+	}
+	if _, err := b.GetActive(ctx, "agency-1"); !errors.Is(err, entitygraph.ErrSchemaNotFound) {
+		t.Fatalf("GetActive before Activate: got %v, want ErrSchemaNotFound", err)
+	}
+	if err := b.Activate(ctx, "agency-1", 1); err != nil {
+		t.Fatalf("Activate(1): %v", err)
+	}
+	if err := b.Activate(ctx, "agency-1", 2); err != nil {
+		t.Fatalf("Activate(2): %v", err)
+	}
+	active, err := b.GetActive(ctx, "agency-1")
+	if err != nil {
+		t.Fatalf("GetActive: %v", err)
+	}
+	if active.Version != 2 {
+		t.Errorf("active version = %d, want 2", active.Version)
+	}
+	versions, _ := b.ListVersions(ctx, "agency-1")
+	if len(versions) != 2 || versions[0].Version != 1 || versions[0].Active {
+		t.Errorf("ListVersions = %+v, want v1 inactive then v2 active", versions)
+	}
+	if err := b.Activate(ctx, "agency-1", 9); !errors.Is(err, entitygraph.ErrSchemaNotFound) {
+		t.Errorf("Activate(9): got %v, want ErrSchemaNotFound", err)
+	}
+}
+
+func TestActivate_ServesActivatedTypes(t *testing.T) {
+	ctx := context.Background()
+	b := newBackend(t)
+	s := testSchema()
+	s.Types = append(s.Types, types.TypeDefinition{
+		Name:       "Task",
+		UniqueKey:  []string{"code"},
+		Properties: []types.PropertyDefinition{{Name: "code", Type: types.PropertyTypeString}},
+	})
+	if err := b.SetSchema(ctx, s); err != nil {
+		t.Fatalf("SetSchema: %v", err)
+	}
+	if err := b.Publish(ctx, "agency-1"); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if err := b.Activate(ctx, "agency-1", 1); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	mustCreate(t, b, "agency-1", "Task", map[string]any{"code": "T1"})
+	_, err := b.CreateEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: "agency-1", TypeID: "Task", Properties: map[string]any{"code": "T1"},
+	})
+	if !errors.Is(err, entitygraph.ErrEntityAlreadyExists) {
+		t.Errorf("CreateEntity(duplicate code): got %v, want ErrEntityAlreadyExists", err)
+	}
+}
+
+func TestPublish_InvalidDraft_CreatesNoVersion(t *testing.T) {
+	ctx := context.Background()
+	b := newBackend(t)
+	bad := types.Schema{AgencyID: "agency-1", Types: []types.TypeDefinition{{Name: "A"}, {Name: "A"}}}
+	if err := b.SetSchema(ctx, bad); err != nil {
+		t.Fatalf("SetSchema: %v", err)
+	}
+	if err := b.Publish(ctx, "agency-1"); err == nil {
+		t.Fatal("expected Publish to fail validation")
+	}
+	versions, _ := b.ListVersions(ctx, "agency-1")
+	if len(versions) != 0 {
+		t.Errorf("got %d versions after failed publish, want 0", len(versions))
+	}
+}
+
+func TestSeedSchema_WithMemoryBackend_IsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	b := newBackend(t)
+	for i := 0; i < 2; i++ {
+		if err := entitygraph.SeedSchema(ctx, b, "agency-1", testSchema()); err != nil {
+			t.Fatalf("SeedSchema #%d: %v", i+1, err)
+		}
+	}
+	versions, _ := b.ListVersions(ctx, "agency-1")
+	if len(versions) != 1 {
+		t.Errorf("got %d versions, want 1", len(versions))
+	}
+}
@@ -0,0 +1,510 @@
+// relationships.go contains CreateRelationship, GetRelationship,
+// DeleteRelationship, ListRelationships, ListRelationshipsPage, and
+// TraverseGraph for the Backend.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+// CreateRelationship stores a directed edge between two entities.
+//
+// The edge is validated against the source entity's TypeDefinition via
+// [entitygraph.ValidateCreateRelationship]. For ToMany=true relationships a
+// new edge is always inserted. For ToMany=false relationships an existing
+// edge with the same FromID and Name is re-pointed at the new target and its
+// properties replaced, keeping its ID — matching the ArangoDB backend's UPSERT.
+// When the definition declares an Inverse, the derived inverse edge is written
+// under the same lock (see writeLinkedEdgeLocked).
+//
+// Returns entitygraph.ErrEntityNotFound if the FromID or ToID entity does not
+// exist for the agency. Returns entitygraph.ErrInvalidRelationship if the edge
+// is not declared by the schema.
+func (b *Backend) CreateRelationship(
+	ctx context.Context,
+	req entitygraph.CreateRelationshipRequest,
+) (entitygraph.Relationship, error) {
+	if err := ctx.Err(); err != nil {
+		return entitygraph.Relationship{}, fmt.Errorf("CreateRelationship: %w", err)
+	}
+	props, err := cloneProps(req.Properties)
+	if err != nil {
+		return entitygraph.Relationship{}, fmt.Errorf("CreateRelationship: %w", err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	from, ok := b.liveEntityLocked(req.AgencyID, req.FromID)
+	if !ok {
+		return entitygraph.Relationship{}, fmt.Errorf("CreateRelationship from: entityHandle %s: %w", req.FromID, entitygraph.ErrEntityNotFound)
+	}
+	to, ok := b.liveEntityLocked(req.AgencyID, req.ToID)
+	if !ok {
+		return entitygraph.Relationship{}, fmt.Errorf("CreateRelationship to: entityHandle %s: %w", req.ToID, entitygraph.ErrEntityNotFound)
+	}
+	rd, err := b.relationshipDef(from.TypeID, req.Name, to.TypeID)
+	if err != nil {
+		return entitygraph.Relationship{}, fmt.Errorf("CreateRelationship: %w", err)
+	}
+	r := entitygraph.Relationship{
+		ID:         uuid.NewString(),
+		AgencyID:   req.AgencyID,
+		Name:       req.Name,
+		FromID:     req.FromID,
+		ToID:       req.ToID,
+		Properties: props,
+		CreatedAt:  time.Now().UTC(),
+	}
+	return copyRelationship(b.writeLinkedEdgeLocked(r, rd, false)), nil
+}
+
+// writeLinkedEdgeLocked stores the primary edge r of relationship rd via
+// writeEdgeLocked and keeps its inverse pair consistent:
+//
+//   - Re-asserting an edge that already exists with the same target leaves
+//     its pairing untouched and copies the new properties onto its pair.
+//   - Re-pointing an edge (ToMany=false replacement) removes its stale pair.
+//   - When rd declares an Inverse, a Derived edge ToID → Inverse → FromID is
+//     written with the inverse definition's cardinality. If that write itself
+//     re-points a functional edge on the target, the edge it was paired with
+//     is removed too. If the reciprocal edge already exists, r stays unpaired.
+//
+// The caller must hold b.mu for writing.
+func (b *Backend) writeLinkedEdgeLocked(r entitygraph.Relationship, rd types.RelationshipDefinition, idempotent bool) entitygraph.Relationship {
+	stored, old := b.writeEdgeLocked(r, rd.ToMany, idempotent)
+	if old != nil && old.ToID == stored.ToID {
+		if pair, ok := b.relationships[stored.PairID]; ok {
+			pair.Properties = copyRelationship(stored).Properties
+			b.relationships[pair.ID] = pair
+			return stored
+		}
+	} else if old != nil {
+		b.removeRelationshipLocked(old.PairID)
+	}
+	if rd.Inverse == "" {
+		return stored
+	}
+	inverse := entitygraph.Relationship{
+		ID:         uuid.NewString(),
+		AgencyID:   stored.AgencyID,
+		Name:       rd.Inverse,
+		FromID:     stored.ToID,
+		ToID:       stored.FromID,
+		Properties: copyRelationship(stored).Properties,
+		Derived:    true,
+		PairID:     stored.ID,
+		CreatedAt:  time.Now().UTC(),
+	}
+	pair, pairOld := b.writeEdgeLocked(inverse, b.inverseToMany(rd), false)
+	if pairOld != nil {
+		if pairOld.ToID == pair.ToID {
+			return stored
+		}
+		if pairOld.PairID != stored.ID {
+			b.removeRelationshipLocked(pairOld.PairID)
+		}
+	}
+	stored.PairID = pair.ID
+	b.relationships[stored.ID] = stored
+	return stored
+}
+
+// inverseToMany reports the cardinality of rd's inverse definition on
+// rd.ToType. An inverse missing from the schema is treated as ToMany so that
+// it never replaces an unrelated edge.
+func (b *Backend) inverseToMany(rd types.RelationshipDefinition) bool {
+	inv, err := entitygraph.FindRelationshipDef(b.typeDefs[rd.ToType], rd.Inverse)
+	return err != nil || inv.ToMany
+}
+
+// writeEdgeLocked stores r according to the relationship's cardinality and
+// returns the stored edge. ToMany edges are inserted; a ToMany=false edge
+// replaces the existing edge with the same FromID and Name (keeping its ID).
+// When idempotent is set, a ToMany edge that already exists with the same
+// FromID, Name, and ToID is updated in place instead of duplicated.
+//
+// When an existing edge is updated, its state before the write is returned as
+// well. A re-pointed edge takes r's Derived and PairID; an edge whose target
+// is unchanged keeps its own. The caller must hold b.mu for writing.
+func (b *Backend) writeEdgeLocked(r entitygraph.Relationship, toMany, idempotent bool) (entitygraph.Relationship, *entitygraph.Relationship) {
+	if !toMany || idempotent {
+		for _, id := range b.relOrder {
+			existing := b.relationships[id]
+			if existing.AgencyID != r.AgencyID || existing.FromID != r.FromID || existing.Name != r.Name {
+				continue
+			}
+			if toMany && existing.ToID != r.ToID {
+				continue
+			}
+			old := existing
+			if existing.ToID != r.ToID {
+				existing.ToID = r.ToID
+				existing.Derived = r.Derived
+				existing.PairID = r.PairID
+			}
+			existing.Properties = r.Properties
+			b.relationships[existing.ID] = existing
+			return existing, &old
+		}
+	}
+	b.relationships[r.ID] = r
+	b.relOrder = append(b.relOrder, r.ID)
+	return r, nil
+}
+
+// inlineEdge is a validated entry of CreateEntityRequest.Relationships ready
+// to be written once the source entity's ID is known.
+type inlineEdge struct {
+	r  entitygraph.Relationship // FromID is filled in by the caller
+	rd types.RelationshipDefinition
+}
+
+// inlineEdgesLocked resolves every target in req.Relationships and validates
+// the set with [entitygraph.ValidateEntityRelationships]. It performs no
+// writes. The caller must hold b.mu.
+func (b *Backend) inlineEdgesLocked(req entitygraph.CreateEntityRequest) ([]inlineEdge, error) {
+	if len(req.Relationships) == 0 {
+		return nil, nil
+	}
+	td, ok := b.typeDefs[req.TypeID]
+	if !ok {
+		return nil, fmt.Errorf("type %q not in schema: %w", req.TypeID, entitygraph.ErrInvalidRelationship)
+	}
+	toTypeIDs := make([]string, len(req.Relationships))
+	for i, rel := range req.Relationships {
+		to, ok := b.liveEntityLocked(req.AgencyID, rel.ToID)
+		if !ok {
+			return nil, fmt.Errorf("relationship %q: entityHandle %s: %w", rel.Name, rel.ToID, entitygraph.ErrEntityNotFound)
+		}
+		toTypeIDs[i] = to.TypeID
+	}
+	if err := entitygraph.ValidateEntityRelationships(td, req.Relationships, toTypeIDs); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	edges := make([]inlineEdge, len(req.Relationships))
+	for i, rel := range req.Relationships {
+		rd, _ := entitygraph.FindRelationshipDef(td, rel.Name)
+		edges[i] = inlineEdge{
+			r: entitygraph.Relationship{
+				ID:         uuid.NewString(),
+				AgencyID:   req.AgencyID,
+				Name:       rel.Name,
+				ToID:       rel.ToID,
+				Properties: make(map[string]any),
+				CreatedAt:  now,
+			},
+			rd: rd,
+		}
+	}
+	return edges, nil
+}
+
+// writeInlineEdgesLocked writes edges from fromID. The caller must hold b.mu
+// for writing.
+func (b *Backend) writeInlineEdgesLocked(fromID string, edges []inlineEdge, idempotent bool) {
+	for _, e := range edges {
+		e.r.FromID = fromID
+		b.writeLinkedEdgeLocked(e.r, e.rd, idempotent)
+	}
+}
+
+// GetRelationship returns the relationship identified by agencyID and
+// relationshipID. Returns entitygraph.ErrRelationshipNotFound if absent or
+// owned by a different agency.
+func (b *Backend) GetRelationship(
+	ctx context.Context,
+	agencyID, relationshipID string,
+) (entitygraph.Relationship, error) {
+	if err := ctx.Err(); err != nil {
+		return entitygraph.Relationship{}, fmt.Errorf("GetRelationship %s: %w", relationshipID, err)
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	r, ok := b.relationships[relationshipID]
+	if !ok || r.AgencyID != agencyID {
+		return entitygraph.Relationship{}, fmt.Errorf("GetRelationship %s: %w", relationshipID, entitygraph.ErrRelationshipNotFound)
+	}
+	return copyRelationship(r), nil
+}
+
+// DeleteRelationship removes an edge permanently, together with its paired
+// edge when it has one.
+// Returns entitygraph.ErrRelationshipNotFound if the relationship does not exist.
+func (b *Backend) DeleteRelationship(
+	ctx context.Context,
+	agencyID, relationshipID string,
+) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("DeleteRelationship %s: %w", relationshipID, err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	r, ok := b.relationships[relationshipID]
+	if !ok || r.AgencyID != agencyID {
+		return fmt.Errorf("DeleteRelationship %s: %w", relationshipID, entitygraph.ErrRelationshipNotFound)
+	}
+	b.removeRelationshipLocked(relationshipID)
+	b.removeRelationshipLocked(r.PairID)
+	return nil
+}
+
+// ListRelationships returns all edges matching the filter in insertion order,
+// or sorted and paged when filter.OrderBy, Limit, or PageToken is set.
+// Zero-value filter fields are treated as "no restriction".
+func (b *Backend) ListRelationships(
+	ctx context.Context,
+	filter entitygraph.RelationshipFilter,
+) ([]entitygraph.Relationship, error) {
+	page, err := b.listRelationships(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("ListRelationships: %w", err)
+	}
+	return page.Relationships, nil
+}
+
+// ListRelationshipsPage returns one page of the edges matching the filter
+// together with the next page token and, when requested, the total count.
+func (b *Backend) ListRelationshipsPage(
+	ctx context.Context,
+	filter entitygraph.RelationshipFilter,
+) (entitygraph.RelationshipPage, error) {
+	page, err := b.listRelationships(ctx, filter)
+	if err != nil {
+		return entitygraph.RelationshipPage{}, fmt.Errorf("ListRelationshipsPage: %w", err)
+	}
+	return page, nil
+}
+
+// listRelationships implements ListRelationships and ListRelationshipsPage.
+func (b *Backend) listRelationships(ctx context.Context, filter entitygraph.RelationshipFilter) (entitygraph.RelationshipPage, error) {
+	if err := ctx.Err(); err != nil {
+		return entitygraph.RelationshipPage{}, err
+	}
+	if err := entitygraph.ValidatePageRequest(filter.Limit, filter.OrderBy, nil, true); err != nil {
+		return entitygraph.RelationshipPage{}, err
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	var matched []entitygraph.Relationship
+	for _, id := range b.relOrder {
+		r := b.relationships[id]
+		if filter.AgencyID != "" && r.AgencyID != filter.AgencyID {
+			continue
+		}
+		if filter.Name != "" && r.Name != filter.Name {
+			continue
+		}
+		if filter.FromID != "" && r.FromID != filter.FromID {
+			continue
+		}
+		if filter.ToID != "" && r.ToID != filter.ToID {
+			continue
+		}
+		matched = append(matched, r)
+	}
+
+	var page entitygraph.RelationshipPage
+	if filter.IncludeTotal {
+		page.Total = len(matched)
+	}
+	if entitygraph.Paginated(filter.OrderBy, filter.Limit, filter.PageToken) {
+		keys := func(r entitygraph.Relationship) ([]any, string) {
+			return entitygraph.RelationshipSortKeys(r, filter.OrderBy), r.ID
+		}
+		var err error
+		matched, page.NextPageToken, err = pageOf(matched, keys, filter.OrderBy, filter.Limit, filter.PageToken)
+		if err != nil {
+			return entitygraph.RelationshipPage{}, err
+		}
+	}
+	for _, r := range matched {
+		page.Relationships = append(page.Relationships, copyRelationship(r))
+	}
+	return page, nil
+}
+
+// TraverseGraph walks the stored edges from the start entity up to the
+// requested depth and returns both the traversed edges and the reachable
+// non-deleted vertices.
+//
+// The walk is depth-first and, like an ArangoDB graph traversal, never reuses
+// an edge within a single path. Edges from other agencies, edges whose Name is
+// not in req.Names (when non-empty), and soft-deleted vertices are not
+// followed. Direction accepts "outbound" / "inbound" / "any"
+// (case-insensitive); empty defaults to outbound. Vertices are deduplicated by
+// entity ID; edges are reported once per path on which they are traversed.
+func (b *Backend) TraverseGraph(
+	ctx context.Context,
+	req entitygraph.TraverseGraphRequest,
+) (entitygraph.TraverseGraphResult, error) {
+	if err := ctx.Err(); err != nil {
+		return entitygraph.TraverseGraphResult{}, fmt.Errorf("TraverseGraph: %w", err)
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if !b.entityExistsLocked(req.AgencyID, req.StartID) {
+		return entitygraph.TraverseGraphResult{}, fmt.Errorf("TraverseGraph start: entityHandle %s: %w", req.StartID, entitygraph.ErrEntityNotFound)
+	}
+	direction, err := normalizeDirection(req.Direction)
+	if err != nil {
+		return entitygraph.TraverseGraphResult{}, err
+	}
+	depth := req.Depth
+	if depth <= 0 {
+		depth = 1
+	}
+	t := traversal{
+		b:         b,
+		agencyID:  req.AgencyID,
+		direction: direction,
+		maxDepth:  depth,
+		names:     make(map[string]struct{}, len(req.Names)),
+		onPath:    make(map[string]struct{}),
+		seenVtx:   make(map[string]struct{}),
+	}
+	for _, n := range req.Names {
+		t.names[n] = struct{}{}
+	}
+	t.walk(req.StartID, 1)
+	return entitygraph.TraverseGraphResult{Vertices: t.vertices, Edges: t.edges}, nil
+}
+
+// traversal carries the state of a single TraverseGraph walk.
+type traversal struct {
+	b         *Backend
+	agencyID  string
+	direction string
+	maxDepth  int
+	names     map[string]struct{}
+	onPath    map[string]struct{} // edge IDs on the current path
+	seenVtx   map[string]struct{}
+	vertices  []entitygraph.Entity
+	edges     []entitygraph.Relationship
+}
+
+// walk visits every eligible edge incident to vertexID and recurses until
+// maxDepth is reached.
+func (t *traversal) walk(vertexID string, depth int) {
+	if depth > t.maxDepth {
+		return
+	}
+	for _, id := range t.b.relOrder {
+		r := t.b.relationships[id]
+		if _, used := t.onPath[r.ID]; used || r.AgencyID != t.agencyID {
+			continue
+		}
+		if len(t.names) > 0 {
+			if _, ok := t.names[r.Name]; !ok {
+				continue
+			}
+		}
+		next, ok := t.neighbour(r, vertexID)
+		if !ok {
+			continue
+		}
+		v, exists := t.b.entities[next]
+		if !exists || v.Deleted {
+			continue
+		}
+		if _, seen := t.seenVtx[v.ID]; !seen {
+			t.seenVtx[v.ID] = struct{}{}
+			t.vertices = append(t.vertices, copyEntity(v))
+		}
+		t.edges = append(t.edges, copyRelationship(r))
+		t.onPath[r.ID] = struct{}{}
+		t.walk(next, depth+1)
+		delete(t.onPath, r.ID)
+	}
+}
+
+// neighbour returns the vertex reached from vertexID via r in the traversal
+// direction, or false when r cannot be followed from vertexID.
+func (t *traversal) neighbour(r entitygraph.Relationship, vertexID string) (string, bool) {
+	switch t.direction {
+	case "OUTBOUND":
+		return r.ToID, r.FromID == vertexID
+	case "INBOUND":
+		return r.FromID, r.ToID == vertexID
+	default: // ANY
+		if r.FromID == vertexID {
+			return r.ToID, true
+		}
+		if r.ToID == vertexID {
+			return r.FromID, true
+		}
+		return "", false
+	}
+}
+
+// normalizeDirection accepts the entitygraph contract values
+// ("outbound"/"inbound"/"any") case-insensitively and returns the canonical
+// upper-case form. An empty input defaults to OUTBOUND.
+func normalizeDirection(d string) (string, error) {
+	switch strings.ToUpper(d) {
+	case "", "OUTBOUND":
+		return "OUTBOUND", nil
+	case "INBOUND":
+		return "INBOUND", nil
+	case "ANY":
+		return "ANY", nil
+	default:
+		return "", fmt.Errorf("TraverseGraph: invalid direction %q (want outbound|inbound|any)", d)
+	}
+}
+
+// entityExistsLocked reports whether an entity with entityID exists for
+// agencyID, including soft-deleted entities — matching the ArangoDB backend's
+// document-handle resolution. The caller must hold b.mu.
+func (b *Backend) entityExistsLocked(agencyID, entityID string) bool {
+	_, ok := b.entityLocked(agencyID, entityID)
+	return ok
+}
+
+// entityLocked returns the entity with entityID owned by agencyID, including
+// soft-deleted entities. The caller must hold b.mu.
+func (b *Backend) entityLocked(agencyID, entityID string) (entitygraph.Entity, bool) {
+	e, ok := b.entities[entityID]
+	if !ok || e.AgencyID != agencyID {
+		return entitygraph.Entity{}, false
+	}
+	return e, true
+}
+
+// relationshipDef resolves the RelationshipDefinition for an edge labelled
+// name from an entity of fromTypeID to one of toTypeID. Returns
+// [entitygraph.ErrInvalidRelationship] if the source type is not in the
+// schema, does not declare name, or declares it with a different ToType.
+func (b *Backend) relationshipDef(fromTypeID, name, toTypeID string) (types.RelationshipDefinition, error) {
+	td, ok := b.typeDefs[fromTypeID]
+	if !ok {
+		return types.RelationshipDefinition{}, fmt.Errorf("type %q not in schema: %w", fromTypeID, entitygraph.ErrInvalidRelationship)
+	}
+	if err := entitygraph.ValidateCreateRelationship(td, name, toTypeID); err != nil {
+		return types.RelationshipDefinition{}, fmt.Errorf("%q from %q to %q: %w", name, fromTypeID, toTypeID, err)
+	}
+	return entitygraph.FindRelationshipDef(td, name)
+}
+
+// removeRelationshipLocked deletes the edge with id from both the index and
+// the insertion-order slice. An empty or unknown id is a no-op. The caller
+// must hold b.mu for writing.
+func (b *Backend) removeRelationshipLocked(id string) {
+	if _, ok := b.relationships[id]; !ok {
+		return
+	}
+	delete(b.relationships, id)
+	for i, rid := range b.relOrder {
+		if rid == id {
+			b.relOrder = append(b.relOrder[:i], b.relOrder[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// schemaops.go contains the SchemaManager implementation for Backend,
+// providing the same draft/published schema lifecycle as the ArangoDB backend:
+//
+//   - drafts    — one mutable schema per agency, keyed by agencyID.
+//   - published — immutable append-only snapshots per agency; at most one
+//     Active==true version at a time.
+//
+// Workflow: SetSchema (update draft) → Publish (snapshot to published, version N)
+// → Activate (promote version N to active).
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+// SetSchema overwrites the agency's current draft. ValidateSchema is NOT
+// called here; invalid drafts are permitted until Publish.
+func (b *Backend) SetSchema(ctx context.Context, schema types.Schema) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("SetSchema %s: %w", schema.AgencyID, err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.drafts[schema.AgencyID] = types.Schema{
+		ID:       schema.AgencyID,
+		AgencyID: schema.AgencyID,
+		Tag:      schema.Tag,
+		Types:    copyTypes(schema.Types),
+	}
+	return nil
+}
+
+// GetSchema returns the agency's current draft schema.
+// Returns [entitygraph.ErrSchemaNotFound] if no draft has been created yet.
+func (b *Backend) GetSchema(ctx context.Context, agencyID string) (types.Schema, error) {
+	if err := ctx.Err(); err != nil {
+		return types.Schema{}, fmt.Errorf("GetSchema %s: %w", agencyID, err)
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	draft, ok := b.drafts[agencyID]
+	if !ok {
+		return types.Schema{}, fmt.Errorf("GetSchema %s: %w", agencyID, entitygraph.ErrSchemaNotFound)
+	}
+	return copySchema(draft), nil
+}
+
+// Publish validates the current draft and snapshots it as a new published
+// version with Active = false. The version number is max(existing)+1,
+// starting at 1. Returns an error if ValidateSchema fails or no draft exists.
+func (b *Backend) Publish(ctx context.Context, agencyID string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("Publish %s: %w", agencyID, err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	draft, ok := b.drafts[agencyID]
+	if !ok {
+		return fmt.Errorf("Publish %s: get draft: GetSchema %s: %w", agencyID, agencyID, entitygraph.ErrSchemaNotFound)
+	}
+	if err := entitygraph.ValidateSchema(draft); err != nil {
+		return fmt.Errorf("Publish %s: validate: %w", agencyID, err)
+	}
+	versions := b.published[agencyID]
+	next := 1
+	if n := len(versions); n > 0 {
+		next = versions[n-1].Version + 1
+	}
+	b.published[agencyID] = append(versions, types.Schema{
+		ID:        uuid.NewString(),
+		AgencyID:  agencyID,
+		Version:   next,
+		Tag:       draft.Tag,
+		Types:     copyTypes(draft.Types),
+		Active:    false,
+		CreatedAt: time.Now().UTC(),
+	})
+	return nil
+}
+
+// Activate makes the backend serve the TypeDefinitions of the specified
+// published version, then sets Active=true on it and Active=false on all
+// others for the agency. Types the version does not declare keep their
+// current definitions. Returns [entitygraph.ErrSchemaNotFound] if the version
+// does not exist. The memory backend has no collections or indexes to
+// reconcile.
+func (b *Backend) Activate(ctx context.Context, agencyID string, version int) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("Activate %s v%d: %w", agencyID, version, err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	versions := b.published[agencyID]
+	i := slices.IndexFunc(versions, func(s types.Schema) bool { return s.Version == version })
+	if i < 0 {
+		return fmt.Errorf("Activate %s v%d: %w", agencyID, version, entitygraph.ErrSchemaNotFound)
+	}
+	defs := maps.Clone(b.typeDefs)
+	for _, td := range versions[i].Types {
+		defs[td.Name] = td
+	}
+	b.typeDefs = defs
+	for i := range versions {
+		versions[i].Active = versions[i].Version == version
+	}
+	return nil
+}
+
+// GetActive returns the single published version where Active == true.
+// Returns [entitygraph.ErrSchemaNotFound] if no version has been activated yet.
+func (b *Backend) GetActive(ctx context.Context, agencyID string) (types.Schema, error) {
+	if err := ctx.Err(); err != nil {
+		return types.Schema{}, fmt.Errorf("GetActive %s: %w", agencyID, err)
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, s := range b.published[agencyID] {
+		if s.Active {
+			return copySchema(s), nil
+		}
+	}
+	return types.Schema{}, fmt.Errorf("GetActive %s: %w", agencyID, entitygraph.ErrSchemaNotFound)
+}
+
+// GetVersion returns a specific published version.
+// Returns [entitygraph.ErrSchemaNotFound] if the version does not exist.
+func (b *Backend) GetVersion(ctx context.Context, agencyID string, version int) (types.Schema, error) {
+	if err := ctx.Err(); err != nil {
+		return types.Schema{}, fmt.Errorf("GetVersion %s v%d: %w", agencyID, version, err)
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, s := range b.published[agencyID] {
+		if s.Version == version {
+			return copySchema(s), nil
+		}
+	}
+	return types.Schema{}, fmt.Errorf("GetVersion %s v%d: %w", agencyID, version, entitygraph.ErrSchemaNotFound)
+}
+
+// ListVersions returns all published versions for the agency in ascending
+// version order. Returns an empty slice if no versions have been published.
+func (b *Backend) ListVersions(ctx context.Context, agencyID string) ([]types.Schema, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("ListVersions %s: %w", agencyID, err)
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	var schemas []types.Schema
+	for _, s := range b.published[agencyID] {
+		schemas = append(schemas, copySchema(s))
+	}
+	return schemas, nil
+}
+
+// copySchema returns s with its own copy of the Types slice. A nil Types
+// slice is returned as an empty slice, matching the ArangoDB backend.
+func copySchema(s types.Schema) types.Schema {
+	s.Types = copyTypes(s.Types)
+	return s
+}
+
+// copyTypes returns a shallow copy of tds, never nil.
+func copyTypes(tds []types.TypeDefinition) []types.TypeDefinition {
+	out := make([]types.TypeDefinition, len(tds))
+	copy(out, tds)
+	return out
+}
@@ -0,0 +1,95 @@
+// search.go contains SearchEntities for the Backend. Entities are scanned in
+// full and scored with Okapi BM25, the ranking the ArangoDB backend uses.
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+)
+
+// BM25 parameters, matching the ArangoSearch defaults.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// SearchEntities returns the live entities of req.TypeID whose Searchable
+// properties contain any word of req.Query, ranked by BM25 over the searched
+// properties of every live entity of the type in the agency.
+func (b *Backend) SearchEntities(ctx context.Context, req entitygraph.SearchRequest) ([]entitygraph.SearchHit, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("SearchEntities %s: %w", req.TypeID, err)
+	}
+	props, terms, err := entitygraph.ValidateSearchRequest(req, b.typeDefFor(req.TypeID))
+	if err != nil {
+		return nil, fmt.Errorf("SearchEntities %s: %w", req.TypeID, err)
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	type candidate struct {
+		entity entitygraph.Entity
+		freq   map[string]int
+		length int
+	}
+	var corpus []candidate
+	docFreq := make(map[string]int, len(terms))
+	totalLength := 0
+	for _, id := range b.entityOrder {
+		e := b.entities[id]
+		if e.Deleted || e.TypeID != req.TypeID {
+			continue
+		}
+		if req.AgencyID != "" && e.AgencyID != req.AgencyID {
+			continue
+		}
+		c := candidate{
+			entity: e,
+			freq:   entitygraph.TermFrequencies(e, props, terms),
+			length: entitygraph.SearchedWordCount(e, props),
+		}
+		for t := range c.freq {
+			docFreq[t]++
+		}
+		totalLength += c.length
+		corpus = append(corpus, c)
+	}
+	if len(corpus) == 0 {
+		return nil, nil
+	}
+	avgLength := float64(totalLength) / float64(len(corpus))
+
+	var hits []entitygraph.SearchHit
+	for _, c := range corpus {
+		if len(c.freq) == 0 {
+			continue
+		}
+		score := 0.0
+		for t, tf := range c.freq {
+			idf := math.Log(1 + (float64(len(corpus))-float64(docFreq[t])+0.5)/(float64(docFreq[t])+0.5))
+			norm := 1 - bm25B + bm25B*float64(c.length)/math.Max(avgLength, 1)
+			score += idf * float64(tf) * (bm25K1 + 1) / (float64(tf) + bm25K1*norm)
+		}
+		hits = append(hits, entitygraph.SearchHit{
+			Entity:     copyEntity(c.entity),
+			Score:      score,
+			Highlights: entitygraph.HighlightEntity(c.entity, props, terms),
+		})
+	}
+	slices.SortFunc(hits, func(x, y entitygraph.SearchHit) int {
+		if c := cmp.Compare(y.Score, x.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(x.Entity.ID, y.Entity.ID)
+	})
+	if limit := entitygraph.SearchLimit(req); len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
@@ -0,0 +1,199 @@
+// Package memory provides an in-process implementation of
+// [entitygraph.DataManager] and [entitygraph.SchemaManager] for unit tests and
+// local development. It mirrors the observable behaviour of the
+// [github.com/aosanya/CodeValdSharedLib/entitygraph/arangodb] backend —
+// soft delete, agency isolation, UniqueKey upsert, draft/publish/activate
+// schema versioning, and TraverseGraph direction/depth/name filtering — so
+// that code exercised against this package behaves identically in production.
+//
+// Nothing is persisted: all state lives in maps guarded by a single
+// sync.RWMutex and is discarded when the Backend is garbage-collected.
+//
+// Property maps are deep-copied through a JSON round-trip on every write, so
+// values read back have the same shape they would have after an ArangoDB
+// round-trip (numbers decode as float64, structs as map[string]any).
+//
+// File layout:
+//   - storage.go       — Config, Backend struct, constructors, shared helpers
+//   - entities.go      — CreateEntity, GetEntity, UpdateEntity, DeleteEntity, ListEntities,
+//     ListEntitiesPage, UpsertEntity, RestoreEntity, PurgeEntity, PurgeDeletedBefore
+//   - relationships.go — CreateRelationship, GetRelationship, DeleteRelationship,
+//     ListRelationships, ListRelationshipsPage, TraverseGraph
+//   - search.go        — SearchEntities
+//   - batch.go         — Batch
+//   - history.go       — GetEntityHistory, GetEntityAt
+//   - schemaops.go     — SetSchema, GetSchema, Publish, Activate, GetActive,
+//     GetVersion, ListVersions
+//
+// Use [New] to obtain a (DataManager, SchemaManager) pair.
+// Use [NewBackend] when the concrete *Backend is needed.
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+// Config holds the construction parameters for the in-memory backend.
+type Config struct {
+	// Schema drives type-level behaviour such as immutability and UniqueKey
+	// lookups, exactly as arangodb.Config.Schema does for the ArangoDB backend.
+	Schema types.Schema
+}
+
+// Backend is the in-memory implementation of both [entitygraph.DataManager]
+// and [entitygraph.SchemaManager]. It is obtained via [New] or [NewBackend]
+// and is safe for concurrent use.
+//
+// entities and relationships are keyed by ID; entityOrder and relOrder record
+// insertion order so that list results are deterministic. typeDefs maps
+// TypeID → TypeDefinition for O(1) immutability and UniqueKey lookups.
+type Backend struct {
+	mu            sync.RWMutex
+	typeDefs      map[string]types.TypeDefinition // TypeID → TypeDefinition
+	entities      map[string]entitygraph.Entity   // entity ID → entity
+	entityOrder   []string                        // entity IDs in insertion order
+	relationships map[string]entitygraph.Relationship
+	relOrder      []string                                // relationship IDs in insertion order
+	drafts        map[string]types.Schema                 // agencyID → draft schema
+	published     map[string][]types.Schema               // agencyID → published versions, ascending
+	revision      uint64                                  // last Entity.Revision issued
+	history       map[string][]entitygraph.EntityRevision // entity ID → revisions, oldest first
+}
+
+// New constructs a Backend from cfg and returns it as both a DataManager and a
+// SchemaManager.
+func New(cfg Config) (entitygraph.DataManager, entitygraph.SchemaManager) {
+	b := NewBackend(cfg)
+	return b, b
+}
+
+// NewBackend constructs an empty Backend from cfg.
+func NewBackend(cfg Config) *Backend {
+	typeDefs := make(map[string]types.TypeDefinition, len(cfg.Schema.Types))
+	for _, td := range cfg.Schema.Types {
+		typeDefs[td.Name] = td
+	}
+	return &Backend{
+		typeDefs:      typeDefs,
+		entities:      make(map[string]entitygraph.Entity),
+		relationships: make(map[string]entitygraph.Relationship),
+		drafts:        make(map[string]types.Schema),
+		published:     make(map[string][]types.Schema),
+		history:       make(map[string][]entitygraph.EntityRevision),
+	}
+}
+
+// isImmutable returns true when the TypeDefinition for typeID has Immutable set.
+func (b *Backend) isImmutable(typeID string) bool {
+	if td, ok := b.typeDefs[typeID]; ok {
+		return td.Immutable
+	}
+	return false
+}
+
+// validateProperties runs [entitygraph.ValidateProperties] against the
+// TypeDefinition for typeID. Types not declared in the schema are stored
+// without validation.
+func (b *Backend) validateProperties(typeID string, props map[string]any, mode entitygraph.ValidationMode) error {
+	td, ok := b.typeDefs[typeID]
+	if !ok {
+		return nil
+	}
+	return entitygraph.ValidateProperties(td, props, mode)
+}
+
+// typeDefFor returns the TypeDefinition for typeID, or nil when typeID is
+// empty or not declared in the schema.
+func (b *Backend) typeDefFor(typeID string) *types.TypeDefinition {
+	if td, ok := b.typeDefs[typeID]; ok {
+		return &td
+	}
+	return nil
+}
+
+// pageOf sorts items by order (ID as tie-breaker), skips everything up to and
+// including the position recorded in token, and cuts the result to limit
+// items. keys returns an item's sort key values and ID. The returned token
+// resumes after the last item and is empty when no items remain.
+func pageOf[T any](items []T, keys func(T) ([]any, string), order []entitygraph.OrderBy, limit int, token string) ([]T, string, error) {
+	cursor, err := entitygraph.DecodePageToken(token, order)
+	if err != nil {
+		return nil, "", err
+	}
+	type keyed struct {
+		item T
+		keys []any
+		id   string
+	}
+	sorted := make([]keyed, 0, len(items))
+	for _, it := range items {
+		k, id := keys(it)
+		if cursor != nil && entitygraph.CompareSortKeys(order, k, id, cursor.Keys, cursor.ID) <= 0 {
+			continue
+		}
+		sorted = append(sorted, keyed{item: it, keys: k, id: id})
+	}
+	slices.SortFunc(sorted, func(a, b keyed) int {
+		return entitygraph.CompareSortKeys(order, a.keys, a.id, b.keys, b.id)
+	})
+	next := ""
+	if limit > 0 && len(sorted) > limit {
+		sorted = sorted[:limit]
+		last := sorted[limit-1]
+		next = entitygraph.EncodePageToken(order, last.keys, last.id)
+	}
+	out := make([]T, len(sorted))
+	for i, k := range sorted {
+		out[i] = k.item
+	}
+	return out, next, nil
+}
+
+// cloneProps deep-copies props through a JSON round-trip so that stored
+// values are isolated from the caller and decode to the same Go types an
+// ArangoDB read would produce. A nil map is returned as an empty map.
+func cloneProps(props map[string]any) (map[string]any, error) {
+	out := make(map[string]any, len(props))
+	if len(props) == 0 {
+		return out, nil
+	}
+	raw, err := json.Marshal(props)
+	if err != nil {
+		return nil, fmt.Errorf("encode properties: %w", err)
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("decode properties: %w", err)
+	}
+	return out, nil
+}
+
+// copyEntity returns e with a shallow copy of its Properties map so that
+// callers mutating the returned value cannot corrupt stored state.
+func copyEntity(e entitygraph.Entity) entitygraph.Entity {
+	props := make(map[string]any, len(e.Properties))
+	for k, v := range e.Properties {
+		props[k] = v
+	}
+	e.Properties = props
+	if e.DeletedAt != nil {
+		t := *e.DeletedAt
+		e.DeletedAt = &t
+	}
+	return e
+}
+
+// copyRelationship returns r with a shallow copy of its Properties map.
+func copyRelationship(r entitygraph.Relationship) entitygraph.Relationship {
+	props := make(map[string]any, len(r.Properties))
+	for k, v := range r.Properties {
+		props[k] = v
+	}
+	r.Properties = props
+	return r
+}
@@ -0,0 +1,295 @@
+// paging.go — cursor pagination and ordering shared by every DataManager
+// backend.
+//
+// ListEntitiesPage and ListRelationshipsPage use keyset pagination: results
+// are sorted by the requested [OrderBy] keys with ID as the final tie-breaker,
+// and the page token records the sort key values and ID of the last item
+// returned. The next page resumes strictly after that position, so pages stay
+// stable while items are inserted or deleted elsewhere in the result set.
+//
+// Time fields are ordered by their Unix-millisecond value so that every backend
+// orders them identically; values of different JSON types follow the ArangoDB
+// type order (null < bool < number < string < array < object).
+package entitygraph
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+// ErrInvalidPageRequest is returned by ListEntities, ListEntitiesPage,
+// ListRelationships, and ListRelationshipsPage when the filter's Limit,
+// OrderBy, or PageToken is malformed — including a token issued for a
+// different OrderBy.
+var ErrInvalidPageRequest = errors.New("invalid page request")
+
+// Sort fields accepted in [OrderBy.Field] besides "properties.<name>".
+const (
+	// OrderByCreatedAt orders by the creation timestamp.
+	OrderByCreatedAt = "created_at"
+
+	// OrderByUpdatedAt orders by the last-update timestamp. Entities only.
+	OrderByUpdatedAt = "updated_at"
+
+	// OrderByPropertyPrefix prefixes a property name to order by that
+	// property's value, e.g. "properties.title".
+	OrderByPropertyPrefix = "properties."
+)
+
+// OrderBy is one sort key of an [EntityFilter] or [RelationshipFilter].
+type OrderBy struct {
+	// Field is OrderByCreatedAt, OrderByUpdatedAt, or
+	// OrderByPropertyPrefix + a property name.
+	Field string
+
+	// Desc sorts this key in descending order.
+	Desc bool
+}
+
+// EntityPage is one page of ListEntitiesPage results.
+type EntityPage struct {
+	// Entities is the page content, in filter.OrderBy order.
+	Entities []Entity
+
+	// NextPageToken continues the listing; empty on the last page.
+	NextPageToken string
+
+	// Total is the number of entities matching the filter across all pages.
+	// Only set when EntityFilter.IncludeTotal is true.
+	Total int
+}
+
+// RelationshipPage is one page of ListRelationshipsPage results.
+type RelationshipPage struct {
+	// Relationships is the page content, in filter.OrderBy order.
+	Relationships []Relationship
+
+	// NextPageToken continues the listing; empty on the last page.
+	NextPageToken string
+
+	// Total is the number of relationships matching the filter across all
+	// pages. Only set when RelationshipFilter.IncludeTotal is true.
+	Total int
+}
+
+// PageCursor is the decoded form of a page token: the sort key values and ID
+// of the last item of the previous page.
+type PageCursor struct {
+	// Order fingerprints the OrderBy the token was issued for.
+	Order string `json:"o"`
+
+	// Keys holds one value per OrderBy key.
+	Keys []any `json:"k"`
+
+	// ID is the last item's ID, the final tie-breaker.
+	ID string `json:"id"`
+}
+
+// propertyNamePattern restricts orderable property names to identifiers so
+// they can be embedded in AQL attribute accessors.
+var propertyNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Paginated reports whether a listing must be sorted and paged rather than
+// returned in the backend's natural order.
+func Paginated(order []OrderBy, limit int, pageToken string) bool {
+	return len(order) > 0 || limit > 0 || pageToken != ""
+}
+
+// PropertyOrderField returns the property name of a "properties.<name>"
+// OrderBy field.
+func PropertyOrderField(field string) (string, bool) {
+	name, ok := strings.CutPrefix(field, OrderByPropertyPrefix)
+	return name, ok && name != ""
+}
+
+// ValidatePageRequest checks limit and order. When td is non-nil, property
+// sort fields must be declared in it. forRelationships rejects
+// OrderByUpdatedAt, which relationships do not carry.
+func ValidatePageRequest(limit int, order []OrderBy, td *types.TypeDefinition, forRelationships bool) error {
+	if limit < 0 {
+		return fmt.Errorf("limit %d is negative: %w", limit, ErrInvalidPageRequest)
+	}
+	for _, o := range order {
+		switch o.Field {
+		case OrderByCreatedAt:
+			continue
+		case OrderByUpdatedAt:
+			if forRelationships {
+				return fmt.Errorf("relationships cannot be ordered by %q: %w", o.Field, ErrInvalidPageRequest)
+			}
+			continue
+		}
+		name, ok := PropertyOrderField(o.Field)
+		if !ok || !propertyNamePattern.MatchString(name) {
+			return fmt.Errorf("unknown order field %q: %w", o.Field, ErrInvalidPageRequest)
+		}
+		if td != nil && !slices.ContainsFunc(td.Properties, func(pd types.PropertyDefinition) bool { return pd.Name == name }) {
+			return fmt.Errorf("order field %q is not a property of %s: %w", o.Field, td.Name, ErrInvalidPageRequest)
+		}
+	}
+	return nil
+}
+
+// orderFingerprint identifies order inside a page token.
+func orderFingerprint(order []OrderBy) string {
+	parts := make([]string, len(order))
+	for i, o := range order {
+		parts[i] = o.Field
+		if o.Desc {
+			parts[i] = "-" + o.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// EncodePageToken returns the opaque token resuming after the item with the
+// given sort keys and ID.
+func EncodePageToken(order []OrderBy, keys []any, id string) string {
+	raw, err := json.Marshal(PageCursor{Order: orderFingerprint(order), Keys: keys, ID: id})
+	if err != nil {
+		// Keys come from stored JSON documents, so this cannot happen in
+		// practice; fall back to an ID-only cursor rather than failing.
+		raw, _ = json.Marshal(PageCursor{Order: orderFingerprint(order), ID: id})
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodePageToken parses token for a listing ordered by order. It returns
+// nil for an empty token.
+func DecodePageToken(token string, order []OrderBy) (*PageCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("malformed page token: %w", ErrInvalidPageRequest)
+	}
+	var c PageCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == "" {
+		return nil, fmt.Errorf("malformed page token: %w", ErrInvalidPageRequest)
+	}
+	if c.Order != orderFingerprint(order) || len(c.Keys) != len(order) {
+		return nil, fmt.Errorf("page token was issued for a different order: %w", ErrInvalidPageRequest)
+	}
+	return &c, nil
+}
+
+// EntitySortKeys returns e's value for each key of order.
+func EntitySortKeys(e Entity, order []OrderBy) []any {
+	keys := make([]any, len(order))
+	for i, o := range order {
+		switch o.Field {
+		case OrderByCreatedAt:
+			keys[i] = e.CreatedAt.UnixMilli()
+		case OrderByUpdatedAt:
+			keys[i] = e.UpdatedAt.UnixMilli()
+		default:
+			name, _ := PropertyOrderField(o.Field)
+			keys[i] = e.Properties[name]
+		}
+	}
+	return keys
+}
+
+// RelationshipSortKeys returns r's value for each key of order.
+func RelationshipSortKeys(r Relationship, order []OrderBy) []any {
+	keys := make([]any, len(order))
+	for i, o := range order {
+		if o.Field == OrderByCreatedAt {
+			keys[i] = r.CreatedAt.UnixMilli()
+			continue
+		}
+		name, _ := PropertyOrderField(o.Field)
+		keys[i] = r.Properties[name]
+	}
+	return keys
+}
+
+// CompareSortKeys orders two items by their sort keys under order, falling
+// back to their IDs. It returns a negative number when a sorts first.
+func CompareSortKeys(order []OrderBy, aKeys []any, aID string, bKeys []any, bID string) int {
+	for i, o := range order {
+		c := CompareValues(aKeys[i], bKeys[i])
+		if o.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(aID, bID)
+}
+
+// CompareValues compares two JSON-shaped values in ArangoDB order: null <
+// bool < number < string < array < object, with arrays compared element-wise
+// and objects by their JSON encoding.
+func CompareValues(a, b any) int {
+	ra, rb := typeRank(a), typeRank(b)
+	if ra != rb {
+		return ra - rb
+	}
+	switch ra {
+	case 1:
+		ab, bb := a.(bool), b.(bool)
+		switch {
+		case ab == bb:
+			return 0
+		case !ab:
+			return -1
+		}
+		return 1
+	case 2:
+		af, _ := asFloat(a)
+		bf, _ := asFloat(b)
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		}
+		return 0
+	case 3:
+		return strings.Compare(a.(string), b.(string))
+	case 4:
+		as, _ := asSlice(a)
+		bs, _ := asSlice(b)
+		for i := 0; i < len(as) && i < len(bs); i++ {
+			if c := CompareValues(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+		return len(as) - len(bs)
+	case 5:
+		aj, _ := json.Marshal(a)
+		bj, _ := json.Marshal(b)
+		return strings.Compare(string(aj), string(bj))
+	}
+	return 0
+}
+
+// typeRank returns v's position in the ArangoDB type order.
+func typeRank(v any) int {
+	if v == nil {
+		return 0
+	}
+	if _, ok := v.(bool); ok {
+		return 1
+	}
+	if _, ok := asFloat(v); ok {
+		return 2
+	}
+	if _, ok := v.(string); ok {
+		return 3
+	}
+	if _, ok := asSlice(v); ok {
+		return 4
+	}
+	return 5
+}
@@ -0,0 +1,72 @@
+package entitygraph_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+func TestCompareValues_FollowsArangoTypeOrder(t *testing.T) {
+	ordered := []any{nil, false, true, -1, 0.5, 2, "", "a", "b", []any{}, []any{1}, map[string]any{}}
+	for i := 1; i < len(ordered); i++ {
+		if c := entitygraph.CompareValues(ordered[i-1], ordered[i]); c >= 0 {
+			t.Errorf("CompareValues(%v, %v) = %d, want < 0", ordered[i-1], ordered[i], c)
+		}
+		if c := entitygraph.CompareValues(ordered[i], ordered[i-1]); c <= 0 {
+			t.Errorf("CompareValues(%v, %v) = %d, want > 0", ordered[i], ordered[i-1], c)
+		}
+	}
+	if c := entitygraph.CompareValues(int64(3), 3.0); c != 0 {
+		t.Errorf("CompareValues(int64 3, float 3) = %d, want 0", c)
+	}
+}
+
+func TestPageToken_RoundTrip(t *testing.T) {
+	order := []entitygraph.OrderBy{{Field: "properties.rank", Desc: true}, {Field: entitygraph.OrderByCreatedAt}}
+	token := entitygraph.EncodePageToken(order, []any{"x", int64(42)}, "id-1")
+
+	c, err := entitygraph.DecodePageToken(token, order)
+	if err != nil {
+		t.Fatalf("DecodePageToken: %v", err)
+	}
+	if c.ID != "id-1" || c.Keys[0] != "x" || c.Keys[1] != float64(42) {
+		t.Errorf("cursor = %+v, want keys [x 42] and ID id-1", c)
+	}
+	if _, err := entitygraph.DecodePageToken(token, order[:1]); !errors.Is(err, entitygraph.ErrInvalidPageRequest) {
+		t.Errorf("token reused with other order: got %v, want ErrInvalidPageRequest", err)
+	}
+	if c, err := entitygraph.DecodePageToken("", order); c != nil || err != nil {
+		t.Errorf("empty token = (%v, %v), want (nil, nil)", c, err)
+	}
+}
+
+func TestValidatePageRequest(t *testing.T) {
+	td := &types.TypeDefinition{Name: "Goal", Properties: []types.PropertyDefinition{{Name: "title"}}}
+	cases := []struct {
+		name  string
+		limit int
+		order []entitygraph.OrderBy
+		td    *types.TypeDefinition
+		rels  bool
+		ok    bool
+	}{
+		{"declared property", 10, []entitygraph.OrderBy{{Field: "properties.title"}}, td, false, true},
+		{"timestamps", 0, []entitygraph.OrderBy{{Field: "created_at"}, {Field: "updated_at", Desc: true}}, nil, false, true},
+		{"negative limit", -1, nil, nil, false, false},
+		{"undeclared property", 0, []entitygraph.OrderBy{{Field: "properties.code"}}, td, false, false},
+		{"injection attempt", 0, []entitygraph.OrderBy{{Field: "properties.a` RETURN 1"}}, nil, false, false},
+		{"bare name", 0, []entitygraph.OrderBy{{Field: "title"}}, nil, false, false},
+		{"relationship updated_at", 0, []entitygraph.OrderBy{{Field: "updated_at"}}, nil, true, false},
+	}
+	for _, tc := range cases {
+		err := entitygraph.ValidatePageRequest(tc.limit, tc.order, tc.td, tc.rels)
+		if tc.ok && err != nil {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+		}
+		if !tc.ok && !errors.Is(err, entitygraph.ErrInvalidPageRequest) {
+			t.Errorf("%s: got %v, want ErrInvalidPageRequest", tc.name, err)
+		}
+	}
+}
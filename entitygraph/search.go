@@ -0,0 +1,262 @@
+// search.go — full-text search over the Searchable properties of a type.
+//
+// SearchEntities matches the words of a free-text query against the
+// properties flagged [types.PropertyDefinition.Searchable]. Text is split into
+// words at every character that is not a letter or digit and compared
+// case-insensitively, without stemming. An entity matches when any query word
+// occurs in any searched property; hits are ranked by relevance, best first,
+// and carry [Highlight]s locating the matched words in each property value.
+//
+// The helpers here — [SearchTerms], [ValidateSearchRequest], and
+// [HighlightEntity] — are shared by every backend so that tokenisation and
+// highlighting are identical; only scoring is backend-specific.
+package entitygraph
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+// ErrInvalidSearch is returned by SearchEntities when the request is
+// malformed: no TypeID or query words, a negative Limit, a type without
+// Searchable properties, or a Properties entry that is not searchable.
+var ErrInvalidSearch = errors.New("invalid search request")
+
+// DefaultSearchLimit is the number of hits SearchEntities returns when
+// SearchRequest.Limit is zero.
+const DefaultSearchLimit = 20
+
+// MaxSearchLimit caps SearchRequest.Limit.
+const MaxSearchLimit = 200
+
+// SearchRequest is the input to DataManager.SearchEntities.
+type SearchRequest struct {
+	// AgencyID restricts hits to one agency. Empty searches every agency.
+	AgencyID string
+
+	// TypeID is the entity type to search. Required.
+	TypeID string
+
+	// Query is the free-text query. It must contain at least one word.
+	Query string
+
+	// Properties restricts the search to a subset of the type's Searchable
+	// properties. Empty searches all of them.
+	Properties []string
+
+	// Limit is the maximum number of hits; 0 means DefaultSearchLimit, and
+	// values above MaxSearchLimit are capped.
+	Limit int
+}
+
+// SearchHit is one ranked result of SearchEntities.
+type SearchHit struct {
+	// Entity is the matching entity.
+	Entity Entity
+
+	// Score is the relevance of the hit; higher is better. Scores are only
+	// comparable within one result set and differ between backends.
+	Score float64
+
+	// Highlights locate the matched query words, one per matching property
+	// value, in the order the properties are declared.
+	Highlights []Highlight
+}
+
+// Highlight locates matched query words within one property value.
+type Highlight struct {
+	// Property is the name of the matching property.
+	Property string
+
+	// Text is the property value — or, for an array property, the matching
+	// element.
+	Text string
+
+	// Spans are the byte ranges of Text holding a matched word, in order.
+	Spans []TextSpan
+}
+
+// TextSpan is the half-open byte range [Start, End) of a word in a
+// [Highlight]'s Text.
+type TextSpan struct {
+	Start int
+	End   int
+}
+
+// SearchTerms splits text into lower-cased words, dropping duplicates while
+// keeping first-occurrence order.
+func SearchTerms(text string) []string {
+	var terms []string
+	for _, w := range words(text) {
+		t := strings.ToLower(text[w.Start:w.End])
+		if !slices.Contains(terms, t) {
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
+
+// words returns the byte ranges of the words of text.
+func words(text string) []TextSpan {
+	var spans []TextSpan
+	start := -1
+	for i, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case inWord && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			spans = append(spans, TextSpan{Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, TextSpan{Start: start, End: len(text)})
+	}
+	return spans
+}
+
+// SearchableProperties returns the names of td's Searchable properties in
+// declaration order.
+func SearchableProperties(td types.TypeDefinition) []string {
+	var names []string
+	for _, pd := range td.Properties {
+		if pd.Searchable {
+			names = append(names, pd.Name)
+		}
+	}
+	return names
+}
+
+// ValidateSearchRequest checks req against td, the TypeDefinition of
+// req.TypeID (nil when the type is not declared), and returns the properties
+// to search and the query words. Errors wrap [ErrInvalidSearch].
+func ValidateSearchRequest(req SearchRequest, td *types.TypeDefinition) (props, terms []string, err error) {
+	if req.TypeID == "" {
+		return nil, nil, fmt.Errorf("%w: type is required", ErrInvalidSearch)
+	}
+	if req.Limit < 0 {
+		return nil, nil, fmt.Errorf("%w: limit %d is negative", ErrInvalidSearch, req.Limit)
+	}
+	terms = SearchTerms(req.Query)
+	if len(terms) == 0 {
+		return nil, nil, fmt.Errorf("%w: query has no words", ErrInvalidSearch)
+	}
+	if td == nil {
+		return nil, nil, fmt.Errorf("%w: type %q is not declared", ErrInvalidSearch, req.TypeID)
+	}
+	searchable := SearchableProperties(*td)
+	if len(searchable) == 0 {
+		return nil, nil, fmt.Errorf("%w: type %q has no searchable properties", ErrInvalidSearch, req.TypeID)
+	}
+	if len(req.Properties) == 0 {
+		return searchable, terms, nil
+	}
+	for _, p := range req.Properties {
+		if !slices.Contains(searchable, p) {
+			return nil, nil, fmt.Errorf("%w: %q is not a searchable property of %s", ErrInvalidSearch, p, req.TypeID)
+		}
+	}
+	// Keep declaration order so highlights are ordered the same way.
+	props = slices.DeleteFunc(searchable, func(p string) bool { return !slices.Contains(req.Properties, p) })
+	return props, terms, nil
+}
+
+// SearchLimit returns the effective hit limit for req.
+func SearchLimit(req SearchRequest) int {
+	switch {
+	case req.Limit == 0:
+		return DefaultSearchLimit
+	case req.Limit > MaxSearchLimit:
+		return MaxSearchLimit
+	}
+	return req.Limit
+}
+
+// HighlightEntity returns the highlights of terms in the given properties of
+// e. Properties that are not strings or arrays of strings are skipped.
+func HighlightEntity(e Entity, props, terms []string) []Highlight {
+	var out []Highlight
+	for _, p := range props {
+		for _, text := range searchTexts(e.Properties[p]) {
+			if spans := matchTerms(text, terms); len(spans) > 0 {
+				out = append(out, Highlight{Property: p, Text: text, Spans: spans})
+			}
+		}
+	}
+	return out
+}
+
+// searchTexts returns the searchable strings of a property value: the value
+// itself for a string, or its string elements for an array.
+func searchTexts(v any) []string {
+	if s, ok := v.(string); ok {
+		return []string{s}
+	}
+	elems, ok := asSlice(v)
+	if !ok {
+		return nil
+	}
+	var texts []string
+	for _, e := range elems {
+		if s, ok := e.(string); ok {
+			texts = append(texts, s)
+		}
+	}
+	return texts
+}
+
+// matchTerms returns the spans of the words of text equal to any of terms.
+func matchTerms(text string, terms []string) []TextSpan {
+	var spans []TextSpan
+	for _, w := range words(text) {
+		if slices.Contains(terms, strings.ToLower(text[w.Start:w.End])) {
+			spans = append(spans, w)
+		}
+	}
+	return spans
+}
+
+// TermFrequencies counts, for each of terms, how often it occurs in the given
+// properties of e. Backends without a search index use it for matching and
+// scoring.
+func TermFrequencies(e Entity, props, terms []string) map[string]int {
+	freq := make(map[string]int, len(terms))
+	for _, p := range props {
+		for _, text := range searchTexts(e.Properties[p]) {
+			for _, span := range matchTerms(text, terms) {
+				freq[strings.ToLower(text[span.Start:span.End])]++
+			}
+		}
+	}
+	return freq
+}
+
+// SearchedWordCount returns the number of words in the given properties of e,
+// the document length used by length-normalised scoring.
+func SearchedWordCount(e Entity, props []string) int {
+	n := 0
+	for _, p := range props {
+		for _, text := range searchTexts(e.Properties[p]) {
+			n += len(words(text))
+		}
+	}
+	return n
+}
+
+// validSearchableType reports whether a property of type pd may be
+// Searchable: string-valued, or an array of strings.
+func validSearchableType(pd types.PropertyDefinition) bool {
+	switch propertyKind(pd.Type) {
+	case "string":
+		return true
+	case "array":
+		return propertyKind(arrayElementType(pd)) == "string"
+	}
+	return false
+}
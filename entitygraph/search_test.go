@@ -0,0 +1,42 @@
+package entitygraph_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+func TestSearchTerms_LowerCasedUniqueWords(t *testing.T) {
+	got := entitygraph.SearchTerms("Fix the LOGIN-page; fix émigré v2")
+	want := []string{"fix", "the", "login", "page", "émigré", "v2"}
+	if !slices.Equal(got, want) {
+		t.Errorf("SearchTerms = %q, want %q", got, want)
+	}
+}
+
+func TestValidateSchema_SearchableMustBeText(t *testing.T) {
+	schema := func(pd types.PropertyDefinition) types.Schema {
+		return types.Schema{Types: []types.TypeDefinition{{Name: "Doc", Properties: []types.PropertyDefinition{pd}}}}
+	}
+	ok := []types.PropertyDefinition{
+		{Name: "title", Type: types.PropertyTypeString, Searchable: true},
+		{Name: "tags", Type: types.PropertyTypeArray, ElementType: types.PropertyTypeString, Searchable: true},
+		{Name: "count", Type: types.PropertyTypeInteger},
+	}
+	for _, pd := range ok {
+		if err := entitygraph.ValidateSchema(schema(pd)); err != nil {
+			t.Errorf("%s: unexpected error %v", pd.Name, err)
+		}
+	}
+	bad := []types.PropertyDefinition{
+		{Name: "count", Type: types.PropertyTypeInteger, Searchable: true},
+		{Name: "scores", Type: types.PropertyTypeArray, ElementType: types.PropertyTypeNumber, Searchable: true},
+	}
+	for _, pd := range bad {
+		if err := entitygraph.ValidateSchema(schema(pd)); err == nil {
+			t.Errorf("%s: Searchable %s accepted", pd.Name, pd.Type)
+		}
+	}
+}
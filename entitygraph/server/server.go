@@ -14,7 +14,9 @@ import (
 
 	"github.com/aosanya/CodeValdSharedLib/entitygraph"
 	pb "github.com/aosanya/CodeValdSharedLib/gen/go/entitygraph/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -27,6 +29,12 @@ import (
 // strings if the proto package is ever renamed.
 const GRPCServicePath = "/entitygraph.v1.EntityService"
 
+// ActorMetadataKey is the incoming gRPC metadata key naming who makes a
+// request. Write handlers attribute their changes to it (see
+// entitygraph.WithActor), so it appears in the history of History-enabled
+// types.
+const ActorMetadataKey = "x-codevald-actor"
+
 // EntityServer implements pb.EntityServiceServer by delegating to an
 // entitygraph.DataManager. Construct via NewEntityServer; register with
 // pb.RegisterEntityServiceServer.
@@ -42,24 +50,42 @@ func NewEntityServer(dm entitygraph.DataManager) *EntityServer {
 
 // ListEntities implements pb.EntityServiceServer.
 // type_id is injected by CodeValdCross via ConstantBinding at dispatch time.
+// page_size, page_token, order_by and include_total are passed through to
+// DataManager.ListEntitiesPage.
 func (s *EntityServer) ListEntities(ctx context.Context, req *pb.ListEntitiesRequest) (*pb.ListEntitiesResponse, error) {
-	entities, err := s.dm.ListEntities(ctx, entitygraph.EntityFilter{
-		AgencyID:   req.GetAgencyId(),
-		TypeID:     req.GetTypeId(),
-		Properties: structToMap(req.GetProperties()),
-	})
+	filter := entityFilterFromProto(req)
+	filter.IncludeDeleted = req.GetIncludeDeleted()
+	return s.listEntities(ctx, filter)
+}
+
+// ListDeletedEntities implements pb.EntityServiceServer.
+// Only soft-deleted entities are returned; include_deleted is ignored.
+func (s *EntityServer) ListDeletedEntities(ctx context.Context, req *pb.ListEntitiesRequest) (*pb.ListEntitiesResponse, error) {
+	filter := entityFilterFromProto(req)
+	filter.OnlyDeleted = true
+	return s.listEntities(ctx, filter)
+}
+
+// listEntities lists one page of entities for ListEntities and
+// ListDeletedEntities.
+func (s *EntityServer) listEntities(ctx context.Context, filter entitygraph.EntityFilter) (*pb.ListEntitiesResponse, error) {
+	page, err := s.dm.ListEntitiesPage(ctx, filter)
 	if err != nil {
 		return nil, toGRPCError(err)
 	}
-	items := make([]*pb.EntityItem, 0, len(entities))
-	for _, e := range entities {
+	items := make([]*pb.EntityItem, 0, len(page.Entities))
+	for _, e := range page.Entities {
 		item, convErr := entityToProto(e)
 		if convErr != nil {
 			return nil, toGRPCError(convErr)
 		}
 		items = append(items, item)
 	}
-	return &pb.ListEntitiesResponse{Entities: items}, nil
+	return &pb.ListEntitiesResponse{
+		Entities:      items,
+		NextPageToken: page.NextPageToken,
+		TotalSize:     int64(page.Total),
+	}, nil
 }
 
 // CreateEntity implements pb.EntityServiceServer.
@@ -69,6 +95,7 @@ func (s *EntityServer) ListEntities(ctx context.Context, req *pb.ListEntitiesReq
 // of inserting a duplicate. Types without a UniqueKey fall back to a plain
 // CreateEntity (immutable types, etc.).
 func (s *EntityServer) CreateEntity(ctx context.Context, req *pb.CreateEntityRequest) (*pb.EntityItem, error) {
+	ctx = withActor(ctx)
 	props := structToMap(req.GetProperties())
 	createReq := entitygraph.CreateEntityRequest{
 		AgencyID:   req.GetAgencyId(),
@@ -102,8 +129,10 @@ func (s *EntityServer) GetEntity(ctx context.Context, req *pb.GetEntityRequest)
 // type_id is injected by CodeValdCross via ConstantBinding but not used by the
 // DataManager (entity is located by ID).
 func (s *EntityServer) UpdateEntity(ctx context.Context, req *pb.UpdateEntityRequest) (*pb.EntityItem, error) {
+	ctx = withActor(ctx)
 	entity, err := s.dm.UpdateEntity(ctx, req.GetAgencyId(), req.GetEntityId(), entitygraph.UpdateEntityRequest{
 		Properties: structToMap(req.GetProperties()),
+		IfMatch:    req.GetIfMatch(),
 	})
 	if err != nil {
 		return nil, toGRPCError(err)
@@ -113,32 +142,98 @@ func (s *EntityServer) UpdateEntity(ctx context.Context, req *pb.UpdateEntityReq
 
 // DeleteEntity implements pb.EntityServiceServer.
 func (s *EntityServer) DeleteEntity(ctx context.Context, req *pb.DeleteEntityRequest) (*pb.DeleteEntityResponse, error) {
-	if err := s.dm.DeleteEntity(ctx, req.GetAgencyId(), req.GetEntityId()); err != nil {
+	ctx = withActor(ctx)
+	if err := s.dm.DeleteEntityIfMatch(ctx, req.GetAgencyId(), req.GetEntityId(), req.GetIfMatch()); err != nil {
 		return nil, toGRPCError(err)
 	}
 	return &pb.DeleteEntityResponse{}, nil
 }
 
+// RestoreEntity implements pb.EntityServiceServer.
+// type_id is injected by CodeValdCross via ConstantBinding but not used by the
+// DataManager (entity is located by ID).
+func (s *EntityServer) RestoreEntity(ctx context.Context, req *pb.RestoreEntityRequest) (*pb.EntityItem, error) {
+	ctx = withActor(ctx)
+	entity, err := s.dm.RestoreEntity(ctx, req.GetAgencyId(), req.GetEntityId())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return entityToProto(entity)
+}
+
+// PurgeEntity implements pb.EntityServiceServer.
+func (s *EntityServer) PurgeEntity(ctx context.Context, req *pb.PurgeEntityRequest) (*pb.PurgeEntityResponse, error) {
+	if err := s.dm.PurgeEntity(ctx, req.GetAgencyId(), req.GetEntityId()); err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &pb.PurgeEntityResponse{}, nil
+}
+
+// PurgeDeletedBefore implements pb.EntityServiceServer.
+// A missing cutoff is rejected rather than treated as the zero time.
+func (s *EntityServer) PurgeDeletedBefore(ctx context.Context, req *pb.PurgeDeletedBeforeRequest) (*pb.PurgeDeletedBeforeResponse, error) {
+	if req.GetCutoff() == nil {
+		return nil, status.Error(codes.InvalidArgument, "cutoff is required")
+	}
+	n, err := s.dm.PurgeDeletedBefore(ctx, req.GetAgencyId(), req.GetCutoff().AsTime())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &pb.PurgeDeletedBeforeResponse{Purged: int32(n)}, nil
+}
+
+// SearchEntities implements pb.EntityServiceServer.
+// type_id is injected by CodeValdCross via ConstantBinding at dispatch time.
+func (s *EntityServer) SearchEntities(ctx context.Context, req *pb.SearchEntitiesRequest) (*pb.SearchEntitiesResponse, error) {
+	hits, err := s.dm.SearchEntities(ctx, entitygraph.SearchRequest{
+		AgencyID:   req.GetAgencyId(),
+		TypeID:     req.GetTypeId(),
+		Query:      req.GetQuery(),
+		Properties: req.GetProperties(),
+		Limit:      int(req.GetLimit()),
+	})
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	out := make([]*pb.SearchHit, 0, len(hits))
+	for _, h := range hits {
+		item, convErr := entityToProto(h.Entity)
+		if convErr != nil {
+			return nil, toGRPCError(convErr)
+		}
+		out = append(out, &pb.SearchHit{Entity: item, Score: h.Score, Highlights: highlightsToProto(h.Highlights)})
+	}
+	return &pb.SearchEntitiesResponse{Hits: out}, nil
+}
+
 // ListRelationships implements pb.EntityServiceServer.
 // name is injected by CodeValdCross via ConstantBinding at dispatch time.
 func (s *EntityServer) ListRelationships(ctx context.Context, req *pb.ListRelationshipsRequest) (*pb.ListRelationshipsResponse, error) {
-	rels, err := s.dm.ListRelationships(ctx, entitygraph.RelationshipFilter{
-		AgencyID: req.GetAgencyId(),
-		FromID:   req.GetEntityId(),
-		Name:     req.GetName(),
+	page, err := s.dm.ListRelationshipsPage(ctx, entitygraph.RelationshipFilter{
+		AgencyID:     req.GetAgencyId(),
+		FromID:       req.GetEntityId(),
+		Name:         req.GetName(),
+		OrderBy:      orderByFromProto(req.GetOrderBy()),
+		Limit:        int(req.GetPageSize()),
+		PageToken:    req.GetPageToken(),
+		IncludeTotal: req.GetIncludeTotal(),
 	})
 	if err != nil {
 		return nil, toGRPCError(err)
 	}
-	items := make([]*pb.RelationshipItem, 0, len(rels))
-	for _, r := range rels {
+	items := make([]*pb.RelationshipItem, 0, len(page.Relationships))
+	for _, r := range page.Relationships {
 		item, convErr := relationshipToProto(r)
 		if convErr != nil {
 			return nil, toGRPCError(convErr)
 		}
 		items = append(items, item)
 	}
-	return &pb.ListRelationshipsResponse{Relationships: items}, nil
+	return &pb.ListRelationshipsResponse{
+		Relationships: items,
+		NextPageToken: page.NextPageToken,
+		TotalSize:     int64(page.Total),
+	}, nil
 }
 
 // CreateRelationship implements pb.EntityServiceServer.
@@ -207,6 +302,75 @@ func (s *EntityServer) TraverseGraph(ctx context.Context, req *pb.TraverseGraphR
 	return &pb.TraverseGraphResponse{Vertices: vertices, Edges: edges}, nil
 }
 
+// GetEntityHistory implements pb.EntityServiceServer.
+// type_id is injected by CodeValdCross via ConstantBinding but not used by the
+// DataManager (entity is located by ID).
+func (s *EntityServer) GetEntityHistory(ctx context.Context, req *pb.GetEntityHistoryRequest) (*pb.GetEntityHistoryResponse, error) {
+	history, err := s.dm.GetEntityHistory(ctx, req.GetAgencyId(), req.GetEntityId())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	out := make([]*pb.EntityRevisionItem, 0, len(history))
+	for _, rev := range history {
+		item, convErr := entityToProto(rev.Entity)
+		if convErr != nil {
+			return nil, toGRPCError(convErr)
+		}
+		out = append(out, &pb.EntityRevisionItem{
+			Entity:     item,
+			Change:     string(rev.Change),
+			Actor:      rev.Actor,
+			RecordedAt: timestamppb.New(rev.RecordedAt),
+		})
+	}
+	return &pb.GetEntityHistoryResponse{Revisions: out}, nil
+}
+
+// GetEntityAt implements pb.EntityServiceServer.
+// A missing at is rejected rather than treated as the zero time.
+func (s *EntityServer) GetEntityAt(ctx context.Context, req *pb.GetEntityAtRequest) (*pb.EntityItem, error) {
+	if req.GetAt() == nil {
+		return nil, status.Error(codes.InvalidArgument, "at is required")
+	}
+	entity, err := s.dm.GetEntityAt(ctx, req.GetAgencyId(), req.GetEntityId(), req.GetAt().AsTime())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return entityToProto(entity)
+}
+
+// BatchWrite implements pb.EntityServiceServer.
+// The operations run as one DataManager.Batch; when one fails, the status
+// carries that operation's error code and its message names the operation's
+// index.
+func (s *EntityServer) BatchWrite(ctx context.Context, req *pb.BatchWriteRequest) (*pb.BatchWriteResponse, error) {
+	ctx = withActor(ctx)
+	ops := make([]entitygraph.BatchOp, 0, len(req.GetOperations()))
+	for _, o := range req.GetOperations() {
+		ops = append(ops, batchOpFromProto(o))
+	}
+	result, err := s.dm.Batch(ctx, req.GetAgencyId(), ops)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	out := make([]*pb.BatchWriteResult, 0, len(result.Results))
+	for _, r := range result.Results {
+		item := &pb.BatchWriteResult{}
+		var convErr error
+		if r.Entity != nil {
+			item.Entity, convErr = entityToProto(*r.Entity)
+		}
+		if convErr == nil && r.Relationship != nil {
+			item.Relationship, convErr = relationshipToProto(*r.Relationship)
+		}
+		if convErr != nil {
+			return nil, toGRPCError(convErr)
+		}
+		out = append(out, item)
+	}
+	return &pb.BatchWriteResponse{Results: out, Refs: result.Refs}, nil
+}
+
 // ── Conversion helpers ────────────────────────────────────────────────────────
 
 // entityToProto converts an entitygraph.Entity to its proto representation.
@@ -215,14 +379,20 @@ func entityToProto(e entitygraph.Entity) (*pb.EntityItem, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &pb.EntityItem{
+	item := &pb.EntityItem{
 		Id:         e.ID,
 		AgencyId:   e.AgencyID,
 		TypeId:     e.TypeID,
 		Properties: protoProps,
 		CreatedAt:  timestamppb.New(e.CreatedAt),
 		UpdatedAt:  timestamppb.New(e.UpdatedAt),
-	}, nil
+		Deleted:    e.Deleted,
+		Revision:   e.Revision,
+	}
+	if e.DeletedAt != nil {
+		item.DeletedAt = timestamppb.New(*e.DeletedAt)
+	}
+	return item, nil
 }
 
 // relationshipToProto converts an entitygraph.Relationship to its proto
@@ -240,9 +410,125 @@ func relationshipToProto(r entitygraph.Relationship) (*pb.RelationshipItem, erro
 		ToId:       r.ToID,
 		Properties: protoProps,
 		CreatedAt:  timestamppb.New(r.CreatedAt),
+		Derived:    r.Derived,
+		PairId:     r.PairID,
 	}, nil
 }
 
+// highlightsToProto converts search highlights to their proto representation.
+func highlightsToProto(in []entitygraph.Highlight) []*pb.SearchHighlight {
+	out := make([]*pb.SearchHighlight, len(in))
+	for i, h := range in {
+		spans := make([]*pb.TextSpan, len(h.Spans))
+		for j, sp := range h.Spans {
+			spans[j] = &pb.TextSpan{Start: int32(sp.Start), End: int32(sp.End)}
+		}
+		out[i] = &pb.SearchHighlight{Property: h.Property, Text: h.Text, Spans: spans}
+	}
+	return out
+}
+
+// entityFilterFromProto builds the EntityFilter shared by ListEntities and
+// ListDeletedEntities; the deleted-entity flags are left to the caller.
+func entityFilterFromProto(req *pb.ListEntitiesRequest) entitygraph.EntityFilter {
+	return entitygraph.EntityFilter{
+		AgencyID:     req.GetAgencyId(),
+		TypeID:       req.GetTypeId(),
+		Properties:   structToMap(req.GetProperties()),
+		OrderBy:      orderByFromProto(req.GetOrderBy()),
+		Limit:        int(req.GetPageSize()),
+		PageToken:    req.GetPageToken(),
+		IncludeTotal: req.GetIncludeTotal(),
+		Where:        filterFromProto(req.GetFilter()),
+	}
+}
+
+// filterFromProto converts a proto filter tree to an entitygraph.FilterExpr.
+// A nil tree is returned as nil.
+func filterFromProto(f *pb.FilterExpr) *entitygraph.FilterExpr {
+	if f == nil {
+		return nil
+	}
+	out := &entitygraph.FilterExpr{
+		Op:       entitygraph.FilterOp(f.GetOp()),
+		Property: f.GetProperty(),
+	}
+	if f.GetValue() != nil {
+		out.Value = f.GetValue().AsInterface()
+	}
+	for _, op := range f.GetOperands() {
+		out.Operands = append(out.Operands, *filterFromProto(op))
+	}
+	return out
+}
+
+// orderByFromProto converts proto sort keys to entitygraph.OrderBy values.
+func orderByFromProto(in []*pb.OrderBy) []entitygraph.OrderBy {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make([]entitygraph.OrderBy, len(in))
+	for i, o := range in {
+		out[i] = entitygraph.OrderBy{Field: o.GetField(), Desc: o.GetDesc()}
+	}
+	return out
+}
+
+// withActor returns ctx carrying, as the entitygraph actor, the first
+// ActorMetadataKey value of the incoming request metadata, if any.
+func withActor(ctx context.Context) context.Context {
+	if actors := metadata.ValueFromIncomingContext(ctx, ActorMetadataKey); len(actors) > 0 {
+		return entitygraph.WithActor(ctx, actors[0])
+	}
+	return ctx
+}
+
+// batchOpFromProto converts a proto BatchOperation to an entitygraph.BatchOp.
+// An operation with no op set converts to a BatchOp with an empty Kind, which
+// Batch rejects as ErrInvalidBatch.
+func batchOpFromProto(o *pb.BatchOperation) entitygraph.BatchOp {
+	op := entitygraph.BatchOp{Ref: o.GetRef()}
+	switch {
+	case o.GetCreateEntity() != nil, o.GetUpsertEntity() != nil:
+		req := o.GetCreateEntity()
+		op.Kind = entitygraph.BatchCreateEntity
+		if req == nil {
+			req = o.GetUpsertEntity()
+			op.Kind = entitygraph.BatchUpsertEntity
+		}
+		op.Entity = entitygraph.CreateEntityRequest{
+			TypeID:     req.GetTypeId(),
+			Properties: structToMap(req.GetProperties()),
+		}
+	case o.GetUpdateEntity() != nil:
+		req := o.GetUpdateEntity()
+		op.Kind = entitygraph.BatchUpdateEntity
+		op.EntityID = req.GetEntityId()
+		op.Update = entitygraph.UpdateEntityRequest{
+			Properties: structToMap(req.GetProperties()),
+			IfMatch:    req.GetIfMatch(),
+		}
+	case o.GetDeleteEntity() != nil:
+		req := o.GetDeleteEntity()
+		op.Kind = entitygraph.BatchDeleteEntity
+		op.EntityID = req.GetEntityId()
+		op.IfMatch = req.GetIfMatch()
+	case o.GetCreateRelationship() != nil:
+		req := o.GetCreateRelationship()
+		op.Kind = entitygraph.BatchCreateRelationship
+		op.Relationship = entitygraph.CreateRelationshipRequest{
+			Name:       req.GetName(),
+			FromID:     req.GetEntityId(),
+			ToID:       req.GetToId(),
+			Properties: structToMap(req.GetProperties()),
+		}
+	case o.GetDeleteRelationship() != nil:
+		op.Kind = entitygraph.BatchDeleteRelationship
+		op.RelationshipID = o.GetDeleteRelationship().GetRelationshipId()
+	}
+	return op
+}
+
 // structToMap converts a proto Struct to map[string]any.
 // A nil Struct is returned as a nil map.
 func structToMap(s *structpb.Struct) map[string]any {
@@ -255,11 +541,16 @@ func structToMap(s *structpb.Struct) map[string]any {
 // toGRPCError maps entitygraph domain errors to the appropriate gRPC status.
 // Unknown errors are wrapped as codes.Internal.
 func toGRPCError(err error) error {
+	var ve *entitygraph.ValidationError
 	switch {
+	case errors.As(err, &ve):
+		return validationStatus(err, ve)
 	case errors.Is(err, entitygraph.ErrEntityNotFound):
 		return status.Error(codes.NotFound, err.Error())
 	case errors.Is(err, entitygraph.ErrEntityAlreadyExists):
 		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, entitygraph.ErrConflict):
+		return status.Error(codes.Aborted, err.Error())
 	case errors.Is(err, entitygraph.ErrRelationshipNotFound):
 		return status.Error(codes.NotFound, err.Error())
 	case errors.Is(err, entitygraph.ErrImmutableType):
@@ -270,7 +561,40 @@ func toGRPCError(err error) error {
 		return status.Error(codes.FailedPrecondition, err.Error())
 	case errors.Is(err, entitygraph.ErrRequiredRelationshipViolation):
 		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, entitygraph.ErrDeleteRestricted):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, entitygraph.ErrEntityNotDeleted):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, entitygraph.ErrInvalidPageRequest):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, entitygraph.ErrInvalidFilter):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, entitygraph.ErrInvalidSearch):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, entitygraph.ErrInvalidBatch):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, entitygraph.ErrHistoryNotEnabled):
+		return status.Error(codes.FailedPrecondition, err.Error())
 	default:
 		return status.Errorf(codes.Internal, "internal error: %v", err)
 	}
 }
+
+// validationStatus builds a codes.InvalidArgument status carrying one
+// errdetails.BadRequest field violation per invalid property, so clients can
+// map failures back to form fields. Fields are reported as
+// "properties.<name>".
+func validationStatus(err error, ve *entitygraph.ValidationError) error {
+	br := &errdetails.BadRequest{}
+	for _, v := range ve.Violations {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       "properties." + v.Property,
+			Description: v.Reason,
+		})
+	}
+	st, detailErr := status.New(codes.InvalidArgument, err.Error()).WithDetails(br)
+	if detailErr != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return st.Err()
+}
@@ -0,0 +1,143 @@
+// topicschema.go — JSON Schemas for the lifecycle topics of a schema.
+//
+// [TopicSchemas] describes the [EntityEvent] payload of every topic
+// types.TopicsFromSchema lists, walking the schema with
+// types.LifecycleTopics exactly as TopicsFromSchema does, so the two can
+// never disagree on which topics exist.
+package entitygraph
+
+import (
+	"github.com/aosanya/CodeValdSharedLib/topicschema"
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+// TopicSchemas returns the JSON Schema of the EntityEvent payload of each
+// lifecycle topic of schema under servicePrefix, keyed by topic. The entity
+// properties are described by their PropertyDefinitions: types map to JSON
+// types and formats, option properties to an enum of their Options, rating
+// properties to a bounded integer, and properties that are not Required
+// also accept null.
+//
+// Register the result with CodeValdCross through
+// registrar.RegisterTopicSchemas(ctx, agencyID, set.Strings()), and check
+// payloads against it with eventbus.NewValidatingPublisher and
+// eventreceiver.DispatcherConfig.Schemas.
+func TopicSchemas(servicePrefix string, schema types.Schema) topicschema.Set {
+	set := make(topicschema.Set)
+	for _, lt := range types.LifecycleTopics(servicePrefix, schema) {
+		entity := entitySchema(lt.Type)
+		payload := &topicschema.Schema{
+			Schema:   topicschema.Draft,
+			Title:    lt.Topic,
+			Type:     topicschema.Types{topicschema.TypeObject},
+			Required: []string{"id", "entity"},
+			Properties: map[string]*topicschema.Schema{
+				"id":     {Type: topicschema.Types{topicschema.TypeString}},
+				"entity": entity,
+				"actor":  {Type: topicschema.Types{topicschema.TypeString}},
+			},
+		}
+		switch {
+		case lt.Property != nil:
+			payload.Description = lt.Type.Name + " " + lt.Property.Name + " changed"
+			payload.Required = append(payload.Required, "property")
+			payload.Properties["property"] = &topicschema.Schema{Type: topicschema.Types{topicschema.TypeString}, Const: lt.Property.Name}
+			payload.Properties["oldValue"] = propertySchema(*lt.Property, true)
+			payload.Properties["newValue"] = propertySchema(*lt.Property, true)
+		case lt.Event == types.TopicEventUpdated:
+			payload.Description = lt.Type.Name + " updated"
+			payload.Properties["previous"] = entity
+		default:
+			payload.Description = lt.Type.Name + " " + lt.Event
+		}
+		set[lt.Topic] = payload
+	}
+	return set
+}
+
+// entitySchema describes the JSON form of an Entity of type td.
+func entitySchema(td types.TypeDefinition) *topicschema.Schema {
+	str := topicschema.Types{topicschema.TypeString}
+	props := &topicschema.Schema{
+		Type:       topicschema.Types{topicschema.TypeObject},
+		Properties: make(map[string]*topicschema.Schema, len(td.Properties)),
+	}
+	for _, pd := range td.Properties {
+		props.Properties[pd.Name] = propertySchema(pd, !pd.Required)
+		if pd.Required {
+			props.Required = append(props.Required, pd.Name)
+		}
+	}
+	return &topicschema.Schema{
+		Type:     topicschema.Types{topicschema.TypeObject},
+		Required: []string{"id", "agencyId", "typeId", "createdAt", "updatedAt"},
+		Properties: map[string]*topicschema.Schema{
+			"id":         {Type: str},
+			"agencyId":   {Type: str},
+			"typeId":     {Type: str, Const: td.Name},
+			"properties": props,
+			"createdAt":  {Type: str, Format: topicschema.FormatDateTime},
+			"updatedAt":  {Type: str, Format: topicschema.FormatDateTime},
+			"deleted":    {Type: topicschema.Types{topicschema.TypeBoolean}},
+			"deletedAt":  {Type: str, Format: topicschema.FormatDateTime},
+			"revision":   {Type: str},
+		},
+	}
+}
+
+// propertySchema describes a value of the property pd, also accepting null
+// when nullable is set.
+func propertySchema(pd types.PropertyDefinition, nullable bool) *topicschema.Schema {
+	s := valueSchema(pd, pd.Type)
+	switch pd.Type {
+	case types.PropertyTypeArray:
+		s.Items = &topicschema.Schema{}
+		if pd.ElementType != "" {
+			s.Items = valueSchema(pd, pd.ElementType)
+		}
+	case types.PropertyTypeMultiSelect:
+		s.Items = &topicschema.Schema{Type: topicschema.Types{topicschema.TypeString}}
+	}
+	if nullable && len(s.Type) > 0 {
+		s.Type = append(s.Type, topicschema.TypeNull)
+	}
+	return s
+}
+
+// valueSchema describes a single value of PropertyType pt, taking options
+// and rating bounds from pd.
+func valueSchema(pd types.PropertyDefinition, pt types.PropertyType) *topicschema.Schema {
+	str := topicschema.Types{topicschema.TypeString}
+	switch pt {
+	case types.PropertyTypeString, types.PropertyTypeSelect:
+		return &topicschema.Schema{Type: str}
+	case types.PropertyTypeInteger:
+		return &topicschema.Schema{Type: topicschema.Types{topicschema.TypeInteger}}
+	case types.PropertyTypeFloat, types.PropertyTypeNumber:
+		return &topicschema.Schema{Type: topicschema.Types{topicschema.TypeNumber}}
+	case types.PropertyTypeBoolean:
+		return &topicschema.Schema{Type: topicschema.Types{topicschema.TypeBoolean}}
+	case types.PropertyTypeDate:
+		return &topicschema.Schema{Type: str, Format: topicschema.FormatDate}
+	case types.PropertyTypeDatetime:
+		return &topicschema.Schema{Type: str, Format: topicschema.FormatDateTime}
+	case types.PropertyTypeUUID:
+		return &topicschema.Schema{Type: str, Format: topicschema.FormatUUID}
+	case types.PropertyTypeOption:
+		s := &topicschema.Schema{Type: str}
+		for _, o := range pd.Options {
+			s.Enum = append(s.Enum, o)
+		}
+		return s
+	case types.PropertyTypeRating:
+		s := &topicschema.Schema{Type: topicschema.Types{topicschema.TypeInteger}}
+		if pd.RatingConfig != nil {
+			lo, hi := float64(pd.RatingConfig.Min), float64(pd.RatingConfig.Max)
+			s.Minimum, s.Maximum = &lo, &hi
+		}
+		return s
+	case types.PropertyTypeMultiSelect, types.PropertyTypeArray:
+		return &topicschema.Schema{Type: topicschema.Types{topicschema.TypeArray}}
+	}
+	return &topicschema.Schema{}
+}
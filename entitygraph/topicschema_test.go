@@ -0,0 +1,76 @@
+package entitygraph_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+	"github.com/aosanya/CodeValdSharedLib/topicschema"
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+func TestTopicSchemas_CoverTopicsFromSchema(t *testing.T) {
+	set := entitygraph.TopicSchemas("work", eventsSchema())
+	var got []string
+	for topic := range set {
+		got = append(got, topic)
+	}
+	want := types.TopicsFromSchema("work", eventsSchema())
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("TopicSchemas topics = %v, want %v", got, want)
+	}
+}
+
+func TestTopicSchemas_PublishedEventsValidate(t *testing.T) {
+	ctx := context.Background()
+	dm, rec := newPublishing(t)
+	set := entitygraph.TopicSchemas("work", eventsSchema())
+
+	task, err := dm.CreateEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: eventsAgency, TypeID: "Task", Properties: map[string]any{"code": "T1", "status": "open"},
+	})
+	if err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+	if _, err := dm.UpdateEntity(ctx, eventsAgency, task.ID, entitygraph.UpdateEntityRequest{
+		Properties: map[string]any{"status": "done", "points": 3},
+	}); err != nil {
+		t.Fatalf("UpdateEntity: %v", err)
+	}
+	if err := dm.DeleteEntity(ctx, eventsAgency, task.ID); err != nil {
+		t.Fatalf("DeleteEntity: %v", err)
+	}
+	_, events := rec.take()
+	if len(events) != 5 {
+		t.Fatalf("published %d events, want 5", len(events))
+	}
+	for _, e := range events {
+		if _, ok := set[e.Topic]; !ok {
+			t.Errorf("no schema for published topic %s", e.Topic)
+		}
+		if err := set.Validate(e.Topic, e.Payload); err != nil {
+			t.Errorf("payload of %s: %v", e.Topic, err)
+		}
+	}
+}
+
+func TestTopicSchemas_RejectsMismatchedPayloads(t *testing.T) {
+	set := entitygraph.TopicSchemas("work", eventsSchema())
+	entity := entitygraph.Entity{ID: "t1", AgencyID: eventsAgency, TypeID: "Task", Properties: map[string]any{"points": "three"}}
+	cases := map[string]entitygraph.EntityEvent{
+		"work.task.created":       {ID: "e1", Entity: entity},
+		"work.task.update.status": {ID: "e2", Entity: entitygraph.Entity{ID: "t1", AgencyID: eventsAgency, TypeID: "Task"}, Property: "points"},
+		"work.board.deleted":      {ID: "e3", Entity: entitygraph.Entity{ID: "b1", AgencyID: eventsAgency, TypeID: "Task"}},
+	}
+	for topic, payload := range cases {
+		err := set.Validate(topic, payload)
+		var verr *topicschema.ValidationError
+		if !errors.Is(err, topicschema.ErrInvalidPayload) || !errors.As(err, &verr) || verr.Topic != topic {
+			t.Errorf("Validate(%s): got %v, want a ValidationError for the topic", topic, err)
+		}
+	}
+}
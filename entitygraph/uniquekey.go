@@ -0,0 +1,43 @@
+// uniquekey.go — enforcement of TypeDefinition.UniqueKey.
+//
+// A type's UniqueKey is both the lookup key of UpsertEntity and a uniqueness
+// constraint on every write: CreateEntity, UpdateEntity, UpsertEntity and
+// RestoreEntity return [ErrEntityAlreadyExists] rather than leave two live
+// entities of the type in one agency holding the same key values.
+// [UniqueKeyOf] defines when two entities collide, so that every backend —
+// whether it enforces the constraint with an index or a scan — agrees.
+package entitygraph
+
+import (
+	"encoding/json"
+
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+// UniqueKeyOf returns the canonical form of the UniqueKey values in props for
+// type td: the type name followed by the JSON encoding of the values in
+// UniqueKey order. Two live entities of the same agency collide exactly when
+// their canonical keys are equal.
+//
+// ok is false when td has no UniqueKey, or when any key value is absent or
+// null; such entities are not subject to the constraint.
+func UniqueKeyOf(td types.TypeDefinition, props map[string]any) (key string, ok bool) {
+	if len(td.UniqueKey) == 0 {
+		return "", false
+	}
+	values := make([]any, len(td.UniqueKey))
+	for i, field := range td.UniqueKey {
+		v, present := props[field]
+		if !present || v == nil {
+			return "", false
+		}
+		values[i] = v
+	}
+	// Numbers encode identically whether they are Go ints or the float64s
+	// produced by JSON decoding, so stored and requested values compare equal.
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return "", false
+	}
+	return td.Name + ":" + string(raw), true
+}
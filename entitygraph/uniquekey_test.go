@@ -0,0 +1,35 @@
+package entitygraph_test
+
+import (
+	"testing"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+func TestUniqueKeyOf(t *testing.T) {
+	td := types.TypeDefinition{Name: "Goal", UniqueKey: []string{"code", "year"}}
+
+	a, ok := entitygraph.UniqueKeyOf(td, map[string]any{"code": "G1", "year": 2024, "title": "x"})
+	if !ok {
+		t.Fatal("complete key reported as exempt")
+	}
+	// Decoded JSON numbers and extra properties must not change the key.
+	b, _ := entitygraph.UniqueKeyOf(td, map[string]any{"code": "G1", "year": float64(2024)})
+	if a != b {
+		t.Errorf("keys differ: %q vs %q", a, b)
+	}
+	other, _ := entitygraph.UniqueKeyOf(types.TypeDefinition{Name: "Task", UniqueKey: td.UniqueKey}, map[string]any{"code": "G1", "year": 2024})
+	if other == a {
+		t.Errorf("keys of different types are equal: %q", a)
+	}
+
+	for _, props := range []map[string]any{{"code": "G1"}, {"code": "G1", "year": nil}} {
+		if key, ok := entitygraph.UniqueKeyOf(td, props); ok {
+			t.Errorf("UniqueKeyOf(%v) = %q, want exempt", props, key)
+		}
+	}
+	if _, ok := entitygraph.UniqueKeyOf(types.TypeDefinition{Name: "Note"}, map[string]any{"code": "G1"}); ok {
+		t.Error("type without UniqueKey reported a key")
+	}
+}
@@ -0,0 +1,266 @@
+// validation.go — schema-driven property validation shared by every
+// DataManager backend.
+//
+// Backends call [ValidateProperties] before persisting Properties on
+// CreateEntity, UpdateEntity, and UpsertEntity so that a rating outside its
+// range, an option outside its Options, or a missing Required field is
+// rejected identically regardless of the storage engine.
+package entitygraph
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+// ErrInvalidProperties is returned (wrapped in a [*ValidationError]) by
+// CreateEntity, UpdateEntity, and UpsertEntity when the supplied Properties do
+// not conform to the entity's TypeDefinition.
+var ErrInvalidProperties = errors.New("invalid properties")
+
+// ValidationMode selects how [ValidateProperties] treats absent properties.
+type ValidationMode int
+
+const (
+	// ValidateCreate validates a complete property set: every property declared
+	// Required must be present with a non-nil value.
+	ValidateCreate ValidationMode = iota
+
+	// ValidatePatch validates a partial update: only the keys present in props
+	// are checked, and a Required property may not be cleared by patching it
+	// to nil.
+	ValidatePatch
+)
+
+// PropertyViolation describes a single property that failed validation.
+type PropertyViolation struct {
+	// Property is the property name. Array elements are reported as
+	// "name[i]".
+	Property string
+
+	// Reason is a human-readable description of the violation
+	// (e.g. "must be between 1 and 5").
+	Reason string
+}
+
+// ValidationError is the structured error returned by [ValidateProperties].
+// It lists every violation found, in PropertyDefinition declaration order, so
+// callers can report all field errors at once.
+//
+// errors.Is(err, ErrInvalidProperties) reports true for any *ValidationError.
+type ValidationError struct {
+	// TypeID is the TypeDefinition.Name the properties were validated against.
+	TypeID string
+
+	// Violations lists every failing property; never empty.
+	Violations []PropertyViolation
+}
+
+// Error implements error.
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		parts[i] = v.Property + ": " + v.Reason
+	}
+	return fmt.Sprintf("%s for type %q: %s", ErrInvalidProperties, e.TypeID, strings.Join(parts, "; "))
+}
+
+// Unwrap returns [ErrInvalidProperties].
+func (e *ValidationError) Unwrap() error { return ErrInvalidProperties }
+
+// ValidateProperties checks props against the PropertyDefinitions declared on
+// td. Must be called by every DataManager backend before writing entity
+// properties.
+//
+// Rules enforced:
+//  1. In [ValidateCreate] mode every Required property is present and non-nil;
+//     in [ValidatePatch] mode a Required property present in props is non-nil.
+//  2. Every non-nil value matches its PropertyType:
+//     - string, select       — a string
+//     - integer              — an integral number
+//     - float, number        — any number
+//     - boolean              — a bool
+//     - date                 — a string in "2006-01-02" form
+//     - datetime             — an RFC 3339 string or a time.Time
+//     - uuid                 — a canonical RFC 4122 UUID string
+//     - option               — a string contained in Options
+//     - multiselect          — an array of strings
+//     - rating               — an integer within RatingConfig.Min..Max
+//     - array                — an array whose elements match ElementType
+//     (any JSON value when ElementType is empty)
+//
+// Properties not declared on td are passed through unchecked, and a nil value
+// for a non-Required property is permitted (it clears the property).
+//
+// Returns a [*ValidationError] listing every violation, or nil.
+func ValidateProperties(td types.TypeDefinition, props map[string]any, mode ValidationMode) error {
+	var violations []PropertyViolation
+	for _, pd := range td.Properties {
+		v, present := props[pd.Name]
+		if !present || v == nil {
+			if pd.Required && (mode == ValidateCreate || present) {
+				violations = append(violations, PropertyViolation{Property: pd.Name, Reason: "is required"})
+			}
+			continue
+		}
+		violations = append(violations, checkProperty(pd, pd.Name, v)...)
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return &ValidationError{TypeID: td.Name, Violations: violations}
+}
+
+// checkProperty validates a single non-nil value against pd, reporting
+// violations under name.
+func checkProperty(pd types.PropertyDefinition, name string, v any) []PropertyViolation {
+	if pd.Type != types.PropertyTypeArray && pd.Type != types.PropertyTypeMultiSelect {
+		if reason := checkScalar(pd, pd.Type, v); reason != "" {
+			return []PropertyViolation{{Property: name, Reason: reason}}
+		}
+		return nil
+	}
+	elems, ok := asSlice(v)
+	if !ok {
+		return []PropertyViolation{{Property: name, Reason: "must be an array"}}
+	}
+	elemType := pd.ElementType
+	if pd.Type == types.PropertyTypeMultiSelect {
+		elemType = types.PropertyTypeString
+	}
+	if elemType == "" {
+		return nil
+	}
+	var violations []PropertyViolation
+	for i, elem := range elems {
+		reason := "must not be null"
+		if elem != nil {
+			reason = checkScalar(pd, elemType, elem)
+		}
+		if reason != "" {
+			violations = append(violations, PropertyViolation{
+				Property: fmt.Sprintf("%s[%d]", name, i),
+				Reason:   reason,
+			})
+		}
+	}
+	return violations
+}
+
+// checkScalar validates a single non-nil value of type pt, using pd for
+// Options and RatingConfig. Returns "" when v is valid, otherwise the reason.
+func checkScalar(pd types.PropertyDefinition, pt types.PropertyType, v any) string {
+	switch pt {
+	case types.PropertyTypeString, types.PropertyTypeSelect:
+		if _, ok := v.(string); !ok {
+			return "must be a string"
+		}
+	case types.PropertyTypeInteger:
+		if !isInteger(v) {
+			return "must be an integer"
+		}
+	case types.PropertyTypeFloat, types.PropertyTypeNumber:
+		if _, ok := asFloat(v); !ok {
+			return "must be a number"
+		}
+	case types.PropertyTypeBoolean:
+		if _, ok := v.(bool); !ok {
+			return "must be a boolean"
+		}
+	case types.PropertyTypeDate:
+		s, ok := v.(string)
+		if !ok {
+			return "must be a date string (YYYY-MM-DD)"
+		}
+		if _, err := time.Parse(time.DateOnly, s); err != nil {
+			return "must be a date string (YYYY-MM-DD)"
+		}
+	case types.PropertyTypeDatetime:
+		if _, ok := v.(time.Time); ok {
+			return ""
+		}
+		s, ok := v.(string)
+		if !ok {
+			return "must be an RFC 3339 datetime string"
+		}
+		if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+			return "must be an RFC 3339 datetime string"
+		}
+	case types.PropertyTypeUUID:
+		s, ok := v.(string)
+		if !ok || len(s) != 36 {
+			return "must be a UUID string"
+		}
+		if _, err := uuid.Parse(s); err != nil {
+			return "must be a UUID string"
+		}
+	case types.PropertyTypeOption:
+		s, ok := v.(string)
+		if !ok {
+			return "must be a string"
+		}
+		if !slices.Contains(pd.Options, s) {
+			return fmt.Sprintf("must be one of [%s]", strings.Join(pd.Options, ", "))
+		}
+	case types.PropertyTypeRating:
+		if !isInteger(v) {
+			return "must be an integer"
+		}
+		if rc := pd.RatingConfig; rc != nil {
+			f, _ := asFloat(v)
+			if f < float64(rc.Min) || f > float64(rc.Max) {
+				return fmt.Sprintf("must be between %d and %d", rc.Min, rc.Max)
+			}
+		}
+	case types.PropertyTypeArray, types.PropertyTypeMultiSelect:
+		return "nested arrays are not supported"
+	}
+	return ""
+}
+
+// asFloat returns v as a float64 when v is any Go numeric type.
+func asFloat(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		return f, !math.IsNaN(f) && !math.IsInf(f, 0)
+	}
+	return 0, false
+}
+
+// isInteger reports whether v is a number with no fractional part. JSON-decoded
+// integers arrive as float64, so whole floats are accepted.
+func isInteger(v any) bool {
+	f, ok := asFloat(v)
+	return ok && f == math.Trunc(f)
+}
+
+// asSlice returns the elements of v when v is a slice or array of any
+// element type (e.g. []any from JSON decoding, or a native []string).
+func asSlice(v any) ([]any, bool) {
+	if s, ok := v.([]any); ok {
+		return s, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	out := make([]any, rv.Len())
+	for i := range out {
+		out[i] = rv.Index(i).Interface()
+	}
+	return out, true
+}
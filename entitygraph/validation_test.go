@@ -0,0 +1,228 @@
+package entitygraph_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+// validationTypeDef declares one property of every PropertyType.
+func validationTypeDef() types.TypeDefinition {
+	return types.TypeDefinition{
+		Name: "Sample",
+		Properties: []types.PropertyDefinition{
+			{Name: "name", Type: types.PropertyTypeString, Required: true},
+			{Name: "count", Type: types.PropertyTypeInteger},
+			{Name: "ratio", Type: types.PropertyTypeFloat},
+			{Name: "hours", Type: types.PropertyTypeNumber},
+			{Name: "due", Type: types.PropertyTypeDate},
+			{Name: "at", Type: types.PropertyTypeDatetime},
+			{Name: "done", Type: types.PropertyTypeBoolean},
+			{Name: "ref", Type: types.PropertyTypeUUID},
+			{Name: "status", Type: types.PropertyTypeOption, Options: []string{"open", "closed"}},
+			{Name: "owner", Type: types.PropertyTypeSelect},
+			{Name: "labels", Type: types.PropertyTypeMultiSelect},
+			{Name: "score", Type: types.PropertyTypeRating, RatingConfig: &types.RatingConfig{Min: 1, Max: 5}},
+			{Name: "scores", Type: types.PropertyTypeArray, ElementType: types.PropertyTypeRating, RatingConfig: &types.RatingConfig{Min: 1, Max: 5}},
+			{Name: "anything", Type: types.PropertyTypeArray},
+		},
+	}
+}
+
+func TestValidateProperties_AllTypesValid_NoError(t *testing.T) {
+	props := map[string]any{
+		"name":     "x",
+		"count":    float64(3), // JSON-decoded integer
+		"ratio":    0.5,
+		"hours":    int64(8),
+		"due":      "2026-01-15",
+		"at":       "2026-01-15T10:30:00Z",
+		"done":     true,
+		"ref":      "550e8400-e29b-41d4-a716-446655440000",
+		"status":   "open",
+		"owner":    "alice",
+		"labels":   []string{"a", "b"},
+		"score":    5,
+		"scores":   []any{1.0, 2.0},
+		"anything": []any{1, "two", nil},
+	}
+	if err := entitygraph.ValidateProperties(validationTypeDef(), props, entitygraph.ValidateCreate); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateProperties_DatetimeAcceptsTimeValue(t *testing.T) {
+	props := map[string]any{"name": "x", "at": time.Now()}
+	if err := entitygraph.ValidateProperties(validationTypeDef(), props, entitygraph.ValidateCreate); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateProperties_InvalidValues(t *testing.T) {
+	cases := []struct {
+		key      string
+		value    any
+		property string
+		reason   string
+	}{
+		{"name", 7, "name", "string"},
+		{"count", 1.5, "count", "integer"},
+		{"count", "3", "count", "integer"},
+		{"ratio", "0.5", "ratio", "number"},
+		{"due", "2026-13-01", "due", "date"},
+		{"due", "2026-01-15T10:30:00Z", "due", "date"},
+		{"at", "2026-01-15", "at", "RFC 3339"},
+		{"done", "true", "done", "boolean"},
+		{"ref", "550e8400e29b41d4a716446655440000", "ref", "UUID"},
+		{"status", "pending", "status", "one of [open, closed]"},
+		{"owner", 1, "owner", "string"},
+		{"labels", "a", "labels", "array"},
+		{"labels", []any{"a", 1}, "labels[1]", "string"},
+		{"score", 0, "score", "between 1 and 5"},
+		{"score", 2.5, "score", "integer"},
+		{"scores", []any{1, 6}, "scores[1]", "between 1 and 5"},
+		{"scores", []any{nil}, "scores[0]", "null"},
+		{"anything", map[string]any{}, "anything", "array"},
+	}
+	for _, c := range cases {
+		t.Run(c.property, func(t *testing.T) {
+			props := map[string]any{"name": "x", c.key: c.value}
+			err := entitygraph.ValidateProperties(validationTypeDef(), props, entitygraph.ValidateCreate)
+			var ve *entitygraph.ValidationError
+			if !errors.As(err, &ve) {
+				t.Fatalf("got %v, want *ValidationError", err)
+			}
+			if len(ve.Violations) != 1 {
+				t.Fatalf("got %d violations %+v, want 1", len(ve.Violations), ve.Violations)
+			}
+			v := ve.Violations[0]
+			if v.Property != c.property || !strings.Contains(v.Reason, c.reason) {
+				t.Errorf("got %s: %s, want %s containing %q", v.Property, v.Reason, c.property, c.reason)
+			}
+		})
+	}
+}
+
+func TestValidateProperties_RequiredMissing_CreateMode(t *testing.T) {
+	err := entitygraph.ValidateProperties(validationTypeDef(), map[string]any{"count": 1}, entitygraph.ValidateCreate)
+	if !errors.Is(err, entitygraph.ErrInvalidProperties) {
+		t.Fatalf("got %v, want ErrInvalidProperties", err)
+	}
+	if !strings.Contains(err.Error(), "name: is required") {
+		t.Errorf("error %q does not mention the missing property", err)
+	}
+}
+
+func TestValidateProperties_RequiredNil_CreateMode(t *testing.T) {
+	err := entitygraph.ValidateProperties(validationTypeDef(), map[string]any{"name": nil}, entitygraph.ValidateCreate)
+	if !errors.Is(err, entitygraph.ErrInvalidProperties) {
+		t.Fatalf("got %v, want ErrInvalidProperties", err)
+	}
+}
+
+func TestValidateProperties_RequiredAbsent_PatchModeAllowed(t *testing.T) {
+	err := entitygraph.ValidateProperties(validationTypeDef(), map[string]any{"count": 2}, entitygraph.ValidatePatch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateProperties_RequiredCleared_PatchModeRejected(t *testing.T) {
+	err := entitygraph.ValidateProperties(validationTypeDef(), map[string]any{"name": nil}, entitygraph.ValidatePatch)
+	if !errors.Is(err, entitygraph.ErrInvalidProperties) {
+		t.Fatalf("got %v, want ErrInvalidProperties", err)
+	}
+}
+
+func TestValidateProperties_OptionalNil_Allowed(t *testing.T) {
+	err := entitygraph.ValidateProperties(validationTypeDef(), map[string]any{"name": "x", "score": nil}, entitygraph.ValidateCreate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateProperties_UndeclaredProperty_PassesThrough(t *testing.T) {
+	err := entitygraph.ValidateProperties(validationTypeDef(), map[string]any{"name": "x", "extra": struct{}{}}, entitygraph.ValidateCreate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateProperties_MultipleViolations_ReportedInDeclarationOrder(t *testing.T) {
+	props := map[string]any{"score": 9, "count": "x"}
+	err := entitygraph.ValidateProperties(validationTypeDef(), props, entitygraph.ValidateCreate)
+	var ve *entitygraph.ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("got %v, want *ValidationError", err)
+	}
+	var got []string
+	for _, v := range ve.Violations {
+		got = append(got, v.Property)
+	}
+	want := []string{"name", "count", "score"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("violations = %v, want %v", got, want)
+	}
+	if ve.TypeID != "Sample" {
+		t.Errorf("TypeID = %q, want Sample", ve.TypeID)
+	}
+}
+
+// ── ValidateEntityRelationships / ValidateRequiredRelationships ─────────────
+
+func TestValidateEntityRelationships_Valid_NoError(t *testing.T) {
+	agency, _ := entitygraph.FindTypeDef(testSchema(), "Agency")
+	rels := []entitygraph.EntityRelationshipRequest{
+		{Name: "has_goal", ToID: "g1"},
+		{Name: "has_goal", ToID: "g2"},
+		{Name: "has_workflow", ToID: "w1"},
+	}
+	if err := entitygraph.ValidateEntityRelationships(agency, rels, []string{"Goal", "Goal", "Workflow"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateEntityRelationships_WrongTarget_ReturnsErrInvalidRelationship(t *testing.T) {
+	agency, _ := entitygraph.FindTypeDef(testSchema(), "Agency")
+	rels := []entitygraph.EntityRelationshipRequest{{Name: "has_goal", ToID: "w1"}}
+	err := entitygraph.ValidateEntityRelationships(agency, rels, []string{"Workflow"})
+	if !errors.Is(err, entitygraph.ErrInvalidRelationship) {
+		t.Errorf("got %v, want ErrInvalidRelationship", err)
+	}
+}
+
+func TestValidateEntityRelationships_DuplicateToOne_ReturnsCardinalityViolation(t *testing.T) {
+	goal, _ := entitygraph.FindTypeDef(testSchema(), "Goal")
+	rels := []entitygraph.EntityRelationshipRequest{
+		{Name: "belongs_to_agency", ToID: "a1"},
+		{Name: "belongs_to_agency", ToID: "a2"},
+	}
+	err := entitygraph.ValidateEntityRelationships(goal, rels, []string{"Agency", "Agency"})
+	if !errors.Is(err, entitygraph.ErrRelationshipCardinalityViolation) {
+		t.Errorf("got %v, want ErrRelationshipCardinalityViolation", err)
+	}
+}
+
+func TestValidateRequiredRelationships(t *testing.T) {
+	td := types.TypeDefinition{
+		Name: "Task",
+		Relationships: []types.RelationshipDefinition{
+			{Name: "part_of", ToType: "Goal", Required: true},
+			{Name: "tagged", ToType: "Agency", ToMany: true},
+		},
+	}
+	if err := entitygraph.ValidateRequiredRelationships(td, []entitygraph.EntityRelationshipRequest{{Name: "part_of", ToID: "g1"}}); err != nil {
+		t.Errorf("required supplied: unexpected error: %v", err)
+	}
+	err := entitygraph.ValidateRequiredRelationships(td, []entitygraph.EntityRelationshipRequest{{Name: "tagged", ToID: "a1"}})
+	if !errors.Is(err, entitygraph.ErrRequiredRelationshipViolation) {
+		t.Errorf("required missing: got %v, want ErrRequiredRelationshipViolation", err)
+	}
+	if !strings.Contains(err.Error(), "part_of") {
+		t.Errorf("error %q does not name the missing relationship", err)
+	}
+}
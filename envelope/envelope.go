@@ -0,0 +1,432 @@
+// Package envelope is the canonical CodeVald event envelope, compatible with
+// CloudEvents 1.0 (https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/spec.md).
+//
+// An [Envelope] carries an event's data together with its identity and
+// context: a unique ID, the producing service (Source), the topic (Type),
+// the owning agency, a correlation ID and the payload schema version. It
+// has two encodings:
+//
+//   - structured mode — the whole envelope as one JSON document
+//     (application/cloudevents+json); see [Envelope.MarshalJSON]. This is
+//     how envelopes travel in the payload string of
+//     registrar.Registrar.Publish and NotifyEventRequest.
+//   - binary mode — the attributes as "ce-" headers and the data as the
+//     body; see [ToBinary] and [FromBinary] for HTTP headers, and
+//     [ToMetadata] and [FromMetadata] for gRPC metadata.
+//
+// eventbus.EnvelopeFromEvent and eventbus.EventFromEnvelope map between
+// envelopes and eventbus.Event; registrar.PublishEnvelope sends one through
+// CodeValdCross, as eventbus.NewCrossPublisher and the entitygraph outbox
+// relay do for every event; eventreceiver unwraps envelopes found in
+// NotifyEvent requests.
+package envelope
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SpecVersion is the CloudEvents version of the envelopes this package
+// produces and accepts.
+const SpecVersion = "1.0"
+
+// Content types.
+const (
+	// ContentTypeJSON is the default DataContentType.
+	ContentTypeJSON = "application/json"
+
+	// ContentTypeStructured is the media type of a structured-mode envelope.
+	ContentTypeStructured = "application/cloudevents+json"
+)
+
+// Extension attribute names of the CodeVald-specific envelope fields.
+const (
+	ExtAgencyID      = "agencyid"
+	ExtCorrelationID = "correlationid"
+	ExtSchemaVersion = "schemaversion"
+)
+
+// headerPrefix prefixes every attribute header in binary mode.
+const headerPrefix = "ce-"
+
+// maxExtensionNameLen is the longest extension name CloudEvents recommends.
+const maxExtensionNameLen = 20
+
+// ErrInvalidEnvelope is returned when an envelope lacks a required attribute,
+// has a malformed one, or cannot be decoded.
+var ErrInvalidEnvelope = errors.New("invalid event envelope")
+
+// Envelope is one event in CloudEvents 1.0 form. ID, Source, SpecVersion and
+// Type are required; the rest are optional.
+type Envelope struct {
+	// ID identifies the event; unique per Source. Consumers use it to drop
+	// duplicates.
+	ID string
+
+	// Source names the producing service, e.g. "codevaldwork".
+	Source string
+
+	// SpecVersion is the CloudEvents version; always SpecVersion.
+	SpecVersion string
+
+	// Type is the topic, e.g. "work.task.created".
+	Type string
+
+	// DataContentType is the media type of Data. Empty means
+	// ContentTypeJSON.
+	DataContentType string
+
+	// DataSchema optionally identifies the schema Data adheres to.
+	DataSchema string
+
+	// Subject optionally names what the event is about within Source, e.g.
+	// the entity ID.
+	Subject string
+
+	// Time is when the event occurred; zero when unknown.
+	Time time.Time
+
+	// AgencyID is the owning agency (extension "agencyid").
+	AgencyID string
+
+	// CorrelationID ties together the events caused by one request
+	// (extension "correlationid").
+	CorrelationID string
+
+	// SchemaVersion is the version of the payload schema of Type, so
+	// consumers can tell payload shapes apart (extension "schemaversion").
+	SchemaVersion string
+
+	// Extensions holds any other extension attributes, keyed by their
+	// lowercase alphanumeric names.
+	Extensions map[string]string
+
+	// Data is the encoded event payload; JSON unless DataContentType says
+	// otherwise. Nil means no data.
+	Data []byte
+}
+
+// Validate reports whether e can be encoded: the required attributes are
+// set, SpecVersion is SpecVersion, and extension names are lowercase
+// alphanumeric and at most 20 characters. Returns an error wrapping
+// ErrInvalidEnvelope, or nil.
+func (e Envelope) Validate() error {
+	var missing []string
+	for _, a := range []struct{ name, value string }{
+		{"id", e.ID}, {"source", e.Source}, {"specversion", e.SpecVersion}, {"type", e.Type},
+	} {
+		if a.value == "" {
+			missing = append(missing, a.name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: missing %s", ErrInvalidEnvelope, strings.Join(missing, ", "))
+	}
+	if e.SpecVersion != SpecVersion {
+		return fmt.Errorf("%w: unsupported specversion %q", ErrInvalidEnvelope, e.SpecVersion)
+	}
+	for name := range e.Extensions {
+		if !validExtensionName(name) {
+			return fmt.Errorf("%w: extension name %q must be 1-20 lowercase letters or digits", ErrInvalidEnvelope, name)
+		}
+		if isReserved(name) {
+			return fmt.Errorf("%w: extension name %q is a reserved attribute", ErrInvalidEnvelope, name)
+		}
+	}
+	return nil
+}
+
+// isJSON reports whether Data is JSON according to DataContentType.
+func (e Envelope) isJSON() bool {
+	ct := strings.TrimSpace(strings.SplitN(e.DataContentType, ";", 2)[0])
+	return ct == "" || ct == ContentTypeJSON || strings.HasSuffix(ct, "+json")
+}
+
+// attributes returns every set attribute except data, as strings keyed by
+// attribute name.
+func (e Envelope) attributes() map[string]string {
+	attrs := make(map[string]string, 8+len(e.Extensions))
+	maps.Copy(attrs, e.Extensions)
+	set := func(name, value string) {
+		if value != "" {
+			attrs[name] = value
+		}
+	}
+	set("id", e.ID)
+	set("source", e.Source)
+	set("specversion", e.SpecVersion)
+	set("type", e.Type)
+	set("datacontenttype", e.DataContentType)
+	set("dataschema", e.DataSchema)
+	set("subject", e.Subject)
+	if !e.Time.IsZero() {
+		attrs["time"] = e.Time.UTC().Format(time.RFC3339Nano)
+	}
+	set(ExtAgencyID, e.AgencyID)
+	set(ExtCorrelationID, e.CorrelationID)
+	set(ExtSchemaVersion, e.SchemaVersion)
+	return attrs
+}
+
+// fromAttributes builds an envelope from attribute strings, as produced by
+// attributes, and validates it.
+func fromAttributes(attrs map[string]string) (Envelope, error) {
+	e := Envelope{
+		ID:              attrs["id"],
+		Source:          attrs["source"],
+		SpecVersion:     attrs["specversion"],
+		Type:            attrs["type"],
+		DataContentType: attrs["datacontenttype"],
+		DataSchema:      attrs["dataschema"],
+		Subject:         attrs["subject"],
+		AgencyID:        attrs[ExtAgencyID],
+		CorrelationID:   attrs[ExtCorrelationID],
+		SchemaVersion:   attrs[ExtSchemaVersion],
+	}
+	if t := attrs["time"]; t != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return Envelope{}, fmt.Errorf("%w: time %q is not RFC 3339", ErrInvalidEnvelope, t)
+		}
+		e.Time = parsed
+	}
+	for name, value := range attrs {
+		if !isReserved(name) {
+			if e.Extensions == nil {
+				e.Extensions = make(map[string]string)
+			}
+			e.Extensions[name] = value
+		}
+	}
+	if err := e.Validate(); err != nil {
+		return Envelope{}, err
+	}
+	return e, nil
+}
+
+// MarshalJSON encodes e in structured mode. JSON data is embedded as the
+// "data" member; any other data is base64-encoded as "data_base64".
+func (e Envelope) MarshalJSON() ([]byte, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	doc := make(map[string]any, 12)
+	for name, value := range e.attributes() {
+		doc[name] = value
+	}
+	if e.Data != nil {
+		if e.isJSON() {
+			if !json.Valid(e.Data) {
+				return nil, fmt.Errorf("%w: data is not valid JSON", ErrInvalidEnvelope)
+			}
+			doc["data"] = json.RawMessage(e.Data)
+		} else {
+			doc["data_base64"] = base64.StdEncoding.EncodeToString(e.Data)
+		}
+	}
+	return json.Marshal(doc)
+}
+
+// UnmarshalJSON decodes a structured-mode envelope. Extension attributes
+// that are JSON numbers or booleans are kept in their JSON text form.
+func (e *Envelope) UnmarshalJSON(data []byte) error {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	attrs := make(map[string]string, len(doc))
+	for name, raw := range doc {
+		if name == "data" || name == "data_base64" {
+			continue
+		}
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			s = string(raw)
+		}
+		attrs[name] = s
+	}
+	out, err := fromAttributes(attrs)
+	if err != nil {
+		return err
+	}
+	if raw, ok := doc["data_base64"]; ok {
+		var b64 string
+		if err := json.Unmarshal(raw, &b64); err != nil {
+			return fmt.Errorf("%w: data_base64 is not a string", ErrInvalidEnvelope)
+		}
+		if out.Data, err = base64.StdEncoding.DecodeString(b64); err != nil {
+			return fmt.Errorf("%w: data_base64: %v", ErrInvalidEnvelope, err)
+		}
+	} else if raw, ok := doc["data"]; ok {
+		if out.isJSON() {
+			out.Data = []byte(raw)
+		} else {
+			// Non-JSON data carried in "data" is a JSON string.
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return fmt.Errorf("%w: data of type %s must be a string", ErrInvalidEnvelope, out.DataContentType)
+			}
+			out.Data = []byte(s)
+		}
+	}
+	*e = out
+	return nil
+}
+
+// Structured reports whether data looks like a structured-mode envelope: a
+// JSON object holding a string "specversion" member. Use it to tell
+// envelopes from bare payloads before calling UnmarshalJSON.
+func Structured(data []byte) bool {
+	var probe struct {
+		SpecVersion *string `json:"specversion"`
+	}
+	trimmed := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(trimmed, "{") || json.Unmarshal([]byte(trimmed), &probe) != nil {
+		return false
+	}
+	return probe.SpecVersion != nil
+}
+
+// ToBinary encodes e in binary mode for HTTP: every attribute becomes a
+// "ce-<name>" header, DataContentType becomes "content-type", and Data is
+// the body. Header values are percent-encoded as the CloudEvents HTTP
+// binding requires.
+func ToBinary(e Envelope) (headers map[string]string, body []byte, err error) {
+	return toBinary(e, true)
+}
+
+// ToMetadata encodes e in binary mode for gRPC metadata. It is ToBinary
+// except that DataContentType travels as "ce-datacontenttype": gRPC owns
+// "content-type" and always sets it to its own "application/grpc".
+func ToMetadata(e Envelope) (md map[string]string, body []byte, err error) {
+	return toBinary(e, false)
+}
+
+// toBinary implements ToBinary and, without contentType, ToMetadata.
+func toBinary(e Envelope, contentType bool) (map[string]string, []byte, error) {
+	if err := e.Validate(); err != nil {
+		return nil, nil, err
+	}
+	attrs := e.attributes()
+	headers := make(map[string]string, len(attrs))
+	for name, value := range attrs {
+		if name == "datacontenttype" && contentType {
+			headers["content-type"] = value
+			continue
+		}
+		headers[headerPrefix+name] = percentEncode(value)
+	}
+	return headers, e.Data, nil
+}
+
+// FromBinary decodes a binary-mode envelope from its HTTP headers, matched
+// case-insensitively, and body. Headers other than "ce-*" and
+// "content-type" are ignored.
+func FromBinary(headers map[string]string, body []byte) (Envelope, error) {
+	return fromBinary(headers, body, true)
+}
+
+// FromMetadata decodes a binary-mode envelope from gRPC metadata, as
+// produced by ToMetadata, and body. Entries other than "ce-*" are ignored,
+// "content-type" included, since it describes the gRPC message rather than
+// the event data.
+func FromMetadata(md map[string]string, body []byte) (Envelope, error) {
+	return fromBinary(md, body, false)
+}
+
+// fromBinary implements FromBinary and, without contentType, FromMetadata.
+func fromBinary(headers map[string]string, body []byte, contentType bool) (Envelope, error) {
+	attrs := make(map[string]string, len(headers))
+	for name, value := range headers {
+		lower := strings.ToLower(name)
+		switch {
+		case lower == "content-type" && contentType:
+			attrs["datacontenttype"] = value
+		case strings.HasPrefix(lower, headerPrefix):
+			decoded, err := percentDecode(value)
+			if err != nil {
+				return Envelope{}, fmt.Errorf("%w: header %s: %v", ErrInvalidEnvelope, name, err)
+			}
+			attrs[strings.TrimPrefix(lower, headerPrefix)] = decoded
+		}
+	}
+	e, err := fromAttributes(attrs)
+	if err != nil {
+		return Envelope{}, err
+	}
+	if len(body) > 0 {
+		e.Data = body
+	}
+	return e, nil
+}
+
+// reserved lists the attribute names that are not extensions.
+var reserved = map[string]bool{
+	"id": true, "source": true, "specversion": true, "type": true,
+	"datacontenttype": true, "dataschema": true, "subject": true, "time": true,
+	"data": true, "data_base64": true,
+	ExtAgencyID: true, ExtCorrelationID: true, ExtSchemaVersion: true,
+}
+
+// isReserved reports whether name is a context attribute with its own
+// Envelope field.
+func isReserved(name string) bool { return reserved[name] }
+
+// validExtensionName reports whether name is 1-20 lowercase ASCII letters or
+// digits.
+func validExtensionName(name string) bool {
+	if name == "" || len(name) > maxExtensionNameLen {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// percentEncode escapes the bytes of s outside printable ASCII, plus space,
+// '"' and '%'.
+func percentEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c > '~' || c == '"' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// percentDecode reverses percentEncode.
+func percentDecode(s string) (string, error) {
+	if !strings.Contains(s, "%") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+2 >= len(s) {
+			return "", fmt.Errorf("truncated escape in %q", s)
+		}
+		c, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("bad escape in %q", s)
+		}
+		b.WriteByte(byte(c))
+		i += 2
+	}
+	return b.String(), nil
+}
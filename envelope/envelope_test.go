@@ -0,0 +1,166 @@
+package envelope_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aosanya/CodeValdSharedLib/envelope"
+)
+
+func sample() envelope.Envelope {
+	return envelope.Envelope{
+		ID:              "e-1",
+		Source:          "codevaldwork",
+		SpecVersion:     envelope.SpecVersion,
+		Type:            "work.task.created",
+		DataContentType: envelope.ContentTypeJSON,
+		Subject:         "task/42",
+		Time:            time.Date(2026, 3, 1, 12, 30, 0, 123456789, time.UTC),
+		AgencyID:        "ag-1",
+		CorrelationID:   "req 7 — \"retry\"",
+		SchemaVersion:   "2",
+		Extensions:      map[string]string{"traceparent": "00-abc-01"},
+		Data:            []byte(`{"taskId":"t-1"}`),
+	}
+}
+
+func TestStructured_RoundTrip(t *testing.T) {
+	in := sample()
+	raw, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var doc map[string]any
+	_ = json.Unmarshal(raw, &doc)
+	if doc["specversion"] != "1.0" || doc["type"] != in.Type || doc["agencyid"] != in.AgencyID {
+		t.Errorf("structured document %s lacks its attributes", raw)
+	}
+	if data, ok := doc["data"].(map[string]any); !ok || data["taskId"] != "t-1" {
+		t.Errorf("data = %v, want the embedded JSON object", doc["data"])
+	}
+	if !envelope.Structured(raw) {
+		t.Error("Structured(encoded envelope) = false")
+	}
+
+	var out envelope.Envelope
+	if err := json.Unmarshal(raw, &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("round trip = %+v, want %+v", out, in)
+	}
+}
+
+func TestStructured_BinaryDataUsesBase64(t *testing.T) {
+	in := sample()
+	in.DataContentType = "application/octet-stream"
+	in.Data = []byte{0x00, 0xff, 0x10}
+	raw, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if !strings.Contains(string(raw), `"data_base64":"AP8Q"`) {
+		t.Errorf("encoded = %s, want data_base64", raw)
+	}
+	var out envelope.Envelope
+	if err := json.Unmarshal(raw, &out); err != nil || !reflect.DeepEqual(out, in) {
+		t.Errorf("round trip = %+v, %v; want %+v", out, err, in)
+	}
+}
+
+func TestBinary_RoundTrip(t *testing.T) {
+	in := sample()
+	headers, body, err := envelope.ToBinary(in)
+	if err != nil {
+		t.Fatalf("ToBinary: %v", err)
+	}
+	if headers["ce-type"] != in.Type || headers["content-type"] != envelope.ContentTypeJSON || string(body) != string(in.Data) {
+		t.Errorf("ToBinary = %v, %s", headers, body)
+	}
+	if got := headers["ce-correlationid"]; got != "req%207%20%E2%80%94%20%22retry%22" {
+		t.Errorf("ce-correlationid = %q, want percent-encoded", got)
+	}
+
+	// Header names are case-insensitive, as in HTTP.
+	mixed := map[string]string{"X-Other": "ignored"}
+	for k, v := range headers {
+		mixed[strings.ToUpper(k[:1])+k[1:]] = v
+	}
+	out, err := envelope.FromBinary(mixed, body)
+	if err != nil {
+		t.Fatalf("FromBinary: %v", err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("round trip = %+v, want %+v", out, in)
+	}
+}
+
+func TestMetadata_RoundTrip(t *testing.T) {
+	in := sample()
+	in.DataContentType = "text/plain"
+	md, body, err := envelope.ToMetadata(in)
+	if err != nil {
+		t.Fatalf("ToMetadata: %v", err)
+	}
+	if _, ok := md["content-type"]; ok || md["ce-datacontenttype"] != "text/plain" {
+		t.Errorf("ToMetadata = %v, want ce-datacontenttype and no content-type", md)
+	}
+
+	// gRPC sets its own content-type, which is not the data's.
+	md["content-type"] = "application/grpc"
+	out, err := envelope.FromMetadata(md, body)
+	if err != nil {
+		t.Fatalf("FromMetadata: %v", err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("round trip = %+v, want %+v", out, in)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := map[string]func(*envelope.Envelope){
+		"missing id":          func(e *envelope.Envelope) { e.ID = "" },
+		"missing type":        func(e *envelope.Envelope) { e.Type = "" },
+		"wrong specversion":   func(e *envelope.Envelope) { e.SpecVersion = "0.3" },
+		"uppercase extension": func(e *envelope.Envelope) { e.Extensions = map[string]string{"Trace": "x"} },
+		"reserved extension":  func(e *envelope.Envelope) { e.Extensions = map[string]string{"subject": "x"} },
+	}
+	for name, mutate := range cases {
+		e := sample()
+		mutate(&e)
+		if err := e.Validate(); !errors.Is(err, envelope.ErrInvalidEnvelope) {
+			t.Errorf("%s: Validate = %v, want ErrInvalidEnvelope", name, err)
+		}
+		if _, err := json.Marshal(e); err == nil {
+			t.Errorf("%s: Marshal succeeded", name)
+		}
+	}
+	if err := sample().Validate(); err != nil {
+		t.Errorf("Validate(sample) = %v", err)
+	}
+}
+
+func TestDecodeRejectsInvalidEnvelopes(t *testing.T) {
+	for _, doc := range []string{
+		`{"specversion":"1.0","id":"e","type":"t"}`,
+		`{"specversion":"1.0","id":"e","type":"t","source":"s","time":"yesterday"}`,
+		`[1,2]`,
+	} {
+		var e envelope.Envelope
+		if err := json.Unmarshal([]byte(doc), &e); !errors.Is(err, envelope.ErrInvalidEnvelope) {
+			t.Errorf("Unmarshal(%s) = %v, want ErrInvalidEnvelope", doc, err)
+		}
+	}
+	if _, err := envelope.FromBinary(map[string]string{"ce-specversion": "1.0"}, nil); !errors.Is(err, envelope.ErrInvalidEnvelope) {
+		t.Errorf("FromBinary without attributes = %v, want ErrInvalidEnvelope", err)
+	}
+	for _, bare := range []string{`{"taskId":"t-1"}`, `"text"`, ``} {
+		if envelope.Structured([]byte(bare)) {
+			t.Errorf("Structured(%q) = true", bare)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrQueueFull is returned by [AsyncPublisher.Publish] when the queue is full
+// and the overflow policy is [OverflowDrop]; the event was discarded.
+var ErrQueueFull = errors.New("eventbus: publish queue full")
+
+// ErrPublisherClosed is returned by [AsyncPublisher.Publish] after Close.
+var ErrPublisherClosed = errors.New("eventbus: publisher closed")
+
+// OverflowPolicy decides what [AsyncPublisher.Publish] does when the queue is
+// full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock makes Publish wait for room in the queue, or for its
+	// context to end. No event is lost, at the cost of back-pressure on the
+	// write path.
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowDrop makes Publish discard the event and return ErrQueueFull
+	// at once. The write path never waits on the bus.
+	OverflowDrop
+)
+
+// defaultQueueSize is the AsyncConfig.QueueSize used when it is not set.
+const defaultQueueSize = 1024
+
+// AsyncConfig configures an [AsyncPublisher].
+type AsyncConfig struct {
+	// QueueSize bounds the number of events waiting for delivery. Defaults
+	// to 1024.
+	QueueSize int
+
+	// Overflow is the policy applied when the queue is full. Defaults to
+	// OverflowBlock.
+	Overflow OverflowPolicy
+
+	// OnError is called with every event the wrapped Publisher failed to
+	// deliver. Defaults to logging the failure.
+	OnError func(Event, error)
+}
+
+// AsyncStats counts the events an [AsyncPublisher] has handled.
+type AsyncStats struct {
+	// Queued is the number of events waiting for delivery.
+	Queued int
+
+	// Published is the number of events delivered successfully.
+	Published uint64
+
+	// Failed is the number of events the wrapped Publisher returned an
+	// error for.
+	Failed uint64
+
+	// Dropped is the number of events discarded because the queue was full.
+	Dropped uint64
+
+	// Waiting is the number of Publish calls blocked on a full queue.
+	Waiting int
+}
+
+// queuedEvent is an event waiting in an AsyncPublisher's queue, with the
+// context it was published under.
+type queuedEvent struct {
+	ctx   context.Context
+	event Event
+}
+
+// AsyncPublisher is a [Publisher] that queues events and delivers them to a
+// wrapped Publisher from a background goroutine, one at a time and in the
+// order they were published. Create it with [NewAsyncPublisher] and call
+// Close on shutdown to flush the queue.
+type AsyncPublisher struct {
+	next      Publisher
+	cfg       AsyncConfig
+	queue     chan queuedEvent
+	done      chan struct{}
+	closing   chan struct{} // closed by Close to release blocked senders
+	mu        sync.RWMutex  // guards closed and senders.Add against Close
+	closed    bool
+	senders   sync.WaitGroup // Publish calls that may still send on queue
+	published atomic.Uint64
+	failed    atomic.Uint64
+	dropped   atomic.Uint64
+	waiting   atomic.Int64
+}
+
+// NewAsyncPublisher starts an AsyncPublisher delivering to next.
+func NewAsyncPublisher(next Publisher, cfg AsyncConfig) *AsyncPublisher {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultQueueSize
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(e Event, err error) {
+			log.Printf("eventbus: async publish topic=%q agencyID=%q: %v", e.Topic, e.AgencyID, err)
+		}
+	}
+	a := &AsyncPublisher{
+		next:    next,
+		cfg:     cfg,
+		queue:   make(chan queuedEvent, cfg.QueueSize),
+		done:    make(chan struct{}),
+		closing: make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+// Publish stamps a zero Timestamp and queues the event. The event is
+// delivered with ctx's values but not its cancellation, since the caller has
+// usually returned by then. When the queue is full Publish applies the
+// overflow policy. Returns ErrPublisherClosed after Close, including when
+// Close is called while Publish waits for room.
+func (a *AsyncPublisher) Publish(ctx context.Context, e Event) error {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now().UTC()
+	}
+	item := queuedEvent{ctx: context.WithoutCancel(ctx), event: e}
+	a.mu.RLock()
+	if a.closed {
+		a.mu.RUnlock()
+		return ErrPublisherClosed
+	}
+	a.senders.Add(1)
+	a.mu.RUnlock()
+	defer a.senders.Done()
+	select {
+	case a.queue <- item:
+		return nil
+	default:
+	}
+	if a.cfg.Overflow == OverflowDrop {
+		a.dropped.Add(1)
+		return ErrQueueFull
+	}
+	a.waiting.Add(1)
+	defer a.waiting.Add(-1)
+	select {
+	case a.queue <- item:
+		return nil
+	case <-a.closing:
+		a.dropped.Add(1)
+		return ErrPublisherClosed
+	case <-ctx.Done():
+		a.dropped.Add(1)
+		return ctx.Err()
+	}
+}
+
+// run delivers queued events until the queue is closed and empty.
+func (a *AsyncPublisher) run() {
+	defer close(a.done)
+	for item := range a.queue {
+		if err := a.next.Publish(item.ctx, item.event); err != nil {
+			a.failed.Add(1)
+			a.cfg.OnError(item.event, err)
+			continue
+		}
+		a.published.Add(1)
+	}
+}
+
+// Close stops accepting events and waits until every queued event has been
+// delivered, or ctx ends, in which case the remaining events are delivered in
+// the background and ctx.Err() is returned. Publish calls blocked on a full
+// queue return ErrPublisherClosed. Calling Close more than once is safe.
+func (a *AsyncPublisher) Close(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.closing)
+		go func() {
+			a.senders.Wait()
+			close(a.queue)
+		}()
+	}
+	a.mu.Unlock()
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats returns the current queue length and delivery counters.
+func (a *AsyncPublisher) Stats() AsyncStats {
+	return AsyncStats{
+		Queued:    len(a.queue),
+		Published: a.published.Load(),
+		Failed:    a.failed.Load(),
+		Dropped:   a.dropped.Load(),
+		Waiting:   int(a.waiting.Load()),
+	}
+}
@@ -0,0 +1,125 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aosanya/CodeValdSharedLib/registrar"
+)
+
+// Codec converts [Event.Payload] to and from the string payload carried by
+// CodeValdCross.
+type Codec interface {
+	// Marshal encodes payload. A nil payload should encode as "".
+	Marshal(payload any) (string, error)
+
+	// Unmarshal decodes data into v, a pointer.
+	Unmarshal(data string, v any) error
+}
+
+// JSONCodec is the default [Codec]: payloads travel as JSON text.
+type JSONCodec struct{}
+
+// Marshal encodes payload as JSON; nil encodes as "". Every other value,
+// strings included, is JSON-encoded, except a json.RawMessage, which is
+// already JSON and is passed through unchanged.
+func (JSONCodec) Marshal(payload any) (string, error) {
+	switch p := payload.(type) {
+	case nil:
+		return "", nil
+	case json.RawMessage:
+		return string(p), nil
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+// Unmarshal decodes the JSON text data into v. An empty data leaves v
+// untouched.
+func (JSONCodec) Unmarshal(data string, v any) error {
+	if data == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(data), v)
+}
+
+// crossPublisher is the Publisher returned by NewCrossPublisher.
+type crossPublisher struct {
+	reg    registrar.Registrar
+	source string
+	codec  Codec
+}
+
+// NewCrossPublisher returns a [Publisher] that forwards every event to
+// CodeValdCross through reg.Publish as a CloudEvents envelope in structured
+// mode (see [EnvelopeFromEvent] and registrar.PublishEnvelope), so consumers
+// receive the event's ID, source, correlation ID, and schema version along
+// with its payload. source names the originating service when e.Source is
+// empty, and codec encodes the payload (nil selects [JSONCodec]). A zero
+// Timestamp is stamped with the current time.
+//
+// Publish is synchronous and returns the encode or RPC error, so a caller
+// that retries — such as an outbox relay — knows the event did not go
+// through; SafePublish ignores it as usual. Wrap the result with
+// [NewAsyncPublisher] to take the RPC off the write path.
+//
+// Consumers that predate the envelope can be served with
+// [NewLegacyCrossPublisher] until they are upgraded.
+func NewCrossPublisher(reg registrar.Registrar, source string, codec Codec) Publisher {
+	if codec == nil {
+		codec = JSONCodec{}
+	}
+	return &crossPublisher{reg: reg, source: source, codec: codec}
+}
+
+// Publish wraps e in an envelope and calls registrar.PublishEnvelope.
+func (p *crossPublisher) Publish(ctx context.Context, e Event) error {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now().UTC()
+	}
+	env, err := EnvelopeFromEvent(e, p.source, p.codec)
+	if err != nil {
+		return err
+	}
+	if err := registrar.PublishEnvelope(ctx, p.reg, env); err != nil {
+		return fmt.Errorf("eventbus: publish %s: %w", e.Topic, err)
+	}
+	return nil
+}
+
+// legacyCrossPublisher is the Publisher returned by NewLegacyCrossPublisher.
+type legacyCrossPublisher struct {
+	reg    registrar.Registrar
+	source string
+	codec  Codec
+}
+
+// NewLegacyCrossPublisher returns a [Publisher] that forwards every event to
+// CodeValdCross through reg.Publish with the bare payload encoded by codec
+// (nil selects [JSONCodec]) and source as the originating service name. The
+// event's ID, Source, CorrelationID, and SchemaVersion are not sent. Use it
+// only for consumers that cannot yet read envelopes; [NewCrossPublisher] is
+// the default.
+func NewLegacyCrossPublisher(reg registrar.Registrar, source string, codec Codec) Publisher {
+	if codec == nil {
+		codec = JSONCodec{}
+	}
+	return &legacyCrossPublisher{reg: reg, source: source, codec: codec}
+}
+
+// Publish encodes e.Payload and calls reg.Publish.
+func (p *legacyCrossPublisher) Publish(ctx context.Context, e Event) error {
+	payload, err := p.codec.Marshal(e.Payload)
+	if err != nil {
+		return fmt.Errorf("eventbus: encode %s payload: %w", e.Topic, err)
+	}
+	if err := p.reg.Publish(ctx, e.AgencyID, e.Topic, p.source, payload); err != nil {
+		return fmt.Errorf("eventbus: publish %s: %w", e.Topic, err)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package eventbus_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aosanya/CodeValdSharedLib/eventbus"
+	"github.com/aosanya/CodeValdSharedLib/registrar"
+)
+
+// fakeRegistrar records Publish calls; every other Registrar method panics.
+type fakeRegistrar struct {
+	registrar.Registrar
+	mu    sync.Mutex
+	calls []publishCall
+	err   error
+}
+
+type publishCall struct {
+	agencyID, topic, source, payload string
+}
+
+func (f *fakeRegistrar) Publish(_ context.Context, agencyID, topic, source, payload string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.calls = append(f.calls, publishCall{agencyID, topic, source, payload})
+	return nil
+}
+
+func TestLegacyCrossPublisher_SendsBareJSONPayload(t *testing.T) {
+	reg := &fakeRegistrar{}
+	p := eventbus.NewLegacyCrossPublisher(reg, "codevaldwork", nil)
+	payload := struct {
+		TaskID string `json:"taskId"`
+	}{"t-1"}
+	if err := p.Publish(context.Background(), eventbus.Event{Topic: "work.task.created", AgencyID: "ag", Payload: payload}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	want := publishCall{"ag", "work.task.created", "codevaldwork", `{"taskId":"t-1"}`}
+	if len(reg.calls) != 1 || reg.calls[0] != want {
+		t.Errorf("calls = %+v, want [%+v]", reg.calls, want)
+	}
+}
+
+func TestCrossPublisher_ReturnsErrors(t *testing.T) {
+	reg := &fakeRegistrar{err: errors.New("cross down")}
+	p := eventbus.NewCrossPublisher(reg, "svc", nil)
+	if err := p.Publish(context.Background(), eventbus.Event{Topic: "x", AgencyID: "ag"}); err == nil {
+		t.Error("Publish with a failing registrar returned nil")
+	}
+	if err := p.Publish(context.Background(), eventbus.Event{Topic: "x", Payload: func() {}}); err == nil {
+		t.Error("Publish with an unencodable payload returned nil")
+	}
+}
+
+func TestJSONCodec_RoundTripAndPassThrough(t *testing.T) {
+	var c eventbus.JSONCodec
+	for in, want := range map[any]string{nil: "", "hello": `"hello"`, 42: "42"} {
+		if got, err := c.Marshal(in); err != nil || got != want {
+			t.Errorf("Marshal(%v) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if got, err := c.Marshal(json.RawMessage(`{"a":1}`)); err != nil || got != `{"a":1}` {
+		t.Errorf("Marshal(RawMessage) = %q, %v; want it unchanged", got, err)
+	}
+	var out struct{ N int }
+	if err := c.Unmarshal(`{"N":7}`, &out); err != nil || out.N != 7 {
+		t.Errorf("Unmarshal = %+v, %v; want N=7", out, err)
+	}
+}
+
+// gatedPublisher records events, waiting on gate before each one.
+type gatedPublisher struct {
+	gate   chan struct{}
+	mu     sync.Mutex
+	topics []string
+}
+
+func (g *gatedPublisher) Publish(_ context.Context, e eventbus.Event) error {
+	<-g.gate
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.topics = append(g.topics, e.Topic)
+	return nil
+}
+
+func (g *gatedPublisher) got() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return slices.Clone(g.topics)
+}
+
+func TestAsyncPublisher_DeliversInOrderAndFlushesOnClose(t *testing.T) {
+	next := &gatedPublisher{gate: make(chan struct{})}
+	close(next.gate)
+	a := eventbus.NewAsyncPublisher(next, eventbus.AsyncConfig{QueueSize: 8})
+	for _, topic := range []string{"a", "b", "c"} {
+		if err := a.Publish(context.Background(), eventbus.Event{Topic: topic}); err != nil {
+			t.Fatalf("Publish(%s): %v", topic, err)
+		}
+	}
+	if err := a.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := next.got(); len(got) != 3 || got[0] != "a" || got[1] != "b" || got[2] != "c" {
+		t.Errorf("delivered %v, want [a b c]", got)
+	}
+	if s := a.Stats(); s.Published != 3 || s.Queued != 0 {
+		t.Errorf("Stats = %+v, want 3 published", s)
+	}
+	if err := a.Publish(context.Background(), eventbus.Event{Topic: "late"}); !errors.Is(err, eventbus.ErrPublisherClosed) {
+		t.Errorf("Publish after Close: got %v, want ErrPublisherClosed", err)
+	}
+}
+
+func TestAsyncPublisher_OverflowPolicies(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		policy  eventbus.OverflowPolicy
+		wantErr error
+	}{
+		{"drop", eventbus.OverflowDrop, eventbus.ErrQueueFull},
+		{"block", eventbus.OverflowBlock, context.DeadlineExceeded},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			next := &gatedPublisher{gate: make(chan struct{})}
+			a := eventbus.NewAsyncPublisher(next, eventbus.AsyncConfig{QueueSize: 1, Overflow: tc.policy})
+			// The worker holds the first event at the gate; the second fills
+			// the queue.
+			_ = a.Publish(context.Background(), eventbus.Event{Topic: "1"})
+			waitUntil(t, "the worker holds the first event", func() bool { return a.Stats().Queued == 0 })
+			_ = a.Publish(context.Background(), eventbus.Event{Topic: "2"})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			if err := a.Publish(ctx, eventbus.Event{Topic: "3"}); !errors.Is(err, tc.wantErr) {
+				t.Errorf("Publish on a full queue: got %v, want %v", err, tc.wantErr)
+			}
+			if a.Stats().Dropped != 1 {
+				t.Errorf("Dropped = %d, want 1", a.Stats().Dropped)
+			}
+			close(next.gate)
+			if err := a.Close(context.Background()); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+			if got := next.got(); len(got) != 2 {
+				t.Errorf("delivered %v, want [1 2]", got)
+			}
+		})
+	}
+}
+
+func TestAsyncPublisher_ReportsFailures(t *testing.T) {
+	var failed []string
+	var mu sync.Mutex
+	next := eventbus.NewCrossPublisher(&fakeRegistrar{err: errors.New("down")}, "svc", nil)
+	a := eventbus.NewAsyncPublisher(next, eventbus.AsyncConfig{OnError: func(e eventbus.Event, _ error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failed = append(failed, e.Topic)
+	}})
+	_ = a.Publish(context.Background(), eventbus.Event{Topic: "x"})
+	if err := a.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if len(failed) != 1 || a.Stats().Failed != 1 {
+		t.Errorf("failed = %v, Stats = %+v; want one failure", failed, a.Stats())
+	}
+}
+
+func TestAsyncPublisher_CloseReleasesBlockedPublish(t *testing.T) {
+	next := &gatedPublisher{gate: make(chan struct{})}
+	a := eventbus.NewAsyncPublisher(next, eventbus.AsyncConfig{QueueSize: 1})
+	_ = a.Publish(context.Background(), eventbus.Event{Topic: "1"})
+	waitUntil(t, "the worker holds the first event", func() bool { return a.Stats().Queued == 0 })
+	_ = a.Publish(context.Background(), eventbus.Event{Topic: "2"})
+
+	blocked := make(chan error, 1)
+	go func() { blocked <- a.Publish(context.Background(), eventbus.Event{Topic: "3"}) }()
+	waitUntil(t, "the third Publish blocks", func() bool { return a.Stats().Waiting == 1 })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := a.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Close with a stuck delivery: got %v, want DeadlineExceeded", err)
+	}
+	select {
+	case err := <-blocked:
+		if !errors.Is(err, eventbus.ErrPublisherClosed) {
+			t.Errorf("blocked Publish: got %v, want ErrPublisherClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close did not release the blocked Publish")
+	}
+	close(next.gate)
+	if err := a.Close(context.Background()); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	if got := next.got(); len(got) != 2 {
+		t.Errorf("delivered %v, want [1 2]", got)
+	}
+}
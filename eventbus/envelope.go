@@ -0,0 +1,81 @@
+package eventbus
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aosanya/CodeValdSharedLib/envelope"
+	"github.com/google/uuid"
+)
+
+// ContentType is the media type of the payloads a [Codec] produces, carried
+// in envelope.Envelope.DataContentType. JSONCodec returns
+// envelope.ContentTypeJSON.
+func (JSONCodec) ContentType() string { return envelope.ContentTypeJSON }
+
+// EnvelopeFromEvent wraps e in a CloudEvents envelope, encoding e.Payload
+// with codec (nil selects [JSONCodec]). The envelope's Type is e.Topic, its
+// Time e.Timestamp, and its Source e.Source, or source when e.Source is
+// empty. An empty e.ID gets a random UUID. When codec has a
+// ContentType() string method, its result is the DataContentType;
+// otherwise the data is taken to be JSON.
+func EnvelopeFromEvent(e Event, source string, codec Codec) (envelope.Envelope, error) {
+	if codec == nil {
+		codec = JSONCodec{}
+	}
+	env := envelope.Envelope{
+		ID:            e.ID,
+		Source:        e.Source,
+		SpecVersion:   envelope.SpecVersion,
+		Type:          e.Topic,
+		Time:          e.Timestamp,
+		AgencyID:      e.AgencyID,
+		CorrelationID: e.CorrelationID,
+		SchemaVersion: e.SchemaVersion,
+	}
+	if env.ID == "" {
+		env.ID = uuid.NewString()
+	}
+	if env.Source == "" {
+		env.Source = source
+	}
+	if ct, ok := codec.(interface{ ContentType() string }); ok {
+		env.DataContentType = ct.ContentType()
+	}
+	if e.Payload != nil {
+		data, err := codec.Marshal(e.Payload)
+		if err != nil {
+			return envelope.Envelope{}, fmt.Errorf("eventbus: encode %s payload: %w", e.Topic, err)
+		}
+		env.Data = []byte(data)
+	}
+	if err := env.Validate(); err != nil {
+		return envelope.Envelope{}, fmt.Errorf("eventbus: envelope for %s: %w", e.Topic, err)
+	}
+	return env, nil
+}
+
+// EventFromEnvelope is the inverse of [EnvelopeFromEvent]. The payload is
+// left encoded: a json.RawMessage when the data is JSON, a string
+// otherwise, and nil when the envelope has no data. JSONCodec passes a
+// json.RawMessage through unchanged, so a JSON event can be republished as
+// is; a string payload is re-encoded as a JSON string.
+func EventFromEnvelope(env envelope.Envelope) Event {
+	e := Event{
+		ID:            env.ID,
+		Topic:         env.Type,
+		AgencyID:      env.AgencyID,
+		Timestamp:     env.Time,
+		Source:        env.Source,
+		CorrelationID: env.CorrelationID,
+		SchemaVersion: env.SchemaVersion,
+	}
+	switch {
+	case env.Data == nil:
+	case env.DataContentType == "" || env.DataContentType == envelope.ContentTypeJSON:
+		e.Payload = json.RawMessage(env.Data)
+	default:
+		e.Payload = string(env.Data)
+	}
+	return e
+}
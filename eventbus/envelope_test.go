@@ -0,0 +1,65 @@
+package eventbus_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aosanya/CodeValdSharedLib/envelope"
+	"github.com/aosanya/CodeValdSharedLib/eventbus"
+)
+
+func TestEnvelopeFromEvent_RoundTrip(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	in := eventbus.Event{
+		ID: "e-1", Topic: "work.task.created", AgencyID: "ag", Timestamp: at,
+		Payload: json.RawMessage(`{"taskId":"t-1"}`), CorrelationID: "req-7", SchemaVersion: "2",
+	}
+	env, err := eventbus.EnvelopeFromEvent(in, "codevaldwork", nil)
+	if err != nil {
+		t.Fatalf("EnvelopeFromEvent: %v", err)
+	}
+	if env.Source != "codevaldwork" || env.Type != in.Topic || env.DataContentType != envelope.ContentTypeJSON || string(env.Data) != `{"taskId":"t-1"}` {
+		t.Errorf("envelope = %+v", env)
+	}
+	out := eventbus.EventFromEnvelope(env)
+	in.Source = "codevaldwork"
+	if out.ID != in.ID || out.Topic != in.Topic || out.AgencyID != in.AgencyID || !out.Timestamp.Equal(at) ||
+		out.Source != in.Source || out.CorrelationID != in.CorrelationID || out.SchemaVersion != in.SchemaVersion ||
+		string(out.Payload.(json.RawMessage)) != `{"taskId":"t-1"}` {
+		t.Errorf("round trip = %+v, want %+v", out, in)
+	}
+
+	noID, err := eventbus.EnvelopeFromEvent(eventbus.Event{Topic: "x", AgencyID: "ag"}, "svc", nil)
+	if err != nil || noID.ID == "" || noID.Data != nil {
+		t.Errorf("EnvelopeFromEvent without ID = %+v, %v; want a generated ID and no data", noID, err)
+	}
+	if _, err := eventbus.EnvelopeFromEvent(eventbus.Event{Topic: "x"}, "", nil); err == nil {
+		t.Error("EnvelopeFromEvent without a source succeeded")
+	}
+}
+
+func TestCrossPublisher_SendsStructuredEnvelope(t *testing.T) {
+	reg := &fakeRegistrar{}
+	p := eventbus.NewCrossPublisher(reg, "codevaldwork", nil)
+	if err := p.Publish(context.Background(), eventbus.Event{
+		Topic: "work.task.created", AgencyID: "ag", Payload: map[string]string{"taskId": "t-1"}, CorrelationID: "req-7",
+	}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if len(reg.calls) != 1 {
+		t.Fatalf("calls = %+v, want one", reg.calls)
+	}
+	call := reg.calls[0]
+	if call.agencyID != "ag" || call.topic != "work.task.created" || call.source != "codevaldwork" {
+		t.Errorf("call = %+v", call)
+	}
+	var env envelope.Envelope
+	if err := json.Unmarshal([]byte(call.payload), &env); err != nil {
+		t.Fatalf("payload is not an envelope: %v", err)
+	}
+	if env.ID == "" || env.Time.IsZero() || env.CorrelationID != "req-7" || string(env.Data) != `{"taskId":"t-1"}` {
+		t.Errorf("envelope = %+v", env)
+	}
+}
@@ -14,11 +14,40 @@
 //
 // # Cross dependency
 //
-// The eventual real-world implementation calls a CodeValdCross
-// `OrchestratorService.Publish` RPC (planned but not yet shipped). Until
-// that RPC lands, services should construct a [LogPublisher] as the
-// default. Once the RPC ships, an eventbus-internal adapter will be added
-// here without changing the [Publisher] surface — service code stays put.
+// [NewCrossPublisher] delivers events to CodeValdCross through
+// registrar.Registrar.Publish (the `OrchestratorService.Publish` RPC), each
+// wrapped in a CloudEvents 1.0 envelope (package envelope) carrying its ID,
+// source, correlation ID, and schema version, with the payload encoded by a
+// [Codec] — JSON by default; eventreceiver unwraps it on the consuming side.
+// It publishes synchronously; wrap it with [NewAsyncPublisher] to deliver
+// from a bounded background queue instead, blocking or dropping when the
+// queue is full:
+//
+//	pub := eventbus.NewAsyncPublisher(
+//	    eventbus.NewCrossPublisher(reg, "codevaldwork", nil),
+//	    eventbus.AsyncConfig{QueueSize: 1000, Overflow: eventbus.OverflowDrop},
+//	)
+//	defer pub.Close(context.Background())
+//
+// [NewLegacyCrossPublisher] sends the bare encoded payload instead, for
+// consumers that cannot yet read envelopes.
+//
+// # Middleware
+//
+// [Chain] decorates a Publisher with [Middleware]: [Stamp] fills in
+// timestamps, IDs, source, and correlation IDs; [RequireAgency] and
+// [AllowTopics] reject events of unexpected agencies or undeclared topics;
+// [Sample] thins high-volume topics; [Log] logs a sample of events with
+// payload fields redacted; [Metrics] and [Trace] hook in instrumentation.
+//
+// # In-process delivery
+//
+// [Local] is a Publisher that delivers events to in-process subscribers
+// instead of CodeValdCross, for monoliths, tests, and local development. It
+// matches topic patterns exactly as Cross does (see [MatchTopic]) and hands
+// each subscriber its events from its own queues, in order per agency.
+//
+// [LogPublisher] remains for local development and tests.
 package eventbus
 
 import (
@@ -28,8 +57,14 @@ import (
 )
 
 // Event is the unit of publication. Topic, AgencyID, and Timestamp are
-// always set; Payload is opaque to the eventbus layer.
+// always set; Payload is opaque to the eventbus layer. ID, Source,
+// CorrelationID, and SchemaVersion are optional and travel in the
+// CloudEvents envelope (see [EnvelopeFromEvent]).
 type Event struct {
+	// ID uniquely identifies the event, letting consumers drop duplicates.
+	// Optional: [EnvelopeFromEvent] assigns a random UUID when empty.
+	ID string
+
 	// Topic is the dotted-namespace event name, e.g. "work.task.created"
 	// or "agency.created". Required.
 	Topic string
@@ -47,6 +82,18 @@ type Event struct {
 	// payload avoids the round-trip-to-DB pattern that bare topic events
 	// force on subscribers. May be nil for events that need no detail.
 	Payload any
+
+	// Source names the producing service, e.g. "codevaldwork". Optional:
+	// publishers that know their service name fill it in when empty.
+	Source string
+
+	// CorrelationID ties together the events caused by one request.
+	// Optional.
+	CorrelationID string
+
+	// SchemaVersion is the version of the payload schema of Topic, so
+	// consumers can tell payload shapes apart. Optional.
+	SchemaVersion string
 }
 
 // Publisher delivers [Event]s to CodeValdCross. Implementations must be safe
@@ -56,8 +103,9 @@ type Event struct {
 // treat nil as "drop the event silently" (events are best-effort by
 // design; the persisted operation has already succeeded).
 //
-// Errors are non-fatal — implementations should log and return nil rather
-// than propagate failures up the originating call path.
+// Errors are non-fatal — the originating call path ignores them (see
+// [SafePublish]). Publishers that deliver over the network return them so
+// that a caller able to retry, such as an outbox relay, can.
 type Publisher interface {
 	Publish(ctx context.Context, event Event) error
 }
@@ -73,8 +121,8 @@ func (f PublisherFunc) Publish(ctx context.Context, event Event) error {
 
 // LogPublisher returns a [Publisher] that writes each event to the standard
 // `log` package, prefixed with serviceName. This matches the per-service
-// "log-only" stub the migration replaces, and remains the recommended
-// default until the CodeValdCross Publish RPC ships.
+// "log-only" stub the migration replaces; production services use
+// [NewCrossPublisher].
 func LogPublisher(serviceName string) Publisher {
 	return PublisherFunc(func(_ context.Context, e Event) error {
 		log.Printf("eventbus[%s]: topic=%q agencyID=%q payload=%T",
@@ -0,0 +1,346 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"slices"
+	"sync"
+	"time"
+)
+
+// HandlerFunc handles an event delivered by a [Local] bus.
+type HandlerFunc func(ctx context.Context, e Event) error
+
+// LocalConfig configures a [Local] bus.
+type LocalConfig struct {
+	// QueueSize bounds the events waiting in each delivery queue of a
+	// subscriber. Defaults to 1024.
+	QueueSize int
+
+	// Workers is the number of delivery queues, each with its own
+	// goroutine, per subscriber. Events are assigned to a queue by
+	// AgencyID, so one agency's events reach a subscriber in publication
+	// order while different agencies are handled in parallel. Defaults to 1,
+	// which delivers every event in publication order.
+	Workers int
+
+	// Overflow is the policy applied when a subscriber's queue is full.
+	// Defaults to OverflowBlock.
+	Overflow OverflowPolicy
+
+	// OnError is called with every event a handler failed on, or panicked
+	// on, with a *SubscriberError. Defaults to logging it.
+	OnError func(Event, error)
+}
+
+// SubscriberError reports that the handler subscribed with Pattern failed on
+// an event.
+type SubscriberError struct {
+	Pattern string
+	Err     error
+}
+
+// Error implements error.
+func (e *SubscriberError) Error() string {
+	return fmt.Sprintf("eventbus: subscriber %q: %v", e.Pattern, e.Err)
+}
+
+// Unwrap returns the handler's error.
+func (e *SubscriberError) Unwrap() error { return e.Err }
+
+// errUnsubscribed is returned by subscriber.enqueue once the subscription
+// is cancelled.
+var errUnsubscribed = errors.New("eventbus: subscription cancelled")
+
+// subscriber is one Subscribe registration and its delivery queues.
+type subscriber struct {
+	pattern string
+	handle  HandlerFunc
+	queues  []chan queuedEvent
+	closing chan struct{} // closed by stop to release blocked senders
+	mu      sync.RWMutex  // guards stopped and senders.Add against stop
+	stopped bool
+	senders sync.WaitGroup // Publish calls that may still send on queues
+}
+
+// enqueue sends item on the queue with index shard, applying overflow when
+// it is full. Returns errUnsubscribed once s is stopped.
+func (s *subscriber) enqueue(ctx context.Context, shard int, item queuedEvent, overflow OverflowPolicy) error {
+	s.mu.RLock()
+	if s.stopped {
+		s.mu.RUnlock()
+		return errUnsubscribed
+	}
+	s.senders.Add(1)
+	s.mu.RUnlock()
+	defer s.senders.Done()
+	q := s.queues[shard]
+	select {
+	case q <- item:
+		return nil
+	default:
+	}
+	if overflow == OverflowDrop {
+		return ErrQueueFull
+	}
+	select {
+	case q <- item:
+		return nil
+	case <-s.closing:
+		return errUnsubscribed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// stop makes s accept no more events and closes its queues once the
+// senders under way have returned, which ends its goroutines after the
+// queued events are delivered.
+func (s *subscriber) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
+	s.stopped = true
+	close(s.closing)
+	go func() {
+		s.senders.Wait()
+		for _, q := range s.queues {
+			close(q)
+		}
+	}()
+}
+
+// Local is an in-process [Publisher] that delivers events straight to
+// handlers subscribed by topic pattern, standing in for CodeValdCross when
+// several services run in one process — monoliths, tests, and local
+// development:
+//
+//	bus := eventbus.NewLocal(eventbus.LocalConfig{})
+//	bus.Subscribe("work.task.*", func(ctx context.Context, e eventbus.Event) error {
+//	    ...
+//	})
+//	workService := work.New(store, bus) // any code taking a Publisher
+//	defer bus.Close(context.Background())
+//
+// Patterns follow the Cross semantics of [MatchTopic]. Each subscriber has
+// its own queues and goroutines, so a slow or failing handler never holds up
+// the publisher or other subscribers; see [LocalConfig.Workers] for
+// ordering. Handlers receive the published Event as is — Payload is not
+// encoded, so it is shared between subscribers and must not be mutated.
+type Local struct {
+	cfg     LocalConfig
+	mu      sync.RWMutex // guards subs and closed
+	subs    []*subscriber
+	closed  bool
+	workers sync.WaitGroup
+
+	pendingMu sync.Mutex // guards pending and idle
+	pending   int
+	idle      chan struct{} // closed while pending is zero
+}
+
+// NewLocal returns a Local bus with no subscribers.
+func NewLocal(cfg LocalConfig) *Local {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultQueueSize
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(e Event, err error) {
+			log.Printf("eventbus: local delivery topic=%q agencyID=%q: %v", e.Topic, e.AgencyID, err)
+		}
+	}
+	idle := make(chan struct{})
+	close(idle)
+	return &Local{cfg: cfg, idle: idle}
+}
+
+// Subscribe registers fn for events whose topic matches pattern and returns
+// a function that cancels the subscription. After cancelling, no new events
+// are queued for fn; those already queued are still delivered. Subscribe
+// returns an error when pattern is malformed (see ValidatePattern), and
+// ErrPublisherClosed after Close.
+func (l *Local) Subscribe(pattern string, fn HandlerFunc) (unsubscribe func(), err error) {
+	if err := ValidatePattern(pattern); err != nil {
+		return nil, err
+	}
+	s := &subscriber{pattern: pattern, handle: fn, queues: make([]chan queuedEvent, l.cfg.Workers), closing: make(chan struct{})}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil, ErrPublisherClosed
+	}
+	for i := range s.queues {
+		s.queues[i] = make(chan queuedEvent, l.cfg.QueueSize)
+		l.workers.Add(1)
+		go l.run(s, s.queues[i])
+	}
+	l.subs = append(l.subs, s)
+	var once sync.Once
+	return func() { once.Do(func() { l.remove(s) }) }, nil
+}
+
+// remove cancels the subscription s.
+func (l *Local) remove(s *subscriber) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	i := slices.Index(l.subs, s)
+	if i < 0 {
+		return
+	}
+	l.subs = slices.Delete(l.subs, i, i+1)
+	s.stop()
+}
+
+// Publish stamps a zero Timestamp and queues the event for every subscriber
+// whose pattern matches its topic, returning once it is queued. The event is
+// delivered with ctx's values but not its cancellation. When a queue is full
+// the overflow policy applies; the returned error joins ErrQueueFull or
+// ctx.Err() for each subscriber that missed the event. Returns
+// ErrPublisherClosed after Close. No lock is held while waiting for room, so
+// handlers may publish, subscribe and unsubscribe; a subscription cancelled
+// meanwhile just misses the event.
+func (l *Local) Publish(ctx context.Context, e Event) error {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now().UTC()
+	}
+	item := queuedEvent{ctx: context.WithoutCancel(ctx), event: e}
+	l.mu.RLock()
+	if l.closed {
+		l.mu.RUnlock()
+		return ErrPublisherClosed
+	}
+	var matched []*subscriber
+	for _, s := range l.subs {
+		if MatchTopic(s.pattern, e.Topic) {
+			matched = append(matched, s)
+		}
+	}
+	l.mu.RUnlock()
+	shard := l.shard(e.AgencyID)
+	var errs []error
+	for _, s := range matched {
+		l.addPending(1)
+		err := s.enqueue(ctx, shard, item, l.cfg.Overflow)
+		if err == nil {
+			continue
+		}
+		l.addPending(-1)
+		if err != errUnsubscribed {
+			errs = append(errs, &SubscriberError{Pattern: s.pattern, Err: err})
+		} else if l.isClosed() {
+			return ErrPublisherClosed
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// isClosed reports whether Close has been called.
+func (l *Local) isClosed() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.closed
+}
+
+// shard returns the index of the delivery queue for agencyID's events.
+func (l *Local) shard(agencyID string) int {
+	if l.cfg.Workers == 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	h.Write([]byte(agencyID))
+	return int(h.Sum32() % uint32(l.cfg.Workers))
+}
+
+// run delivers the events of q to s until q is closed and empty.
+func (l *Local) run(s *subscriber, q chan queuedEvent) {
+	defer l.workers.Done()
+	for item := range q {
+		if err := deliver(item.ctx, s.handle, item.event); err != nil {
+			l.cfg.OnError(item.event, &SubscriberError{Pattern: s.pattern, Err: err})
+		}
+		l.addPending(-1)
+	}
+}
+
+// deliver calls fn, converting a panic into an error.
+func deliver(ctx context.Context, fn HandlerFunc, e Event) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return fn(ctx, e)
+}
+
+// addPending adjusts the count of queued and in-flight deliveries by n.
+func (l *Local) addPending(n int) {
+	l.pendingMu.Lock()
+	defer l.pendingMu.Unlock()
+	if l.pending == 0 && n > 0 {
+		l.idle = make(chan struct{})
+	}
+	l.pending += n
+	if l.pending == 0 {
+		close(l.idle)
+	}
+}
+
+// Pending returns the number of deliveries not yet finished: events queued
+// or being handled, and events a Publish is still waiting to queue.
+func (l *Local) Pending() int {
+	l.pendingMu.Lock()
+	defer l.pendingMu.Unlock()
+	return l.pending
+}
+
+// Drain waits until every event queued so far has been handled, or ctx
+// ends, in which case it returns ctx.Err(). The bus keeps accepting events;
+// Drain returns as soon as the bus is idle, so events published while it
+// waits delay it too. Tests use it to await asynchronous handlers.
+func (l *Local) Drain(ctx context.Context) error {
+	l.pendingMu.Lock()
+	idle := l.idle
+	l.pendingMu.Unlock()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting events and subscriptions and waits until every
+// queued event has been handled, or ctx ends, in which case the remaining
+// events are handled in the background and ctx.Err() is returned. Publish
+// calls blocked on a full queue return ErrPublisherClosed. Calling Close more
+// than once is safe.
+func (l *Local) Close(ctx context.Context) error {
+	l.mu.Lock()
+	if !l.closed {
+		l.closed = true
+		for _, s := range l.subs {
+			s.stop()
+		}
+		l.subs = nil
+	}
+	l.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		l.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
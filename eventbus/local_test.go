@@ -0,0 +1,314 @@
+package eventbus_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aosanya/CodeValdSharedLib/eventbus"
+)
+
+// recorder collects the topics a subscriber handled.
+type recorder struct {
+	mu     sync.Mutex
+	topics []string
+}
+
+func (r *recorder) handle(_ context.Context, e eventbus.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.topics = append(r.topics, e.Topic)
+	return nil
+}
+
+func (r *recorder) got() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.topics)
+}
+
+func drain(t *testing.T, bus *eventbus.Local) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := bus.Drain(ctx); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+}
+
+// waitUntil polls cond until it holds, failing the test after 5s.
+func waitUntil(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting until %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLocal_RoutesByPattern(t *testing.T) {
+	bus := eventbus.NewLocal(eventbus.LocalConfig{})
+	defer bus.Close(context.Background())
+	var tasks, work, git recorder
+	for pattern, r := range map[string]*recorder{"work.task.*": &tasks, "work.#": &work, "git.push": &git} {
+		if _, err := bus.Subscribe(pattern, r.handle); err != nil {
+			t.Fatalf("Subscribe(%s): %v", pattern, err)
+		}
+	}
+	if _, err := bus.Subscribe("work..x", tasks.handle); err == nil {
+		t.Error("Subscribe with a malformed pattern succeeded")
+	}
+
+	ctx := context.Background()
+	for _, topic := range []string{"work.task.created", "work.project.archived", "git.push", "agency.created"} {
+		if err := bus.Publish(ctx, eventbus.Event{Topic: topic, AgencyID: "ag"}); err != nil {
+			t.Fatalf("Publish(%s): %v", topic, err)
+		}
+	}
+	drain(t, bus)
+	if got := tasks.got(); !slices.Equal(got, []string{"work.task.created"}) {
+		t.Errorf("work.task.* got %v", got)
+	}
+	if got := work.got(); !slices.Equal(got, []string{"work.task.created", "work.project.archived"}) {
+		t.Errorf("work.# got %v", got)
+	}
+	if got := git.got(); !slices.Equal(got, []string{"git.push"}) {
+		t.Errorf("git.push got %v", got)
+	}
+}
+
+func TestLocal_OrdersEventsPerAgency(t *testing.T) {
+	bus := eventbus.NewLocal(eventbus.LocalConfig{Workers: 4, QueueSize: 8})
+	defer bus.Close(context.Background())
+	var mu sync.Mutex
+	seen := map[string][]int{}
+	_, _ = bus.Subscribe("#", func(_ context.Context, e eventbus.Event) error {
+		time.Sleep(time.Duration(e.Payload.(int)%3) * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		seen[e.AgencyID] = append(seen[e.AgencyID], e.Payload.(int))
+		return nil
+	})
+	for i := range 60 {
+		ag := fmt.Sprintf("ag-%d", i%5)
+		if err := bus.Publish(context.Background(), eventbus.Event{Topic: "work.task.updated", AgencyID: ag, Payload: i}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+	drain(t, bus)
+	for ag, seq := range seen {
+		if len(seq) != 12 || !slices.IsSorted(seq) {
+			t.Errorf("agency %s handled %v, want 12 events in publication order", ag, seq)
+		}
+	}
+}
+
+func TestLocal_IsolatesSubscribers(t *testing.T) {
+	var mu sync.Mutex
+	var reported []error
+	bus := eventbus.NewLocal(eventbus.LocalConfig{QueueSize: 1, Overflow: eventbus.OverflowDrop, OnError: func(_ eventbus.Event, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, err)
+	}})
+	gate, started := make(chan struct{}), make(chan struct{}, 3)
+	var fast recorder
+	_, _ = bus.Subscribe("work.#", func(context.Context, eventbus.Event) error { started <- struct{}{}; <-gate; return nil })
+	_, _ = bus.Subscribe("work.#", fast.handle)
+	_, _ = bus.Subscribe("work.#", func(context.Context, eventbus.Event) error { return errors.New("boom") })
+	_, _ = bus.Subscribe("work.#", func(context.Context, eventbus.Event) error { panic("oops") })
+
+	ctx := context.Background()
+	var dropped error
+	for i := range 3 {
+		if err := bus.Publish(ctx, eventbus.Event{Topic: "work.x", AgencyID: "ag"}); err != nil {
+			dropped = err
+		}
+		if i == 0 {
+			<-started
+		}
+		// Wait for the other subscribers to empty their one-event queues.
+		waitUntil(t, "the other subscribers handled the event", func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(fast.got()) == i+1 && len(reported) == 2*(i+1)
+		})
+	}
+	var serr *eventbus.SubscriberError
+	if !errors.Is(dropped, eventbus.ErrQueueFull) || !errors.As(dropped, &serr) {
+		t.Errorf("Publish to the blocked subscriber = %v, want a *SubscriberError wrapping ErrQueueFull", dropped)
+	}
+	if got := len(fast.got()); got != 3 {
+		t.Errorf("fast subscriber handled %d events while another was blocked, want 3", got)
+	}
+
+	close(gate)
+	drain(t, bus)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 6 || !errors.As(reported[0], &serr) {
+		t.Errorf("OnError got %v, want 6 *SubscriberErrors (3 errors, 3 panics)", reported)
+	}
+}
+
+func TestLocal_UnsubscribeAndClose(t *testing.T) {
+	bus := eventbus.NewLocal(eventbus.LocalConfig{})
+	var kept, gone recorder
+	_, _ = bus.Subscribe("work.#", kept.handle)
+	unsubscribe, _ := bus.Subscribe("work.#", gone.handle)
+
+	ctx := context.Background()
+	_ = bus.Publish(ctx, eventbus.Event{Topic: "work.a", AgencyID: "ag"})
+	unsubscribe()
+	unsubscribe()
+	_ = bus.Publish(ctx, eventbus.Event{Topic: "work.b", AgencyID: "ag"})
+	if err := bus.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := kept.got(); !slices.Equal(got, []string{"work.a", "work.b"}) {
+		t.Errorf("kept subscriber got %v, want both events delivered before Close returned", got)
+	}
+	if got := gone.got(); !slices.Equal(got, []string{"work.a"}) {
+		t.Errorf("unsubscribed subscriber got %v, want only the event queued before", got)
+	}
+	if err := bus.Publish(ctx, eventbus.Event{Topic: "work.c"}); !errors.Is(err, eventbus.ErrPublisherClosed) {
+		t.Errorf("Publish after Close = %v, want ErrPublisherClosed", err)
+	}
+	if _, err := bus.Subscribe("work.#", kept.handle); !errors.Is(err, eventbus.ErrPublisherClosed) {
+		t.Errorf("Subscribe after Close = %v, want ErrPublisherClosed", err)
+	}
+	if err := bus.Close(ctx); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+func TestLocal_HandlerRepublishesToItsOwnTopic(t *testing.T) {
+	bus := eventbus.NewLocal(eventbus.LocalConfig{QueueSize: 1})
+	defer bus.Close(context.Background())
+	var hops recorder
+	_, err := bus.Subscribe("work.hop", func(ctx context.Context, e eventbus.Event) error {
+		hops.handle(ctx, e)
+		if n := e.Payload.(int); n < 5 {
+			return bus.Publish(ctx, eventbus.Event{Topic: e.Topic, AgencyID: e.AgencyID, Payload: n + 1})
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	// Subscribing concurrently queues writers on the bus lock while the
+	// handler publishes.
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			unsubscribe, err := bus.Subscribe("other", func(context.Context, eventbus.Event) error { return nil })
+			if err == nil {
+				unsubscribe()
+			}
+		}
+	}()
+	if err := bus.Publish(context.Background(), eventbus.Event{Topic: "work.hop", AgencyID: "ag", Payload: 1}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	drain(t, bus)
+	close(stop)
+	<-done
+	if got := len(hops.got()); got != 5 {
+		t.Errorf("handled %d hops, want 5", got)
+	}
+}
+
+func TestLocal_HandlerSubscribesWhilePublishBlocks(t *testing.T) {
+	bus := eventbus.NewLocal(eventbus.LocalConfig{QueueSize: 1})
+	gate, started := make(chan struct{}), make(chan struct{}, 3)
+	var late recorder
+	var once sync.Once
+	_, err := bus.Subscribe("work.a", func(ctx context.Context, e eventbus.Event) error {
+		started <- struct{}{}
+		<-gate
+		var err error
+		once.Do(func() { _, err = bus.Subscribe("work.b", late.handle) })
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	ctx := context.Background()
+	// The handler holds the first event at the gate and the second fills the
+	// queue, so the third Publish blocks.
+	_ = bus.Publish(ctx, eventbus.Event{Topic: "work.a", AgencyID: "ag"})
+	<-started
+	_ = bus.Publish(ctx, eventbus.Event{Topic: "work.a", AgencyID: "ag"})
+	blocked := make(chan error, 1)
+	go func() { blocked <- bus.Publish(ctx, eventbus.Event{Topic: "work.a", AgencyID: "ag"}) }()
+	waitUntil(t, "the third Publish blocks", func() bool { return bus.Pending() == 3 })
+	close(gate)
+
+	select {
+	case err := <-blocked:
+		if err != nil {
+			t.Fatalf("blocked Publish: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Publish and Subscribe from the handler deadlocked")
+	}
+	drain(t, bus)
+	if err := bus.Publish(ctx, eventbus.Event{Topic: "work.b", AgencyID: "ag"}); err != nil {
+		t.Fatalf("Publish(work.b): %v", err)
+	}
+	if err := bus.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := late.got(); !slices.Equal(got, []string{"work.b"}) {
+		t.Errorf("subscriber added by the handler got %v, want [work.b]", got)
+	}
+}
+
+func TestLocal_CloseReleasesBlockedPublish(t *testing.T) {
+	bus := eventbus.NewLocal(eventbus.LocalConfig{QueueSize: 1})
+	gate, started := make(chan struct{}), make(chan struct{}, 3)
+	_, _ = bus.Subscribe("work.a", func(context.Context, eventbus.Event) error {
+		started <- struct{}{}
+		<-gate
+		return nil
+	})
+	ctx := context.Background()
+	_ = bus.Publish(ctx, eventbus.Event{Topic: "work.a"})
+	<-started
+	_ = bus.Publish(ctx, eventbus.Event{Topic: "work.a"})
+	blocked := make(chan error, 1)
+	go func() { blocked <- bus.Publish(ctx, eventbus.Event{Topic: "work.a"}) }()
+	waitUntil(t, "the third Publish blocks", func() bool { return bus.Pending() == 3 })
+
+	closeCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := bus.Close(closeCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Close with a stuck handler = %v, want DeadlineExceeded", err)
+	}
+	select {
+	case err := <-blocked:
+		if !errors.Is(err, eventbus.ErrPublisherClosed) {
+			t.Errorf("blocked Publish = %v, want ErrPublisherClosed", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not release the blocked Publish")
+	}
+	close(gate)
+	if err := bus.Close(ctx); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package eventbus
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Topic pattern wildcards, matching the semantics CodeValdCross applies to
+// subscriptions, used by [Local.Subscribe] and eventreceiver. Each occupies
+// a whole dot-separated segment.
+const (
+	// WildcardOne matches exactly one topic segment:
+	// "work.task.*" matches "work.task.created" but not "work.task".
+	WildcardOne = "*"
+
+	// WildcardMany matches zero or more topic segments:
+	// "work.#" matches "work", "work.task" and "work.task.update.status".
+	WildcardMany = "#"
+)
+
+// ValidatePattern reports whether pattern is a well-formed topic pattern: a
+// non-empty dot-separated list of non-empty segments, where a segment
+// containing a wildcard is exactly "*" or "#".
+func ValidatePattern(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("eventbus: empty topic pattern")
+	}
+	for _, seg := range strings.Split(pattern, ".") {
+		if seg == "" {
+			return fmt.Errorf("eventbus: topic pattern %q has an empty segment", pattern)
+		}
+		if seg != WildcardOne && seg != WildcardMany && strings.ContainsAny(seg, "*#") {
+			return fmt.Errorf("eventbus: topic pattern %q: wildcard must be a whole segment, got %q", pattern, seg)
+		}
+	}
+	return nil
+}
+
+// MatchTopic reports whether topic matches pattern. A pattern without
+// wildcards matches only the identical topic. Malformed patterns (see
+// ValidatePattern) never match.
+func MatchTopic(pattern, topic string) bool {
+	if ValidatePattern(pattern) != nil || topic == "" {
+		return false
+	}
+	return matchSegments(strings.Split(pattern, "."), strings.Split(topic, "."))
+}
+
+// matchSegments matches split pattern segments against split topic segments.
+func matchSegments(pattern, topic []string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case WildcardMany:
+			rest := pattern[1:]
+			for i := 0; i <= len(topic); i++ {
+				if matchSegments(rest, topic[i:]) {
+					return true
+				}
+			}
+			return false
+		case WildcardOne:
+			if len(topic) == 0 {
+				return false
+			}
+		default:
+			if len(topic) == 0 || topic[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern, topic = pattern[1:], topic[1:]
+	}
+	return len(topic) == 0
+}
@@ -0,0 +1,295 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Errors returned by the middleware that reject events.
+var (
+	// ErrMissingAgency is returned by [RequireAgency] for an event without
+	// an AgencyID.
+	ErrMissingAgency = errors.New("eventbus: event has no agency ID")
+
+	// ErrAgencyNotAllowed is returned by [RequireAgency] for an event of an
+	// agency outside its allowed list.
+	ErrAgencyNotAllowed = errors.New("eventbus: agency not allowed")
+
+	// ErrTopicNotAllowed is returned by [AllowTopics] for an event whose
+	// topic the service does not declare.
+	ErrTopicNotAllowed = errors.New("eventbus: topic not declared as produced")
+)
+
+// Middleware decorates a [Publisher], typically inspecting or amending each
+// event before handing it to next. Assemble middleware with [Chain].
+type Middleware func(next Publisher) Publisher
+
+// Chain returns p wrapped by mw, the first middleware outermost: an event
+// passes through mw[0], then mw[1], …, and finally reaches p.
+//
+//	pub := eventbus.Chain(eventbus.NewCrossPublisher(reg, "codevaldwork", nil),
+//	    eventbus.Stamp("codevaldwork"),
+//	    eventbus.RequireAgency(),
+//	    eventbus.AllowTopics(types.TopicsFromSchema("work", schema)),
+//	    eventbus.Metrics(observe),
+//	)
+func Chain(p Publisher, mw ...Middleware) Publisher {
+	for i := len(mw) - 1; i >= 0; i-- {
+		p = mw[i](p)
+	}
+	return p
+}
+
+// correlationKey is the context key under which WithCorrelationID stores
+// the correlation ID.
+type correlationKey struct{}
+
+// WithCorrelationID returns a copy of ctx whose published events [Stamp]
+// tags with correlation ID id.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationIDFromContext returns the ID set by WithCorrelationID, or "".
+func CorrelationIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// Stamp fills in what an event leaves empty: Timestamp with the current UTC
+// time, ID with a random UUID, Source with source, and CorrelationID with
+// the ID set on the context by WithCorrelationID.
+func Stamp(source string) Middleware {
+	return func(next Publisher) Publisher {
+		return PublisherFunc(func(ctx context.Context, e Event) error {
+			if e.Timestamp.IsZero() {
+				e.Timestamp = time.Now().UTC()
+			}
+			if e.ID == "" {
+				e.ID = uuid.NewString()
+			}
+			if e.Source == "" {
+				e.Source = source
+			}
+			if e.CorrelationID == "" {
+				e.CorrelationID = CorrelationIDFromContext(ctx)
+			}
+			return next.Publish(ctx, e)
+		})
+	}
+}
+
+// RequireAgency rejects events without an AgencyID with ErrMissingAgency.
+// When allowed is non-empty, events of any other agency are rejected with
+// ErrAgencyNotAllowed — for services that act for a fixed set of agencies.
+func RequireAgency(allowed ...string) Middleware {
+	set := make(map[string]bool, len(allowed))
+	for _, a := range allowed {
+		set[a] = true
+	}
+	return func(next Publisher) Publisher {
+		return PublisherFunc(func(ctx context.Context, e Event) error {
+			if e.AgencyID == "" {
+				return fmt.Errorf("%w: %s", ErrMissingAgency, e.Topic)
+			}
+			if len(set) > 0 && !set[e.AgencyID] {
+				return fmt.Errorf("%w: %s publishing %s", ErrAgencyNotAllowed, e.AgencyID, e.Topic)
+			}
+			return next.Publish(ctx, e)
+		})
+	}
+}
+
+// AllowTopics rejects events whose topic matches none of produces with
+// ErrTopicNotAllowed, so a service publishes only what it declares in
+// types.ServiceRegistration.Produces. Entries may be patterns, matched as
+// by [MatchTopic].
+func AllowTopics(produces []string) Middleware {
+	exact := make(map[string]bool, len(produces))
+	var patterns []string
+	for _, p := range produces {
+		if strings.ContainsAny(p, WildcardOne+WildcardMany) {
+			patterns = append(patterns, p)
+		} else {
+			exact[p] = true
+		}
+	}
+	return func(next Publisher) Publisher {
+		return PublisherFunc(func(ctx context.Context, e Event) error {
+			if !exact[e.Topic] && !matchesAny(patterns, e.Topic) {
+				return fmt.Errorf("%w: %s", ErrTopicNotAllowed, e.Topic)
+			}
+			return next.Publish(ctx, e)
+		})
+	}
+}
+
+// matchesAny reports whether topic matches any of patterns.
+func matchesAny(patterns []string, topic string) bool {
+	for _, p := range patterns {
+		if MatchTopic(p, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sample forwards only a fraction rate, between 0 and 1, of the events whose
+// topic matches one of patterns, or of every event when patterns is empty;
+// the rest are dropped without error. Other events always pass. Use it for
+// high-volume, loss-tolerant topics, never for lifecycle events consumers
+// rely on.
+func Sample(rate float64, patterns ...string) Middleware {
+	return func(next Publisher) Publisher {
+		return PublisherFunc(func(ctx context.Context, e Event) error {
+			if (len(patterns) == 0 || matchesAny(patterns, e.Topic)) && rand.Float64() >= rate {
+				return nil
+			}
+			return next.Publish(ctx, e)
+		})
+	}
+}
+
+// redacted replaces the values of redacted payload fields in log lines.
+const redacted = "[REDACTED]"
+
+// LogConfig configures the [Log] middleware.
+type LogConfig struct {
+	// SampleRate points to the fraction, between 0 and 1, of successful
+	// publishes that are logged; 0 logs failures only. Failed publishes are
+	// always logged. Nil logs every publish. It is a pointer so that 0 can
+	// be told apart from unset.
+	SampleRate *float64
+
+	// Redact lists payload field names, matched case-insensitively at any
+	// depth, whose values are replaced by "[REDACTED]" in the log line.
+	Redact []string
+
+	// Logf writes a log line. Defaults to log.Printf.
+	Logf func(format string, args ...any)
+}
+
+// Log writes a line for each event published through it, after next
+// returns, with its outcome and JSON payload; the fields named in
+// cfg.Redact are masked. The event itself passes through unchanged.
+func Log(cfg LogConfig) Middleware {
+	rate := 1.0
+	if cfg.SampleRate != nil {
+		rate = *cfg.SampleRate
+	}
+	if cfg.Logf == nil {
+		cfg.Logf = log.Printf
+	}
+	redact := make(map[string]bool, len(cfg.Redact))
+	for _, f := range cfg.Redact {
+		redact[strings.ToLower(f)] = true
+	}
+	return func(next Publisher) Publisher {
+		return PublisherFunc(func(ctx context.Context, e Event) error {
+			err := next.Publish(ctx, e)
+			if err == nil && rand.Float64() >= rate {
+				return nil
+			}
+			outcome := "ok"
+			if err != nil {
+				outcome = err.Error()
+			}
+			cfg.Logf("eventbus: publish topic=%q agencyID=%q id=%q payload=%s: %s",
+				e.Topic, e.AgencyID, e.ID, redactPayload(e.Payload, redact), outcome)
+			return err
+		})
+	}
+}
+
+// redactPayload returns payload as JSON with the values of the fields named
+// in redact masked.
+func redactPayload(payload any, redact map[string]bool) string {
+	raw, err := JSONCodec{}.Marshal(payload)
+	if err != nil {
+		return fmt.Sprintf("<%T: %v>", payload, err)
+	}
+	if len(redact) == 0 || raw == "" {
+		return raw
+	}
+	var v any
+	if err := json.Unmarshal([]byte(raw), &v); err != nil {
+		return redacted
+	}
+	out, err := json.Marshal(redactValue(v, redact))
+	if err != nil {
+		return redacted
+	}
+	return string(out)
+}
+
+// redactValue masks the redacted fields of the decoded JSON value v.
+func redactValue(v any, redact map[string]bool) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, member := range val {
+			if redact[strings.ToLower(k)] {
+				val[k] = redacted
+			} else {
+				val[k] = redactValue(member, redact)
+			}
+		}
+	case []any:
+		for i, elem := range val {
+			val[i] = redactValue(elem, redact)
+		}
+	}
+	return v
+}
+
+// MetricsFunc records the outcome of one publish: the event, how long next
+// took, and the error it returned, if any.
+type MetricsFunc func(e Event, elapsed time.Duration, err error)
+
+// Metrics times every publish through next and reports it to observe — for
+// example to increment a counter and a latency histogram labelled by topic.
+func Metrics(observe MetricsFunc) Middleware {
+	return func(next Publisher) Publisher {
+		return PublisherFunc(func(ctx context.Context, e Event) error {
+			start := time.Now()
+			err := next.Publish(ctx, e)
+			observe(e, time.Since(start), err)
+			return err
+		})
+	}
+}
+
+// StartSpanFunc starts a tracing span for publishing e and returns the
+// context carrying it and a function that ends it with the publish error.
+// Adapting a tracing library takes a few lines, e.g. for OpenTelemetry:
+//
+//	func(ctx context.Context, e eventbus.Event) (context.Context, func(error)) {
+//	    ctx, span := tracer.Start(ctx, "publish "+e.Topic)
+//	    return ctx, func(err error) {
+//	        if err != nil {
+//	            span.RecordError(err)
+//	        }
+//	        span.End()
+//	    }
+//	}
+type StartSpanFunc func(ctx context.Context, e Event) (context.Context, func(err error))
+
+// Trace wraps every publish through next in a span started by start, and
+// passes the span's context on to next.
+func Trace(start StartSpanFunc) Middleware {
+	return func(next Publisher) Publisher {
+		return PublisherFunc(func(ctx context.Context, e Event) error {
+			ctx, end := start(ctx, e)
+			err := next.Publish(ctx, e)
+			end(err)
+			return err
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package eventbus_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aosanya/CodeValdSharedLib/eventbus"
+)
+
+// sink records the events that reach the end of a chain.
+type sink struct {
+	events []eventbus.Event
+	ctxs   []context.Context
+	err    error
+}
+
+func (s *sink) Publish(ctx context.Context, e eventbus.Event) error {
+	s.events = append(s.events, e)
+	s.ctxs = append(s.ctxs, ctx)
+	return s.err
+}
+
+func TestChain_AppliesMiddlewareInOrder(t *testing.T) {
+	var order []string
+	mark := func(name string) eventbus.Middleware {
+		return func(next eventbus.Publisher) eventbus.Publisher {
+			return eventbus.PublisherFunc(func(ctx context.Context, e eventbus.Event) error {
+				order = append(order, name)
+				return next.Publish(ctx, e)
+			})
+		}
+	}
+	s := &sink{}
+	if err := eventbus.Chain(s, mark("a"), mark("b"), mark("c")).Publish(context.Background(), eventbus.Event{}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if !slices.Equal(order, []string{"a", "b", "c"}) || len(s.events) != 1 {
+		t.Errorf("order = %v, delivered %d; want a, b, c then the publisher", order, len(s.events))
+	}
+	if eventbus.Chain(s) != eventbus.Publisher(s) {
+		t.Error("Chain without middleware did not return the publisher")
+	}
+}
+
+func TestStamp_FillsMissingFields(t *testing.T) {
+	s := &sink{}
+	p := eventbus.Chain(s, eventbus.Stamp("codevaldwork"))
+	ctx := eventbus.WithCorrelationID(context.Background(), "req-7")
+	_ = p.Publish(ctx, eventbus.Event{Topic: "work.x", AgencyID: "ag"})
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	_ = p.Publish(ctx, eventbus.Event{ID: "e1", Source: "other", CorrelationID: "c1", Timestamp: at})
+
+	got := s.events[0]
+	if got.ID == "" || got.Timestamp.IsZero() || got.Source != "codevaldwork" || got.CorrelationID != "req-7" {
+		t.Errorf("stamped event = %+v", got)
+	}
+	if kept := s.events[1]; kept.ID != "e1" || kept.Source != "other" || kept.CorrelationID != "c1" || !kept.Timestamp.Equal(at) {
+		t.Errorf("event with fields set = %+v, want them kept", kept)
+	}
+}
+
+func TestRequireAgency(t *testing.T) {
+	s := &sink{}
+	required := eventbus.Chain(s, eventbus.RequireAgency())
+	if err := required.Publish(context.Background(), eventbus.Event{Topic: "x"}); !errors.Is(err, eventbus.ErrMissingAgency) {
+		t.Errorf("Publish without agency = %v, want ErrMissingAgency", err)
+	}
+	if err := required.Publish(context.Background(), eventbus.Event{Topic: "x", AgencyID: "ag"}); err != nil {
+		t.Errorf("Publish with agency = %v", err)
+	}
+	fixed := eventbus.Chain(s, eventbus.RequireAgency("ag-1", "ag-2"))
+	if err := fixed.Publish(context.Background(), eventbus.Event{Topic: "x", AgencyID: "ag-3"}); !errors.Is(err, eventbus.ErrAgencyNotAllowed) {
+		t.Errorf("Publish for another agency = %v, want ErrAgencyNotAllowed", err)
+	}
+	if err := fixed.Publish(context.Background(), eventbus.Event{Topic: "x", AgencyID: "ag-2"}); err != nil {
+		t.Errorf("Publish for an allowed agency = %v", err)
+	}
+	if len(s.events) != 2 {
+		t.Errorf("delivered %d events, want only the 2 accepted", len(s.events))
+	}
+}
+
+func TestAllowTopics(t *testing.T) {
+	s := &sink{}
+	p := eventbus.Chain(s, eventbus.AllowTopics([]string{"work.task.created", "work.project.*"}))
+	for topic, allowed := range map[string]bool{
+		"work.task.created":     true,
+		"work.project.archived": true,
+		"work.task.deleted":     false,
+		"work.project":          false,
+	} {
+		err := p.Publish(context.Background(), eventbus.Event{Topic: topic, AgencyID: "ag"})
+		if allowed != (err == nil) || (!allowed && !errors.Is(err, eventbus.ErrTopicNotAllowed)) {
+			t.Errorf("Publish(%s) = %v, allowed %v", topic, err, allowed)
+		}
+	}
+}
+
+func TestSample(t *testing.T) {
+	s := &sink{}
+	p := eventbus.Chain(s, eventbus.Sample(0, "telemetry.#"))
+	for _, topic := range []string{"telemetry.cpu", "work.task.created", "telemetry.mem"} {
+		if err := p.Publish(context.Background(), eventbus.Event{Topic: topic}); err != nil {
+			t.Errorf("Publish(%s) = %v", topic, err)
+		}
+	}
+	if len(s.events) != 1 || s.events[0].Topic != "work.task.created" {
+		t.Errorf("delivered %+v, want only the unsampled topic", s.events)
+	}
+	all := eventbus.Chain(s, eventbus.Sample(1))
+	_ = all.Publish(context.Background(), eventbus.Event{Topic: "telemetry.cpu"})
+	if len(s.events) != 2 {
+		t.Error("Sample(1) dropped an event")
+	}
+}
+
+func TestLog_RedactsPayloadFields(t *testing.T) {
+	var lines []string
+	s := &sink{}
+	p := eventbus.Chain(s, eventbus.Log(eventbus.LogConfig{
+		Redact: []string{"password", "Token"},
+		Logf:   func(format string, args ...any) { lines = append(lines, fmt.Sprintf(format, args...)) },
+	}))
+	payload := map[string]any{"user": "ana", "password": "hunter2", "auth": map[string]any{"token": "abc"}}
+	if err := p.Publish(context.Background(), eventbus.Event{Topic: "agency.user.created", AgencyID: "ag", Payload: payload}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if len(lines) != 1 {
+		t.Fatalf("logged %d lines, want 1", len(lines))
+	}
+	if strings.Contains(lines[0], "hunter2") || strings.Contains(lines[0], "abc") || !strings.Contains(lines[0], `"user":"ana"`) {
+		t.Errorf("log line %q does not redact exactly the configured fields", lines[0])
+	}
+	if payload["password"] != "hunter2" || s.events[0].Payload.(map[string]any)["password"] != "hunter2" {
+		t.Error("Log modified the published payload")
+	}
+
+	lines = nil
+	failuresOnly := 0.0
+	quiet := eventbus.Chain(s, eventbus.Log(eventbus.LogConfig{
+		SampleRate: &failuresOnly,
+		Logf:       func(format string, args ...any) { lines = append(lines, fmt.Sprintf(format, args...)) },
+	}))
+	for range 100 {
+		_ = quiet.Publish(context.Background(), eventbus.Event{Topic: "x"})
+	}
+	if len(lines) != 0 {
+		t.Errorf("SampleRate 0 logged %d successful publishes, want none", len(lines))
+	}
+	s.err = errors.New("cross down")
+	if err := quiet.Publish(context.Background(), eventbus.Event{Topic: "x"}); err == nil {
+		t.Error("Log swallowed the publish error")
+	}
+	if len(lines) != 1 || !strings.Contains(lines[0], "cross down") {
+		t.Errorf("failed publish logged %v, want it logged regardless of sampling", lines)
+	}
+}
+
+func TestMetricsAndTrace(t *testing.T) {
+	boom := errors.New("boom")
+	s := &sink{err: boom}
+	var observed []error
+	var ended []error
+	type spanKey struct{}
+	p := eventbus.Chain(s,
+		eventbus.Metrics(func(e eventbus.Event, elapsed time.Duration, err error) {
+			observed = append(observed, err)
+		}),
+		eventbus.Trace(func(ctx context.Context, e eventbus.Event) (context.Context, func(error)) {
+			return context.WithValue(ctx, spanKey{}, "publish "+e.Topic), func(err error) { ended = append(ended, err) }
+		}),
+	)
+	if err := p.Publish(context.Background(), eventbus.Event{Topic: "work.x"}); !errors.Is(err, boom) {
+		t.Fatalf("Publish = %v, want the publisher's error", err)
+	}
+	if len(observed) != 1 || observed[0] != boom || len(ended) != 1 || ended[0] != boom {
+		t.Errorf("metrics saw %v, span ended with %v; want the error once each", observed, ended)
+	}
+	if s.ctxs[0].Value(spanKey{}) != "publish work.x" {
+		t.Error("the publisher did not receive the span's context")
+	}
+}
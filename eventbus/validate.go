@@ -0,0 +1,30 @@
+package eventbus
+
+import (
+	"context"
+
+	"github.com/aosanya/CodeValdSharedLib/topicschema"
+)
+
+// validatingPublisher is the Publisher returned by NewValidatingPublisher.
+type validatingPublisher struct {
+	next    Publisher
+	schemas topicschema.Set
+}
+
+// NewValidatingPublisher returns a [Publisher] that checks each event's
+// Payload against the schema of its topic in schemas before handing it to
+// next. An invalid payload is not published: Publish returns the
+// *topicschema.ValidationError (errors.Is topicschema.ErrInvalidPayload).
+// Topics without a schema pass through unchecked.
+func NewValidatingPublisher(next Publisher, schemas topicschema.Set) Publisher {
+	return &validatingPublisher{next: next, schemas: schemas}
+}
+
+// Publish validates e.Payload and forwards e to the wrapped Publisher.
+func (p *validatingPublisher) Publish(ctx context.Context, e Event) error {
+	if err := p.schemas.Validate(e.Topic, e.Payload); err != nil {
+		return err
+	}
+	return p.next.Publish(ctx, e)
+}
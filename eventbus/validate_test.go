@@ -0,0 +1,34 @@
+package eventbus_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aosanya/CodeValdSharedLib/eventbus"
+	"github.com/aosanya/CodeValdSharedLib/topicschema"
+)
+
+func TestValidatingPublisher_BlocksInvalidPayloads(t *testing.T) {
+	next := &gatedPublisher{gate: make(chan struct{})}
+	close(next.gate)
+	schemas := topicschema.Set{"work.task.created": {
+		Type:     topicschema.Types{topicschema.TypeObject},
+		Required: []string{"taskId"},
+	}}
+	p := eventbus.NewValidatingPublisher(next, schemas)
+	ctx := context.Background()
+
+	if err := p.Publish(ctx, eventbus.Event{Topic: "work.task.created", Payload: map[string]any{}}); !errors.Is(err, topicschema.ErrInvalidPayload) {
+		t.Errorf("Publish invalid payload: got %v, want ErrInvalidPayload", err)
+	}
+	if err := p.Publish(ctx, eventbus.Event{Topic: "work.task.created", Payload: map[string]any{"taskId": "t1"}}); err != nil {
+		t.Errorf("Publish valid payload: %v", err)
+	}
+	if err := p.Publish(ctx, eventbus.Event{Topic: "unschematised", Payload: 1}); err != nil {
+		t.Errorf("Publish without a schema: %v", err)
+	}
+	if len(next.topics) != 2 {
+		t.Errorf("forwarded %v, want the two valid events", next.topics)
+	}
+}
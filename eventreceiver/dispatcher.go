@@ -0,0 +1,282 @@
+package eventreceiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/aosanya/CodeValdSharedLib/envelope"
+	"github.com/aosanya/CodeValdSharedLib/eventbus"
+	pb "github.com/aosanya/CodeValdSharedLib/gen/go/codevaldshared/v1"
+	"github.com/aosanya/CodeValdSharedLib/topicschema"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Event is a pub/sub event delivered by CodeValdCross, as handed to
+// Dispatcher handlers.
+type Event struct {
+	EventID  string
+	Topic    string
+	AgencyID string
+	Source   string
+	Payload  string // encoded event body, JSON by default
+
+	// Envelope is the CloudEvents envelope the event arrived in, or nil for
+	// a bare payload. When set, Payload holds the envelope's data.
+	Envelope *envelope.Envelope
+}
+
+// EventFromRequest converts a NotifyEvent request to an Event. A payload
+// that is a structured-mode CloudEvents envelope (as sent by
+// eventbus.NewCrossPublisher) is unwrapped: Envelope is set, Payload
+// becomes the envelope's data, and EventID, AgencyID, and Source fall back
+// to the envelope's attributes when the request leaves them empty.
+func EventFromRequest(req *pb.NotifyEventRequest) Event {
+	return unwrap(Event{
+		EventID:  req.GetEventId(),
+		Topic:    req.GetTopic(),
+		AgencyID: req.GetAgencyId(),
+		Source:   req.GetSource(),
+		Payload:  req.GetPayload(),
+	})
+}
+
+// eventFromIncoming converts a NotifyEvent request as EventFromRequest
+// does, and also accepts a binary-mode envelope whose attributes arrive as
+// "ce-" entries of the incoming gRPC metadata (see envelope.FromMetadata).
+func eventFromIncoming(ctx context.Context, req *pb.NotifyEventRequest) Event {
+	ev := EventFromRequest(req)
+	md, ok := metadata.FromIncomingContext(ctx)
+	if ev.Envelope != nil || !ok || len(md.Get("ce-specversion")) == 0 {
+		return ev
+	}
+	headers := make(map[string]string, md.Len())
+	for name, values := range md {
+		if len(values) > 0 {
+			headers[name] = values[0]
+		}
+	}
+	env, err := envelope.FromMetadata(headers, []byte(ev.Payload))
+	if err != nil {
+		return ev
+	}
+	return withEnvelope(ev, env)
+}
+
+// unwrap decodes ev.Payload when it is a structured-mode envelope. A
+// payload that does not decode as one is left as is.
+func unwrap(ev Event) Event {
+	if !envelope.Structured([]byte(ev.Payload)) {
+		return ev
+	}
+	var env envelope.Envelope
+	if err := env.UnmarshalJSON([]byte(ev.Payload)); err != nil {
+		return ev
+	}
+	return withEnvelope(ev, env)
+}
+
+// withEnvelope attaches env to ev, taking the payload from its data.
+func withEnvelope(ev Event, env envelope.Envelope) Event {
+	ev.Envelope = &env
+	ev.Payload = string(env.Data)
+	if ev.EventID == "" {
+		ev.EventID = env.ID
+	}
+	if ev.AgencyID == "" {
+		ev.AgencyID = env.AgencyID
+	}
+	if ev.Source == "" {
+		ev.Source = env.Source
+	}
+	return ev
+}
+
+// wirePayload returns the payload as it arrived: the structured-mode
+// encoding of ev.Envelope when set, so that a stored event keeps its
+// envelope, or ev.Payload.
+func (ev Event) wirePayload() string {
+	if ev.Envelope == nil {
+		return ev.Payload
+	}
+	raw, err := ev.Envelope.MarshalJSON()
+	if err != nil {
+		return ev.Payload
+	}
+	return string(raw)
+}
+
+// HandlerFunc handles an event whose payload is left undecoded.
+type HandlerFunc func(ctx context.Context, ev Event) error
+
+// HandlerError reports that the handler registered for Pattern failed on an
+// event. A panicking handler is reported the same way.
+type HandlerError struct {
+	Pattern string
+	Err     error
+}
+
+// Error implements error.
+func (e *HandlerError) Error() string {
+	return fmt.Sprintf("eventreceiver: handler %q: %v", e.Pattern, e.Err)
+}
+
+// Unwrap returns the handler's error.
+func (e *HandlerError) Unwrap() error { return e.Err }
+
+// DispatcherConfig configures a [Dispatcher].
+type DispatcherConfig struct {
+	// Codec decodes payloads for handlers registered with [Handle].
+	// Defaults to eventbus.JSONCodec, matching eventbus.NewCrossPublisher.
+	Codec eventbus.Codec
+
+	// Schemas, when set, is checked before any handler runs: an event whose
+	// payload does not satisfy the schema of its topic is not handed to the
+	// handlers, and Dispatch returns the *topicschema.ValidationError.
+	// Topics without a schema are not checked.
+	Schemas topicschema.Set
+
+	// OnError is called by NotifyEvent when any handler fails, or the
+	// payload is invalid, with the error Dispatch returned. Defaults to
+	// logging it.
+	OnError func(Event, error)
+}
+
+// route is one registered handler.
+type route struct {
+	pattern string
+	handle  HandlerFunc
+}
+
+// Dispatcher routes events pushed by CodeValdCross to handlers registered
+// by topic pattern, replacing per-service switches over topic strings. It
+// implements the generated EventReceiverServiceServer:
+//
+//	d := eventreceiver.NewDispatcher(eventreceiver.DispatcherConfig{})
+//	eventreceiver.Handle(d, "work.task.*", func(ctx context.Context, ev eventreceiver.Event, p TaskPayload) error {
+//	    ...
+//	})
+//	pb.RegisterEventReceiverServiceServer(grpcServer, d)
+//
+// Every handler whose pattern matches the topic runs, in registration
+// order. Handlers are isolated from one another: an error, panic, or
+// undecodable payload in one is recorded and the rest still run.
+type Dispatcher struct {
+	pb.UnimplementedEventReceiverServiceServer
+	cfg    DispatcherConfig
+	mu     sync.RWMutex
+	routes []route
+}
+
+// NewDispatcher constructs a Dispatcher with no handlers.
+func NewDispatcher(cfg DispatcherConfig) *Dispatcher {
+	if cfg.Codec == nil {
+		cfg.Codec = eventbus.JSONCodec{}
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(ev Event, err error) {
+			log.Printf("eventreceiver: event_id=%s topic=%s: %v", ev.EventID, ev.Topic, err)
+		}
+	}
+	return &Dispatcher{cfg: cfg}
+}
+
+// HandleFunc registers fn for events whose topic matches pattern. It returns
+// an error when pattern is malformed (see ValidatePattern).
+func (d *Dispatcher) HandleFunc(pattern string, fn HandlerFunc) error {
+	if err := ValidatePattern(pattern); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.routes = append(d.routes, route{pattern: pattern, handle: fn})
+	return nil
+}
+
+// Handle registers fn for events whose topic matches pattern, decoding each
+// payload into a fresh T with the Dispatcher's Codec first. A payload that
+// fails to decode is reported as that handler's error; fn is not called.
+func Handle[T any](d *Dispatcher, pattern string, fn func(ctx context.Context, ev Event, payload T) error) error {
+	return d.HandleFunc(pattern, func(ctx context.Context, ev Event) error {
+		var payload T
+		if err := d.cfg.Codec.Unmarshal(ev.Payload, &payload); err != nil {
+			return fmt.Errorf("decode payload as %T: %w", payload, err)
+		}
+		return fn(ctx, ev, payload)
+	})
+}
+
+// Patterns returns the registered patterns, without duplicates, in
+// registration order — the topics to declare as consumed or pass to
+// registrar.SubscribeTopic.
+func (d *Dispatcher) Patterns() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	seen := make(map[string]bool, len(d.routes))
+	var out []string
+	for _, r := range d.routes {
+		if !seen[r.pattern] {
+			seen[r.pattern] = true
+			out = append(out, r.pattern)
+		}
+	}
+	return out
+}
+
+// Dispatch runs every handler matching ev.Topic and returns the number that
+// matched and the joined *HandlerError of those that failed, or nil. When
+// the payload fails the Schemas check no handler runs and the validation
+// error is returned.
+func (d *Dispatcher) Dispatch(ctx context.Context, ev Event) (int, error) {
+	if err := d.cfg.Schemas.ValidateJSON(ev.Topic, ev.Payload); err != nil {
+		return 0, err
+	}
+	d.mu.RLock()
+	var matched []route
+	for _, r := range d.routes {
+		if MatchTopic(r.pattern, ev.Topic) {
+			matched = append(matched, r)
+		}
+	}
+	d.mu.RUnlock()
+
+	var errs []error
+	for _, r := range matched {
+		if err := runHandler(ctx, r.handle, ev); err != nil {
+			errs = append(errs, &HandlerError{Pattern: r.pattern, Err: err})
+		}
+	}
+	return len(matched), errors.Join(errs...)
+}
+
+// runHandler calls fn, converting a panic into an error.
+func runHandler(ctx context.Context, fn HandlerFunc, ev Event) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return fn(ctx, ev)
+}
+
+// NotifyEvent implements pb.EventReceiverServiceServer. It dispatches the
+// event and acknowledges it even when handlers fail or the payload is
+// invalid — the error goes to OnError — so Cross does not redeliver to the
+// handlers that succeeded. A request without a topic is rejected with
+// InvalidArgument. Envelopes are unwrapped as described at
+// [EventFromRequest], including binary-mode envelopes whose attributes
+// arrive as "ce-" gRPC metadata.
+func (d *Dispatcher) NotifyEvent(ctx context.Context, req *pb.NotifyEventRequest) (*pb.NotifyEventResponse, error) {
+	if req.GetTopic() == "" {
+		return nil, status.Error(codes.InvalidArgument, "topic is required")
+	}
+	ev := eventFromIncoming(ctx, req)
+	if _, err := d.Dispatch(ctx, ev); err != nil {
+		d.cfg.OnError(ev, err)
+	}
+	return &pb.NotifyEventResponse{}, nil
+}
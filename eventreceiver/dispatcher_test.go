@@ -0,0 +1,154 @@
+package eventreceiver_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/aosanya/CodeValdSharedLib/eventreceiver"
+	pb "github.com/aosanya/CodeValdSharedLib/gen/go/codevaldshared/v1"
+	"github.com/aosanya/CodeValdSharedLib/topicschema"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMatchTopic(t *testing.T) {
+	cases := []struct {
+		pattern, topic string
+		want           bool
+	}{
+		{"work.task.created", "work.task.created", true},
+		{"work.task.created", "work.task.updated", false},
+		{"work.task.*", "work.task.created", true},
+		{"work.task.*", "work.task", false},
+		{"work.task.*", "work.task.update.status", false},
+		{"work.*.created", "work.task.created", true},
+		{"work.#", "work", true},
+		{"work.#", "work.task.update.status", true},
+		{"work.#", "git.push", false},
+		{"#.created", "work.task.created", true},
+		{"work.#.status", "work.task.update.status", true},
+		{"work.#.status", "work.task.created", false},
+		{"#", "anything.at.all", true},
+		{"work.ta*", "work.task", false},
+	}
+	for _, c := range cases {
+		if got := eventreceiver.MatchTopic(c.pattern, c.topic); got != c.want {
+			t.Errorf("MatchTopic(%q, %q) = %v, want %v", c.pattern, c.topic, got, c.want)
+		}
+	}
+}
+
+func TestValidatePattern(t *testing.T) {
+	for _, p := range []string{"", "work..task", "work.", "work.ta*", "work.#x"} {
+		if eventreceiver.ValidatePattern(p) == nil {
+			t.Errorf("ValidatePattern(%q) = nil, want error", p)
+		}
+	}
+	for _, p := range []string{"work", "work.task.*", "work.#", "#"} {
+		if err := eventreceiver.ValidatePattern(p); err != nil {
+			t.Errorf("ValidatePattern(%q) = %v, want nil", p, err)
+		}
+	}
+}
+
+type taskPayload struct {
+	TaskID string `json:"taskId"`
+}
+
+func TestDispatcher_DecodesAndRoutesByPattern(t *testing.T) {
+	d := eventreceiver.NewDispatcher(eventreceiver.DispatcherConfig{})
+	var got []string
+	if err := eventreceiver.Handle(d, "work.task.*", func(_ context.Context, ev eventreceiver.Event, p taskPayload) error {
+		got = append(got, "task:"+p.TaskID+":"+ev.AgencyID)
+		return nil
+	}); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if err := d.HandleFunc("work.#", func(_ context.Context, ev eventreceiver.Event) error {
+		got = append(got, "all:"+ev.Topic)
+		return nil
+	}); err != nil {
+		t.Fatalf("HandleFunc: %v", err)
+	}
+	if err := d.HandleFunc("work..x", nil); err == nil {
+		t.Error("HandleFunc with a malformed pattern succeeded")
+	}
+
+	_, err := d.NotifyEvent(context.Background(), &pb.NotifyEventRequest{
+		EventId: "e1", Topic: "work.task.created", AgencyId: "ag", Payload: `{"taskId":"t-1"}`,
+	})
+	if err != nil {
+		t.Fatalf("NotifyEvent: %v", err)
+	}
+	want := []string{"task:t-1:ag", "all:work.task.created"}
+	if !slices.Equal(got, want) {
+		t.Errorf("handled %v, want %v", got, want)
+	}
+	if p := d.Patterns(); !slices.Equal(p, []string{"work.task.*", "work.#"}) {
+		t.Errorf("Patterns = %v", p)
+	}
+}
+
+func TestDispatcher_IsolatesHandlerFailures(t *testing.T) {
+	var reported error
+	d := eventreceiver.NewDispatcher(eventreceiver.DispatcherConfig{OnError: func(_ eventreceiver.Event, err error) {
+		reported = err
+	}})
+	boom := errors.New("boom")
+	ran := 0
+	_ = d.HandleFunc("work.#", func(context.Context, eventreceiver.Event) error { return boom })
+	_ = d.HandleFunc("work.#", func(context.Context, eventreceiver.Event) error { panic("oops") })
+	_ = eventreceiver.Handle(d, "work.#", func(context.Context, eventreceiver.Event, taskPayload) error { return nil })
+	_ = d.HandleFunc("work.#", func(context.Context, eventreceiver.Event) error { ran++; return nil })
+
+	n, err := d.Dispatch(context.Background(), eventreceiver.Event{Topic: "work.task.created", Payload: "not json"})
+	if n != 4 {
+		t.Errorf("matched = %d, want 4", n)
+	}
+	if ran != 1 {
+		t.Errorf("last handler ran %d times, want 1", ran)
+	}
+	if !errors.Is(err, boom) {
+		t.Errorf("Dispatch error %v does not wrap the handler error", err)
+	}
+	var herr *eventreceiver.HandlerError
+	if !errors.As(err, &herr) || herr.Pattern != "work.#" {
+		t.Errorf("Dispatch error %v is not a *HandlerError", err)
+	}
+	if got := len(err.(interface{ Unwrap() []error }).Unwrap()); got != 3 {
+		t.Errorf("joined errors = %d, want 3 (error, panic, decode)", got)
+	}
+
+	if _, err := d.NotifyEvent(context.Background(), &pb.NotifyEventRequest{Topic: "work.x", Payload: "{}"}); err != nil {
+		t.Errorf("NotifyEvent with failing handlers: %v, want acknowledged", err)
+	}
+	if reported == nil {
+		t.Error("OnError was not called")
+	}
+}
+
+func TestDispatcher_NotifyEventRequiresTopic(t *testing.T) {
+	d := eventreceiver.NewDispatcher(eventreceiver.DispatcherConfig{})
+	_, err := d.NotifyEvent(context.Background(), &pb.NotifyEventRequest{EventId: "e1"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("NotifyEvent without topic: got %v, want InvalidArgument", err)
+	}
+}
+
+func TestDispatcher_RejectsPayloadsFailingSchema(t *testing.T) {
+	d := eventreceiver.NewDispatcher(eventreceiver.DispatcherConfig{Schemas: topicschema.Set{
+		"work.task.created": {Type: topicschema.Types{topicschema.TypeObject}, Required: []string{"taskId"}},
+	}})
+	ran := 0
+	_ = d.HandleFunc("work.#", func(context.Context, eventreceiver.Event) error { ran++; return nil })
+
+	n, err := d.Dispatch(context.Background(), eventreceiver.Event{Topic: "work.task.created", Payload: `{}`})
+	if n != 0 || !errors.Is(err, topicschema.ErrInvalidPayload) || ran != 0 {
+		t.Errorf("Dispatch invalid = %d, %v (ran %d); want 0, ErrInvalidPayload, no handler", n, err, ran)
+	}
+	if _, err := d.Dispatch(context.Background(), eventreceiver.Event{Topic: "work.task.created", Payload: `{"taskId":"t1"}`}); err != nil || ran != 1 {
+		t.Errorf("Dispatch valid = %v (ran %d); want nil, one handler", err, ran)
+	}
+}
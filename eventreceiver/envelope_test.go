@@ -0,0 +1,128 @@
+package eventreceiver_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/aosanya/CodeValdSharedLib/envelope"
+	"github.com/aosanya/CodeValdSharedLib/eventreceiver"
+	pb "github.com/aosanya/CodeValdSharedLib/gen/go/codevaldshared/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func testEnvelope() envelope.Envelope {
+	return envelope.Envelope{
+		ID: "env-1", Source: "codevaldwork", SpecVersion: envelope.SpecVersion, Type: "work.task.created",
+		AgencyID: "ag", CorrelationID: "req-7", Data: []byte(`{"taskId":"t-1"}`),
+	}
+}
+
+func TestEventFromRequest_UnwrapsStructuredEnvelope(t *testing.T) {
+	raw, _ := json.Marshal(testEnvelope())
+	ev := eventreceiver.EventFromRequest(&pb.NotifyEventRequest{Topic: "work.task.created", Payload: string(raw)})
+	if ev.Envelope == nil || ev.Envelope.CorrelationID != "req-7" {
+		t.Fatalf("Envelope = %+v, want the decoded envelope", ev.Envelope)
+	}
+	if ev.Payload != `{"taskId":"t-1"}` || ev.EventID != "env-1" || ev.AgencyID != "ag" || ev.Source != "codevaldwork" {
+		t.Errorf("event = %+v, want the envelope's data and attributes", ev)
+	}
+
+	bare := eventreceiver.EventFromRequest(&pb.NotifyEventRequest{EventId: "e1", Topic: "x", Payload: `{"a":1}`})
+	if bare.Envelope != nil || bare.Payload != `{"a":1}` {
+		t.Errorf("bare event = %+v, want the payload untouched", bare)
+	}
+}
+
+func TestDispatcher_UnwrapsBinaryEnvelopeFromMetadata(t *testing.T) {
+	d := eventreceiver.NewDispatcher(eventreceiver.DispatcherConfig{})
+	var got taskPayload
+	var correlation string
+	_ = eventreceiver.Handle(d, "work.#", func(_ context.Context, ev eventreceiver.Event, p taskPayload) error {
+		got, correlation = p, ev.Envelope.CorrelationID
+		return nil
+	})
+	headers, body, err := envelope.ToBinary(testEnvelope())
+	if err != nil {
+		t.Fatalf("ToBinary: %v", err)
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.New(headers))
+	if _, err := d.NotifyEvent(ctx, &pb.NotifyEventRequest{EventId: "e1", Topic: "work.task.created", Payload: string(body)}); err != nil {
+		t.Fatalf("NotifyEvent: %v", err)
+	}
+	if got.TaskID != "t-1" || correlation != "req-7" {
+		t.Errorf("handled %+v with correlation %q", got, correlation)
+	}
+}
+
+func TestDispatcher_BinaryEnvelopeOverGRPC(t *testing.T) {
+	d := eventreceiver.NewDispatcher(eventreceiver.DispatcherConfig{})
+	seen := make(chan eventreceiver.Event, 2)
+	_ = d.HandleFunc("work.#", func(_ context.Context, ev eventreceiver.Event) error {
+		seen <- ev
+		return nil
+	})
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	pb.RegisterEventReceiverServiceServer(srv, d)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer conn.Close()
+	client := pb.NewEventReceiverServiceClient(conn)
+
+	text := testEnvelope()
+	text.ID, text.DataContentType, text.Data = "env-2", "text/plain", []byte("hello")
+	for _, env := range []envelope.Envelope{testEnvelope(), text} {
+		md, body, err := envelope.ToMetadata(env)
+		if err != nil {
+			t.Fatalf("ToMetadata: %v", err)
+		}
+		ctx := metadata.NewOutgoingContext(context.Background(), metadata.New(md))
+		if _, err := client.NotifyEvent(ctx, &pb.NotifyEventRequest{Topic: env.Type, Payload: string(body)}); err != nil {
+			t.Fatalf("NotifyEvent: %v", err)
+		}
+		ev := <-seen
+		if ev.Envelope == nil {
+			t.Fatalf("event %+v arrived without its envelope", ev)
+		}
+		if ev.Envelope.DataContentType != env.DataContentType || ev.Payload != string(env.Data) || ev.EventID != env.ID {
+			t.Errorf("received %+v with envelope %+v, want DataContentType %q and data %q",
+				ev, *ev.Envelope, env.DataContentType, env.Data)
+		}
+	}
+}
+
+func TestInbox_KeepsEnvelopeForReplay(t *testing.T) {
+	in, d := newTestInbox(t)
+	var seen []eventreceiver.Event
+	_ = d.HandleFunc("work.#", func(_ context.Context, ev eventreceiver.Event) error {
+		seen = append(seen, ev)
+		return nil
+	})
+	raw, _ := json.Marshal(testEnvelope())
+	if _, err := in.NotifyEvent(context.Background(), &pb.NotifyEventRequest{Topic: "work.task.created", Payload: string(raw)}); err != nil {
+		t.Fatalf("NotifyEvent: %v", err)
+	}
+	if _, err := in.Replay(context.Background(), eventreceiver.ReceivedFilter{}); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if len(seen) != 2 {
+		t.Fatalf("handled %d times, want 2", len(seen))
+	}
+	for _, ev := range seen {
+		if ev.EventID != "env-1" || ev.Payload != `{"taskId":"t-1"}` || ev.Envelope == nil || ev.Envelope.CorrelationID != "req-7" {
+			t.Errorf("handled %+v, want the unwrapped envelope", ev)
+		}
+	}
+}
@@ -1,26 +1,36 @@
 // Package eventreceiver provides the platform-wide standard for services that
 // receive pub/sub events pushed by CodeValdCross via EventReceiverService.NotifyEvent.
+//
+// [Dispatcher] implements that service, routing each event to the handlers
+// registered for a matching topic pattern (see [MatchTopic]). [Inbox] puts a
+// Dispatcher behind an idempotent log: each event is stored as a
+// ReceivedEvent and claimed through an EventClaim before its handlers run,
+// redeliveries are deduplicated by event_id, and every run is recorded as an
+// EventAttempt. [Retrier] retries failed events from that log with
+// exponential backoff and moves those that keep failing to a DeadLetter
+// collection, from which they can be requeued.
 package eventreceiver
 
 import "github.com/aosanya/CodeValdSharedLib/types"
 
 // ReceivedEvent is written by a consumer service immediately upon receiving a
 // NotifyEvent RPC call. It is a pure log: no status field, no mutation after
-// creation.
+// creation. An [Inbox] tracks the handling of the event in an [EventClaim].
 type ReceivedEvent struct {
 	ID         string
 	EventID    string
 	Topic      string
 	AgencyID   string
 	Source     string
-	Payload    string
+	Payload    string // as received; a structured envelope when it came in one
 	ReceivedAt string // RFC3339 UTC
 }
 
 // ReceivedEventTypeDefinition returns the TypeDefinition for the ReceivedEvent
 // entity, scoped to the given service prefix (e.g. "ai" → collection "ai_received_events").
 // Register the returned definition in the service's Schema so the collection is
-// seeded on startup.
+// seeded on startup. event_id is the UniqueKey, so storing a redelivered event
+// a second time fails with entitygraph.ErrEntityAlreadyExists (see [Inbox]).
 func ReceivedEventTypeDefinition(prefix string) types.TypeDefinition {
 	return types.TypeDefinition{
 		Name:              "ReceivedEvent",
@@ -29,6 +39,7 @@ func ReceivedEventTypeDefinition(prefix string) types.TypeDefinition {
 		EntityIDParam:     "receivedEventId",
 		StorageCollection: prefix + "_received_events",
 		Immutable:         true,
+		UniqueKey:         []string{"event_id"},
 		Properties: []types.PropertyDefinition{
 			{Name: "event_id", Type: types.PropertyTypeString, Required: true},
 			{Name: "topic", Type: types.PropertyTypeString, Required: true},
@@ -0,0 +1,607 @@
+package eventreceiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+	pb "github.com/aosanya/CodeValdSharedLib/gen/go/codevaldshared/v1"
+	"github.com/aosanya/CodeValdSharedLib/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Type names of the entities an Inbox writes.
+const (
+	receivedEventType = "ReceivedEvent"
+	eventAttemptType  = "EventAttempt"
+	eventClaimType    = "EventClaim"
+)
+
+// Statuses of an [EventClaim].
+const (
+	// StatusInProgress means a delivery has claimed the event and is running
+	// its handlers.
+	StatusInProgress = "in_progress"
+
+	// StatusDone means the handlers of the last claim succeeded.
+	StatusDone = "done"
+
+	// StatusFailed means the handlers of the last claim failed, or its
+	// outcome could not be recorded; the next delivery or retry claims the
+	// event again.
+	StatusFailed = "failed"
+)
+
+// Outcomes recorded on an [EventAttempt].
+const (
+	// OutcomeSucceeded means every matching handler returned nil, or none
+	// matched.
+	OutcomeSucceeded = "succeeded"
+
+	// OutcomeFailed means at least one matching handler failed.
+	OutcomeFailed = "failed"
+
+	// OutcomeRequeued marks a dead-lettered event handed back for retries by
+	// Retrier.Requeue; no handler ran. Failures before it no longer count
+	// toward RetryPolicy.MaxAttempts.
+	OutcomeRequeued = "requeued"
+)
+
+// timestampLayout is RFC3339 with a fixed-width fraction, so the timestamps
+// an Inbox stores sort as strings in the order they were taken.
+const timestampLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// maxAttemptRecordTries bounds how often recordAttempt renumbers an attempt
+// that collided with one recorded concurrently.
+const maxAttemptRecordTries = 3
+
+// claimTimeout is how long an EventClaim holds off other
+// deliveries. A claim left in progress for longer — by a consumer that
+// stopped mid-delivery — is taken over by the next delivery or retry.
+const claimTimeout = 5 * time.Minute
+
+// errClaimed is returned by claim when another delivery holds a live claim
+// on the event.
+var errClaimed = errors.New("eventreceiver: event is being handled by another delivery")
+
+// EventAttempt records one run of the handlers for a received event. Like
+// ReceivedEvent it is a pure log: each run adds a record and none is ever
+// changed, so the latest attempt carries the event's current outcome.
+type EventAttempt struct {
+	ID        string
+	EventID   string
+	Attempt   int // 1 for the first run
+	Outcome   string
+	Error     string // joined handler errors when Outcome is OutcomeFailed
+	HandledAt string // RFC3339 UTC
+}
+
+// EventAttemptTypeDefinition returns the TypeDefinition for the EventAttempt
+// entity, scoped to the given service prefix (e.g. "ai" → collection
+// "ai_event_attempts"). Register it next to ReceivedEventTypeDefinition when
+// using an [Inbox].
+func EventAttemptTypeDefinition(prefix string) types.TypeDefinition {
+	return types.TypeDefinition{
+		Name:              eventAttemptType,
+		DisplayName:       "Event Attempt",
+		PathSegment:       "event-attempts",
+		EntityIDParam:     "eventAttemptId",
+		StorageCollection: prefix + "_event_attempts",
+		Immutable:         true,
+		UniqueKey:         []string{"event_id", "attempt"},
+		Properties: []types.PropertyDefinition{
+			{Name: "event_id", Type: types.PropertyTypeString, Required: true},
+			{Name: "attempt", Type: types.PropertyTypeInteger, Required: true},
+			{Name: "outcome", Type: types.PropertyTypeString, Required: true},
+			{Name: "error", Type: types.PropertyTypeString},
+			{Name: "handled_at", Type: types.PropertyTypeString, Required: true},
+		},
+	}
+}
+
+// EventClaim tracks which delivery of a received event runs its handlers.
+// Unlike ReceivedEvent and EventAttempt it is changed in place: there is one
+// per event, and each delivery or retry that takes the claim over moves its
+// Status and ClaimedAt.
+type EventClaim struct {
+	ID        string
+	EventID   string
+	Status    string // StatusInProgress, StatusDone or StatusFailed
+	ClaimedAt string // RFC3339 UTC; when the current or last claim was taken
+}
+
+// EventClaimTypeDefinition returns the TypeDefinition for the EventClaim
+// entity, scoped to the given service prefix (e.g. "ai" → collection
+// "ai_event_claims"). Register it next to ReceivedEventTypeDefinition when
+// using an [Inbox]. event_id is the UniqueKey, so only one delivery can
+// create the claim of an event.
+func EventClaimTypeDefinition(prefix string) types.TypeDefinition {
+	return types.TypeDefinition{
+		Name:              eventClaimType,
+		DisplayName:       "Event Claim",
+		PathSegment:       "event-claims",
+		EntityIDParam:     "eventClaimId",
+		StorageCollection: prefix + "_event_claims",
+		UniqueKey:         []string{"event_id"},
+		Properties: []types.PropertyDefinition{
+			{Name: "event_id", Type: types.PropertyTypeString, Required: true},
+			{Name: "status", Type: types.PropertyTypeString, Required: true},
+			{Name: "claimed_at", Type: types.PropertyTypeString, Required: true},
+		},
+	}
+}
+
+// ReceivedFilter selects stored events for [Inbox.List] and [Inbox.Replay],
+// and dead letters for [Retrier.DeadLetters], where From and Until apply to
+// the time the event was dead-lettered. Zero-value fields are ignored.
+type ReceivedFilter struct {
+	// TopicPattern restricts results to topics matching the pattern,
+	// wildcards included (see MatchTopic).
+	TopicPattern string
+
+	// From restricts results to events received at or after this time.
+	From time.Time
+
+	// Until restricts results to events received before this time.
+	Until time.Time
+}
+
+// Inbox is an idempotent [Dispatcher] front: it implements the generated
+// EventReceiverServiceServer by first storing each NotifyEvent request as a
+// ReceivedEvent and claiming it — creating its EventClaim, keyed by the
+// unique event_id, in StatusInProgress — and only then running the
+// Dispatcher's handlers, recording the outcome as an EventAttempt and marking
+// the claim StatusDone or StatusFailed.
+//
+// Only the delivery holding the claim runs the handlers. A redelivered event
+// whose handlers have already succeeded is acknowledged without running them
+// again; one whose last claim failed, or whose claim is older than
+// claimTimeout, is claimed and handled again. A duplicate arriving while
+// another delivery holds the claim is refused with Aborted, so that Cross
+// keeps it pending and redelivers it once the outcome is known. Handler
+// failures are recorded and acknowledged, as by the Dispatcher; only a failed
+// write is returned to Cross, so it keeps the delivery pending and redelivers
+// it.
+//
+// The service schema must include ReceivedEventTypeDefinition,
+// EventAttemptTypeDefinition and EventClaimTypeDefinition.
+type Inbox struct {
+	pb.UnimplementedEventReceiverServiceServer
+	dm       entitygraph.DataManager
+	d        *Dispatcher
+	agencyID string
+	retrier  atomic.Pointer[Retrier] // set by NewRetrier
+}
+
+// NewInbox constructs an Inbox that stores events through dm under agencyID
+// and hands them to d.
+func NewInbox(dm entitygraph.DataManager, d *Dispatcher, agencyID string) *Inbox {
+	return &Inbox{dm: dm, d: d, agencyID: agencyID}
+}
+
+// NotifyEvent implements pb.EventReceiverServiceServer. Envelopes are
+// unwrapped as by [Dispatcher.NotifyEvent] and stored whole, so Replay and
+// the Retrier hand handlers the same Event. A request without a topic, or
+// without an event_id in either the request or its envelope, is rejected
+// with InvalidArgument; a duplicate of an event another delivery is handling
+// returns Aborted; a failed write returns Internal.
+func (in *Inbox) NotifyEvent(ctx context.Context, req *pb.NotifyEventRequest) (*pb.NotifyEventResponse, error) {
+	ev := eventFromIncoming(ctx, req)
+	if ev.EventID == "" || ev.Topic == "" {
+		return nil, status.Error(codes.InvalidArgument, "event_id and topic are required")
+	}
+	row, claimed, err := in.claim(ctx, ev)
+	if errors.Is(err, errClaimed) {
+		return nil, status.Error(codes.Aborted, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if !claimed {
+		return &pb.NotifyEventResponse{}, nil
+	}
+	if _, err := in.process(ctx, ev, row); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.NotifyEventResponse{}, nil
+}
+
+// claim stores ev as a ReceivedEvent, unless it is already stored, and
+// creates its EventClaim in StatusInProgress, or, when the event is already
+// claimed, takes the claim over as takeOver does. It returns the claim row
+// and whether the caller now holds the claim.
+func (in *Inbox) claim(ctx context.Context, ev Event) (entitygraph.Entity, bool, error) {
+	now := time.Now().UTC().Format(timestampLayout)
+	_, err := in.dm.CreateEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: in.agencyID,
+		TypeID:   receivedEventType,
+		Properties: map[string]any{
+			"event_id":    ev.EventID,
+			"topic":       ev.Topic,
+			"agency_id":   ev.AgencyID,
+			"source":      ev.Source,
+			"payload":     ev.wirePayload(),
+			"received_at": now,
+		},
+	})
+	stored := errors.Is(err, entitygraph.ErrEntityAlreadyExists)
+	if err != nil && !stored {
+		return entitygraph.Entity{}, false, fmt.Errorf("eventreceiver: store event %s: %w", ev.EventID, err)
+	}
+	row, err := in.dm.CreateEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: in.agencyID,
+		TypeID:   eventClaimType,
+		Properties: map[string]any{
+			"event_id":   ev.EventID,
+			"status":     StatusInProgress,
+			"claimed_at": now,
+		},
+	})
+	switch {
+	case err == nil && !stored:
+		return row, true, nil
+	case err == nil:
+		// Events stored before claims existed, or by a delivery that stopped
+		// before claiming, are only known to be done by their attempts.
+		return in.settled(ctx, row)
+	case !errors.Is(err, entitygraph.ErrEntityAlreadyExists):
+		return entitygraph.Entity{}, false, fmt.Errorf("eventreceiver: claim event %s: %w", ev.EventID, err)
+	}
+	row, err = in.claimEntity(ctx, ev.EventID)
+	if err != nil {
+		return entitygraph.Entity{}, false, err
+	}
+	return in.takeOver(ctx, row)
+}
+
+// takeOver claims the EventClaim row for another run of its event's
+// handlers, conditionally on the row being unchanged since it was read. It
+// reports false, without claiming, when the handlers have already
+// succeeded, and returns errClaimed when a claim younger than claimTimeout
+// holds the row or a concurrent delivery claimed it first.
+func (in *Inbox) takeOver(ctx context.Context, row entitygraph.Entity) (entitygraph.Entity, bool, error) {
+	eventID := stringProp(row, "event_id")
+	switch stringProp(row, "status") {
+	case StatusDone:
+		return row, false, nil
+	case StatusInProgress:
+		claimedAt, err := time.Parse(timestampLayout, stringProp(row, "claimed_at"))
+		if err == nil && time.Since(claimedAt) < claimTimeout {
+			return row, false, errClaimed
+		}
+	}
+	// Claims that stopped before their outcome was marked are only known to
+	// be done by their attempts.
+	attempts, err := in.Attempts(ctx, eventID)
+	if err != nil {
+		return row, false, err
+	}
+	if succeeded(attempts) {
+		return row, false, nil
+	}
+	claimed, err := in.dm.UpdateEntity(ctx, in.agencyID, row.ID, entitygraph.UpdateEntityRequest{
+		Properties: map[string]any{
+			"status":     StatusInProgress,
+			"claimed_at": time.Now().UTC().Format(timestampLayout),
+		},
+		IfMatch: row.Revision,
+	})
+	switch {
+	case errors.Is(err, entitygraph.ErrConflict):
+		return row, false, errClaimed
+	case err != nil:
+		return row, false, fmt.Errorf("eventreceiver: claim event %s: %w", eventID, err)
+	}
+	return claimed, true, nil
+}
+
+// settled checks the attempts of the event whose claim row was just created
+// over an already stored event. When one succeeded, it marks the claim
+// StatusDone and reports false; otherwise the caller keeps the claim.
+func (in *Inbox) settled(ctx context.Context, row entitygraph.Entity) (entitygraph.Entity, bool, error) {
+	eventID := stringProp(row, "event_id")
+	attempts, err := in.Attempts(ctx, eventID)
+	if err != nil {
+		return row, false, err
+	}
+	if !succeeded(attempts) {
+		return row, true, nil
+	}
+	_, err = in.dm.UpdateEntity(ctx, in.agencyID, row.ID, entitygraph.UpdateEntityRequest{
+		Properties: map[string]any{"status": StatusDone},
+		IfMatch:    row.Revision,
+	})
+	if err != nil && !errors.Is(err, entitygraph.ErrConflict) {
+		return row, false, fmt.Errorf("eventreceiver: mark event %s %s: %w", eventID, StatusDone, err)
+	}
+	return row, false, nil
+}
+
+// process runs the handlers for ev under the claim row, as handle does, and
+// then marks the claim StatusDone or StatusFailed by the attempt's outcome.
+// The mark is conditional on the claim: one taken over after claimTimeout
+// is left to the delivery that took it.
+func (in *Inbox) process(ctx context.Context, ev Event, row entitygraph.Entity) (EventAttempt, error) {
+	attempt, err := in.handle(ctx, ev)
+	outcome := StatusFailed
+	if err == nil && attempt.Outcome == OutcomeSucceeded {
+		outcome = StatusDone
+	}
+	_, merr := in.dm.UpdateEntity(ctx, in.agencyID, row.ID, entitygraph.UpdateEntityRequest{
+		Properties: map[string]any{"status": outcome},
+		IfMatch:    row.Revision,
+	})
+	if err != nil {
+		return attempt, err
+	}
+	if merr != nil && !errors.Is(merr, entitygraph.ErrConflict) {
+		return attempt, fmt.Errorf("eventreceiver: mark event %s %s: %w", ev.EventID, outcome, merr)
+	}
+	return attempt, nil
+}
+
+// handle dispatches ev and records the attempt, returning it, and lets an
+// attached Retrier schedule or clear the event's retry. Handler failures
+// also go to the Dispatcher's OnError; the returned error is only set when
+// the attempt or the retry state could not be written.
+func (in *Inbox) handle(ctx context.Context, ev Event) (EventAttempt, error) {
+	outcome, msg := OutcomeSucceeded, ""
+	if _, herr := in.d.Dispatch(ctx, ev); herr != nil {
+		in.d.cfg.OnError(ev, herr)
+		outcome, msg = OutcomeFailed, herr.Error()
+	}
+	attempt, err := in.recordAttempt(ctx, ev.EventID, outcome, msg)
+	if err != nil {
+		return attempt, err
+	}
+	if r := in.retrier.Load(); r != nil {
+		if err := r.settle(ctx, ev, attempt); err != nil {
+			return attempt, err
+		}
+	}
+	return attempt, nil
+}
+
+// recordAttempt stores the next EventAttempt for eventID, renumbering when a
+// concurrent delivery took the same number.
+func (in *Inbox) recordAttempt(ctx context.Context, eventID, outcome, msg string) (EventAttempt, error) {
+	var err error
+	for range maxAttemptRecordTries {
+		var prior []EventAttempt
+		prior, err = in.Attempts(ctx, eventID)
+		if err != nil {
+			return EventAttempt{}, err
+		}
+		var e entitygraph.Entity
+		e, err = in.dm.CreateEntity(ctx, entitygraph.CreateEntityRequest{
+			AgencyID: in.agencyID,
+			TypeID:   eventAttemptType,
+			Properties: map[string]any{
+				"event_id":   eventID,
+				"attempt":    len(prior) + 1,
+				"outcome":    outcome,
+				"error":      msg,
+				"handled_at": time.Now().UTC().Format(timestampLayout),
+			},
+		})
+		if err == nil {
+			return attemptFromEntity(e), nil
+		}
+		if !errors.Is(err, entitygraph.ErrEntityAlreadyExists) {
+			break
+		}
+	}
+	return EventAttempt{}, fmt.Errorf("eventreceiver: record attempt for event %s: %w", eventID, err)
+}
+
+// Attempts returns the recorded attempts for eventID, oldest first.
+func (in *Inbox) Attempts(ctx context.Context, eventID string) ([]EventAttempt, error) {
+	entities, err := in.dm.ListEntities(ctx, entitygraph.EntityFilter{
+		AgencyID:   in.agencyID,
+		TypeID:     eventAttemptType,
+		Properties: map[string]any{"event_id": eventID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("eventreceiver: list attempts for event %s: %w", eventID, err)
+	}
+	out := make([]EventAttempt, len(entities))
+	for i, e := range entities {
+		out[i] = attemptFromEntity(e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Attempt < out[j].Attempt })
+	return out, nil
+}
+
+// Claim returns the EventClaim of eventID, or an error wrapping
+// entitygraph.ErrEntityNotFound when no delivery has claimed the event yet.
+func (in *Inbox) Claim(ctx context.Context, eventID string) (EventClaim, error) {
+	e, err := in.claimEntity(ctx, eventID)
+	if err != nil {
+		return EventClaim{}, err
+	}
+	return claimFromEntity(e), nil
+}
+
+// List returns the stored events matching f, in the order they were
+// received.
+func (in *Inbox) List(ctx context.Context, f ReceivedFilter) ([]ReceivedEvent, error) {
+	where, err := f.where("received_at")
+	if err != nil {
+		return nil, err
+	}
+	filter := entitygraph.EntityFilter{
+		AgencyID: in.agencyID,
+		TypeID:   receivedEventType,
+		Where:    where,
+		OrderBy:  []entitygraph.OrderBy{{Field: entitygraph.OrderByPropertyPrefix + "received_at"}},
+	}
+	entities, err := in.dm.ListEntities(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("eventreceiver: list received events: %w", err)
+	}
+	var out []ReceivedEvent
+	for _, e := range entities {
+		re := receivedEventFromEntity(e)
+		if f.TopicPattern == "" || MatchTopic(f.TopicPattern, re.Topic) {
+			out = append(out, re)
+		}
+	}
+	return out, nil
+}
+
+// where compiles f to a filter over the topic property and the timestamp
+// property timeProp. A TopicPattern with wildcards is left for the caller to
+// apply with MatchTopic. Returns nil when f selects everything.
+func (f ReceivedFilter) where(timeProp string) (*entitygraph.FilterExpr, error) {
+	var conds []entitygraph.FilterExpr
+	if f.TopicPattern != "" {
+		if err := ValidatePattern(f.TopicPattern); err != nil {
+			return nil, err
+		}
+		if !strings.ContainsAny(f.TopicPattern, WildcardOne+WildcardMany) {
+			conds = append(conds, entitygraph.Eq("topic", f.TopicPattern))
+		}
+	}
+	if !f.From.IsZero() {
+		conds = append(conds, entitygraph.Gte(timeProp, f.From.UTC().Format(timestampLayout)))
+	}
+	if !f.Until.IsZero() {
+		conds = append(conds, entitygraph.Lt(timeProp, f.Until.UTC().Format(timestampLayout)))
+	}
+	if len(conds) == 0 {
+		return nil, nil
+	}
+	where := entitygraph.And(conds...)
+	return &where, nil
+}
+
+// receivedEntity returns the stored ReceivedEvent row of eventID.
+func (in *Inbox) receivedEntity(ctx context.Context, eventID string) (entitygraph.Entity, error) {
+	entities, err := in.dm.ListEntities(ctx, entitygraph.EntityFilter{
+		AgencyID:   in.agencyID,
+		TypeID:     receivedEventType,
+		Properties: map[string]any{"event_id": eventID},
+	})
+	if err != nil {
+		return entitygraph.Entity{}, fmt.Errorf("eventreceiver: get event %s: %w", eventID, err)
+	}
+	if len(entities) == 0 {
+		return entitygraph.Entity{}, fmt.Errorf("eventreceiver: get event %s: %w", eventID, entitygraph.ErrEntityNotFound)
+	}
+	return entities[0], nil
+}
+
+// claimEntity returns the EventClaim row of eventID.
+func (in *Inbox) claimEntity(ctx context.Context, eventID string) (entitygraph.Entity, error) {
+	entities, err := in.dm.ListEntities(ctx, entitygraph.EntityFilter{
+		AgencyID:   in.agencyID,
+		TypeID:     eventClaimType,
+		Properties: map[string]any{"event_id": eventID},
+	})
+	if err != nil {
+		return entitygraph.Entity{}, fmt.Errorf("eventreceiver: get claim of event %s: %w", eventID, err)
+	}
+	if len(entities) == 0 {
+		return entitygraph.Entity{}, fmt.Errorf("eventreceiver: get claim of event %s: %w", eventID, entitygraph.ErrEntityNotFound)
+	}
+	return entities[0], nil
+}
+
+// Replay runs the handlers again for every stored event matching f, in the
+// order they were received, recording an attempt for each — whether or not
+// an earlier attempt succeeded. Replay is an explicit rerun: it neither
+// takes nor waits for claims, and leaves each EventClaim as it was. It
+// returns the number of events replayed.
+// Handler failures go to the Dispatcher's OnError and do not stop the
+// replay; a failed read or write does.
+func (in *Inbox) Replay(ctx context.Context, f ReceivedFilter) (int, error) {
+	events, err := in.List(ctx, f)
+	if err != nil {
+		return 0, err
+	}
+	for i, re := range events {
+		if err := ctx.Err(); err != nil {
+			return i, err
+		}
+		if _, err := in.handle(ctx, re.event()); err != nil {
+			return i, err
+		}
+	}
+	return len(events), nil
+}
+
+// succeeded reports whether any attempt succeeded.
+func succeeded(attempts []EventAttempt) bool {
+	for _, a := range attempts {
+		if a.Outcome == OutcomeSucceeded {
+			return true
+		}
+	}
+	return false
+}
+
+// event returns the Event re was stored from, unwrapping its envelope.
+func (re ReceivedEvent) event() Event {
+	return unwrap(Event{EventID: re.EventID, Topic: re.Topic, AgencyID: re.AgencyID, Source: re.Source, Payload: re.Payload})
+}
+
+// receivedEventFromEntity converts a stored ReceivedEvent entity.
+func receivedEventFromEntity(e entitygraph.Entity) ReceivedEvent {
+	return ReceivedEvent{
+		ID:         e.ID,
+		EventID:    stringProp(e, "event_id"),
+		Topic:      stringProp(e, "topic"),
+		AgencyID:   stringProp(e, "agency_id"),
+		Source:     stringProp(e, "source"),
+		Payload:    stringProp(e, "payload"),
+		ReceivedAt: stringProp(e, "received_at"),
+	}
+}
+
+// claimFromEntity converts a stored EventClaim entity.
+func claimFromEntity(e entitygraph.Entity) EventClaim {
+	return EventClaim{
+		ID:        e.ID,
+		EventID:   stringProp(e, "event_id"),
+		Status:    stringProp(e, "status"),
+		ClaimedAt: stringProp(e, "claimed_at"),
+	}
+}
+
+// attemptFromEntity converts a stored EventAttempt entity.
+func attemptFromEntity(e entitygraph.Entity) EventAttempt {
+	return EventAttempt{
+		ID:        e.ID,
+		EventID:   stringProp(e, "event_id"),
+		Attempt:   intProp(e, "attempt"),
+		Outcome:   stringProp(e, "outcome"),
+		Error:     stringProp(e, "error"),
+		HandledAt: stringProp(e, "handled_at"),
+	}
+}
+
+// stringProp returns the string property name of e, or "".
+func stringProp(e entitygraph.Entity, name string) string {
+	s, _ := e.Properties[name].(string)
+	return s
+}
+
+// intProp returns the integer property name of e, or 0. Stored numbers may
+// come back as float64 after a JSON round trip.
+func intProp(e entitygraph.Entity, name string) int {
+	switch n := e.Properties[name].(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	}
+	return 0
+}
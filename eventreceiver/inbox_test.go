@@ -0,0 +1,213 @@
+package eventreceiver_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+	"github.com/aosanya/CodeValdSharedLib/entitygraph/memory"
+	"github.com/aosanya/CodeValdSharedLib/eventreceiver"
+	pb "github.com/aosanya/CodeValdSharedLib/gen/go/codevaldshared/v1"
+	"github.com/aosanya/CodeValdSharedLib/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newTestInbox returns an Inbox over a fresh memory backend, and the
+// Dispatcher it hands events to.
+func newTestInbox(t *testing.T) (*eventreceiver.Inbox, *eventreceiver.Dispatcher) {
+	t.Helper()
+	dm, _ := memory.New(memory.Config{Schema: types.Schema{Types: []types.TypeDefinition{
+		eventreceiver.ReceivedEventTypeDefinition("test"),
+		eventreceiver.EventAttemptTypeDefinition("test"),
+		eventreceiver.EventClaimTypeDefinition("test"),
+	}}})
+	d := eventreceiver.NewDispatcher(eventreceiver.DispatcherConfig{OnError: func(eventreceiver.Event, error) {}})
+	return eventreceiver.NewInbox(dm, d, "svc-agency"), d
+}
+
+func notify(t *testing.T, in *eventreceiver.Inbox, id, topic string) {
+	t.Helper()
+	if _, err := in.NotifyEvent(context.Background(), &pb.NotifyEventRequest{EventId: id, Topic: topic, AgencyId: "ag", Payload: "{}"}); err != nil {
+		t.Fatalf("NotifyEvent(%s): %v", id, err)
+	}
+}
+
+func TestInbox_SkipsHandledDuplicates(t *testing.T) {
+	in, d := newTestInbox(t)
+	calls := 0
+	_ = d.HandleFunc("work.#", func(context.Context, eventreceiver.Event) error { calls++; return nil })
+
+	notify(t, in, "e1", "work.task.created")
+	notify(t, in, "e1", "work.task.created")
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want 1", calls)
+	}
+	attempts, err := in.Attempts(context.Background(), "e1")
+	if err != nil {
+		t.Fatalf("Attempts: %v", err)
+	}
+	if len(attempts) != 1 || attempts[0].Attempt != 1 || attempts[0].Outcome != eventreceiver.OutcomeSucceeded {
+		t.Errorf("attempts = %+v, want one succeeded attempt", attempts)
+	}
+	events, err := in.List(context.Background(), eventreceiver.ReceivedFilter{})
+	if err != nil || len(events) != 1 || events[0].EventID != "e1" || events[0].AgencyID != "ag" {
+		t.Errorf("List = %+v, %v; want the one stored event", events, err)
+	}
+}
+
+func TestInbox_ConcurrentDuplicatesRunHandlersOnce(t *testing.T) {
+	in, d := newTestInbox(t)
+	var calls atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+	_ = d.HandleFunc("work.#", func(context.Context, eventreceiver.Event) error {
+		calls.Add(1)
+		close(started)
+		<-release
+		return nil
+	})
+
+	req := &pb.NotifyEventRequest{EventId: "e1", Topic: "work.task.created", AgencyId: "ag", Payload: "{}"}
+	errs := make(chan error, 2)
+	for range 2 {
+		go func() {
+			_, err := in.NotifyEvent(context.Background(), req)
+			errs <- err
+		}()
+	}
+	// The handler blocks until released, so the delivery that lost the claim
+	// returns first.
+	if err := <-errs; status.Code(err) != codes.Aborted {
+		t.Errorf("duplicate NotifyEvent: got %v, want Aborted", err)
+	}
+	<-started
+	if c, err := in.Claim(context.Background(), "e1"); err != nil || c.Status != eventreceiver.StatusInProgress {
+		t.Errorf("Claim while handling = %+v, %v; want in progress", c, err)
+	}
+	close(release)
+	if err := <-errs; err != nil {
+		t.Errorf("claiming NotifyEvent: %v", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("handler ran %d times, want 1", n)
+	}
+
+	notify(t, in, "e1", "work.task.created")
+	if n := calls.Load(); n != 1 {
+		t.Errorf("handler ran %d times after redelivery, want 1", n)
+	}
+	if c, err := in.Claim(context.Background(), "e1"); err != nil || c.Status != eventreceiver.StatusDone {
+		t.Errorf("Claim after handling = %+v, %v; want done", c, err)
+	}
+	if events, err := in.List(context.Background(), eventreceiver.ReceivedFilter{}); err != nil || len(events) != 1 {
+		t.Errorf("List after handling = %+v, %v; want one event", events, err)
+	}
+}
+
+func TestInbox_RecordsFailuresAndHandlesRedelivery(t *testing.T) {
+	in, d := newTestInbox(t)
+	fail := true
+	_ = d.HandleFunc("work.#", func(context.Context, eventreceiver.Event) error {
+		if fail {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	notify(t, in, "e1", "work.task.created")
+	if c, err := in.Claim(context.Background(), "e1"); err != nil || c.Status != eventreceiver.StatusFailed {
+		t.Errorf("Claim after failure = %+v, %v; want failed", c, err)
+	}
+	fail = false
+	notify(t, in, "e1", "work.task.created")
+	notify(t, in, "e1", "work.task.created")
+
+	attempts, err := in.Attempts(context.Background(), "e1")
+	if err != nil {
+		t.Fatalf("Attempts: %v", err)
+	}
+	if len(attempts) != 2 {
+		t.Fatalf("attempts = %+v, want 2", attempts)
+	}
+	if attempts[0].Outcome != eventreceiver.OutcomeFailed || attempts[0].Error == "" {
+		t.Errorf("attempt 1 = %+v, want failed with error", attempts[0])
+	}
+	if attempts[1].Attempt != 2 || attempts[1].Outcome != eventreceiver.OutcomeSucceeded {
+		t.Errorf("attempt 2 = %+v, want succeeded", attempts[1])
+	}
+}
+
+func TestInbox_ReplayByTopicAndTimeRange(t *testing.T) {
+	in, d := newTestInbox(t)
+	var replayed []string
+	_ = d.HandleFunc("#", func(_ context.Context, ev eventreceiver.Event) error {
+		replayed = append(replayed, ev.EventID)
+		return nil
+	})
+	notify(t, in, "e1", "work.task.created")
+	notify(t, in, "e2", "git.push")
+	notify(t, in, "e3", "work.task.deleted")
+	replayed = nil
+	ctx := context.Background()
+
+	n, err := in.Replay(ctx, eventreceiver.ReceivedFilter{TopicPattern: "work.task.*", Until: time.Now().Add(time.Hour)})
+	if err != nil || n != 2 {
+		t.Fatalf("Replay = %d, %v; want 2, nil", n, err)
+	}
+	if len(replayed) != 2 || replayed[0] != "e1" || replayed[1] != "e3" {
+		t.Errorf("replayed %v, want [e1 e3]", replayed)
+	}
+	if attempts, _ := in.Attempts(ctx, "e1"); len(attempts) != 2 {
+		t.Errorf("e1 attempts after replay = %d, want 2", len(attempts))
+	}
+
+	if n, err := in.Replay(ctx, eventreceiver.ReceivedFilter{TopicPattern: "git.push", From: time.Now().Add(time.Hour)}); err != nil || n != 0 {
+		t.Errorf("Replay of a future range = %d, %v; want 0, nil", n, err)
+	}
+	if events, err := in.List(ctx, eventreceiver.ReceivedFilter{TopicPattern: "git.push"}); err != nil || len(events) != 1 {
+		t.Errorf("List(git.push) = %+v, %v; want one event", events, err)
+	}
+	if _, err := in.List(ctx, eventreceiver.ReceivedFilter{TopicPattern: "work..x"}); err == nil {
+		t.Error("List with a malformed pattern succeeded")
+	}
+}
+
+func TestInbox_NotifyEventRequiresIDAndTopic(t *testing.T) {
+	in, _ := newTestInbox(t)
+	for _, req := range []*pb.NotifyEventRequest{{Topic: "work.x"}, {EventId: "e1"}} {
+		if _, err := in.NotifyEvent(context.Background(), req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("NotifyEvent(%v): got %v, want InvalidArgument", req, err)
+		}
+	}
+}
+
+func TestReceivedEventTypeDefinition_UniqueEventID(t *testing.T) {
+	td := eventreceiver.ReceivedEventTypeDefinition("ai")
+	if len(td.UniqueKey) != 1 || td.UniqueKey[0] != "event_id" {
+		t.Errorf("UniqueKey = %v, want [event_id]", td.UniqueKey)
+	}
+	schema := types.Schema{Types: []types.TypeDefinition{
+		td,
+		eventreceiver.EventAttemptTypeDefinition("ai"),
+		eventreceiver.EventClaimTypeDefinition("ai"),
+	}}
+	if err := entitygraph.ValidateSchema(schema); err != nil {
+		t.Errorf("inbox schema is invalid: %v", err)
+	}
+}
+
+func TestEventClaimTypeDefinition_MutableAndUniqueEventID(t *testing.T) {
+	td := eventreceiver.EventClaimTypeDefinition("ai")
+	if td.StorageCollection != "ai_event_claims" {
+		t.Errorf("StorageCollection = %q, want ai_event_claims", td.StorageCollection)
+	}
+	if td.Immutable {
+		t.Error("TypeDefinition.Immutable = true, want false so the Inbox can mark the status")
+	}
+	if len(td.UniqueKey) != 1 || td.UniqueKey[0] != "event_id" {
+		t.Errorf("UniqueKey = %v, want [event_id]", td.UniqueKey)
+	}
+}
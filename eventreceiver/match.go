@@ -0,0 +1,30 @@
+package eventreceiver
+
+import "github.com/aosanya/CodeValdSharedLib/eventbus"
+
+// Topic pattern wildcards, matching the semantics CodeValdCross applies to
+// subscriptions. They are shared with eventbus.Local, so a pattern routes
+// the same events in-process as through Cross.
+const (
+	// WildcardOne matches exactly one topic segment:
+	// "work.task.*" matches "work.task.created" but not "work.task".
+	WildcardOne = eventbus.WildcardOne
+
+	// WildcardMany matches zero or more topic segments:
+	// "work.#" matches "work", "work.task" and "work.task.update.status".
+	WildcardMany = eventbus.WildcardMany
+)
+
+// ValidatePattern reports whether pattern is a well-formed topic pattern: a
+// non-empty dot-separated list of non-empty segments, where a segment
+// containing a wildcard is exactly "*" or "#". See eventbus.ValidatePattern.
+func ValidatePattern(pattern string) error {
+	return eventbus.ValidatePattern(pattern)
+}
+
+// MatchTopic reports whether topic matches pattern. A pattern without
+// wildcards matches only the identical topic. Malformed patterns (see
+// ValidatePattern) never match. See eventbus.MatchTopic.
+func MatchTopic(pattern, topic string) bool {
+	return eventbus.MatchTopic(pattern, topic)
+}
@@ -0,0 +1,446 @@
+package eventreceiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph"
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+// Type names of the entities a Retrier writes.
+const (
+	deadLetterType   = "DeadLetter"
+	pendingRetryType = "PendingRetry"
+)
+
+// retryPageSize is how many due PendingRetry rows RetryDue reads at a time.
+const retryPageSize = 100
+
+// Defaults for the zero-valued fields of a RetryPolicy.
+const (
+	defaultRetryMaxAttempts  = 5
+	defaultRetryMinBackoff   = time.Second
+	defaultRetryMaxBackoff   = 5 * time.Minute
+	defaultRetryPollInterval = time.Second
+)
+
+// ErrNotDeadLettered is returned by [Retrier.Requeue] for an event without a
+// live dead letter.
+var ErrNotDeadLettered = errors.New("eventreceiver: event is not dead-lettered")
+
+// DeadLetter is written by a [Retrier] for an event whose handlers kept
+// failing until RetryPolicy.MaxAttempts was reached. It carries the event
+// itself so it can be inspected without the ReceivedEvent, and is never
+// changed: Requeue soft-deletes it, leaving a tombstone as the record.
+type DeadLetter struct {
+	ID             string
+	EventID        string
+	Topic          string
+	AgencyID       string
+	Source         string
+	Payload        string
+	Attempts       int    // failed attempts counted toward MaxAttempts
+	LastError      string // error of the last failed attempt
+	DeadLetteredAt string // RFC3339 UTC
+}
+
+// DeadLetterTypeDefinition returns the TypeDefinition for the DeadLetter
+// entity, scoped to the given service prefix (e.g. "ai" → collection
+// "ai_dead_letters"). Register it next to ReceivedEventTypeDefinition and
+// EventAttemptTypeDefinition when using a [Retrier]. event_id is the
+// UniqueKey, so an event has at most one live dead letter.
+func DeadLetterTypeDefinition(prefix string) types.TypeDefinition {
+	return types.TypeDefinition{
+		Name:              deadLetterType,
+		DisplayName:       "Dead Letter",
+		PathSegment:       "dead-letters",
+		EntityIDParam:     "deadLetterId",
+		StorageCollection: prefix + "_dead_letters",
+		Immutable:         true,
+		UniqueKey:         []string{"event_id"},
+		Properties: []types.PropertyDefinition{
+			{Name: "event_id", Type: types.PropertyTypeString, Required: true},
+			{Name: "topic", Type: types.PropertyTypeString, Required: true},
+			{Name: "agency_id", Type: types.PropertyTypeString},
+			{Name: "source", Type: types.PropertyTypeString},
+			{Name: "payload", Type: types.PropertyTypeString},
+			{Name: "attempts", Type: types.PropertyTypeInteger, Required: true},
+			{Name: "last_error", Type: types.PropertyTypeString},
+			{Name: "dead_lettered_at", Type: types.PropertyTypeString, Required: true},
+		},
+	}
+}
+
+// PendingRetryTypeDefinition returns the TypeDefinition for the PendingRetry
+// entity, scoped to the given service prefix (e.g. "ai" → collection
+// "ai_pending_retries"). Register it next to DeadLetterTypeDefinition when
+// using a [Retrier]. A PendingRetry row exists for every failed event that
+// is waiting for its next attempt, at next_attempt_at; it is removed when
+// the event succeeds or is dead-lettered. event_id is the UniqueKey.
+func PendingRetryTypeDefinition(prefix string) types.TypeDefinition {
+	return types.TypeDefinition{
+		Name:              pendingRetryType,
+		DisplayName:       "Pending Retry",
+		PathSegment:       "pending-retries",
+		EntityIDParam:     "pendingRetryId",
+		StorageCollection: prefix + "_pending_retries",
+		UniqueKey:         []string{"event_id"},
+		Properties: []types.PropertyDefinition{
+			{Name: "event_id", Type: types.PropertyTypeString, Required: true},
+			{Name: "failures", Type: types.PropertyTypeInteger, Required: true},
+			{Name: "next_attempt_at", Type: types.PropertyTypeString, Required: true},
+		},
+	}
+}
+
+// RetryPolicy configures a [Retrier].
+type RetryPolicy struct {
+	// MaxAttempts is how many failed attempts an event gets before it is
+	// dead-lettered, the first delivery included. Defaults to 5.
+	MaxAttempts int
+
+	// MinBackoff and MaxBackoff bound the delay between a failed attempt and
+	// the next; it doubles with every failed attempt. Default to 1s and 5m.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// PollInterval is how long Run waits between passes. Defaults to 1s.
+	PollInterval time.Duration
+
+	// Now returns the current time, against which backoff is scheduled and
+	// due retries are selected. Defaults to time.Now; tests set it to step
+	// through backoff without sleeping.
+	Now func() time.Time
+}
+
+// Retrier retries the events an [Inbox] recorded as failed, with exponential
+// backoff, and dead-letters those still failing after MaxAttempts so a
+// poison event stops being retried. Create it with [NewRetrier] and call Run
+// in a goroutine, or RetryDue from a scheduler.
+//
+// Once attached to its Inbox, every failed attempt the Inbox records
+// schedules the event's next attempt in a PendingRetry row, which a success
+// or dead-lettering removes, so RetryDue reads only the events that are due.
+// All of its state lives in the Inbox's store, so several replicas may run a
+// Retrier over the same store; an event may then occasionally be retried
+// twice, as with any at-least-once delivery. The service schema must include
+// DeadLetterTypeDefinition and PendingRetryTypeDefinition.
+type Retrier struct {
+	in     *Inbox
+	policy RetryPolicy
+}
+
+// NewRetrier constructs a Retrier for the events of in and attaches it to in,
+// so that the failures in records from then on are scheduled for retry.
+// Create it before in starts serving.
+func NewRetrier(in *Inbox, policy RetryPolicy) *Retrier {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = defaultRetryMaxAttempts
+	}
+	if policy.MinBackoff <= 0 {
+		policy.MinBackoff = defaultRetryMinBackoff
+	}
+	if policy.MaxBackoff < policy.MinBackoff {
+		policy.MaxBackoff = max(defaultRetryMaxBackoff, policy.MinBackoff)
+	}
+	if policy.PollInterval <= 0 {
+		policy.PollInterval = defaultRetryPollInterval
+	}
+	if policy.Now == nil {
+		policy.Now = time.Now
+	}
+	r := &Retrier{in: in, policy: policy}
+	in.retrier.Store(r)
+	return r
+}
+
+// Run calls RetryDue every PollInterval until ctx is done, logging errors.
+func (r *Retrier) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.policy.PollInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.RetryDue(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("eventreceiver: retrier: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RetryDue makes one pass over the events whose next attempt is due, page
+// by page: each is handled again, or dead-lettered when it has used up
+// MaxAttempts. It returns the number of events retried. Handler failures
+// are recorded as attempts and are not errors; the error reports a failure
+// to read or write the store.
+func (r *Retrier) RetryDue(ctx context.Context) (int, error) {
+	due := entitygraph.Lte("next_attempt_at", r.policy.Now().UTC().Format(timestampLayout))
+	filter := entitygraph.EntityFilter{
+		AgencyID: r.in.agencyID,
+		TypeID:   pendingRetryType,
+		Where:    &due,
+		OrderBy:  []entitygraph.OrderBy{{Field: entitygraph.OrderByPropertyPrefix + "next_attempt_at"}},
+		Limit:    retryPageSize,
+	}
+	retried := 0
+	for {
+		page, err := r.in.dm.ListEntitiesPage(ctx, filter)
+		if err != nil {
+			return retried, fmt.Errorf("eventreceiver: list pending retries: %w", err)
+		}
+		for _, e := range page.Entities {
+			if err := ctx.Err(); err != nil {
+				return retried, err
+			}
+			ok, err := r.retry(ctx, stringProp(e, "event_id"))
+			if err != nil {
+				return retried, err
+			}
+			if ok {
+				retried++
+			}
+		}
+		if page.NextPageToken == "" {
+			return retried, nil
+		}
+		filter.PageToken = page.NextPageToken
+	}
+}
+
+// retry handles the due event eventID again, or dead-letters it once it has
+// failed MaxAttempts times. A pending retry left behind by an event that has
+// since succeeded or been dead-lettered is removed, and an event a delivery
+// has claimed meanwhile is left to it. It reports whether the handlers ran.
+func (r *Retrier) retry(ctx context.Context, eventID string) (bool, error) {
+	attempts, err := r.in.Attempts(ctx, eventID)
+	if err != nil {
+		return false, err
+	}
+	if len(attempts) == 0 || attempts[len(attempts)-1].Outcome == OutcomeSucceeded {
+		return false, r.unschedule(ctx, eventID)
+	}
+	if _, found, err := r.deadLetter(ctx, eventID); err != nil || found {
+		if err != nil {
+			return false, err
+		}
+		return false, r.unschedule(ctx, eventID)
+	}
+	row, err := r.in.receivedEntity(ctx, eventID)
+	if err != nil {
+		return false, err
+	}
+	ev := receivedEventFromEntity(row).event()
+	if failures := failuresSinceRequeue(attempts); failures >= r.policy.MaxAttempts {
+		return false, r.retire(ctx, ev, failures, attempts[len(attempts)-1].Error)
+	}
+	claim, claimed, err := r.in.claim(ctx, ev)
+	if errors.Is(err, errClaimed) || (err == nil && !claimed) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if _, err := r.in.process(ctx, ev, claim); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// settle updates the retry state of ev after the Inbox recorded attempt: a
+// success removes its pending retry, and a failure schedules the next
+// attempt after the backoff, or dead-letters ev once it has failed
+// MaxAttempts times.
+func (r *Retrier) settle(ctx context.Context, ev Event, attempt EventAttempt) error {
+	if attempt.Outcome == OutcomeSucceeded {
+		return r.unschedule(ctx, ev.EventID)
+	}
+	attempts, err := r.in.Attempts(ctx, ev.EventID)
+	if err != nil {
+		return err
+	}
+	failures := failuresSinceRequeue(attempts)
+	if failures >= r.policy.MaxAttempts {
+		return r.retire(ctx, ev, failures, attempt.Error)
+	}
+	return r.schedule(ctx, ev.EventID, failures, r.policy.Now().Add(r.backoff(failures)))
+}
+
+// schedule records that eventID, having failed failures times, is due for
+// another attempt at next.
+func (r *Retrier) schedule(ctx context.Context, eventID string, failures int, next time.Time) error {
+	_, err := r.in.dm.UpsertEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: r.in.agencyID,
+		TypeID:   pendingRetryType,
+		Properties: map[string]any{
+			"event_id":        eventID,
+			"failures":        failures,
+			"next_attempt_at": next.UTC().Format(timestampLayout),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("eventreceiver: schedule retry of event %s: %w", eventID, err)
+	}
+	return nil
+}
+
+// unschedule removes the pending retry of eventID, if any. One removed
+// concurrently by another Retrier is not an error.
+func (r *Retrier) unschedule(ctx context.Context, eventID string) error {
+	entities, err := r.in.dm.ListEntities(ctx, entitygraph.EntityFilter{
+		AgencyID:   r.in.agencyID,
+		TypeID:     pendingRetryType,
+		Properties: map[string]any{"event_id": eventID},
+	})
+	if err != nil {
+		return fmt.Errorf("eventreceiver: get pending retry of event %s: %w", eventID, err)
+	}
+	for _, e := range entities {
+		err := r.in.dm.DeleteEntity(ctx, r.in.agencyID, e.ID)
+		if err == nil {
+			err = r.in.dm.PurgeEntity(ctx, r.in.agencyID, e.ID)
+		}
+		if err != nil && !errors.Is(err, entitygraph.ErrEntityNotFound) {
+			return fmt.Errorf("eventreceiver: unschedule retry of event %s: %w", eventID, err)
+		}
+	}
+	return nil
+}
+
+// retire dead-letters ev and removes its pending retry.
+func (r *Retrier) retire(ctx context.Context, ev Event, failures int, lastError string) error {
+	if err := r.bury(ctx, ev, failures, lastError); err != nil {
+		return err
+	}
+	return r.unschedule(ctx, ev.EventID)
+}
+
+// failuresSinceRequeue counts the failed attempts after the last requeue.
+func failuresSinceRequeue(attempts []EventAttempt) int {
+	n := 0
+	for _, a := range attempts {
+		switch a.Outcome {
+		case OutcomeFailed:
+			n++
+		case OutcomeRequeued:
+			n = 0
+		}
+	}
+	return n
+}
+
+// backoff returns the delay before the retry that follows the given number
+// of failed attempts.
+func (r *Retrier) backoff(failures int) time.Duration {
+	d := r.policy.MinBackoff
+	for i := 1; i < failures && d < r.policy.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, r.policy.MaxBackoff)
+}
+
+// bury writes the dead letter for ev. A dead letter written concurrently by
+// another Retrier is not an error.
+func (r *Retrier) bury(ctx context.Context, ev Event, failures int, lastError string) error {
+	_, err := r.in.dm.CreateEntity(ctx, entitygraph.CreateEntityRequest{
+		AgencyID: r.in.agencyID,
+		TypeID:   deadLetterType,
+		Properties: map[string]any{
+			"event_id":         ev.EventID,
+			"topic":            ev.Topic,
+			"agency_id":        ev.AgencyID,
+			"source":           ev.Source,
+			"payload":          ev.wirePayload(),
+			"attempts":         failures,
+			"last_error":       lastError,
+			"dead_lettered_at": r.policy.Now().UTC().Format(timestampLayout),
+		},
+	})
+	if err != nil && !errors.Is(err, entitygraph.ErrEntityAlreadyExists) {
+		return fmt.Errorf("eventreceiver: dead-letter event %s: %w", ev.EventID, err)
+	}
+	return nil
+}
+
+// deadLetter returns the live dead letter entity for eventID, if any.
+func (r *Retrier) deadLetter(ctx context.Context, eventID string) (entitygraph.Entity, bool, error) {
+	entities, err := r.in.dm.ListEntities(ctx, entitygraph.EntityFilter{
+		AgencyID:   r.in.agencyID,
+		TypeID:     deadLetterType,
+		Properties: map[string]any{"event_id": eventID},
+	})
+	if err != nil {
+		return entitygraph.Entity{}, false, fmt.Errorf("eventreceiver: get dead letter for event %s: %w", eventID, err)
+	}
+	if len(entities) == 0 {
+		return entitygraph.Entity{}, false, nil
+	}
+	return entities[0], true, nil
+}
+
+// DeadLetters returns the live dead letters whose events match f, oldest
+// first.
+func (r *Retrier) DeadLetters(ctx context.Context, f ReceivedFilter) ([]DeadLetter, error) {
+	where, err := f.where("dead_lettered_at")
+	if err != nil {
+		return nil, err
+	}
+	entities, err := r.in.dm.ListEntities(ctx, entitygraph.EntityFilter{
+		AgencyID: r.in.agencyID,
+		TypeID:   deadLetterType,
+		Where:    where,
+		OrderBy:  []entitygraph.OrderBy{{Field: entitygraph.OrderByPropertyPrefix + "dead_lettered_at"}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("eventreceiver: list dead letters: %w", err)
+	}
+	var out []DeadLetter
+	for _, e := range entities {
+		dl := deadLetterFromEntity(e)
+		if f.TopicPattern == "" || MatchTopic(f.TopicPattern, dl.Topic) {
+			out = append(out, dl)
+		}
+	}
+	return out, nil
+}
+
+// Requeue hands the dead-lettered event eventID back for retries: it
+// soft-deletes the dead letter and records an OutcomeRequeued attempt, which
+// gives the event a fresh MaxAttempts budget, and schedules it at once.
+// Returns ErrNotDeadLettered when the event has no live dead letter.
+func (r *Retrier) Requeue(ctx context.Context, eventID string) error {
+	dl, found, err := r.deadLetter(ctx, eventID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("%w: %s", ErrNotDeadLettered, eventID)
+	}
+	if err := r.in.dm.DeleteEntityIfMatch(ctx, r.in.agencyID, dl.ID, dl.Revision); err != nil {
+		return fmt.Errorf("eventreceiver: requeue event %s: %w", eventID, err)
+	}
+	if _, err := r.in.recordAttempt(ctx, eventID, OutcomeRequeued, ""); err != nil {
+		return err
+	}
+	return r.schedule(ctx, eventID, 0, r.policy.Now())
+}
+
+// deadLetterFromEntity converts a stored DeadLetter entity.
+func deadLetterFromEntity(e entitygraph.Entity) DeadLetter {
+	return DeadLetter{
+		ID:             e.ID,
+		EventID:        stringProp(e, "event_id"),
+		Topic:          stringProp(e, "topic"),
+		AgencyID:       stringProp(e, "agency_id"),
+		Source:         stringProp(e, "source"),
+		Payload:        stringProp(e, "payload"),
+		Attempts:       intProp(e, "attempts"),
+		LastError:      stringProp(e, "last_error"),
+		DeadLetteredAt: stringProp(e, "dead_lettered_at"),
+	}
+}
@@ -0,0 +1,160 @@
+package eventreceiver_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aosanya/CodeValdSharedLib/entitygraph/memory"
+	"github.com/aosanya/CodeValdSharedLib/eventreceiver"
+	pb "github.com/aosanya/CodeValdSharedLib/gen/go/codevaldshared/v1"
+	"github.com/aosanya/CodeValdSharedLib/types"
+)
+
+// testClock is a RetryPolicy.Now that moves only when advanced.
+type testClock struct{ now atomic.Int64 }
+
+func (c *testClock) Now() time.Time { return time.Unix(0, c.now.Load()) }
+
+// advance moves the clock past the test Retrier's backoff.
+func (c *testClock) advance() { c.now.Add(int64(time.Minute)) }
+
+// newTestRetrier returns a Retrier with a one-second backoff, on a clock
+// that only advance moves, over a fresh Inbox whose only handler fails
+// while fail is set.
+func newTestRetrier(t *testing.T, maxAttempts int, fail *atomic.Bool) (*eventreceiver.Retrier, *eventreceiver.Inbox, *testClock) {
+	t.Helper()
+	dm, _ := memory.New(memory.Config{Schema: types.Schema{Types: []types.TypeDefinition{
+		eventreceiver.ReceivedEventTypeDefinition("test"),
+		eventreceiver.EventAttemptTypeDefinition("test"),
+		eventreceiver.EventClaimTypeDefinition("test"),
+		eventreceiver.DeadLetterTypeDefinition("test"),
+		eventreceiver.PendingRetryTypeDefinition("test"),
+	}}})
+	d := eventreceiver.NewDispatcher(eventreceiver.DispatcherConfig{OnError: func(eventreceiver.Event, error) {}})
+	_ = d.HandleFunc("work.#", func(context.Context, eventreceiver.Event) error {
+		if fail.Load() {
+			return errors.New("poison")
+		}
+		return nil
+	})
+	in := eventreceiver.NewInbox(dm, d, "svc-agency")
+	clock := &testClock{}
+	clock.now.Store(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano())
+	r := eventreceiver.NewRetrier(in, eventreceiver.RetryPolicy{
+		MaxAttempts: maxAttempts, MinBackoff: time.Second, MaxBackoff: time.Second, Now: clock.Now,
+	})
+	return r, in, clock
+}
+
+func TestRetrier_RetriesAfterBackoff(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	r, in, clock := newTestRetrier(t, 3, &fail)
+	ctx := context.Background()
+	notify(t, in, "e1", "work.task.created")
+
+	if n, err := r.RetryDue(ctx); err != nil || n != 0 {
+		t.Fatalf("RetryDue before backoff = %d, %v; want 0, nil", n, err)
+	}
+	fail.Store(false)
+	clock.advance()
+	if n, err := r.RetryDue(ctx); err != nil || n != 1 {
+		t.Fatalf("RetryDue after backoff = %d, %v; want 1, nil", n, err)
+	}
+	if n, err := r.RetryDue(ctx); err != nil || n != 0 {
+		t.Errorf("RetryDue after success = %d, %v; want 0, nil", n, err)
+	}
+	attempts, _ := in.Attempts(ctx, "e1")
+	if len(attempts) != 2 || attempts[1].Outcome != eventreceiver.OutcomeSucceeded {
+		t.Errorf("attempts = %+v, want a failure then a success", attempts)
+	}
+}
+
+func TestRetrier_DeadLettersAndRequeues(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	r, in, clock := newTestRetrier(t, 2, &fail)
+	ctx := context.Background()
+	notify(t, in, "e1", "work.task.created")
+
+	clock.advance()
+	if n, err := r.RetryDue(ctx); err != nil || n != 1 {
+		t.Fatalf("RetryDue = %d, %v; want 1, nil", n, err)
+	}
+	dls, err := r.DeadLetters(ctx, eventreceiver.ReceivedFilter{TopicPattern: "work.#"})
+	if err != nil {
+		t.Fatalf("DeadLetters: %v", err)
+	}
+	if len(dls) != 1 || dls[0].EventID != "e1" || dls[0].Attempts != 2 || dls[0].LastError == "" || dls[0].Payload != "{}" {
+		t.Fatalf("dead letters = %+v, want e1 after 2 attempts", dls)
+	}
+	clock.advance()
+	if n, err := r.RetryDue(ctx); err != nil || n != 0 {
+		t.Errorf("RetryDue of a dead-lettered event = %d, %v; want 0, nil", n, err)
+	}
+
+	fail.Store(false)
+	if err := r.Requeue(ctx, "e1"); err != nil {
+		t.Fatalf("Requeue: %v", err)
+	}
+	if dls, _ := r.DeadLetters(ctx, eventreceiver.ReceivedFilter{}); len(dls) != 0 {
+		t.Errorf("dead letters after Requeue = %+v, want none", dls)
+	}
+	if n, err := r.RetryDue(ctx); err != nil || n != 1 {
+		t.Fatalf("RetryDue after Requeue = %d, %v; want 1, nil", n, err)
+	}
+	attempts, _ := in.Attempts(ctx, "e1")
+	if got := attempts[len(attempts)-1]; got.Outcome != eventreceiver.OutcomeSucceeded {
+		t.Errorf("last attempt = %+v, want succeeded", got)
+	}
+	if err := r.Requeue(ctx, "e1"); !errors.Is(err, eventreceiver.ErrNotDeadLettered) {
+		t.Errorf("Requeue of a live event: got %v, want ErrNotDeadLettered", err)
+	}
+}
+
+func TestRetrier_PoisonEventDoesNotBlockOthers(t *testing.T) {
+	var fail atomic.Bool
+	r, in, _ := newTestRetrier(t, 1, &fail)
+	ctx := context.Background()
+	fail.Store(true)
+	notify(t, in, "poison", "work.task.created")
+	fail.Store(false)
+	if _, err := in.NotifyEvent(ctx, &pb.NotifyEventRequest{EventId: "ok", Topic: "work.task.created"}); err != nil {
+		t.Fatalf("NotifyEvent: %v", err)
+	}
+	if _, err := r.RetryDue(ctx); err != nil {
+		t.Fatalf("RetryDue: %v", err)
+	}
+	dls, _ := r.DeadLetters(ctx, eventreceiver.ReceivedFilter{})
+	if len(dls) != 1 || dls[0].EventID != "poison" {
+		t.Errorf("dead letters = %+v, want only the poison event", dls)
+	}
+	if attempts, _ := in.Attempts(ctx, "ok"); len(attempts) != 1 || attempts[0].Outcome != eventreceiver.OutcomeSucceeded {
+		t.Errorf("ok attempts = %+v, want one success", attempts)
+	}
+}
+
+func TestRetrier_PagesThroughDueEvents(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	r, in, clock := newTestRetrier(t, 3, &fail)
+	ctx := context.Background()
+	const events = 250
+	for i := range events {
+		notify(t, in, fmt.Sprintf("e%d", i), "work.task.created")
+	}
+
+	fail.Store(false)
+	clock.advance()
+	if n, err := r.RetryDue(ctx); err != nil || n != events {
+		t.Fatalf("RetryDue = %d, %v; want %d, nil", n, err, events)
+	}
+	clock.advance()
+	if n, err := r.RetryDue(ctx); err != nil || n != 0 {
+		t.Errorf("RetryDue after every event succeeded = %d, %v; want 0, nil", n, err)
+	}
+}
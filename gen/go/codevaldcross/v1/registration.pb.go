@@ -386,7 +386,12 @@ type RegisterRequest struct {
 	// produces_hash is SHA-256(sorted produces joined by "\n"), hex-encoded.
 	// Cross forwards this to PubSubService.RegisterTopics alongside the full
 	// produces list so PubSub can skip DB upserts on repeated heartbeats.
-	ProducesHash  string `protobuf:"bytes,7,opt,name=produces_hash,json=producesHash,proto3" json:"produces_hash,omitempty"`
+	ProducesHash string `protobuf:"bytes,7,opt,name=produces_hash,json=producesHash,proto3" json:"produces_hash,omitempty"`
+	// topic_schemas maps each consumed topic to a human-readable description of
+	// its expected payload fields. Injected into the LLM system prompt so agents
+	// know exactly what to include when emitting an action for that topic.
+	// Example: {"git.branch.create": "{repository: string, name: string, from_branch?: string}"}
+	TopicSchemas  map[string]string `protobuf:"bytes,8,rep,name=topic_schemas,json=topicSchemas,proto3" json:"topic_schemas,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -470,6 +475,13 @@ func (x *RegisterRequest) GetProducesHash() string {
 	return ""
 }
 
+func (x *RegisterRequest) GetTopicSchemas() map[string]string {
+	if x != nil {
+		return x.TopicSchemas
+	}
+	return nil
+}
+
 // RegisterResponse is intentionally empty.
 // A successful RPC response is the confirmation of availability.
 type RegisterResponse struct {
@@ -746,7 +758,7 @@ const file_codevaldcross_v1_registration_proto_rawDesc = "" +
 	"grpcMethod\x12B\n" +
 	"\rpath_bindings\x18\x05 \x03(\v2\x1d.codevaldcross.v1.PathBindingR\fpathBindings\x12N\n" +
 	"\x11constant_bindings\x18\x06 \x03(\v2!.codevaldcross.v1.ConstantBindingR\x10constantBindings\x12\x19\n" +
-	"\bis_write\x18\a \x01(\bR\aisWrite\"\xfe\x01\n" +
+	"\bis_write\x18\a \x01(\bR\aisWrite\"\x99\x03\n" +
 	"\x0fRegisterRequest\x12!\n" +
 	"\fservice_name\x18\x01 \x01(\tR\vserviceName\x12\x1a\n" +
 	"\bproduces\x18\x02 \x03(\tR\bproduces\x12\x1a\n" +
@@ -754,7 +766,11 @@ const file_codevaldcross_v1_registration_proto_rawDesc = "" +
 	"\x04addr\x18\x04 \x01(\tR\x04addr\x12\x1b\n" +
 	"\tagency_id\x18\x05 \x01(\tR\bagencyId\x12:\n" +
 	"\x06routes\x18\x06 \x03(\v2\".codevaldcross.v1.RouteDeclarationR\x06routes\x12#\n" +
-	"\rproduces_hash\x18\a \x01(\tR\fproducesHash\"\x12\n" +
+	"\rproduces_hash\x18\a \x01(\tR\fproducesHash\x12X\n" +
+	"\rtopic_schemas\x18\b \x03(\v23.codevaldcross.v1.RegisterRequest.TopicSchemasEntryR\ftopicSchemas\x1a?\n" +
+	"\x11TopicSchemasEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x12\n" +
 	"\x10RegisterResponse\"\x88\x01\n" +
 	"\x15SubscribeTopicRequest\x12\x1b\n" +
 	"\tagency_id\x18\x01 \x01(\tR\bagencyId\x12-\n" +
@@ -785,7 +801,7 @@ func file_codevaldcross_v1_registration_proto_rawDescGZIP() []byte {
 	return file_codevaldcross_v1_registration_proto_rawDescData
 }
 
-var file_codevaldcross_v1_registration_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_codevaldcross_v1_registration_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
 var file_codevaldcross_v1_registration_proto_goTypes = []any{
 	(*PublishEventRequest)(nil),    // 0: codevaldcross.v1.PublishEventRequest
 	(*PublishEventResponse)(nil),   // 1: codevaldcross.v1.PublishEventResponse
@@ -798,24 +814,26 @@ var file_codevaldcross_v1_registration_proto_goTypes = []any{
 	(*SubscribeTopicResponse)(nil), // 8: codevaldcross.v1.SubscribeTopicResponse
 	(*CreateOrgRoleRequest)(nil),   // 9: codevaldcross.v1.CreateOrgRoleRequest
 	(*CreateOrgRoleResponse)(nil),  // 10: codevaldcross.v1.CreateOrgRoleResponse
+	nil,                            // 11: codevaldcross.v1.RegisterRequest.TopicSchemasEntry
 }
 var file_codevaldcross_v1_registration_proto_depIdxs = []int32{
 	2,  // 0: codevaldcross.v1.RouteDeclaration.path_bindings:type_name -> codevaldcross.v1.PathBinding
 	3,  // 1: codevaldcross.v1.RouteDeclaration.constant_bindings:type_name -> codevaldcross.v1.ConstantBinding
 	4,  // 2: codevaldcross.v1.RegisterRequest.routes:type_name -> codevaldcross.v1.RouteDeclaration
-	5,  // 3: codevaldcross.v1.OrchestratorService.Register:input_type -> codevaldcross.v1.RegisterRequest
-	0,  // 4: codevaldcross.v1.OrchestratorService.Publish:input_type -> codevaldcross.v1.PublishEventRequest
-	7,  // 5: codevaldcross.v1.OrchestratorService.SubscribeTopic:input_type -> codevaldcross.v1.SubscribeTopicRequest
-	9,  // 6: codevaldcross.v1.OrchestratorService.CreateOrgRole:input_type -> codevaldcross.v1.CreateOrgRoleRequest
-	6,  // 7: codevaldcross.v1.OrchestratorService.Register:output_type -> codevaldcross.v1.RegisterResponse
-	1,  // 8: codevaldcross.v1.OrchestratorService.Publish:output_type -> codevaldcross.v1.PublishEventResponse
-	8,  // 9: codevaldcross.v1.OrchestratorService.SubscribeTopic:output_type -> codevaldcross.v1.SubscribeTopicResponse
-	10, // 10: codevaldcross.v1.OrchestratorService.CreateOrgRole:output_type -> codevaldcross.v1.CreateOrgRoleResponse
-	7,  // [7:11] is the sub-list for method output_type
-	3,  // [3:7] is the sub-list for method input_type
-	3,  // [3:3] is the sub-list for extension type_name
-	3,  // [3:3] is the sub-list for extension extendee
-	0,  // [0:3] is the sub-list for field type_name
+	11, // 3: codevaldcross.v1.RegisterRequest.topic_schemas:type_name -> codevaldcross.v1.RegisterRequest.TopicSchemasEntry
+	5,  // 4: codevaldcross.v1.OrchestratorService.Register:input_type -> codevaldcross.v1.RegisterRequest
+	0,  // 5: codevaldcross.v1.OrchestratorService.Publish:input_type -> codevaldcross.v1.PublishEventRequest
+	7,  // 6: codevaldcross.v1.OrchestratorService.SubscribeTopic:input_type -> codevaldcross.v1.SubscribeTopicRequest
+	9,  // 7: codevaldcross.v1.OrchestratorService.CreateOrgRole:input_type -> codevaldcross.v1.CreateOrgRoleRequest
+	6,  // 8: codevaldcross.v1.OrchestratorService.Register:output_type -> codevaldcross.v1.RegisterResponse
+	1,  // 9: codevaldcross.v1.OrchestratorService.Publish:output_type -> codevaldcross.v1.PublishEventResponse
+	8,  // 10: codevaldcross.v1.OrchestratorService.SubscribeTopic:output_type -> codevaldcross.v1.SubscribeTopicResponse
+	10, // 11: codevaldcross.v1.OrchestratorService.CreateOrgRole:output_type -> codevaldcross.v1.CreateOrgRoleResponse
+	8,  // [8:12] is the sub-list for method output_type
+	4,  // [4:8] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_codevaldcross_v1_registration_proto_init() }
@@ -829,7 +847,7 @@ func file_codevaldcross_v1_registration_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_codevaldcross_v1_registration_proto_rawDesc), len(file_codevaldcross_v1_registration_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   11,
+			NumMessages:   12,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
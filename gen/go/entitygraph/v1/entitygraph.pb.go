@@ -32,13 +32,21 @@ const (
 // EntityItem is a generic entity document.
 // Properties are represented as an arbitrary JSON object.
 type EntityItem struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	AgencyId      string                 `protobuf:"bytes,2,opt,name=agency_id,json=agencyId,proto3" json:"agency_id,omitempty"`
-	TypeId        string                 `protobuf:"bytes,3,opt,name=type_id,json=typeId,proto3" json:"type_id,omitempty"`
-	Properties    *structpb.Struct       `protobuf:"bytes,4,opt,name=properties,proto3" json:"properties,omitempty"`
-	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Id         string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	AgencyId   string                 `protobuf:"bytes,2,opt,name=agency_id,json=agencyId,proto3" json:"agency_id,omitempty"`
+	TypeId     string                 `protobuf:"bytes,3,opt,name=type_id,json=typeId,proto3" json:"type_id,omitempty"`
+	Properties *structpb.Struct       `protobuf:"bytes,4,opt,name=properties,proto3" json:"properties,omitempty"`
+	CreatedAt  *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt  *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// deleted is true for a soft-deleted entity; only returned by
+	// ListDeletedEntities or when include_deleted is set.
+	Deleted bool `protobuf:"varint,7,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	// deleted_at is when the entity was soft-deleted; unset for live entities.
+	DeletedAt *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`
+	// revision changes on every write; pass it as if_match to make an update
+	// or delete conditional on the entity being unchanged.
+	Revision      string `protobuf:"bytes,9,opt,name=revision,proto3" json:"revision,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -115,16 +123,43 @@ func (x *EntityItem) GetUpdatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *EntityItem) GetDeleted() bool {
+	if x != nil {
+		return x.Deleted
+	}
+	return false
+}
+
+func (x *EntityItem) GetDeletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeletedAt
+	}
+	return nil
+}
+
+func (x *EntityItem) GetRevision() string {
+	if x != nil {
+		return x.Revision
+	}
+	return ""
+}
+
 // RelationshipItem is a directed graph edge between two entities.
 type RelationshipItem struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	AgencyId      string                 `protobuf:"bytes,2,opt,name=agency_id,json=agencyId,proto3" json:"agency_id,omitempty"`
-	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
-	FromId        string                 `protobuf:"bytes,4,opt,name=from_id,json=fromId,proto3" json:"from_id,omitempty"`
-	ToId          string                 `protobuf:"bytes,5,opt,name=to_id,json=toId,proto3" json:"to_id,omitempty"`
-	Properties    *structpb.Struct       `protobuf:"bytes,6,opt,name=properties,proto3" json:"properties,omitempty"`
-	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Id         string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	AgencyId   string                 `protobuf:"bytes,2,opt,name=agency_id,json=agencyId,proto3" json:"agency_id,omitempty"`
+	Name       string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	FromId     string                 `protobuf:"bytes,4,opt,name=from_id,json=fromId,proto3" json:"from_id,omitempty"`
+	ToId       string                 `protobuf:"bytes,5,opt,name=to_id,json=toId,proto3" json:"to_id,omitempty"`
+	Properties *structpb.Struct       `protobuf:"bytes,6,opt,name=properties,proto3" json:"properties,omitempty"`
+	CreatedAt  *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// derived is true when the edge was written automatically as the inverse of
+	// another edge rather than created directly.
+	Derived bool `protobuf:"varint,8,opt,name=derived,proto3" json:"derived,omitempty"`
+	// pair_id is the ID of the counterpart edge (inverse or primary); empty when
+	// the relationship declares no inverse.
+	PairId        string `protobuf:"bytes,9,opt,name=pair_id,json=pairId,proto3" json:"pair_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -208,6 +243,146 @@ func (x *RelationshipItem) GetCreatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *RelationshipItem) GetDerived() bool {
+	if x != nil {
+		return x.Derived
+	}
+	return false
+}
+
+func (x *RelationshipItem) GetPairId() string {
+	if x != nil {
+		return x.PairId
+	}
+	return ""
+}
+
+// OrderBy is one sort key of a list request. field is "created_at",
+// "updated_at" (entities only), or "properties.<name>".
+type OrderBy struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Field         string                 `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	Desc          bool                   `protobuf:"varint,2,opt,name=desc,proto3" json:"desc,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderBy) Reset() {
+	*x = OrderBy{}
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderBy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderBy) ProtoMessage() {}
+
+func (x *OrderBy) ProtoReflect() protoreflect.Message {
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderBy.ProtoReflect.Descriptor instead.
+func (*OrderBy) Descriptor() ([]byte, []int) {
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *OrderBy) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *OrderBy) GetDesc() bool {
+	if x != nil {
+		return x.Desc
+	}
+	return false
+}
+
+// FilterExpr is one node of a typed filter tree. Comparison nodes (op "eq",
+// "ne", "lt", "lte", "gt", "gte", "in", "contains", "prefix", "exists") set
+// property and value — a list value for "in", no value for "exists". Logical
+// nodes (op "and", "or", "not") set operands instead.
+type FilterExpr struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Op            string                 `protobuf:"bytes,1,opt,name=op,proto3" json:"op,omitempty"`
+	Property      string                 `protobuf:"bytes,2,opt,name=property,proto3" json:"property,omitempty"`
+	Value         *structpb.Value        `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	Operands      []*FilterExpr          `protobuf:"bytes,4,rep,name=operands,proto3" json:"operands,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FilterExpr) Reset() {
+	*x = FilterExpr{}
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FilterExpr) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FilterExpr) ProtoMessage() {}
+
+func (x *FilterExpr) ProtoReflect() protoreflect.Message {
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FilterExpr.ProtoReflect.Descriptor instead.
+func (*FilterExpr) Descriptor() ([]byte, []int) {
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *FilterExpr) GetOp() string {
+	if x != nil {
+		return x.Op
+	}
+	return ""
+}
+
+func (x *FilterExpr) GetProperty() string {
+	if x != nil {
+		return x.Property
+	}
+	return ""
+}
+
+func (x *FilterExpr) GetValue() *structpb.Value {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *FilterExpr) GetOperands() []*FilterExpr {
+	if x != nil {
+		return x.Operands
+	}
+	return nil
+}
+
 // ListEntitiesRequest selects all entities of a given type for the agency.
 // type_id is injected at dispatch time via ConstantBinding — HTTP callers never
 // set it explicitly.
@@ -218,14 +393,28 @@ type ListEntitiesRequest struct {
 	// properties is an optional property-level filter. When set, only entities
 	// whose stored properties contain all of the specified key-value pairs are
 	// returned. Injected at dispatch time from the URL path via PathBinding.
-	Properties    *structpb.Struct `protobuf:"bytes,3,opt,name=properties,proto3" json:"properties,omitempty"`
+	Properties *structpb.Struct `protobuf:"bytes,3,opt,name=properties,proto3" json:"properties,omitempty"`
+	// include_deleted also returns soft-deleted entities.
+	IncludeDeleted bool `protobuf:"varint,4,opt,name=include_deleted,json=includeDeleted,proto3" json:"include_deleted,omitempty"`
+	// page_size caps the number of entities returned; 0 returns every match.
+	PageSize int32 `protobuf:"varint,5,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// page_token continues a previous listing from its next_page_token. It is
+	// only valid with the same order_by.
+	PageToken string `protobuf:"bytes,6,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// order_by sorts the results; ties are broken by entity ID.
+	OrderBy []*OrderBy `protobuf:"bytes,7,rep,name=order_by,json=orderBy,proto3" json:"order_by,omitempty"`
+	// include_total asks for total_size to be filled in.
+	IncludeTotal bool `protobuf:"varint,8,opt,name=include_total,json=includeTotal,proto3" json:"include_total,omitempty"`
+	// filter restricts results to entities matching the expression tree, in
+	// addition to properties. Invalid trees return INVALID_ARGUMENT.
+	Filter        *FilterExpr `protobuf:"bytes,9,opt,name=filter,proto3" json:"filter,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ListEntitiesRequest) Reset() {
 	*x = ListEntitiesRequest{}
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[2]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -237,7 +426,7 @@ func (x *ListEntitiesRequest) String() string {
 func (*ListEntitiesRequest) ProtoMessage() {}
 
 func (x *ListEntitiesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[2]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -250,7 +439,7 @@ func (x *ListEntitiesRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListEntitiesRequest.ProtoReflect.Descriptor instead.
 func (*ListEntitiesRequest) Descriptor() ([]byte, []int) {
-	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{2}
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *ListEntitiesRequest) GetAgencyId() string {
@@ -274,17 +463,64 @@ func (x *ListEntitiesRequest) GetProperties() *structpb.Struct {
 	return nil
 }
 
+func (x *ListEntitiesRequest) GetIncludeDeleted() bool {
+	if x != nil {
+		return x.IncludeDeleted
+	}
+	return false
+}
+
+func (x *ListEntitiesRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListEntitiesRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListEntitiesRequest) GetOrderBy() []*OrderBy {
+	if x != nil {
+		return x.OrderBy
+	}
+	return nil
+}
+
+func (x *ListEntitiesRequest) GetIncludeTotal() bool {
+	if x != nil {
+		return x.IncludeTotal
+	}
+	return false
+}
+
+func (x *ListEntitiesRequest) GetFilter() *FilterExpr {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
 // ListEntitiesResponse wraps the result slice.
 type ListEntitiesResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Entities      []*EntityItem          `protobuf:"bytes,1,rep,name=entities,proto3" json:"entities,omitempty"`
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Entities []*EntityItem          `protobuf:"bytes,1,rep,name=entities,proto3" json:"entities,omitempty"`
+	// next_page_token continues the listing; empty on the last page.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	// total_size is the number of matches across all pages; only set when
+	// include_total was requested.
+	TotalSize     int64 `protobuf:"varint,3,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ListEntitiesResponse) Reset() {
 	*x = ListEntitiesResponse{}
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[3]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -296,7 +532,7 @@ func (x *ListEntitiesResponse) String() string {
 func (*ListEntitiesResponse) ProtoMessage() {}
 
 func (x *ListEntitiesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[3]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -309,7 +545,7 @@ func (x *ListEntitiesResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListEntitiesResponse.ProtoReflect.Descriptor instead.
 func (*ListEntitiesResponse) Descriptor() ([]byte, []int) {
-	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{3}
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *ListEntitiesResponse) GetEntities() []*EntityItem {
@@ -319,6 +555,20 @@ func (x *ListEntitiesResponse) GetEntities() []*EntityItem {
 	return nil
 }
 
+func (x *ListEntitiesResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+func (x *ListEntitiesResponse) GetTotalSize() int64 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
 // CreateEntityRequest creates a new entity of the route-bound type.
 // type_id is injected at dispatch time via ConstantBinding.
 type CreateEntityRequest struct {
@@ -332,7 +582,7 @@ type CreateEntityRequest struct {
 
 func (x *CreateEntityRequest) Reset() {
 	*x = CreateEntityRequest{}
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[4]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -344,7 +594,7 @@ func (x *CreateEntityRequest) String() string {
 func (*CreateEntityRequest) ProtoMessage() {}
 
 func (x *CreateEntityRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[4]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -357,7 +607,7 @@ func (x *CreateEntityRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateEntityRequest.ProtoReflect.Descriptor instead.
 func (*CreateEntityRequest) Descriptor() ([]byte, []int) {
-	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{4}
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *CreateEntityRequest) GetAgencyId() string {
@@ -394,7 +644,7 @@ type GetEntityRequest struct {
 
 func (x *GetEntityRequest) Reset() {
 	*x = GetEntityRequest{}
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[5]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -406,7 +656,7 @@ func (x *GetEntityRequest) String() string {
 func (*GetEntityRequest) ProtoMessage() {}
 
 func (x *GetEntityRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[5]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -419,7 +669,7 @@ func (x *GetEntityRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetEntityRequest.ProtoReflect.Descriptor instead.
 func (*GetEntityRequest) Descriptor() ([]byte, []int) {
-	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{5}
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *GetEntityRequest) GetAgencyId() string {
@@ -446,18 +696,21 @@ func (x *GetEntityRequest) GetTypeId() string {
 // UpdateEntityRequest patches the properties of an existing entity.
 // type_id is injected at dispatch time via ConstantBinding.
 type UpdateEntityRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	AgencyId      string                 `protobuf:"bytes,1,opt,name=agency_id,json=agencyId,proto3" json:"agency_id,omitempty"`
-	EntityId      string                 `protobuf:"bytes,2,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
-	TypeId        string                 `protobuf:"bytes,3,opt,name=type_id,json=typeId,proto3" json:"type_id,omitempty"`
-	Properties    *structpb.Struct       `protobuf:"bytes,4,opt,name=properties,proto3" json:"properties,omitempty"`
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	AgencyId   string                 `protobuf:"bytes,1,opt,name=agency_id,json=agencyId,proto3" json:"agency_id,omitempty"`
+	EntityId   string                 `protobuf:"bytes,2,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
+	TypeId     string                 `protobuf:"bytes,3,opt,name=type_id,json=typeId,proto3" json:"type_id,omitempty"`
+	Properties *structpb.Struct       `protobuf:"bytes,4,opt,name=properties,proto3" json:"properties,omitempty"`
+	// if_match, when set, rejects the update with ABORTED unless it equals the
+	// entity's current revision.
+	IfMatch       string `protobuf:"bytes,5,opt,name=if_match,json=ifMatch,proto3" json:"if_match,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *UpdateEntityRequest) Reset() {
 	*x = UpdateEntityRequest{}
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[6]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -469,7 +722,7 @@ func (x *UpdateEntityRequest) String() string {
 func (*UpdateEntityRequest) ProtoMessage() {}
 
 func (x *UpdateEntityRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[6]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -482,7 +735,7 @@ func (x *UpdateEntityRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateEntityRequest.ProtoReflect.Descriptor instead.
 func (*UpdateEntityRequest) Descriptor() ([]byte, []int) {
-	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{6}
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *UpdateEntityRequest) GetAgencyId() string {
@@ -513,18 +766,28 @@ func (x *UpdateEntityRequest) GetProperties() *structpb.Struct {
 	return nil
 }
 
+func (x *UpdateEntityRequest) GetIfMatch() string {
+	if x != nil {
+		return x.IfMatch
+	}
+	return ""
+}
+
 // DeleteEntityRequest soft-deletes an entity by its ID.
 type DeleteEntityRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	AgencyId      string                 `protobuf:"bytes,1,opt,name=agency_id,json=agencyId,proto3" json:"agency_id,omitempty"`
-	EntityId      string                 `protobuf:"bytes,2,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	AgencyId string                 `protobuf:"bytes,1,opt,name=agency_id,json=agencyId,proto3" json:"agency_id,omitempty"`
+	EntityId string                 `protobuf:"bytes,2,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
+	// if_match, when set, rejects the delete with ABORTED unless it equals the
+	// entity's current revision.
+	IfMatch       string `protobuf:"bytes,3,opt,name=if_match,json=ifMatch,proto3" json:"if_match,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *DeleteEntityRequest) Reset() {
 	*x = DeleteEntityRequest{}
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[7]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -536,7 +799,7 @@ func (x *DeleteEntityRequest) String() string {
 func (*DeleteEntityRequest) ProtoMessage() {}
 
 func (x *DeleteEntityRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[7]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -549,7 +812,7 @@ func (x *DeleteEntityRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteEntityRequest.ProtoReflect.Descriptor instead.
 func (*DeleteEntityRequest) Descriptor() ([]byte, []int) {
-	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{7}
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *DeleteEntityRequest) GetAgencyId() string {
@@ -566,6 +829,13 @@ func (x *DeleteEntityRequest) GetEntityId() string {
 	return ""
 }
 
+func (x *DeleteEntityRequest) GetIfMatch() string {
+	if x != nil {
+		return x.IfMatch
+	}
+	return ""
+}
+
 // DeleteEntityResponse is intentionally empty.
 type DeleteEntityResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -575,7 +845,7 @@ type DeleteEntityResponse struct {
 
 func (x *DeleteEntityResponse) Reset() {
 	*x = DeleteEntityResponse{}
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[8]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -587,7 +857,7 @@ func (x *DeleteEntityResponse) String() string {
 func (*DeleteEntityResponse) ProtoMessage() {}
 
 func (x *DeleteEntityResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[8]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -600,36 +870,35 @@ func (x *DeleteEntityResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteEntityResponse.ProtoReflect.Descriptor instead.
 func (*DeleteEntityResponse) Descriptor() ([]byte, []int) {
-	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{8}
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{10}
 }
 
-// ListRelationshipsRequest selects all outbound edges from a source entity
-// with a given relationship name.
-// name is injected at dispatch time via ConstantBinding.
-type ListRelationshipsRequest struct {
+// RestoreEntityRequest clears the soft-delete flag on an entity.
+// type_id is injected at dispatch time via ConstantBinding.
+type RestoreEntityRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	AgencyId      string                 `protobuf:"bytes,1,opt,name=agency_id,json=agencyId,proto3" json:"agency_id,omitempty"`
 	EntityId      string                 `protobuf:"bytes,2,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
-	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	TypeId        string                 `protobuf:"bytes,3,opt,name=type_id,json=typeId,proto3" json:"type_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListRelationshipsRequest) Reset() {
-	*x = ListRelationshipsRequest{}
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[9]
+func (x *RestoreEntityRequest) Reset() {
+	*x = RestoreEntityRequest{}
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListRelationshipsRequest) String() string {
+func (x *RestoreEntityRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListRelationshipsRequest) ProtoMessage() {}
+func (*RestoreEntityRequest) ProtoMessage() {}
 
-func (x *ListRelationshipsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[9]
+func (x *RestoreEntityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -640,55 +909,56 @@ func (x *ListRelationshipsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListRelationshipsRequest.ProtoReflect.Descriptor instead.
-func (*ListRelationshipsRequest) Descriptor() ([]byte, []int) {
-	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use RestoreEntityRequest.ProtoReflect.Descriptor instead.
+func (*RestoreEntityRequest) Descriptor() ([]byte, []int) {
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *ListRelationshipsRequest) GetAgencyId() string {
+func (x *RestoreEntityRequest) GetAgencyId() string {
 	if x != nil {
 		return x.AgencyId
 	}
 	return ""
 }
 
-func (x *ListRelationshipsRequest) GetEntityId() string {
+func (x *RestoreEntityRequest) GetEntityId() string {
 	if x != nil {
 		return x.EntityId
 	}
 	return ""
 }
 
-func (x *ListRelationshipsRequest) GetName() string {
+func (x *RestoreEntityRequest) GetTypeId() string {
 	if x != nil {
-		return x.Name
+		return x.TypeId
 	}
 	return ""
 }
 
-// ListRelationshipsResponse wraps the result slice.
-type ListRelationshipsResponse struct {
+// PurgeEntityRequest permanently removes a soft-deleted entity.
+type PurgeEntityRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Relationships []*RelationshipItem    `protobuf:"bytes,1,rep,name=relationships,proto3" json:"relationships,omitempty"`
+	AgencyId      string                 `protobuf:"bytes,1,opt,name=agency_id,json=agencyId,proto3" json:"agency_id,omitempty"`
+	EntityId      string                 `protobuf:"bytes,2,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListRelationshipsResponse) Reset() {
-	*x = ListRelationshipsResponse{}
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[10]
+func (x *PurgeEntityRequest) Reset() {
+	*x = PurgeEntityRequest{}
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListRelationshipsResponse) String() string {
+func (x *PurgeEntityRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListRelationshipsResponse) ProtoMessage() {}
+func (*PurgeEntityRequest) ProtoMessage() {}
 
-func (x *ListRelationshipsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[10]
+func (x *PurgeEntityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -699,9 +969,299 @@ func (x *ListRelationshipsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListRelationshipsResponse.ProtoReflect.Descriptor instead.
-func (*ListRelationshipsResponse) Descriptor() ([]byte, []int) {
-	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use PurgeEntityRequest.ProtoReflect.Descriptor instead.
+func (*PurgeEntityRequest) Descriptor() ([]byte, []int) {
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *PurgeEntityRequest) GetAgencyId() string {
+	if x != nil {
+		return x.AgencyId
+	}
+	return ""
+}
+
+func (x *PurgeEntityRequest) GetEntityId() string {
+	if x != nil {
+		return x.EntityId
+	}
+	return ""
+}
+
+// PurgeEntityResponse is intentionally empty.
+type PurgeEntityResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PurgeEntityResponse) Reset() {
+	*x = PurgeEntityResponse{}
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PurgeEntityResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PurgeEntityResponse) ProtoMessage() {}
+
+func (x *PurgeEntityResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PurgeEntityResponse.ProtoReflect.Descriptor instead.
+func (*PurgeEntityResponse) Descriptor() ([]byte, []int) {
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{13}
+}
+
+// PurgeDeletedBeforeRequest permanently removes every entity of the agency
+// soft-deleted before cutoff.
+type PurgeDeletedBeforeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AgencyId      string                 `protobuf:"bytes,1,opt,name=agency_id,json=agencyId,proto3" json:"agency_id,omitempty"`
+	Cutoff        *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=cutoff,proto3" json:"cutoff,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PurgeDeletedBeforeRequest) Reset() {
+	*x = PurgeDeletedBeforeRequest{}
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PurgeDeletedBeforeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PurgeDeletedBeforeRequest) ProtoMessage() {}
+
+func (x *PurgeDeletedBeforeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PurgeDeletedBeforeRequest.ProtoReflect.Descriptor instead.
+func (*PurgeDeletedBeforeRequest) Descriptor() ([]byte, []int) {
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *PurgeDeletedBeforeRequest) GetAgencyId() string {
+	if x != nil {
+		return x.AgencyId
+	}
+	return ""
+}
+
+func (x *PurgeDeletedBeforeRequest) GetCutoff() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Cutoff
+	}
+	return nil
+}
+
+// PurgeDeletedBeforeResponse reports how many entities were purged.
+type PurgeDeletedBeforeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Purged        int32                  `protobuf:"varint,1,opt,name=purged,proto3" json:"purged,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PurgeDeletedBeforeResponse) Reset() {
+	*x = PurgeDeletedBeforeResponse{}
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PurgeDeletedBeforeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PurgeDeletedBeforeResponse) ProtoMessage() {}
+
+func (x *PurgeDeletedBeforeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PurgeDeletedBeforeResponse.ProtoReflect.Descriptor instead.
+func (*PurgeDeletedBeforeResponse) Descriptor() ([]byte, []int) {
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *PurgeDeletedBeforeResponse) GetPurged() int32 {
+	if x != nil {
+		return x.Purged
+	}
+	return 0
+}
+
+// ListRelationshipsRequest selects all outbound edges from a source entity
+// with a given relationship name.
+// name is injected at dispatch time via ConstantBinding.
+type ListRelationshipsRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	AgencyId string                 `protobuf:"bytes,1,opt,name=agency_id,json=agencyId,proto3" json:"agency_id,omitempty"`
+	EntityId string                 `protobuf:"bytes,2,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
+	Name     string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	// page_size, page_token, order_by and include_total page the result as in
+	// ListEntitiesRequest; order_by accepts "created_at" and property keys.
+	PageSize      int32      `protobuf:"varint,4,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken     string     `protobuf:"bytes,5,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	OrderBy       []*OrderBy `protobuf:"bytes,6,rep,name=order_by,json=orderBy,proto3" json:"order_by,omitempty"`
+	IncludeTotal  bool       `protobuf:"varint,7,opt,name=include_total,json=includeTotal,proto3" json:"include_total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRelationshipsRequest) Reset() {
+	*x = ListRelationshipsRequest{}
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRelationshipsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRelationshipsRequest) ProtoMessage() {}
+
+func (x *ListRelationshipsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRelationshipsRequest.ProtoReflect.Descriptor instead.
+func (*ListRelationshipsRequest) Descriptor() ([]byte, []int) {
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListRelationshipsRequest) GetAgencyId() string {
+	if x != nil {
+		return x.AgencyId
+	}
+	return ""
+}
+
+func (x *ListRelationshipsRequest) GetEntityId() string {
+	if x != nil {
+		return x.EntityId
+	}
+	return ""
+}
+
+func (x *ListRelationshipsRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ListRelationshipsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListRelationshipsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListRelationshipsRequest) GetOrderBy() []*OrderBy {
+	if x != nil {
+		return x.OrderBy
+	}
+	return nil
+}
+
+func (x *ListRelationshipsRequest) GetIncludeTotal() bool {
+	if x != nil {
+		return x.IncludeTotal
+	}
+	return false
+}
+
+// ListRelationshipsResponse wraps the result slice.
+type ListRelationshipsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Relationships []*RelationshipItem    `protobuf:"bytes,1,rep,name=relationships,proto3" json:"relationships,omitempty"`
+	// next_page_token continues the listing; empty on the last page.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	// total_size is the number of matches across all pages; only set when
+	// include_total was requested.
+	TotalSize     int64 `protobuf:"varint,3,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRelationshipsResponse) Reset() {
+	*x = ListRelationshipsResponse{}
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRelationshipsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRelationshipsResponse) ProtoMessage() {}
+
+func (x *ListRelationshipsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRelationshipsResponse.ProtoReflect.Descriptor instead.
+func (*ListRelationshipsResponse) Descriptor() ([]byte, []int) {
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *ListRelationshipsResponse) GetRelationships() []*RelationshipItem {
@@ -711,6 +1271,20 @@ func (x *ListRelationshipsResponse) GetRelationships() []*RelationshipItem {
 	return nil
 }
 
+func (x *ListRelationshipsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+func (x *ListRelationshipsResponse) GetTotalSize() int64 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
 // CreateRelationshipRequest creates a directed edge from entity_id to to_id.
 // name is injected at dispatch time via ConstantBinding.
 type CreateRelationshipRequest struct {
@@ -726,7 +1300,7 @@ type CreateRelationshipRequest struct {
 
 func (x *CreateRelationshipRequest) Reset() {
 	*x = CreateRelationshipRequest{}
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[11]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -738,7 +1312,7 @@ func (x *CreateRelationshipRequest) String() string {
 func (*CreateRelationshipRequest) ProtoMessage() {}
 
 func (x *CreateRelationshipRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[11]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -751,7 +1325,7 @@ func (x *CreateRelationshipRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateRelationshipRequest.ProtoReflect.Descriptor instead.
 func (*CreateRelationshipRequest) Descriptor() ([]byte, []int) {
-	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{11}
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *CreateRelationshipRequest) GetAgencyId() string {
@@ -801,7 +1375,7 @@ type DeleteRelationshipRequest struct {
 
 func (x *DeleteRelationshipRequest) Reset() {
 	*x = DeleteRelationshipRequest{}
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[12]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -813,7 +1387,7 @@ func (x *DeleteRelationshipRequest) String() string {
 func (*DeleteRelationshipRequest) ProtoMessage() {}
 
 func (x *DeleteRelationshipRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[12]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -826,7 +1400,7 @@ func (x *DeleteRelationshipRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteRelationshipRequest.ProtoReflect.Descriptor instead.
 func (*DeleteRelationshipRequest) Descriptor() ([]byte, []int) {
-	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{12}
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *DeleteRelationshipRequest) GetAgencyId() string {
@@ -859,7 +1433,7 @@ type DeleteRelationshipResponse struct {
 
 func (x *DeleteRelationshipResponse) Reset() {
 	*x = DeleteRelationshipResponse{}
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[13]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -871,7 +1445,7 @@ func (x *DeleteRelationshipResponse) String() string {
 func (*DeleteRelationshipResponse) ProtoMessage() {}
 
 func (x *DeleteRelationshipResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[13]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -884,7 +1458,7 @@ func (x *DeleteRelationshipResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteRelationshipResponse.ProtoReflect.Descriptor instead.
 func (*DeleteRelationshipResponse) Descriptor() ([]byte, []int) {
-	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{13}
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{20}
 }
 
 // GetRelationshipRequest retrieves a single relationship by its ID.
@@ -898,7 +1472,7 @@ type GetRelationshipRequest struct {
 
 func (x *GetRelationshipRequest) Reset() {
 	*x = GetRelationshipRequest{}
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[14]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -910,7 +1484,7 @@ func (x *GetRelationshipRequest) String() string {
 func (*GetRelationshipRequest) ProtoMessage() {}
 
 func (x *GetRelationshipRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[14]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -923,7 +1497,7 @@ func (x *GetRelationshipRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetRelationshipRequest.ProtoReflect.Descriptor instead.
 func (*GetRelationshipRequest) Descriptor() ([]byte, []int) {
-	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{14}
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *GetRelationshipRequest) GetAgencyId() string {
@@ -958,7 +1532,7 @@ type TraverseGraphRequest struct {
 
 func (x *TraverseGraphRequest) Reset() {
 	*x = TraverseGraphRequest{}
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[15]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[22]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -970,7 +1544,7 @@ func (x *TraverseGraphRequest) String() string {
 func (*TraverseGraphRequest) ProtoMessage() {}
 
 func (x *TraverseGraphRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[15]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[22]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -983,7 +1557,7 @@ func (x *TraverseGraphRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TraverseGraphRequest.ProtoReflect.Descriptor instead.
 func (*TraverseGraphRequest) Descriptor() ([]byte, []int) {
-	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{15}
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{22}
 }
 
 func (x *TraverseGraphRequest) GetAgencyId() string {
@@ -1032,7 +1606,7 @@ type TraverseGraphResponse struct {
 
 func (x *TraverseGraphResponse) Reset() {
 	*x = TraverseGraphResponse{}
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[16]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1044,7 +1618,7 @@ func (x *TraverseGraphResponse) String() string {
 func (*TraverseGraphResponse) ProtoMessage() {}
 
 func (x *TraverseGraphResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[16]
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1057,7 +1631,7 @@ func (x *TraverseGraphResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TraverseGraphResponse.ProtoReflect.Descriptor instead.
 func (*TraverseGraphResponse) Descriptor() ([]byte, []int) {
-	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{16}
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{23}
 }
 
 func (x *TraverseGraphResponse) GetVertices() []*EntityItem {
@@ -1074,11 +1648,887 @@ func (x *TraverseGraphResponse) GetEdges() []*RelationshipItem {
 	return nil
 }
 
+// SearchEntitiesRequest runs a full-text search over the Searchable
+// properties of the route-bound type. type_id is injected at dispatch time via
+// ConstantBinding; query (and optionally properties and limit) come from the
+// URL query string of the GET …/search route.
+type SearchEntitiesRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	AgencyId string                 `protobuf:"bytes,1,opt,name=agency_id,json=agencyId,proto3" json:"agency_id,omitempty"`
+	TypeId   string                 `protobuf:"bytes,2,opt,name=type_id,json=typeId,proto3" json:"type_id,omitempty"`
+	Query    string                 `protobuf:"bytes,3,opt,name=query,proto3" json:"query,omitempty"`
+	// properties restricts the search to some of the type's searchable
+	// properties; empty searches all of them.
+	Properties []string `protobuf:"bytes,4,rep,name=properties,proto3" json:"properties,omitempty"`
+	// limit caps the number of hits; 0 uses the server default.
+	Limit         int32 `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchEntitiesRequest) Reset() {
+	*x = SearchEntitiesRequest{}
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchEntitiesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchEntitiesRequest) ProtoMessage() {}
+
+func (x *SearchEntitiesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchEntitiesRequest.ProtoReflect.Descriptor instead.
+func (*SearchEntitiesRequest) Descriptor() ([]byte, []int) {
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *SearchEntitiesRequest) GetAgencyId() string {
+	if x != nil {
+		return x.AgencyId
+	}
+	return ""
+}
+
+func (x *SearchEntitiesRequest) GetTypeId() string {
+	if x != nil {
+		return x.TypeId
+	}
+	return ""
+}
+
+func (x *SearchEntitiesRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchEntitiesRequest) GetProperties() []string {
+	if x != nil {
+		return x.Properties
+	}
+	return nil
+}
+
+func (x *SearchEntitiesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// TextSpan is the half-open byte range [start, end) of a matched word.
+type TextSpan struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         int32                  `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           int32                  `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TextSpan) Reset() {
+	*x = TextSpan{}
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TextSpan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TextSpan) ProtoMessage() {}
+
+func (x *TextSpan) ProtoReflect() protoreflect.Message {
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TextSpan.ProtoReflect.Descriptor instead.
+func (*TextSpan) Descriptor() ([]byte, []int) {
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *TextSpan) GetStart() int32 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *TextSpan) GetEnd() int32 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+// SearchHighlight locates the matched words within one property value.
+type SearchHighlight struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Property      string                 `protobuf:"bytes,1,opt,name=property,proto3" json:"property,omitempty"`
+	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	Spans         []*TextSpan            `protobuf:"bytes,3,rep,name=spans,proto3" json:"spans,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchHighlight) Reset() {
+	*x = SearchHighlight{}
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchHighlight) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchHighlight) ProtoMessage() {}
+
+func (x *SearchHighlight) ProtoReflect() protoreflect.Message {
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchHighlight.ProtoReflect.Descriptor instead.
+func (*SearchHighlight) Descriptor() ([]byte, []int) {
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *SearchHighlight) GetProperty() string {
+	if x != nil {
+		return x.Property
+	}
+	return ""
+}
+
+func (x *SearchHighlight) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *SearchHighlight) GetSpans() []*TextSpan {
+	if x != nil {
+		return x.Spans
+	}
+	return nil
+}
+
+// SearchHit is one ranked search result.
+type SearchHit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entity        *EntityItem            `protobuf:"bytes,1,opt,name=entity,proto3" json:"entity,omitempty"`
+	Score         float64                `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`
+	Highlights    []*SearchHighlight     `protobuf:"bytes,3,rep,name=highlights,proto3" json:"highlights,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchHit) Reset() {
+	*x = SearchHit{}
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchHit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchHit) ProtoMessage() {}
+
+func (x *SearchHit) ProtoReflect() protoreflect.Message {
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchHit.ProtoReflect.Descriptor instead.
+func (*SearchHit) Descriptor() ([]byte, []int) {
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *SearchHit) GetEntity() *EntityItem {
+	if x != nil {
+		return x.Entity
+	}
+	return nil
+}
+
+func (x *SearchHit) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *SearchHit) GetHighlights() []*SearchHighlight {
+	if x != nil {
+		return x.Highlights
+	}
+	return nil
+}
+
+// SearchEntitiesResponse lists the hits, best first.
+type SearchEntitiesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hits          []*SearchHit           `protobuf:"bytes,1,rep,name=hits,proto3" json:"hits,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchEntitiesResponse) Reset() {
+	*x = SearchEntitiesResponse{}
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchEntitiesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchEntitiesResponse) ProtoMessage() {}
+
+func (x *SearchEntitiesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchEntitiesResponse.ProtoReflect.Descriptor instead.
+func (*SearchEntitiesResponse) Descriptor() ([]byte, []int) {
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *SearchEntitiesResponse) GetHits() []*SearchHit {
+	if x != nil {
+		return x.Hits
+	}
+	return nil
+}
+
+// BatchOperation is one write of a BatchWriteRequest. The agency_id fields of
+// the embedded requests are ignored in favour of BatchWriteRequest.agency_id.
+// Entity IDs (entity_id, to_id) may be temporary IDs: "$" followed by the ref
+// of an earlier create or upsert in the same batch.
+type BatchOperation struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ref names the entity produced by a create_entity or upsert_entity so that
+	// later operations can refer to it as "$" + ref.
+	Ref string `protobuf:"bytes,1,opt,name=ref,proto3" json:"ref,omitempty"`
+	// Types that are valid to be assigned to Op:
+	//
+	//	*BatchOperation_CreateEntity
+	//	*BatchOperation_UpsertEntity
+	//	*BatchOperation_UpdateEntity
+	//	*BatchOperation_DeleteEntity
+	//	*BatchOperation_CreateRelationship
+	//	*BatchOperation_DeleteRelationship
+	Op            isBatchOperation_Op `protobuf_oneof:"op"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchOperation) Reset() {
+	*x = BatchOperation{}
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchOperation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchOperation) ProtoMessage() {}
+
+func (x *BatchOperation) ProtoReflect() protoreflect.Message {
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchOperation.ProtoReflect.Descriptor instead.
+func (*BatchOperation) Descriptor() ([]byte, []int) {
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *BatchOperation) GetRef() string {
+	if x != nil {
+		return x.Ref
+	}
+	return ""
+}
+
+func (x *BatchOperation) GetOp() isBatchOperation_Op {
+	if x != nil {
+		return x.Op
+	}
+	return nil
+}
+
+func (x *BatchOperation) GetCreateEntity() *CreateEntityRequest {
+	if x != nil {
+		if x, ok := x.Op.(*BatchOperation_CreateEntity); ok {
+			return x.CreateEntity
+		}
+	}
+	return nil
+}
+
+func (x *BatchOperation) GetUpsertEntity() *CreateEntityRequest {
+	if x != nil {
+		if x, ok := x.Op.(*BatchOperation_UpsertEntity); ok {
+			return x.UpsertEntity
+		}
+	}
+	return nil
+}
+
+func (x *BatchOperation) GetUpdateEntity() *UpdateEntityRequest {
+	if x != nil {
+		if x, ok := x.Op.(*BatchOperation_UpdateEntity); ok {
+			return x.UpdateEntity
+		}
+	}
+	return nil
+}
+
+func (x *BatchOperation) GetDeleteEntity() *DeleteEntityRequest {
+	if x != nil {
+		if x, ok := x.Op.(*BatchOperation_DeleteEntity); ok {
+			return x.DeleteEntity
+		}
+	}
+	return nil
+}
+
+func (x *BatchOperation) GetCreateRelationship() *CreateRelationshipRequest {
+	if x != nil {
+		if x, ok := x.Op.(*BatchOperation_CreateRelationship); ok {
+			return x.CreateRelationship
+		}
+	}
+	return nil
+}
+
+func (x *BatchOperation) GetDeleteRelationship() *DeleteRelationshipRequest {
+	if x != nil {
+		if x, ok := x.Op.(*BatchOperation_DeleteRelationship); ok {
+			return x.DeleteRelationship
+		}
+	}
+	return nil
+}
+
+type isBatchOperation_Op interface {
+	isBatchOperation_Op()
+}
+
+type BatchOperation_CreateEntity struct {
+	CreateEntity *CreateEntityRequest `protobuf:"bytes,2,opt,name=create_entity,json=createEntity,proto3,oneof"`
+}
+
+type BatchOperation_UpsertEntity struct {
+	UpsertEntity *CreateEntityRequest `protobuf:"bytes,3,opt,name=upsert_entity,json=upsertEntity,proto3,oneof"`
+}
+
+type BatchOperation_UpdateEntity struct {
+	UpdateEntity *UpdateEntityRequest `protobuf:"bytes,4,opt,name=update_entity,json=updateEntity,proto3,oneof"`
+}
+
+type BatchOperation_DeleteEntity struct {
+	DeleteEntity *DeleteEntityRequest `protobuf:"bytes,5,opt,name=delete_entity,json=deleteEntity,proto3,oneof"`
+}
+
+type BatchOperation_CreateRelationship struct {
+	CreateRelationship *CreateRelationshipRequest `protobuf:"bytes,6,opt,name=create_relationship,json=createRelationship,proto3,oneof"`
+}
+
+type BatchOperation_DeleteRelationship struct {
+	DeleteRelationship *DeleteRelationshipRequest `protobuf:"bytes,7,opt,name=delete_relationship,json=deleteRelationship,proto3,oneof"`
+}
+
+func (*BatchOperation_CreateEntity) isBatchOperation_Op() {}
+
+func (*BatchOperation_UpsertEntity) isBatchOperation_Op() {}
+
+func (*BatchOperation_UpdateEntity) isBatchOperation_Op() {}
+
+func (*BatchOperation_DeleteEntity) isBatchOperation_Op() {}
+
+func (*BatchOperation_CreateRelationship) isBatchOperation_Op() {}
+
+func (*BatchOperation_DeleteRelationship) isBatchOperation_Op() {}
+
+// BatchWriteRequest applies operations in order as one transaction.
+type BatchWriteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AgencyId      string                 `protobuf:"bytes,1,opt,name=agency_id,json=agencyId,proto3" json:"agency_id,omitempty"`
+	Operations    []*BatchOperation      `protobuf:"bytes,2,rep,name=operations,proto3" json:"operations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchWriteRequest) Reset() {
+	*x = BatchWriteRequest{}
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchWriteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchWriteRequest) ProtoMessage() {}
+
+func (x *BatchWriteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchWriteRequest.ProtoReflect.Descriptor instead.
+func (*BatchWriteRequest) Descriptor() ([]byte, []int) {
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *BatchWriteRequest) GetAgencyId() string {
+	if x != nil {
+		return x.AgencyId
+	}
+	return ""
+}
+
+func (x *BatchWriteRequest) GetOperations() []*BatchOperation {
+	if x != nil {
+		return x.Operations
+	}
+	return nil
+}
+
+// BatchWriteResult is the outcome of one operation: the entity written by a
+// create, upsert or update, or the relationship written by a
+// create_relationship. Deletes leave both unset.
+type BatchWriteResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entity        *EntityItem            `protobuf:"bytes,1,opt,name=entity,proto3" json:"entity,omitempty"`
+	Relationship  *RelationshipItem      `protobuf:"bytes,2,opt,name=relationship,proto3" json:"relationship,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchWriteResult) Reset() {
+	*x = BatchWriteResult{}
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchWriteResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchWriteResult) ProtoMessage() {}
+
+func (x *BatchWriteResult) ProtoReflect() protoreflect.Message {
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchWriteResult.ProtoReflect.Descriptor instead.
+func (*BatchWriteResult) Descriptor() ([]byte, []int) {
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *BatchWriteResult) GetEntity() *EntityItem {
+	if x != nil {
+		return x.Entity
+	}
+	return nil
+}
+
+func (x *BatchWriteResult) GetRelationship() *RelationshipItem {
+	if x != nil {
+		return x.Relationship
+	}
+	return nil
+}
+
+// BatchWriteResponse holds one result per operation, in order, and maps every
+// ref declared in the batch to the real ID of its entity.
+type BatchWriteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*BatchWriteResult    `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	Refs          map[string]string      `protobuf:"bytes,2,rep,name=refs,proto3" json:"refs,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchWriteResponse) Reset() {
+	*x = BatchWriteResponse{}
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchWriteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchWriteResponse) ProtoMessage() {}
+
+func (x *BatchWriteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchWriteResponse.ProtoReflect.Descriptor instead.
+func (*BatchWriteResponse) Descriptor() ([]byte, []int) {
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *BatchWriteResponse) GetResults() []*BatchWriteResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *BatchWriteResponse) GetRefs() map[string]string {
+	if x != nil {
+		return x.Refs
+	}
+	return nil
+}
+
+// GetEntityHistoryRequest lists the recorded revisions of an entity whose
+// type has history enabled. type_id is injected at dispatch time via
+// ConstantBinding.
+type GetEntityHistoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AgencyId      string                 `protobuf:"bytes,1,opt,name=agency_id,json=agencyId,proto3" json:"agency_id,omitempty"`
+	EntityId      string                 `protobuf:"bytes,2,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
+	TypeId        string                 `protobuf:"bytes,3,opt,name=type_id,json=typeId,proto3" json:"type_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEntityHistoryRequest) Reset() {
+	*x = GetEntityHistoryRequest{}
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEntityHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEntityHistoryRequest) ProtoMessage() {}
+
+func (x *GetEntityHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEntityHistoryRequest.ProtoReflect.Descriptor instead.
+func (*GetEntityHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *GetEntityHistoryRequest) GetAgencyId() string {
+	if x != nil {
+		return x.AgencyId
+	}
+	return ""
+}
+
+func (x *GetEntityHistoryRequest) GetEntityId() string {
+	if x != nil {
+		return x.EntityId
+	}
+	return ""
+}
+
+func (x *GetEntityHistoryRequest) GetTypeId() string {
+	if x != nil {
+		return x.TypeId
+	}
+	return ""
+}
+
+// EntityRevisionItem is one immutable revision of an entity's history.
+type EntityRevisionItem struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// entity is the full state of the entity immediately after the change.
+	Entity *EntityItem `protobuf:"bytes,1,opt,name=entity,proto3" json:"entity,omitempty"`
+	// change is one of "created", "updated", "deleted" or "restored".
+	Change string `protobuf:"bytes,2,opt,name=change,proto3" json:"change,omitempty"`
+	// actor identifies who made the change; empty when unknown.
+	Actor         string                 `protobuf:"bytes,3,opt,name=actor,proto3" json:"actor,omitempty"`
+	RecordedAt    *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=recorded_at,json=recordedAt,proto3" json:"recorded_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EntityRevisionItem) Reset() {
+	*x = EntityRevisionItem{}
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EntityRevisionItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EntityRevisionItem) ProtoMessage() {}
+
+func (x *EntityRevisionItem) ProtoReflect() protoreflect.Message {
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EntityRevisionItem.ProtoReflect.Descriptor instead.
+func (*EntityRevisionItem) Descriptor() ([]byte, []int) {
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *EntityRevisionItem) GetEntity() *EntityItem {
+	if x != nil {
+		return x.Entity
+	}
+	return nil
+}
+
+func (x *EntityRevisionItem) GetChange() string {
+	if x != nil {
+		return x.Change
+	}
+	return ""
+}
+
+func (x *EntityRevisionItem) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *EntityRevisionItem) GetRecordedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RecordedAt
+	}
+	return nil
+}
+
+// GetEntityHistoryResponse lists the revisions, oldest first.
+type GetEntityHistoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Revisions     []*EntityRevisionItem  `protobuf:"bytes,1,rep,name=revisions,proto3" json:"revisions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEntityHistoryResponse) Reset() {
+	*x = GetEntityHistoryResponse{}
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEntityHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEntityHistoryResponse) ProtoMessage() {}
+
+func (x *GetEntityHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEntityHistoryResponse.ProtoReflect.Descriptor instead.
+func (*GetEntityHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *GetEntityHistoryResponse) GetRevisions() []*EntityRevisionItem {
+	if x != nil {
+		return x.Revisions
+	}
+	return nil
+}
+
+// GetEntityAtRequest reads an entity as it was at a point in time. type_id is
+// injected at dispatch time via ConstantBinding; at comes from the URL query
+// string of the GET …/at route as an RFC 3339 timestamp.
+type GetEntityAtRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AgencyId      string                 `protobuf:"bytes,1,opt,name=agency_id,json=agencyId,proto3" json:"agency_id,omitempty"`
+	EntityId      string                 `protobuf:"bytes,2,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
+	TypeId        string                 `protobuf:"bytes,3,opt,name=type_id,json=typeId,proto3" json:"type_id,omitempty"`
+	At            *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=at,proto3" json:"at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEntityAtRequest) Reset() {
+	*x = GetEntityAtRequest{}
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEntityAtRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEntityAtRequest) ProtoMessage() {}
+
+func (x *GetEntityAtRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_entitygraph_v1_entitygraph_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEntityAtRequest.ProtoReflect.Descriptor instead.
+func (*GetEntityAtRequest) Descriptor() ([]byte, []int) {
+	return file_entitygraph_v1_entitygraph_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *GetEntityAtRequest) GetAgencyId() string {
+	if x != nil {
+		return x.AgencyId
+	}
+	return ""
+}
+
+func (x *GetEntityAtRequest) GetEntityId() string {
+	if x != nil {
+		return x.EntityId
+	}
+	return ""
+}
+
+func (x *GetEntityAtRequest) GetTypeId() string {
+	if x != nil {
+		return x.TypeId
+	}
+	return ""
+}
+
+func (x *GetEntityAtRequest) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
 var File_entitygraph_v1_entitygraph_proto protoreflect.FileDescriptor
 
 const file_entitygraph_v1_entitygraph_proto_rawDesc = "" +
 	"\n" +
-	" entitygraph/v1/entitygraph.proto\x12\x0eentitygraph.v1\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1cgoogle/protobuf/struct.proto\"\x81\x02\n" +
+	" entitygraph/v1/entitygraph.proto\x12\x0eentitygraph.v1\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1cgoogle/protobuf/struct.proto\"\xf2\x02\n" +
 	"\n" +
 	"EntityItem\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
@@ -1090,7 +2540,11 @@ const file_entitygraph_v1_entitygraph_proto_rawDesc = "" +
 	"\n" +
 	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
 	"\n" +
-	"updated_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\xf5\x01\n" +
+	"updated_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x18\n" +
+	"\adeleted\x18\a \x01(\bR\adeleted\x129\n" +
+	"\n" +
+	"deleted_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tdeletedAt\x12\x1a\n" +
+	"\brevision\x18\t \x01(\tR\brevision\"\xa8\x02\n" +
 	"\x10RelationshipItem\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
 	"\tagency_id\x18\x02 \x01(\tR\bagencyId\x12\x12\n" +
@@ -1101,15 +2555,36 @@ const file_entitygraph_v1_entitygraph_proto_rawDesc = "" +
 	"properties\x18\x06 \x01(\v2\x17.google.protobuf.StructR\n" +
 	"properties\x129\n" +
 	"\n" +
-	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\x84\x01\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x18\n" +
+	"\aderived\x18\b \x01(\bR\aderived\x12\x17\n" +
+	"\apair_id\x18\t \x01(\tR\x06pairId\"3\n" +
+	"\aOrderBy\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12\x12\n" +
+	"\x04desc\x18\x02 \x01(\bR\x04desc\"\x9e\x01\n" +
+	"\n" +
+	"FilterExpr\x12\x0e\n" +
+	"\x02op\x18\x01 \x01(\tR\x02op\x12\x1a\n" +
+	"\bproperty\x18\x02 \x01(\tR\bproperty\x12,\n" +
+	"\x05value\x18\x03 \x01(\v2\x16.google.protobuf.ValueR\x05value\x126\n" +
+	"\boperands\x18\x04 \x03(\v2\x1a.entitygraph.v1.FilterExprR\boperands\"\xf6\x02\n" +
 	"\x13ListEntitiesRequest\x12\x1b\n" +
 	"\tagency_id\x18\x01 \x01(\tR\bagencyId\x12\x17\n" +
 	"\atype_id\x18\x02 \x01(\tR\x06typeId\x127\n" +
 	"\n" +
 	"properties\x18\x03 \x01(\v2\x17.google.protobuf.StructR\n" +
-	"properties\"N\n" +
+	"properties\x12'\n" +
+	"\x0finclude_deleted\x18\x04 \x01(\bR\x0eincludeDeleted\x12\x1b\n" +
+	"\tpage_size\x18\x05 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x06 \x01(\tR\tpageToken\x122\n" +
+	"\border_by\x18\a \x03(\v2\x17.entitygraph.v1.OrderByR\aorderBy\x12#\n" +
+	"\rinclude_total\x18\b \x01(\bR\fincludeTotal\x122\n" +
+	"\x06filter\x18\t \x01(\v2\x1a.entitygraph.v1.FilterExprR\x06filter\"\x95\x01\n" +
 	"\x14ListEntitiesResponse\x126\n" +
-	"\bentities\x18\x01 \x03(\v2\x1a.entitygraph.v1.EntityItemR\bentities\"\x84\x01\n" +
+	"\bentities\x18\x01 \x03(\v2\x1a.entitygraph.v1.EntityItemR\bentities\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x03 \x01(\x03R\ttotalSize\"\x84\x01\n" +
 	"\x13CreateEntityRequest\x12\x1b\n" +
 	"\tagency_id\x18\x01 \x01(\tR\bagencyId\x12\x17\n" +
 	"\atype_id\x18\x02 \x01(\tR\x06typeId\x127\n" +
@@ -1119,24 +2594,47 @@ const file_entitygraph_v1_entitygraph_proto_rawDesc = "" +
 	"\x10GetEntityRequest\x12\x1b\n" +
 	"\tagency_id\x18\x01 \x01(\tR\bagencyId\x12\x1b\n" +
 	"\tentity_id\x18\x02 \x01(\tR\bentityId\x12\x17\n" +
-	"\atype_id\x18\x03 \x01(\tR\x06typeId\"\xa1\x01\n" +
+	"\atype_id\x18\x03 \x01(\tR\x06typeId\"\xbc\x01\n" +
 	"\x13UpdateEntityRequest\x12\x1b\n" +
 	"\tagency_id\x18\x01 \x01(\tR\bagencyId\x12\x1b\n" +
 	"\tentity_id\x18\x02 \x01(\tR\bentityId\x12\x17\n" +
 	"\atype_id\x18\x03 \x01(\tR\x06typeId\x127\n" +
 	"\n" +
 	"properties\x18\x04 \x01(\v2\x17.google.protobuf.StructR\n" +
-	"properties\"O\n" +
+	"properties\x12\x19\n" +
+	"\bif_match\x18\x05 \x01(\tR\aifMatch\"j\n" +
 	"\x13DeleteEntityRequest\x12\x1b\n" +
 	"\tagency_id\x18\x01 \x01(\tR\bagencyId\x12\x1b\n" +
-	"\tentity_id\x18\x02 \x01(\tR\bentityId\"\x16\n" +
-	"\x14DeleteEntityResponse\"h\n" +
+	"\tentity_id\x18\x02 \x01(\tR\bentityId\x12\x19\n" +
+	"\bif_match\x18\x03 \x01(\tR\aifMatch\"\x16\n" +
+	"\x14DeleteEntityResponse\"i\n" +
+	"\x14RestoreEntityRequest\x12\x1b\n" +
+	"\tagency_id\x18\x01 \x01(\tR\bagencyId\x12\x1b\n" +
+	"\tentity_id\x18\x02 \x01(\tR\bentityId\x12\x17\n" +
+	"\atype_id\x18\x03 \x01(\tR\x06typeId\"N\n" +
+	"\x12PurgeEntityRequest\x12\x1b\n" +
+	"\tagency_id\x18\x01 \x01(\tR\bagencyId\x12\x1b\n" +
+	"\tentity_id\x18\x02 \x01(\tR\bentityId\"\x15\n" +
+	"\x13PurgeEntityResponse\"l\n" +
+	"\x19PurgeDeletedBeforeRequest\x12\x1b\n" +
+	"\tagency_id\x18\x01 \x01(\tR\bagencyId\x122\n" +
+	"\x06cutoff\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x06cutoff\"4\n" +
+	"\x1aPurgeDeletedBeforeResponse\x12\x16\n" +
+	"\x06purged\x18\x01 \x01(\x05R\x06purged\"\xfd\x01\n" +
 	"\x18ListRelationshipsRequest\x12\x1b\n" +
 	"\tagency_id\x18\x01 \x01(\tR\bagencyId\x12\x1b\n" +
 	"\tentity_id\x18\x02 \x01(\tR\bentityId\x12\x12\n" +
-	"\x04name\x18\x03 \x01(\tR\x04name\"c\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x1b\n" +
+	"\tpage_size\x18\x04 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x05 \x01(\tR\tpageToken\x122\n" +
+	"\border_by\x18\x06 \x03(\v2\x17.entitygraph.v1.OrderByR\aorderBy\x12#\n" +
+	"\rinclude_total\x18\a \x01(\bR\fincludeTotal\"\xaa\x01\n" +
 	"\x19ListRelationshipsResponse\x12F\n" +
-	"\rrelationships\x18\x01 \x03(\v2 .entitygraph.v1.RelationshipItemR\rrelationships\"\xb7\x01\n" +
+	"\rrelationships\x18\x01 \x03(\v2 .entitygraph.v1.RelationshipItemR\rrelationships\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x03 \x01(\x03R\ttotalSize\"\xb7\x01\n" +
 	"\x19CreateRelationshipRequest\x12\x1b\n" +
 	"\tagency_id\x18\x01 \x01(\tR\bagencyId\x12\x1b\n" +
 	"\tentity_id\x18\x02 \x01(\tR\bentityId\x12\x12\n" +
@@ -1161,18 +2659,90 @@ const file_entitygraph_v1_entitygraph_proto_rawDesc = "" +
 	"\x05names\x18\x05 \x03(\tR\x05names\"\x87\x01\n" +
 	"\x15TraverseGraphResponse\x126\n" +
 	"\bvertices\x18\x01 \x03(\v2\x1a.entitygraph.v1.EntityItemR\bvertices\x126\n" +
-	"\x05edges\x18\x02 \x03(\v2 .entitygraph.v1.RelationshipItemR\x05edges2\xa7\a\n" +
+	"\x05edges\x18\x02 \x03(\v2 .entitygraph.v1.RelationshipItemR\x05edges\"\x99\x01\n" +
+	"\x15SearchEntitiesRequest\x12\x1b\n" +
+	"\tagency_id\x18\x01 \x01(\tR\bagencyId\x12\x17\n" +
+	"\atype_id\x18\x02 \x01(\tR\x06typeId\x12\x14\n" +
+	"\x05query\x18\x03 \x01(\tR\x05query\x12\x1e\n" +
+	"\n" +
+	"properties\x18\x04 \x03(\tR\n" +
+	"properties\x12\x14\n" +
+	"\x05limit\x18\x05 \x01(\x05R\x05limit\"2\n" +
+	"\bTextSpan\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\x05R\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\x05R\x03end\"q\n" +
+	"\x0fSearchHighlight\x12\x1a\n" +
+	"\bproperty\x18\x01 \x01(\tR\bproperty\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12.\n" +
+	"\x05spans\x18\x03 \x03(\v2\x18.entitygraph.v1.TextSpanR\x05spans\"\x96\x01\n" +
+	"\tSearchHit\x122\n" +
+	"\x06entity\x18\x01 \x01(\v2\x1a.entitygraph.v1.EntityItemR\x06entity\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x01R\x05score\x12?\n" +
+	"\n" +
+	"highlights\x18\x03 \x03(\v2\x1f.entitygraph.v1.SearchHighlightR\n" +
+	"highlights\"G\n" +
+	"\x16SearchEntitiesResponse\x12-\n" +
+	"\x04hits\x18\x01 \x03(\v2\x19.entitygraph.v1.SearchHitR\x04hits\"\x94\x04\n" +
+	"\x0eBatchOperation\x12\x10\n" +
+	"\x03ref\x18\x01 \x01(\tR\x03ref\x12J\n" +
+	"\rcreate_entity\x18\x02 \x01(\v2#.entitygraph.v1.CreateEntityRequestH\x00R\fcreateEntity\x12J\n" +
+	"\rupsert_entity\x18\x03 \x01(\v2#.entitygraph.v1.CreateEntityRequestH\x00R\fupsertEntity\x12J\n" +
+	"\rupdate_entity\x18\x04 \x01(\v2#.entitygraph.v1.UpdateEntityRequestH\x00R\fupdateEntity\x12J\n" +
+	"\rdelete_entity\x18\x05 \x01(\v2#.entitygraph.v1.DeleteEntityRequestH\x00R\fdeleteEntity\x12\\\n" +
+	"\x13create_relationship\x18\x06 \x01(\v2).entitygraph.v1.CreateRelationshipRequestH\x00R\x12createRelationship\x12\\\n" +
+	"\x13delete_relationship\x18\a \x01(\v2).entitygraph.v1.DeleteRelationshipRequestH\x00R\x12deleteRelationshipB\x04\n" +
+	"\x02op\"p\n" +
+	"\x11BatchWriteRequest\x12\x1b\n" +
+	"\tagency_id\x18\x01 \x01(\tR\bagencyId\x12>\n" +
+	"\n" +
+	"operations\x18\x02 \x03(\v2\x1e.entitygraph.v1.BatchOperationR\n" +
+	"operations\"\x8c\x01\n" +
+	"\x10BatchWriteResult\x122\n" +
+	"\x06entity\x18\x01 \x01(\v2\x1a.entitygraph.v1.EntityItemR\x06entity\x12D\n" +
+	"\frelationship\x18\x02 \x01(\v2 .entitygraph.v1.RelationshipItemR\frelationship\"\xcb\x01\n" +
+	"\x12BatchWriteResponse\x12:\n" +
+	"\aresults\x18\x01 \x03(\v2 .entitygraph.v1.BatchWriteResultR\aresults\x12@\n" +
+	"\x04refs\x18\x02 \x03(\v2,.entitygraph.v1.BatchWriteResponse.RefsEntryR\x04refs\x1a7\n" +
+	"\tRefsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"l\n" +
+	"\x17GetEntityHistoryRequest\x12\x1b\n" +
+	"\tagency_id\x18\x01 \x01(\tR\bagencyId\x12\x1b\n" +
+	"\tentity_id\x18\x02 \x01(\tR\bentityId\x12\x17\n" +
+	"\atype_id\x18\x03 \x01(\tR\x06typeId\"\xb3\x01\n" +
+	"\x12EntityRevisionItem\x122\n" +
+	"\x06entity\x18\x01 \x01(\v2\x1a.entitygraph.v1.EntityItemR\x06entity\x12\x16\n" +
+	"\x06change\x18\x02 \x01(\tR\x06change\x12\x14\n" +
+	"\x05actor\x18\x03 \x01(\tR\x05actor\x12;\n" +
+	"\vrecorded_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"recordedAt\"\\\n" +
+	"\x18GetEntityHistoryResponse\x12@\n" +
+	"\trevisions\x18\x01 \x03(\v2\".entitygraph.v1.EntityRevisionItemR\trevisions\"\x93\x01\n" +
+	"\x12GetEntityAtRequest\x12\x1b\n" +
+	"\tagency_id\x18\x01 \x01(\tR\bagencyId\x12\x1b\n" +
+	"\tentity_id\x18\x02 \x01(\tR\bentityId\x12\x17\n" +
+	"\atype_id\x18\x03 \x01(\tR\x06typeId\x12*\n" +
+	"\x02at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x02at2\x8d\r\n" +
 	"\rEntityService\x12Y\n" +
 	"\fListEntities\x12#.entitygraph.v1.ListEntitiesRequest\x1a$.entitygraph.v1.ListEntitiesResponse\x12O\n" +
 	"\fCreateEntity\x12#.entitygraph.v1.CreateEntityRequest\x1a\x1a.entitygraph.v1.EntityItem\x12I\n" +
 	"\tGetEntity\x12 .entitygraph.v1.GetEntityRequest\x1a\x1a.entitygraph.v1.EntityItem\x12O\n" +
 	"\fUpdateEntity\x12#.entitygraph.v1.UpdateEntityRequest\x1a\x1a.entitygraph.v1.EntityItem\x12Y\n" +
-	"\fDeleteEntity\x12#.entitygraph.v1.DeleteEntityRequest\x1a$.entitygraph.v1.DeleteEntityResponse\x12h\n" +
+	"\fDeleteEntity\x12#.entitygraph.v1.DeleteEntityRequest\x1a$.entitygraph.v1.DeleteEntityResponse\x12`\n" +
+	"\x13ListDeletedEntities\x12#.entitygraph.v1.ListEntitiesRequest\x1a$.entitygraph.v1.ListEntitiesResponse\x12Q\n" +
+	"\rRestoreEntity\x12$.entitygraph.v1.RestoreEntityRequest\x1a\x1a.entitygraph.v1.EntityItem\x12V\n" +
+	"\vPurgeEntity\x12\".entitygraph.v1.PurgeEntityRequest\x1a#.entitygraph.v1.PurgeEntityResponse\x12k\n" +
+	"\x12PurgeDeletedBefore\x12).entitygraph.v1.PurgeDeletedBeforeRequest\x1a*.entitygraph.v1.PurgeDeletedBeforeResponse\x12_\n" +
+	"\x0eSearchEntities\x12%.entitygraph.v1.SearchEntitiesRequest\x1a&.entitygraph.v1.SearchEntitiesResponse\x12h\n" +
 	"\x11ListRelationships\x12(.entitygraph.v1.ListRelationshipsRequest\x1a).entitygraph.v1.ListRelationshipsResponse\x12a\n" +
 	"\x12CreateRelationship\x12).entitygraph.v1.CreateRelationshipRequest\x1a .entitygraph.v1.RelationshipItem\x12k\n" +
 	"\x12DeleteRelationship\x12).entitygraph.v1.DeleteRelationshipRequest\x1a*.entitygraph.v1.DeleteRelationshipResponse\x12[\n" +
 	"\x0fGetRelationship\x12&.entitygraph.v1.GetRelationshipRequest\x1a .entitygraph.v1.RelationshipItem\x12\\\n" +
-	"\rTraverseGraph\x12$.entitygraph.v1.TraverseGraphRequest\x1a%.entitygraph.v1.TraverseGraphResponseBJZHgithub.com/aosanya/CodeValdSharedLib/gen/go/entitygraph/v1;entitygraphv1b\x06proto3"
+	"\rTraverseGraph\x12$.entitygraph.v1.TraverseGraphRequest\x1a%.entitygraph.v1.TraverseGraphResponse\x12e\n" +
+	"\x10GetEntityHistory\x12'.entitygraph.v1.GetEntityHistoryRequest\x1a(.entitygraph.v1.GetEntityHistoryResponse\x12M\n" +
+	"\vGetEntityAt\x12\".entitygraph.v1.GetEntityAtRequest\x1a\x1a.entitygraph.v1.EntityItem\x12S\n" +
+	"\n" +
+	"BatchWrite\x12!.entitygraph.v1.BatchWriteRequest\x1a\".entitygraph.v1.BatchWriteResponseBJZHgithub.com/aosanya/CodeValdSharedLib/gen/go/entitygraph/v1;entitygraphv1b\x06proto3"
 
 var (
 	file_entitygraph_v1_entitygraph_proto_rawDescOnce sync.Once
@@ -1186,67 +2756,131 @@ func file_entitygraph_v1_entitygraph_proto_rawDescGZIP() []byte {
 	return file_entitygraph_v1_entitygraph_proto_rawDescData
 }
 
-var file_entitygraph_v1_entitygraph_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_entitygraph_v1_entitygraph_proto_msgTypes = make([]protoimpl.MessageInfo, 38)
 var file_entitygraph_v1_entitygraph_proto_goTypes = []any{
 	(*EntityItem)(nil),                 // 0: entitygraph.v1.EntityItem
 	(*RelationshipItem)(nil),           // 1: entitygraph.v1.RelationshipItem
-	(*ListEntitiesRequest)(nil),        // 2: entitygraph.v1.ListEntitiesRequest
-	(*ListEntitiesResponse)(nil),       // 3: entitygraph.v1.ListEntitiesResponse
-	(*CreateEntityRequest)(nil),        // 4: entitygraph.v1.CreateEntityRequest
-	(*GetEntityRequest)(nil),           // 5: entitygraph.v1.GetEntityRequest
-	(*UpdateEntityRequest)(nil),        // 6: entitygraph.v1.UpdateEntityRequest
-	(*DeleteEntityRequest)(nil),        // 7: entitygraph.v1.DeleteEntityRequest
-	(*DeleteEntityResponse)(nil),       // 8: entitygraph.v1.DeleteEntityResponse
-	(*ListRelationshipsRequest)(nil),   // 9: entitygraph.v1.ListRelationshipsRequest
-	(*ListRelationshipsResponse)(nil),  // 10: entitygraph.v1.ListRelationshipsResponse
-	(*CreateRelationshipRequest)(nil),  // 11: entitygraph.v1.CreateRelationshipRequest
-	(*DeleteRelationshipRequest)(nil),  // 12: entitygraph.v1.DeleteRelationshipRequest
-	(*DeleteRelationshipResponse)(nil), // 13: entitygraph.v1.DeleteRelationshipResponse
-	(*GetRelationshipRequest)(nil),     // 14: entitygraph.v1.GetRelationshipRequest
-	(*TraverseGraphRequest)(nil),       // 15: entitygraph.v1.TraverseGraphRequest
-	(*TraverseGraphResponse)(nil),      // 16: entitygraph.v1.TraverseGraphResponse
-	(*structpb.Struct)(nil),            // 17: google.protobuf.Struct
-	(*timestamppb.Timestamp)(nil),      // 18: google.protobuf.Timestamp
+	(*OrderBy)(nil),                    // 2: entitygraph.v1.OrderBy
+	(*FilterExpr)(nil),                 // 3: entitygraph.v1.FilterExpr
+	(*ListEntitiesRequest)(nil),        // 4: entitygraph.v1.ListEntitiesRequest
+	(*ListEntitiesResponse)(nil),       // 5: entitygraph.v1.ListEntitiesResponse
+	(*CreateEntityRequest)(nil),        // 6: entitygraph.v1.CreateEntityRequest
+	(*GetEntityRequest)(nil),           // 7: entitygraph.v1.GetEntityRequest
+	(*UpdateEntityRequest)(nil),        // 8: entitygraph.v1.UpdateEntityRequest
+	(*DeleteEntityRequest)(nil),        // 9: entitygraph.v1.DeleteEntityRequest
+	(*DeleteEntityResponse)(nil),       // 10: entitygraph.v1.DeleteEntityResponse
+	(*RestoreEntityRequest)(nil),       // 11: entitygraph.v1.RestoreEntityRequest
+	(*PurgeEntityRequest)(nil),         // 12: entitygraph.v1.PurgeEntityRequest
+	(*PurgeEntityResponse)(nil),        // 13: entitygraph.v1.PurgeEntityResponse
+	(*PurgeDeletedBeforeRequest)(nil),  // 14: entitygraph.v1.PurgeDeletedBeforeRequest
+	(*PurgeDeletedBeforeResponse)(nil), // 15: entitygraph.v1.PurgeDeletedBeforeResponse
+	(*ListRelationshipsRequest)(nil),   // 16: entitygraph.v1.ListRelationshipsRequest
+	(*ListRelationshipsResponse)(nil),  // 17: entitygraph.v1.ListRelationshipsResponse
+	(*CreateRelationshipRequest)(nil),  // 18: entitygraph.v1.CreateRelationshipRequest
+	(*DeleteRelationshipRequest)(nil),  // 19: entitygraph.v1.DeleteRelationshipRequest
+	(*DeleteRelationshipResponse)(nil), // 20: entitygraph.v1.DeleteRelationshipResponse
+	(*GetRelationshipRequest)(nil),     // 21: entitygraph.v1.GetRelationshipRequest
+	(*TraverseGraphRequest)(nil),       // 22: entitygraph.v1.TraverseGraphRequest
+	(*TraverseGraphResponse)(nil),      // 23: entitygraph.v1.TraverseGraphResponse
+	(*SearchEntitiesRequest)(nil),      // 24: entitygraph.v1.SearchEntitiesRequest
+	(*TextSpan)(nil),                   // 25: entitygraph.v1.TextSpan
+	(*SearchHighlight)(nil),            // 26: entitygraph.v1.SearchHighlight
+	(*SearchHit)(nil),                  // 27: entitygraph.v1.SearchHit
+	(*SearchEntitiesResponse)(nil),     // 28: entitygraph.v1.SearchEntitiesResponse
+	(*BatchOperation)(nil),             // 29: entitygraph.v1.BatchOperation
+	(*BatchWriteRequest)(nil),          // 30: entitygraph.v1.BatchWriteRequest
+	(*BatchWriteResult)(nil),           // 31: entitygraph.v1.BatchWriteResult
+	(*BatchWriteResponse)(nil),         // 32: entitygraph.v1.BatchWriteResponse
+	(*GetEntityHistoryRequest)(nil),    // 33: entitygraph.v1.GetEntityHistoryRequest
+	(*EntityRevisionItem)(nil),         // 34: entitygraph.v1.EntityRevisionItem
+	(*GetEntityHistoryResponse)(nil),   // 35: entitygraph.v1.GetEntityHistoryResponse
+	(*GetEntityAtRequest)(nil),         // 36: entitygraph.v1.GetEntityAtRequest
+	nil,                                // 37: entitygraph.v1.BatchWriteResponse.RefsEntry
+	(*structpb.Struct)(nil),            // 38: google.protobuf.Struct
+	(*timestamppb.Timestamp)(nil),      // 39: google.protobuf.Timestamp
+	(*structpb.Value)(nil),             // 40: google.protobuf.Value
 }
 var file_entitygraph_v1_entitygraph_proto_depIdxs = []int32{
-	17, // 0: entitygraph.v1.EntityItem.properties:type_name -> google.protobuf.Struct
-	18, // 1: entitygraph.v1.EntityItem.created_at:type_name -> google.protobuf.Timestamp
-	18, // 2: entitygraph.v1.EntityItem.updated_at:type_name -> google.protobuf.Timestamp
-	17, // 3: entitygraph.v1.RelationshipItem.properties:type_name -> google.protobuf.Struct
-	18, // 4: entitygraph.v1.RelationshipItem.created_at:type_name -> google.protobuf.Timestamp
-	17, // 5: entitygraph.v1.ListEntitiesRequest.properties:type_name -> google.protobuf.Struct
-	0,  // 6: entitygraph.v1.ListEntitiesResponse.entities:type_name -> entitygraph.v1.EntityItem
-	17, // 7: entitygraph.v1.CreateEntityRequest.properties:type_name -> google.protobuf.Struct
-	17, // 8: entitygraph.v1.UpdateEntityRequest.properties:type_name -> google.protobuf.Struct
-	1,  // 9: entitygraph.v1.ListRelationshipsResponse.relationships:type_name -> entitygraph.v1.RelationshipItem
-	17, // 10: entitygraph.v1.CreateRelationshipRequest.properties:type_name -> google.protobuf.Struct
-	0,  // 11: entitygraph.v1.TraverseGraphResponse.vertices:type_name -> entitygraph.v1.EntityItem
-	1,  // 12: entitygraph.v1.TraverseGraphResponse.edges:type_name -> entitygraph.v1.RelationshipItem
-	2,  // 13: entitygraph.v1.EntityService.ListEntities:input_type -> entitygraph.v1.ListEntitiesRequest
-	4,  // 14: entitygraph.v1.EntityService.CreateEntity:input_type -> entitygraph.v1.CreateEntityRequest
-	5,  // 15: entitygraph.v1.EntityService.GetEntity:input_type -> entitygraph.v1.GetEntityRequest
-	6,  // 16: entitygraph.v1.EntityService.UpdateEntity:input_type -> entitygraph.v1.UpdateEntityRequest
-	7,  // 17: entitygraph.v1.EntityService.DeleteEntity:input_type -> entitygraph.v1.DeleteEntityRequest
-	9,  // 18: entitygraph.v1.EntityService.ListRelationships:input_type -> entitygraph.v1.ListRelationshipsRequest
-	11, // 19: entitygraph.v1.EntityService.CreateRelationship:input_type -> entitygraph.v1.CreateRelationshipRequest
-	12, // 20: entitygraph.v1.EntityService.DeleteRelationship:input_type -> entitygraph.v1.DeleteRelationshipRequest
-	14, // 21: entitygraph.v1.EntityService.GetRelationship:input_type -> entitygraph.v1.GetRelationshipRequest
-	15, // 22: entitygraph.v1.EntityService.TraverseGraph:input_type -> entitygraph.v1.TraverseGraphRequest
-	3,  // 23: entitygraph.v1.EntityService.ListEntities:output_type -> entitygraph.v1.ListEntitiesResponse
-	0,  // 24: entitygraph.v1.EntityService.CreateEntity:output_type -> entitygraph.v1.EntityItem
-	0,  // 25: entitygraph.v1.EntityService.GetEntity:output_type -> entitygraph.v1.EntityItem
-	0,  // 26: entitygraph.v1.EntityService.UpdateEntity:output_type -> entitygraph.v1.EntityItem
-	8,  // 27: entitygraph.v1.EntityService.DeleteEntity:output_type -> entitygraph.v1.DeleteEntityResponse
-	10, // 28: entitygraph.v1.EntityService.ListRelationships:output_type -> entitygraph.v1.ListRelationshipsResponse
-	1,  // 29: entitygraph.v1.EntityService.CreateRelationship:output_type -> entitygraph.v1.RelationshipItem
-	13, // 30: entitygraph.v1.EntityService.DeleteRelationship:output_type -> entitygraph.v1.DeleteRelationshipResponse
-	1,  // 31: entitygraph.v1.EntityService.GetRelationship:output_type -> entitygraph.v1.RelationshipItem
-	16, // 32: entitygraph.v1.EntityService.TraverseGraph:output_type -> entitygraph.v1.TraverseGraphResponse
-	23, // [23:33] is the sub-list for method output_type
-	13, // [13:23] is the sub-list for method input_type
-	13, // [13:13] is the sub-list for extension type_name
-	13, // [13:13] is the sub-list for extension extendee
-	0,  // [0:13] is the sub-list for field type_name
+	38, // 0: entitygraph.v1.EntityItem.properties:type_name -> google.protobuf.Struct
+	39, // 1: entitygraph.v1.EntityItem.created_at:type_name -> google.protobuf.Timestamp
+	39, // 2: entitygraph.v1.EntityItem.updated_at:type_name -> google.protobuf.Timestamp
+	39, // 3: entitygraph.v1.EntityItem.deleted_at:type_name -> google.protobuf.Timestamp
+	38, // 4: entitygraph.v1.RelationshipItem.properties:type_name -> google.protobuf.Struct
+	39, // 5: entitygraph.v1.RelationshipItem.created_at:type_name -> google.protobuf.Timestamp
+	40, // 6: entitygraph.v1.FilterExpr.value:type_name -> google.protobuf.Value
+	3,  // 7: entitygraph.v1.FilterExpr.operands:type_name -> entitygraph.v1.FilterExpr
+	38, // 8: entitygraph.v1.ListEntitiesRequest.properties:type_name -> google.protobuf.Struct
+	2,  // 9: entitygraph.v1.ListEntitiesRequest.order_by:type_name -> entitygraph.v1.OrderBy
+	3,  // 10: entitygraph.v1.ListEntitiesRequest.filter:type_name -> entitygraph.v1.FilterExpr
+	0,  // 11: entitygraph.v1.ListEntitiesResponse.entities:type_name -> entitygraph.v1.EntityItem
+	38, // 12: entitygraph.v1.CreateEntityRequest.properties:type_name -> google.protobuf.Struct
+	38, // 13: entitygraph.v1.UpdateEntityRequest.properties:type_name -> google.protobuf.Struct
+	39, // 14: entitygraph.v1.PurgeDeletedBeforeRequest.cutoff:type_name -> google.protobuf.Timestamp
+	2,  // 15: entitygraph.v1.ListRelationshipsRequest.order_by:type_name -> entitygraph.v1.OrderBy
+	1,  // 16: entitygraph.v1.ListRelationshipsResponse.relationships:type_name -> entitygraph.v1.RelationshipItem
+	38, // 17: entitygraph.v1.CreateRelationshipRequest.properties:type_name -> google.protobuf.Struct
+	0,  // 18: entitygraph.v1.TraverseGraphResponse.vertices:type_name -> entitygraph.v1.EntityItem
+	1,  // 19: entitygraph.v1.TraverseGraphResponse.edges:type_name -> entitygraph.v1.RelationshipItem
+	25, // 20: entitygraph.v1.SearchHighlight.spans:type_name -> entitygraph.v1.TextSpan
+	0,  // 21: entitygraph.v1.SearchHit.entity:type_name -> entitygraph.v1.EntityItem
+	26, // 22: entitygraph.v1.SearchHit.highlights:type_name -> entitygraph.v1.SearchHighlight
+	27, // 23: entitygraph.v1.SearchEntitiesResponse.hits:type_name -> entitygraph.v1.SearchHit
+	6,  // 24: entitygraph.v1.BatchOperation.create_entity:type_name -> entitygraph.v1.CreateEntityRequest
+	6,  // 25: entitygraph.v1.BatchOperation.upsert_entity:type_name -> entitygraph.v1.CreateEntityRequest
+	8,  // 26: entitygraph.v1.BatchOperation.update_entity:type_name -> entitygraph.v1.UpdateEntityRequest
+	9,  // 27: entitygraph.v1.BatchOperation.delete_entity:type_name -> entitygraph.v1.DeleteEntityRequest
+	18, // 28: entitygraph.v1.BatchOperation.create_relationship:type_name -> entitygraph.v1.CreateRelationshipRequest
+	19, // 29: entitygraph.v1.BatchOperation.delete_relationship:type_name -> entitygraph.v1.DeleteRelationshipRequest
+	29, // 30: entitygraph.v1.BatchWriteRequest.operations:type_name -> entitygraph.v1.BatchOperation
+	0,  // 31: entitygraph.v1.BatchWriteResult.entity:type_name -> entitygraph.v1.EntityItem
+	1,  // 32: entitygraph.v1.BatchWriteResult.relationship:type_name -> entitygraph.v1.RelationshipItem
+	31, // 33: entitygraph.v1.BatchWriteResponse.results:type_name -> entitygraph.v1.BatchWriteResult
+	37, // 34: entitygraph.v1.BatchWriteResponse.refs:type_name -> entitygraph.v1.BatchWriteResponse.RefsEntry
+	0,  // 35: entitygraph.v1.EntityRevisionItem.entity:type_name -> entitygraph.v1.EntityItem
+	39, // 36: entitygraph.v1.EntityRevisionItem.recorded_at:type_name -> google.protobuf.Timestamp
+	34, // 37: entitygraph.v1.GetEntityHistoryResponse.revisions:type_name -> entitygraph.v1.EntityRevisionItem
+	39, // 38: entitygraph.v1.GetEntityAtRequest.at:type_name -> google.protobuf.Timestamp
+	4,  // 39: entitygraph.v1.EntityService.ListEntities:input_type -> entitygraph.v1.ListEntitiesRequest
+	6,  // 40: entitygraph.v1.EntityService.CreateEntity:input_type -> entitygraph.v1.CreateEntityRequest
+	7,  // 41: entitygraph.v1.EntityService.GetEntity:input_type -> entitygraph.v1.GetEntityRequest
+	8,  // 42: entitygraph.v1.EntityService.UpdateEntity:input_type -> entitygraph.v1.UpdateEntityRequest
+	9,  // 43: entitygraph.v1.EntityService.DeleteEntity:input_type -> entitygraph.v1.DeleteEntityRequest
+	4,  // 44: entitygraph.v1.EntityService.ListDeletedEntities:input_type -> entitygraph.v1.ListEntitiesRequest
+	11, // 45: entitygraph.v1.EntityService.RestoreEntity:input_type -> entitygraph.v1.RestoreEntityRequest
+	12, // 46: entitygraph.v1.EntityService.PurgeEntity:input_type -> entitygraph.v1.PurgeEntityRequest
+	14, // 47: entitygraph.v1.EntityService.PurgeDeletedBefore:input_type -> entitygraph.v1.PurgeDeletedBeforeRequest
+	24, // 48: entitygraph.v1.EntityService.SearchEntities:input_type -> entitygraph.v1.SearchEntitiesRequest
+	16, // 49: entitygraph.v1.EntityService.ListRelationships:input_type -> entitygraph.v1.ListRelationshipsRequest
+	18, // 50: entitygraph.v1.EntityService.CreateRelationship:input_type -> entitygraph.v1.CreateRelationshipRequest
+	19, // 51: entitygraph.v1.EntityService.DeleteRelationship:input_type -> entitygraph.v1.DeleteRelationshipRequest
+	21, // 52: entitygraph.v1.EntityService.GetRelationship:input_type -> entitygraph.v1.GetRelationshipRequest
+	22, // 53: entitygraph.v1.EntityService.TraverseGraph:input_type -> entitygraph.v1.TraverseGraphRequest
+	33, // 54: entitygraph.v1.EntityService.GetEntityHistory:input_type -> entitygraph.v1.GetEntityHistoryRequest
+	36, // 55: entitygraph.v1.EntityService.GetEntityAt:input_type -> entitygraph.v1.GetEntityAtRequest
+	30, // 56: entitygraph.v1.EntityService.BatchWrite:input_type -> entitygraph.v1.BatchWriteRequest
+	5,  // 57: entitygraph.v1.EntityService.ListEntities:output_type -> entitygraph.v1.ListEntitiesResponse
+	0,  // 58: entitygraph.v1.EntityService.CreateEntity:output_type -> entitygraph.v1.EntityItem
+	0,  // 59: entitygraph.v1.EntityService.GetEntity:output_type -> entitygraph.v1.EntityItem
+	0,  // 60: entitygraph.v1.EntityService.UpdateEntity:output_type -> entitygraph.v1.EntityItem
+	10, // 61: entitygraph.v1.EntityService.DeleteEntity:output_type -> entitygraph.v1.DeleteEntityResponse
+	5,  // 62: entitygraph.v1.EntityService.ListDeletedEntities:output_type -> entitygraph.v1.ListEntitiesResponse
+	0,  // 63: entitygraph.v1.EntityService.RestoreEntity:output_type -> entitygraph.v1.EntityItem
+	13, // 64: entitygraph.v1.EntityService.PurgeEntity:output_type -> entitygraph.v1.PurgeEntityResponse
+	15, // 65: entitygraph.v1.EntityService.PurgeDeletedBefore:output_type -> entitygraph.v1.PurgeDeletedBeforeResponse
+	28, // 66: entitygraph.v1.EntityService.SearchEntities:output_type -> entitygraph.v1.SearchEntitiesResponse
+	17, // 67: entitygraph.v1.EntityService.ListRelationships:output_type -> entitygraph.v1.ListRelationshipsResponse
+	1,  // 68: entitygraph.v1.EntityService.CreateRelationship:output_type -> entitygraph.v1.RelationshipItem
+	20, // 69: entitygraph.v1.EntityService.DeleteRelationship:output_type -> entitygraph.v1.DeleteRelationshipResponse
+	1,  // 70: entitygraph.v1.EntityService.GetRelationship:output_type -> entitygraph.v1.RelationshipItem
+	23, // 71: entitygraph.v1.EntityService.TraverseGraph:output_type -> entitygraph.v1.TraverseGraphResponse
+	35, // 72: entitygraph.v1.EntityService.GetEntityHistory:output_type -> entitygraph.v1.GetEntityHistoryResponse
+	0,  // 73: entitygraph.v1.EntityService.GetEntityAt:output_type -> entitygraph.v1.EntityItem
+	32, // 74: entitygraph.v1.EntityService.BatchWrite:output_type -> entitygraph.v1.BatchWriteResponse
+	57, // [57:75] is the sub-list for method output_type
+	39, // [39:57] is the sub-list for method input_type
+	39, // [39:39] is the sub-list for extension type_name
+	39, // [39:39] is the sub-list for extension extendee
+	0,  // [0:39] is the sub-list for field type_name
 }
 
 func init() { file_entitygraph_v1_entitygraph_proto_init() }
@@ -1254,13 +2888,21 @@ func file_entitygraph_v1_entitygraph_proto_init() {
 	if File_entitygraph_v1_entitygraph_proto != nil {
 		return
 	}
+	file_entitygraph_v1_entitygraph_proto_msgTypes[29].OneofWrappers = []any{
+		(*BatchOperation_CreateEntity)(nil),
+		(*BatchOperation_UpsertEntity)(nil),
+		(*BatchOperation_UpdateEntity)(nil),
+		(*BatchOperation_DeleteEntity)(nil),
+		(*BatchOperation_CreateRelationship)(nil),
+		(*BatchOperation_DeleteRelationship)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_entitygraph_v1_entitygraph_proto_rawDesc), len(file_entitygraph_v1_entitygraph_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   17,
+			NumMessages:   38,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
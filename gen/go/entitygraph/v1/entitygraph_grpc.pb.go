@@ -25,16 +25,24 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	EntityService_ListEntities_FullMethodName       = "/entitygraph.v1.EntityService/ListEntities"
-	EntityService_CreateEntity_FullMethodName       = "/entitygraph.v1.EntityService/CreateEntity"
-	EntityService_GetEntity_FullMethodName          = "/entitygraph.v1.EntityService/GetEntity"
-	EntityService_UpdateEntity_FullMethodName       = "/entitygraph.v1.EntityService/UpdateEntity"
-	EntityService_DeleteEntity_FullMethodName       = "/entitygraph.v1.EntityService/DeleteEntity"
-	EntityService_ListRelationships_FullMethodName  = "/entitygraph.v1.EntityService/ListRelationships"
-	EntityService_CreateRelationship_FullMethodName = "/entitygraph.v1.EntityService/CreateRelationship"
-	EntityService_DeleteRelationship_FullMethodName = "/entitygraph.v1.EntityService/DeleteRelationship"
-	EntityService_GetRelationship_FullMethodName    = "/entitygraph.v1.EntityService/GetRelationship"
-	EntityService_TraverseGraph_FullMethodName      = "/entitygraph.v1.EntityService/TraverseGraph"
+	EntityService_ListEntities_FullMethodName        = "/entitygraph.v1.EntityService/ListEntities"
+	EntityService_CreateEntity_FullMethodName        = "/entitygraph.v1.EntityService/CreateEntity"
+	EntityService_GetEntity_FullMethodName           = "/entitygraph.v1.EntityService/GetEntity"
+	EntityService_UpdateEntity_FullMethodName        = "/entitygraph.v1.EntityService/UpdateEntity"
+	EntityService_DeleteEntity_FullMethodName        = "/entitygraph.v1.EntityService/DeleteEntity"
+	EntityService_ListDeletedEntities_FullMethodName = "/entitygraph.v1.EntityService/ListDeletedEntities"
+	EntityService_RestoreEntity_FullMethodName       = "/entitygraph.v1.EntityService/RestoreEntity"
+	EntityService_PurgeEntity_FullMethodName         = "/entitygraph.v1.EntityService/PurgeEntity"
+	EntityService_PurgeDeletedBefore_FullMethodName  = "/entitygraph.v1.EntityService/PurgeDeletedBefore"
+	EntityService_SearchEntities_FullMethodName      = "/entitygraph.v1.EntityService/SearchEntities"
+	EntityService_ListRelationships_FullMethodName   = "/entitygraph.v1.EntityService/ListRelationships"
+	EntityService_CreateRelationship_FullMethodName  = "/entitygraph.v1.EntityService/CreateRelationship"
+	EntityService_DeleteRelationship_FullMethodName  = "/entitygraph.v1.EntityService/DeleteRelationship"
+	EntityService_GetRelationship_FullMethodName     = "/entitygraph.v1.EntityService/GetRelationship"
+	EntityService_TraverseGraph_FullMethodName       = "/entitygraph.v1.EntityService/TraverseGraph"
+	EntityService_GetEntityHistory_FullMethodName    = "/entitygraph.v1.EntityService/GetEntityHistory"
+	EntityService_GetEntityAt_FullMethodName         = "/entitygraph.v1.EntityService/GetEntityAt"
+	EntityService_BatchWrite_FullMethodName          = "/entitygraph.v1.EntityService/BatchWrite"
 )
 
 // EntityServiceClient is the client API for EntityService service.
@@ -57,6 +65,19 @@ type EntityServiceClient interface {
 	UpdateEntity(ctx context.Context, in *UpdateEntityRequest, opts ...grpc.CallOption) (*EntityItem, error)
 	// DeleteEntity soft-deletes an entity by ID.
 	DeleteEntity(ctx context.Context, in *DeleteEntityRequest, opts ...grpc.CallOption) (*DeleteEntityResponse, error)
+	// ListDeletedEntities returns only the soft-deleted entities of the
+	// type_id bound to this route.
+	ListDeletedEntities(ctx context.Context, in *ListEntitiesRequest, opts ...grpc.CallOption) (*ListEntitiesResponse, error)
+	// RestoreEntity un-deletes a soft-deleted entity.
+	RestoreEntity(ctx context.Context, in *RestoreEntityRequest, opts ...grpc.CallOption) (*EntityItem, error)
+	// PurgeEntity permanently removes a soft-deleted entity and its edges.
+	PurgeEntity(ctx context.Context, in *PurgeEntityRequest, opts ...grpc.CallOption) (*PurgeEntityResponse, error)
+	// PurgeDeletedBefore permanently removes every entity of the agency
+	// soft-deleted before cutoff.
+	PurgeDeletedBefore(ctx context.Context, in *PurgeDeletedBeforeRequest, opts ...grpc.CallOption) (*PurgeDeletedBeforeResponse, error)
+	// SearchEntities returns the entities of the route-bound type best matching
+	// a free-text query, with highlights.
+	SearchEntities(ctx context.Context, in *SearchEntitiesRequest, opts ...grpc.CallOption) (*SearchEntitiesResponse, error)
 	// ListRelationships returns all outbound edges of the relationship name
 	// bound to this route from the given source entity.
 	ListRelationships(ctx context.Context, in *ListRelationshipsRequest, opts ...grpc.CallOption) (*ListRelationshipsResponse, error)
@@ -69,6 +90,15 @@ type EntityServiceClient interface {
 	// TraverseGraph walks the entity graph from start_id and returns all
 	// reachable vertices and traversed edges up to the given depth.
 	TraverseGraph(ctx context.Context, in *TraverseGraphRequest, opts ...grpc.CallOption) (*TraverseGraphResponse, error)
+	// GetEntityHistory returns the change history of an entity whose type has
+	// history enabled.
+	GetEntityHistory(ctx context.Context, in *GetEntityHistoryRequest, opts ...grpc.CallOption) (*GetEntityHistoryResponse, error)
+	// GetEntityAt returns an entity as it was at a point in time, replayed from
+	// its history.
+	GetEntityAt(ctx context.Context, in *GetEntityAtRequest, opts ...grpc.CallOption) (*EntityItem, error)
+	// BatchWrite applies a mixed list of entity and relationship writes as one
+	// transaction: all of them take effect or none do.
+	BatchWrite(ctx context.Context, in *BatchWriteRequest, opts ...grpc.CallOption) (*BatchWriteResponse, error)
 }
 
 type entityServiceClient struct {
@@ -129,6 +159,56 @@ func (c *entityServiceClient) DeleteEntity(ctx context.Context, in *DeleteEntity
 	return out, nil
 }
 
+func (c *entityServiceClient) ListDeletedEntities(ctx context.Context, in *ListEntitiesRequest, opts ...grpc.CallOption) (*ListEntitiesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListEntitiesResponse)
+	err := c.cc.Invoke(ctx, EntityService_ListDeletedEntities_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *entityServiceClient) RestoreEntity(ctx context.Context, in *RestoreEntityRequest, opts ...grpc.CallOption) (*EntityItem, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EntityItem)
+	err := c.cc.Invoke(ctx, EntityService_RestoreEntity_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *entityServiceClient) PurgeEntity(ctx context.Context, in *PurgeEntityRequest, opts ...grpc.CallOption) (*PurgeEntityResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PurgeEntityResponse)
+	err := c.cc.Invoke(ctx, EntityService_PurgeEntity_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *entityServiceClient) PurgeDeletedBefore(ctx context.Context, in *PurgeDeletedBeforeRequest, opts ...grpc.CallOption) (*PurgeDeletedBeforeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PurgeDeletedBeforeResponse)
+	err := c.cc.Invoke(ctx, EntityService_PurgeDeletedBefore_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *entityServiceClient) SearchEntities(ctx context.Context, in *SearchEntitiesRequest, opts ...grpc.CallOption) (*SearchEntitiesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchEntitiesResponse)
+	err := c.cc.Invoke(ctx, EntityService_SearchEntities_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *entityServiceClient) ListRelationships(ctx context.Context, in *ListRelationshipsRequest, opts ...grpc.CallOption) (*ListRelationshipsResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ListRelationshipsResponse)
@@ -179,6 +259,36 @@ func (c *entityServiceClient) TraverseGraph(ctx context.Context, in *TraverseGra
 	return out, nil
 }
 
+func (c *entityServiceClient) GetEntityHistory(ctx context.Context, in *GetEntityHistoryRequest, opts ...grpc.CallOption) (*GetEntityHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetEntityHistoryResponse)
+	err := c.cc.Invoke(ctx, EntityService_GetEntityHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *entityServiceClient) GetEntityAt(ctx context.Context, in *GetEntityAtRequest, opts ...grpc.CallOption) (*EntityItem, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EntityItem)
+	err := c.cc.Invoke(ctx, EntityService_GetEntityAt_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *entityServiceClient) BatchWrite(ctx context.Context, in *BatchWriteRequest, opts ...grpc.CallOption) (*BatchWriteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchWriteResponse)
+	err := c.cc.Invoke(ctx, EntityService_BatchWrite_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // EntityServiceServer is the server API for EntityService service.
 // All implementations must embed UnimplementedEntityServiceServer
 // for forward compatibility.
@@ -199,6 +309,19 @@ type EntityServiceServer interface {
 	UpdateEntity(context.Context, *UpdateEntityRequest) (*EntityItem, error)
 	// DeleteEntity soft-deletes an entity by ID.
 	DeleteEntity(context.Context, *DeleteEntityRequest) (*DeleteEntityResponse, error)
+	// ListDeletedEntities returns only the soft-deleted entities of the
+	// type_id bound to this route.
+	ListDeletedEntities(context.Context, *ListEntitiesRequest) (*ListEntitiesResponse, error)
+	// RestoreEntity un-deletes a soft-deleted entity.
+	RestoreEntity(context.Context, *RestoreEntityRequest) (*EntityItem, error)
+	// PurgeEntity permanently removes a soft-deleted entity and its edges.
+	PurgeEntity(context.Context, *PurgeEntityRequest) (*PurgeEntityResponse, error)
+	// PurgeDeletedBefore permanently removes every entity of the agency
+	// soft-deleted before cutoff.
+	PurgeDeletedBefore(context.Context, *PurgeDeletedBeforeRequest) (*PurgeDeletedBeforeResponse, error)
+	// SearchEntities returns the entities of the route-bound type best matching
+	// a free-text query, with highlights.
+	SearchEntities(context.Context, *SearchEntitiesRequest) (*SearchEntitiesResponse, error)
 	// ListRelationships returns all outbound edges of the relationship name
 	// bound to this route from the given source entity.
 	ListRelationships(context.Context, *ListRelationshipsRequest) (*ListRelationshipsResponse, error)
@@ -211,6 +334,15 @@ type EntityServiceServer interface {
 	// TraverseGraph walks the entity graph from start_id and returns all
 	// reachable vertices and traversed edges up to the given depth.
 	TraverseGraph(context.Context, *TraverseGraphRequest) (*TraverseGraphResponse, error)
+	// GetEntityHistory returns the change history of an entity whose type has
+	// history enabled.
+	GetEntityHistory(context.Context, *GetEntityHistoryRequest) (*GetEntityHistoryResponse, error)
+	// GetEntityAt returns an entity as it was at a point in time, replayed from
+	// its history.
+	GetEntityAt(context.Context, *GetEntityAtRequest) (*EntityItem, error)
+	// BatchWrite applies a mixed list of entity and relationship writes as one
+	// transaction: all of them take effect or none do.
+	BatchWrite(context.Context, *BatchWriteRequest) (*BatchWriteResponse, error)
 	mustEmbedUnimplementedEntityServiceServer()
 }
 
@@ -236,6 +368,21 @@ func (UnimplementedEntityServiceServer) UpdateEntity(context.Context, *UpdateEnt
 func (UnimplementedEntityServiceServer) DeleteEntity(context.Context, *DeleteEntityRequest) (*DeleteEntityResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method DeleteEntity not implemented")
 }
+func (UnimplementedEntityServiceServer) ListDeletedEntities(context.Context, *ListEntitiesRequest) (*ListEntitiesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListDeletedEntities not implemented")
+}
+func (UnimplementedEntityServiceServer) RestoreEntity(context.Context, *RestoreEntityRequest) (*EntityItem, error) {
+	return nil, status.Error(codes.Unimplemented, "method RestoreEntity not implemented")
+}
+func (UnimplementedEntityServiceServer) PurgeEntity(context.Context, *PurgeEntityRequest) (*PurgeEntityResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method PurgeEntity not implemented")
+}
+func (UnimplementedEntityServiceServer) PurgeDeletedBefore(context.Context, *PurgeDeletedBeforeRequest) (*PurgeDeletedBeforeResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method PurgeDeletedBefore not implemented")
+}
+func (UnimplementedEntityServiceServer) SearchEntities(context.Context, *SearchEntitiesRequest) (*SearchEntitiesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SearchEntities not implemented")
+}
 func (UnimplementedEntityServiceServer) ListRelationships(context.Context, *ListRelationshipsRequest) (*ListRelationshipsResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method ListRelationships not implemented")
 }
@@ -251,6 +398,15 @@ func (UnimplementedEntityServiceServer) GetRelationship(context.Context, *GetRel
 func (UnimplementedEntityServiceServer) TraverseGraph(context.Context, *TraverseGraphRequest) (*TraverseGraphResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method TraverseGraph not implemented")
 }
+func (UnimplementedEntityServiceServer) GetEntityHistory(context.Context, *GetEntityHistoryRequest) (*GetEntityHistoryResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetEntityHistory not implemented")
+}
+func (UnimplementedEntityServiceServer) GetEntityAt(context.Context, *GetEntityAtRequest) (*EntityItem, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetEntityAt not implemented")
+}
+func (UnimplementedEntityServiceServer) BatchWrite(context.Context, *BatchWriteRequest) (*BatchWriteResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method BatchWrite not implemented")
+}
 func (UnimplementedEntityServiceServer) mustEmbedUnimplementedEntityServiceServer() {}
 func (UnimplementedEntityServiceServer) testEmbeddedByValue()                       {}
 
@@ -362,6 +518,96 @@ func _EntityService_DeleteEntity_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EntityService_ListDeletedEntities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEntitiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EntityServiceServer).ListDeletedEntities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EntityService_ListDeletedEntities_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EntityServiceServer).ListDeletedEntities(ctx, req.(*ListEntitiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EntityService_RestoreEntity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreEntityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EntityServiceServer).RestoreEntity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EntityService_RestoreEntity_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EntityServiceServer).RestoreEntity(ctx, req.(*RestoreEntityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EntityService_PurgeEntity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurgeEntityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EntityServiceServer).PurgeEntity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EntityService_PurgeEntity_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EntityServiceServer).PurgeEntity(ctx, req.(*PurgeEntityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EntityService_PurgeDeletedBefore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurgeDeletedBeforeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EntityServiceServer).PurgeDeletedBefore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EntityService_PurgeDeletedBefore_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EntityServiceServer).PurgeDeletedBefore(ctx, req.(*PurgeDeletedBeforeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EntityService_SearchEntities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchEntitiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EntityServiceServer).SearchEntities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EntityService_SearchEntities_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EntityServiceServer).SearchEntities(ctx, req.(*SearchEntitiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _EntityService_ListRelationships_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ListRelationshipsRequest)
 	if err := dec(in); err != nil {
@@ -452,6 +698,60 @@ func _EntityService_TraverseGraph_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EntityService_GetEntityHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEntityHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EntityServiceServer).GetEntityHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EntityService_GetEntityHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EntityServiceServer).GetEntityHistory(ctx, req.(*GetEntityHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EntityService_GetEntityAt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEntityAtRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EntityServiceServer).GetEntityAt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EntityService_GetEntityAt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EntityServiceServer).GetEntityAt(ctx, req.(*GetEntityAtRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EntityService_BatchWrite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchWriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EntityServiceServer).BatchWrite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EntityService_BatchWrite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EntityServiceServer).BatchWrite(ctx, req.(*BatchWriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // EntityService_ServiceDesc is the grpc.ServiceDesc for EntityService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -479,6 +779,26 @@ var EntityService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteEntity",
 			Handler:    _EntityService_DeleteEntity_Handler,
 		},
+		{
+			MethodName: "ListDeletedEntities",
+			Handler:    _EntityService_ListDeletedEntities_Handler,
+		},
+		{
+			MethodName: "RestoreEntity",
+			Handler:    _EntityService_RestoreEntity_Handler,
+		},
+		{
+			MethodName: "PurgeEntity",
+			Handler:    _EntityService_PurgeEntity_Handler,
+		},
+		{
+			MethodName: "PurgeDeletedBefore",
+			Handler:    _EntityService_PurgeDeletedBefore_Handler,
+		},
+		{
+			MethodName: "SearchEntities",
+			Handler:    _EntityService_SearchEntities_Handler,
+		},
 		{
 			MethodName: "ListRelationships",
 			Handler:    _EntityService_ListRelationships_Handler,
@@ -499,6 +819,18 @@ var EntityService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "TraverseGraph",
 			Handler:    _EntityService_TraverseGraph_Handler,
 		},
+		{
+			MethodName: "GetEntityHistory",
+			Handler:    _EntityService_GetEntityHistory_Handler,
+		},
+		{
+			MethodName: "GetEntityAt",
+			Handler:    _EntityService_GetEntityAt_Handler,
+		},
+		{
+			MethodName: "BatchWrite",
+			Handler:    _EntityService_BatchWrite_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "entitygraph/v1/entitygraph.proto",
@@ -12,10 +12,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"maps"
 	"net/http"
 	"sort"
+	"sync"
 	"time"
 
+	"github.com/aosanya/CodeValdSharedLib/envelope"
 	crossv1 "github.com/aosanya/CodeValdSharedLib/gen/go/codevaldcross/v1"
 	"github.com/aosanya/CodeValdSharedLib/types"
 	"google.golang.org/grpc"
@@ -54,8 +57,10 @@ type Registrar interface {
 	// RegisterTopicSchemas sends a PATCH to CodeValdCross to store payload-schema
 	// descriptions for the service's consumed topics. Called once after the initial
 	// Register ping so the LLM action catalogue includes field-level guidance.
-	// schemas maps topic name → human-readable payload description.
-	// No-op when CrossHTTPAddr has not been set.
+	// schemas maps topic name → human-readable payload description or JSON
+	// Schema document (see entitygraph.TopicSchemas and topicschema.Set.Strings).
+	// Every later heartbeat also carries schemas in RegisterRequest.topic_schemas.
+	// The PATCH is skipped when CrossHTTPAddr has not been set.
 	RegisterTopicSchemas(ctx context.Context, agencyID string, schemas map[string]string) error
 
 	// CreateOrgRole asks Cross to create a role in CodeValdOrg for the given
@@ -75,6 +80,8 @@ type registrar struct {
 	producesHash  string // SHA-256(sorted produces joined by "\n"), computed once at New()
 	consumes      []string
 	routes        []*crossv1.RouteDeclaration // converted from []types.RouteInfo at construction
+	mu            sync.Mutex                  // guards topicSchemas
+	topicSchemas  map[string]string           // set by RegisterTopicSchemas; sent with each heartbeat
 	pingInterval  time.Duration
 	pingTimeout   time.Duration
 	conn          *grpc.ClientConn
@@ -255,12 +262,28 @@ func (r *registrar) Publish(ctx context.Context, agencyID, topic, source, payloa
 	return err
 }
 
+// PublishEnvelope publishes env through reg.Publish in CloudEvents
+// structured mode: the agency, topic, and source come from env.AgencyID,
+// env.Type, and env.Source, and the payload is the JSON-encoded envelope,
+// which eventreceiver unwraps on the consuming side. Returns an error
+// wrapping envelope.ErrInvalidEnvelope when env cannot be encoded.
+func PublishEnvelope(ctx context.Context, reg Registrar, env envelope.Envelope) error {
+	raw, err := env.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("registrar: encode envelope %s: %w", env.ID, err)
+	}
+	return reg.Publish(ctx, env.AgencyID, env.Type, env.Source, string(raw))
+}
+
 // ping sends a single Register RPC to CodeValdCross. Errors are logged; the
 // caller is not blocked beyond the configured timeout.
 func (r *registrar) ping(ctx context.Context) {
 	callCtx, cancel := context.WithTimeout(ctx, r.pingTimeout)
 	defer cancel()
 
+	r.mu.Lock()
+	topicSchemas := r.topicSchemas
+	r.mu.Unlock()
 	_, err := r.client.Register(callCtx, &crossv1.RegisterRequest{
 		ServiceName:  r.serviceName,
 		Addr:         r.listenAddr,
@@ -269,6 +292,7 @@ func (r *registrar) ping(ctx context.Context) {
 		ProducesHash: r.producesHash,
 		Consumes:     r.consumes,
 		Routes:       r.routes,
+		TopicSchemas: topicSchemas,
 	})
 	if err != nil {
 		log.Printf("registrar[%s]: Register to CodeValdCross %s: %v", r.serviceName, r.crossAddr, err)
@@ -283,9 +307,12 @@ func (r *registrar) SetCrossHTTPAddr(addr string) {
 }
 
 // RegisterTopicSchemas PATCHes the topic-schema map to CodeValdCross so the
-// LLM action catalogue includes payload field descriptions. No-op when
-// crossHTTPAddr is empty.
+// LLM action catalogue includes payload field descriptions, and keeps it for
+// the following heartbeats. The PATCH is skipped when crossHTTPAddr is empty.
 func (r *registrar) RegisterTopicSchemas(ctx context.Context, agencyID string, schemas map[string]string) error {
+	r.mu.Lock()
+	r.topicSchemas = maps.Clone(schemas)
+	r.mu.Unlock()
 	if r.crossHTTPAddr == "" || len(schemas) == 0 {
 		return nil
 	}
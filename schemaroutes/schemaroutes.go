@@ -11,20 +11,49 @@
 //
 //	GET    {basePath}/{type.PathSegment}                                     → ListEntities
 //	POST   {basePath}/{type.PathSegment}                                     → CreateEntity
+//	POST   {basePath}/{type.PathSegment}/query                               → ListEntities   (paged; read)
+//	GET    {basePath}/{type.PathSegment}/search                              → SearchEntities (types with Searchable properties)
+//	GET    {basePath}/{type.PathSegment}/deleted                             → ListDeletedEntities
 //	GET    {basePath}/{type.PathSegment}/{type.EntityIDParam}                → GetEntity
 //	PUT    {basePath}/{type.PathSegment}/{type.EntityIDParam}                → UpdateEntity   (mutable types only)
 //	DELETE {basePath}/{type.PathSegment}/{type.EntityIDParam}                → DeleteEntity
+//	POST   {basePath}/{type.PathSegment}/{type.EntityIDParam}/restore        → RestoreEntity
+//	DELETE {basePath}/{type.PathSegment}/{type.EntityIDParam}/purge          → PurgeEntity
+//	GET    {basePath}/{type.PathSegment}/{type.EntityIDParam}/history        → GetEntityHistory (types with History)
+//	GET    {basePath}/{type.PathSegment}/{type.EntityIDParam}/at             → GetEntityAt      (types with History)
 //
 // For each RelationshipDefinition with a non-empty PathSegment on a
 // TypeDefinition that itself has a non-empty PathSegment and EntityIDParam:
 //
 //	GET    {basePath}/{type.PathSegment}/{type.EntityIDParam}/{rel.PathSegment}          → ListRelationships
 //	POST   {basePath}/{type.PathSegment}/{type.EntityIDParam}/{rel.PathSegment}          → CreateRelationship
+//	POST   {basePath}/{type.PathSegment}/{type.EntityIDParam}/{rel.PathSegment}/query    → ListRelationships (paged; read)
 //	DELETE {basePath}/{type.PathSegment}/{type.EntityIDParam}/{rel.PathSegment}/{relId}  → DeleteRelationship
 //
+// The POST …/query routes are reads (IsWrite false) whose JSON body carries the
+// paging fields of ListEntitiesRequest / ListRelationshipsRequest — page_size,
+// page_token, order_by, include_total — which do not fit the path bindings of
+// the GET list routes.
+//
+// The GET …/at route takes the RFC 3339 instant to read the entity at from
+// the "at" URL query parameter.
+//
+// The GET …/search route takes the query (and optional properties and limit)
+// from the URL query string. It is only generated for types that declare at
+// least one Searchable property and whose PathSegment has no intermediate
+// {param} placeholders, since SearchEntitiesRequest cannot carry the property
+// scoping those placeholders bind.
+//
 // TypeDefinitions with a non-empty PathSegment but an empty EntityIDParam only
-// receive the collection-level routes (ListEntities, CreateEntity); per-entity
-// and relationship routes are skipped.
+// receive the collection-level routes (ListEntities, CreateEntity, and the
+// paged query); per-entity, soft-delete and relationship routes are skipped.
+//
+// When at least one type receives per-entity routes, two agency-level routes
+// are appended: retention cleanup, and transactional batch writes whose JSON
+// body carries the operations of a BatchWriteRequest:
+//
+//	POST   {basePath}/purge-deleted                                          → PurgeDeletedBefore
+//	POST   {basePath}/batch                                                  → BatchWrite
 package schemaroutes
 
 import (
@@ -54,6 +83,7 @@ func RoutesFromSchema(schema types.Schema, basePath, agencyIDParam, grpcService
 	relBinding := types.PathBinding{URLParam: "relId", Field: "relationship_id"}
 
 	var routes []types.RouteInfo
+	hasEntityRoutes := false
 
 	for _, td := range schema.Types {
 		if td.PathSegment == "" {
@@ -91,6 +121,31 @@ func RoutesFromSchema(schema types.Schema, basePath, agencyIDParam, grpcService
 			ConstantBindings: typeConstant,
 		})
 
+		// QUERY entities of this type with paging and ordering in the body.
+		// A read despite the POST verb.
+		routes = append(routes, types.RouteInfo{
+			Method:           "POST",
+			Pattern:          typePath + "/query",
+			Capability:       "query_" + typeName,
+			GrpcMethod:       grpcService + "/ListEntities",
+			PathBindings:     listBindings,
+			ConstantBindings: typeConstant,
+		})
+
+		// SEARCH entities of this type by the text of their Searchable
+		// properties. Emitted before the per-entity GET so that "search" is
+		// never captured as an entity ID.
+		if hasSearchableProperty(td) && len(intermediatBindings) == 0 {
+			routes = append(routes, types.RouteInfo{
+				Method:           "GET",
+				Pattern:          typePath + "/search",
+				Capability:       "search_" + typeName,
+				GrpcMethod:       grpcService + "/SearchEntities",
+				PathBindings:     []types.PathBinding{agencyBinding},
+				ConstantBindings: typeConstant,
+			})
+		}
+
 		// CREATE a new entity of this type.
 		routes = append(routes, types.RouteInfo{
 			Method:           "POST",
@@ -108,6 +163,18 @@ func RoutesFromSchema(schema types.Schema, basePath, agencyIDParam, grpcService
 		}
 
 		entitySeg := "/{" + entityIDParam + "}"
+		hasEntityRoutes = true
+
+		// LIST soft-deleted entities of this type. Emitted before the per-entity
+		// GET so that "deleted" is never captured as an entity ID.
+		routes = append(routes, types.RouteInfo{
+			Method:           "GET",
+			Pattern:          typePath + "/deleted",
+			Capability:       "list_deleted_" + typeName,
+			GrpcMethod:       grpcService + "/ListDeletedEntities",
+			PathBindings:     listBindings,
+			ConstantBindings: typeConstant,
+		})
 
 		// GET a single entity by ID.
 		routes = append(routes, types.RouteInfo{
@@ -144,6 +211,51 @@ func RoutesFromSchema(schema types.Schema, basePath, agencyIDParam, grpcService
 			IsWrite:          true,
 		})
 
+		// RESTORE a soft-deleted entity by ID.
+		routes = append(routes, types.RouteInfo{
+			Method:           "POST",
+			Pattern:          typePath + entitySeg + "/restore",
+			Capability:       "restore_" + typeName,
+			GrpcMethod:       grpcService + "/RestoreEntity",
+			PathBindings:     []types.PathBinding{agencyBinding, entityBinding},
+			ConstantBindings: typeConstant,
+			IsWrite:          true,
+		})
+
+		// PURGE a soft-deleted entity by ID — permanent.
+		routes = append(routes, types.RouteInfo{
+			Method:           "DELETE",
+			Pattern:          typePath + entitySeg + "/purge",
+			Capability:       "purge_" + typeName,
+			GrpcMethod:       grpcService + "/PurgeEntity",
+			PathBindings:     []types.PathBinding{agencyBinding, entityBinding},
+			ConstantBindings: typeConstant,
+			IsWrite:          true,
+		})
+
+		// HISTORY of a single entity, and its state at a point in time — only
+		// for types that record history.
+		if td.History {
+			routes = append(routes,
+				types.RouteInfo{
+					Method:           "GET",
+					Pattern:          typePath + entitySeg + "/history",
+					Capability:       "history_" + typeName,
+					GrpcMethod:       grpcService + "/GetEntityHistory",
+					PathBindings:     []types.PathBinding{agencyBinding, entityBinding},
+					ConstantBindings: typeConstant,
+				},
+				types.RouteInfo{
+					Method:           "GET",
+					Pattern:          typePath + entitySeg + "/at",
+					Capability:       "get_" + typeName + "_at",
+					GrpcMethod:       grpcService + "/GetEntityAt",
+					PathBindings:     []types.PathBinding{agencyBinding, entityBinding},
+					ConstantBindings: typeConstant,
+				},
+			)
+		}
+
 		// Relationship routes for each declared edge with a PathSegment.
 		for _, rel := range td.Relationships {
 			if rel.PathSegment == "" {
@@ -175,6 +287,17 @@ func RoutesFromSchema(schema types.Schema, basePath, agencyIDParam, grpcService
 				IsWrite:          true,
 			})
 
+			// QUERY edges from the source entity with paging and ordering in
+			// the body. A read despite the POST verb.
+			routes = append(routes, types.RouteInfo{
+				Method:           "POST",
+				Pattern:          relPath + "/query",
+				Capability:       "query_" + relCap,
+				GrpcMethod:       grpcService + "/ListRelationships",
+				PathBindings:     []types.PathBinding{agencyBinding, entityBinding},
+				ConstantBindings: relNameConstant,
+			})
+
 			// DELETE an edge by relationship ID — no constant bindings needed.
 			routes = append(routes, types.RouteInfo{
 				Method:       "DELETE",
@@ -187,9 +310,43 @@ func RoutesFromSchema(schema types.Schema, basePath, agencyIDParam, grpcService
 		}
 	}
 
+	// PURGE every entity of the agency soft-deleted before the cutoff in the
+	// request body. Agency-wide, so it carries no type_id binding; nor does
+	// BATCH below.
+	if hasEntityRoutes {
+		routes = append(routes, types.RouteInfo{
+			Method:       "POST",
+			Pattern:      basePath + "/purge-deleted",
+			Capability:   "purge_deleted",
+			GrpcMethod:   grpcService + "/PurgeDeletedBefore",
+			PathBindings: []types.PathBinding{agencyBinding},
+			IsWrite:      true,
+		})
+		// BATCH — a mixed list of entity and relationship writes applied as
+		// one transaction. Each operation names its own type or relationship.
+		routes = append(routes, types.RouteInfo{
+			Method:       "POST",
+			Pattern:      basePath + "/batch",
+			Capability:   "batch_write",
+			GrpcMethod:   grpcService + "/BatchWrite",
+			PathBindings: []types.PathBinding{agencyBinding},
+			IsWrite:      true,
+		})
+	}
+
 	return routes
 }
 
+// hasSearchableProperty reports whether td declares a Searchable property.
+func hasSearchableProperty(td types.TypeDefinition) bool {
+	for _, pd := range td.Properties {
+		if pd.Searchable {
+			return true
+		}
+	}
+	return false
+}
+
 // toSnake converts a PascalCase or camelCase string to snake_case.
 //
 //	"WorkItem"                → "work_item"
@@ -105,9 +105,9 @@ func TestRoutesFromSchema_TypeWithNoEntityIDParam_OnlyCollectionRoutes(t *testin
 	svc := "/svc.v1.EntityService"
 	routes := schemaroutes.RoutesFromSchema(schema, basePath, "agencyId", svc)
 
-	// Must have exactly 2 routes: GET /tags and POST /tags.
-	if len(routes) != 2 {
-		t.Fatalf("got %d routes, want 2 (list + create); routes: %v", len(routes), routePatterns(routes))
+	// Must have exactly 3 routes: GET /tags, POST /tags and POST /tags/query.
+	if len(routes) != 3 {
+		t.Fatalf("got %d routes, want 3 (list + create + query); routes: %v", len(routes), routePatterns(routes))
 	}
 
 	list := findRoute(routes, "GET", basePath+"/tags")
@@ -144,21 +144,28 @@ func TestRoutesFromSchema_MutableType_ProducesFiveCRUDRoutes(t *testing.T) {
 	svc := "/svc.v1.EntityService"
 	routes := schemaroutes.RoutesFromSchema(schema, basePath, "agencyId", svc)
 
-	// Expect: GET /goals, POST /goals, GET /goals/{goalId}, PUT /goals/{goalId}, DELETE /goals/{goalId}
+	// Expect the five CRUD routes, the paged query route, the three
+	// soft-delete routes, and the agency-level purge-deleted and batch routes.
 	expected := []struct{ method, pattern string }{
 		{"GET", basePath + "/goals"},
 		{"POST", basePath + "/goals"},
 		{"GET", basePath + "/goals/{goalId}"},
 		{"PUT", basePath + "/goals/{goalId}"},
 		{"DELETE", basePath + "/goals/{goalId}"},
+		{"POST", basePath + "/goals/query"},
+		{"GET", basePath + "/goals/deleted"},
+		{"POST", basePath + "/goals/{goalId}/restore"},
+		{"DELETE", basePath + "/goals/{goalId}/purge"},
+		{"POST", basePath + "/purge-deleted"},
+		{"POST", basePath + "/batch"},
 	}
 	for _, e := range expected {
 		if r := findRoute(routes, e.method, e.pattern); r == nil {
 			t.Errorf("missing route %s %s", e.method, e.pattern)
 		}
 	}
-	if len(routes) != 5 {
-		t.Errorf("got %d routes for mutable type, want 5; patterns: %v", len(routes), routePatterns(routes))
+	if len(routes) != 11 {
+		t.Errorf("got %d routes for mutable type, want 11; patterns: %v", len(routes), routePatterns(routes))
 	}
 }
 
@@ -195,8 +202,8 @@ func TestRoutesFromSchema_ImmutableType_NoPUTRoute(t *testing.T) {
 			t.Errorf("missing route %s %s for immutable type", e.method, e.pattern)
 		}
 	}
-	if len(routes) != 4 {
-		t.Errorf("got %d routes for immutable type, want 4; patterns: %v", len(routes), routePatterns(routes))
+	if len(routes) != 10 {
+		t.Errorf("got %d routes for immutable type, want 10; patterns: %v", len(routes), routePatterns(routes))
 	}
 }
 
@@ -275,6 +282,9 @@ func TestRoutesFromSchema_EntityCRUDRoutes_HaveTypeIDConstantBinding(t *testing.
 	routes := schemaroutes.RoutesFromSchema(schema, basePath, "agencyId", svc)
 
 	for _, r := range routes {
+		if r.Pattern == basePath+"/purge-deleted" || r.Pattern == basePath+"/batch" {
+			continue // agency-wide, not bound to a type
+		}
 		if !hasConstantBinding(r, "type_id", "Workflow") {
 			t.Errorf("route %s %s missing constant binding type_id=Workflow", r.Method, r.Pattern)
 		}
@@ -491,8 +501,15 @@ func TestRoutesFromSchema_GRPCMethodMapping(t *testing.T) {
 		{"GET", basePath + "/goals/{goalId}", svc + "/GetEntity"},
 		{"PUT", basePath + "/goals/{goalId}", svc + "/UpdateEntity"},
 		{"DELETE", basePath + "/goals/{goalId}", svc + "/DeleteEntity"},
+		{"POST", basePath + "/goals/query", svc + "/ListEntities"},
+		{"GET", basePath + "/goals/deleted", svc + "/ListDeletedEntities"},
+		{"POST", basePath + "/goals/{goalId}/restore", svc + "/RestoreEntity"},
+		{"DELETE", basePath + "/goals/{goalId}/purge", svc + "/PurgeEntity"},
+		{"POST", basePath + "/purge-deleted", svc + "/PurgeDeletedBefore"},
+		{"POST", basePath + "/batch", svc + "/BatchWrite"},
 		{"GET", basePath + "/goals/{goalId}/tasks", svc + "/ListRelationships"},
 		{"POST", basePath + "/goals/{goalId}/tasks", svc + "/CreateRelationship"},
+		{"POST", basePath + "/goals/{goalId}/tasks/query", svc + "/ListRelationships"},
 		{"DELETE", basePath + "/goals/{goalId}/tasks/{relId}", svc + "/DeleteRelationship"},
 	}
 
@@ -523,9 +540,177 @@ func TestRoutesFromSchema_MultipleTypes_AllGetRoutes(t *testing.T) {
 	svc := "/svc.v1.EntityService"
 	routes := schemaroutes.RoutesFromSchema(schema, basePath, "agencyId", svc)
 
-	// 5 routes per mutable type × 2 types + 0 for Internal = 10 routes.
-	if len(routes) != 10 {
-		t.Errorf("got %d routes, want 10; patterns: %v", len(routes), routePatterns(routes))
+	// 9 routes per mutable type × 2 types + 0 for Internal + the agency-level
+	// purge-deleted and batch routes = 20 routes.
+	if len(routes) != 20 {
+		t.Errorf("got %d routes, want 20; patterns: %v", len(routes), routePatterns(routes))
+	}
+}
+
+func TestRoutesFromSchema_SoftDeleteRoutes_WriteFlagsAndBindings(t *testing.T) {
+	schema := types.Schema{
+		ID:    "soft-delete",
+		Types: []types.TypeDefinition{{Name: "Goal", PathSegment: "goals", EntityIDParam: "goalId"}},
+	}
+	basePath := "/agency/{agencyId}"
+	routes := schemaroutes.RoutesFromSchema(schema, basePath, "agencyId", "/svc.v1.EntityService")
+
+	listDeleted := findRoute(routes, "GET", basePath+"/goals/deleted")
+	if listDeleted == nil {
+		t.Fatal("missing GET /goals/deleted")
+	}
+	if listDeleted.IsWrite || !hasConstantBinding(*listDeleted, "type_id", "Goal") {
+		t.Errorf("list_deleted route: IsWrite=%v, want read-only with type_id=Goal", listDeleted.IsWrite)
+	}
+	for _, p := range []struct{ method, pattern string }{
+		{"POST", basePath + "/goals/{goalId}/restore"},
+		{"DELETE", basePath + "/goals/{goalId}/purge"},
+	} {
+		r := findRoute(routes, p.method, p.pattern)
+		if r == nil {
+			t.Fatalf("missing route %s %s", p.method, p.pattern)
+		}
+		if !r.IsWrite || !hasPathBinding(*r, "goalId", "entity_id") {
+			t.Errorf("%s %s: IsWrite=%v, want write route bound to entity_id", p.method, p.pattern, r.IsWrite)
+		}
+	}
+	purge := findRoute(routes, "POST", basePath+"/purge-deleted")
+	if purge == nil {
+		t.Fatal("missing POST /purge-deleted")
+	}
+	if !purge.IsWrite || len(purge.ConstantBindings) != 0 || !hasPathBinding(*purge, "agencyId", "agency_id") {
+		t.Errorf("purge-deleted route = %+v, want agency-scoped write with no constants", *purge)
+	}
+	batch := findRoute(routes, "POST", basePath+"/batch")
+	if batch == nil {
+		t.Fatal("missing POST /batch")
+	}
+	if !batch.IsWrite || len(batch.ConstantBindings) != 0 || !hasPathBinding(*batch, "agencyId", "agency_id") {
+		t.Errorf("batch route = %+v, want agency-scoped write with no constants", *batch)
+	}
+}
+
+func TestRoutesFromSchema_QueryRoutes_AreReads(t *testing.T) {
+	schema := types.Schema{
+		ID: "query",
+		Types: []types.TypeDefinition{
+			{
+				Name:          "Goal",
+				PathSegment:   "goals",
+				EntityIDParam: "goalId",
+				Relationships: []types.RelationshipDefinition{
+					{Name: "has_task", ToType: "Task", ToMany: true, PathSegment: "tasks"},
+				},
+			},
+		},
+	}
+	basePath := "/agency/{agencyId}"
+	routes := schemaroutes.RoutesFromSchema(schema, basePath, "agencyId", "/svc.v1.EntityService")
+
+	entityQuery := findRoute(routes, "POST", basePath+"/goals/query")
+	if entityQuery == nil {
+		t.Fatal("missing POST /goals/query")
+	}
+	if entityQuery.IsWrite || !hasConstantBinding(*entityQuery, "type_id", "Goal") {
+		t.Errorf("entity query route: IsWrite=%v, want read with type_id=Goal", entityQuery.IsWrite)
+	}
+	relQuery := findRoute(routes, "POST", basePath+"/goals/{goalId}/tasks/query")
+	if relQuery == nil {
+		t.Fatal("missing POST /goals/{goalId}/tasks/query")
+	}
+	if relQuery.IsWrite || !hasConstantBinding(*relQuery, "name", "has_task") || !hasPathBinding(*relQuery, "goalId", "entity_id") {
+		t.Errorf("relationship query route = %+v, want read bound to name and entity_id", *relQuery)
+	}
+}
+
+func TestRoutesFromSchema_SearchRoute_OnlyForSearchableTypes(t *testing.T) {
+	schema := types.Schema{
+		ID: "search",
+		Types: []types.TypeDefinition{
+			{
+				Name:          "WorkItem",
+				PathSegment:   "work-items",
+				EntityIDParam: "workItemId",
+				Properties: []types.PropertyDefinition{
+					{Name: "title", Type: types.PropertyTypeString, Searchable: true},
+				},
+			},
+			{Name: "Goal", PathSegment: "goals", EntityIDParam: "goalId"},
+			{
+				Name:          "DraftGoal",
+				PathSegment:   "drafts/{draftRefCode}/goals",
+				EntityIDParam: "goalId",
+				Properties: []types.PropertyDefinition{
+					{Name: "title", Type: types.PropertyTypeString, Searchable: true},
+				},
+			},
+		},
+	}
+	basePath := "/agency/{agencyId}"
+	routes := schemaroutes.RoutesFromSchema(schema, basePath, "agencyId", "/svc.v1.EntityService")
+
+	search := findRoute(routes, "GET", basePath+"/work-items/search")
+	if search == nil {
+		t.Fatal("missing GET /work-items/search")
+	}
+	if search.IsWrite || search.Capability != "search_work_item" ||
+		search.GrpcMethod != "/svc.v1.EntityService/SearchEntities" ||
+		!hasConstantBinding(*search, "type_id", "WorkItem") || !hasPathBinding(*search, "agencyId", "agency_id") {
+		t.Errorf("search route = %+v, want read SearchEntities bound to agency_id and type_id=WorkItem", *search)
+	}
+	if findRoute(routes, "GET", basePath+"/goals/search") != nil {
+		t.Error("GET /goals/search generated for a type without Searchable properties")
+	}
+	if findRoute(routes, "GET", basePath+"/drafts/{draftRefCode}/goals/search") != nil {
+		t.Error("GET search generated for a type scoped by an intermediate path param")
+	}
+
+	// The search route must precede GET /{workItemId} so "search" is not
+	// captured as an entity ID.
+	searchIdx, getIdx := -1, -1
+	for i, r := range routes {
+		switch {
+		case r.Method == "GET" && r.Pattern == basePath+"/work-items/search":
+			searchIdx = i
+		case r.Method == "GET" && r.Pattern == basePath+"/work-items/{workItemId}":
+			getIdx = i
+		}
+	}
+	if searchIdx > getIdx {
+		t.Errorf("search route at %d, after GET by ID at %d", searchIdx, getIdx)
+	}
+}
+
+func TestRoutesFromSchema_HistoryRoutes_OnlyForHistoryTypes(t *testing.T) {
+	schema := types.Schema{
+		ID: "history",
+		Types: []types.TypeDefinition{
+			{Name: "Deliverable", PathSegment: "deliverables", EntityIDParam: "deliverableId", History: true},
+			{Name: "Goal", PathSegment: "goals", EntityIDParam: "goalId"},
+		},
+	}
+	basePath := "/agency/{agencyId}"
+	svc := "/svc.v1.EntityService"
+	routes := schemaroutes.RoutesFromSchema(schema, basePath, "agencyId", svc)
+
+	for _, tc := range []struct{ pattern, capability, grpc string }{
+		{basePath + "/deliverables/{deliverableId}/history", "history_deliverable", svc + "/GetEntityHistory"},
+		{basePath + "/deliverables/{deliverableId}/at", "get_deliverable_at", svc + "/GetEntityAt"},
+	} {
+		r := findRoute(routes, "GET", tc.pattern)
+		if r == nil {
+			t.Errorf("missing GET %s", tc.pattern)
+			continue
+		}
+		if r.IsWrite || r.Capability != tc.capability || r.GrpcMethod != tc.grpc ||
+			!hasConstantBinding(*r, "type_id", "Deliverable") || !hasPathBinding(*r, "deliverableId", "entity_id") {
+			t.Errorf("GET %s = %+v, want read %s bound to entity_id and type_id=Deliverable", tc.pattern, *r, tc.grpc)
+		}
+	}
+	for _, p := range []string{"/goals/{goalId}/history", "/goals/{goalId}/at"} {
+		if findRoute(routes, "GET", basePath+p) != nil {
+			t.Errorf("GET %s generated for a type without History", p)
+		}
 	}
 }
 
@@ -0,0 +1,343 @@
+// Package topicschema describes and checks pub/sub event payloads with JSON
+// Schema.
+//
+// A [Set] maps each topic a service produces to the [Schema] its payloads
+// must satisfy. entitygraph.TopicSchemas builds the Set for the lifecycle
+// topics of a types.Schema from the same walk as types.TopicsFromSchema, so
+// the topic list, the schemas registered with CodeValdCross (see
+// [Set.Strings] and registrar.RegisterTopicSchemas), and the validation of
+// outbound (eventbus.NewValidatingPublisher) and inbound
+// (eventreceiver.DispatcherConfig.Schemas) payloads all come from one source.
+//
+// Schema implements the subset of JSON Schema (draft 2020-12) the generated
+// schemas use: type, format (date, date-time, uuid), const, enum, minimum,
+// maximum, properties, required, and items. Undeclared object properties are
+// always allowed.
+package topicschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Draft is the JSON Schema dialect of the generated schemas.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// JSON type names accepted in [Schema.Type].
+const (
+	TypeNull    = "null"
+	TypeBoolean = "boolean"
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeInteger = "integer"
+	TypeObject  = "object"
+	TypeArray   = "array"
+)
+
+// String formats checked by [Schema.Validate].
+const (
+	FormatDate     = "date"
+	FormatDateTime = "date-time"
+	FormatUUID     = "uuid"
+)
+
+// ErrInvalidPayload is returned (wrapped in a [*ValidationError]) when a
+// payload does not satisfy its topic's schema.
+var ErrInvalidPayload = errors.New("invalid payload")
+
+// Types is the JSON Schema "type" keyword: one type name, or several when a
+// value may take any of them (e.g. a nullable property). It is encoded as a
+// string when it holds one name and as an array otherwise.
+type Types []string
+
+// MarshalJSON implements json.Marshaler.
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = Types{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*t = many
+	return nil
+}
+
+// Schema is a JSON Schema document or subschema. The zero value accepts any
+// value.
+type Schema struct {
+	// Schema names the dialect; set on root schemas only.
+	Schema string `json:"$schema,omitempty"`
+
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// Type lists the JSON types the value may have; empty allows any.
+	Type Types `json:"type,omitempty"`
+
+	// Format constrains string values: FormatDate, FormatDateTime, or
+	// FormatUUID. Other formats are not checked.
+	Format string `json:"format,omitempty"`
+
+	// Const, when non-nil, is the only value allowed.
+	Const any `json:"const,omitempty"`
+
+	// Enum, when non-empty, lists the values allowed.
+	Enum []any `json:"enum,omitempty"`
+
+	// Minimum and Maximum bound numeric values, inclusive.
+	Minimum *float64 `json:"minimum,omitempty"`
+	Maximum *float64 `json:"maximum,omitempty"`
+
+	// Properties constrains the object members it names; others are
+	// allowed unchecked.
+	Properties map[string]*Schema `json:"properties,omitempty"`
+
+	// Required lists the members an object must have.
+	Required []string `json:"required,omitempty"`
+
+	// Items constrains every element of an array.
+	Items *Schema `json:"items,omitempty"`
+}
+
+// Violation describes one part of a payload that failed validation.
+type Violation struct {
+	// Path locates the value, e.g. "$.entity.properties.tags[1]".
+	Path string
+
+	// Reason is a human-readable description of the failure (e.g.
+	// "must be an integer").
+	Reason string
+}
+
+// ValidationError is the structured error returned by validation. It lists
+// every violation found, so callers can report them all at once.
+//
+// errors.Is(err, ErrInvalidPayload) reports true for any *ValidationError.
+type ValidationError struct {
+	// Topic is the topic the payload was validated for; empty when a Schema
+	// was used directly.
+	Topic string
+
+	// Violations lists every failure; never empty.
+	Violations []Violation
+}
+
+// Error implements error.
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		parts[i] = v.Path + ": " + v.Reason
+	}
+	if e.Topic == "" {
+		return fmt.Sprintf("%s: %s", ErrInvalidPayload, strings.Join(parts, "; "))
+	}
+	return fmt.Sprintf("%s for topic %q: %s", ErrInvalidPayload, e.Topic, strings.Join(parts, "; "))
+}
+
+// Unwrap returns [ErrInvalidPayload].
+func (e *ValidationError) Unwrap() error { return ErrInvalidPayload }
+
+// Validate checks payload against s. payload may be any value that encodes
+// to JSON — it is compared in its JSON form, so a struct is checked by its
+// json tags. Returns a [*ValidationError], an encoding error, or nil.
+func (s *Schema) Validate(payload any) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("topicschema: encode payload: %w", err)
+	}
+	return s.ValidateJSON(raw)
+}
+
+// ValidateJSON checks the JSON document data against s. Empty data is
+// checked as null.
+func (s *Schema) ValidateJSON(data []byte) error {
+	var v any
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &v); err != nil {
+			return &ValidationError{Violations: []Violation{{Path: "$", Reason: "is not valid JSON: " + err.Error()}}}
+		}
+	}
+	var violations []Violation
+	s.check("$", v, &violations)
+	if len(violations) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: violations}
+}
+
+// check appends the violations of the decoded JSON value v, found at path,
+// to out.
+func (s *Schema) check(path string, v any, out *[]Violation) {
+	if s == nil {
+		return
+	}
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(t string) bool { return hasType(v, t) }) {
+		*out = append(*out, Violation{Path: path, Reason: "must be of type " + strings.Join(s.Type, " or ")})
+		return
+	}
+	if s.Const != nil && !equalJSON(v, s.Const) {
+		*out = append(*out, Violation{Path: path, Reason: fmt.Sprintf("must be %v", s.Const)})
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return equalJSON(v, e) }) {
+		*out = append(*out, Violation{Path: path, Reason: fmt.Sprintf("must be one of %v", s.Enum)})
+	}
+	switch val := v.(type) {
+	case float64:
+		if s.Minimum != nil && val < *s.Minimum {
+			*out = append(*out, Violation{Path: path, Reason: fmt.Sprintf("must be at least %v", *s.Minimum)})
+		}
+		if s.Maximum != nil && val > *s.Maximum {
+			*out = append(*out, Violation{Path: path, Reason: fmt.Sprintf("must be at most %v", *s.Maximum)})
+		}
+	case string:
+		if reason := checkFormat(s.Format, val); reason != "" {
+			*out = append(*out, Violation{Path: path, Reason: reason})
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				*out = append(*out, Violation{Path: path + "." + name, Reason: "is required"})
+			}
+		}
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if member, ok := val[name]; ok {
+				s.Properties[name].check(path+"."+name, member, out)
+			}
+		}
+	case []any:
+		for i, elem := range val {
+			s.Items.check(fmt.Sprintf("%s[%d]", path, i), elem, out)
+		}
+	}
+}
+
+// hasType reports whether the decoded JSON value v is of the JSON type t.
+func hasType(v any, t string) bool {
+	switch t {
+	case TypeNull:
+		return v == nil
+	case TypeBoolean:
+		_, ok := v.(bool)
+		return ok
+	case TypeString:
+		_, ok := v.(string)
+		return ok
+	case TypeNumber:
+		_, ok := v.(float64)
+		return ok
+	case TypeInteger:
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f) && !math.IsInf(f, 0)
+	case TypeObject:
+		_, ok := v.(map[string]any)
+		return ok
+	case TypeArray:
+		_, ok := v.([]any)
+		return ok
+	}
+	return false
+}
+
+// checkFormat returns why s does not match format, or "".
+func checkFormat(format, s string) string {
+	switch format {
+	case FormatDate:
+		if _, err := time.Parse(time.DateOnly, s); err != nil {
+			return `must be a date in "2006-01-02" form`
+		}
+	case FormatDateTime:
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			return "must be an RFC 3339 date-time"
+		}
+	case FormatUUID:
+		if _, err := uuid.Parse(s); err != nil {
+			return "must be a UUID"
+		}
+	}
+	return ""
+}
+
+// equalJSON reports whether the decoded JSON value v equals want once want
+// is brought to its JSON form.
+func equalJSON(v, want any) bool {
+	raw, err := json.Marshal(want)
+	if err != nil {
+		return false
+	}
+	var w any
+	if err := json.Unmarshal(raw, &w); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(v, w)
+}
+
+// Set maps topics to the schemas of their payloads.
+type Set map[string]*Schema
+
+// Validate checks payload against the schema of topic, as [Schema.Validate]
+// does. A topic without a schema is not checked.
+func (s Set) Validate(topic string, payload any) error {
+	sch, ok := s[topic]
+	if !ok {
+		return nil
+	}
+	return withTopic(topic, sch.Validate(payload))
+}
+
+// ValidateJSON checks the JSON text data against the schema of topic, as
+// [Schema.ValidateJSON] does. A topic without a schema is not checked.
+func (s Set) ValidateJSON(topic, data string) error {
+	sch, ok := s[topic]
+	if !ok {
+		return nil
+	}
+	return withTopic(topic, sch.ValidateJSON([]byte(data)))
+}
+
+// withTopic sets topic on err when it is a *ValidationError.
+func withTopic(topic string, err error) error {
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		verr.Topic = topic
+	}
+	return err
+}
+
+// Strings encodes every schema as JSON text, the form carried by
+// types.ServiceRegistration.TopicSchemas and registrar.RegisterTopicSchemas.
+func (s Set) Strings() (map[string]string, error) {
+	out := make(map[string]string, len(s))
+	for topic, sch := range s {
+		raw, err := json.Marshal(sch)
+		if err != nil {
+			return nil, fmt.Errorf("topicschema: encode schema for %s: %w", topic, err)
+		}
+		out[topic] = string(raw)
+	}
+	return out, nil
+}
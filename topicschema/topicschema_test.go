@@ -0,0 +1,95 @@
+package topicschema_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/aosanya/CodeValdSharedLib/topicschema"
+)
+
+func ptr(f float64) *float64 { return &f }
+
+func taskSchema() *topicschema.Schema {
+	return &topicschema.Schema{
+		Type:     topicschema.Types{topicschema.TypeObject},
+		Required: []string{"id", "status"},
+		Properties: map[string]*topicschema.Schema{
+			"id":     {Type: topicschema.Types{topicschema.TypeString}, Format: topicschema.FormatUUID},
+			"status": {Type: topicschema.Types{topicschema.TypeString}, Enum: []any{"open", "done"}},
+			"rating": {Type: topicschema.Types{topicschema.TypeInteger, topicschema.TypeNull}, Minimum: ptr(1), Maximum: ptr(5)},
+			"due":    {Type: topicschema.Types{topicschema.TypeString}, Format: topicschema.FormatDate},
+			"tags":   {Type: topicschema.Types{topicschema.TypeArray}, Items: &topicschema.Schema{Type: topicschema.Types{topicschema.TypeString}}},
+			"kind":   {Const: "task"},
+		},
+	}
+}
+
+func TestSchema_Validate_Accepts(t *testing.T) {
+	payload := map[string]any{
+		"id":     "550e8400-e29b-41d4-a716-446655440000",
+		"status": "open",
+		"rating": nil,
+		"due":    "2026-01-15",
+		"tags":   []string{"a", "b"},
+		"kind":   "task",
+		"extra":  42,
+	}
+	if err := taskSchema().Validate(payload); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+func TestSchema_Validate_ReportsEveryViolation(t *testing.T) {
+	payload := map[string]any{
+		"id":     "not-a-uuid",
+		"rating": 2.5,
+		"due":    "15/01/2026",
+		"tags":   []any{"a", 1},
+		"kind":   "bug",
+	}
+	err := taskSchema().Validate(payload)
+	var verr *topicschema.ValidationError
+	if !errors.As(err, &verr) || !errors.Is(err, topicschema.ErrInvalidPayload) {
+		t.Fatalf("Validate: got %v, want a *ValidationError", err)
+	}
+	want := map[string]bool{"$.status": true, "$.id": true, "$.rating": true, "$.due": true, "$.tags[1]": true, "$.kind": true}
+	if len(verr.Violations) != len(want) {
+		t.Errorf("violations = %+v, want one for each of %v", verr.Violations, want)
+	}
+	for _, v := range verr.Violations {
+		if !want[v.Path] {
+			t.Errorf("unexpected violation %+v", v)
+		}
+	}
+	if err := (&topicschema.Schema{Type: topicschema.Types{topicschema.TypeInteger}, Maximum: ptr(5)}).Validate(6); err == nil {
+		t.Error("Validate(6) against maximum 5 succeeded")
+	}
+}
+
+func TestSet_ValidateJSONAndStrings(t *testing.T) {
+	set := topicschema.Set{"work.task.created": taskSchema()}
+	if err := set.ValidateJSON("work.task.created", `{"id":"x"`); !errors.Is(err, topicschema.ErrInvalidPayload) {
+		t.Errorf("ValidateJSON of malformed JSON: got %v, want ErrInvalidPayload", err)
+	}
+	err := set.ValidateJSON("work.task.created", `{}`)
+	var verr *topicschema.ValidationError
+	if !errors.As(err, &verr) || verr.Topic != "work.task.created" {
+		t.Errorf("ValidateJSON: got %v, want a ValidationError naming the topic", err)
+	}
+	if err := set.Validate("other.topic", 42); err != nil {
+		t.Errorf("Validate of a topic without a schema: %v", err)
+	}
+
+	strs, err := set.Strings()
+	if err != nil {
+		t.Fatalf("Strings: %v", err)
+	}
+	var back topicschema.Schema
+	if err := json.Unmarshal([]byte(strs["work.task.created"]), &back); err != nil {
+		t.Fatalf("decode schema: %v", err)
+	}
+	if len(back.Properties["rating"].Type) != 2 || back.Type[0] != topicschema.TypeObject {
+		t.Errorf("decoded schema = %+v, want the type keywords preserved", back)
+	}
+}
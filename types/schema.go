@@ -113,8 +113,41 @@ type PropertyDefinition struct {
 	// Nested arrays are not supported — ElementType itself may not be
 	// [PropertyTypeArray].
 	ElementType PropertyType
+
+	// Searchable includes this property in full-text search
+	// (DataManager.SearchEntities). Only string-valued properties and arrays
+	// of strings may be searchable. Backends with a search index (the
+	// ArangoDB backend's per-type ArangoSearch view) index exactly the
+	// searchable properties of each type.
+	Searchable bool
+
+	// Indexed asks backends with secondary indexes to index this property,
+	// scoped by agency and type, so that equality filters and OrderBy on it
+	// avoid a collection scan. The ArangoDB backend creates a persistent
+	// index on [agency_id, type_id, properties.<Name>]; the in-memory backend
+	// ignores it.
+	Indexed bool
 }
 
+// DeletePolicy controls what [DataManager.DeleteEntity] does with the
+// outbound edges of a [RelationshipDefinition] when their source entity is
+// deleted. The zero value behaves as [DeletePolicyDetach].
+type DeletePolicy string
+
+const (
+	// DeletePolicyDetach removes the edges (and their inverse edges); the
+	// target entities are left untouched.
+	DeletePolicyDetach DeletePolicy = "detach"
+
+	// DeletePolicyCascade removes the edges and deletes every target entity as
+	// well, applying the target type's own policies recursively.
+	DeletePolicyCascade DeletePolicy = "cascade"
+
+	// DeletePolicyRestrict rejects the delete while any edge of the
+	// relationship exists from the entity.
+	DeletePolicyRestrict DeletePolicy = "restrict"
+)
+
 // RelationshipDefinition declares a legal directed edge from the owning
 // [TypeDefinition] to another type within the same [Schema].
 //
@@ -126,7 +159,8 @@ type PropertyDefinition struct {
 // Validation rules applied by [DataManager.CreateRelationship]:
 //   - The edge label must match a RelationshipDefinition.Name on the source entity's TypeDefinition.
 //   - The target entity's TypeID must equal ToType.
-//   - If ToMany is false, a second edge with the same label from the same source returns ErrRelationshipCardinalityViolation.
+//   - If ToMany is false, a second edge with the same label from the same source replaces the first.
+//   - If Inverse is set, the reciprocal edge is created and deleted alongside it.
 type RelationshipDefinition struct {
 	// Name is the edge label stored in the ArangoDB edge collection
 	// (e.g. "has_goal", "has_work_item"). Must be unique within the
@@ -151,10 +185,17 @@ type RelationshipDefinition struct {
 
 	// Inverse is the optional name of the reciprocal relationship label on the
 	// ToType (e.g. "belongs_to_agency"). If set, DataManager implementations
-	// may use this to auto-create the inverse edge; behaviour is
-	// implementation-defined and not enforced by the schema layer.
+	// write the inverse edge (ToID → Inverse → FromID) in the same transaction
+	// as every edge of this relationship, and delete it with that edge. The
+	// inverse follows the cardinality of its own RelationshipDefinition.
 	Inverse string
 
+	// OnDelete is the policy DeleteEntity applies to edges of this
+	// relationship when the owning (source) entity is deleted. Empty means
+	// [DeletePolicyDetach]. Edges pointing at a deleted entity from other
+	// entities are always detached.
+	OnDelete DeletePolicy
+
 	// PathSegment is the URL sub-resource segment used in schema-driven HTTP
 	// route generation (e.g. "workflows" produces
 	// /v{ver}/{agencyID}/{typeSeg}/{id}/workflows).
@@ -233,6 +274,10 @@ type TypeDefinition struct {
 	// All names must reference a PropertyDefinition.Name declared in Properties.
 	// An empty or nil slice means no unique key is defined — UpsertEntity returns
 	// ErrUniqueKeyNotDefined for this type.
+	// Every write also enforces the key: no two live entities of the type in
+	// one agency may hold the same values, and a write that would create such
+	// a duplicate returns ErrEntityAlreadyExists. Entities missing any key
+	// value, and soft-deleted entities, are exempt (see entitygraph.UniqueKeyOf).
 	UniqueKey []string
 
 	// Code is the human-readable, user-facing label for this TypeDefinition
@@ -255,10 +300,20 @@ type TypeDefinition struct {
 
 	// PublishEvents controls whether this type contributes lifecycle topics to
 	// the service's pub/sub produces list. When true, [TopicsFromSchema] emits
-	// standard events for this type (created, updated, deleted, status.changed).
+	// standard events for this type (created, updated, update.{field},
+	// deleted), and entitygraph.NewPublishingDataManager publishes exactly
+	// those topics as the type's entities are written.
 	// Independent of PathSegment — internal types with no HTTP routes may still
 	// publish events.
 	PublishEvents bool
+
+	// History opts this type into change history: every create, update,
+	// delete and restore of one of its entities is recorded as an immutable
+	// revision, readable through DataManager.GetEntityHistory and
+	// DataManager.GetEntityAt, and schemaroutes.RoutesFromSchema emits the
+	// …/history and …/at routes for it. Off by default, since every write then
+	// also stores a full copy of the entity.
+	History bool
 }
 
 // Schema is a versioned, immutable collection of [TypeDefinition]s for one
@@ -2,6 +2,69 @@ package types
 
 import "strings"
 
+// Lifecycle event names — the final segment of the topics [TopicsFromSchema]
+// derives for a type. Property updates use [PropertyUpdateTopic] instead.
+const (
+	TopicEventCreated = "created"
+	TopicEventUpdated = "updated"
+	TopicEventDeleted = "deleted"
+)
+
+// EntityTopic returns the lifecycle topic {prefix}.{type}.{event} for the
+// type named typeName, e.g. EntityTopic("work", "Task", TopicEventCreated) is
+// "work.task.created". Publishers build their topic names with it so that
+// they always match what TopicsFromSchema declares.
+func EntityTopic(servicePrefix, typeName, event string) string {
+	return servicePrefix + "." + strings.ToLower(typeName) + "." + event
+}
+
+// PropertyUpdateTopic returns the per-property topic
+// {prefix}.{type}.update.{property}, e.g. "work.task.update.status".
+func PropertyUpdateTopic(servicePrefix, typeName, property string) string {
+	return EntityTopic(servicePrefix, typeName, "update."+property)
+}
+
+// LifecycleTopic describes one topic derived from a schema by
+// [LifecycleTopics].
+type LifecycleTopic struct {
+	// Topic is the full topic name, e.g. "work.task.update.status".
+	Topic string
+
+	// Type is the TypeDefinition the topic belongs to.
+	Type TypeDefinition
+
+	// Event is TopicEventCreated, TopicEventUpdated or TopicEventDeleted.
+	// Per-property update topics carry TopicEventUpdated with Property set.
+	Event string
+
+	// Property is the property a per-property update topic reports; nil for
+	// the other topics.
+	Property *PropertyDefinition
+}
+
+// LifecycleTopics returns the topics [TopicsFromSchema] lists, in the same
+// order, together with the type, event, and property each one reports. It is
+// the single walk over the schema that both the topic list and the per-topic
+// payload schemas are built from.
+func LifecycleTopics(servicePrefix string, schema Schema) []LifecycleTopic {
+	var topics []LifecycleTopic
+	for _, td := range schema.Types {
+		if !td.PublishEvents {
+			continue
+		}
+		topics = append(topics, LifecycleTopic{Topic: EntityTopic(servicePrefix, td.Name, TopicEventCreated), Type: td, Event: TopicEventCreated})
+		if !td.Immutable {
+			topics = append(topics, LifecycleTopic{Topic: EntityTopic(servicePrefix, td.Name, TopicEventUpdated), Type: td, Event: TopicEventUpdated})
+			for i := range td.Properties {
+				p := &td.Properties[i]
+				topics = append(topics, LifecycleTopic{Topic: PropertyUpdateTopic(servicePrefix, td.Name, p.Name), Type: td, Event: TopicEventUpdated, Property: p})
+			}
+		}
+		topics = append(topics, LifecycleTopic{Topic: EntityTopic(servicePrefix, td.Name, TopicEventDeleted), Type: td, Event: TopicEventDeleted})
+	}
+	return topics
+}
+
 // TopicsFromSchema derives the standard pub/sub topic list for a service from
 // its schema. For each [TypeDefinition] with [TypeDefinition.PublishEvents] set
 // to true it emits:
@@ -19,19 +82,8 @@ import "strings"
 // list stays in sync with the schema without manual maintenance.
 func TopicsFromSchema(servicePrefix string, schema Schema) []string {
 	var topics []string
-	for _, td := range schema.Types {
-		if !td.PublishEvents {
-			continue
-		}
-		base := servicePrefix + "." + strings.ToLower(td.Name) + "."
-		topics = append(topics, base+"created")
-		if !td.Immutable {
-			topics = append(topics, base+"updated")
-			for _, p := range td.Properties {
-				topics = append(topics, base+"update."+p.Name)
-			}
-		}
-		topics = append(topics, base+"deleted")
+	for _, t := range LifecycleTopics(servicePrefix, schema) {
+		topics = append(topics, t.Topic)
 	}
 	return topics
 }
@@ -86,6 +86,8 @@ type ServiceRegistration struct {
 	// its expected payload fields. Injected by CodeValdAI into the LLM system
 	// prompt so agents know exactly what to include when emitting an action.
 	// Example: {"git.branch.create": "{repository: string, name: string, from_branch?: string}"}
+	// A value may also be a JSON Schema document, as generated for produced
+	// lifecycle topics by entitygraph.TopicSchemas.
 	TopicSchemas map[string]string `json:"topic_schemas,omitempty"`
 	// LastPing is the UTC timestamp of the most recent Register or Ping call
 	// received from this service.
@@ -217,3 +217,31 @@ func TestPropertyDefinition_ZeroValueOptionsAndElementType(t *testing.T) {
 		t.Errorf("ElementType: got %q, want empty zero value", p.ElementType)
 	}
 }
+
+func TestTopicsFromSchema_UsesTopicHelpers(t *testing.T) {
+	schema := types.Schema{Types: []types.TypeDefinition{
+		{Name: "Task", PublishEvents: true, Properties: []types.PropertyDefinition{{Name: "status"}}},
+		{Name: "AuditLog", PublishEvents: true, Immutable: true, Properties: []types.PropertyDefinition{{Name: "line"}}},
+		{Name: "Draft"},
+	}}
+	got := types.TopicsFromSchema("work", schema)
+	want := []string{
+		types.EntityTopic("work", "Task", types.TopicEventCreated),
+		types.EntityTopic("work", "Task", types.TopicEventUpdated),
+		types.PropertyUpdateTopic("work", "Task", "status"),
+		types.EntityTopic("work", "Task", types.TopicEventDeleted),
+		"work.auditlog.created",
+		"work.auditlog.deleted",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("TopicsFromSchema = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("topic %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+	if want[2] != "work.task.update.status" {
+		t.Errorf("PropertyUpdateTopic = %q, want work.task.update.status", want[2])
+	}
+}